@@ -12,6 +12,8 @@ import (
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/jeranaias/rigrun-tui/internal/security"
 )
 
 // =============================================================================
@@ -71,11 +73,17 @@ type Comparison struct {
 // RESULT STORAGE
 // =============================================================================
 
-// Storage handles saving and loading benchmark results.
+// Storage handles saving and loading benchmark results. Results hold model
+// responses to the test prompts, so they are encrypted with the data
+// cipher when one is set.
 type Storage struct {
-	dir string
+	dir    string
+	cipher security.FieldCipher // Seals result files; nil writes plaintext
 }
 
+// Compile-time check that Storage is a protected store.
+var _ security.ProtectedStore = (*Storage)(nil)
+
 // NewStorage creates a new storage instance.
 // By default, results are stored in ~/.rigrun/benchmarks/
 func NewStorage() (*Storage, error) {
@@ -89,7 +97,7 @@ func NewStorage() (*Storage, error) {
 		return nil, fmt.Errorf("failed to create benchmark directory: %w", err)
 	}
 
-	return &Storage{dir: benchmarkDir}, nil
+	return &Storage{dir: benchmarkDir, cipher: security.DataCipher()}, nil
 }
 
 // NewStorageWithDir creates a storage instance with a custom directory.
@@ -97,7 +105,7 @@ func NewStorageWithDir(dir string) (*Storage, error) {
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create directory: %w", err)
 	}
-	return &Storage{dir: dir}, nil
+	return &Storage{dir: dir, cipher: security.DataCipher()}, nil
 }
 
 // Save saves a benchmark result to disk.
@@ -112,7 +120,7 @@ func (s *Storage) Save(result *Result) error {
 		return fmt.Errorf("failed to marshal result: %w", err)
 	}
 
-	if err := os.WriteFile(path, data, 0644); err != nil {
+	if err := s.writeFile(path, data); err != nil {
 		return fmt.Errorf("failed to write result: %w", err)
 	}
 
@@ -130,7 +138,7 @@ func (s *Storage) SaveComparison(comparison *Comparison) error {
 		return fmt.Errorf("failed to marshal comparison: %w", err)
 	}
 
-	if err := os.WriteFile(path, data, 0644); err != nil {
+	if err := s.writeFile(path, data); err != nil {
 		return fmt.Errorf("failed to write comparison: %w", err)
 	}
 
@@ -140,7 +148,7 @@ func (s *Storage) SaveComparison(comparison *Comparison) error {
 // Load loads a benchmark result from disk.
 func (s *Storage) Load(filename string) (*Result, error) {
 	path := filepath.Join(s.dir, filename)
-	data, err := os.ReadFile(path)
+	data, err := s.readFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read result: %w", err)
 	}
@@ -156,7 +164,7 @@ func (s *Storage) Load(filename string) (*Result, error) {
 // LoadComparison loads a comparison from disk.
 func (s *Storage) LoadComparison(filename string) (*Comparison, error) {
 	path := filepath.Join(s.dir, filename)
-	data, err := os.ReadFile(path)
+	data, err := s.readFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read comparison: %w", err)
 	}
@@ -214,6 +222,82 @@ func (s *Storage) GetLatestForModel(modelName string) (*Result, error) {
 	return nil, fmt.Errorf("no results found for model: %s", modelName)
 }
 
+// writeFile writes a result file, encrypted if the storage has a cipher.
+func (s *Storage) writeFile(path string, data []byte) error {
+	sealed, err := security.SealField(s.cipher, string(data))
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(sealed), 0644)
+}
+
+// readFile reads a result file written by writeFile.
+func (s *Storage) readFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	content, err := security.OpenField(s.cipher, string(data))
+	if err != nil {
+		return nil, err
+	}
+	return []byte(content), nil
+}
+
+// EncryptionStatus counts the result files and how many are encrypted.
+func (s *Storage) EncryptionStatus() (security.StoreStatus, error) {
+	status := security.StoreStatus{Name: "Benchmark results", Path: s.dir}
+	files, err := s.List()
+	if err != nil {
+		return status, err
+	}
+	for _, file := range files {
+		sealed, err := security.IsFileSealed(filepath.Join(s.dir, file))
+		if err != nil {
+			return status, err
+		}
+		status.Items++
+		if sealed {
+			status.Encrypted++
+		}
+	}
+	return status, nil
+}
+
+// Reencrypt replaces each result file with recrypt's result.
+func (s *Storage) Reencrypt(recrypt security.Recrypt) error {
+	files, err := s.List()
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := security.ReencryptFile(filepath.Join(s.dir, file), recrypt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Compile-time check that Storage is a retained store.
+var _ security.RetainedStore = (*Storage)(nil)
+
+// RetentionClass returns the data class of benchmark results.
+func (s *Storage) RetentionClass() string {
+	return security.RetentionBenchmarks
+}
+
+// RetentionItems lists the result and comparison files.
+func (s *Storage) RetentionItems() ([]security.RetentionItem, error) {
+	return security.RetentionFiles(s.dir, func(name string) bool {
+		return filepath.Ext(name) == ".json"
+	})
+}
+
+// Purge securely deletes result and comparison files.
+func (s *Storage) Purge(items []security.RetentionItem) error {
+	return security.PurgeFiles(s.dir, items)
+}
+
 // sanitizeFilename removes characters that aren't safe for filenames.
 func sanitizeFilename(name string) string {
 	// Replace common separators with underscores
@@ -27,6 +27,10 @@
 //   -a, --agentic       Enable agentic mode (tool use)
 //   --max-iter N        Max iterations in agentic mode (default: 25)
 //   --json              Output response as JSON
+//   --output-format FMT text, json or stream-json (NDJSON events)
+//   --input-format FMT  text or stream-json (follow-up messages on stdin)
+//   --permission-policy FILE
+//                       Tool permission policy (allow/ask/deny rules) for CI
 //   --local             Force local model (alias for --paranoid)
 //   -v, --verbose       Verbose output
 //   -q, --quiet         Minimal output
@@ -53,10 +57,12 @@ import (
 	"github.com/jeranaias/rigrun-tui/internal/cloud"
 	"github.com/jeranaias/rigrun-tui/internal/config"
 	ctxmention "github.com/jeranaias/rigrun-tui/internal/context"
+	"github.com/jeranaias/rigrun-tui/internal/instructions"
 	"github.com/jeranaias/rigrun-tui/internal/offline"
 	"github.com/jeranaias/rigrun-tui/internal/ollama"
 	"github.com/jeranaias/rigrun-tui/internal/router"
 	"github.com/jeranaias/rigrun-tui/internal/security"
+	"github.com/jeranaias/rigrun-tui/internal/telemetry"
 	"github.com/jeranaias/rigrun-tui/internal/tools"
 	"github.com/jeranaias/rigrun-tui/internal/ui/styles"
 	"github.com/jeranaias/rigrun-tui/internal/util"
@@ -210,30 +216,52 @@ func readFileForContext(path string) (string, error) {
 
 // HandleAskCommand handles the "ask" command with full routing and streaming support.
 // This replaces the stub implementation in cli.go.
-// Supports JSON output for IL5 SIEM integration (AU-6, SI-4).
+// Supports JSON output for IL5 SIEM integration (AU-6, SI-4) and a headless
+// NDJSON event stream (--output-format stream-json).
 func HandleAskCommand(args Args) error {
 	// Load configuration
 	cfg := config.Global()
 
+	// Headless event stream and follow-up messages on stdin
+	stream, input, err := askStreams(args)
+	if err != nil {
+		if args.JSON {
+			NewJSONErrorResponse("ask", err).Print()
+		}
+		return err
+	}
+	stream.Emit(StreamEventInit, InitEventData{
+		Version:     Version,
+		Agentic:     args.Agentic,
+		InputFormat: args.InputFormat,
+	})
+	stream.StartTurn()
+
 	// ==========================================================================
 	// IL5 SC-7: Offline Mode Setup
 	// Block ALL network except localhost Ollama when --no-network or config set
 	// ==========================================================================
 	if args.NoNetwork || cfg.Routing.OfflineMode {
 		offline.SetOfflineMode(true)
-		if !args.Quiet && !args.JSON {
+		if !args.Quiet && !args.MachineOutput() {
 			fmt.Fprintf(os.Stderr, "%s %s\n",
 				lipgloss.NewStyle().Foreground(styles.Rose).Bold(true).Render("[OFFLINE MODE]"),
 				"IL5 SC-7: Network restricted to localhost only")
 		}
 	}
 
+	// CM-5: Enforce the administrator policy network allow-list
+	EnforcePolicyBoundary(cfg)
+
 	// Get the question from args.Query (built by parseAskArgs from positional args)
 	// NOTE: Do NOT use args.Raw here - it contains unparsed flags like "--agentic"
 	question := args.Query
 
 	// If no question from args, try reading from stdin (for piped input)
-	if question == "" {
+	if question == "" && input != nil {
+		// stream-json input: the first user message is the question
+		question, _ = input.NextUserMessage(stream)
+	} else if question == "" {
 		// Check if stdin has data (is a pipe, not a terminal)
 		stat, _ := os.Stdin.Stat()
 		if (stat.Mode() & os.ModeCharDevice) == 0 {
@@ -252,19 +280,14 @@ func HandleAskCommand(args Args) error {
 	}
 
 	if question == "" {
-		err := fmt.Errorf("no question provided. Usage: rigrun ask \"your question\"")
-		if args.JSON {
-			resp := NewJSONErrorResponse("ask", err)
-			resp.Print()
-		}
-		return err
+		return askFailed(args, stream, fmt.Errorf("no question provided. Usage: rigrun ask \"your question\""))
 	}
 
 	// If file is specified, read and append to question
 	if args.File != "" {
 		fileContent, err := readFileForContext(args.File)
 		if err != nil {
-			return fmt.Errorf("failed to read file: %w", err)
+			return askFailed(args, stream, fmt.Errorf("failed to read file: %w", err))
 		}
 		question = question + "\n" + fileContent
 
@@ -279,27 +302,7 @@ func HandleAskCommand(args Args) error {
 	// CONTEXT EXPANSION - Process @ mentions before sending to LLM
 	// Supports: @file:path, @git, @codebase, @error, @clipboard
 	// ==========================================================================
-	if ctxmention.HasMentions(question) {
-		expander := ctxmention.NewExpander(nil)
-		result := expander.Expand(question)
-
-		// Display what context was included (unless --quiet)
-		if !args.Quiet && result.Summary.TotalCount > 0 {
-			fmt.Fprintf(os.Stderr, "%s Context: %s\n",
-				lipgloss.NewStyle().Foreground(styles.Cyan).Render("[+]"),
-				result.Summary.FormatSummary())
-		}
-
-		// Report any errors fetching context
-		if result.HasErrors() && !args.Quiet {
-			fmt.Fprintf(os.Stderr, "%s Context errors: %s\n",
-				lipgloss.NewStyle().Foreground(styles.Rose).Render("[!]"),
-				result.ErrorSummary())
-		}
-
-		// Use the expanded message (with context prepended) for the LLM
-		question = result.ExpandedMessage
-	}
+	question = expandAskMentions(question, args)
 
 	// Route the query (passing config for routing decisions)
 	// In offline mode, always route to local and force paranoid mode
@@ -311,6 +314,8 @@ func HandleAskCommand(args Args) error {
 		AutoPreferLocal: cfg.Routing.AutoPreferLocal,
 		AutoMaxCost:     cfg.Routing.AutoMaxCost,
 		AutoFallback:    cfg.Routing.AutoFallback,
+		Budget:          newBudgetGuard(cfg),
+		BudgetAction:    router.BudgetActionLocal, // Non-interactive: never hold a query
 	}
 
 	// AGENTIC MODE: Force OpenRouter auto-routing for tool-use tasks when available
@@ -321,12 +326,14 @@ func HandleAskCommand(args Args) error {
 		routerOpts.AutoPreferLocal = false // Don't prefer local for agentic tasks
 	}
 
-	// Default to Unclassified for CLI (TUI uses interactive classification)
-	// Classification enforcement ensures CUI+ data stays on-premise (NIST AC-4)
-	decision := router.RouteQueryDetailed(question, security.ClassificationUnclassified, routerOpts)
+	// Route on the question's own marking (session level, explicit markings and
+	// @mentioned content). Classification enforcement ensures CUI+ data stays
+	// on-premise (NIST AC-4)
+	questionClass := classifyInput(cfg, question)
+	decision := router.RouteQueryDetailed(question, questionClass.Level, routerOpts)
 
 	// Display routing decision (unless --quiet)
-	if !args.Quiet && !args.JSON {
+	if !args.Quiet && !args.MachineOutput() {
 		displayRoutingDecision(decision)
 	}
 
@@ -335,11 +342,7 @@ func HandleAskCommand(args Args) error {
 	// Only localhost connections are allowed
 	// ==========================================================================
 	if err := offline.ValidateOllamaURL(cfg.Local.OllamaURL); err != nil {
-		if args.JSON {
-			resp := NewJSONErrorResponse("ask", err)
-			resp.Print()
-		}
-		return err
+		return askFailed(args, stream, err)
 	}
 
 	// Create Ollama client with config
@@ -352,12 +355,7 @@ func HandleAskCommand(args Args) error {
 	// Check if Ollama is running
 	ctx := context.Background()
 	if err := client.CheckRunning(ctx); err != nil {
-		err := fmt.Errorf("Ollama is not running. Start it with: ollama serve")
-		if args.JSON {
-			resp := NewJSONErrorResponse("ask", err)
-			resp.Print()
-		}
-		return err
+		return askFailed(args, stream, fmt.Errorf("Ollama is not running. Start it with: ollama serve"))
 	}
 
 	// Determine model to use (CLI arg > config > client default)
@@ -394,7 +392,8 @@ func HandleAskCommand(args Args) error {
 					cloudModel)
 			}
 
-			return runCloudAgenticLoop(ctx, cfg, cloudModel, question, args)
+			stream.Routing(decision, cloudModel)
+			return runCloudAgenticLoop(ctx, cfg, cloudModel, question, args, stream, input)
 		}
 
 		// Fallback to local Ollama for agentic mode
@@ -447,23 +446,49 @@ func HandleAskCommand(args Args) error {
 			fmt.Fprintln(os.Stderr) // Blank line
 		}
 
-		// Use specialized agentic prompt with platform awareness
+		// Use specialized agentic prompt with platform awareness and
+		// project instructions (RIGRUN.md)
 		cwd, _ := os.Getwd()
-		agenticPrompt := tools.GenerateAgenticLoopPromptWithContext(runtime.GOOS, cwd)
+		projectInstructions := instructions.NewTracker(cwd)
 		agenticMessages := []ollama.Message{
-			ollama.NewSystemMessage(agenticPrompt),
+			ollama.NewSystemMessage(agenticSystemPrompt(projectInstructions)),
 			ollama.NewUserMessage(question),
 		}
-		return runAgenticLoop(ctx, client, agenticModel, agenticMessages, args)
+		stream.Routing(decision, agenticModel)
+		return runAgenticLoop(ctx, client, agenticModel, agenticMessages, projectInstructions, args, stream, input)
 	}
 
-	// Build messages with system prompt optimized for small models
-	systemPrompt := tools.GenerateSmallModelPrompt()
+	// Build messages with system prompt optimized for small models,
+	// plus project instructions (RIGRUN.md)
+	cwd, _ := os.Getwd()
+	systemPrompt := instructions.Load(cwd).Apply(tools.GenerateSmallModelPrompt())
 	messages := []ollama.Message{
 		ollama.NewSystemMessage(systemPrompt),
 		ollama.NewUserMessage(question),
 	}
 
+	stream.Routing(decision, model)
+	for {
+		response, err := runAskTurn(ctx, client, model, messages, decision, args, stream)
+		if err != nil {
+			return err
+		}
+
+		// stream-json input: answer follow-up messages in the same conversation
+		next, ok := input.NextUserMessage(stream)
+		if !ok {
+			return nil
+		}
+		stream.StartTurn()
+		messages = append(messages,
+			ollama.NewAssistantMessage(response),
+			ollama.NewUserMessage(expandAskMentions(next, args)))
+	}
+}
+
+// runAskTurn streams one answer from the local model and prints it in the
+// selected output format. It returns the full response.
+func runAskTurn(ctx context.Context, client *ollama.Client, model string, messages []ollama.Message, decision router.RoutingDecision, args Args, stream *StreamJSONWriter) (string, error) {
 	// Track timing and tokens
 	startTime := time.Now()
 	var totalTokens int
@@ -479,16 +504,16 @@ func HandleAskCommand(args Args) error {
 
 	// Determine if we should use markdown rendering
 	// USABILITY: Render markdown on TTY for better formatting, stream plain for pipes
-	useMarkdown := IsStdoutTTY() && !args.JSON
+	useMarkdown := IsStdoutTTY() && !args.MachineOutput()
 
 	// Stream the response
-	if !args.Quiet && !args.JSON {
+	if !args.Quiet && !args.MachineOutput() {
 		fmt.Println() // Space before response
 	}
 
 	err := client.ChatStream(ctx, model, messages, func(chunk ollama.StreamChunk) {
 		if chunk.Error != nil {
-			if !args.JSON {
+			if !args.MachineOutput() {
 				fmt.Fprintf(os.Stderr, "\n%s %v\n",
 					errorStyle.Render("[Error]"),
 					chunk.Error)
@@ -501,10 +526,11 @@ func HandleAskCommand(args Args) error {
 
 		// Collect the content
 		fullResponse.WriteString(chunk.Content)
+		stream.Token(chunk.Content)
 
 		// Stream output in non-JSON mode when not using markdown
 		// When using markdown, we collect and render at the end for proper formatting
-		if !args.JSON && !useMarkdown {
+		if !args.MachineOutput() && !useMarkdown {
 			streamToStdout(chunk.Content)
 		}
 
@@ -524,24 +550,33 @@ func HandleAskCommand(args Args) error {
 	if err != nil {
 		// Store error for @error mention retrieval
 		ctxmention.StoreLastError("Streaming failed: " + err.Error())
-		if args.JSON {
-			resp := NewJSONErrorResponse("ask", err)
-			resp.Print()
-		}
-		return fmt.Errorf("streaming failed: %w", err)
+		return "", askFailed(args, stream, fmt.Errorf("streaming failed: %w", err))
 	}
 
 	if streamErr != nil {
-		if args.JSON {
-			resp := NewJSONErrorResponse("ask", streamErr)
-			resp.Print()
-		}
-		return streamErr
+		return "", askFailed(args, stream, streamErr)
 	}
 
 	// Calculate actual cost
 	cost := decision.Tier.CalculateCostCents(uint32(inputTokens), uint32(outputTokens))
 
+	// stream-json output mode
+	if stream != nil {
+		stream.Cost(inputTokens, outputTokens, cost)
+		stream.Result(ResultEventData{
+			Response:     fullResponse.String(),
+			Tier:         decision.Tier.Name(),
+			Model:        model,
+			Iterations:   1,
+			InputTokens:  inputTokens,
+			OutputTokens: outputTokens,
+			CostCents:    cost,
+			DurationMs:   duration.Milliseconds(),
+			StopReason:   "complete",
+		})
+		return fullResponse.String(), nil
+	}
+
 	// JSON output mode
 	if args.JSON {
 		data := AskData{
@@ -557,7 +592,7 @@ func HandleAskCommand(args Args) error {
 		}
 
 		resp := NewJSONResponse("ask", data)
-		return resp.Print()
+		return fullResponse.String(), resp.Print()
 	}
 
 	// USABILITY: Display response with markdown rendering when on TTY
@@ -573,7 +608,76 @@ func HandleAskCommand(args Args) error {
 		displayCostSummary(decision.Tier, inputTokens, outputTokens, totalTokens, duration)
 	}
 
-	return nil
+	return fullResponse.String(), nil
+}
+
+// askStreams validates --output-format and --input-format and returns the
+// stream-json writer and reader they select (nil otherwise).
+func askStreams(args Args) (*StreamJSONWriter, *StreamJSONReader, error) {
+	switch args.OutputFormat {
+	case "", FormatText, FormatJSON, FormatStreamJSON:
+	default:
+		return nil, nil, fmt.Errorf("invalid --output-format %q (valid: text, json, stream-json)", args.OutputFormat)
+	}
+	switch args.InputFormat {
+	case "", FormatText:
+	case FormatStreamJSON:
+		if args.OutputFormat != FormatStreamJSON {
+			return nil, nil, fmt.Errorf("--input-format stream-json requires --output-format stream-json")
+		}
+	default:
+		return nil, nil, fmt.Errorf("invalid --input-format %q (valid: text, stream-json)", args.InputFormat)
+	}
+
+	var stream *StreamJSONWriter
+	var input *StreamJSONReader
+	if args.OutputFormat == FormatStreamJSON {
+		stream = NewStreamJSONWriter(os.Stdout)
+	}
+	if args.InputFormat == FormatStreamJSON {
+		input = NewStreamJSONReader(os.Stdin)
+	}
+	return stream, input, nil
+}
+
+// askFailed reports an error that ends the ask command in the selected
+// output format and returns it.
+func askFailed(args Args, stream *StreamJSONWriter, err error) error {
+	if stream != nil {
+		return stream.Error(err, true)
+	}
+	if args.JSON {
+		NewJSONErrorResponse("ask", err).Print()
+	}
+	return err
+}
+
+// expandAskMentions expands @ mentions in a question, reporting the
+// included context and any errors on stderr.
+func expandAskMentions(question string, args Args) string {
+	if !ctxmention.HasMentions(question) {
+		return question
+	}
+
+	expander := ctxmention.NewExpander(nil)
+	result := expander.Expand(question)
+
+	// Display what context was included (unless --quiet)
+	if !args.Quiet && result.Summary.TotalCount > 0 {
+		fmt.Fprintf(os.Stderr, "%s Context: %s\n",
+			lipgloss.NewStyle().Foreground(styles.Cyan).Render("[+]"),
+			result.Summary.FormatSummary())
+	}
+
+	// Report any errors fetching context
+	if result.HasErrors() && !args.Quiet {
+		fmt.Fprintf(os.Stderr, "%s Context errors: %s\n",
+			lipgloss.NewStyle().Foreground(styles.Rose).Render("[!]"),
+			result.ErrorSummary())
+	}
+
+	// Use the expanded message (with context prepended) for the LLM
+	return result.ExpandedMessage
 }
 
 // displayRoutingDecision shows the routing decision to the user.
@@ -697,10 +801,15 @@ func formatNumber(n int) string {
 
 // runAgenticLoop executes the agentic tool-use loop for CLI mode.
 // This allows the model to use tools (Read, Glob, Grep, Bash, WebSearch, etc.)
-// and iteratively explore/act until the task is complete.
-func runAgenticLoop(ctx context.Context, client *ollama.Client, model string, messages []ollama.Message, args Args) error {
+// and iteratively explore/act until the task is complete. With stream-json
+// input, each follow-up message starts another run of the loop in the same
+// conversation.
+func runAgenticLoop(ctx context.Context, client *ollama.Client, model string, messages []ollama.Message, projectInstructions *instructions.Tracker, args Args, stream *StreamJSONWriter, input *StreamJSONReader) error {
 	// Create tool registry with all available tools
-	registry := tools.NewRegistry()
+	registry, err := newAskToolRegistry(config.Global(), args)
+	if err != nil {
+		return askFailed(args, stream, err)
+	}
 
 	// Convert tools to Ollama format
 	ollamaTools := registry.ToOllamaTools()
@@ -712,136 +821,184 @@ func runAgenticLoop(ctx context.Context, client *ollama.Client, model string, me
 		fmt.Fprintf(os.Stderr, "%s Max iterations: %d\n",
 			lipgloss.NewStyle().Foreground(styles.Cyan).Render("[AGENTIC]"),
 			args.MaxIter)
-		fmt.Println()
+		if !args.MachineOutput() {
+			fmt.Println()
+		}
 	}
 
 	startTime := time.Now()
 	var totalTokens int
 	iteration := 0
 
-	for iteration < args.MaxIter {
-		iteration++
-
-		if !args.Quiet && iteration > 1 {
-			fmt.Fprintf(os.Stderr, "\n%s Iteration %d/%d\n",
-				lipgloss.NewStyle().Foreground(styles.Purple).Render("[LOOP]"),
-				iteration, args.MaxIter)
-		}
+	for {
+		turnStart := time.Now()
+		var turnInput, turnOutput, turnIterations int
+		var response string
+		stopReason := "max_iterations"
 
-		// Accumulate response and detect tool calls
-		var responseContent strings.Builder
-		var detectedToolCalls []ollama.ToolCall
-		var iterTokens int
+		for turnIterations < args.MaxIter {
+			turnIterations++
+			iteration++
 
-		// Stream with tools
-		err := client.ChatStreamWithTools(ctx, model, messages, ollamaTools, func(chunk ollama.StreamChunk) {
-			if chunk.Error != nil {
-				fmt.Fprintf(os.Stderr, "\n%s %v\n", errorStyle.Render("[Error]"), chunk.Error)
-				return
+			if !args.Quiet && turnIterations > 1 {
+				fmt.Fprintf(os.Stderr, "\n%s Iteration %d/%d\n",
+					lipgloss.NewStyle().Foreground(styles.Purple).Render("[LOOP]"),
+					turnIterations, args.MaxIter)
 			}
 
-			// Accumulate content
-			if chunk.Content != "" {
-				responseContent.WriteString(chunk.Content)
-				if !args.JSON {
-					fmt.Print(chunk.Content)
+			// Accumulate response and detect tool calls
+			var responseContent strings.Builder
+			var detectedToolCalls []ollama.ToolCall
+			var iterInput, iterOutput int
+
+			// Stream with tools
+			err := client.ChatStreamWithTools(ctx, model, messages, ollamaTools, func(chunk ollama.StreamChunk) {
+				if chunk.Error != nil {
+					fmt.Fprintf(os.Stderr, "\n%s %v\n", errorStyle.Render("[Error]"), chunk.Error)
+					return
 				}
-			}
 
-			// Detect tool calls
-			if len(chunk.ToolCalls) > 0 {
-				detectedToolCalls = append(detectedToolCalls, chunk.ToolCalls...)
-			}
+				// Accumulate content
+				if chunk.Content != "" {
+					responseContent.WriteString(chunk.Content)
+					stream.Token(chunk.Content)
+					if !args.MachineOutput() {
+						fmt.Print(chunk.Content)
+					}
+				}
 
-			// Track tokens
-			if chunk.Done {
-				iterTokens = chunk.PromptTokens + chunk.CompletionTokens
-			}
-		})
+				// Detect tool calls
+				if len(chunk.ToolCalls) > 0 {
+					detectedToolCalls = append(detectedToolCalls, chunk.ToolCalls...)
+				}
 
-		if err != nil {
-			return fmt.Errorf("agentic streaming failed: %w", err)
-		}
+				// Track tokens
+				if chunk.Done {
+					iterInput, iterOutput = chunk.PromptTokens, chunk.CompletionTokens
+				}
+			})
+
+			if err != nil {
+				return askFailed(args, stream, fmt.Errorf("agentic streaming failed: %w", err))
+			}
 
-		totalTokens += iterTokens
+			totalTokens += iterInput + iterOutput
+			turnInput += iterInput
+			turnOutput += iterOutput
+			stream.Cost(iterInput, iterOutput, 0) // Local is free
+			response = responseContent.String()
+
+			// If no structured tool calls detected, try to parse from JSON text output
+			// (Many small models output tool calls as JSON text rather than structured calls)
+			if len(detectedToolCalls) == 0 {
+				parsedCalls := parseToolCallsFromText(responseContent.String())
+				if len(parsedCalls) > 0 {
+					detectedToolCalls = parsedCalls
+					if !args.Quiet {
+						fmt.Fprintf(os.Stderr, "\n%s Parsed %d tool call(s) from text output\n",
+							lipgloss.NewStyle().Foreground(styles.Purple).Render("[PARSE]"),
+							len(parsedCalls))
+					}
+				}
+			}
 
-		// If no structured tool calls detected, try to parse from JSON text output
-		// (Many small models output tool calls as JSON text rather than structured calls)
-		if len(detectedToolCalls) == 0 {
-			parsedCalls := parseToolCallsFromText(responseContent.String())
-			if len(parsedCalls) > 0 {
-				detectedToolCalls = parsedCalls
+			// If still no tool calls detected, we're done
+			if len(detectedToolCalls) == 0 {
 				if !args.Quiet {
-					fmt.Fprintf(os.Stderr, "\n%s Parsed %d tool call(s) from text output\n",
-						lipgloss.NewStyle().Foreground(styles.Purple).Render("[PARSE]"),
-						len(parsedCalls))
+					if !args.MachineOutput() {
+						fmt.Println() // Ensure newline
+					}
+					fmt.Fprintf(os.Stderr, "\n%s Task complete after %d iteration(s)\n",
+						lipgloss.NewStyle().Foreground(styles.Emerald).Render("[DONE]"),
+						turnIterations)
 				}
+				stopReason = "complete"
+				break
 			}
-		}
 
-		// If still no tool calls detected, we're done
-		if len(detectedToolCalls) == 0 {
+			// Execute tool calls
 			if !args.Quiet {
-				fmt.Println() // Ensure newline
-				fmt.Fprintf(os.Stderr, "\n%s Task complete after %d iteration(s)\n",
-					lipgloss.NewStyle().Foreground(styles.Emerald).Render("[DONE]"),
-					iteration)
+				fmt.Fprintf(os.Stderr, "\n%s Executing %d tool call(s)...\n",
+					lipgloss.NewStyle().Foreground(styles.Amber).Render("[TOOLS]"),
+					len(detectedToolCalls))
 			}
-			break
-		}
 
-		// Execute tool calls
-		if !args.Quiet {
-			fmt.Fprintf(os.Stderr, "\n%s Executing %d tool call(s)...\n",
-				lipgloss.NewStyle().Foreground(styles.Amber).Render("[TOOLS]"),
-				len(detectedToolCalls))
-		}
+			// Add assistant message with tool calls
+			assistantMsg := ollama.Message{
+				Role:      "assistant",
+				Content:   responseContent.String(),
+				ToolCalls: detectedToolCalls,
+			}
+			messages = append(messages, assistantMsg)
 
-		// Add assistant message with tool calls
-		assistantMsg := ollama.Message{
-			Role:      "assistant",
-			Content:   responseContent.String(),
-			ToolCalls: detectedToolCalls,
-		}
-		messages = append(messages, assistantMsg)
+			// Execute each tool and collect results
+			for _, tc := range detectedToolCalls {
+				toolName := tc.Function.Name
+				toolArgs := tc.Function.Arguments
 
-		// Execute each tool and collect results
-		for _, tc := range detectedToolCalls {
-			toolName := tc.Function.Name
-			toolArgs := tc.Function.Arguments
+				if !args.Quiet {
+					fmt.Fprintf(os.Stderr, "  %s %s\n",
+						lipgloss.NewStyle().Foreground(styles.Cyan).Render("->"),
+						toolName)
+				}
 
-			if !args.Quiet {
-				fmt.Fprintf(os.Stderr, "  %s %s\n",
-					lipgloss.NewStyle().Foreground(styles.Cyan).Render("->"),
-					toolName)
-			}
+				// Execute the tool
+				result, err := executeToolForCLI(ctx, registry, stream, toolName, toolArgs, canAskForApproval(args, input))
+				if err != nil {
+					result = fmt.Sprintf("Error: %v", err)
+				}
 
-			// Execute the tool
-			result, err := executeToolForCLI(ctx, registry, toolName, toolArgs)
-			if err != nil {
-				result = fmt.Sprintf("Error: %v", err)
-			}
+				// Files in other directories can bring nested RIGRUN.md files into scope
+				if projectInstructions.Touch(instructions.PathsFromToolParams(toolArgs)...) {
+					messages[0].Content = agenticSystemPrompt(projectInstructions)
+				}
 
-			// UNICODE: Rune-aware truncation preserves multi-byte characters
-			if util.RuneLen(result) > 4000 {
-				result = util.TruncateRunesNoEllipsis(result, 4000) + "\n... (truncated)"
-			}
+				// UNICODE: Rune-aware truncation preserves multi-byte characters
+				if util.RuneLen(result) > 4000 {
+					result = util.TruncateRunesNoEllipsis(result, 4000) + "\n... (truncated)"
+				}
 
-			// Add tool result message
-			toolResultMsg := ollama.Message{
-				Role:    "tool",
-				Content: fmt.Sprintf("[%s result]\n%s", toolName, result),
-			}
-			messages = append(messages, toolResultMsg)
+				// Add tool result message
+				toolResultMsg := ollama.Message{
+					Role:    "tool",
+					Content: fmt.Sprintf("[%s result]\n%s", toolName, result),
+				}
+				messages = append(messages, toolResultMsg)
 
-			if args.Verbose {
-				fmt.Fprintf(os.Stderr, "    Result: %s\n",
-					lipgloss.NewStyle().Foreground(styles.TextMuted).Render(
-						truncateString(result, 100)))
+				if args.Verbose {
+					fmt.Fprintf(os.Stderr, "    Result: %s\n",
+						lipgloss.NewStyle().Foreground(styles.TextMuted).Render(
+							truncateString(result, 100)))
+				}
 			}
 		}
+
+		stream.Result(ResultEventData{
+			Response:     response,
+			Tier:         router.TierLocal.Name(),
+			Model:        model,
+			Iterations:   turnIterations,
+			InputTokens:  turnInput,
+			OutputTokens: turnOutput,
+			DurationMs:   time.Since(turnStart).Milliseconds(),
+			StopReason:   stopReason,
+		})
+
+		// stream-json input: continue the conversation with the next message
+		next, ok := input.NextUserMessage(stream)
+		if !ok {
+			break
+		}
+		stream.StartTurn()
+		if stopReason == "complete" {
+			messages = append(messages, ollama.NewAssistantMessage(response))
+		}
+		messages = append(messages, ollama.NewUserMessage(expandAskMentions(next, args)))
 	}
 
+	// Stop hooks run once the agent has finished
+	runStopHooks(ctx, registry)
+
 	// Show final summary
 	duration := time.Since(startTime)
 	if !args.Quiet {
@@ -860,8 +1017,103 @@ func runAgenticLoop(ctx context.Context, client *ollama.Client, model string, me
 	return nil
 }
 
-// executeToolForCLI executes a single tool in CLI context.
-func executeToolForCLI(ctx context.Context, registry *tools.Registry, toolName string, args map[string]interface{}) (string, error) {
+// agenticSystemPrompt returns the agentic loop system prompt for the working
+// directory with the current project instructions layered in.
+func agenticSystemPrompt(projectInstructions *instructions.Tracker) string {
+	cwd, _ := os.Getwd()
+	return projectInstructions.Set().Apply(tools.GenerateAgenticLoopPromptWithContext(runtime.GOOS, cwd))
+}
+
+// runStopHooks runs the configured stop hooks and prints their output.
+func runStopHooks(ctx context.Context, registry *tools.Registry) {
+	if !registry.HasHooks(tools.HookStop) {
+		return
+	}
+	if output := registry.RunStopHooks(ctx); output != "" {
+		fmt.Fprintf(os.Stderr, "\n%s\n%s\n",
+			lipgloss.NewStyle().Foreground(styles.Amber).Render("[HOOKS]"),
+			output)
+	}
+}
+
+// newAskToolRegistry creates the tool registry for an agentic ask run:
+// administrator policy, Bash sandbox, hooks and the permission policy from
+// --permission-policy or the config's [permissions] table. A setup error or a
+// policy that fails to load is an error, since running without it could allow
+// more than the job intends.
+func newAskToolRegistry(cfg *config.Config, args Args) (*tools.Registry, error) {
+	registry, err := tools.NewConfiguredRegistry(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("tool setup: %w", err)
+	}
+
+	// A policy file replaces the config's [permissions] table
+	if source := args.PermissionPolicy; source != "" {
+		perms, err := config.LoadPermissionsFile(source)
+		if err != nil {
+			return nil, err
+		}
+		if err := registry.ApplyPermissionPolicy(perms.Allow, perms.Ask, perms.Deny, perms.Default, source); err != nil {
+			return nil, fmt.Errorf("invalid permission policy (%s): %w", source, err)
+		}
+	}
+
+	if policy := registry.PermissionPolicy(); policy != nil && !args.Quiet {
+		fmt.Fprintf(os.Stderr, "%s Tool permissions from %s\n",
+			lipgloss.NewStyle().Foreground(styles.Cyan).Render("[POLICY]"),
+			policy.Source())
+	}
+	return registry, nil
+}
+
+// canAskForApproval reports whether the user can be asked to approve a tool
+// call: stdin must be a terminal that isn't carrying stream-json input, and
+// stdout must not be machine output.
+func canAskForApproval(args Args, input *StreamJSONReader) bool {
+	return input == nil && !args.MachineOutput() && IsTTY()
+}
+
+// cliToolPermission decides whether a tool call may run in CLI mode, and
+// why. Calls denied by policy never run. Without a permission policy the
+// CLI has no one to ask, so every other call runs; with one, calls that
+// need approval are asked about when possible and denied otherwise.
+func cliToolPermission(registry *tools.Registry, decision tools.PermissionDecision, toolName string, args map[string]interface{}, canPrompt bool) (bool, string) {
+	if decision.Level == tools.PermissionNever {
+		if decision.Rule == "" {
+			return false, "denied by policy"
+		}
+		return false, "denied by " + decision.Describe()
+	}
+
+	if registry.PermissionPolicy() == nil {
+		return true, "not denied by policy"
+	}
+	if decision.Level == tools.PermissionAsk {
+		if !canPrompt {
+			return false, "approval required by " + decision.Describe() + " and no one to ask"
+		}
+		if !PromptYesNo(fmt.Sprintf("Allow %s %s?", toolName, truncateString(fmt.Sprint(args), 120))) {
+			return false, "denied by the user"
+		}
+		return true, "approved by the user"
+	}
+	return true, "allowed by " + decision.Describe()
+}
+
+// executeToolForCLI executes a single tool in CLI context. The call, its
+// permission decision and its result are reported on the event stream.
+func executeToolForCLI(ctx context.Context, registry *tools.Registry, stream *StreamJSONWriter, toolName string, args map[string]interface{}, canPrompt bool) (output string, err error) {
+	callID := stream.ToolCall(toolName, args)
+	start := time.Now()
+	success := false
+	defer func() {
+		result := output
+		if err != nil {
+			result = err.Error()
+		}
+		stream.ToolResult(callID, toolName, err == nil && success, result, time.Since(start))
+	}()
+
 	tool := registry.Get(toolName)
 	if tool == nil {
 		return "", fmt.Errorf("unknown tool: %s", toolName)
@@ -871,16 +1123,52 @@ func executeToolForCLI(ctx context.Context, registry *tools.Registry, toolName s
 		return "", fmt.Errorf("tool %s has no executor", toolName)
 	}
 
+	// CM-5: Tools denied by policy are never executed, and no hook runs
+	decision := registry.PermissionDecision(toolName, args)
+	if decision.Level == tools.PermissionNever {
+		registry.AuditPermissionDecision(toolName, decision)
+		_, reason := cliToolPermission(registry, decision, toolName, args, false)
+		stream.Permission(callID, toolName, false, reason)
+		return "", fmt.Errorf("tool %s is not permitted: %s", toolName, reason)
+	}
+
+	// Pre-tool hooks may veto the call or rewrite its parameters
+	args, rewritten, err := registry.RunPreToolHooks(ctx, toolName, args)
+	if err != nil {
+		stream.Permission(callID, toolName, false, err.Error())
+		return "", err
+	}
+	if rewritten {
+		decision = registry.PermissionDecision(toolName, args)
+	}
+	registry.AuditPermissionDecision(toolName, decision)
+
+	allowed, reason := cliToolPermission(registry, decision, toolName, args, canPrompt)
+	if rewritten {
+		reason += " for the parameters set by a hook"
+	}
+	stream.Permission(callID, toolName, allowed, reason)
+	if !allowed {
+		return "", fmt.Errorf("tool %s is not permitted: %s", toolName, reason)
+	}
+
 	result, err := tool.Executor.Execute(ctx, args)
 	if err != nil {
 		return "", err
 	}
+	success = result.Success
 
+	output = result.Output
 	if !result.Success {
-		return fmt.Sprintf("Tool error: %s", result.Error), nil
+		output = fmt.Sprintf("Tool error: %s", result.Error)
 	}
 
-	return result.Output, nil
+	// Post-tool hook output is shown to the model with the result
+	if notes := registry.RunPostToolHooks(ctx, toolName, args, result); notes != "" {
+		output = strings.TrimRight(output, "\n") + "\n\n" + notes
+	}
+
+	return output, nil
 }
 
 // truncateString truncates a string to maxLen runes (characters).
@@ -987,13 +1275,18 @@ func parseToolCallsFromText(text string) []ollama.ToolCall {
 
 // runCloudAgenticLoop executes the agentic tool-use loop using OpenRouter cloud API.
 // This provides better tool support than local models and uses openrouter/auto by default.
-func runCloudAgenticLoop(ctx context.Context, cfg *config.Config, model string, question string, args Args) error {
+func runCloudAgenticLoop(ctx context.Context, cfg *config.Config, model string, question string, args Args, stream *StreamJSONWriter, input *StreamJSONReader) error {
 	// Create OpenRouter client
 	cloudClient := cloud.NewOpenRouterClient(cfg.Cloud.OpenRouterKey)
 	cloudClient.SetModel(model)
+	// SC-7(10): Scan outbound prompts for credentials
+	guardCloudClient(cloudClient, cfg)
 
 	// Create tool registry
-	registry := tools.NewRegistry()
+	registry, err := newAskToolRegistry(cfg, args)
+	if err != nil {
+		return askFailed(args, stream, err)
+	}
 	toolsList := registry.All()
 
 	if !args.Quiet {
@@ -1003,16 +1296,19 @@ func runCloudAgenticLoop(ctx context.Context, cfg *config.Config, model string,
 		fmt.Fprintf(os.Stderr, "%s Max iterations: %d\n",
 			lipgloss.NewStyle().Foreground(styles.Cyan).Render("[AGENTIC]"),
 			args.MaxIter)
-		fmt.Println()
+		if !args.MachineOutput() {
+			fmt.Println()
+		}
 	}
 
-	// Build agentic system prompt with platform awareness
+	// Build agentic system prompt with platform awareness and project
+	// instructions (RIGRUN.md)
 	cwd, _ := os.Getwd()
-	agenticPrompt := tools.GenerateAgenticLoopPromptWithContext(runtime.GOOS, cwd)
+	projectInstructions := instructions.NewTracker(cwd)
 
 	// Build messages for cloud API
 	messages := []cloud.ChatMessage{
-		cloud.NewSystemMessage(agenticPrompt),
+		cloud.NewSystemMessage(agenticSystemPrompt(projectInstructions)),
 		cloud.NewUserMessage(question),
 	}
 
@@ -1020,93 +1316,155 @@ func runCloudAgenticLoop(ctx context.Context, cfg *config.Config, model string,
 	var totalTokens int
 	var totalCost float64
 	iteration := 0
+	budget := newBudgetGuard(cfg)
+
+	for {
+		turnStart := time.Now()
+		var turnInput, turnOutput, turnIterations int
+		var turnCost float64
+		var response string
+		stopReason := "max_iterations"
+
+		for turnIterations < args.MaxIter {
+			turnIterations++
+			iteration++
+
+			if !args.Quiet && turnIterations > 1 {
+				fmt.Fprintf(os.Stderr, "\n%s Iteration %d/%d\n",
+					lipgloss.NewStyle().Foreground(styles.Purple).Render("[LOOP]"),
+					turnIterations, args.MaxIter)
+			}
 
-	for iteration < args.MaxIter {
-		iteration++
-
-		if !args.Quiet && iteration > 1 {
-			fmt.Fprintf(os.Stderr, "\n%s Iteration %d/%d\n",
-				lipgloss.NewStyle().Foreground(styles.Purple).Render("[LOOP]"),
-				iteration, args.MaxIter)
-		}
-
-		// Call cloud API
-		resp, err := cloudClient.Chat(ctx, messages)
-		if err != nil {
-			return fmt.Errorf("cloud API call failed: %w", err)
-		}
+			// Call cloud API; every call is checked against the spend budgets
+			resp, exceeded, err := chatWithinBudget(ctx, cloudClient, budget, messages)
+			if err != nil {
+				return askFailed(args, stream, fmt.Errorf("cloud API call failed: %w", err))
+			}
+			if exceeded != "" {
+				if !args.Quiet {
+					fmt.Fprintf(os.Stderr, "\n%s Cost budget exceeded (%s), stopping\n",
+						lipgloss.NewStyle().Foreground(styles.Amber).Render("[BUDGET]"),
+						exceeded)
+				}
+				stream.Error(fmt.Errorf("cost budget exceeded: %s", exceeded), false)
+				stopReason = "budget_exceeded"
+				break
+			}
 
-		// Track tokens and cost
-		iterTokens := resp.Usage.PromptTokens + resp.Usage.CompletionTokens
-		totalTokens += iterTokens
+			// Track tokens and cost
+			iterTokens := resp.Usage.PromptTokens + resp.Usage.CompletionTokens
+			totalTokens += iterTokens
+			turnInput += resp.Usage.PromptTokens
+			turnOutput += resp.Usage.CompletionTokens
+
+			// Calculate cost (check if free model)
+			var iterCost float64
+			if !strings.HasSuffix(model, ":free") {
+				iterCost = recordCloudSpend(budget, router.TierCloud, resp.Usage.PromptTokens, resp.Usage.CompletionTokens,
+					time.Since(turnStart), question)
+				totalCost += iterCost
+				turnCost += iterCost
+			}
+			stream.Cost(resp.Usage.PromptTokens, resp.Usage.CompletionTokens, iterCost*100)
 
-		// Calculate cost (check if free model)
-		if !strings.HasSuffix(model, ":free") {
-			inputCost := float64(resp.Usage.PromptTokens) / 1000000.0 * 3.0
-			outputCost := float64(resp.Usage.CompletionTokens) / 1000000.0 * 15.0
-			totalCost += inputCost + outputCost
-		}
+			// Get response content
+			responseContent := resp.GetContent()
+			response = responseContent
+			stream.Token(responseContent)
 
-		// Get response content
-		responseContent := resp.GetContent()
+			// Print response
+			if !args.MachineOutput() {
+				fmt.Println(responseContent)
+			}
 
-		// Print response
-		if !args.JSON {
-			fmt.Println(responseContent)
-		}
+			// Add assistant response to messages
+			messages = append(messages, cloud.NewAssistantMessage(responseContent))
 
-		// Add assistant response to messages
-		messages = append(messages, cloud.NewAssistantMessage(responseContent))
+			// Try to parse tool calls from response
+			parsedCalls := parseToolCallsFromText(responseContent)
 
-		// Try to parse tool calls from response
-		parsedCalls := parseToolCallsFromText(responseContent)
+			// If no tool calls detected, we're done
+			if len(parsedCalls) == 0 {
+				if !args.Quiet {
+					fmt.Fprintf(os.Stderr, "\n%s Task complete after %d iteration(s)\n",
+						lipgloss.NewStyle().Foreground(styles.Emerald).Render("[DONE]"),
+						turnIterations)
+				}
+				stopReason = "complete"
+				break
+			}
 
-		// If no tool calls detected, we're done
-		if len(parsedCalls) == 0 {
 			if !args.Quiet {
-				fmt.Fprintf(os.Stderr, "\n%s Task complete after %d iteration(s)\n",
-					lipgloss.NewStyle().Foreground(styles.Emerald).Render("[DONE]"),
-					iteration)
+				fmt.Fprintf(os.Stderr, "%s Parsed %d tool call(s)\n",
+					lipgloss.NewStyle().Foreground(styles.Purple).Render("[PARSE]"),
+					len(parsedCalls))
+				fmt.Fprintf(os.Stderr, "%s Executing %d tool call(s)...\n",
+					lipgloss.NewStyle().Foreground(styles.Cyan).Render("[TOOLS]"),
+					len(parsedCalls))
 			}
-			break
-		}
 
-		if !args.Quiet {
-			fmt.Fprintf(os.Stderr, "%s Parsed %d tool call(s)\n",
-				lipgloss.NewStyle().Foreground(styles.Purple).Render("[PARSE]"),
-				len(parsedCalls))
-			fmt.Fprintf(os.Stderr, "%s Executing %d tool call(s)...\n",
-				lipgloss.NewStyle().Foreground(styles.Cyan).Render("[TOOLS]"),
-				len(parsedCalls))
-		}
+			// Execute tool calls and collect results
+			var toolResults strings.Builder
+			for _, tc := range parsedCalls {
+				toolName := tc.Function.Name
+				toolArgs := tc.Function.Arguments
 
-		// Execute tool calls and collect results
-		var toolResults strings.Builder
-		for _, tc := range parsedCalls {
-			toolName := tc.Function.Name
-			toolArgs := tc.Function.Arguments
+				if !args.Quiet {
+					fmt.Fprintf(os.Stderr, "  -> %s\n", toolName)
+				}
 
-			if !args.Quiet {
-				fmt.Fprintf(os.Stderr, "  -> %s\n", toolName)
-			}
+				result, err := executeToolForCLI(ctx, registry, stream, toolName, toolArgs, canAskForApproval(args, input))
+				if err != nil {
+					result = fmt.Sprintf("Error: %v", err)
+				}
 
-			result, err := executeToolForCLI(ctx, registry, toolName, toolArgs)
-			if err != nil {
-				result = fmt.Sprintf("Error: %v", err)
-			}
+				// Files in other directories can bring nested RIGRUN.md files into scope
+				if projectInstructions.Touch(instructions.PathsFromToolParams(toolArgs)...) {
+					messages[0].Content = agenticSystemPrompt(projectInstructions)
+				}
 
-			// Truncate long results
-			if util.RuneLen(result) > 4000 {
-				result = util.TruncateRunesNoEllipsis(result, 4000) + "\n... (truncated)"
+				// Truncate long results
+				if util.RuneLen(result) > 4000 {
+					result = util.TruncateRunesNoEllipsis(result, 4000) + "\n... (truncated)"
+				}
+
+				toolResults.WriteString(fmt.Sprintf("[%s result]\n%s\n\n", toolName, result))
 			}
 
-			toolResults.WriteString(fmt.Sprintf("[%s result]\n%s\n\n", toolName, result))
+			// Add tool results as user message for next iteration
+			messages = append(messages, cloud.NewUserMessage(toolResults.String()))
 		}
 
-		// Add tool results as user message for next iteration
-		messages = append(messages, cloud.NewUserMessage(toolResults.String()))
+		stream.Result(ResultEventData{
+			Response:     response,
+			Tier:         router.TierCloud.Name(),
+			Model:        model,
+			Iterations:   turnIterations,
+			InputTokens:  turnInput,
+			OutputTokens: turnOutput,
+			CostCents:    turnCost * 100,
+			DurationMs:   time.Since(turnStart).Milliseconds(),
+			StopReason:   stopReason,
+		})
+
+		// No further paid calls once a budget is spent
+		if stopReason == "budget_exceeded" {
+			break
+		}
+
+		// stream-json input: continue the conversation with the next message.
+		// AC-4: a follow-up marked CUI or higher never goes to the cloud.
+		next, ok := nextCloudFollowUp(cfg, input, stream, args)
+		if !ok {
+			break
+		}
+		stream.StartTurn()
+		messages = append(messages, cloud.NewUserMessage(next))
 	}
 
+	// Stop hooks run once the agent has finished
+	runStopHooks(ctx, registry)
+
 	// Show final summary
 	duration := time.Since(startTime)
 	if !args.Quiet {
@@ -1132,3 +1490,36 @@ func runCloudAgenticLoop(ctx context.Context, cfg *config.Config, model string,
 
 	return nil
 }
+
+// chatWithinBudget sends messages to the cloud model unless a spend budget
+// is exceeded, in which case it sends nothing and describes the exceeded
+// budget. The agentic loop checks before every call, since a single turn
+// can make many.
+func chatWithinBudget(ctx context.Context, cloudClient *cloud.OpenRouterClient, budget *telemetry.BudgetGuard, messages []cloud.ChatMessage) (*cloud.ChatResponse, string, error) {
+	if exceeded := budget.ExceededBudget(); exceeded != "" {
+		return nil, exceeded, nil
+	}
+	resp, err := cloudClient.Chat(ctx, messages)
+	return resp, "", err
+}
+
+// nextCloudFollowUp returns the next stream-json follow-up message for a
+// cloud session with its @ mentions expanded. Messages classified CUI or
+// higher are rejected with an error event (AC-4); the session continues
+// with the next message.
+func nextCloudFollowUp(cfg *config.Config, input *StreamJSONReader, stream *StreamJSONWriter, args Args) (string, bool) {
+	for {
+		next, ok := input.NextUserMessage(stream)
+		if !ok {
+			return "", false
+		}
+		next = expandAskMentions(next, args)
+
+		class := classifyInput(cfg, next)
+		if class.Level >= security.ClassificationCUI {
+			stream.Error(fmt.Errorf("AC-4: message classified %s cannot be sent to the cloud model; start a local session for it", class.Level), false)
+			continue
+		}
+		return next, true
+	}
+}
@@ -14,9 +14,10 @@
 //
 // Subcommands:
 //   status (default)    Show authentication status
-//   login               Authenticate (API key validation)
+//   login               Authenticate (API key or certificate, recovery code for MFA)
 //   logout              End authenticated session
 //   mfa                 Show MFA status
+//   recovery            Generate or inspect one-time recovery codes
 //   sessions            List active sessions
 //
 // Examples:
@@ -24,6 +25,15 @@
 //   rigrun auth status                 Show authentication status
 //   rigrun auth status --json          Status in JSON format
 //   rigrun auth login                  Start authentication
+//   rigrun auth login --cert piv.pem --key piv.key
+//                                      Certificate (PIV/CAC) login
+//   rigrun auth login --recovery-code abcd-efgh-jkmn
+//                                      Complete MFA with a recovery code
+//   rigrun auth recovery generate --recovery-code abcd-efgh-jkmn
+//                                      Issue 10 new recovery codes (requires MFA)
+//   rigrun auth recovery generate --user alice
+//                                      Issue codes for another user (admin)
+//   rigrun auth recovery status        Show remaining recovery codes
 //   rigrun auth logout                 End current session
 //   rigrun auth mfa status             Check MFA status
 //   rigrun auth sessions               List active sessions
@@ -32,9 +42,17 @@
 //   - API key based authentication for cloud services
 //   - Session tracking with timeouts (AC-12)
 //   - MFA support (placeholder for future)
+//   - Certificate login verified against security.trust_store (IA-2(12))
+//   - Single-use recovery codes, hashed at rest (IA-5(1)); issuing codes
+//     requires an MFA-verified session for the same user or an admin session
+//   - Failed certificate and recovery code attempts feed AC-7 lockout
 //   - All auth events logged to audit
 //
 // Flags:
+//   --key KEY           API key (login), or private key file with --cert
+//   --cert FILE         Client certificate PEM, optionally with chain
+//   --recovery-code C   One-time recovery code completing MFA
+//   --user ID           User for recovery codes (default: authenticated user)
 //   --json              Output in JSON format
 //
 // Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
@@ -43,7 +61,10 @@
 package cli
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -106,10 +127,15 @@ var (
 
 // AuthArgs holds parsed auth command arguments.
 type AuthArgs struct {
-	Subcommand string
-	APIKey     string
-	SessionID  string
-	JSON       bool
+	Subcommand   string
+	Action       string
+	APIKey       string
+	SessionID    string
+	CertFile     string
+	KeyFile      string
+	RecoveryCode string
+	UserID       string
+	JSON         bool
 }
 
 // parseAuthCmdArgs parses auth command specific arguments.
@@ -137,6 +163,21 @@ func parseAuthCmdArgs(args *Args, remaining []string) AuthArgs {
 				i++
 				authArgs.SessionID = remaining[i]
 			}
+		case "--cert":
+			if i+1 < len(remaining) {
+				i++
+				authArgs.CertFile = remaining[i]
+			}
+		case "--recovery-code":
+			if i+1 < len(remaining) {
+				i++
+				authArgs.RecoveryCode = remaining[i]
+			}
+		case "--user", "-u":
+			if i+1 < len(remaining) {
+				i++
+				authArgs.UserID = remaining[i]
+			}
 		case "--json":
 			authArgs.JSON = true
 		default:
@@ -144,10 +185,23 @@ func parseAuthCmdArgs(args *Args, remaining []string) AuthArgs {
 				authArgs.APIKey = strings.TrimPrefix(arg, "--key=")
 			} else if strings.HasPrefix(arg, "--session=") {
 				authArgs.SessionID = strings.TrimPrefix(arg, "--session=")
+			} else if strings.HasPrefix(arg, "--cert=") {
+				authArgs.CertFile = strings.TrimPrefix(arg, "--cert=")
+			} else if strings.HasPrefix(arg, "--recovery-code=") {
+				authArgs.RecoveryCode = strings.TrimPrefix(arg, "--recovery-code=")
+			} else if strings.HasPrefix(arg, "--user=") {
+				authArgs.UserID = strings.TrimPrefix(arg, "--user=")
+			} else if !strings.HasPrefix(arg, "-") && authArgs.Action == "" {
+				authArgs.Action = arg
 			}
 		}
 	}
 
+	// With --cert, --key names the certificate's private key file
+	if authArgs.CertFile != "" {
+		authArgs.KeyFile, authArgs.APIKey = authArgs.APIKey, ""
+	}
+
 	return authArgs
 }
 
@@ -173,14 +227,22 @@ func HandleAuth(args Args) error {
 		return handleAuthSessions(authArgs)
 	case "mfa":
 		return handleAuthMFA(authArgs)
+	case "recovery":
+		return handleAuthRecovery(authArgs)
 	default:
 		return fmt.Errorf("unknown auth subcommand: %s\n\nUsage:\n"+
 			"  rigrun auth status              Show authentication status\n"+
 			"  rigrun auth login [--key KEY]   Authenticate with API key\n"+
+			"  rigrun auth login --cert FILE [--key FILE]\n"+
+			"                                  Authenticate with a client certificate\n"+
+			"  rigrun auth login --recovery-code CODE\n"+
+			"                                  Complete MFA with a recovery code\n"+
 			"  rigrun auth logout              End current session\n"+
 			"  rigrun auth validate            Validate configured API key\n"+
 			"  rigrun auth sessions            List active sessions\n"+
-			"  rigrun auth mfa status          Show MFA status (placeholder)", authArgs.Subcommand)
+			"  rigrun auth mfa status          Show MFA status (placeholder)\n"+
+			"  rigrun auth recovery generate   Issue new one-time recovery codes\n"+
+			"  rigrun auth recovery status     Show remaining recovery codes", authArgs.Subcommand)
 	}
 }
 
@@ -340,9 +402,51 @@ func handleAuthStatus(args AuthArgs) error {
 // AUTH LOGIN
 // =============================================================================
 
-// handleAuthLogin performs authentication.
+// handleAuthLogin performs authentication. With --recovery-code, the code
+// completes MFA (IA-2(1)) on the session opened by the primary credential.
 func handleAuthLogin(args AuthArgs) error {
-	authManager := security.GlobalAuthManager()
+	authManager, session, authErr, err := authenticatePrimary(args)
+	if err != nil {
+		return err
+	}
+	if authErr == nil && args.RecoveryCode != "" {
+		if authErr = authManager.VerifyRecoveryCode(session.SessionID, args.RecoveryCode); authErr != nil {
+			authManager.Logout(session.SessionID)
+			session = nil
+		}
+	}
+
+	if printErr := printAuthLoginResult(args, session, authErr); printErr != nil || authErr != nil || args.RecoveryCode == "" || args.JSON {
+		return printErr
+	}
+
+	fmt.Println(authYellowStyle.Render(fmt.Sprintf("  MFA completed with a recovery code. %s code(s) remaining.",
+		session.Metadata["recovery_codes_remaining"])))
+	fmt.Println(authDimStyle.Render("  Run 'rigrun auth recovery generate --recovery-code CODE' to issue a new set."))
+	fmt.Println()
+	return nil
+}
+
+// authenticatePrimary opens a session with the primary credential: a client
+// certificate with --cert, otherwise an API key. Setup problems are returned
+// as err; a rejected credential is returned as authErr. The returned manager
+// is installed as the global auth manager with the recovery code store attached.
+func authenticatePrimary(args AuthArgs) (authManager *security.AuthManager, session *security.AuthSession, authErr, err error) {
+	recoveryCodes := security.WithRecoveryCodes(security.NewRecoveryCodeStore(""))
+
+	if args.CertFile != "" {
+		chain, err := loadCertificateChain(args)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		pm := security.GlobalPKIManager()
+		pm.SetTrustStore(config.Global().Security.TrustStore)
+		authManager = security.NewAuthManager(security.WithPKIManager(pm), recoveryCodes)
+		security.SetGlobalAuthManager(authManager)
+
+		session, authErr = authManager.AuthenticateCertificateChain(chain[0], chain[1:])
+		return authManager, session, authErr, nil
+	}
 
 	// Get API key from args, config, or prompt
 	apiKey := args.APIKey
@@ -359,18 +463,56 @@ func handleAuthLogin(args AuthArgs) error {
 		fmt.Print("Enter API key: ")
 		keyBytes, err := term.ReadPassword(int(os.Stdin.Fd()))
 		if err != nil {
-			return fmt.Errorf("failed to read API key: %w", err)
+			return nil, nil, nil, fmt.Errorf("failed to read API key: %w", err)
 		}
 		fmt.Println() // Add newline after hidden input
 		apiKey = strings.TrimSpace(string(keyBytes))
 	}
 
 	if apiKey == "" {
-		return fmt.Errorf("API key required")
+		return nil, nil, nil, fmt.Errorf("API key required")
 	}
 
+	authManager = security.NewAuthManager(recoveryCodes)
+	security.SetGlobalAuthManager(authManager)
+
 	// Attempt authentication
-	session, err := authManager.Authenticate(security.AuthMethodAPIKey, apiKey)
+	session, authErr = authManager.Authenticate(security.AuthMethodAPIKey, apiKey)
+	return authManager, session, authErr, nil
+}
+
+// loadCertificateChain loads the --cert chain for certificate login (IA-2(12)).
+// The private key must match the certificate, and the certificate must chain
+// to the configured trust store.
+func loadCertificateChain(args AuthArgs) ([]*x509.Certificate, error) {
+	if config.Global().Security.TrustStore == "" {
+		return nil, fmt.Errorf("certificate login requires a trust store\n\n" +
+			"Configure the CA bundle with:\n" +
+			"  rigrun config set security.trust_store /path/to/ca-bundle.pem")
+	}
+
+	// LoadX509KeyPair verifies the private key matches the certificate
+	keyFile := args.KeyFile
+	if keyFile == "" {
+		keyFile = args.CertFile
+	}
+	pair, err := tls.LoadX509KeyPair(args.CertFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load certificate: %w", err)
+	}
+	chain := make([]*x509.Certificate, 0, len(pair.Certificate))
+	for _, der := range pair.Certificate {
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate: %w", err)
+		}
+		chain = append(chain, cert)
+	}
+	return chain, nil
+}
+
+// printAuthLoginResult prints the outcome of a login attempt.
+func printAuthLoginResult(args AuthArgs, session *security.AuthSession, err error) error {
 	if err != nil {
 		if args.JSON {
 			data, _ := json.MarshalIndent(map[string]interface{}{
@@ -392,6 +534,7 @@ func handleAuthLogin(args AuthArgs) error {
 			"authenticated": true,
 			"session_id":    session.SessionID,
 			"user_id":       session.UserID,
+			"auth_method":   session.AuthMethod,
 			"expires_at":    session.ExpiresAt.Format(time.RFC3339),
 		}, "", "  ")
 		fmt.Println(string(data))
@@ -402,12 +545,132 @@ func handleAuthLogin(args AuthArgs) error {
 	fmt.Printf("%s Authenticated successfully\n", authSuccessStyle.Render("[OK]"))
 	fmt.Printf("  Session ID: %s\n", authDimStyle.Render(session.SessionID[:16]+"..."))
 	fmt.Printf("  User ID:    %s\n", session.UserID)
+	fmt.Printf("  Method:     %s\n", session.AuthMethod)
+	if session.CertificateInfo != nil {
+		fmt.Printf("  Subject:    %s\n", session.CertificateInfo.Subject)
+	}
 	fmt.Printf("  Expires:    %s\n", session.ExpiresAt.Format(time.RFC1123))
 	fmt.Println()
 
 	return nil
 }
 
+// =============================================================================
+// AUTH RECOVERY
+// =============================================================================
+
+// handleAuthRecovery handles recovery code subcommands (IA-5(1)).
+func handleAuthRecovery(args AuthArgs) error {
+	switch args.Action {
+	case "", "status", "generate", "regenerate":
+	default:
+		return fmt.Errorf("unknown recovery subcommand: %s\nUsage: rigrun auth recovery [generate|status] [--user ID]", args.Action)
+	}
+
+	// Codes are kept under the user ID authentication derives from the
+	// credential, not the OS user, so every subcommand authenticates first
+	authManager, session, authErr, err := authenticatePrimary(args)
+	if err != nil {
+		return err
+	}
+	if authErr != nil {
+		return fmt.Errorf("recovery codes require an authenticated session: %w", authErr)
+	}
+	defer authManager.Logout(session.SessionID)
+
+	userID := args.UserID
+	if userID == "" {
+		userID = session.UserID
+	}
+
+	switch args.Action {
+	case "generate", "regenerate":
+		// Issuing codes requires an authenticated session that has completed
+		// MFA for the same user, or an administrator session (IA-5(1), AC-6).
+		if args.RecoveryCode != "" {
+			if err := authManager.VerifyRecoveryCode(session.SessionID, args.RecoveryCode); err != nil {
+				return fmt.Errorf("MFA verification failed: %w", err)
+			}
+		}
+
+		codes, err := authManager.GenerateRecoveryCodes(session.SessionID, args.UserID, security.GlobalRBACManager())
+		if errors.Is(err, security.ErrMFARequired) {
+			return fmt.Errorf("%w\n\nComplete MFA with an existing code (--recovery-code CODE),\n"+
+				"or ask an administrator to run 'rigrun auth recovery generate --user %s'", err, session.UserID)
+		}
+		if err != nil {
+			return err
+		}
+
+		if args.JSON {
+			data, _ := json.MarshalIndent(map[string]interface{}{
+				"user_id": userID,
+				"codes":   codes,
+			}, "", "  ")
+			fmt.Println(string(data))
+			return nil
+		}
+
+		fmt.Println()
+		fmt.Println(authTitleStyle.Render("Recovery Codes (IA-5(1))"))
+		fmt.Printf("  %s%s\n", authLabelStyle.Render("User:"), authValueStyle.Render(userID))
+		fmt.Println()
+		for i, code := range codes {
+			fmt.Printf("  %2d. %s\n", i+1, authValueStyle.Render(code))
+		}
+		fmt.Println()
+		fmt.Println(authYellowStyle.Render("  Store these codes somewhere safe. They will not be shown again."))
+		fmt.Println(authDimStyle.Render("  Each code works once. Any previously issued codes are now invalid."))
+		fmt.Println()
+		return nil
+
+	case "", "status":
+		status, err := security.NewRecoveryCodeStore("").Status(userID)
+		if err != nil && !errors.Is(err, security.ErrNoRecoveryCodes) {
+			return err
+		}
+
+		if args.JSON {
+			output := map[string]interface{}{
+				"user_id":   userID,
+				"generated": status != nil,
+			}
+			if status != nil {
+				output["status"] = status
+			}
+			data, _ := json.MarshalIndent(output, "", "  ")
+			fmt.Println(string(data))
+			return nil
+		}
+
+		fmt.Println()
+		fmt.Println(authTitleStyle.Render("Recovery Codes (IA-5(1))"))
+		fmt.Printf("  %s%s\n", authLabelStyle.Render("User:"), authValueStyle.Render(userID))
+		if status == nil {
+			fmt.Printf("  %s%s\n", authLabelStyle.Render("Status:"), authDimStyle.Render("NOT GENERATED"))
+			fmt.Println()
+			fmt.Println(authDimStyle.Render("  Ask an administrator to run 'rigrun auth recovery generate --user " + userID + "'"))
+			fmt.Println()
+			return nil
+		}
+
+		remainingStyle := authGreenStyle
+		if status.Remaining == 0 {
+			remainingStyle = authRedStyle
+		} else if status.Remaining <= 3 {
+			remainingStyle = authYellowStyle
+		}
+		fmt.Printf("  %s%s\n", authLabelStyle.Render("Remaining:"),
+			remainingStyle.Render(fmt.Sprintf("%d of %d", status.Remaining, status.Total)))
+		fmt.Printf("  %s%s\n", authLabelStyle.Render("Generated:"), authValueStyle.Render(status.GeneratedAt.Format(time.RFC1123)))
+		if status.LastUsedAt != nil {
+			fmt.Printf("  %s%s\n", authLabelStyle.Render("Last Used:"), authValueStyle.Render(status.LastUsedAt.Format(time.RFC1123)))
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
 // =============================================================================
 // AUTH LOGOUT
 // =============================================================================
@@ -597,11 +860,8 @@ func handleAuthSessions(args AuthArgs) error {
 func handleAuthMFA(args AuthArgs) error {
 	// Parse MFA subcommand
 	mfaSubcommand := "status"
-	if len(args.Subcommand) > 0 {
-		parts := strings.Split(args.Subcommand, " ")
-		if len(parts) > 1 {
-			mfaSubcommand = parts[1]
-		}
+	if args.Action != "" {
+		mfaSubcommand = args.Action
 	}
 
 	authManager := security.GlobalAuthManager()
@@ -55,10 +55,12 @@ import (
 
 	"github.com/jeranaias/rigrun-tui/internal/cloud"
 	"github.com/jeranaias/rigrun-tui/internal/config"
+	"github.com/jeranaias/rigrun-tui/internal/instructions"
 	"github.com/jeranaias/rigrun-tui/internal/offline"
 	"github.com/jeranaias/rigrun-tui/internal/ollama"
 	"github.com/jeranaias/rigrun-tui/internal/router"
 	"github.com/jeranaias/rigrun-tui/internal/security"
+	"github.com/jeranaias/rigrun-tui/internal/telemetry"
 	"github.com/jeranaias/rigrun-tui/internal/ui/styles"
 )
 
@@ -189,6 +191,10 @@ type ChatSession struct {
 	// Cloud message history (separate format for OpenRouter)
 	CloudMessages []cloud.ChatMessage
 
+	// SystemPrompt holds the project instructions (RIGRUN.md) sent ahead
+	// of the history; "" when there are none
+	SystemPrompt string
+
 	// Session statistics
 	Stats *router.SessionStats
 
@@ -199,11 +205,17 @@ type ChatSession struct {
 	Quiet      bool
 	Paranoid   bool
 
+	// Classification is the high-water mark of everything sent this session
+	Classification security.Classification
+
 	// Tracking
 	StartTime   time.Time
 	TotalTokens int
 	TotalCost   float64 // Cloud cost in dollars
 
+	// Budget records cloud spend and checks it against the [budget] limits
+	Budget *telemetry.BudgetGuard
+
 	// Clients
 	Client      *ollama.Client
 	CloudClient *cloud.OpenRouterClient
@@ -229,6 +241,9 @@ func NewChatSession(args Args) *ChatSession {
 		offline.SetOfflineMode(true)
 	}
 
+	// CM-5: Enforce the administrator policy network allow-list
+	EnforcePolicyBoundary(cfg)
+
 	// Create Ollama client with config
 	ollamaConfig := &ollama.ClientConfig{
 		BaseURL:      cfg.Local.OllamaURL,
@@ -264,11 +279,17 @@ func NewChatSession(args Args) *ChatSession {
 	if cfg.Cloud.OpenRouterKey != "" && !paranoid {
 		cloudClient = cloud.NewOpenRouterClient(cfg.Cloud.OpenRouterKey)
 		cloudClient.SetModel(cloudModel)
+		// SC-7(10): Scan outbound prompts for credentials
+		guardCloudClient(cloudClient, cfg)
 	}
 
+	// Project instructions (RIGRUN.md) for the working directory
+	cwd, _ := os.Getwd()
+
 	return &ChatSession{
 		Messages:      make([]ollama.Message, 0),
 		CloudMessages: make([]cloud.ChatMessage, 0),
+		SystemPrompt:  instructions.Load(cwd).Apply(""),
 		Stats:         router.NewSessionStats(),
 		Config:        cfg,
 		Model:         model,
@@ -276,6 +297,9 @@ func NewChatSession(args Args) *ChatSession {
 		Quiet:         args.Quiet,
 		Paranoid:      paranoid,
 		StartTime:     time.Now(),
+
+		Classification: security.ClassificationFromEnv(cfg.Security.Classification),
+		Budget:        newBudgetGuard(cfg),
 		Client:        client,
 		CloudClient:   cloudClient,
 		InputCLI:      NewChatCLI(),
@@ -395,6 +419,24 @@ func HandleChatCommand(args Args) error {
 	}
 }
 
+// localRequest returns the history to send to the local model, led by the
+// system prompt.
+func (s *ChatSession) localRequest() []ollama.Message {
+	if s.SystemPrompt == "" {
+		return s.Messages
+	}
+	return append([]ollama.Message{ollama.NewSystemMessage(s.SystemPrompt)}, s.Messages...)
+}
+
+// cloudRequest returns the history to send to the cloud model, led by the
+// system prompt.
+func (s *ChatSession) cloudRequest() []cloud.ChatMessage {
+	if s.SystemPrompt == "" {
+		return s.CloudMessages
+	}
+	return append([]cloud.ChatMessage{cloud.NewSystemMessage(s.SystemPrompt)}, s.CloudMessages...)
+}
+
 // =============================================================================
 // MESSAGE PROCESSING
 // =============================================================================
@@ -408,10 +450,14 @@ func processMessage(session *ChatSession, input string) error {
 		MaxTier:     session.Config.Routing.MaxTier,
 		Paranoid:    session.Paranoid || offline.IsOfflineMode(),
 		HasCloudKey: session.Config.Cloud.OpenRouterKey != "" && !offline.IsOfflineMode(),
+		// There is no one to ask mid-stream, so a spent budget falls back to local
+		Budget:       session.Budget,
+		BudgetAction: router.BudgetActionLocal,
 	}
-	// Default to Unclassified for CLI chat (TUI uses interactive classification)
-	// Classification enforcement ensures CUI+ data stays on-premise (NIST AC-4)
-	decision := router.RouteQueryDetailed(input, security.ClassificationUnclassified, routerOpts)
+	// Route on the session high-water mark: once classified content enters the
+	// history, every later turn carries it in context (NIST AC-4)
+	session.Classification = security.HighWaterMark(session.Classification, classifyInput(session.Config, input))
+	decision := router.RouteQueryDetailed(input, session.Classification.Level, routerOpts)
 
 	// Determine if we should use cloud based on routing decision
 	useCloud := !decision.Tier.IsLocal() && session.CloudClient != nil
@@ -452,7 +498,7 @@ func processMessage(session *ChatSession, input string) error {
 		session.Messages = append(session.Messages, ollama.NewUserMessage(input))
 
 		// Call cloud API
-		resp, err := session.CloudClient.Chat(ctx, session.CloudMessages)
+		resp, err := session.CloudClient.Chat(ctx, session.cloudRequest())
 		if err != nil {
 			// Remove messages on error
 			if len(session.CloudMessages) > 0 {
@@ -470,10 +516,7 @@ func processMessage(session *ChatSession, input string) error {
 
 		// Calculate cost (check if free model)
 		if !strings.HasSuffix(session.CloudModel, ":free") {
-			// Estimate cost using openrouter/auto pricing (varies, use conservative estimate)
-			inputCost := float64(inputTokens) / 1000000.0 * 3.0
-			outputCost := float64(outputTokens) / 1000000.0 * 15.0
-			iterationCost = inputCost + outputCost
+			iterationCost = recordCloudSpend(session.Budget, decision.Tier, inputTokens, outputTokens, time.Since(startTime), input)
 			session.TotalCost += iterationCost
 		}
 
@@ -495,7 +538,7 @@ func processMessage(session *ChatSession, input string) error {
 		// Create accumulator
 		accumulator := ollama.NewStreamAccumulator()
 
-		err := session.Client.ChatStream(ctx, session.Model, session.Messages, func(chunk ollama.StreamChunk) {
+		err := session.Client.ChatStream(ctx, session.Model, session.localRequest(), func(chunk ollama.StreamChunk) {
 			if chunk.Error != nil {
 				fmt.Fprintf(os.Stderr, "\n%s %v\n",
 					errorStyle.Render("[Error]"),
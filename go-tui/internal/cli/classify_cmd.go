@@ -720,6 +720,18 @@ func handleClassifyLevels(args Args) error {
 // HELPER FUNCTIONS
 // =============================================================================
 
+// classifyInput derives the marking of content sent to a model: the configured
+// session classification, raised by explicit markings in the content and, when
+// spillage detection is enabled, by detected classification markers.
+func classifyInput(cfg *config.Config, content string) security.Classification {
+	floor := security.ClassificationFromEnv(cfg.Security.Classification)
+	var detector *security.SpillageManager
+	if cfg.Security.SpillageDetection {
+		detector = security.GlobalSpillageManager()
+	}
+	return security.ClassifyContent(content, floor, detector)
+}
+
 // getColorName returns the human-readable color name for a classification level.
 func getColorName(level security.ClassificationLevel) string {
 	switch level {
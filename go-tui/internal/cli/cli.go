@@ -59,6 +59,14 @@ const (
 	CmdTransport  // NIST 800-53 SC-8: Transmission Confidentiality and Integrity
 	CmdSecTest    // NIST 800-53 SA-11: Developer Security Testing
 	CmdIntel      // Competitive Intelligence Research
+	CmdPolicy     // NIST 800-53 CM-5: Signed administrator policy bundles
+	CmdScanSecrets // NIST 800-53 SC-7(10): Secret scanning
+	CmdReview      // Local code review of a diff or branch
+	CmdPlan        // Persisted multi-step plans
+	CmdTasks       // Persisted background tasks
+	CmdSchedule    // Cron-scheduled prompts and plans
+	CmdDaemon      // Runs scheduled prompts and plans
+	CmdCost        // Cloud spend and budgets
 	CmdHelp
 )
 
@@ -85,6 +93,15 @@ type Args struct {
 	Agentic    bool
 	MaxIter    int // Maximum agentic iterations (default: 10)
 
+	// Headless output for ask: "text", "json" or "stream-json" (NDJSON
+	// events), and "text" or "stream-json" input on stdin
+	OutputFormat string
+	InputFormat  string
+
+	// PermissionPolicy is a tool permission policy file for agentic ask
+	// (--permission-policy); it replaces the config's [permissions] table
+	PermissionPolicy string
+
 	// Raw args (remaining after flag parsing)
 	Raw []string
 
@@ -123,6 +140,14 @@ Usage:
   rigrun lockout [subcommand] Account lockout management (AC-7)
   rigrun auth [subcommand]    Authentication management (IA-2)
   rigrun boundary [subcommand] Network boundary protection (SC-7)
+  rigrun policy [subcommand]  Signed administrator policy bundles (CM-5)
+  rigrun scan-secrets <path>  Scan files for credentials (SC-7(10))
+  rigrun review [range|--staged] Review a diff with the local model
+  rigrun plan [subcommand]    List, resume or cancel saved plans
+  rigrun tasks [subcommand]   List, inspect, cancel or retry background tasks
+  rigrun schedule [list]      List scheduled prompts and plans
+  rigrun daemon [--once]      Run scheduled prompts and plans as they come due
+  rigrun cost budget          Show cloud spend against the [budget] limits
   rigrun sectest [subcommand] Security testing (SA-11)
   rigrun maintenance [subcommand] Maintenance mode management (MA-4, MA-5)
   rigrun test [subcommand]   Built-in self-test (IL5 CI/CD)
@@ -200,6 +225,7 @@ Encryption Commands (NIST 800-53 SC-28: Protection of Information at Rest):
   rigrun encrypt config           Encrypt config file sensitive fields
   rigrun encrypt cache            Encrypt cache database
   rigrun encrypt audit            Encrypt audit logs (optional, for highly sensitive deployments)
+  rigrun encrypt data             Encrypt stored conversations, costs, benchmarks, tasks, plans and indexes
   rigrun encrypt rotate           Rotate master encryption key
     --confirm                     Required confirmation flag for key rotation
 
@@ -269,6 +295,14 @@ nBackup Commands (NIST 800-53 CP-9/CP-10: System Backup & Recovery):
     - Automated retention policy (keep last N backups)
     - Secure deletion (DoD 5220.22-M standard)
 
+Data Retention Commands (NIST 800-53 SI-12: Information Management and Retention):
+  rigrun data purge             Securely delete data past its [retention] policy
+    --dry-run                   Show what would be purged without deleting
+    --json                      Output in JSON format
+
+  Data Classes: conversations, costs, intel, benchmarks, checkpoints, tasks, audit_archives
+  Policies also run at startup (retention.purge_on_startup); every purge is audited
+
 RBAC Commands (NIST 800-53 AC-5/AC-6: Separation of Duties & Least Privilege):
   rigrun rbac status              Show current user role and permissions
   rigrun rbac assign USER ROLE    Assign role to user (admin only)
@@ -350,6 +384,12 @@ Examples:
   rigrun ask "List processes" --json        Output response as JSON
   rigrun ask --local "Explain this error"   Force local model only
   rigrun ask --agentic "Find all TODO comments"  Enable tool use mode
+  rigrun ask --agentic --output-format stream-json "Fix the build"
+                                      Stream NDJSON events (see docs/STREAM_JSON.md)
+  rigrun ask --output-format stream-json --input-format stream-json
+                                      Read follow-up messages from stdin as NDJSON
+  rigrun ask --agentic --permission-policy ci-policy.toml "Fix the tests"
+                                      Decide tool calls from a policy file (CI)
 
   # Chat command options
   rigrun chat --model qwen2.5:14b     Start chat with specific model
@@ -603,6 +643,54 @@ func Parse() (Command, Args) {
 		parseIntelArgs(&parsedArgs, remaining)
 		return CmdIntel, parsedArgs
 
+	case "policy":
+		// NIST 800-53 CM-5: Signed administrator policy bundles
+		// Argument parsing is done in policy_cmd.go HandlePolicy
+		parsedArgs.Raw = remaining
+		return CmdPolicy, parsedArgs
+
+	case "scan-secrets", "secrets":
+		// NIST 800-53 SC-7(10): Secret scanning
+		// Argument parsing is done in secrets_cmd.go HandleScanSecrets
+		parsedArgs.Raw = remaining
+		return CmdScanSecrets, parsedArgs
+
+	case "review":
+		// Local code review of a diff or branch
+		// Argument parsing is done in review_cmd.go HandleReview
+		parsedArgs.Raw = remaining
+		return CmdReview, parsedArgs
+
+	case "plan", "plans":
+		// Persisted multi-step plans
+		// Argument parsing is done in plan_cmd.go HandlePlan
+		parsedArgs.Raw = remaining
+		return CmdPlan, parsedArgs
+
+	case "tasks", "task":
+		// Persisted background tasks
+		// Argument parsing is done in tasks_cmd.go HandleTasks
+		parsedArgs.Raw = remaining
+		return CmdTasks, parsedArgs
+
+	case "schedule", "schedules":
+		// Cron-scheduled prompts and plans
+		// Argument parsing is done in schedule_cmd.go HandleSchedule
+		parsedArgs.Raw = remaining
+		return CmdSchedule, parsedArgs
+
+	case "daemon":
+		// Runs scheduled prompts and plans
+		// Argument parsing is done in daemon_cmd.go HandleDaemon
+		parsedArgs.Raw = remaining
+		return CmdDaemon, parsedArgs
+
+	case "cost", "costs":
+		// Cloud spend and budgets
+		// Argument parsing is done in cost_cmd.go HandleCost
+		parsedArgs.Raw = remaining
+		return CmdCost, parsedArgs
+
 	case "version", "-v", "--version":
 		return CmdVersion, parsedArgs
 
@@ -692,12 +780,33 @@ func parseAskArgs(args *Args, remaining []string) {
 					args.MaxIter = n
 				}
 			}
+		case "--output-format":
+			if i+1 < len(remaining) {
+				i++
+				args.OutputFormat = remaining[i]
+			}
+		case "--input-format":
+			if i+1 < len(remaining) {
+				i++
+				args.InputFormat = remaining[i]
+			}
+		case "--permission-policy":
+			if i+1 < len(remaining) {
+				i++
+				args.PermissionPolicy = remaining[i]
+			}
 		default:
 			// Check for --file=value or --model=value format
 			if strings.HasPrefix(arg, "--file=") {
 				args.File = strings.TrimPrefix(arg, "--file=")
 			} else if strings.HasPrefix(arg, "--model=") {
 				args.Model = strings.TrimPrefix(arg, "--model=")
+			} else if strings.HasPrefix(arg, "--output-format=") {
+				args.OutputFormat = strings.TrimPrefix(arg, "--output-format=")
+			} else if strings.HasPrefix(arg, "--input-format=") {
+				args.InputFormat = strings.TrimPrefix(arg, "--input-format=")
+			} else if strings.HasPrefix(arg, "--permission-policy=") {
+				args.PermissionPolicy = strings.TrimPrefix(arg, "--permission-policy=")
 			} else if strings.HasPrefix(arg, "--max-iter=") {
 				if n, err := strconv.Atoi(strings.TrimPrefix(arg, "--max-iter=")); err == nil && n > 0 {
 					args.MaxIter = n
@@ -710,6 +819,17 @@ func parseAskArgs(args *Args, remaining []string) {
 	}
 
 	args.Query = strings.Join(query, " ")
+
+	// --output-format json is the long form of --json
+	if args.OutputFormat == FormatJSON {
+		args.JSON = true
+	}
+}
+
+// MachineOutput reports whether stdout carries JSON (--json or
+// --output-format stream-json), so human-readable text must stay off it.
+func (a Args) MachineOutput() bool {
+	return a.JSON || a.OutputFormat == FormatStreamJSON
 }
 
 // parseChatArgs parses chat command specific arguments.
@@ -8,9 +8,21 @@
 package cli
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/jeranaias/rigrun-tui/internal/cloud"
+	"github.com/jeranaias/rigrun-tui/internal/ollama"
+	"github.com/jeranaias/rigrun-tui/internal/router"
+	"github.com/jeranaias/rigrun-tui/internal/telemetry"
+	"github.com/jeranaias/rigrun-tui/internal/tools"
 )
 
 // =============================================================================
@@ -566,3 +578,168 @@ func BenchmarkArgParser_ManyFlags(b *testing.B) {
 		NewArgParser(args)
 	}
 }
+
+// =============================================================================
+// PERMISSION POLICY TESTS
+// =============================================================================
+
+func TestAskPermissionPolicy(t *testing.T) {
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+
+	policy := filepath.Join(t.TempDir(), "ci.toml")
+	os.WriteFile(policy, []byte(`default = "deny"
+allow = ["Bash(go test *)"]
+ask = ["Write"]
+`), 0600)
+
+	os.Args = []string{"rigrun", "ask", "--agentic", "--permission-policy", policy, "Fix the tests"}
+	_, args := Parse()
+	if args.PermissionPolicy != policy || args.Query != "Fix the tests" {
+		t.Fatalf("unexpected parse: %+v", args)
+	}
+	args.Quiet = true
+
+	registry, err := newAskToolRegistry(nil, args)
+	if err != nil {
+		t.Fatalf("newAskToolRegistry() error = %v", err)
+	}
+
+	check := func(tool string, params map[string]interface{}, wantAllowed bool, wantReason string) {
+		t.Helper()
+		decision := registry.PermissionDecision(tool, params)
+		allowed, reason := cliToolPermission(registry, decision, tool, params, false)
+		if allowed != wantAllowed || !strings.Contains(reason, wantReason) {
+			t.Errorf("%s %v = %v %q, want %v containing %q", tool, params, allowed, reason, wantAllowed, wantReason)
+		}
+	}
+	check("Bash", map[string]interface{}{"command": "go test ./..."}, true, "Bash(go test *)")
+	check("Bash", map[string]interface{}{"command": "rm -rf /"}, false, "default")
+	// Nobody can approve a call in CI, so it is denied
+	check("Write", map[string]interface{}{"file_path": "x.go"}, false, "no one to ask")
+
+	// A policy file that can't be loaded is an error, not an open door
+	args.PermissionPolicy = filepath.Join(t.TempDir(), "missing.toml")
+	if _, err := newAskToolRegistry(nil, args); err == nil {
+		t.Error("a missing policy file should be an error")
+	}
+
+	// Without a policy the CLI keeps allowing what isn't denied
+	registry, _ = newAskToolRegistry(nil, Args{Quiet: true})
+	params := map[string]interface{}{"file_path": "x.go"}
+	if allowed, _ := cliToolPermission(registry, registry.PermissionDecision("Write", params), "Write", params, false); !allowed {
+		t.Error("without a policy, Write should be allowed")
+	}
+	if registry.GetPermissionWithParams("Write", params) != tools.PermissionAsk {
+		t.Error("without a policy, Write keeps its own permission")
+	}
+}
+
+// =============================================================================
+// CHAT TESTS (chat.go)
+// =============================================================================
+
+func TestChatSessionSendsProjectInstructions(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "RIGRUN.md"), []byte("Answer in British English."), 0600)
+	t.Chdir(dir)
+
+	session := NewChatSession(Args{Quiet: true, Paranoid: true})
+	session.Messages = append(session.Messages, ollama.NewUserMessage("hi"))
+	session.CloudMessages = append(session.CloudMessages, cloud.NewUserMessage("hi"))
+
+	local := session.localRequest()
+	if len(local) != 2 || local[0].Role != "system" || !strings.Contains(local[0].Content, "British English") {
+		t.Errorf("local request = %+v, want the instructions first", local)
+	}
+	if remote := session.cloudRequest(); len(remote) != 2 || !strings.Contains(remote[0].Content, "British English") {
+		t.Errorf("cloud request = %+v, want the instructions first", remote)
+	}
+	if len(session.Messages) != 1 {
+		t.Errorf("history holds %d messages, want only the user's", len(session.Messages))
+	}
+}
+
+// =============================================================================
+// BUDGET TESTS (ask.go)
+// =============================================================================
+
+func TestChatWithinBudget(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": "test-id", "model": "test-model",
+			"choices": [{"message": {"role": "assistant", "content": "done"}, "finish_reason": "stop"}],
+			"usage": {"prompt_tokens": 10, "completion_tokens": 20, "total_tokens": 30}}`))
+	}))
+	defer server.Close()
+
+	client := cloud.NewOpenRouterClient("sk-or-test-abcdefghijklmnopqrstuvwxyz0123456789")
+	client.WithBaseURL(server.URL)
+	client.WithCertValidation(false)
+	tracker, err := telemetry.NewCostTracker(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewCostTracker() error = %v", err)
+	}
+	budget := &telemetry.BudgetGuard{Tracker: tracker, Budget: telemetry.Budget{Session: 0.01}}
+	messages := []cloud.ChatMessage{cloud.NewUserMessage("hi")}
+
+	resp, exceeded, err := chatWithinBudget(context.Background(), client, budget, messages)
+	if err != nil || exceeded != "" || resp.GetContent() != "done" {
+		t.Fatalf("under budget: got %v %q %v", resp, exceeded, err)
+	}
+
+	// An iteration that spends the budget stops the next one before it is sent
+	recordCloudSpend(budget, router.TierOpus, 200000, 100000, time.Second, "hi")
+	resp, exceeded, err = chatWithinBudget(context.Background(), client, budget, messages)
+	if err != nil || exceeded == "" || resp != nil {
+		t.Errorf("over budget: got %v %q %v, want the exceeded budget", resp, exceeded, err)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("requests = %d, want 1", got)
+	}
+
+	// Without a cost store nothing is enforced
+	if _, exceeded, err := chatWithinBudget(context.Background(), client, nil, messages); err != nil || exceeded != "" {
+		t.Errorf("no budget: got %q %v", exceeded, err)
+	}
+}
+
+// =============================================================================
+// REVIEW TESTS (review_cmd.go)
+// =============================================================================
+
+func TestParseReviewArgs(t *testing.T) {
+	got, err := parseReviewArgs(&Args{}, []string{"main...HEAD", "--format=sarif", "--output", "r.sarif", "--fail-on", "warning", "--chunk-size=4000", "--fix", "--yes"})
+	if err != nil {
+		t.Fatalf("parseReviewArgs() error = %v", err)
+	}
+	if got.Range != "main...HEAD" || got.Format != ReviewFormatSARIF || got.Output != "r.sarif" ||
+		got.FailOn != "medium" || got.ChunkSize != 4000 || !got.Fix || !got.Yes {
+		t.Errorf("parseReviewArgs() = %+v", got)
+	}
+
+	// --json selects JSON output, and markdown is the default
+	if got, _ := parseReviewArgs(&Args{JSON: true}, []string{"--staged"}); got.Format != ReviewFormatJSON || !got.Staged {
+		t.Errorf("--json --staged = %+v", got)
+	}
+	if got, _ := parseReviewArgs(&Args{}, nil); got.Format != ReviewFormatMarkdown || got.ChunkSize != defaultReviewChunkSize {
+		t.Errorf("defaults = %+v", got)
+	}
+
+	for _, bad := range [][]string{
+		{"--format", "html"},
+		{"--fail-on", "sometimes"},
+		{"--chunk-size", "10"},
+		{"--staged", "HEAD~1"},
+		{"a..b", "c..d"},
+		{"--output"},
+		{"--frobnicate"},
+	} {
+		if _, err := parseReviewArgs(&Args{}, bad); err == nil {
+			t.Errorf("parseReviewArgs(%v) should fail", bad)
+		}
+	}
+}
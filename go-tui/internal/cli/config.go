@@ -54,6 +54,7 @@ package cli
 
 import (
 	"crypto/sha256"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -107,6 +108,10 @@ var (
 	configPathStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("245")).
 			Italic(true)
+
+	// Locked-by-policy indicator style
+	configLockedStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("220")) // Yellow
 )
 
 // =============================================================================
@@ -209,6 +214,21 @@ func handleConfigShowJSON() error {
 		Path: ConfigPath(),
 	}
 
+	// CM-5: Report settings locked by administrator policy
+	if cfg.PolicyPath() != "" {
+		data.Policy = &ConfigPolicyInfo{
+			Path:       cfg.PolicyPath(),
+			Valid:      cfg.PolicyError() == nil,
+			LockedKeys: cfg.LockedKeys(),
+		}
+		if doc := cfg.Policy(); doc != nil {
+			data.Policy.Issuer = doc.Issuer
+		}
+		if err := cfg.PolicyError(); err != nil {
+			data.Policy.Error = err.Error()
+		}
+	}
+
 	resp := NewJSONResponse("config show", data)
 	return resp.Print()
 }
@@ -248,17 +268,17 @@ func handleConfigShow() error {
 		configValueStyle.Render(cfg.DefaultModel))
 	fmt.Printf("  %s%s\n",
 		configKeyStyle.Render("default_mode:"),
-		configValueStyle.Render(cfg.Routing.DefaultMode))
+		configValue(cfg, "routing.default_mode", cfg.Routing.DefaultMode))
 	fmt.Println()
 
 	// Local section
 	fmt.Println(configSectionStyle.Render("[local]"))
 	fmt.Printf("  %s%s\n",
 		configKeyStyle.Render("ollama_url:"),
-		configValueStyle.Render(cfg.Local.OllamaURL))
+		configValue(cfg, "local.ollama_url", cfg.Local.OllamaURL))
 	fmt.Printf("  %s%s\n",
 		configKeyStyle.Render("ollama_model:"),
-		configValueStyle.Render(cfg.Local.OllamaModel))
+		configValue(cfg, "local.ollama_model", cfg.Local.OllamaModel))
 	fmt.Println()
 
 	// Cloud section
@@ -269,38 +289,51 @@ func handleConfigShow() error {
 		configMaskedStyle.Render(keyDisplay))
 	fmt.Printf("  %s%s\n",
 		configKeyStyle.Render("cloud_model:"),
-		configValueStyle.Render(cfg.Cloud.DefaultModel))
+		configValue(cfg, "cloud.default_model", cfg.Cloud.DefaultModel))
 	fmt.Println()
 
 	// Routing section
 	fmt.Println(configSectionStyle.Render("[routing]"))
 	fmt.Printf("  %s%s\n",
 		configKeyStyle.Render("default_mode:"),
-		configValueStyle.Render(cfg.Routing.DefaultMode))
+		configValue(cfg, "routing.default_mode", cfg.Routing.DefaultMode))
 	fmt.Printf("  %s%s\n",
 		configKeyStyle.Render("max_tier:"),
-		configValueStyle.Render(cfg.Routing.MaxTier))
+		configValue(cfg, "routing.max_tier", cfg.Routing.MaxTier))
 	paranoidStr := "false"
 	if cfg.Routing.ParanoidMode {
 		paranoidStr = "true"
 	}
 	fmt.Printf("  %s%s\n",
 		configKeyStyle.Render("paranoid_mode:"),
-		configValueStyle.Render(paranoidStr))
+		configValue(cfg, "routing.paranoid_mode", paranoidStr))
+	fmt.Printf("  %s%s\n",
+		configKeyStyle.Render("offline_mode:"),
+		configValue(cfg, "routing.offline_mode", fmt.Sprintf("%t", cfg.Routing.OfflineMode)))
 	fmt.Println()
 
 	// Security section
 	fmt.Println(configSectionStyle.Render("[security]"))
 	fmt.Printf("  %s%s\n",
 		configKeyStyle.Render("session_timeout:"),
-		configValueStyle.Render(fmt.Sprintf("%d seconds", cfg.Security.SessionTimeoutSecs)))
+		configValue(cfg, "security.session_timeout_secs", fmt.Sprintf("%d seconds", cfg.Security.SessionTimeoutSecs)))
 	auditStr := "false"
 	if cfg.Security.AuditEnabled {
 		auditStr = "true"
 	}
 	fmt.Printf("  %s%s\n",
 		configKeyStyle.Render("audit_enabled:"),
-		configValueStyle.Render(auditStr))
+		configValue(cfg, "security.audit_enabled", auditStr))
+	fmt.Printf("  %s%s\n",
+		configKeyStyle.Render("classification:"),
+		configValue(cfg, "security.classification", cfg.Security.Classification))
+	secretStr := "off"
+	if cfg.Security.SecretScanning {
+		secretStr = cfg.Security.SecretAction
+	}
+	fmt.Printf("  %s%s\n",
+		configKeyStyle.Render("secret_scanning:"),
+		configValue(cfg, "security.secret_action", secretStr))
 	fmt.Println()
 
 	// Cache section
@@ -311,12 +344,41 @@ func handleConfigShow() error {
 	}
 	fmt.Printf("  %s%s\n",
 		configKeyStyle.Render("enabled:"),
-		configValueStyle.Render(cacheStr))
+		configValue(cfg, "cache.enabled", cacheStr))
 	fmt.Printf("  %s%s\n",
 		configKeyStyle.Render("ttl_hours:"),
-		configValueStyle.Render(fmt.Sprintf("%d", cfg.Cache.TTLHours)))
+		configValue(cfg, "cache.ttl_hours", fmt.Sprintf("%d", cfg.Cache.TTLHours)))
 	fmt.Println()
 
+	// Policy section (CM-5)
+	if cfg.PolicyPath() != "" {
+		fmt.Println(configSectionStyle.Render("[policy]"))
+		fmt.Printf("  %s%s\n",
+			configKeyStyle.Render("bundle:"),
+			configPathStyle.Render(cfg.PolicyPath()))
+		if err := cfg.PolicyError(); err != nil {
+			fmt.Printf("  %s%s\n",
+				configKeyStyle.Render("status:"),
+				configErrorStyle.Render("INVALID - paranoid mode enforced"))
+			fmt.Printf("  %s%s\n",
+				configKeyStyle.Render("error:"),
+				configMaskedStyle.Render(err.Error()))
+		} else {
+			fmt.Printf("  %s%s\n",
+				configKeyStyle.Render("status:"),
+				configValueStyle.Render("verified"))
+			if doc := cfg.Policy(); doc != nil && doc.Issuer != "" {
+				fmt.Printf("  %s%s\n",
+					configKeyStyle.Render("issuer:"),
+					configValueStyle.Render(doc.Issuer))
+			}
+		}
+		fmt.Printf("  %s%s\n",
+			configKeyStyle.Render("locked keys:"),
+			configValueStyle.Render(strings.Join(cfg.LockedKeys(), ", ")))
+		fmt.Println()
+	}
+
 	// Config file path
 	fmt.Println(separatorStyle.Render(strings.Repeat("-", 41)))
 	fmt.Printf("Config file: %s\n", configPathStyle.Render(ConfigPath()))
@@ -342,10 +404,24 @@ func handleConfigSet(key, value string) error {
 
 	// Normalize key (support both dot notation and underscore)
 	key = strings.ToLower(key)
+	fullKey := key
 	key = strings.ReplaceAll(key, "_", ".")
 
-	// Try using the config's Set method for dot notation
-	if err := cfg.Set(key, value); err == nil {
+	// CM-5: Refuse keys pinned by administrator policy
+	if dotKey, ok := configShortcutKeys[strings.ReplaceAll(key, ".", "_")]; ok && cfg.IsLocked(dotKey) {
+		return fmt.Errorf("%s is locked by policy (%s)", dotKey, cfg.PolicyPath())
+	}
+
+	// Try using the config's Set method for dot notation. Keys whose field
+	// names contain underscores (security.trust_store) are tried as given.
+	if strings.Contains(fullKey, ".") {
+		if _, getErr := cfg.Get(fullKey); getErr == nil {
+			key = fullKey
+		}
+	}
+	if err := cfg.Set(key, value); errors.Is(err, config.ErrLockedByPolicy) {
+		return fmt.Errorf("%w (%s)", err, cfg.PolicyPath())
+	} else if err == nil {
 		// Successfully set using dot notation - now validate before saving
 		if validateErr := cfg.Validate(); validateErr != nil {
 			return fmt.Errorf("invalid configuration value: %w", validateErr)
@@ -548,6 +624,45 @@ func maskIfSecret(key, value string) string {
 	return value
 }
 
+// configValue renders a config value, marking it if pinned by policy (CM-5).
+func configValue(cfg *Config, key, value string) string {
+	if cfg.IsLocked(key) {
+		return configValueStyle.Render(value) + " " + configLockedStyle.Render("(locked by policy)")
+	}
+	return configValueStyle.Render(value)
+}
+
+// configShortcutKeys maps the shortcut keys accepted by "config set" to
+// their dot-notation config keys, for policy lock checks.
+var configShortcutKeys = map[string]string{
+	"default_model":                 "default_model",
+	"default_mode":                  "routing.default_mode",
+	"routing_default_mode":          "routing.default_mode",
+	"ollama_url":                    "local.ollama_url",
+	"local_ollama_url":              "local.ollama_url",
+	"ollama_model":                  "local.ollama_model",
+	"local_ollama_model":            "local.ollama_model",
+	"openrouter_key":                "cloud.openrouter_key",
+	"cloud_openrouter_key":          "cloud.openrouter_key",
+	"cloud_model":                   "cloud.default_model",
+	"cloud_default_model":           "cloud.default_model",
+	"max_tier":                      "routing.max_tier",
+	"routing_max_tier":              "routing.max_tier",
+	"paranoid_mode":                 "routing.paranoid_mode",
+	"routing_paranoid_mode":         "routing.paranoid_mode",
+	"offline_mode":                  "routing.offline_mode",
+	"routing_offline_mode":          "routing.offline_mode",
+	"no_network":                    "routing.offline_mode",
+	"session_timeout":               "security.session_timeout_secs",
+	"session_timeout_secs":          "security.session_timeout_secs",
+	"security_session_timeout_secs": "security.session_timeout_secs",
+	"audit_enabled":                 "security.audit_enabled",
+	"security_audit_enabled":        "security.audit_enabled",
+	"cache_enabled":                 "cache.enabled",
+	"cache_ttl_hours":               "cache.ttl_hours",
+	"cache_ttl":                     "cache.ttl_hours",
+}
+
 // parseBool parses a boolean string value.
 func parseBool(s string) bool {
 	s = strings.ToLower(strings.TrimSpace(s))
@@ -0,0 +1,299 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// cost_cmd.go - Cloud spend CLI commands for rigrun.
+//
+// CLI: Comprehensive help and examples for all commands
+//
+// Cloud spend is recorded per session in ~/.rigrun/costs from the token
+// usage the provider reports. The [budget] section of ~/.rigrun/config.toml
+// caps it per session, day, week and month; once a budget is spent the
+// router sends queries to the local model instead of a paid tier, or with
+// on_exceeded = "prompt" the TUI asks first:
+//
+//   [budget]
+//   daily_usd = 5.0
+//   monthly_usd = 50.0
+//   warn_percent = 80
+//   on_exceeded = "local"
+//
+// Command: cost [subcommand]
+// Short:   Show cloud spend against the budgets
+// Aliases: costs
+//
+// Subcommands:
+//   budget (default)    Show spend against each configured budget
+//
+// Examples:
+//   rigrun cost budget                     Show spend against the budgets
+//   rigrun cost budget --json              JSON output
+//   rigrun config set budget.daily_usd 5   Cap cloud spend at $5 a day
+//
+// Flags:
+//   --json              Output in JSON format
+
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/jeranaias/rigrun-tui/internal/config"
+	"github.com/jeranaias/rigrun-tui/internal/router"
+	"github.com/jeranaias/rigrun-tui/internal/telemetry"
+)
+
+// =============================================================================
+// COST COMMAND STYLES
+// =============================================================================
+
+var (
+	// Cost title style
+	costTitleStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("39")). // Cyan
+			MarginBottom(1)
+
+	// Cost label style
+	costLabelStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("252")).
+			Width(10)
+
+	// Cost value style
+	costValueStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("255")) // White
+
+	// Cost success style
+	costSuccessStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("82")).
+				Bold(true)
+
+	// Cost warning style
+	costWarningStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("220")).
+				Bold(true)
+
+	// Cost error style
+	costErrorStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("196")).
+			Bold(true)
+
+	// Cost dim style
+	costDimStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("242"))
+)
+
+// =============================================================================
+// COST ARGUMENTS
+// =============================================================================
+
+// CostArgs holds parsed cost command arguments.
+type CostArgs struct {
+	Subcommand string
+	JSON       bool
+}
+
+// parseCostArgs parses cost command specific arguments.
+func parseCostArgs(args *Args, remaining []string) CostArgs {
+	costArgs := CostArgs{
+		JSON: args.JSON,
+	}
+
+	if len(remaining) > 0 && !strings.HasPrefix(remaining[0], "-") {
+		costArgs.Subcommand = remaining[0]
+		remaining = remaining[1:]
+	}
+
+	for _, arg := range remaining {
+		switch arg {
+		case "--json":
+			costArgs.JSON = true
+		}
+	}
+
+	return costArgs
+}
+
+// =============================================================================
+// COST JSON TYPES
+// =============================================================================
+
+// BudgetInfo is the JSON form of the spend against one budget.
+type BudgetInfo struct {
+	Period   string     `json:"period"`
+	Since    *time.Time `json:"since,omitempty"` // Start of the period; nil per session
+	LimitUSD float64    `json:"limit_usd"`
+	SpentUSD float64    `json:"spent_usd"`
+	Percent  float64    `json:"percent"`
+	Status   string     `json:"status"` // ok, warning, exceeded or per_session
+}
+
+// BudgetReport is the JSON form of "rigrun cost budget".
+type BudgetReport struct {
+	Budgets     []BudgetInfo `json:"budgets"`
+	WarnPercent int          `json:"warn_percent"`
+	OnExceeded  string       `json:"on_exceeded"`
+}
+
+// =============================================================================
+// BUDGET HELPERS
+// =============================================================================
+
+// BudgetFromConfig returns the spend budgets of the [budget] config section.
+func BudgetFromConfig(cfg *config.Config) telemetry.Budget {
+	return telemetry.Budget{
+		Session:     cfg.Budget.SessionUSD,
+		Daily:       cfg.Budget.DailyUSD,
+		Weekly:      cfg.Budget.WeeklyUSD,
+		Monthly:     cfg.Budget.MonthlyUSD,
+		WarnPercent: float64(cfg.Budget.WarnPercent),
+	}
+}
+
+// newBudgetGuard returns a guard that records cloud spend and checks it
+// against the configured budgets, or nil if the cost store is unavailable.
+func newBudgetGuard(cfg *config.Config) *telemetry.BudgetGuard {
+	tracker, err := telemetry.NewCostTracker("")
+	if err != nil {
+		return nil
+	}
+	return &telemetry.BudgetGuard{Tracker: tracker, Budget: BudgetFromConfig(cfg)}
+}
+
+// recordCloudSpend records the token usage of a cloud query and returns its
+// cost in dollars, priced as the cost tracker prices it, so that the cost
+// shown matches the spend budgets are checked against. The session is saved
+// after each query so that later budget checks count its spend.
+func recordCloudSpend(guard *telemetry.BudgetGuard, tier router.Tier, inputTokens, outputTokens int, duration time.Duration, prompt string) float64 {
+	cost := tier.CalculateCostCents(uint32(inputTokens), uint32(outputTokens)) / 100.0
+	if guard == nil || guard.Tracker == nil {
+		return cost
+	}
+	guard.Tracker.RecordQuery(strings.ToLower(tier.String()), inputTokens, outputTokens, duration, prompt)
+	_ = guard.Tracker.SaveCurrentSession()
+	return cost
+}
+
+// =============================================================================
+// HANDLE COST
+// =============================================================================
+
+// HandleCost handles the "cost" command with various subcommands.
+// Subcommands:
+//   - cost budget: Show spend against each configured budget
+func HandleCost(args Args) error {
+	costArgs := parseCostArgs(&args, args.Raw)
+
+	switch costArgs.Subcommand {
+	case "", "budget", "budgets":
+		return handleCostBudget(costArgs)
+	default:
+		return fmt.Errorf("unknown cost subcommand: %s\n\nUsage:\n"+
+			"  rigrun cost budget    Show cloud spend against the budgets", costArgs.Subcommand)
+	}
+}
+
+// =============================================================================
+// COST BUDGET
+// =============================================================================
+
+// handleCostBudget shows the cloud spend against each configured budget.
+func handleCostBudget(costArgs CostArgs) error {
+	cfg := config.Global()
+	budget := BudgetFromConfig(cfg)
+
+	tracker, err := telemetry.NewCostTracker("")
+	if err != nil {
+		return fmt.Errorf("failed to open cost history: %w", err)
+	}
+	status := tracker.BudgetStatus(budget)
+
+	report := BudgetReport{
+		Budgets:     make([]BudgetInfo, 0, len(status.Usage)),
+		WarnPercent: cfg.Budget.WarnPercent,
+		OnExceeded:  cfg.Budget.OnExceeded,
+	}
+	for _, usage := range status.Usage {
+		info := BudgetInfo{Period: usage.Period, LimitUSD: usage.Limit}
+		switch {
+		case usage.Period == telemetry.BudgetSession:
+			// A new tracker has no spend; the limit applies to each session
+			info.Status = "per_session"
+		case usage.Exceeded():
+			info.Status = "exceeded"
+		case status.WarnPercent > 0 && usage.Percent() >= status.WarnPercent:
+			info.Status = "warning"
+		default:
+			info.Status = "ok"
+		}
+		if info.Status != "per_session" {
+			since := usage.Since
+			info.Since = &since
+			info.SpentUSD = usage.Spent
+			info.Percent = usage.Percent()
+		}
+		report.Budgets = append(report.Budgets, info)
+	}
+
+	if costArgs.JSON {
+		return NewJSONResponse("cost budget", report).Print()
+	}
+
+	fmt.Println()
+	fmt.Println(costTitleStyle.Render("Cloud Spend Budgets"))
+	fmt.Println(separatorStyle.Render(strings.Repeat("=", 41)))
+
+	if budget.IsZero() {
+		fmt.Println(costDimStyle.Render("  No budgets set. Add a [budget] section to ~/.rigrun/config.toml,"))
+		fmt.Println(costDimStyle.Render("  e.g.: rigrun config set budget.daily_usd 5"))
+		fmt.Println()
+		return nil
+	}
+
+	for _, info := range report.Budgets {
+		printBudget(info)
+	}
+
+	fmt.Println()
+	if report.WarnPercent > 0 {
+		fmt.Printf("  %s%s\n", costLabelStyle.Render("Warn at:"),
+			costValueStyle.Render(fmt.Sprintf("%d%%", report.WarnPercent)))
+	}
+	fallback := "route to the local model"
+	if report.OnExceeded == router.BudgetActionPrompt {
+		fallback = "ask before using a paid tier (TUI)"
+	}
+	fmt.Printf("  %s%s\n", costLabelStyle.Render("Exceeded:"), costValueStyle.Render(fallback))
+	fmt.Println()
+
+	return nil
+}
+
+// printBudget prints the spend against one budget.
+func printBudget(info BudgetInfo) {
+	label := costLabelStyle.Render(strings.ToUpper(info.Period[:1]) + info.Period[1:] + ":")
+
+	if info.Status == "per_session" {
+		fmt.Printf("  %s%s  %s\n", label,
+			costValueStyle.Render(fmt.Sprintf("$%.2f", info.LimitUSD)),
+			costDimStyle.Render("per rigrun session"))
+		return
+	}
+
+	var state string
+	switch info.Status {
+	case "exceeded":
+		state = costErrorStyle.Render("EXCEEDED")
+	case "warning":
+		state = costWarningStyle.Render(fmt.Sprintf("%.0f%%", info.Percent))
+	default:
+		state = costSuccessStyle.Render(fmt.Sprintf("%.0f%%", info.Percent))
+	}
+	fmt.Printf("  %s%s  %s  %s\n", label,
+		costValueStyle.Render(fmt.Sprintf("$%.2f of $%.2f", info.SpentUSD, info.LimitUSD)),
+		state,
+		costDimStyle.Render("since "+info.Since.Format("2006-01-02")))
+}
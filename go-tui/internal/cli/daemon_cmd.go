@@ -0,0 +1,199 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// daemon_cmd.go - Background daemon for scheduled jobs.
+//
+// CLI: Comprehensive help and examples for all commands
+//
+// The daemon runs schedules (see schedule_cmd.go) as they come due. Each
+// run is a background task in ~/.rigrun/tasks.db, so "rigrun tasks" shows
+// its progress and output; its answer is saved as a conversation and the
+// run is recorded in the audit log. Runs missed while no daemon was
+// running follow the catch-up policy (scheduler.catch_up).
+//
+// The daemon also runs background tasks left queued by TUI sessions that
+// exited, and tasks retried with "rigrun tasks retry".
+//
+// Command: daemon
+// Short:   Run scheduled prompts and plans
+//
+// Examples:
+//   rigrun daemon                      Run until interrupted
+//   rigrun daemon --once               Run what is due now, then exit
+//
+// Flags:
+//   --once              Queue the runs due now, wait for them, and exit
+//                       (for cron or systemd timers)
+//
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/jeranaias/rigrun-tui/internal/config"
+	"github.com/jeranaias/rigrun-tui/internal/offline"
+	"github.com/jeranaias/rigrun-tui/internal/ollama"
+	"github.com/jeranaias/rigrun-tui/internal/plan"
+	"github.com/jeranaias/rigrun-tui/internal/schedule"
+	"github.com/jeranaias/rigrun-tui/internal/security"
+	"github.com/jeranaias/rigrun-tui/internal/storage"
+	"github.com/jeranaias/rigrun-tui/internal/tasks"
+)
+
+// =============================================================================
+// HANDLE DAEMON
+// =============================================================================
+
+// HandleDaemon runs the scheduler until interrupted (or, with --once, until
+// the runs due now have finished).
+func HandleDaemon(args Args) error {
+	once := false
+	for _, arg := range args.Raw {
+		switch arg {
+		case "--once":
+			once = true
+		default:
+			return fmt.Errorf("unknown daemon flag: %s\nUsage: rigrun daemon [--once]", arg)
+		}
+	}
+
+	cfg := config.Global()
+	jobs, state, err := loadSchedules()
+	if err != nil {
+		return err
+	}
+
+	// SC-7: Scheduled runs use the local model only
+	if err := offline.ValidateOllamaURL(cfg.Local.OllamaURL); err != nil {
+		return err
+	}
+	client := ollama.NewClientWithConfig(&ollama.ClientConfig{
+		BaseURL:      cfg.Local.OllamaURL,
+		DefaultModel: cfg.Local.OllamaModel,
+	})
+	registry, err := newAskToolRegistry(cfg, args)
+	if err != nil {
+		return err
+	}
+	planStore, err := plan.NewStore("")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not initialize plan storage: %v\n", err)
+		planStore = nil
+	}
+	conversations, err := storage.NewConversationStore()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not initialize session storage: %v\n", err)
+		conversations = nil
+	} else {
+		defer conversations.Close()
+	}
+
+	taskStore, err := tasks.OpenStore("")
+	if err != nil {
+		return fmt.Errorf("failed to open task store: %w", err)
+	}
+	queue := tasks.NewQueue(100)
+	runner := tasks.NewRunner(queue)
+
+	backend := &schedule.Backend{Client: client, Registry: registry, PlanStore: planStore}
+	scheduler := schedule.New(schedule.Config{
+		Jobs:          jobs,
+		State:         state,
+		Queue:         queue,
+		Work:          backend.Work,
+		Conversations: conversations,
+		MaxCatchUp:    cfg.Scheduler.MaxCatchUp,
+	})
+	queue.SetAgentFactory(func(task *tasks.Task) (tasks.AgentFunc, error) {
+		if work, ok, err := scheduler.Restore(task); ok {
+			return work, err
+		}
+		return backend.Restore(task)
+	})
+	if err := queue.AttachStore(taskStore); err != nil {
+		taskStore.Close()
+		return fmt.Errorf("failed to restore background tasks: %w", err)
+	}
+
+	// AU-2: Record when schedules can run
+	security.AuditLogEvent(auditSessionScheduler, "SCHEDULER_START", map[string]string{
+		"schedules": strconv.Itoa(len(jobs)),
+		"pid":       strconv.Itoa(os.Getpid()),
+	})
+	defer security.AuditLogEvent(auditSessionScheduler, "SCHEDULER_STOP", map[string]string{
+		"pid": strconv.Itoa(os.Getpid()),
+	})
+
+	runner.Start()
+	defer func() {
+		// Runs still going are recorded as interrupted
+		_ = queue.Shutdown()
+		runner.Stop()
+	}()
+	go printTaskNotifications(queue)
+
+	fmt.Printf("%s %d schedule(s)\n", scheduleSuccessStyle.Render("[DAEMON]"), len(jobs))
+	now := time.Now()
+	for _, job := range jobs {
+		if job.Disabled {
+			continue
+		}
+		fmt.Printf("  %s  %s\n", scheduleValueStyle.Render(job.Name),
+			scheduleDimStyle.Render("next "+job.Next(now).Format("2006-01-02 15:04 MST")))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if once {
+		scheduler.Tick(time.Now())
+		return waitForTasks(ctx, queue)
+	}
+	scheduler.Run(ctx)
+	fmt.Println(scheduleDimStyle.Render("Stopping..."))
+	return nil
+}
+
+// auditSessionScheduler identifies the daemon in the audit log.
+const auditSessionScheduler = "SCHEDULER"
+
+// printTaskNotifications prints task progress until the queue is shut down.
+func printTaskNotifications(queue *tasks.Queue) {
+	for notif := range queue.Notifications() {
+		if notif.Status == tasks.TaskStatusRunning {
+			continue
+		}
+		line := fmt.Sprintf("[%s] %s %s (%s)", notif.Status, shortTaskID(notif.TaskID), notif.Description,
+			formatDurationShort(notif.Duration))
+		if notif.Error != "" {
+			fmt.Println(scheduleErrorStyle.Render(line + ": " + notif.Error))
+		} else {
+			fmt.Println(line)
+		}
+	}
+}
+
+// waitForTasks waits until no task is queued or running.
+func waitForTasks(ctx context.Context, queue *tasks.Queue) error {
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		if len(queue.Queued()) == 0 && queue.RunningCount() == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
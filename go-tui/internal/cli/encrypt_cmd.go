@@ -14,6 +14,7 @@
 //   config              Encrypt config file sensitive fields
 //   cache               Encrypt cache database
 //   audit               Encrypt audit logs
+//   data                Encrypt stored conversations, costs, benchmarks, tasks, plans and codebase indexes
 //   status (default)    Show encryption status
 //   rotate              Rotate master key
 //
@@ -25,12 +26,18 @@
 //   rigrun encrypt config            Encrypt config sensitive fields
 //   rigrun encrypt cache             Encrypt response cache
 //   rigrun encrypt audit             Encrypt audit logs
+//   rigrun encrypt data              Encrypt user content stored in plaintext
 //   rigrun encrypt rotate            Rotate master encryption key
 //
 // Security Notes (SC-28):
 //   - Uses AES-256-GCM for data encryption
 //   - Master key stored in system keyring (when available)
 //   - Key rotation preserves data integrity
+//   - Conversations, cost history, benchmark results, background tasks,
+//     plans and codebase indexes are encrypted as they are written
+//     (security.encrypt_data)
+//   - Key rotation re-encrypts every index listed in ~/.rigrun/indexes.json
+//     and refuses to run if any store cannot be opened
 //   - All encryption operations are logged to audit
 //
 // Flags:
@@ -42,14 +49,22 @@ package cli
 import (
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"syscall"
 	"unicode"
 
 	"golang.org/x/term"
 
+	"github.com/jeranaias/rigrun-tui/internal/benchmark"
 	"github.com/jeranaias/rigrun-tui/internal/config"
+	"github.com/jeranaias/rigrun-tui/internal/index"
+	"github.com/jeranaias/rigrun-tui/internal/plan"
 	"github.com/jeranaias/rigrun-tui/internal/security"
+	"github.com/jeranaias/rigrun-tui/internal/storage"
+	"github.com/jeranaias/rigrun-tui/internal/tasks"
+	"github.com/jeranaias/rigrun-tui/internal/telemetry"
 )
 
 // =============================================================================
@@ -80,6 +95,8 @@ func HandleEncrypt(args Args) error {
 		return handleEncryptCache(args)
 	case "audit":
 		return handleEncryptAudit(args)
+	case "data":
+		return handleEncryptData(args)
 	case "status", "":
 		return handleEncryptStatus(args)
 	case "rotate":
@@ -89,7 +106,7 @@ func HandleEncrypt(args Args) error {
 	case "decrypt-cache":
 		return handleDecryptCache(args)
 	default:
-		return fmt.Errorf("unknown encrypt subcommand: %s\nUsage: rigrun encrypt [init|config|cache|audit|status|rotate]", subcommand)
+		return fmt.Errorf("unknown encrypt subcommand: %s\nUsage: rigrun encrypt [init|config|cache|audit|data|status|rotate]", subcommand)
 	}
 }
 
@@ -449,17 +466,171 @@ func handleEncryptAudit(args Args) error {
 	return nil
 }
 
+// =============================================================================
+// DATA COMMAND
+// =============================================================================
+
+// handleEncryptData encrypts the user content the data stores hold in
+// plaintext, written before encryption was enabled.
+func handleEncryptData(args Args) error {
+	em := security.GlobalEncryptionManager()
+
+	if !em.IsInitialized() {
+		return security.ErrNotInitialized
+	}
+
+	stores, closeStores, _ := openDataStores()
+	defer closeStores()
+	if err := em.EncryptExisting(stores...); err != nil {
+		return fmt.Errorf("failed to encrypt stored data: %w", err)
+	}
+
+	// Update config so that new data is encrypted too
+	cfg, _ := config.Load()
+	if cfg != nil && !cfg.Security.EncryptData {
+		cfg.Security.EncryptData = true
+		_ = config.Save(cfg)
+	}
+
+	var storeStatus []security.StoreStatus
+	for _, store := range stores {
+		if st, err := store.EncryptionStatus(); err == nil {
+			storeStatus = append(storeStatus, st)
+		}
+	}
+
+	if args.JSON {
+		resp := NewJSONResponse("encrypt_data", map[string]interface{}{
+			"status": "success",
+			"stores": storeStatus,
+		})
+		resp.Print()
+		return nil
+	}
+
+	fmt.Println("User data encryption complete!")
+	for _, st := range storeStatus {
+		fmt.Printf("  %-18s %s\n", st.Name+":", formatStoreStatus(st))
+	}
+	return nil
+}
+
+// openDataStores opens the stores of user content: conversations, cost
+// history, benchmark results, background tasks, plans and every codebase
+// index listed in the index registry, along with that of the current
+// directory if one has been built. A store that cannot be opened is left
+// out with a warning and counted in the returned skipped count. The
+// returned func closes the stores.
+func openDataStores() (stores []security.ProtectedStore, closeStores func(), skipped int) {
+	var closers []func() error
+	warn := func(name string, err error) {
+		fmt.Fprintf(os.Stderr, "Warning: could not open %s: %v\n", name, err)
+		skipped++
+	}
+
+	if conversations, err := storage.NewConversationStore(); err == nil {
+		stores = append(stores, conversations)
+		closers = append(closers, conversations.Close)
+	} else {
+		warn("conversations", err)
+	}
+	if costs, err := telemetry.NewCostStorage(""); err == nil {
+		stores = append(stores, costs)
+	} else {
+		warn("cost history", err)
+	}
+	if benchmarks, err := benchmark.NewStorage(); err == nil {
+		stores = append(stores, benchmarks)
+	} else {
+		warn("benchmark results", err)
+	}
+	if taskStore, err := tasks.OpenStore(""); err == nil {
+		stores = append(stores, taskStore)
+		closers = append(closers, taskStore.Close)
+	} else {
+		warn("background tasks", err)
+	}
+	if plans, err := plan.NewStore(""); err == nil {
+		stores = append(stores, plans)
+	} else {
+		warn("plans", err)
+	}
+
+	locations, err := index.KnownIndexes(index.DefaultRegistryPath())
+	if err != nil {
+		warn("index registry", err)
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		cfg := index.DefaultConfig(cwd)
+		if abs, err := filepath.Abs(cfg.DatabasePath); err == nil {
+			if _, err := os.Stat(abs); err == nil && !knownIndex(locations, abs) {
+				locations = append(locations, index.Location{Root: cwd, DatabasePath: abs})
+			}
+		}
+	}
+	for _, loc := range locations {
+		cfg := index.DefaultConfig(loc.Root)
+		cfg.DatabasePath = loc.DatabasePath
+		cfg.EnableWatch = false
+		if idx, err := index.NewCodebaseIndex(cfg); err == nil {
+			stores = append(stores, idx)
+			closers = append(closers, idx.Close)
+		} else {
+			warn("codebase index "+loc.DatabasePath, err)
+		}
+	}
+
+	return stores, func() {
+		for _, closeStore := range closers {
+			closeStore()
+		}
+	}, skipped
+}
+
+// knownIndex reports whether locations include the index at dbPath.
+func knownIndex(locations []index.Location, dbPath string) bool {
+	for _, loc := range locations {
+		if loc.DatabasePath == dbPath {
+			return true
+		}
+	}
+	return false
+}
+
 // =============================================================================
 // STATUS COMMAND
 // =============================================================================
 
+// encryptStatusReport is the JSON output of "rigrun encrypt status".
+type encryptStatusReport struct {
+	*security.EncryptionStatus
+	DataEncryption bool                   `json:"data_encryption"`
+	Stores         []security.StoreStatus `json:"stores"`
+}
+
 // handleEncryptStatus shows the current encryption status.
 func handleEncryptStatus(args Args) error {
 	em := security.GlobalEncryptionManager()
 	status := em.GetStatus()
 
+	stores, closeStores, _ := openDataStores()
+	defer closeStores()
+	var storeStatus []security.StoreStatus
+	for _, store := range stores {
+		st, err := store.EncryptionStatus()
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", st.Name, err)
+		}
+		storeStatus = append(storeStatus, st)
+	}
+	dataEncryption := security.DataCipher() != nil
+
 	if args.JSON {
-		resp := NewJSONResponse("encrypt_status", status)
+		resp := NewJSONResponse("encrypt_status", encryptStatusReport{
+			EncryptionStatus: status,
+			DataEncryption:   dataEncryption,
+			Stores:           storeStatus,
+		})
 		resp.Print()
 		return nil
 	}
@@ -488,6 +659,26 @@ func handleEncryptStatus(args Args) error {
 	printEncryptionStatus("  Audit Log", status.AuditEncrypted)
 	fmt.Println()
 
+	if dataEncryption {
+		fmt.Println("User Data:      encrypted as written")
+	} else {
+		fmt.Println("User Data:      NOT encrypted as written (security.encrypt_data = false)")
+	}
+	plaintext := false
+	for _, st := range storeStatus {
+		fmt.Printf("  %-18s %s\n", st.Name+":", formatStoreStatus(st))
+		fmt.Printf("  %-18s %s\n", "", st.Path)
+		plaintext = plaintext || !st.FullyEncrypted()
+	}
+	fmt.Println()
+
+	if plaintext {
+		fmt.Println("*** SC-28 WARNING ***")
+		fmt.Println("Some user data is stored in plaintext. To encrypt it:")
+		fmt.Println("  Run: rigrun encrypt data")
+		fmt.Println()
+	}
+
 	// IL5 compliance check - warn if cache is not encrypted
 	if !status.CacheEncrypted {
 		fmt.Println("*** IL5 COMPLIANCE WARNING ***")
@@ -499,6 +690,18 @@ func handleEncryptStatus(args Args) error {
 	return nil
 }
 
+// formatStoreStatus describes how much of a store is encrypted.
+func formatStoreStatus(st security.StoreStatus) string {
+	switch {
+	case st.Items == 0:
+		return "empty"
+	case st.FullyEncrypted():
+		return fmt.Sprintf("ENCRYPTED (%d values)", st.Items)
+	default:
+		return fmt.Sprintf("%d of %d values encrypted", st.Encrypted, st.Items)
+	}
+}
+
 func printEncryptionStatus(name string, encrypted bool) {
 	if encrypted {
 		fmt.Printf("%s:     ENCRYPTED\n", name)
@@ -554,8 +757,15 @@ func handleEncryptRotate(args Args) error {
 		}
 	}
 
-	// Step 2: Rotate key
-	if err := em.RotateKey(); err != nil {
+	// Step 2: Rotate key, re-encrypting the stores of user content. A
+	// store left out would stay encrypted under the discarded key, so
+	// every store must open.
+	stores, closeStores, skipped := openDataStores()
+	defer closeStores()
+	if skipped > 0 {
+		return fmt.Errorf("cannot rotate key: %d data store(s) could not be opened", skipped)
+	}
+	if err := em.RotateKey(stores...); err != nil {
 		return fmt.Errorf("failed to rotate key: %w", err)
 	}
 
@@ -585,6 +795,7 @@ func handleEncryptRotate(args Args) error {
 			"status":       "success",
 			"config_updated": decryptedKey != "",
 			"cache_updated":  status.CacheEncrypted,
+			"stores_updated": len(stores),
 		})
 		resp.Print()
 		return nil
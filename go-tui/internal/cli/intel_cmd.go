@@ -61,6 +61,7 @@ import (
 
 	"github.com/jeranaias/rigrun-tui/internal/cloud"
 	"github.com/jeranaias/rigrun-tui/internal/config"
+	"github.com/jeranaias/rigrun-tui/internal/router"
 	"github.com/jeranaias/rigrun-tui/internal/security"
 	"github.com/jeranaias/rigrun-tui/internal/tools"
 )
@@ -379,7 +380,10 @@ func executeIntelResearch(ctx context.Context, cfg *config.Config, opts *IntelOp
 	}
 
 	// Create tool registry and executor
-	registry := tools.NewRegistry()
+	registry, err := tools.NewConfiguredRegistry(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("tool setup: %w", err)
+	}
 	executor := tools.NewExecutor(registry)
 
 	// Get modules based on depth
@@ -629,8 +633,19 @@ Write in a professional, analytical tone. Only include information that is suppo
 		return nil, fmt.Errorf("synthesis requires cloud API, not available for %s classification", opts.Classification)
 	}
 
+	// Synthesis only runs in the cloud, so a spent budget skips it (the raw
+	// data is reported instead)
+	budget := newBudgetGuard(cfg)
+	decision := router.ApplyBudget(router.RoutingDecision{Tier: router.TierCloud, Reason: "intel synthesis"},
+		budget, router.BudgetActionLocal)
+	if decision.Tier.IsLocal() {
+		return nil, fmt.Errorf("synthesis skipped: %s", decision.Reason)
+	}
+
 	// Create OpenRouter client
 	client := cloud.NewOpenRouterClient(cfg.Cloud.OpenRouterKey)
+	// SC-7(10): Scan outbound prompts for credentials
+	guardCloudClient(client, cfg)
 
 	// Use user-specified model or OpenRouter auto-routing (lets OpenRouter pick optimal model)
 	model := "openrouter/auto"
@@ -644,22 +659,18 @@ Write in a professional, analytical tone. Only include information that is suppo
 		cloud.NewUserMessage(prompt),
 	}
 
+	callStart := time.Now()
 	resp, err := client.Chat(ctx, messages)
 	if err != nil {
 		return nil, fmt.Errorf("LLM synthesis failed: %w", err)
 	}
 
-	// Calculate cost based on model
-	// Free models (ending in ":free") have zero cost
+	// Free models (ending in ":free") have zero cost; the rest count toward
+	// the spend budgets
 	var totalCost float64
-	if strings.HasSuffix(model, ":free") {
-		totalCost = 0.0
-	} else {
-		// Estimate cost based on typical OpenRouter auto pricing (~$3/MTok input, $15/MTok output)
-		// This is approximate - actual cost depends on which model OpenRouter selects
-		inputCost := float64(resp.Usage.PromptTokens) / 1000000.0 * 3.0
-		outputCost := float64(resp.Usage.CompletionTokens) / 1000000.0 * 15.0
-		totalCost = inputCost + outputCost
+	if !strings.HasSuffix(model, ":free") {
+		totalCost = recordCloudSpend(budget, router.TierCloud, resp.Usage.PromptTokens, resp.Usage.CompletionTokens,
+			time.Since(callStart), "intel synthesis: "+opts.Company)
 	}
 
 	return &SynthesisResult{
@@ -1121,6 +1132,43 @@ func getIntelCacheDir() string {
 	return filepath.Join(home, ".rigrun", "intel", "cache")
 }
 
+// intelCache is the report cache as a store kept under the intel retention
+// policy (SI-12).
+type intelCache struct {
+	dir string
+}
+
+// RetentionClass returns the data class of cached reports.
+func (c intelCache) RetentionClass() string {
+	return security.RetentionIntel
+}
+
+// RetentionItems lists the cached reports with their classification.
+func (c intelCache) RetentionItems() ([]security.RetentionItem, error) {
+	items, err := security.RetentionFiles(c.dir, func(name string) bool {
+		return strings.HasSuffix(name, ".json")
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		var report struct {
+			Classification string `json:"classification"`
+		}
+		if data, err := os.ReadFile(filepath.Join(c.dir, items[i].ID)); err == nil {
+			if json.Unmarshal(data, &report) == nil {
+				items[i].Classification = report.Classification
+			}
+		}
+	}
+	return items, nil
+}
+
+// Purge securely deletes cached reports.
+func (c intelCache) Purge(items []security.RetentionItem) error {
+	return security.PurgeFiles(c.dir, items)
+}
+
 // sanitizeFilename sanitizes a string for use as a filename.
 func sanitizeFilename(s string) string {
 	s = strings.ToLower(s)
@@ -202,6 +202,7 @@ type ConfigData struct {
 	Security ConfigSecurityInfo `json:"security"`
 	Cache    ConfigCacheInfo    `json:"cache"`
 	Path     string             `json:"config_path"`
+	Policy   *ConfigPolicyInfo  `json:"policy,omitempty"`
 }
 
 // ConfigGeneralInfo contains general configuration.
@@ -241,6 +242,15 @@ type ConfigCacheInfo struct {
 	TTLHours int  `json:"ttl_hours"`
 }
 
+// ConfigPolicyInfo describes the administrator policy in effect (CM-5).
+type ConfigPolicyInfo struct {
+	Path       string   `json:"path"`
+	Issuer     string   `json:"issuer,omitempty"`
+	Valid      bool     `json:"valid"`
+	Error      string   `json:"error,omitempty"`
+	LockedKeys []string `json:"locked_keys"`
+}
+
 // CacheStatsData represents the data returned by the cache stats command.
 type CacheStatsData struct {
 	ExactCache    CacheTypeStats `json:"exact_cache"`
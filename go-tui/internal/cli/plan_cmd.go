@@ -0,0 +1,624 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// plan_cmd.go - Saved plan CLI commands for rigrun.
+//
+// CLI: Comprehensive help and examples for all commands
+//
+// Plans created with /plan are saved to ~/.rigrun/plans/<id>.json after
+// every step transition, so a plan interrupted by a crash or quit can be
+// inspected and resumed from the command line.
+//
+// Command: plan [subcommand]
+// Short:   List, resume or cancel saved plans
+// Aliases: plans
+//
+// Subcommands:
+//   list (default)      List saved plans, most recent first
+//   show <id>           Show a plan's steps and results
+//   resume <id>         Resume an interrupted plan
+//   cancel <id>         Cancel a plan so it is no longer offered for resume
+//
+// Examples:
+//   rigrun plan                        List saved plans
+//   rigrun plan show 3f2a              Show plan (ID prefix accepted)
+//   rigrun plan resume 3f2a            Resume, asking first if files changed
+//   rigrun plan resume 3f2a --confirm  Resume even if files changed
+//   rigrun plan cancel 3f2a --json     Cancel, JSON output
+//
+// Resume re-checks the files touched by completed steps. If any changed
+// since the step completed, they are listed and resuming needs confirmation,
+// since the remaining steps may rely on the earlier results.
+//
+// With plan.max_replans set, a failed plan is revised by the local model;
+// each revision is shown as a diff of the remaining steps for approval
+// (--confirm approves) and kept in the plan's history (see "plan show").
+//
+// Flags:
+//   --confirm           Resume without asking when files changed, and
+//                       approve re-plans
+//   --json              Output in JSON format
+//
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/jeranaias/rigrun-tui/internal/config"
+	"github.com/jeranaias/rigrun-tui/internal/offline"
+	"github.com/jeranaias/rigrun-tui/internal/ollama"
+	"github.com/jeranaias/rigrun-tui/internal/plan"
+	"github.com/jeranaias/rigrun-tui/internal/tools"
+)
+
+// =============================================================================
+// PLAN COMMAND STYLES
+// =============================================================================
+
+var (
+	// Plan title style
+	planTitleStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("39")). // Cyan
+			MarginBottom(1)
+
+	// Plan section style
+	planSectionStyle = lipgloss.NewStyle().
+				Bold(true).
+				Foreground(lipgloss.Color("255")). // White
+				MarginTop(1)
+
+	// Plan label style
+	planLabelStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("245")). // Light gray
+			Width(14)
+
+	// Plan value style
+	planValueStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("255")) // White
+
+	// Plan success style
+	planSuccessStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("82")).
+				Bold(true)
+
+	// Plan warning style
+	planWarningStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("220")).
+				Bold(true)
+
+	// Plan error style
+	planErrorStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("196")).
+			Bold(true)
+
+	// Plan dim style
+	planDimStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("242"))
+)
+
+// =============================================================================
+// PLAN ARGUMENTS
+// =============================================================================
+
+// PlanArgs holds parsed plan command arguments.
+type PlanArgs struct {
+	Subcommand string
+	PlanID     string
+	Confirm    bool
+	JSON       bool
+}
+
+// parsePlanArgs parses plan command specific arguments.
+func parsePlanArgs(args *Args, remaining []string) PlanArgs {
+	planArgs := PlanArgs{
+		JSON: args.JSON,
+	}
+
+	if len(remaining) > 0 {
+		planArgs.Subcommand = remaining[0]
+		remaining = remaining[1:]
+	}
+
+	for _, arg := range remaining {
+		switch arg {
+		case "--json":
+			planArgs.JSON = true
+		case "--confirm", "-y", "--yes":
+			planArgs.Confirm = true
+		default:
+			if !strings.HasPrefix(arg, "-") && planArgs.PlanID == "" {
+				planArgs.PlanID = arg
+			}
+		}
+	}
+
+	return planArgs
+}
+
+// =============================================================================
+// PLAN JSON TYPES
+// =============================================================================
+
+// PlanSummary is the JSON form of a saved plan in listings.
+type PlanSummary struct {
+	ID          string    `json:"id"`
+	Description string    `json:"description"`
+	Status      string    `json:"status"`
+	Progress    string    `json:"progress"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// PlanStepInfo is the JSON form of a plan step.
+type PlanStepInfo struct {
+	ID           string   `json:"id"`
+	Description  string   `json:"description"`
+	Status       string   `json:"status"`
+	Dependencies []string `json:"dependencies,omitempty"`
+	Result       string   `json:"result,omitempty"`
+	Error        string   `json:"error,omitempty"`
+	DurationMs   int64    `json:"duration_ms,omitempty"`
+}
+
+// PlanRevisionInfo is the JSON form of a plan revision.
+type PlanRevisionInfo struct {
+	Number     int            `json:"number"`
+	FailedStep string         `json:"failed_step"`
+	Reason     string         `json:"reason,omitempty"`
+	Status     string         `json:"status"`
+	Error      string         `json:"error,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+	OldSteps   []PlanStepInfo `json:"old_steps"`
+	NewSteps   []PlanStepInfo `json:"new_steps,omitempty"`
+}
+
+// PlanDetail is the JSON form of a saved plan.
+type PlanDetail struct {
+	PlanSummary
+	Task         string             `json:"task,omitempty"`
+	WorkDir      string             `json:"work_dir,omitempty"`
+	Error        string             `json:"error,omitempty"`
+	Steps        []PlanStepInfo     `json:"steps"`
+	Revisions    []PlanRevisionInfo `json:"revisions,omitempty"`
+	ChangedFiles []plan.FileChange  `json:"changed_files,omitempty"`
+}
+
+// newPlanSummary converts a plan to its listing form.
+func newPlanSummary(p *plan.Plan) PlanSummary {
+	return PlanSummary{
+		ID:          p.ID,
+		Description: p.Description,
+		Status:      p.Status.String(),
+		Progress:    p.Progress(),
+		UpdatedAt:   p.UpdatedAt,
+	}
+}
+
+// newPlanDetail converts a plan to its detailed form.
+func newPlanDetail(p *plan.Plan, changes []plan.FileChange) PlanDetail {
+	detail := PlanDetail{
+		PlanSummary:  newPlanSummary(p),
+		Task:         p.OriginalTask,
+		WorkDir:      p.WorkDir,
+		Steps:        newPlanStepInfos(p.Steps),
+		ChangedFiles: changes,
+	}
+	if p.Error != nil {
+		detail.Error = p.Error.Error()
+	}
+	for _, rev := range p.Revisions {
+		detail.Revisions = append(detail.Revisions, PlanRevisionInfo{
+			Number:     rev.Number,
+			FailedStep: rev.FailedStep,
+			Reason:     rev.Reason,
+			Status:     rev.Status.String(),
+			Error:      rev.Error,
+			CreatedAt:  rev.CreatedAt,
+			OldSteps:   newPlanStepInfos(rev.OldSteps),
+			NewSteps:   newPlanStepInfos(rev.NewSteps),
+		})
+	}
+	return detail
+}
+
+// newPlanStepInfos converts steps to their JSON form.
+func newPlanStepInfos(steps []plan.PlanStep) []PlanStepInfo {
+	infos := make([]PlanStepInfo, 0, len(steps))
+	for i := range steps {
+		step := &steps[i]
+		info := PlanStepInfo{
+			ID:           step.ID,
+			Description:  step.Description,
+			Status:       step.Status.String(),
+			Dependencies: step.Dependencies,
+			Result:       step.Result,
+			DurationMs:   step.Duration().Milliseconds(),
+		}
+		if step.Error != nil {
+			info.Error = step.Error.Error()
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// =============================================================================
+// HANDLE PLAN
+// =============================================================================
+
+// HandlePlan handles the "plan" command with various subcommands.
+// Subcommands:
+//   - plan list: List saved plans
+//   - plan show <id>: Show a plan's steps
+//   - plan resume <id> [--confirm]: Resume an interrupted plan
+//   - plan cancel <id>: Cancel a plan
+func HandlePlan(args Args) error {
+	planArgs := parsePlanArgs(&args, args.Raw)
+
+	store, err := plan.NewStore("")
+	if err != nil {
+		return fmt.Errorf("failed to open plan store: %w", err)
+	}
+
+	switch planArgs.Subcommand {
+	case "", "list", "ls":
+		return handlePlanList(store, planArgs)
+	case "show":
+		return handlePlanShow(store, planArgs)
+	case "resume":
+		return handlePlanResume(store, planArgs, args)
+	case "cancel":
+		return handlePlanCancel(store, planArgs)
+	default:
+		return fmt.Errorf("unknown plan subcommand: %s\n\nUsage:\n"+
+			"  rigrun plan list                    List saved plans\n"+
+			"  rigrun plan show <id>               Show a plan's steps\n"+
+			"  rigrun plan resume <id> [--confirm] Resume an interrupted plan\n"+
+			"  rigrun plan cancel <id>             Cancel a plan", planArgs.Subcommand)
+	}
+}
+
+// loadPlanArg loads the plan named on the command line.
+func loadPlanArg(store *plan.Store, planArgs PlanArgs) (*plan.Plan, error) {
+	if planArgs.PlanID == "" {
+		return nil, fmt.Errorf("plan ID required\nUsage: rigrun plan %s <id>", planArgs.Subcommand)
+	}
+	return store.Load(planArgs.PlanID)
+}
+
+// =============================================================================
+// PLAN LIST
+// =============================================================================
+
+// handlePlanList lists the saved plans.
+func handlePlanList(store *plan.Store, planArgs PlanArgs) error {
+	plans, err := store.List()
+	if err != nil {
+		return fmt.Errorf("failed to list plans: %w", err)
+	}
+
+	if planArgs.JSON {
+		summaries := make([]PlanSummary, 0, len(plans))
+		for _, p := range plans {
+			summaries = append(summaries, newPlanSummary(p))
+		}
+		return NewJSONResponse("plan list", summaries).Print()
+	}
+
+	fmt.Println()
+	fmt.Println(planTitleStyle.Render("Saved Plans"))
+	fmt.Println(separatorStyle.Render(strings.Repeat("=", 41)))
+
+	if len(plans) == 0 {
+		fmt.Println(planDimStyle.Render("  No saved plans. Create one with /plan <task> in the TUI."))
+		fmt.Println()
+		return nil
+	}
+
+	for _, p := range plans {
+		fmt.Printf("  %s  %s  %s  %s\n",
+			planValueStyle.Render(shortPlanID(p.ID)),
+			renderPlanStatus(p.Status),
+			planDimStyle.Render(fmt.Sprintf("%-5s %s", p.Progress(), formatTimeAgo(p.UpdatedAt))),
+			truncateString(p.Description, 50))
+	}
+	fmt.Println()
+	fmt.Println(planDimStyle.Render("  Resume with: rigrun plan resume <id>"))
+	fmt.Println()
+
+	return nil
+}
+
+// =============================================================================
+// PLAN SHOW
+// =============================================================================
+
+// handlePlanShow shows a plan's steps and the files changed since they ran.
+func handlePlanShow(store *plan.Store, planArgs PlanArgs) error {
+	p, err := loadPlanArg(store, planArgs)
+	if err != nil {
+		return err
+	}
+	changes := p.ChangedFiles()
+
+	if planArgs.JSON {
+		return NewJSONResponse("plan show", newPlanDetail(p, changes)).Print()
+	}
+
+	fmt.Println()
+	fmt.Println(planTitleStyle.Render("Plan " + shortPlanID(p.ID)))
+	fmt.Println(separatorStyle.Render(strings.Repeat("=", 41)))
+	printPlanDetail(p)
+	printPlanRevisions(p)
+	printFileChanges(changes)
+	fmt.Println()
+
+	return nil
+}
+
+// printPlanRevisions prints the plan's re-plan history with the diff of
+// each revision.
+func printPlanRevisions(p *plan.Plan) {
+	if len(p.Revisions) == 0 {
+		return
+	}
+	fmt.Println(planSectionStyle.Render("Revisions"))
+	for i := range p.Revisions {
+		rev := &p.Revisions[i]
+		fmt.Printf("  %d. %s after %s failed %s\n", rev.Number, rev.Status, rev.FailedStep,
+			planDimStyle.Render(rev.CreatedAt.Format("2006-01-02 15:04:05")))
+		if rev.Reason != "" {
+			fmt.Printf("     %s\n", planErrorStyle.Render(truncateString(rev.Reason, 100)))
+		}
+		if rev.Error != "" {
+			fmt.Printf("     %s\n", planErrorStyle.Render(truncateString(rev.Error, 100)))
+			continue
+		}
+		printRevisionDiff(rev)
+	}
+}
+
+// printRevisionDiff prints a revision's diff of the remaining steps.
+func printRevisionDiff(rev *plan.Revision) {
+	for _, line := range strings.Split(strings.TrimSuffix(rev.Diff(), "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "+"):
+			fmt.Printf("     %s\n", planSuccessStyle.Render(line))
+		case strings.HasPrefix(line, "-"):
+			fmt.Printf("     %s\n", planErrorStyle.Render(line))
+		default:
+			fmt.Printf("     %s\n", planDimStyle.Render(line))
+		}
+	}
+}
+
+// printPlanDetail prints a plan's header and steps.
+func printPlanDetail(p *plan.Plan) {
+	fmt.Printf("  %s%s\n", planLabelStyle.Render("ID:"), planValueStyle.Render(p.ID))
+	fmt.Printf("  %s%s\n", planLabelStyle.Render("Description:"), planValueStyle.Render(p.Description))
+	if p.OriginalTask != "" && p.OriginalTask != p.Description {
+		fmt.Printf("  %s%s\n", planLabelStyle.Render("Task:"), planValueStyle.Render(p.OriginalTask))
+	}
+	fmt.Printf("  %s%s\n", planLabelStyle.Render("Status:"), renderPlanStatus(p.Status))
+	fmt.Printf("  %s%s\n", planLabelStyle.Render("Progress:"), planValueStyle.Render(p.Progress()))
+	if p.WorkDir != "" {
+		fmt.Printf("  %s%s\n", planLabelStyle.Render("Directory:"), planValueStyle.Render(p.WorkDir))
+	}
+	if !p.UpdatedAt.IsZero() {
+		fmt.Printf("  %s%s\n", planLabelStyle.Render("Updated:"), planValueStyle.Render(p.UpdatedAt.Format("2006-01-02 15:04:05")))
+	}
+	if p.Error != nil {
+		fmt.Printf("  %s%s\n", planLabelStyle.Render("Error:"), planErrorStyle.Render(p.Error.Error()))
+	}
+
+	fmt.Println(planSectionStyle.Render("Steps"))
+	for i := range p.Steps {
+		step := &p.Steps[i]
+		line := fmt.Sprintf("  %s %d. %s", stepStatusIcon(step.Status), i+1, step.Description)
+		if len(step.Dependencies) > 0 {
+			line += planDimStyle.Render(" <- " + strings.Join(step.Dependencies, ", "))
+		}
+		if step.IsComplete() && !step.StartTime.IsZero() {
+			line += planDimStyle.Render(" (" + formatDurationShort(step.Duration()) + ")")
+		}
+		fmt.Println(line)
+		if step.Error != nil {
+			fmt.Printf("       %s\n", planErrorStyle.Render(truncateString(step.Error.Error(), 100)))
+		}
+	}
+}
+
+// printFileChanges warns about files changed since the steps that touched
+// them completed.
+func printFileChanges(changes []plan.FileChange) {
+	if len(changes) == 0 {
+		return
+	}
+	fmt.Println(planSectionStyle.Render("Changed Since Step Completed"))
+	for _, change := range changes {
+		fmt.Printf("  %s %s %s\n",
+			planWarningStyle.Render("[!]"),
+			change.Path,
+			planDimStyle.Render(fmt.Sprintf("(%s, %s)", change.Reason, change.StepID)))
+	}
+}
+
+// stepStatusIcon returns the list marker for a step status.
+func stepStatusIcon(status plan.StepStatus) string {
+	switch status {
+	case plan.StepComplete:
+		return planSuccessStyle.Render("[x]")
+	case plan.StepRunning:
+		return planWarningStyle.Render("[>]")
+	case plan.StepFailed:
+		return planErrorStyle.Render("[!]")
+	case plan.StepSkipped:
+		return planDimStyle.Render("[-]")
+	default:
+		return planDimStyle.Render("[ ]")
+	}
+}
+
+// renderPlanStatus renders a plan status, padded for listings.
+func renderPlanStatus(status plan.PlanStatus) string {
+	label := fmt.Sprintf("%-9s", status)
+	switch status {
+	case plan.StatusComplete:
+		return planSuccessStyle.Render(label)
+	case plan.StatusRunning, plan.StatusPaused:
+		return planWarningStyle.Render(label)
+	case plan.StatusFailed:
+		return planErrorStyle.Render(label)
+	default:
+		return planDimStyle.Render(label)
+	}
+}
+
+// shortPlanID shortens a plan ID for display; Load accepts the prefix.
+func shortPlanID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// =============================================================================
+// PLAN RESUME
+// =============================================================================
+
+// handlePlanResume resumes an interrupted plan in its working directory.
+// Interrupting the resumed run (Ctrl+C) pauses the plan again.
+func handlePlanResume(store *plan.Store, planArgs PlanArgs, args Args) error {
+	p, err := loadPlanArg(store, planArgs)
+	if err != nil {
+		return err
+	}
+
+	p.PrepareResume()
+	if !p.CanExecute() {
+		return fmt.Errorf("plan %s cannot be resumed from status: %s", shortPlanID(p.ID), p.Status)
+	}
+
+	if changes := p.ChangedFiles(); len(changes) > 0 {
+		if !planArgs.JSON {
+			fmt.Println()
+			printFileChanges(changes)
+			fmt.Println()
+		}
+		confirmed, err := RequireConfirmationWithOpts(
+			fmt.Sprintf("resume plan %s although %d file(s) changed", shortPlanID(p.ID), len(changes)),
+			ConfirmationOptions{ConfirmFlag: planArgs.Confirm, JSONMode: planArgs.JSON})
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return nil
+		}
+	}
+
+	registry, err := newAskToolRegistry(config.Global(), args)
+	if err != nil {
+		return err
+	}
+	toolExecutor := tools.NewExecutor(registry)
+	if p.WorkDir != "" {
+		toolExecutor.SetWorkDir(p.WorkDir)
+	}
+
+	executor := plan.NewPlanExecutor(p, toolExecutor)
+	executor.SetStore(store)
+	if cfg := config.Global(); cfg != nil && cfg.Plan.MaxReplans > 0 {
+		// SC-7: Revisions come from the local model only
+		if err := offline.ValidateOllamaURL(cfg.Local.OllamaURL); err != nil {
+			return err
+		}
+		client := ollama.NewClientWithConfig(&ollama.ClientConfig{
+			BaseURL:      cfg.Local.OllamaURL,
+			DefaultModel: cfg.Local.OllamaModel,
+		})
+		executor.SetReplanner(plan.NewGenerator(plan.NewOllamaLLM(client, "")), cfg.Plan.MaxReplans,
+			func(ctx context.Context, rev *plan.Revision) bool {
+				return approvePlanRevision(rev, planArgs)
+			})
+	}
+	if !planArgs.JSON {
+		fmt.Printf("%s Resuming plan %s: %s\n",
+			planValueStyle.Render("[PLAN]"), shortPlanID(p.ID), p.Description)
+		executor.SetProgressCallback(func(step, total int, status string) {
+			fmt.Printf("  %s\n", planDimStyle.Render(status))
+		})
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	execErr := executor.Execute(ctx)
+
+	if planArgs.JSON {
+		detail := newPlanDetail(p, nil)
+		resp := NewJSONResponse("plan resume", detail)
+		if execErr != nil {
+			errStr := execErr.Error()
+			resp.Success = false
+			resp.Error = &errStr
+		}
+		return resp.Print()
+	}
+
+	fmt.Println()
+	printPlanDetail(p)
+	fmt.Println()
+	return execErr
+}
+
+// approvePlanRevision shows a revision of a failed plan and asks whether to
+// continue with it. --confirm approves without asking.
+func approvePlanRevision(rev *plan.Revision, planArgs PlanArgs) bool {
+	if !planArgs.JSON {
+		fmt.Printf("\n%s Revision %d after %s failed: %s\n",
+			planWarningStyle.Render("[REPLAN]"), rev.Number, rev.FailedStep, rev.Reason)
+		printRevisionDiff(rev)
+		fmt.Println()
+	}
+	confirmed, err := RequireConfirmationWithOpts("continue with the revised steps",
+		ConfirmationOptions{ConfirmFlag: planArgs.Confirm, JSONMode: planArgs.JSON})
+	return err == nil && confirmed
+}
+
+// =============================================================================
+// PLAN CANCEL
+// =============================================================================
+
+// handlePlanCancel cancels a saved plan. The plan stays on disk for
+// reference but is no longer offered for resume.
+func handlePlanCancel(store *plan.Store, planArgs PlanArgs) error {
+	p, err := loadPlanArg(store, planArgs)
+	if err != nil {
+		return err
+	}
+	if p.IsComplete() {
+		return fmt.Errorf("plan %s already finished: %s", shortPlanID(p.ID), p.Status)
+	}
+
+	p.PrepareResume()
+	p.Cancel()
+	if err := store.Save(p); err != nil {
+		return fmt.Errorf("failed to save plan: %w", err)
+	}
+
+	if planArgs.JSON {
+		return NewJSONResponse("plan cancel", newPlanSummary(p)).Print()
+	}
+
+	fmt.Printf("%s Plan %s cancelled\n", planSuccessStyle.Render("[OK]"), shortPlanID(p.ID))
+	return nil
+}
@@ -0,0 +1,440 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// policy_cmd.go - Signed policy bundle CLI commands for rigrun.
+//
+// CLI: Comprehensive help and examples for all commands
+//
+// Implements NIST 800-53 CM-5 (Access Restrictions for Change) and
+// CM-6 (Configuration Settings) for centrally managed deployments.
+//
+// Command: policy [subcommand]
+// Short:   Signed administrator policy bundles (IL5 CM-5)
+// Aliases: (none)
+//
+// Subcommands:
+//   show (default)      Show the installed policy and locked settings
+//   sign <file>         Sign a TOML/JSON policy document into a bundle
+//   verify [bundle]     Verify a bundle against the trusted public key
+//
+// Examples:
+//   rigrun policy                                     Show installed policy
+//   rigrun policy show --json                         Policy in JSON format
+//   rigrun policy sign policy.toml --key admin.pem    Print bundle to stdout
+//   rigrun policy sign policy.toml --key admin.pem --output policy.bundle
+//   rigrun policy verify policy.bundle --pubkey admin.pub
+//   rigrun policy verify                              Verify installed bundle
+//
+// Keys:
+//   Ed25519 keys in PEM form (e.g. from "openssl genpkey -algorithm ed25519")
+//   or base64. Install the public key at /etc/rigrun/policy.pub and the bundle
+//   at /etc/rigrun/policy.bundle (%ProgramData%\rigrun on Windows).
+//
+// Flags:
+//   --key FILE          Private signing key (sign)
+//   --pubkey FILE       Public key to verify against (verify)
+//   --output FILE       Write bundle to FILE instead of stdout (sign)
+//   --json              Output in JSON format
+//
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package cli
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/jeranaias/rigrun-tui/internal/config"
+	"github.com/jeranaias/rigrun-tui/internal/security"
+	"github.com/jeranaias/rigrun-tui/internal/util"
+)
+
+// =============================================================================
+// POLICY COMMAND STYLES
+// =============================================================================
+
+var (
+	// Policy title style
+	policyTitleStyle = lipgloss.NewStyle().
+				Bold(true).
+				Foreground(lipgloss.Color("39")). // Cyan
+				MarginBottom(1)
+
+	// Policy section style
+	policySectionStyle = lipgloss.NewStyle().
+				Bold(true).
+				Foreground(lipgloss.Color("255")). // White
+				MarginTop(1)
+
+	// Policy label style
+	policyLabelStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("245")). // Light gray
+				Width(20)
+
+	// Policy value style
+	policyValueStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("255")) // White
+
+	// Policy success style
+	policySuccessStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("82")).
+				Bold(true)
+
+	// Policy error style
+	policyErrorStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("196")).
+				Bold(true)
+
+	// Policy dim style
+	policyDimStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("242"))
+)
+
+// =============================================================================
+// POLICY ARGUMENTS
+// =============================================================================
+
+// PolicyArgs holds parsed policy command arguments.
+type PolicyArgs struct {
+	Subcommand string
+	File       string
+	KeyFile    string
+	PubKeyFile string
+	Output     string
+	JSON       bool
+}
+
+// parsePolicyArgs parses policy command specific arguments.
+func parsePolicyArgs(args *Args, remaining []string) PolicyArgs {
+	policyArgs := PolicyArgs{
+		JSON: args.JSON,
+	}
+
+	if len(remaining) > 0 {
+		policyArgs.Subcommand = remaining[0]
+		remaining = remaining[1:]
+	}
+
+	for i := 0; i < len(remaining); i++ {
+		arg := remaining[i]
+
+		switch arg {
+		case "--json":
+			policyArgs.JSON = true
+		case "--key", "-k":
+			if i+1 < len(remaining) {
+				i++
+				policyArgs.KeyFile = remaining[i]
+			}
+		case "--pubkey", "--public-key":
+			if i+1 < len(remaining) {
+				i++
+				policyArgs.PubKeyFile = remaining[i]
+			}
+		case "--output", "-o":
+			if i+1 < len(remaining) {
+				i++
+				policyArgs.Output = remaining[i]
+			}
+		default:
+			if strings.HasPrefix(arg, "--key=") {
+				policyArgs.KeyFile = strings.TrimPrefix(arg, "--key=")
+			} else if strings.HasPrefix(arg, "--pubkey=") {
+				policyArgs.PubKeyFile = strings.TrimPrefix(arg, "--pubkey=")
+			} else if strings.HasPrefix(arg, "--output=") {
+				policyArgs.Output = strings.TrimPrefix(arg, "--output=")
+			} else if !strings.HasPrefix(arg, "-") && policyArgs.File == "" {
+				policyArgs.File = arg
+			}
+		}
+	}
+
+	return policyArgs
+}
+
+// =============================================================================
+// HANDLE POLICY
+// =============================================================================
+
+// HandlePolicy handles the "policy" command with various subcommands.
+// Subcommands:
+//   - policy show: Show the installed policy and locked settings
+//   - policy sign <file> --key <key>: Sign a policy document
+//   - policy verify [bundle]: Verify a policy bundle
+func HandlePolicy(args Args) error {
+	policyArgs := parsePolicyArgs(&args, args.Raw)
+
+	switch policyArgs.Subcommand {
+	case "", "show", "status":
+		return handlePolicyShow(policyArgs)
+	case "sign":
+		return handlePolicySign(policyArgs)
+	case "verify":
+		return handlePolicyVerify(policyArgs)
+	default:
+		return fmt.Errorf("unknown policy subcommand: %s\n\nUsage:\n"+
+			"  rigrun policy show                         Show installed policy and locked settings\n"+
+			"  rigrun policy sign <file> --key <key>      Sign a policy document into a bundle\n"+
+			"  rigrun policy verify [bundle] [--pubkey <key>]  Verify a policy bundle", policyArgs.Subcommand)
+	}
+}
+
+// =============================================================================
+// POLICY ENFORCEMENT
+// =============================================================================
+
+// EnforcePolicyBoundary applies the network allow-list pinned by the
+// administrator policy (SC-7), replacing the default boundary policy and
+// enforcing it at the HTTP transport. No-op when the policy sets no allow-list.
+func EnforcePolicyBoundary(cfg *config.Config) {
+	if cfg == nil {
+		return
+	}
+	allowlist := cfg.PolicyNetworkAllowlist()
+	if len(allowlist) == 0 {
+		return
+	}
+
+	policy := security.DefaultNetworkPolicy()
+	// Localhost stays reachable for Ollama
+	policy.AllowedHosts = append([]string{"localhost", "127.0.0.1", "::1"}, allowlist...)
+
+	bp := security.GlobalBoundaryProtection()
+	bp.SetNetworkPolicy(policy)
+	bp.EnforceEgress(true)
+	bp.EnforceTransport()
+}
+
+// =============================================================================
+// POLICY SHOW
+// =============================================================================
+
+// handlePolicyShow shows the policy in effect and the settings it locks.
+func handlePolicyShow(policyArgs PolicyArgs) error {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", err)
+	}
+	if cfg == nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	if policyArgs.JSON {
+		data := ConfigPolicyInfo{
+			Path:       config.PolicyBundlePath(),
+			Valid:      cfg.PolicyError() == nil && cfg.Policy() != nil,
+			LockedKeys: cfg.LockedKeys(),
+		}
+		if doc := cfg.Policy(); doc != nil {
+			data.Issuer = doc.Issuer
+		}
+		if err := cfg.PolicyError(); err != nil {
+			data.Error = err.Error()
+		}
+		return NewJSONResponse("policy show", data).Print()
+	}
+
+	fmt.Println()
+	fmt.Println(policyTitleStyle.Render("Administrator Policy (CM-5)"))
+	fmt.Println(separatorStyle.Render(strings.Repeat("=", 41)))
+
+	fmt.Printf("  %s%s\n", policyLabelStyle.Render("Bundle:"), policyValueStyle.Render(config.PolicyBundlePath()))
+
+	if perr := cfg.PolicyError(); perr != nil {
+		fmt.Printf("  %s%s\n", policyLabelStyle.Render("Status:"), policyErrorStyle.Render("INVALID - paranoid mode enforced"))
+		fmt.Printf("  %s%s\n", policyLabelStyle.Render("Error:"), policyDimStyle.Render(perr.Error()))
+		fmt.Println()
+		return nil
+	}
+
+	doc := cfg.Policy()
+	if doc == nil {
+		fmt.Printf("  %s%s\n", policyLabelStyle.Render("Status:"), policyDimStyle.Render("no policy installed"))
+		fmt.Println()
+		return nil
+	}
+
+	fmt.Printf("  %s%s\n", policyLabelStyle.Render("Status:"), policySuccessStyle.Render("verified"))
+	printPolicyDocument(doc)
+
+	fmt.Println(policySectionStyle.Render("Locked Settings"))
+	for _, key := range cfg.LockedKeys() {
+		val, _ := cfg.Get(key)
+		fmt.Printf("  %s%s\n", policyLabelStyle.Render(key), policyValueStyle.Render(fmt.Sprintf("%v", val)))
+	}
+	fmt.Println()
+
+	return nil
+}
+
+// printPolicyDocument prints the contents of a policy document.
+func printPolicyDocument(doc *config.PolicyDocument) {
+	if doc.Issuer != "" {
+		fmt.Printf("  %s%s\n", policyLabelStyle.Render("Issuer:"), policyValueStyle.Render(doc.Issuer))
+	}
+	if !doc.IssuedAt.IsZero() {
+		fmt.Printf("  %s%s\n", policyLabelStyle.Render("Issued:"), policyValueStyle.Render(doc.IssuedAt.Format("2006-01-02 15:04:05 MST")))
+	}
+	if !doc.ExpiresAt.IsZero() {
+		fmt.Printf("  %s%s\n", policyLabelStyle.Render("Expires:"), policyValueStyle.Render(doc.ExpiresAt.Format("2006-01-02 15:04:05 MST")))
+	}
+
+	if len(doc.ToolPermissions) > 0 {
+		fmt.Println(policySectionStyle.Render("Tool Permissions"))
+		tools := make([]string, 0, len(doc.ToolPermissions))
+		for tool := range doc.ToolPermissions {
+			tools = append(tools, tool)
+		}
+		sort.Strings(tools)
+		for _, tool := range tools {
+			fmt.Printf("  %s%s\n", policyLabelStyle.Render(tool), policyValueStyle.Render(doc.ToolPermissions[tool]))
+		}
+	}
+
+	if len(doc.NetworkAllowlist) > 0 {
+		fmt.Println(policySectionStyle.Render("Network Allow-list"))
+		for _, host := range doc.NetworkAllowlist {
+			fmt.Printf("  %s\n", policyValueStyle.Render(host))
+		}
+	}
+	fmt.Println()
+}
+
+// =============================================================================
+// POLICY SIGN
+// =============================================================================
+
+// handlePolicySign signs a policy document into a bundle.
+func handlePolicySign(policyArgs PolicyArgs) error {
+	if policyArgs.File == "" || policyArgs.KeyFile == "" {
+		return fmt.Errorf("usage: rigrun policy sign <policy.toml|policy.json> --key <private-key> [--output <bundle>]")
+	}
+
+	payload, err := os.ReadFile(policyArgs.File)
+	if err != nil {
+		return fmt.Errorf("failed to read policy: %w", err)
+	}
+	keyData, err := os.ReadFile(policyArgs.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to read signing key: %w", err)
+	}
+	priv, err := config.ParsePolicyPrivateKey(keyData)
+	if err != nil {
+		return err
+	}
+
+	format := "toml"
+	if strings.EqualFold(filepath.Ext(policyArgs.File), ".json") {
+		format = "json"
+	}
+
+	bundle, err := config.SignPolicy(payload, format, priv)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode bundle: %w", err)
+	}
+	data = append(data, '\n')
+
+	if policyArgs.Output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+
+	// RELIABILITY: Atomic write so a partially written bundle is never installed
+	if err := util.AtomicWriteFile(policyArgs.Output, data, 0644); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+
+	if policyArgs.JSON {
+		return NewJSONResponse("policy sign", map[string]interface{}{
+			"output": policyArgs.Output,
+			"key_id": bundle.KeyID,
+			"format": bundle.Format,
+		}).Print()
+	}
+
+	fmt.Printf("%s Policy signed (key %s): %s\n",
+		policySuccessStyle.Render("[OK]"), bundle.KeyID, policyArgs.Output)
+	return nil
+}
+
+// =============================================================================
+// POLICY VERIFY
+// =============================================================================
+
+// handlePolicyVerify verifies a bundle against a public key. Defaults to the
+// installed bundle and the trusted key.
+func handlePolicyVerify(policyArgs PolicyArgs) error {
+	path := policyArgs.File
+	if path == "" {
+		path = config.PolicyBundlePath()
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read bundle: %w", err)
+	}
+	bundle, err := config.ParsePolicyBundle(data)
+	if err != nil {
+		return err
+	}
+
+	var pub ed25519.PublicKey
+	if policyArgs.PubKeyFile != "" {
+		keyData, err := os.ReadFile(policyArgs.PubKeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to read public key: %w", err)
+		}
+		if pub, err = config.ParsePolicyPublicKey(keyData); err != nil {
+			return err
+		}
+	} else {
+		cfg, _ := config.Load()
+		if cfg == nil {
+			cfg = config.Default()
+		}
+		if pub, err = cfg.TrustedPolicyKey(); err != nil {
+			return err
+		}
+	}
+
+	doc, verifyErr := bundle.Verify(pub)
+
+	if policyArgs.JSON {
+		result := map[string]interface{}{
+			"path":   path,
+			"key_id": config.PolicyKeyID(pub),
+			"valid":  verifyErr == nil,
+		}
+		if verifyErr != nil {
+			result["error"] = verifyErr.Error()
+		} else {
+			result["issuer"] = doc.Issuer
+		}
+		if err := NewJSONResponse("policy verify", result).Print(); err != nil {
+			return err
+		}
+		return verifyErr
+	}
+
+	if verifyErr != nil {
+		fmt.Printf("%s %s: %v\n", policyErrorStyle.Render("[FAIL]"), path, verifyErr)
+		return verifyErr
+	}
+
+	fmt.Printf("%s %s: signature valid (key %s)\n",
+		policySuccessStyle.Render("[OK]"), path, config.PolicyKeyID(pub))
+	printPolicyDocument(doc)
+	return nil
+}
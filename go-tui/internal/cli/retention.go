@@ -0,0 +1,110 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// retention.go - NIST 800-53 SI-12 retention policies for rigrun's data stores.
+//
+// Builds the retention engine from the [retention] config section and runs
+// it over every store of user data: conversations and the JSON files they
+// were imported from, cost history, the intel cache, benchmark results, plan
+// checkpoints, background tasks and archived audit logs. Used at startup and
+// by "rigrun data purge".
+package cli
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jeranaias/rigrun-tui/internal/benchmark"
+	"github.com/jeranaias/rigrun-tui/internal/config"
+	"github.com/jeranaias/rigrun-tui/internal/plan"
+	"github.com/jeranaias/rigrun-tui/internal/security"
+	"github.com/jeranaias/rigrun-tui/internal/storage"
+	"github.com/jeranaias/rigrun-tui/internal/tasks"
+	"github.com/jeranaias/rigrun-tui/internal/telemetry"
+)
+
+// ApplyRetention runs the retention policies of cfg over the data stores,
+// purging what they select unless dryRun is set. The report covers the
+// stores that could be opened, even when an error is returned.
+func ApplyRetention(cfg *config.Config, dryRun bool) (*security.RetentionReport, error) {
+	stores, closeStores := openRetainedStores()
+	defer closeStores()
+	return RetentionEngine(cfg).Run(stores, dryRun)
+}
+
+// RetentionEngine returns the retention engine configured by cfg.
+func RetentionEngine(cfg *config.Config) *security.RetentionEngine {
+	r := cfg.Retention
+	return &security.RetentionEngine{
+		Policies: map[string]security.RetentionPolicy{
+			security.RetentionConversations: retentionPolicy(r.Conversations),
+			security.RetentionCosts:         retentionPolicy(r.Costs),
+			security.RetentionIntel:         retentionPolicy(r.Intel),
+			security.RetentionBenchmarks:    retentionPolicy(r.Benchmarks),
+			security.RetentionCheckpoints:   retentionPolicy(r.Checkpoints),
+			security.RetentionTasks:         retentionPolicy(r.Tasks),
+			// AU-11: Archived audit logs are only purged by age
+			security.RetentionAuditArchives: {MaxAge: daysDuration(r.AuditArchives.MaxAgeDays)},
+		},
+		Classified: retentionPolicy(r.Classified),
+	}
+}
+
+// retentionPolicy converts a configured policy.
+func retentionPolicy(p config.RetentionPolicyConfig) security.RetentionPolicy {
+	return security.RetentionPolicy{
+		MaxAge:   daysDuration(p.MaxAgeDays),
+		MaxCount: p.MaxCount,
+		MaxSize:  int64(p.MaxSizeMB) * 1024 * 1024,
+	}
+}
+
+// daysDuration returns a duration of n days.
+func daysDuration(n int) time.Duration {
+	return time.Duration(n) * 24 * time.Hour
+}
+
+// openRetainedStores opens the stores kept under retention policies. A
+// store that cannot be opened is left out with a warning. The returned func
+// closes the stores.
+func openRetainedStores() ([]security.RetainedStore, func()) {
+	var stores []security.RetainedStore
+	var closers []func() error
+
+	if conversations, err := storage.NewConversationStore(); err == nil {
+		stores = append(stores, conversations, conversations.ImportedBackups())
+		closers = append(closers, conversations.Close)
+	} else {
+		fmt.Fprintf(os.Stderr, "Warning: could not open conversations: %v\n", err)
+	}
+	if costs, err := telemetry.NewCostStorage(""); err == nil {
+		stores = append(stores, costs)
+	} else {
+		fmt.Fprintf(os.Stderr, "Warning: could not open cost history: %v\n", err)
+	}
+	stores = append(stores, intelCache{dir: getIntelCacheDir()})
+	if benchmarks, err := benchmark.NewStorage(); err == nil {
+		stores = append(stores, benchmarks)
+	} else {
+		fmt.Fprintf(os.Stderr, "Warning: could not open benchmark results: %v\n", err)
+	}
+	if plans, err := plan.NewStore(""); err == nil {
+		stores = append(stores, plans)
+	} else {
+		fmt.Fprintf(os.Stderr, "Warning: could not open plan checkpoints: %v\n", err)
+	}
+	if taskStore, err := tasks.OpenStore(""); err == nil {
+		stores = append(stores, taskStore)
+		closers = append(closers, taskStore.Close)
+	} else {
+		fmt.Fprintf(os.Stderr, "Warning: could not open background tasks: %v\n", err)
+	}
+	stores = append(stores, security.DefaultAuditArchives())
+
+	return stores, func() {
+		for _, closeStore := range closers {
+			closeStore()
+		}
+	}
+}
@@ -0,0 +1,428 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// review_cmd.go - Local code review of a diff or branch.
+//
+// CLI: Comprehensive help and examples for all commands
+//
+// The diff is split into chunks sized for the local model, each chunk is
+// reviewed with related definitions from the codebase index, and the
+// structured findings are deduplicated and written as markdown, JSON or
+// SARIF. The code never leaves the machine: only the local Ollama model
+// is used, and local.ollama_url must point at localhost.
+//
+// Command: review [range|--staged]
+// Short:   Review a diff with the local model
+//
+// Examples:
+//   rigrun review                          Review uncommitted changes
+//   rigrun review --staged                 Review what is about to be committed
+//   rigrun review main...HEAD              Review the current branch
+//   rigrun review HEAD~3..HEAD --format sarif --output review.sarif
+//   rigrun review --fail-on high           Exit 1 on high or critical findings
+//   rigrun review --fix                    Apply the suggested edits
+//
+// Flags:
+//   --staged             Review the index against HEAD
+//   --format FORMAT      markdown (default), json or sarif
+//   --output FILE        Write the report to FILE instead of stdout
+//   --chunk-size CHARS   Largest chunk of diff sent in one request (default: 8000)
+//   --fail-on SEVERITY   Exit 1 if any finding is at least this severe
+//   --fix                Turn findings with exact edits into Edit tool calls
+//   --yes                With --fix, apply edits without asking (required
+//                        when edits cannot be confirmed in a terminal)
+//   --no-context         Don't add definitions from the codebase index
+//   --model NAME         Local model to use
+//   --json               Same as --format json
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/jeranaias/rigrun-tui/internal/config"
+	"github.com/jeranaias/rigrun-tui/internal/index"
+	"github.com/jeranaias/rigrun-tui/internal/offline"
+	"github.com/jeranaias/rigrun-tui/internal/ollama"
+	"github.com/jeranaias/rigrun-tui/internal/review"
+	"github.com/jeranaias/rigrun-tui/internal/security"
+	"github.com/jeranaias/rigrun-tui/internal/ui/styles"
+)
+
+// =============================================================================
+// REVIEW CONSTANTS
+// =============================================================================
+
+const (
+	// defaultReviewChunkSize fits a chunk, its context and the reply in an
+	// 8K-token context window
+	defaultReviewChunkSize = 8000
+
+	// reviewContextSize bounds the related definitions added to a chunk
+	reviewContextSize = 2000
+
+	// reviewNumCtx is the context window requested from Ollama
+	reviewNumCtx = 8192
+)
+
+// Review output formats
+const (
+	ReviewFormatMarkdown = "markdown"
+	ReviewFormatJSON     = "json"
+	ReviewFormatSARIF    = "sarif"
+)
+
+// ErrReviewFindings is returned by HandleReview when --fail-on is met.
+var ErrReviewFindings = errors.New("review findings at or above the --fail-on severity")
+
+// =============================================================================
+// REVIEW ARGUMENTS
+// =============================================================================
+
+// ReviewArgs holds parsed review command arguments.
+type ReviewArgs struct {
+	Range     string
+	Staged    bool
+	Format    string
+	Output    string
+	ChunkSize int
+	FailOn    review.Severity
+	Fix       bool
+	Yes       bool
+	NoContext bool
+}
+
+// parseReviewArgs parses review command specific arguments.
+func parseReviewArgs(args *Args, remaining []string) (ReviewArgs, error) {
+	reviewArgs := ReviewArgs{
+		Format:    ReviewFormatMarkdown,
+		ChunkSize: defaultReviewChunkSize,
+	}
+	if args.JSON {
+		reviewArgs.Format = ReviewFormatJSON
+	}
+
+	// value returns the value of a --flag VALUE or --flag=VALUE option
+	value := func(i *int, arg, flag string) (string, bool, error) {
+		if strings.HasPrefix(arg, flag+"=") {
+			return strings.TrimPrefix(arg, flag+"="), true, nil
+		}
+		if arg != flag {
+			return "", false, nil
+		}
+		if *i+1 >= len(remaining) {
+			return "", true, fmt.Errorf("%s requires a value", flag)
+		}
+		*i++
+		return remaining[*i], true, nil
+	}
+
+	for i := 0; i < len(remaining); i++ {
+		arg := remaining[i]
+
+		switch arg {
+		case "--staged", "--cached":
+			reviewArgs.Staged = true
+			continue
+		case "--fix":
+			reviewArgs.Fix = true
+			continue
+		case "--yes", "-y":
+			reviewArgs.Yes = true
+			continue
+		case "--no-context":
+			reviewArgs.NoContext = true
+			continue
+		}
+
+		if v, ok, err := value(&i, arg, "--format"); ok {
+			if err != nil {
+				return reviewArgs, err
+			}
+			reviewArgs.Format = strings.ToLower(v)
+		} else if v, ok, err := value(&i, arg, "--output"); ok {
+			if err != nil {
+				return reviewArgs, err
+			}
+			reviewArgs.Output = v
+		} else if v, ok, err := value(&i, arg, "--chunk-size"); ok {
+			if err != nil {
+				return reviewArgs, err
+			}
+			n, convErr := strconv.Atoi(v)
+			if convErr != nil || n < 1000 {
+				return reviewArgs, fmt.Errorf("--chunk-size must be a number of characters, at least 1000")
+			}
+			reviewArgs.ChunkSize = n
+		} else if v, ok, err := value(&i, arg, "--fail-on"); ok {
+			if err != nil {
+				return reviewArgs, err
+			}
+			sev, sevErr := review.ParseSeverity(v)
+			if sevErr != nil {
+				return reviewArgs, sevErr
+			}
+			reviewArgs.FailOn = sev
+		} else if strings.HasPrefix(arg, "-") {
+			return reviewArgs, fmt.Errorf("unknown review flag: %s", arg)
+		} else if reviewArgs.Range == "" {
+			reviewArgs.Range = arg
+		} else {
+			return reviewArgs, fmt.Errorf("unexpected argument: %s", arg)
+		}
+	}
+
+	switch reviewArgs.Format {
+	case ReviewFormatMarkdown, ReviewFormatJSON, ReviewFormatSARIF:
+	case "md":
+		reviewArgs.Format = ReviewFormatMarkdown
+	default:
+		return reviewArgs, fmt.Errorf("unknown format %q (want markdown, json or sarif)", reviewArgs.Format)
+	}
+	if reviewArgs.Staged && reviewArgs.Range != "" {
+		return reviewArgs, errors.New("--staged cannot be combined with a range")
+	}
+	return reviewArgs, nil
+}
+
+// =============================================================================
+// HANDLE REVIEW
+// =============================================================================
+
+// HandleReview handles the "review" command.
+// Returns ErrReviewFindings (exit status 1) when --fail-on is met.
+func HandleReview(args Args) error {
+	reviewArgs, err := parseReviewArgs(&args, args.Raw)
+	if err != nil {
+		return err
+	}
+	// Without a terminal to confirm each edit in, --fix needs --yes
+	if reviewArgs.Fix && !reviewArgs.Yes && !reviewCanPrompt(args, reviewArgs) {
+		return fmt.Errorf("--fix cannot ask for confirmation here; pass --yes to apply the suggested edits")
+	}
+	// Progress goes to stderr so it never mixes with the report
+	verbose := !args.Quiet
+	ctx := context.Background()
+
+	cs, err := review.CollectDiffs(ctx, review.Source{Range: reviewArgs.Range, Staged: reviewArgs.Staged})
+	if err != nil {
+		return err
+	}
+
+	cfg := config.Global()
+	model := args.Model
+	if model == "" {
+		model = cfg.Local.OllamaModel
+	}
+	if model == "" {
+		model = cfg.DefaultModel
+	}
+
+	report := review.Report{
+		Description: cs.Description,
+		Model:       model,
+		Files:       len(cs.Diffs),
+		Skipped:     cs.Skipped,
+		Findings:    []review.Finding{},
+	}
+
+	if len(cs.Diffs) > 0 {
+		// SC-7: Reviews run on the local model only; the code stays here
+		if err := offline.ValidateLocalURL(cfg.Local.OllamaURL); err != nil {
+			return fmt.Errorf("rigrun review only uses a local Ollama endpoint (local.ollama_url = %s): %w", cfg.Local.OllamaURL, err)
+		}
+		client := ollama.NewClientWithConfig(&ollama.ClientConfig{
+			BaseURL:      cfg.Local.OllamaURL,
+			DefaultModel: cfg.Local.OllamaModel,
+		})
+		if err := client.CheckRunning(ctx); err != nil {
+			return fmt.Errorf("Ollama is not running. Start it with: ollama serve")
+		}
+		if report.Model == "" {
+			report.Model = client.GetDefaultModel()
+		}
+
+		var idx *index.CodebaseIndex
+		if !reviewArgs.NoContext {
+			idx = openReviewIndex(cs.Root)
+			if idx != nil {
+				defer idx.Close()
+			}
+		}
+
+		chunks := review.BuildChunks(cs.Diffs, reviewArgs.ChunkSize)
+		report.Chunks = len(chunks)
+		var findings []review.Finding
+		for i, chunk := range chunks {
+			if verbose {
+				fmt.Fprintf(os.Stderr, "Reviewing chunk %d/%d (%s)...\n", i+1, len(chunks), strings.Join(chunk.Files(), ", "))
+			}
+			found, err := reviewChunk(ctx, client, report.Model, chunk, review.RelatedContext(idx, chunk, reviewContextSize))
+			if err != nil {
+				report.FailedChunks++
+				fmt.Fprintf(os.Stderr, "Warning: chunk %d/%d: %v\n", i+1, len(chunks), err)
+				continue
+			}
+			findings = append(findings, found...)
+		}
+		if report.FailedChunks == len(chunks) {
+			return fmt.Errorf("no chunk could be reviewed with %s", report.Model)
+		}
+		report.Findings = review.Dedupe(findings)
+	}
+
+	// AU-2: Record what was reviewed and what was found
+	security.AuditLogEvent("", "CODE_REVIEW", map[string]string{
+		"range":    cs.Description,
+		"model":    report.Model,
+		"files":    strconv.Itoa(report.Files),
+		"findings": strconv.Itoa(len(report.Findings)),
+	})
+
+	if err := writeReviewReport(report, reviewArgs, args); err != nil {
+		return err
+	}
+
+	if reviewArgs.Fix {
+		if err := applyReviewFixes(ctx, cfg, args, reviewArgs, cs.Root, report.Findings); err != nil {
+			return err
+		}
+	}
+
+	if reviewArgs.FailOn != "" {
+		for _, f := range report.Findings {
+			if f.Severity.Rank() >= reviewArgs.FailOn.Rank() {
+				return ErrReviewFindings
+			}
+		}
+	}
+	return nil
+}
+
+// reviewChunk asks the model to review one chunk and parses its findings.
+func reviewChunk(ctx context.Context, client *ollama.Client, model string, chunk review.Chunk, related string) ([]review.Finding, error) {
+	messages := []ollama.Message{
+		{Role: "system", Content: review.SystemPrompt},
+		{Role: "user", Content: review.Prompt(chunk, related)},
+	}
+	resp, err := client.ChatWithOptions(ctx, model, messages, &ollama.Options{
+		Temperature: 0.1,
+		NumCtx:      reviewNumCtx,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return review.ParseFindings(resp.Message.Content, chunk)
+}
+
+// openReviewIndex opens the codebase index of the repository if one has
+// been built. A missing or unreadable index only means less context.
+func openReviewIndex(root string) *index.CodebaseIndex {
+	cfg := index.DefaultConfig(root)
+	cfg.EnableWatch = false
+	if _, err := os.Stat(cfg.DatabasePath); err != nil {
+		return nil
+	}
+	idx, err := index.NewCodebaseIndex(cfg)
+	if err != nil {
+		return nil
+	}
+	return idx
+}
+
+// writeReviewReport writes the report in the requested format to stdout or
+// the --output file.
+func writeReviewReport(report review.Report, reviewArgs ReviewArgs, args Args) error {
+	var out string
+	switch reviewArgs.Format {
+	case ReviewFormatJSON:
+		if reviewArgs.Output == "" {
+			return NewJSONResponse("review", report).Print()
+		}
+		out = NewJSONResponse("review", report).String() + "\n"
+	case ReviewFormatSARIF:
+		data, err := report.SARIF(Version)
+		if err != nil {
+			return err
+		}
+		out = string(data) + "\n"
+	default:
+		out = report.Markdown()
+	}
+
+	if reviewArgs.Output != "" {
+		if err := os.WriteFile(reviewArgs.Output, []byte(out), 0644); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+		if !args.Quiet {
+			fmt.Fprintf(os.Stderr, "Wrote %s to %s\n", report.Summary(), reviewArgs.Output)
+		}
+		return nil
+	}
+
+	if reviewArgs.Format == ReviewFormatMarkdown && IsStdoutTTY() {
+		out = renderMarkdown(out)
+	}
+	fmt.Print(out)
+	return nil
+}
+
+// =============================================================================
+// FIXES
+// =============================================================================
+
+// reviewCanPrompt reports whether --fix can confirm edits interactively.
+// Questions are printed on stdout, so only ask when it isn't the report.
+func reviewCanPrompt(args Args, reviewArgs ReviewArgs) bool {
+	return canAskForApproval(args, nil) && (reviewArgs.Format == ReviewFormatMarkdown || reviewArgs.Output != "")
+}
+
+// applyReviewFixes turns findings with exact edits into Edit tool calls.
+// Each edit goes through the same permission checks and hooks as an
+// agentic ask. In a terminal each edit is confirmed unless --yes is given;
+// elsewhere HandleReview has already required --yes.
+func applyReviewFixes(ctx context.Context, cfg *config.Config, args Args, reviewArgs ReviewArgs, root string, findings []review.Finding) error {
+	var fixable []review.Finding
+	for _, f := range findings {
+		if f.Fix != nil {
+			fixable = append(fixable, f)
+		}
+	}
+	if len(fixable) == 0 {
+		fmt.Fprintln(os.Stderr, "No findings have an exact edit to apply.")
+		return nil
+	}
+
+	registry, err := newAskToolRegistry(cfg, args)
+	if err != nil {
+		return err
+	}
+	canPrompt := reviewCanPrompt(args, reviewArgs)
+
+	okStyle := lipgloss.NewStyle().Foreground(styles.Emerald)
+	failStyle := lipgloss.NewStyle().Foreground(styles.Rose)
+	applied := 0
+	for _, f := range fixable {
+		if canPrompt && !reviewArgs.Yes {
+			fmt.Printf("\n%s [%s] %s\n", f.Location(), f.Severity, f.Message)
+			if !PromptYesNo("Apply the suggested edit?") {
+				continue
+			}
+		}
+		if _, err := executeToolForCLI(ctx, registry, nil, "Edit", f.EditParams(root), canPrompt); err != nil {
+			fmt.Fprintf(os.Stderr, "%s %s: %v\n", failStyle.Render("[SKIP]"), f.Location(), err)
+			continue
+		}
+		applied++
+		fmt.Fprintf(os.Stderr, "%s %s\n", okStyle.Render("[FIXED]"), f.Location())
+	}
+	fmt.Fprintf(os.Stderr, "Applied %d of %d edit(s)\n", applied, len(fixable))
+	return nil
+}
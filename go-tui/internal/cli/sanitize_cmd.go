@@ -21,6 +21,7 @@
 //   all                 Securely wipe all data
 //   file <path>         Securely wipe specific file
 //   spillage-scan       Scan for potential data spillage
+//   purge               Apply the SI-12 retention policies
 //
 // Examples:
 //   rigrun sanitize                         Show status (default)
@@ -32,6 +33,8 @@
 //   rigrun sanitize all --confirm           Wipe all data
 //   rigrun sanitize file /path/to/file --confirm  Wipe specific file
 //   rigrun sanitize spillage-scan           Scan for spillage
+//   rigrun data purge --dry-run             Show what retention would purge
+//   rigrun data purge                       Purge data past its retention
 //
 // Secure Deletion (DoD 5220.22-M):
 //   - 3-pass overwrite with random data
@@ -41,6 +44,7 @@
 //
 // Flags:
 //   --confirm           Required for destructive operations
+//   --dry-run           Show what purge would delete without deleting
 //   --json              Output in JSON format
 //
 // Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
@@ -58,6 +62,7 @@ import (
 
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/jeranaias/rigrun-tui/internal/config"
 	"github.com/jeranaias/rigrun-tui/internal/security"
 )
 
@@ -129,6 +134,7 @@ type SanitizeArgs struct {
 	All              bool
 	SpillageResponse bool
 	Recursive        bool
+	DryRun           bool
 }
 
 // parseSanitizeArgs parses sanitize command specific arguments.
@@ -158,6 +164,8 @@ func parseSanitizeArgs(args *Args) SanitizeArgs {
 			sanitizeArgs.JSON = true
 		case "--recursive", "-r":
 			sanitizeArgs.Recursive = true
+		case "--dry-run":
+			sanitizeArgs.DryRun = true
 		case "--secure":
 			// Next arg should be the path
 			if i+1 < len(remaining) {
@@ -192,6 +200,7 @@ func parseSanitizeArgs(args *Args) SanitizeArgs {
 //   - data sanitize --spillage-response: Full IR-9 spillage response
 //   - data wipe --secure <path>: Securely delete file/directory
 //   - data scan --spillage [path]: Scan for spillage
+//   - data purge [--dry-run]: Apply the SI-12 retention policies
 func HandleData(args Args) error {
 	sanitizeArgs := parseSanitizeArgs(&args)
 
@@ -202,6 +211,8 @@ func HandleData(args Args) error {
 		return handleDataWipe(sanitizeArgs)
 	case "scan":
 		return handleDataScan(sanitizeArgs)
+	case "purge":
+		return handleDataPurge(sanitizeArgs)
 	default:
 		return fmt.Errorf("unknown data subcommand: %s\n\nUsage:\n"+
 			"  rigrun data sanitize --cache             Sanitize cache\n"+
@@ -209,7 +220,8 @@ func HandleData(args Args) error {
 			"  rigrun data sanitize --all              Sanitize everything\n"+
 			"  rigrun data sanitize --spillage-response Full IR-9 spillage response\n"+
 			"  rigrun data wipe --secure <path>        Securely delete file/directory\n"+
-			"  rigrun data scan --spillage [path]      Scan for spillage", sanitizeArgs.Subcommand)
+			"  rigrun data scan --spillage [path]      Scan for spillage\n"+
+			"  rigrun data purge [--dry-run]           Purge data past its retention policy", sanitizeArgs.Subcommand)
 	}
 }
 
@@ -575,3 +587,71 @@ func handleDataScan(sanitizeArgs SanitizeArgs) error {
 
 	return nil
 }
+
+// =============================================================================
+// DATA PURGE (RETENTION)
+// =============================================================================
+
+// purgeListLimit caps the items listed per data class by a dry run.
+const purgeListLimit = 10
+
+// handleDataPurge applies the SI-12 retention policies to the data stores,
+// or with --dry-run shows what they would purge.
+func handleDataPurge(sanitizeArgs SanitizeArgs) error {
+	report, err := ApplyRetention(config.Global(), sanitizeArgs.DryRun)
+
+	if sanitizeArgs.JSON {
+		data, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(data))
+		return err
+	}
+
+	fmt.Println()
+	if report.DryRun {
+		fmt.Println(sanitizeTitleStyle.Render("Data Retention (dry run)"))
+	} else {
+		fmt.Println(sanitizeTitleStyle.Render("Data Retention"))
+	}
+	fmt.Println(sanitizeSeparatorStyle.Render(strings.Repeat("=", 60)))
+	fmt.Println()
+
+	verb := "purged"
+	if report.DryRun {
+		verb = "to purge"
+	}
+	for _, res := range report.Results {
+		fmt.Printf("  %s %d of %d items %s (%s)", sanitizeLabelStyle.Render(res.Class),
+			len(res.Purged), res.Items, verb, formatBytes(res.Bytes))
+		switch {
+		case res.Error != "":
+			fmt.Printf(" %s %s\n", sanitizeErrorStyle.Render("[ERROR]"), res.Error)
+		case len(res.Purged) > 0:
+			fmt.Printf(" %s\n", sanitizeWarningStyle.Render("[PURGE]"))
+		default:
+			fmt.Printf(" %s\n", sanitizeSuccessStyle.Render("[OK]"))
+		}
+		if report.DryRun {
+			for i, item := range res.Purged {
+				if i == purgeListLimit {
+					fmt.Printf("      ... and %d more\n", len(res.Purged)-purgeListLimit)
+					break
+				}
+				fmt.Printf("      %s  %s  (%s)\n", item.Time.Format("2006-01-02"), item.ID, item.Reason)
+			}
+		}
+	}
+	fmt.Println()
+
+	switch {
+	case report.Purged() == 0:
+		fmt.Println("  Nothing is past its retention policy.")
+	case report.DryRun:
+		fmt.Printf("  %d items would be securely deleted. To purge them, run:\n", report.Purged())
+		fmt.Println("    rigrun data purge")
+	default:
+		fmt.Printf("  %s %d items securely deleted and audited\n", sanitizeSuccessStyle.Render("[OK]"), report.Purged())
+	}
+	fmt.Println()
+
+	return err
+}
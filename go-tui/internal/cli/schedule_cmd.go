@@ -0,0 +1,279 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// schedule_cmd.go - Scheduled job CLI commands for rigrun.
+//
+// CLI: Comprehensive help and examples for all commands
+//
+// Schedules are prompts and plans run on a cron schedule by "rigrun
+// daemon". They are [[schedules]] tables in ~/.rigrun/config.toml or in
+// <repo root>/.rigrun/schedules.toml (shared with the repository):
+//
+//   [[schedules]]
+//   name = "commit-summary"
+//   cron = "0 8 * * mon-fri"
+//   prompt = "Summarize yesterday's commits"
+//   agentic = true
+//
+// Command: schedule [subcommand]
+// Short:   List scheduled prompts and plans
+// Aliases: schedules
+//
+// Subcommands:
+//   list (default)      List schedules with their next and last runs
+//
+// Examples:
+//   rigrun schedule                    List schedules
+//   rigrun schedule list --json        List schedules, JSON output
+//
+// Flags:
+//   --json              Output in JSON format
+//
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/jeranaias/rigrun-tui/internal/config"
+	"github.com/jeranaias/rigrun-tui/internal/schedule"
+)
+
+// =============================================================================
+// SCHEDULE COMMAND STYLES
+// =============================================================================
+
+var (
+	// Schedule title style
+	scheduleTitleStyle = lipgloss.NewStyle().
+				Bold(true).
+				Foreground(lipgloss.Color("39")). // Cyan
+				MarginBottom(1)
+
+	// Schedule value style
+	scheduleValueStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("255")) // White
+
+	// Schedule success style
+	scheduleSuccessStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("82")).
+				Bold(true)
+
+	// Schedule error style
+	scheduleErrorStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("196")).
+				Bold(true)
+
+	// Schedule dim style
+	scheduleDimStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("242"))
+)
+
+// =============================================================================
+// SCHEDULE ARGUMENTS
+// =============================================================================
+
+// ScheduleArgs holds parsed schedule command arguments.
+type ScheduleArgs struct {
+	Subcommand string
+	JSON       bool
+}
+
+// parseScheduleArgs parses schedule command specific arguments.
+func parseScheduleArgs(args *Args, remaining []string) ScheduleArgs {
+	scheduleArgs := ScheduleArgs{
+		JSON: args.JSON,
+	}
+
+	if len(remaining) > 0 {
+		scheduleArgs.Subcommand = remaining[0]
+		remaining = remaining[1:]
+	}
+
+	for _, arg := range remaining {
+		switch arg {
+		case "--json":
+			scheduleArgs.JSON = true
+		}
+	}
+
+	return scheduleArgs
+}
+
+// =============================================================================
+// SCHEDULE JSON TYPES
+// =============================================================================
+
+// ScheduleInfo is the JSON form of a schedule.
+type ScheduleInfo struct {
+	Name           string     `json:"name"`
+	Cron           string     `json:"cron"`
+	Kind           string     `json:"kind"`
+	Agentic        bool       `json:"agentic,omitempty"`
+	Prompt         string     `json:"prompt"`
+	Model          string     `json:"model,omitempty"`
+	CatchUp        string     `json:"catch_up"`
+	Timezone       string     `json:"timezone,omitempty"`
+	WorkDir        string     `json:"work_dir"`
+	Source         string     `json:"source"`
+	Disabled       bool       `json:"disabled,omitempty"`
+	NextRun        *time.Time `json:"next_run,omitempty"`
+	LastRun        *time.Time `json:"last_run,omitempty"`
+	LastStatus     string     `json:"last_status,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	LastTaskID     string     `json:"last_task_id,omitempty"`
+	ConversationID string     `json:"conversation_id,omitempty"`
+}
+
+// newScheduleInfo converts a job and its state to JSON form.
+func newScheduleInfo(job *schedule.Job, st schedule.JobState, now time.Time) ScheduleInfo {
+	info := ScheduleInfo{
+		Name:           job.Name,
+		Cron:           job.Cron,
+		Kind:           job.Kind,
+		Agentic:        job.Agentic,
+		Prompt:         job.Prompt,
+		Model:          job.Model,
+		CatchUp:        job.CatchUp,
+		Timezone:       job.Timezone,
+		WorkDir:        job.WorkDir,
+		Source:         job.Source,
+		Disabled:       job.Disabled,
+		LastStatus:     st.Status,
+		LastError:      st.Error,
+		LastTaskID:     st.TaskID,
+		ConversationID: st.ConversationID,
+	}
+	if next := job.Next(now); !next.IsZero() && !job.Disabled {
+		info.NextRun = &next
+	}
+	if !st.LastRun.IsZero() {
+		info.LastRun = &st.LastRun
+	}
+	return info
+}
+
+// =============================================================================
+// HANDLE SCHEDULE
+// =============================================================================
+
+// HandleSchedule handles the "schedule" command with various subcommands.
+// Subcommands:
+//   - schedule list: List schedules with their next and last runs
+func HandleSchedule(args Args) error {
+	scheduleArgs := parseScheduleArgs(&args, args.Raw)
+
+	switch scheduleArgs.Subcommand {
+	case "", "list", "ls":
+		return handleScheduleList(scheduleArgs)
+	default:
+		return fmt.Errorf("unknown schedule subcommand: %s\n\nUsage:\n"+
+			"  rigrun schedule list    List schedules\n"+
+			"  rigrun daemon           Run schedules as they come due", scheduleArgs.Subcommand)
+	}
+}
+
+// loadSchedules loads the schedules for the current directory and the
+// scheduler state.
+func loadSchedules() ([]*schedule.Job, *schedule.State, error) {
+	cfg := config.Global()
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get working directory: %w", err)
+	}
+	jobs, err := schedule.LoadJobs(cfg, cwd)
+	if err != nil {
+		return nil, nil, err
+	}
+	state, err := schedule.LoadState("")
+	if err != nil {
+		return nil, nil, err
+	}
+	return jobs, state, nil
+}
+
+// =============================================================================
+// SCHEDULE LIST
+// =============================================================================
+
+// handleScheduleList lists the schedules.
+func handleScheduleList(scheduleArgs ScheduleArgs) error {
+	jobs, state, err := loadSchedules()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+
+	if scheduleArgs.JSON {
+		infos := make([]ScheduleInfo, 0, len(jobs))
+		for _, job := range jobs {
+			st, _ := state.Get(job.Name)
+			infos = append(infos, newScheduleInfo(job, st, now))
+		}
+		return NewJSONResponse("schedule list", infos).Print()
+	}
+
+	fmt.Println()
+	fmt.Println(scheduleTitleStyle.Render("Schedules"))
+	fmt.Println(separatorStyle.Render(strings.Repeat("=", 41)))
+
+	if len(jobs) == 0 {
+		fmt.Println(scheduleDimStyle.Render("  No schedules. Add [[schedules]] to ~/.rigrun/config.toml or " + schedule.ProjectFile + "."))
+		fmt.Println()
+		return nil
+	}
+
+	for _, job := range jobs {
+		st, _ := state.Get(job.Name)
+		printSchedule(newScheduleInfo(job, st, now))
+	}
+	fmt.Println(scheduleDimStyle.Render("  Run them with: rigrun daemon"))
+	fmt.Println()
+
+	return nil
+}
+
+// printSchedule prints a schedule's listing entry.
+func printSchedule(info ScheduleInfo) {
+	kind := info.Kind
+	if info.Agentic {
+		kind += " (agentic)"
+	}
+	fmt.Printf("  %s  %s  %s\n",
+		scheduleValueStyle.Render(info.Name),
+		scheduleDimStyle.Render(info.Cron),
+		scheduleDimStyle.Render(kind))
+	fmt.Printf("    %s\n", truncateString(info.Prompt, 70))
+
+	next := "disabled"
+	if info.NextRun != nil {
+		next = info.NextRun.Format("2006-01-02 15:04 MST")
+	} else if !info.Disabled {
+		next = "never"
+	}
+	fmt.Printf("    %s\n", scheduleDimStyle.Render(fmt.Sprintf("next: %s   catch-up: %s   from: %s", next, info.CatchUp, info.Source)))
+
+	if info.LastRun != nil {
+		status := scheduleDimStyle.Render("running")
+		switch info.LastStatus {
+		case "success":
+			status = scheduleSuccessStyle.Render("success")
+		case "":
+		default:
+			status = scheduleErrorStyle.Render(info.LastStatus)
+		}
+		fmt.Printf("    %s %s %s\n", scheduleDimStyle.Render("last: "+formatTimeAgo(*info.LastRun)), status,
+			scheduleDimStyle.Render(shortTaskID(info.LastTaskID)))
+		if info.LastError != "" {
+			fmt.Printf("    %s\n", scheduleErrorStyle.Render(truncateString(info.LastError, 70)))
+		}
+	}
+	fmt.Println()
+}
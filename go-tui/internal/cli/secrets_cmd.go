@@ -0,0 +1,238 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// secrets_cmd.go - Secret scanning CLI command and outbound guard setup.
+//
+// CLI: Comprehensive help and examples for all commands
+//
+// Implements NIST 800-53 SC-7(10) (Prevent Exfiltration). The same detector
+// that guards prompts sent to cloud tiers is exposed here so files can be
+// checked before they are attached with @file or committed.
+//
+// Command: scan-secrets <path>
+// Short:   Scan files for credentials (SC-7(10))
+// Aliases: secrets
+//
+// Examples:
+//   rigrun scan-secrets .                       Scan the current directory
+//   rigrun scan-secrets config/.env             Scan a single file
+//   rigrun scan-secrets . --pattern 'ACME-\d+'  Add a custom pattern
+//   rigrun scan-secrets . --json                Findings in JSON format
+//
+// Exit status is 1 when secrets are found, so the command can be used as a
+// pre-commit hook.
+//
+// Flags:
+//   --pattern REGEX     Additional secret pattern (repeatable)
+//   --json              Output in JSON format
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/jeranaias/rigrun-tui/internal/cloud"
+	"github.com/jeranaias/rigrun-tui/internal/config"
+	"github.com/jeranaias/rigrun-tui/internal/security"
+)
+
+// =============================================================================
+// SECRETS COMMAND STYLES
+// =============================================================================
+
+var (
+	// Secrets title style
+	secretsTitleStyle = lipgloss.NewStyle().
+				Bold(true).
+				Foreground(lipgloss.Color("39")). // Cyan
+				MarginBottom(1)
+
+	// Secrets location style
+	secretsLocationStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("245")) // Light gray
+
+	// Secrets type style
+	secretsTypeStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("196")).
+				Bold(true)
+
+	// Secrets success style
+	secretsSuccessStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("82")).
+				Bold(true)
+)
+
+// =============================================================================
+// SECRETS ARGUMENTS
+// =============================================================================
+
+// ScanSecretsArgs holds parsed scan-secrets command arguments.
+type ScanSecretsArgs struct {
+	Path     string
+	Patterns []string
+	JSON     bool
+}
+
+// ScanSecretsResult is the JSON payload for scan-secrets.
+type ScanSecretsResult struct {
+	Path     string                   `json:"path"`
+	Count    int                      `json:"count"`
+	Findings []security.SecretFinding `json:"findings"`
+}
+
+// parseScanSecretsArgs parses scan-secrets command specific arguments.
+func parseScanSecretsArgs(args *Args, remaining []string) ScanSecretsArgs {
+	scanArgs := ScanSecretsArgs{
+		JSON: args.JSON,
+	}
+
+	for i := 0; i < len(remaining); i++ {
+		arg := remaining[i]
+
+		switch arg {
+		case "--json":
+			scanArgs.JSON = true
+		case "--pattern", "-p":
+			if i+1 < len(remaining) {
+				i++
+				scanArgs.Patterns = append(scanArgs.Patterns, remaining[i])
+			}
+		default:
+			if strings.HasPrefix(arg, "--pattern=") {
+				scanArgs.Patterns = append(scanArgs.Patterns, strings.TrimPrefix(arg, "--pattern="))
+			} else if !strings.HasPrefix(arg, "-") && scanArgs.Path == "" {
+				scanArgs.Path = arg
+			}
+		}
+	}
+
+	return scanArgs
+}
+
+// =============================================================================
+// HANDLE SCAN-SECRETS
+// =============================================================================
+
+// ErrSecretsFound is returned by HandleScanSecrets when secrets are found.
+var ErrSecretsFound = errors.New("secrets found")
+
+// HandleScanSecrets handles the "scan-secrets" command.
+// Returns ErrSecretsFound (exit status 1) when any secret is found.
+func HandleScanSecrets(args Args) error {
+	scanArgs := parseScanSecretsArgs(&args, args.Raw)
+	if scanArgs.Path == "" {
+		return fmt.Errorf("usage: rigrun scan-secrets <path> [--pattern REGEX] [--json]")
+	}
+
+	// Configured patterns apply in addition to those on the command line
+	patterns := scanArgs.Patterns
+	if cfg := config.Global(); cfg != nil {
+		patterns = append(append([]string(nil), cfg.Security.SecretPatterns...), patterns...)
+	}
+	detector, err := security.NewSecretDetector(patterns)
+	if err != nil {
+		return err
+	}
+
+	findings, err := detector.ScanPath(scanArgs.Path)
+	if err != nil {
+		return fmt.Errorf("scan failed: %w", err)
+	}
+
+	security.AuditLogEvent("", "SECRET_SCAN", map[string]string{
+		"path":  scanArgs.Path,
+		"count": fmt.Sprintf("%d", len(findings)),
+		"types": security.SummarizeSecretFindings(findings),
+	})
+
+	if scanArgs.JSON {
+		if findings == nil {
+			findings = []security.SecretFinding{}
+		}
+		resp := NewJSONResponse("scan-secrets", ScanSecretsResult{
+			Path:     scanArgs.Path,
+			Count:    len(findings),
+			Findings: findings,
+		})
+		if err := resp.Print(); err != nil {
+			return err
+		}
+	} else {
+		printSecretFindings(scanArgs.Path, findings)
+	}
+
+	if len(findings) > 0 {
+		return ErrSecretsFound
+	}
+	return nil
+}
+
+// printSecretFindings prints findings in a grep-like format.
+func printSecretFindings(path string, findings []security.SecretFinding) {
+	fmt.Println()
+	fmt.Println(secretsTitleStyle.Render("Secret Scan (SC-7(10))"))
+
+	if len(findings) == 0 {
+		fmt.Printf("  %s No secrets found in %s\n\n", secretsSuccessStyle.Render("[OK]"), path)
+		return
+	}
+
+	for _, f := range findings {
+		loc := fmt.Sprintf("%s:%d:%d", f.Path, f.Line, f.Column)
+		fmt.Printf("  %s  %s  %s\n",
+			secretsLocationStyle.Render(loc),
+			secretsTypeStyle.Render(f.Type),
+			f.Preview)
+	}
+	fmt.Printf("\n  %d secret(s) found: %s\n\n", len(findings), security.SummarizeSecretFindings(findings))
+}
+
+// =============================================================================
+// OUTBOUND GUARD
+// =============================================================================
+
+// NewSecretGuard builds the outbound secret guard from configuration.
+// Returns nil when secret scanning is disabled. Invalid custom patterns are
+// reported as an error, but the returned guard still applies the built-in
+// rules so a bad pattern never disables protection.
+func NewSecretGuard(cfg *config.Config, prompter security.SecretPrompter) (*security.SecretGuard, error) {
+	if cfg == nil || !cfg.Security.SecretScanning {
+		return nil, nil
+	}
+	detector, err := security.NewSecretDetector(cfg.Security.SecretPatterns)
+	guard := security.NewSecretGuard(detector, cfg.Security.SecretAction)
+	if prompter != nil {
+		guard.SetPrompter(prompter)
+	}
+	return guard, err
+}
+
+// terminalSecretPrompter returns a prompter for the "ask" action when stdin
+// is a terminal, or nil (which blocks) when running non-interactively.
+func terminalSecretPrompter() security.SecretPrompter {
+	if !IsTTY() {
+		return nil
+	}
+	return security.PromptSecretAction(os.Stdin, os.Stderr)
+}
+
+// guardCloudClient attaches the configured secret guard to a CLI cloud
+// client and reports every outbound scan with findings on stderr.
+func guardCloudClient(client *cloud.OpenRouterClient, cfg *config.Config) {
+	guard, err := NewSecretGuard(cfg, terminalSecretPrompter())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	if guard == nil {
+		return
+	}
+	guard.SetNotifier(func(result security.SecretScanResult) {
+		fmt.Fprintf(os.Stderr, "%s %s\n", secretsTypeStyle.Render("[SECRETS]"), result.Describe())
+	})
+	client.WithSecretGuard(guard)
+}
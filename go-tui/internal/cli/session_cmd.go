@@ -29,6 +29,7 @@
 //   rigrun session export 1 --format json   Export as JSON
 //   rigrun session export 1 --format md     Export as Markdown
 //   rigrun session export 1 --format txt    Export as plain text
+//   rigrun session export 1 --tree          Include every branch
 //   rigrun session delete 1 --confirm       Delete first session
 //   rigrun session delete-all --confirm     Delete all sessions
 //   rigrun session stats                    Show statistics
@@ -36,6 +37,7 @@
 //
 // Flags:
 //   --format FORMAT     Export format: json, md, txt (default: txt)
+//   --tree              Export all branches, not just the active one
 //   --confirm           Required for delete operations
 //   --json              Output in JSON format
 //
@@ -68,6 +70,7 @@ type SessionArgs struct {
 	Subcommand string   // list, show, export, delete, delete-all, stats
 	SessionID  string   // Session ID for show, export, delete
 	Format     string   // Export format: json, md, txt
+	Tree       bool     // Export all branches, not just the active one
 	Confirm    bool     // Confirmation flag for delete operations
 	JSON       bool     // Output in JSON format
 	Raw        []string // Raw remaining arguments
@@ -77,7 +80,7 @@ type SessionArgs struct {
 // Subcommands:
 //   - session list: List all saved sessions
 //   - session show <id>: Show session details
-//   - session export <id> [--format json|md|txt]: Export session transcript
+//   - session export <id> [--format json|md|txt] [--tree]: Export session transcript
 //   - session delete <id> --confirm: Delete a session
 //   - session delete-all --confirm: Delete all sessions
 //   - session stats: Show session statistics
@@ -124,6 +127,8 @@ func parseSessionCmdArgs(args Args) SessionArgs {
 			}
 		case "--confirm":
 			sessionArgs.Confirm = true
+		case "--tree":
+			sessionArgs.Tree = true
 		case "--json":
 			sessionArgs.JSON = true
 		default:
@@ -362,7 +367,7 @@ func outputSessionShowText(conv *storage.StoredConversation) error {
 // handleSessionExport exports a session transcript.
 func handleSessionExport(args SessionArgs) error {
 	if args.SessionID == "" {
-		return fmt.Errorf("session ID required\nUsage: rigrun session export <id> [--format json|md|txt]")
+		return fmt.Errorf("session ID required\nUsage: rigrun session export <id> [--format json|md|txt] [--tree]")
 	}
 
 	// Validate format
@@ -385,8 +390,14 @@ func handleSessionExport(args SessionArgs) error {
 	logSessionEvent("SESSION_EXPORT", conv.ID, map[string]string{
 		"format":        args.Format,
 		"message_count": strconv.Itoa(len(conv.Messages)),
+		"tree":          strconv.FormatBool(args.Tree),
 	})
 
+	// Only the active branch is exported unless the whole tree is requested
+	if !args.Tree {
+		conv.Branches = nil
+	}
+
 	switch args.Format {
 	case "json":
 		return exportSessionJSON(conv)
@@ -427,28 +438,13 @@ func exportSessionMarkdown(conv *storage.StoredConversation) error {
 	sb.WriteString("## Transcript\n\n")
 
 	for _, msg := range conv.Messages {
-		role := formatRole(msg.Role)
-		sb.WriteString(fmt.Sprintf("### %s\n\n", role))
-
-		// Handle tool messages specially
-		if msg.Role == "tool" && msg.ToolName != "" {
-			sb.WriteString(fmt.Sprintf("**Tool:** %s  \n", msg.ToolName))
-			if msg.ToolInput != "" {
-				sb.WriteString(fmt.Sprintf("**Input:** `%s`  \n", msg.ToolInput))
-			}
-			sb.WriteString(fmt.Sprintf("**Result:** %s  \n", statusText(msg.IsSuccess)))
-			sb.WriteString("\n```\n")
-			sb.WriteString(msg.ToolResult)
-			sb.WriteString("\n```\n\n")
-		} else {
-			sb.WriteString(msg.Content)
-			sb.WriteString("\n\n")
-		}
+		writeSessionMarkdownMessage(&sb, msg)
+	}
 
-		// Add statistics for assistant messages
-		if msg.Role == "assistant" && msg.TokenCount > 0 {
-			sb.WriteString(fmt.Sprintf("*%d tokens | %.1f tok/s | TTFT: %dms*\n\n",
-				msg.TokenCount, msg.TokensPerSec, msg.TTFTMs))
+	for n, thread := range conv.BranchThreads() {
+		sb.WriteString(fmt.Sprintf("---\n\n## Branch %d\n\n*Forks %s*\n\n", n+1, conv.BranchOrigin(thread)))
+		for _, msg := range thread.Messages {
+			writeSessionMarkdownMessage(&sb, msg)
 		}
 	}
 
@@ -456,6 +452,33 @@ func exportSessionMarkdown(conv *storage.StoredConversation) error {
 	return nil
 }
 
+// writeSessionMarkdownMessage writes one transcript message as Markdown.
+func writeSessionMarkdownMessage(sb *strings.Builder, msg storage.StoredMessage) {
+	role := formatRole(msg.Role)
+	sb.WriteString(fmt.Sprintf("### %s\n\n", role))
+
+	// Handle tool messages specially
+	if msg.Role == "tool" && msg.ToolName != "" {
+		sb.WriteString(fmt.Sprintf("**Tool:** %s  \n", msg.ToolName))
+		if msg.ToolInput != "" {
+			sb.WriteString(fmt.Sprintf("**Input:** `%s`  \n", msg.ToolInput))
+		}
+		sb.WriteString(fmt.Sprintf("**Result:** %s  \n", statusText(msg.IsSuccess)))
+		sb.WriteString("\n```\n")
+		sb.WriteString(msg.ToolResult)
+		sb.WriteString("\n```\n\n")
+	} else {
+		sb.WriteString(msg.Content)
+		sb.WriteString("\n\n")
+	}
+
+	// Add statistics for assistant messages
+	if msg.Role == "assistant" && msg.TokenCount > 0 {
+		sb.WriteString(fmt.Sprintf("*%d tokens | %.1f tok/s | TTFT: %dms*\n\n",
+			msg.TokenCount, msg.TokensPerSec, msg.TTFTMs))
+	}
+}
+
 // exportSessionText exports session as plain text.
 func exportSessionText(conv *storage.StoredConversation) error {
 	var sb strings.Builder
@@ -472,30 +495,43 @@ func exportSessionText(conv *storage.StoredConversation) error {
 
 	// Messages
 	for i, msg := range conv.Messages {
-		role := formatRole(msg.Role)
-		sb.WriteString(fmt.Sprintf("[%d] %s:\n", i+1, role))
-
-		// Handle tool messages specially
-		if msg.Role == "tool" && msg.ToolName != "" {
-			sb.WriteString(fmt.Sprintf("    Tool: %s (%s)\n", msg.ToolName, statusText(msg.IsSuccess)))
-			if msg.ToolInput != "" {
-				sb.WriteString(fmt.Sprintf("    Input: %s\n", msg.ToolInput))
-			}
-			sb.WriteString("    Result:\n")
-			// Indent tool result
-			for _, line := range strings.Split(msg.ToolResult, "\n") {
-				sb.WriteString("      " + line + "\n")
-			}
-		} else {
-			sb.WriteString(msg.Content)
+		writeSessionTextMessage(&sb, fmt.Sprintf("%d", i+1), msg)
+	}
+
+	for n, thread := range conv.BranchThreads() {
+		sb.WriteString(strings.Repeat("-", 60) + "\n")
+		sb.WriteString(fmt.Sprintf("Branch %d (forks %s)\n\n", n+1, conv.BranchOrigin(thread)))
+		for i, msg := range thread.Messages {
+			writeSessionTextMessage(&sb, fmt.Sprintf("b%d.%d", n+1, i+1), msg)
 		}
-		sb.WriteString("\n\n")
 	}
 
 	fmt.Print(sb.String())
 	return nil
 }
 
+// writeSessionTextMessage writes one transcript message as plain text.
+func writeSessionTextMessage(sb *strings.Builder, label string, msg storage.StoredMessage) {
+	role := formatRole(msg.Role)
+	sb.WriteString(fmt.Sprintf("[%s] %s:\n", label, role))
+
+	// Handle tool messages specially
+	if msg.Role == "tool" && msg.ToolName != "" {
+		sb.WriteString(fmt.Sprintf("    Tool: %s (%s)\n", msg.ToolName, statusText(msg.IsSuccess)))
+		if msg.ToolInput != "" {
+			sb.WriteString(fmt.Sprintf("    Input: %s\n", msg.ToolInput))
+		}
+		sb.WriteString("    Result:\n")
+		// Indent tool result
+		for _, line := range strings.Split(msg.ToolResult, "\n") {
+			sb.WriteString("      " + line + "\n")
+		}
+	} else {
+		sb.WriteString(msg.Content)
+	}
+	sb.WriteString("\n\n")
+}
+
 // =============================================================================
 // SESSION DELETE
 // =============================================================================
@@ -0,0 +1,354 @@
+// stream_json.go - NDJSON event stream for headless "rigrun ask".
+//
+// With --output-format stream-json, "rigrun ask" writes one JSON event per
+// line to stdout while it works: the routing decision, token deltas, tool
+// calls, permission decisions, tool results, cost updates, the final answer
+// and errors. Editor integrations and CI scripts can follow the agentic loop
+// as it runs instead of waiting for a final blob.
+//
+// With --input-format stream-json, follow-up user messages are read from
+// stdin, one JSON object per line, and answered in the same conversation
+// until stdin is closed.
+//
+// The schema is documented in docs/STREAM_JSON.md. Every event carries the
+// schema version; it is bumped only on incompatible changes, while new event
+// types and fields may be added at any time.
+//
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jeranaias/rigrun-tui/internal/router"
+)
+
+// =============================================================================
+// SCHEMA
+// =============================================================================
+
+// StreamJSONSchemaVersion is the version of the stream-json event schema.
+const StreamJSONSchemaVersion = 1
+
+// Formats accepted by --output-format and --input-format.
+const (
+	FormatText       = "text"
+	FormatJSON       = "json"
+	FormatStreamJSON = "stream-json"
+)
+
+// Stream event types.
+const (
+	StreamEventInit       = "init"        // First event: schema version and session settings
+	StreamEventRouting    = "routing"     // Routing decision for a turn
+	StreamEventToken      = "token"       // Response text delta
+	StreamEventToolCall   = "tool_call"   // The model requested a tool call
+	StreamEventPermission = "permission"  // Whether the tool call may run
+	StreamEventToolResult = "tool_result" // Output of a tool call
+	StreamEventCost       = "cost"        // Token and cost totals after a model call
+	StreamEventResult     = "result"      // Final answer of a turn
+	StreamEventError      = "error"       // An error; fatal errors end the stream
+)
+
+// StreamEvent is one line of stream-json output.
+type StreamEvent struct {
+	Type      string      `json:"type"`
+	Schema    int         `json:"schema"`
+	Seq       int         `json:"seq"`            // 1-based position in the stream
+	Timestamp string      `json:"timestamp"`      // RFC 3339, UTC
+	Turn      int         `json:"turn,omitempty"` // 1-based user turn
+	Data      interface{} `json:"data"`
+}
+
+// InitEventData describes the session. The model is chosen by routing, so it
+// is reported by the routing event instead.
+type InitEventData struct {
+	Version     string `json:"version"`
+	Agentic     bool   `json:"agentic"`
+	InputFormat string `json:"input_format"`
+}
+
+// RoutingEventData is the routing decision for a turn.
+type RoutingEventData struct {
+	Tier               string  `json:"tier"`
+	Model              string  `json:"model"`
+	Complexity         string  `json:"complexity"`
+	Reason             string  `json:"reason"`
+	EstimatedCostCents float64 `json:"estimated_cost_cents"`
+}
+
+// TokenEventData is a response text delta.
+type TokenEventData struct {
+	Text string `json:"text"`
+}
+
+// ToolCallEventData is a tool call requested by the model.
+type ToolCallEventData struct {
+	ID     string                 `json:"id"`
+	Tool   string                 `json:"tool"`
+	Params map[string]interface{} `json:"params"`
+}
+
+// PermissionEventData is the permission decision for a tool call.
+type PermissionEventData struct {
+	ID       string `json:"id"`
+	Tool     string `json:"tool"`
+	Decision string `json:"decision"` // "allow" or "deny"
+	Reason   string `json:"reason"`
+}
+
+// ToolResultEventData is the output of a tool call.
+type ToolResultEventData struct {
+	ID         string `json:"id"`
+	Tool       string `json:"tool"`
+	Success    bool   `json:"success"`
+	Output     string `json:"output"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// CostEventData holds running totals for the session.
+type CostEventData struct {
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	CostCents    float64 `json:"cost_cents"`
+}
+
+// ResultEventData is the final answer of a turn.
+type ResultEventData struct {
+	Response     string  `json:"response"`
+	Tier         string  `json:"tier"`
+	Model        string  `json:"model"`
+	Iterations   int     `json:"iterations"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	CostCents    float64 `json:"cost_cents"`
+	DurationMs   int64   `json:"duration_ms"`
+	StopReason   string  `json:"stop_reason"` // "complete", "max_iterations" or "budget_exceeded"
+}
+
+// ErrorEventData is an error. Fatal errors are the last event.
+type ErrorEventData struct {
+	Message string `json:"message"`
+	Fatal   bool   `json:"fatal"`
+}
+
+// StreamInputMessage is one line of stream-json input.
+type StreamInputMessage struct {
+	Type    string `json:"type"` // "user"
+	Content string `json:"content"`
+}
+
+// =============================================================================
+// WRITER
+// =============================================================================
+
+// StreamJSONWriter writes stream-json events. All methods are safe on a nil
+// writer, which discards events, so callers need not check the output mode.
+type StreamJSONWriter struct {
+	mu    sync.Mutex
+	w     io.Writer
+	seq   int
+	turn  int
+	calls int
+
+	// Running totals for cost events
+	inputTokens  int
+	outputTokens int
+	costCents    float64
+}
+
+// NewStreamJSONWriter creates a writer that emits events to w.
+func NewStreamJSONWriter(w io.Writer) *StreamJSONWriter {
+	return &StreamJSONWriter{w: w}
+}
+
+// Emit writes one event.
+func (s *StreamJSONWriter) Emit(eventType string, data interface{}) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq++
+	line, err := json.Marshal(StreamEvent{
+		Type:      eventType,
+		Schema:    StreamJSONSchemaVersion,
+		Seq:       s.seq,
+		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+		Turn:      s.turn,
+		Data:      data,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = s.w.Write(append(line, '\n'))
+	return err
+}
+
+// StartTurn begins the next user turn.
+func (s *StreamJSONWriter) StartTurn() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.turn++
+	s.mu.Unlock()
+}
+
+// Routing emits a routing decision.
+func (s *StreamJSONWriter) Routing(decision router.RoutingDecision, model string) {
+	s.Emit(StreamEventRouting, RoutingEventData{
+		Tier:               decision.Tier.Name(),
+		Model:              model,
+		Complexity:         decision.Complexity.String(),
+		Reason:             decision.Reason,
+		EstimatedCostCents: decision.EstimatedCostCents,
+	})
+}
+
+// Token emits a response text delta. Empty deltas are dropped.
+func (s *StreamJSONWriter) Token(text string) {
+	if text != "" {
+		s.Emit(StreamEventToken, TokenEventData{Text: text})
+	}
+}
+
+// ToolCall emits a tool call request and returns its ID, which ties the
+// permission and result events to it.
+func (s *StreamJSONWriter) ToolCall(tool string, params map[string]interface{}) string {
+	if s == nil {
+		return ""
+	}
+	s.mu.Lock()
+	s.calls++
+	id := fmt.Sprintf("call_%d", s.calls)
+	s.mu.Unlock()
+
+	s.Emit(StreamEventToolCall, ToolCallEventData{ID: id, Tool: tool, Params: params})
+	return id
+}
+
+// Permission emits the permission decision for a tool call.
+func (s *StreamJSONWriter) Permission(id, tool string, allowed bool, reason string) {
+	decision := "allow"
+	if !allowed {
+		decision = "deny"
+	}
+	s.Emit(StreamEventPermission, PermissionEventData{ID: id, Tool: tool, Decision: decision, Reason: reason})
+}
+
+// ToolResult emits the output of a tool call.
+func (s *StreamJSONWriter) ToolResult(id, tool string, success bool, output string, duration time.Duration) {
+	s.Emit(StreamEventToolResult, ToolResultEventData{
+		ID:         id,
+		Tool:       tool,
+		Success:    success,
+		Output:     output,
+		DurationMs: duration.Milliseconds(),
+	})
+}
+
+// Cost adds a model call's usage to the running totals and emits them.
+func (s *StreamJSONWriter) Cost(inputTokens, outputTokens int, costCents float64) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.inputTokens += inputTokens
+	s.outputTokens += outputTokens
+	s.costCents += costCents
+	data := CostEventData{InputTokens: s.inputTokens, OutputTokens: s.outputTokens, CostCents: s.costCents}
+	s.mu.Unlock()
+
+	s.Emit(StreamEventCost, data)
+}
+
+// Result emits the final answer of a turn.
+func (s *StreamJSONWriter) Result(data ResultEventData) {
+	s.Emit(StreamEventResult, data)
+}
+
+// Error emits an error and returns it unchanged.
+func (s *StreamJSONWriter) Error(err error, fatal bool) error {
+	if err != nil {
+		s.Emit(StreamEventError, ErrorEventData{Message: err.Error(), Fatal: fatal})
+	}
+	return err
+}
+
+// =============================================================================
+// READER
+// =============================================================================
+
+// maxStreamInputLine is the longest accepted input line (1MB).
+const maxStreamInputLine = 1024 * 1024
+
+// StreamJSONReader reads follow-up user messages in stream-json format.
+type StreamJSONReader struct {
+	scanner *bufio.Scanner
+}
+
+// NewStreamJSONReader creates a reader over r.
+func NewStreamJSONReader(r io.Reader) *StreamJSONReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamInputLine)
+	return &StreamJSONReader{scanner: scanner}
+}
+
+// Next returns the content of the next user message, skipping blank lines.
+// It returns io.EOF when the input ends. A malformed line returns an error;
+// the caller may report it and call Next again.
+func (r *StreamJSONReader) Next() (string, error) {
+	for r.scanner.Scan() {
+		line := strings.TrimSpace(r.scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var msg StreamInputMessage
+		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+			return "", fmt.Errorf("invalid input line: %w", err)
+		}
+		if msg.Type != "user" {
+			return "", fmt.Errorf("unsupported input message type %q (expected \"user\")", msg.Type)
+		}
+		if strings.TrimSpace(msg.Content) == "" {
+			return "", fmt.Errorf("user message has no content")
+		}
+		return msg.Content, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", io.EOF
+}
+
+// NextUserMessage returns the next valid user message, reporting malformed
+// lines as non-fatal error events. It returns false at the end of input or
+// when r is nil.
+func (r *StreamJSONReader) NextUserMessage(out *StreamJSONWriter) (string, bool) {
+	if r == nil {
+		return "", false
+	}
+	for {
+		content, err := r.Next()
+		if err == nil {
+			return content, true
+		}
+		if err == io.EOF {
+			return "", false
+		}
+		out.Error(err, false)
+		if r.scanner.Err() != nil {
+			return "", false
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// decodeEvents parses NDJSON output into generic events.
+func decodeEvents(t *testing.T, data []byte) []map[string]interface{} {
+	t.Helper()
+	var events []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var event map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("invalid event line %q: %v", line, err)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestStreamJSONWriter(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewStreamJSONWriter(&buf)
+
+	s.Emit(StreamEventInit, InitEventData{Version: "test", InputFormat: FormatStreamJSON})
+	s.StartTurn()
+	s.Token("Hello")
+	s.Token("") // dropped
+	id := s.ToolCall("Read", map[string]interface{}{"file_path": "a.go"})
+	s.Permission(id, "Read", false, "denied by policy")
+	s.ToolResult(id, "Read", false, "not permitted", time.Millisecond)
+	s.Cost(10, 5, 0.5)
+	s.Cost(20, 5, 0.25)
+	s.Error(errors.New("boom"), true)
+
+	events := decodeEvents(t, buf.Bytes())
+	wantTypes := []string{StreamEventInit, StreamEventToken, StreamEventToolCall, StreamEventPermission,
+		StreamEventToolResult, StreamEventCost, StreamEventCost, StreamEventError}
+	if len(events) != len(wantTypes) {
+		t.Fatalf("got %d events, want %d:\n%s", len(events), len(wantTypes), buf.String())
+	}
+	for i, event := range events {
+		if event["type"] != wantTypes[i] {
+			t.Errorf("event %d type = %v, want %s", i, event["type"], wantTypes[i])
+		}
+		if event["schema"] != float64(StreamJSONSchemaVersion) || event["seq"] != float64(i+1) {
+			t.Errorf("event %d has schema %v seq %v", i, event["schema"], event["seq"])
+		}
+	}
+
+	if _, ok := events[0]["turn"]; ok {
+		t.Error("events before the first turn should omit turn")
+	}
+	call := events[2]["data"].(map[string]interface{})
+	permission := events[3]["data"].(map[string]interface{})
+	if call["id"] != id || permission["id"] != id || permission["decision"] != "deny" {
+		t.Errorf("permission not tied to the call: %v / %v", call, permission)
+	}
+	cost := events[6]["data"].(map[string]interface{})
+	if cost["input_tokens"] != float64(30) || cost["cost_cents"] != 0.75 {
+		t.Errorf("cost event should carry running totals: %v", cost)
+	}
+
+	// A nil writer discards everything
+	var nilWriter *StreamJSONWriter
+	nilWriter.Token("x")
+	if nilWriter.ToolCall("Read", nil) != "" {
+		t.Error("nil writer should not assign call IDs")
+	}
+}
+
+func TestStreamJSONReader(t *testing.T) {
+	input := strings.Join([]string{
+		`{"type":"user","content":"first"}`,
+		``,
+		`not json`,
+		`{"type":"control","content":"x"}`,
+		`{"type":"user","content":"second"}`,
+	}, "\n")
+	var out bytes.Buffer
+	r := NewStreamJSONReader(strings.NewReader(input))
+	w := NewStreamJSONWriter(&out)
+
+	var got []string
+	for {
+		content, ok := r.NextUserMessage(w)
+		if !ok {
+			break
+		}
+		got = append(got, content)
+	}
+	if strings.Join(got, ",") != "first,second" {
+		t.Errorf("messages = %q", got)
+	}
+	if errs := decodeEvents(t, out.Bytes()); len(errs) != 2 || errs[0]["type"] != StreamEventError {
+		t.Errorf("expected two error events for the bad lines, got %s", out.String())
+	}
+
+	if _, err := NewStreamJSONReader(strings.NewReader("")).Next(); err != io.EOF {
+		t.Errorf("empty input should return io.EOF, got %v", err)
+	}
+}
+
+func TestAskStreamsValidation(t *testing.T) {
+	tests := []struct {
+		args    Args
+		wantErr bool
+		stream  bool
+		input   bool
+	}{
+		{Args{}, false, false, false},
+		{Args{OutputFormat: FormatStreamJSON}, false, true, false},
+		{Args{OutputFormat: FormatStreamJSON, InputFormat: FormatStreamJSON}, false, true, true},
+		{Args{InputFormat: FormatStreamJSON}, true, false, false},
+		{Args{OutputFormat: "yaml"}, true, false, false},
+		{Args{InputFormat: "json"}, true, false, false},
+	}
+	for _, tt := range tests {
+		stream, input, err := askStreams(tt.args)
+		if (err != nil) != tt.wantErr || (stream != nil) != tt.stream || (input != nil) != tt.input {
+			t.Errorf("askStreams(%+v) = %v, %v, %v", tt.args, stream != nil, input != nil, err)
+		}
+	}
+}
+
+func TestParseAskArgs_Formats(t *testing.T) {
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+
+	os.Args = []string{"rigrun", "ask", "--output-format", "stream-json", "--input-format=stream-json", "hi"}
+	cmd, args := Parse()
+	if cmd != CmdAsk || args.OutputFormat != FormatStreamJSON || args.InputFormat != FormatStreamJSON || args.Query != "hi" {
+		t.Errorf("unexpected parse: %v %+v", cmd, args)
+	}
+	if !args.MachineOutput() || args.JSON {
+		t.Error("stream-json output is machine output but not the --json blob")
+	}
+
+	os.Args = []string{"rigrun", "ask", "--output-format=json", "hi"}
+	if _, args := Parse(); !args.JSON {
+		t.Error("--output-format json should imply --json")
+	}
+}
@@ -35,6 +35,9 @@ var validCommands = []string{
 	"rbac",
 	"maintenance",
 	"boundary",
+	"policy",
+	"scan-secrets",
+	"review",
 	"sectest",
 	"conmon",
 	"lockout",
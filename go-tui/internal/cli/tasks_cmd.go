@@ -0,0 +1,418 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// tasks_cmd.go - Background task CLI commands for rigrun.
+//
+// CLI: Comprehensive help and examples for all commands
+//
+// Background tasks started with /task in the TUI are saved to
+// ~/.rigrun/tasks.db along with their output, so they survive restarts:
+// queued tasks run again in the next session, and tasks that were running
+// when rigrun exited are marked interrupted with their partial output.
+//
+// Command: tasks [subcommand]
+// Short:   List, inspect, cancel or retry background tasks
+// Aliases: task
+//
+// Subcommands:
+//   list (default)      List tasks, most recent first
+//   logs <id>           Print a task's output
+//   cancel <id>         Cancel a queued or running task
+//   retry <id>          Queue a finished task again
+//
+// Examples:
+//   rigrun tasks                       List recent tasks
+//   rigrun tasks list --limit 100      List more tasks
+//   rigrun tasks logs 3f2a             Print output (ID prefix accepted)
+//   rigrun tasks cancel 3f2a           Cancel a task
+//   rigrun tasks retry 3f2a --json     Retry, JSON output
+//
+// A running task is canceled by the session running it, within a few
+// seconds. Retried tasks run in the next rigrun session, or in the current
+// one if it is open. Only the most recent output of very long tasks is
+// kept (see tasks.MaxOutputChunks).
+//
+// Flags:
+//   --limit N           Number of tasks to list (default 20, 0 for all)
+//   --json              Output in JSON format
+//
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/jeranaias/rigrun-tui/internal/tasks"
+)
+
+// =============================================================================
+// TASKS COMMAND STYLES
+// =============================================================================
+
+var (
+	// Tasks title style
+	tasksTitleStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("39")). // Cyan
+			MarginBottom(1)
+
+	// Tasks label style
+	tasksLabelStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("245")). // Light gray
+			Width(14)
+
+	// Tasks value style
+	tasksValueStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("255")) // White
+
+	// Tasks success style
+	tasksSuccessStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("82")).
+				Bold(true)
+
+	// Tasks warning style
+	tasksWarningStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("220")).
+				Bold(true)
+
+	// Tasks error style
+	tasksErrorStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("196")).
+			Bold(true)
+
+	// Tasks dim style
+	tasksDimStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("242"))
+)
+
+// =============================================================================
+// TASKS ARGUMENTS
+// =============================================================================
+
+// defaultTasksLimit is how many tasks "tasks list" shows by default.
+const defaultTasksLimit = 20
+
+// TasksArgs holds parsed tasks command arguments.
+type TasksArgs struct {
+	Subcommand string
+	TaskID     string
+	Limit      int
+	JSON       bool
+}
+
+// parseTasksArgs parses tasks command specific arguments.
+func parseTasksArgs(args *Args, remaining []string) TasksArgs {
+	tasksArgs := TasksArgs{
+		Limit: defaultTasksLimit,
+		JSON:  args.JSON,
+	}
+
+	if len(remaining) > 0 {
+		tasksArgs.Subcommand = remaining[0]
+		remaining = remaining[1:]
+	}
+
+	for i := 0; i < len(remaining); i++ {
+		arg := remaining[i]
+		switch arg {
+		case "--json":
+			tasksArgs.JSON = true
+		case "--limit", "-n":
+			if i+1 < len(remaining) {
+				if n, err := strconv.Atoi(remaining[i+1]); err == nil && n >= 0 {
+					tasksArgs.Limit = n
+				}
+				i++
+			}
+		default:
+			if !strings.HasPrefix(arg, "-") && tasksArgs.TaskID == "" {
+				tasksArgs.TaskID = arg
+			}
+		}
+	}
+
+	return tasksArgs
+}
+
+// =============================================================================
+// TASKS JSON TYPES
+// =============================================================================
+
+// TaskInfo is the JSON form of a stored task.
+type TaskInfo struct {
+	ID             string                 `json:"id"`
+	Description    string                 `json:"description"`
+	Command        string                 `json:"command"`
+	Args           []string               `json:"args,omitempty"`
+	Status         string                 `json:"status"`
+	Progress       int                    `json:"progress"`
+	ConversationID string                 `json:"conversation_id,omitempty"`
+	Error          string                 `json:"error,omitempty"`
+	Result         string                 `json:"result,omitempty"`
+	Metadata       map[string]interface{} `json:"metadata,omitempty"`
+	CreatedAt      time.Time              `json:"created_at"`
+	StartedAt      *time.Time             `json:"started_at,omitempty"`
+	EndedAt        *time.Time             `json:"ended_at,omitempty"`
+	DurationMs     int64                  `json:"duration_ms,omitempty"`
+}
+
+// TaskLogs is the JSON form of a task's output.
+type TaskLogs struct {
+	TaskInfo
+	Output    string `json:"output"`
+	Truncated bool   `json:"truncated"`
+}
+
+// newTaskInfo converts a task to its JSON form.
+func newTaskInfo(task *tasks.Task) TaskInfo {
+	info := TaskInfo{
+		ID:             task.ID,
+		Description:    task.Description,
+		Command:        task.Command,
+		Args:           task.Args,
+		Status:         task.Status.String(),
+		Progress:       task.Progress,
+		ConversationID: task.ConversationID,
+		Error:          task.Error,
+		Result:         task.Result,
+		Metadata:       task.Metadata,
+		CreatedAt:      task.CreatedAt,
+		DurationMs:     task.Duration().Milliseconds(),
+	}
+	if !task.StartTime.IsZero() {
+		info.StartedAt = &task.StartTime
+	}
+	if !task.EndTime.IsZero() {
+		info.EndedAt = &task.EndTime
+	}
+	return info
+}
+
+// =============================================================================
+// HANDLE TASKS
+// =============================================================================
+
+// HandleTasks handles the "tasks" command with various subcommands.
+// Subcommands:
+//   - tasks list [--limit N]: List tasks
+//   - tasks logs <id>: Print a task's output
+//   - tasks cancel <id>: Cancel a queued or running task
+//   - tasks retry <id>: Queue a finished task again
+func HandleTasks(args Args) error {
+	tasksArgs := parseTasksArgs(&args, args.Raw)
+
+	store, err := tasks.OpenStore("")
+	if err != nil {
+		return fmt.Errorf("failed to open task store: %w", err)
+	}
+	defer store.Close()
+
+	switch tasksArgs.Subcommand {
+	case "", "list", "ls":
+		return handleTasksList(store, tasksArgs)
+	case "logs", "log", "show":
+		return handleTasksLogs(store, tasksArgs)
+	case "cancel":
+		return handleTasksCancel(store, tasksArgs)
+	case "retry":
+		return handleTasksRetry(store, tasksArgs)
+	default:
+		return fmt.Errorf("unknown tasks subcommand: %s\n\nUsage:\n"+
+			"  rigrun tasks list [--limit N]  List tasks\n"+
+			"  rigrun tasks logs <id>         Print a task's output\n"+
+			"  rigrun tasks cancel <id>       Cancel a queued or running task\n"+
+			"  rigrun tasks retry <id>        Queue a finished task again", tasksArgs.Subcommand)
+	}
+}
+
+// requireTaskID checks that a task was named on the command line.
+func requireTaskID(tasksArgs TasksArgs) error {
+	if tasksArgs.TaskID == "" {
+		return fmt.Errorf("task ID required\nUsage: rigrun tasks %s <id>", tasksArgs.Subcommand)
+	}
+	return nil
+}
+
+// =============================================================================
+// TASKS LIST
+// =============================================================================
+
+// handleTasksList lists the stored tasks.
+func handleTasksList(store *tasks.Store, tasksArgs TasksArgs) error {
+	list, err := store.List(tasksArgs.Limit)
+	if err != nil {
+		return fmt.Errorf("failed to list tasks: %w", err)
+	}
+
+	if tasksArgs.JSON {
+		infos := make([]TaskInfo, 0, len(list))
+		for _, task := range list {
+			infos = append(infos, newTaskInfo(task))
+		}
+		return NewJSONResponse("tasks list", infos).Print()
+	}
+
+	fmt.Println()
+	fmt.Println(tasksTitleStyle.Render("Background Tasks"))
+	fmt.Println(separatorStyle.Render(strings.Repeat("=", 41)))
+
+	if len(list) == 0 {
+		fmt.Println(tasksDimStyle.Render("  No tasks. Start one with /task <command> in the TUI."))
+		fmt.Println()
+		return nil
+	}
+
+	for _, task := range list {
+		fmt.Printf("  %s  %s  %s  %s\n",
+			tasksValueStyle.Render(shortTaskID(task.ID)),
+			renderTaskStatus(task.Status),
+			tasksDimStyle.Render(fmt.Sprintf("%-8s %s", formatDurationShort(task.Duration()), formatTimeAgo(task.CreatedAt))),
+			truncateString(task.Description, 50))
+	}
+	fmt.Println()
+	fmt.Println(tasksDimStyle.Render("  Output with: rigrun tasks logs <id>"))
+	fmt.Println()
+
+	return nil
+}
+
+// renderTaskStatus renders a task status, padded to line up in listings.
+func renderTaskStatus(status tasks.TaskStatus) string {
+	text := fmt.Sprintf("%-11s", status)
+	switch status {
+	case tasks.TaskStatusComplete:
+		return tasksSuccessStyle.Render(text)
+	case tasks.TaskStatusFailed:
+		return tasksErrorStyle.Render(text)
+	case tasks.TaskStatusInterrupted, tasks.TaskStatusRunning:
+		return tasksWarningStyle.Render(text)
+	default:
+		return tasksDimStyle.Render(text)
+	}
+}
+
+// =============================================================================
+// TASKS LOGS
+// =============================================================================
+
+// handleTasksLogs prints a task's header and its stored output, one chunk
+// at a time.
+func handleTasksLogs(store *tasks.Store, tasksArgs TasksArgs) error {
+	if err := requireTaskID(tasksArgs); err != nil {
+		return err
+	}
+	task, err := store.Load(tasksArgs.TaskID)
+	if err != nil {
+		return err
+	}
+
+	if tasksArgs.JSON {
+		var output strings.Builder
+		truncated, err := store.WriteOutput(task.ID, func(chunk string) error {
+			output.WriteString(chunk)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to read task output: %w", err)
+		}
+		return NewJSONResponse("tasks logs", TaskLogs{
+			TaskInfo:  newTaskInfo(task),
+			Output:    output.String(),
+			Truncated: truncated,
+		}).Print()
+	}
+
+	fmt.Println()
+	fmt.Println(tasksTitleStyle.Render("Task " + shortTaskID(task.ID)))
+	fmt.Println(separatorStyle.Render(strings.Repeat("=", 41)))
+	fmt.Printf("  %s%s\n", tasksLabelStyle.Render("Description:"), tasksValueStyle.Render(task.Description))
+	fmt.Printf("  %s%s\n", tasksLabelStyle.Render("Command:"), tasksValueStyle.Render(strings.TrimSpace(task.Command+" "+strings.Join(task.Args, " "))))
+	fmt.Printf("  %s%s\n", tasksLabelStyle.Render("Status:"), renderTaskStatus(task.Status))
+	if d := task.Duration(); d > 0 {
+		fmt.Printf("  %s%s\n", tasksLabelStyle.Render("Duration:"), tasksValueStyle.Render(formatDuration(d)))
+	}
+	if task.Error != "" {
+		fmt.Printf("  %s%s\n", tasksLabelStyle.Render("Error:"), tasksErrorStyle.Render(task.Error))
+	}
+	if task.Result != "" {
+		fmt.Printf("  %s\n%s\n", tasksLabelStyle.Render("Result:"), task.Result)
+	}
+	fmt.Println()
+
+	truncated, err := store.WriteOutput(task.ID, func(chunk string) error {
+		_, err := os.Stdout.WriteString(chunk)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read task output: %w", err)
+	}
+	if truncated {
+		fmt.Println()
+		fmt.Println(tasksDimStyle.Render(fmt.Sprintf("  (earlier output dropped; only the last %d KB are kept)",
+			tasks.MaxOutputChunks*tasks.OutputChunkSize/1024)))
+	}
+	return nil
+}
+
+// =============================================================================
+// TASKS CANCEL / RETRY
+// =============================================================================
+
+// handleTasksCancel cancels a queued task, or asks the session holding a
+// task to cancel it.
+func handleTasksCancel(store *tasks.Store, tasksArgs TasksArgs) error {
+	if err := requireTaskID(tasksArgs); err != nil {
+		return err
+	}
+	task, err := store.RequestCancel(tasksArgs.TaskID)
+	if err != nil {
+		return err
+	}
+
+	if tasksArgs.JSON {
+		return NewJSONResponse("tasks cancel", newTaskInfo(task)).Print()
+	}
+
+	if task.Status == tasks.TaskStatusCanceled {
+		fmt.Printf("%s Task %s canceled\n", tasksSuccessStyle.Render("[OK]"), shortTaskID(task.ID))
+	} else {
+		fmt.Printf("%s Cancel requested for task %s; the session holding it will stop it shortly\n",
+			tasksSuccessStyle.Render("[OK]"), shortTaskID(task.ID))
+	}
+	return nil
+}
+
+// handleTasksRetry queues a copy of a finished task.
+func handleTasksRetry(store *tasks.Store, tasksArgs TasksArgs) error {
+	if err := requireTaskID(tasksArgs); err != nil {
+		return err
+	}
+	task, err := store.Retry(tasksArgs.TaskID)
+	if err != nil {
+		return err
+	}
+
+	if tasksArgs.JSON {
+		return NewJSONResponse("tasks retry", newTaskInfo(task)).Print()
+	}
+
+	fmt.Printf("%s Task queued as %s; it runs in the open rigrun session, or the next one started\n",
+		tasksSuccessStyle.Render("[OK]"), shortTaskID(task.ID))
+	return nil
+}
+
+// shortTaskID returns the first 8 characters of a task ID.
+func shortTaskID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
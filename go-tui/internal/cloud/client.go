@@ -196,6 +196,20 @@ type ChatRequest struct {
 	Stream      bool          `json:"stream"`
 	Temperature float64       `json:"temperature,omitempty"`
 	MaxTokens   int           `json:"max_tokens,omitempty"`
+	Usage       *UsageOptions `json:"usage,omitempty"`
+}
+
+// UsageOptions asks OpenRouter to report token usage. Streamed responses
+// then end with a chunk carrying the usage.
+type UsageOptions struct {
+	Include bool `json:"include"`
+}
+
+// Usage is the token usage the provider reports for a completion.
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
 }
 
 // ChatResponse represents a response from the chat completions endpoint.
@@ -206,11 +220,7 @@ type ChatResponse struct {
 		Message      ChatMessage `json:"message"`
 		FinishReason string      `json:"finish_reason"`
 	} `json:"choices"`
-	Usage struct {
-		PromptTokens     int `json:"prompt_tokens"`
-		CompletionTokens int `json:"completion_tokens"`
-		TotalTokens      int `json:"total_tokens"`
-	} `json:"usage"`
+	Usage Usage `json:"usage"`
 }
 
 // GetContent returns the content of the first choice, or empty string if none.
@@ -269,6 +279,9 @@ type OpenRouterClient struct {
 
 	// validateCerts enables certificate validation before API calls
 	validateCerts bool
+
+	// secretGuard scans outbound messages for credentials (SC-7(10))
+	secretGuard *security.SecretGuard
 }
 
 // NewOpenRouterClient creates a new OpenRouter client with the given API key.
@@ -549,6 +562,12 @@ func (c *OpenRouterClient) Chat(ctx context.Context, messages []ChatMessage) (*C
 		return nil, fmt.Errorf("%w: API key is temporarily locked due to authentication failures", security.ErrLocked)
 	}
 
+	// SC-7(10): Scan for credentials before anything leaves the boundary
+	messages, err := c.guardMessages(ctx, messages)
+	if err != nil {
+		return nil, err
+	}
+
 	url := c.baseURL + "/chat/completions"
 
 	reqBody := ChatRequest{
@@ -580,6 +599,7 @@ func (c *OpenRouterClient) Chat(ctx context.Context, messages []ChatMessage) (*C
 			return nil, err
 		}
 
+		c.restoreResponse(response)
 		return response, nil
 	}
 
@@ -0,0 +1,112 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// secrets.go - Outbound secret scanning for OpenRouter requests.
+//
+// NIST 800-53 SC-7(10): Every cloud request is passed through the client's
+// SecretGuard (when set) before it leaves the boundary. Redacted secrets are
+// restored in responses so the caller never sees placeholders.
+
+package cloud
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jeranaias/rigrun-tui/internal/security"
+)
+
+// WithSecretGuard sets the guard applied to all outbound messages.
+// A nil guard disables scanning.
+func (c *OpenRouterClient) WithSecretGuard(guard *security.SecretGuard) *OpenRouterClient {
+	c.secretGuard = guard
+	return c
+}
+
+// SecretGuard returns the guard applied to outbound messages, if any.
+func (c *OpenRouterClient) SecretGuard() *security.SecretGuard {
+	return c.secretGuard
+}
+
+// guardMessages scans outbound messages and returns the (possibly redacted)
+// messages to send. The caller's slice is never modified.
+func (c *OpenRouterClient) guardMessages(ctx context.Context, messages []ChatMessage) ([]ChatMessage, error) {
+	if c.secretGuard == nil {
+		return messages, nil
+	}
+
+	contents := make([]string, len(messages))
+	for i, msg := range messages {
+		contents[i] = msg.Content
+	}
+	filtered, err := c.secretGuard.Filter(ctx, contents)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]ChatMessage, len(messages))
+	for i, msg := range messages {
+		out[i] = ChatMessage{Role: msg.Role, Content: filtered[i]}
+	}
+	return out, nil
+}
+
+// restoreResponse replaces placeholders in a completed response.
+func (c *OpenRouterClient) restoreResponse(resp *ChatResponse) {
+	if c.secretGuard == nil || resp == nil {
+		return
+	}
+	for i := range resp.Choices {
+		resp.Choices[i].Message.Content = c.secretGuard.Restore(resp.Choices[i].Message.Content)
+	}
+}
+
+// restoringCallback wraps a stream callback so placeholders split across
+// chunks are restored before the caller sees them. The returned flush
+// function must be called after the stream ends to emit held-back text.
+func (c *OpenRouterClient) restoringCallback(callback StreamCallback) (StreamCallback, func()) {
+	if c.secretGuard == nil {
+		return callback, func() {}
+	}
+
+	restorer := c.secretGuard.Vault().NewStreamRestorer()
+	var last StreamChunk
+	var seen, done bool
+
+	wrapped := func(chunk StreamChunk) {
+		if len(chunk.Choices) > 0 {
+			text := restorer.Write(chunk.Choices[0].Delta.Content)
+			if chunk.IsDone() {
+				text += restorer.Flush()
+				done = true
+			}
+			chunk.Choices = append(chunk.Choices[:0:0], chunk.Choices...)
+			chunk.Choices[0].Delta.Content = text
+			last, seen = chunk, true
+		}
+		callback(chunk)
+	}
+
+	flush := func() {
+		text := restorer.Flush()
+		if text == "" || !seen || done {
+			return
+		}
+		chunk := last
+		chunk.Choices = append(chunk.Choices[:0:0], chunk.Choices...)
+		chunk.Choices[0].Delta.Content = text
+		chunk.Choices[0].FinishReason = ""
+		callback(chunk)
+	}
+	return wrapped, flush
+}
+
+// restoreStreamError restores placeholders in any partial content carried
+// by a stream error.
+func (c *OpenRouterClient) restoreStreamError(err error) error {
+	var streamErr *StreamError
+	if c.secretGuard != nil && errors.As(err, &streamErr) {
+		streamErr.Partial = c.secretGuard.Restore(streamErr.Partial)
+	}
+	return err
+}
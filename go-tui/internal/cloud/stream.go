@@ -41,7 +41,8 @@ type StreamChunk struct {
 		} `json:"delta"`
 		FinishReason string `json:"finish_reason"`
 	} `json:"choices"`
-	Error error `json:"-"` // Error field for channel-based streaming
+	Usage *Usage `json:"usage,omitempty"` // Set on the final chunk when usage is requested
+	Error error  `json:"-"`               // Error field for channel-based streaming
 }
 
 // GetContent returns the content from the first choice's delta.
@@ -195,12 +196,19 @@ func (c *OpenRouterClient) ChatStream(ctx context.Context, messages []ChatMessag
 		return ErrNotConfigured
 	}
 
+	// SC-7(10): Scan for credentials before anything leaves the boundary
+	messages, err := c.guardMessages(ctx, messages)
+	if err != nil {
+		return err
+	}
+
 	url := c.baseURL + "/chat/completions"
 
 	reqBody := ChatRequest{
 		Model:    c.model,
 		Messages: messages,
 		Stream:   true,
+		Usage:    &UsageOptions{Include: true}, // Spend is accounted from real usage
 	}
 
 	bodyBytes, err := json.Marshal(reqBody)
@@ -232,7 +240,10 @@ func (c *OpenRouterClient) ChatStream(ctx context.Context, messages []ChatMessag
 		return c.handleErrorResponse(resp.StatusCode, body)
 	}
 
-	return c.processStream(ctx, resp.Body, callback)
+	callback, flush := c.restoringCallback(callback)
+	err = c.processStream(ctx, resp.Body, callback)
+	flush()
+	return err
 }
 
 // processStream reads and processes the SSE stream.
@@ -268,8 +279,9 @@ func (c *OpenRouterClient) processStream(ctx context.Context, body io.Reader, ca
 
 		callback(chunk)
 
-		// Check if finished
-		if chunk.IsDone() {
+		// The usage chunk is the last; it follows the one carrying the
+		// finish reason, so the stream is read on to [DONE] until then
+		if chunk.Usage != nil {
 			return nil
 		}
 	}
@@ -405,12 +417,19 @@ func (c *OpenRouterClient) ChatStreamWithRetry(ctx context.Context, messages []C
 		return ErrNotConfigured
 	}
 
+	// SC-7(10): Scan for credentials before anything leaves the boundary
+	messages, err := c.guardMessages(ctx, messages)
+	if err != nil {
+		return err
+	}
+
 	url := c.baseURL + "/chat/completions"
 
 	reqBody := ChatRequest{
 		Model:    c.model,
 		Messages: messages,
 		Stream:   true,
+		Usage:    &UsageOptions{Include: true},
 	}
 
 	bodyBytes, err := json.Marshal(reqBody)
@@ -428,7 +447,10 @@ func (c *OpenRouterClient) ChatStreamWithRetry(ctx context.Context, messages []C
 	req.Header.Set("Cache-Control", "no-cache")
 	req.Header.Set("Connection", "keep-alive")
 
-	return c.streamWithRetry(ctx, req, callback)
+	callback, flush := c.restoringCallback(callback)
+	err = c.streamWithRetry(ctx, req, callback)
+	flush()
+	return c.restoreStreamError(err)
 }
 
 // =============================================================================
@@ -0,0 +1,510 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package commands provides the slash command system for the TUI.
+//
+// This file loads custom slash commands: markdown prompt templates in
+// ~/.rigrun/commands/ (personal) and <repo root>/.rigrun/commands/ (shared
+// with the team through the repository). A file named review.md becomes
+// /review; files in subdirectories are namespaced, so git/commit.md becomes
+// /git:commit. Project commands replace personal ones with the same name.
+//
+// A template may start with YAML front matter:
+//
+//	---
+//	description: Review a file for bugs
+//	args:
+//	  - name: file
+//	    type: file
+//	    required: true
+//	  - focus
+//	allowed-tools: Read, Grep, Glob
+//	tier: sonnet
+//	---
+//	Review @file:$1 for bugs. Focus on: ${focus}
+//
+// The body is sent as a user message after substituting $ARGUMENTS (all
+// arguments), $1..$9 (positional) and ${name} (named; the last named
+// argument takes the rest of the line). @-mentions in the result are
+// expanded like typed input.
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"gopkg.in/yaml.v3"
+
+	"github.com/jeranaias/rigrun-tui/internal/instructions"
+)
+
+// CustomCategory is the help category of custom commands.
+const CustomCategory = "Custom"
+
+// CustomCommandsDir is the directory, under ~/.rigrun and <repo>/.rigrun,
+// that holds custom command templates.
+const CustomCommandsDir = "commands"
+
+// maxCustomCommandBytes caps the size of a template file.
+const maxCustomCommandBytes = 64 * 1024
+
+// CustomScope identifies where a custom command was defined.
+type CustomScope string
+
+const (
+	// CustomScopeUser is a command in ~/.rigrun/commands.
+	CustomScopeUser CustomScope = "user"
+
+	// CustomScopeProject is a command in <repo root>/.rigrun/commands.
+	CustomScopeProject CustomScope = "project"
+)
+
+// =============================================================================
+// CUSTOM COMMAND
+// =============================================================================
+
+// CustomArg is an argument declared in a template's front matter. In YAML it
+// is either a bare name or a mapping with these fields.
+type CustomArg struct {
+	Name        string   `yaml:"name"`
+	Description string   `yaml:"description"`
+	Required    bool     `yaml:"required"`
+	Type        string   `yaml:"type"`   // string, file, model, tool or enum
+	Values      []string `yaml:"values"` // For enum arguments
+}
+
+// UnmarshalYAML accepts a bare argument name as well as a mapping.
+func (a *CustomArg) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		a.Name = node.Value
+		return nil
+	}
+	type plain CustomArg
+	return node.Decode((*plain)(a))
+}
+
+// argType maps the declared type to the completion type.
+func (a CustomArg) argType() ArgType {
+	switch strings.ToLower(a.Type) {
+	case "file":
+		return ArgTypeFile
+	case "model":
+		return ArgTypeModel
+	case "tool":
+		return ArgTypeTool
+	case "enum":
+		return ArgTypeEnum
+	default:
+		return ArgTypeString
+	}
+}
+
+// stringList accepts a YAML list or a comma-separated string.
+type stringList []string
+
+// UnmarshalYAML decodes a list or a comma-separated scalar.
+func (l *stringList) UnmarshalYAML(node *yaml.Node) error {
+	var items []string
+	if node.Kind == yaml.ScalarNode {
+		items = strings.Split(node.Value, ",")
+	} else if err := node.Decode(&items); err != nil {
+		return err
+	}
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+// customFrontMatter is the YAML front matter of a template.
+type customFrontMatter struct {
+	Description  string      `yaml:"description"`
+	ArgumentHint string      `yaml:"argument-hint"`
+	Args         []CustomArg `yaml:"args"`
+	AllowedTools stringList  `yaml:"allowed-tools"`
+	Tier         string      `yaml:"tier"`
+}
+
+// CustomCommand is a slash command defined by a prompt template.
+type CustomCommand struct {
+	// Name is the command name without the slash (e.g. "review", "git:commit").
+	Name string
+
+	// Description is shown in help and completion.
+	Description string
+
+	// ArgumentHint overrides the generated usage text.
+	ArgumentHint string
+
+	// Args are the declared arguments, in order.
+	Args []CustomArg
+
+	// AllowedTools limits the tools the model may call while answering.
+	// Empty means no restriction.
+	AllowedTools []string
+
+	// Tier is the preferred routing tier (e.g. "local", "sonnet"); empty
+	// uses normal routing. Classification and offline rules still apply.
+	Tier string
+
+	// Template is the prompt body.
+	Template string
+
+	// Path is the template file.
+	Path string
+
+	// Scope is where the template was found.
+	Scope CustomScope
+}
+
+// validCustomName matches command names derived from file paths.
+var validCustomName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*(:[a-z0-9][a-z0-9_-]*)*$`)
+
+// validTiers are the accepted values of the tier front matter field.
+var validTiers = []string{"local", "auto", "cloud", "haiku", "sonnet", "opus", "gpt-4o"}
+
+// ParseCustomCommand parses a template file's content.
+func ParseCustomCommand(name string, data []byte) (*CustomCommand, error) {
+	if !validCustomName.MatchString(name) {
+		return nil, fmt.Errorf("invalid command name %q (use lowercase letters, digits, - and _)", name)
+	}
+
+	text := strings.TrimPrefix(strings.ReplaceAll(string(data), "\r\n", "\n"), "\ufeff")
+	var fm customFrontMatter
+	body := text
+	if rest, ok := strings.CutPrefix(text, "---\n"); ok {
+		header, after, found := strings.Cut("\n"+rest, "\n---")
+		if !found {
+			return nil, fmt.Errorf("front matter is not closed with ---")
+		}
+		if err := yaml.Unmarshal([]byte(header), &fm); err != nil {
+			return nil, fmt.Errorf("invalid front matter: %w", err)
+		}
+		// Drop the rest of the closing --- line
+		_, body, _ = strings.Cut(after, "\n")
+	}
+
+	cmd := &CustomCommand{
+		Name:         name,
+		Description:  strings.TrimSpace(fm.Description),
+		ArgumentHint: strings.TrimSpace(fm.ArgumentHint),
+		Args:         fm.Args,
+		AllowedTools: fm.AllowedTools,
+		Tier:         strings.ToLower(strings.TrimSpace(fm.Tier)),
+		Template:     strings.TrimSpace(body),
+	}
+	if cmd.Template == "" {
+		return nil, fmt.Errorf("template body is empty")
+	}
+	for i, arg := range cmd.Args {
+		if arg.Name == "" {
+			return nil, fmt.Errorf("argument %d has no name", i+1)
+		}
+	}
+	if cmd.Tier != "" && !containsFold(validTiers, cmd.Tier) {
+		return nil, fmt.Errorf("invalid tier %q (valid: %s)", cmd.Tier, strings.Join(validTiers, ", "))
+	}
+	if cmd.Description == "" {
+		cmd.Description = firstLine(cmd.Template, 60)
+	}
+	return cmd, nil
+}
+
+// Usage returns the usage text shown in help.
+func (c *CustomCommand) Usage() string {
+	if c.ArgumentHint != "" {
+		return "/" + c.Name + " " + c.ArgumentHint
+	}
+	parts := []string{"/" + c.Name}
+	for _, arg := range c.Args {
+		if arg.Required {
+			parts = append(parts, "<"+arg.Name+">")
+		} else {
+			parts = append(parts, "["+arg.Name+"]")
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// customPlaceholder matches $ARGUMENTS, $1..$9 and ${name}.
+var customPlaceholder = regexp.MustCompile(`\$ARGUMENTS|\$[1-9]|\$\{[A-Za-z0-9_-]+\}`)
+
+// Expand substitutes the arguments into the template. Arguments missing a
+// required value or outside an enum are rejected. When the template has no
+// placeholders, the arguments are appended to it.
+func (c *CustomCommand) Expand(args []string) (string, error) {
+	if err := ValidateArgs(c.ToCommand(), args); err != nil {
+		return "", err
+	}
+
+	named := make(map[string]string)
+	for i, arg := range c.Args {
+		switch {
+		case i >= len(args):
+			named[arg.Name] = ""
+		case i == len(c.Args)-1:
+			named[arg.Name] = strings.Join(args[i:], " ")
+		default:
+			named[arg.Name] = args[i]
+		}
+	}
+	all := strings.Join(args, " ")
+
+	if !customPlaceholder.MatchString(c.Template) {
+		if all == "" {
+			return c.Template, nil
+		}
+		return c.Template + "\n\n" + all, nil
+	}
+
+	return customPlaceholder.ReplaceAllStringFunc(c.Template, func(p string) string {
+		switch {
+		case p == "$ARGUMENTS":
+			return all
+		case strings.HasPrefix(p, "${"):
+			name := p[2 : len(p)-1]
+			if value, ok := named[name]; ok {
+				return value
+			}
+			return p
+		default:
+			n, _ := strconv.Atoi(p[1:])
+			if n <= len(args) {
+				return args[n-1]
+			}
+			return ""
+		}
+	}), nil
+}
+
+// ToCommand returns the registry entry for the command.
+func (c *CustomCommand) ToCommand() *Command {
+	args := make([]ArgDef, len(c.Args))
+	for i, arg := range c.Args {
+		args[i] = ArgDef{
+			Name:        arg.Name,
+			Required:    arg.Required,
+			Type:        arg.argType(),
+			Description: arg.Description,
+			Values:      arg.Values,
+		}
+	}
+	return &Command{
+		Name:        "/" + c.Name,
+		Description: c.Description,
+		Usage:       c.Usage(),
+		Args:        args,
+		Category:    CustomCategory,
+		Custom:      c,
+		Handler: func(ctx *Context, args []string) tea.Cmd {
+			return HandleCustomCommand(c, args)
+		},
+	}
+}
+
+// CustomCommandMsg asks the chat view to run a custom command.
+type CustomCommandMsg struct {
+	Command *CustomCommand
+	Args    []string
+}
+
+// HandleCustomCommand hands a custom command to the chat view, which sends
+// the expanded template.
+func HandleCustomCommand(cmd *CustomCommand, args []string) tea.Cmd {
+	return func() tea.Msg {
+		return CustomCommandMsg{Command: cmd, Args: args}
+	}
+}
+
+// =============================================================================
+// LOADING
+// =============================================================================
+
+// UserCommandsDir returns ~/.rigrun/commands, or "" if the home directory is
+// unknown.
+func UserCommandsDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".rigrun", CustomCommandsDir)
+}
+
+// ProjectCommandsDir returns <repo root>/.rigrun/commands for a working
+// directory.
+func ProjectCommandsDir(workDir string) string {
+	return filepath.Join(instructions.FindRoot(workDir), ".rigrun", CustomCommandsDir)
+}
+
+// LoadCustomCommands loads the personal and project commands for a working
+// directory, sorted by name. Files that fail to parse are skipped and
+// reported in the returned errors.
+func LoadCustomCommands(workDir string) ([]*CustomCommand, []error) {
+	return loadCustomCommands(UserCommandsDir(), ProjectCommandsDir(workDir))
+}
+
+func loadCustomCommands(userDir, projectDir string) ([]*CustomCommand, []error) {
+	byName := make(map[string]*CustomCommand)
+	var errs []error
+	for _, src := range []struct {
+		dir   string
+		scope CustomScope
+	}{{userDir, CustomScopeUser}, {projectDir, CustomScopeProject}} {
+		if src.dir == "" {
+			continue
+		}
+		cmds, dirErrs := loadCommandDir(src.dir, src.scope)
+		errs = append(errs, dirErrs...)
+		for _, cmd := range cmds {
+			byName[cmd.Name] = cmd
+		}
+	}
+
+	cmds := make([]*CustomCommand, 0, len(byName))
+	for _, cmd := range byName {
+		cmds = append(cmds, cmd)
+	}
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i].Name < cmds[j].Name })
+	return cmds, errs
+}
+
+// loadCommandDir loads the templates under one directory.
+func loadCommandDir(dir string, scope CustomScope) ([]*CustomCommand, []error) {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil, nil
+	}
+
+	var cmds []*CustomCommand
+	var errs []error
+	_ = filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			errs = append(errs, err)
+			return nil
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(path), ".md") {
+			return nil
+		}
+
+		rel, _ := filepath.Rel(dir, path)
+		name := strings.ToLower(strings.TrimSuffix(filepath.ToSlash(rel), filepath.Ext(rel)))
+		name = strings.ReplaceAll(name, "/", ":")
+
+		info, err := d.Info()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			return nil
+		}
+		if info.Size() > maxCustomCommandBytes {
+			errs = append(errs, fmt.Errorf("%s: template exceeds %d bytes", path, maxCustomCommandBytes))
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			return nil
+		}
+
+		cmd, err := ParseCustomCommand(name, data)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			return nil
+		}
+		cmd.Path = path
+		cmd.Scope = scope
+		cmds = append(cmds, cmd)
+		return nil
+	})
+	return cmds, errs
+}
+
+// RegisterCustom replaces the registry's custom commands. Commands that would
+// shadow a built-in command or alias are skipped and reported.
+func (r *Registry) RegisterCustom(cmds []*CustomCommand) []error {
+	for name, cmd := range r.commands {
+		if cmd.Custom != nil {
+			delete(r.commands, name)
+		}
+	}
+
+	var errs []error
+	for _, custom := range cmds {
+		if existing := r.Get("/" + custom.Name); existing != nil {
+			errs = append(errs, fmt.Errorf("%s: /%s is a built-in command", custom.Path, custom.Name))
+			continue
+		}
+		r.Register(custom.ToCommand())
+	}
+	return errs
+}
+
+// CustomCommands returns the registered custom commands, sorted by name.
+func (r *Registry) CustomCommands() []*CustomCommand {
+	var cmds []*CustomCommand
+	for _, cmd := range r.commands {
+		if cmd.Custom != nil {
+			cmds = append(cmds, cmd.Custom)
+		}
+	}
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i].Name < cmds[j].Name })
+	return cmds
+}
+
+// HandleCommands lists the custom commands for the working directory. The
+// chat view also handles reloading them.
+func HandleCommands(ctx *Context, args []string) tea.Cmd {
+	return func() tea.Msg {
+		cwd, _ := os.Getwd()
+		cmds, errs := LoadCustomCommands(cwd)
+		return SystemMessageMsg{Content: FormatCustomCommands(cmds, errs)}
+	}
+}
+
+// FormatCustomCommands describes loaded custom commands and load errors.
+func FormatCustomCommands(cmds []*CustomCommand, errs []error) string {
+	var sb strings.Builder
+	if len(cmds) == 0 {
+		sb.WriteString("No custom commands. Add markdown templates to .rigrun/commands/ in the repository\n")
+		sb.WriteString("(shared with the team) or to ~/.rigrun/commands/ (personal).\n")
+	} else {
+		sb.WriteString("Custom commands:\n")
+		for _, cmd := range cmds {
+			fmt.Fprintf(&sb, "  %-24s %s (%s)\n", cmd.Usage(), cmd.Description, cmd.Scope)
+		}
+	}
+	for _, err := range errs {
+		fmt.Fprintf(&sb, "  Skipped: %v\n", err)
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// =============================================================================
+// HELPERS
+// =============================================================================
+
+// firstLine returns the first non-empty line of s, truncated to maxLen.
+func firstLine(s string, maxLen int) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return truncate(line, maxLen)
+		}
+	}
+	return ""
+}
+
+// containsFold reports whether values contains s, ignoring case.
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const reviewTemplate = `---
+description: Review a file
+args:
+  - name: file
+    type: file
+    required: true
+  - focus
+allowed-tools: Read, Grep
+tier: Sonnet
+---
+Review @file:$1 for bugs. Focus on: ${focus}
+`
+
+func TestParseCustomCommand(t *testing.T) {
+	cmd, err := ParseCustomCommand("review", []byte(reviewTemplate))
+	if err != nil {
+		t.Fatalf("ParseCustomCommand() error = %v", err)
+	}
+	if cmd.Description != "Review a file" || cmd.Tier != "sonnet" {
+		t.Errorf("unexpected description/tier %q/%q", cmd.Description, cmd.Tier)
+	}
+	if len(cmd.Args) != 2 || !cmd.Args[0].Required || cmd.Args[1].Name != "focus" {
+		t.Errorf("unexpected args %+v", cmd.Args)
+	}
+	if strings.Join(cmd.AllowedTools, ",") != "Read,Grep" {
+		t.Errorf("unexpected allowed tools %q", cmd.AllowedTools)
+	}
+	if got := cmd.Usage(); got != "/review <file> [focus]" {
+		t.Errorf("Usage() = %q", got)
+	}
+
+	// Without front matter the first line describes the command
+	plain, err := ParseCustomCommand("explain", []byte("Explain this code.\r\nBe brief.\r\n"))
+	if err != nil || plain.Description != "Explain this code." {
+		t.Errorf("plain template: %+v, %v", plain, err)
+	}
+
+	for name, data := range map[string]string{
+		"Bad Name": "body",
+		"empty":    "---\ndescription: x\n---\n",
+		"unclosed": "---\ndescription: x\nbody",
+		"tier":     "---\ntier: gpt-5\n---\nbody",
+	} {
+		if _, err := ParseCustomCommand(name, []byte(data)); err == nil {
+			t.Errorf("ParseCustomCommand(%q) should fail", name)
+		}
+	}
+}
+
+func TestCustomCommandExpand(t *testing.T) {
+	cmd, _ := ParseCustomCommand("review", []byte(reviewTemplate))
+
+	got, err := cmd.Expand([]string{"main.go", "error", "handling"})
+	if err != nil {
+		t.Fatalf("Expand() error = %v", err)
+	}
+	if want := "Review @file:main.go for bugs. Focus on: error handling"; got != want {
+		t.Errorf("Expand() = %q, want %q", got, want)
+	}
+	if _, err := cmd.Expand(nil); err == nil {
+		t.Error("Expand() should reject a missing required argument")
+	}
+
+	// Arguments are appended to a template without placeholders
+	plain, _ := ParseCustomCommand("explain", []byte("Explain this code."))
+	if got, _ := plain.Expand([]string{"@file:a.go"}); got != "Explain this code.\n\n@file:a.go" {
+		t.Errorf("Expand() = %q", got)
+	}
+}
+
+func TestLoadCustomCommands(t *testing.T) {
+	userDir, projectDir := t.TempDir(), t.TempDir()
+	write := func(dir, rel, content string) {
+		path := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(userDir, "review.md", "personal review")
+	write(userDir, "notes.md", "personal notes")
+	write(projectDir, "review.md", "team review")
+	write(projectDir, "git/commit.md", "write a commit message")
+	write(projectDir, "broken.md", "---\ntier: nope\n---\nbody")
+	write(projectDir, "README.txt", "not a command")
+
+	cmds, errs := loadCustomCommands(userDir, projectDir)
+	if len(errs) != 1 {
+		t.Errorf("expected one load error, got %v", errs)
+	}
+
+	var names []string
+	byName := make(map[string]*CustomCommand)
+	for _, cmd := range cmds {
+		names = append(names, cmd.Name)
+		byName[cmd.Name] = cmd
+	}
+	if got := strings.Join(names, ","); got != "git:commit,notes,review" {
+		t.Fatalf("loaded commands = %s", got)
+	}
+	if byName["review"].Template != "team review" || byName["review"].Scope != CustomScopeProject {
+		t.Errorf("project command should replace the personal one: %+v", byName["review"])
+	}
+	if byName["notes"].Scope != CustomScopeUser {
+		t.Errorf("notes scope = %s", byName["notes"].Scope)
+	}
+}
+
+func TestRegisterCustom(t *testing.T) {
+	deploy, _ := ParseCustomCommand("deploy", []byte("---\nargs:\n  - name: env\n    type: enum\n    values: [staging, production]\n---\nDeploy to $1"))
+	shadow, _ := ParseCustomCommand("help", []byte("not really help"))
+
+	r := NewRegistry()
+	if errs := r.RegisterCustom([]*CustomCommand{deploy, shadow}); len(errs) != 1 {
+		t.Errorf("expected the built-in collision to be reported, got %v", errs)
+	}
+	if cmd := r.Get("/deploy"); cmd == nil || cmd.Custom != deploy || cmd.Category != CustomCategory {
+		t.Fatalf("/deploy not registered: %+v", cmd)
+	}
+	if r.Get("/help").Custom != nil {
+		t.Error("a custom command must not replace a built-in")
+	}
+
+	completions := NewCompleter(r).Complete("/dep", 4)
+	if len(completions) == 0 || completions[0].Value != "/deploy" {
+		t.Errorf("expected /deploy completion, got %+v", completions)
+	}
+
+	// Registering again replaces the previous custom commands
+	r.RegisterCustom(nil)
+	if r.Get("/deploy") != nil || len(r.CustomCommands()) != 0 {
+		t.Error("reloading should remove custom commands that are gone")
+	}
+}
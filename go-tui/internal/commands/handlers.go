@@ -7,12 +7,15 @@ package commands
 import (
 	"context"
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"github.com/jeranaias/rigrun-tui/internal/instructions"
 	"github.com/jeranaias/rigrun-tui/internal/model"
+	"github.com/jeranaias/rigrun-tui/internal/security"
 )
 
 // =============================================================================
@@ -130,6 +133,21 @@ type CopyCompleteMsg struct {
 // ExportConversationMsg triggers exporting the conversation.
 type ExportConversationMsg struct {
 	Format string // "json", "md", "txt"
+	Tree   bool   // Include inactive branches, not just the active one
+}
+
+// BranchCommandMsg asks the chat view to run a branching command ("edit",
+// "regen" or "branch") against the current conversation.
+type BranchCommandMsg struct {
+	Command string
+	Args    []string
+}
+
+// GitCommandMsg asks the app to run a git session command ("undo" or
+// "commit") against the agent's session branch.
+type GitCommandMsg struct {
+	Command string
+	Args    []string
 }
 
 // ExportCompleteMsg indicates export completion.
@@ -384,12 +402,21 @@ func HandleLoad(ctx *Context, args []string) tea.Cmd {
 
 	if ctx != nil && ctx.Storage != nil {
 		store := ctx.Storage
+		sessionClass := security.DefaultClassification()
+		if ctx.Config != nil {
+			sessionClass = security.ClassificationFromEnv(ctx.Config.Security.Classification)
+		}
 		return func() tea.Msg {
 			conv, err := store.Load(sessionID)
 			if err != nil {
 				return ConversationLoadedMsg{ID: sessionID, Error: err}
 			}
 
+			// AC-4: never load a conversation marked above the session level
+			if err := security.CheckSessionClearance(conv.HighWaterMark(), sessionClass); err != nil {
+				return ConversationLoadedMsg{ID: sessionID, Error: err}
+			}
+
 			// Convert messages
 			messages := make([]StoredMessage, len(conv.Messages))
 			for i, m := range conv.Messages {
@@ -432,8 +459,17 @@ func HandleCopy(ctx *Context, args []string) tea.Cmd {
 // HandleExport exports the conversation.
 func HandleExport(ctx *Context, args []string) tea.Cmd {
 	format := "markdown" // Default to markdown
-	if len(args) > 0 {
-		format = strings.ToLower(args[0])
+	tree := false
+	var positional []string
+	for _, arg := range args {
+		if arg == "--tree" {
+			tree = true
+		} else {
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) > 0 {
+		format = strings.ToLower(positional[0])
 		// Support aliases
 		if format == "md" {
 			format = "markdown"
@@ -457,7 +493,23 @@ func HandleExport(ctx *Context, args []string) tea.Cmd {
 	}
 
 	return func() tea.Msg {
-		return ExportConversationMsg{Format: format}
+		return ExportConversationMsg{Format: format, Tree: tree}
+	}
+}
+
+// HandleBranchCommand forwards /edit, /regen and /branch to the chat view,
+// which owns the conversation tree.
+func HandleBranchCommand(command string, args []string) tea.Cmd {
+	return func() tea.Msg {
+		return BranchCommandMsg{Command: command, Args: args}
+	}
+}
+
+// HandleGitCommand forwards /undo and /commit to the app, which owns the
+// git session.
+func HandleGitCommand(command string, args []string) tea.Cmd {
+	return func() tea.Msg {
+		return GitCommandMsg{Command: command, Args: args}
 	}
 }
 
@@ -467,6 +519,20 @@ type SessionListMsg struct {
 	Error    error
 }
 
+// SearchSessionsMsg opens the session search overlay.
+type SearchSessionsMsg struct {
+	Query string
+}
+
+// HandleSearchSessions opens the session search overlay, seeded with the
+// query if one is given.
+func HandleSearchSessions(ctx *Context, args []string) tea.Cmd {
+	query := strings.Join(args, " ")
+	return func() tea.Msg {
+		return SearchSessionsMsg{Query: query}
+	}
+}
+
 // HandleSessions shows the session list.
 func HandleSessions(ctx *Context, args []string) tea.Cmd {
 	if ctx != nil && ctx.Storage != nil {
@@ -912,6 +978,24 @@ func formatDuration(d time.Duration) string {
 	return fmt.Sprintf("%dh %dm", int(d.Hours()), int(d.Minutes())%60)
 }
 
+// HandleMemory lists the project instruction files (RIGRUN.md) in effect for
+// the working directory. The chat view also handles adding notes.
+func HandleMemory(ctx *Context, args []string) tea.Cmd {
+	return func() tea.Msg {
+		cwd, _ := os.Getwd()
+		set := instructions.Load(cwd)
+		if len(set.Files) == 0 {
+			return SystemMessageMsg{Content: "No " + instructions.FileName + " files found"}
+		}
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "Project instructions (~%d tokens):\n", set.Tokens())
+		for _, f := range set.Files {
+			fmt.Fprintf(&sb, "  %s (%s, ~%d tokens)\n", set.DisplayPath(f), f.Scope, f.Tokens)
+		}
+		return SystemMessageMsg{Content: sb.String()}
+	}
+}
+
 // HandleTheme changes the color theme.
 func HandleTheme(ctx *Context, args []string) tea.Cmd {
 	if len(args) == 0 {
@@ -972,6 +1056,7 @@ func GenerateHelpText(r *Registry, mode string) string {
 		"model":        "Model",
 		"tools":        "Tools",
 		"settings":     "Settings",
+		"custom":       CustomCategory,
 	}
 	if canonical, ok := categoryMap[mode]; ok {
 		return generateCategoryHelp(r, canonical)
@@ -1009,6 +1094,7 @@ func generateQuickHelp() string {
 	sb.WriteString("  /help model       - Model and routing commands\n")
 	sb.WriteString("  /help tools       - Tool management\n")
 	sb.WriteString("  /help settings    - Settings and configuration\n")
+	sb.WriteString("  /help custom      - Custom commands from .rigrun/commands\n")
 
 	return sb.String()
 }
@@ -1078,6 +1164,11 @@ func generateCategoryHelp(r *Registry, category string) string {
 		sb.WriteString("  - Config changes persist automatically\n")
 		sb.WriteString("  - Use /status to see current settings\n")
 		sb.WriteString("  - Cache improves response time and reduces costs\n")
+	case CustomCategory:
+		sb.WriteString("Tips:\n")
+		sb.WriteString("  - Add markdown templates to .rigrun/commands/ to share them with the repo\n")
+		sb.WriteString("  - Personal commands go in ~/.rigrun/commands/\n")
+		sb.WriteString("  - Use /commands reload after editing a template\n")
 	}
 
 	sb.WriteString("\nUse /help all to see all commands, or /help quick for essentials.\n")
@@ -1093,7 +1184,7 @@ func generateFullHelp(r *Registry) string {
 	sb.WriteString("==================\n\n")
 
 	categories := r.ByCategory()
-	categoryOrder := []string{"Navigation", "Conversation", "Model", "Tools", "Settings"}
+	categoryOrder := []string{"Navigation", "Conversation", "Model", "Tools", "Settings", CustomCategory}
 
 	for _, category := range categoryOrder {
 		cmds, ok := categories[category]
@@ -1174,6 +1265,23 @@ func HandlePlan(ctx *Context, args []string) tea.Cmd {
 		}
 	}
 
+	// "/plan resume [id]" and "/plan cancel [id]" act on saved plans; the ID
+	// defaults to the most recently interrupted plan
+	if len(args) <= 2 && (args[0] == "resume" || args[0] == "cancel") {
+		planID := ""
+		if len(args) == 2 {
+			planID = args[1]
+		}
+		if args[0] == "resume" {
+			return func() tea.Msg {
+				return ResumePlanMsg{PlanID: planID}
+			}
+		}
+		return func() tea.Msg {
+			return CancelPlanMsg{PlanID: planID}
+		}
+	}
+
 	// Join all args to get the full task description
 	task := strings.Join(args, " ")
 
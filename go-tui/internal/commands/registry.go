@@ -45,6 +45,9 @@ type Command struct {
 
 	// Category for grouping in help display
 	Category string
+
+	// Custom is set for commands loaded from .rigrun/commands templates
+	Custom *CustomCommand
 }
 
 // ArgDef defines an argument for a command.
@@ -228,7 +231,7 @@ func (r *Registry) registerBuiltins() {
 	r.Register(&Command{
 		Name:        "/export",
 		Description: "Export conversation to file",
-		Usage:       "/export [format]",
+		Usage:       "/export [format] [--tree]",
 		Args: []ArgDef{
 			{Name: "format", Required: false, Type: ArgTypeEnum, Values: []string{"json", "md", "txt"}, Description: "Export format"},
 		},
@@ -236,6 +239,43 @@ func (r *Registry) registerBuiltins() {
 		Handler:  handleExport,
 	})
 
+	r.Register(&Command{
+		Name:        "/edit",
+		Description: "Edit an earlier message and fork the conversation",
+		Usage:       "/edit [n] [new text]",
+		Args: []ArgDef{
+			{Name: "n", Required: false, Type: ArgTypeString, Description: "User message number (default: last)"},
+			{Name: "text", Required: false, Type: ArgTypeString, Description: "Replacement text; omit to edit in the input"},
+		},
+		Category: "Conversation",
+		Handler:  handleEdit,
+	})
+
+	r.Register(&Command{
+		Name:        "/regen",
+		Aliases:     []string{"/retry"},
+		Description: "Regenerate a response on a new branch",
+		Usage:       "/regen [n]",
+		Args: []ArgDef{
+			{Name: "n", Required: false, Type: ArgTypeString, Description: "User message number (default: last)"},
+		},
+		Category: "Conversation",
+		Handler:  handleRegen,
+	})
+
+	r.Register(&Command{
+		Name:        "/branch",
+		Aliases:     []string{"/br"},
+		Description: "List branches or switch between them",
+		Usage:       "/branch [list|next|prev] [n]",
+		Args: []ArgDef{
+			{Name: "action", Required: false, Type: ArgTypeEnum, Values: []string{"list", "next", "prev"}, Description: "Action"},
+			{Name: "n", Required: false, Type: ArgTypeString, Description: "User message number (default: last fork point)"},
+		},
+		Category: "Conversation",
+		Handler:  handleBranch,
+	})
+
 	r.Register(&Command{
 		Name:        "/sessions",
 		Aliases:     []string{"/list"},
@@ -244,6 +284,17 @@ func (r *Registry) registerBuiltins() {
 		Handler:     handleSessions,
 	})
 
+	r.Register(&Command{
+		Name:        "/search",
+		Description: "Search messages across saved sessions",
+		Usage:       "/search [query]",
+		Args: []ArgDef{
+			{Name: "query", Required: false, Type: ArgTypeString, Description: "Text to search for"},
+		},
+		Category: "Conversation",
+		Handler:  handleSearchSessions,
+	})
+
 	// Model commands
 	r.Register(&Command{
 		Name:        "/model",
@@ -315,6 +366,19 @@ func (r *Registry) registerBuiltins() {
 		Handler:     handleStatus,
 	})
 
+	r.Register(&Command{
+		Name:        "/memory",
+		Aliases:     []string{"/mem"},
+		Description: "Show project instructions (RIGRUN.md) or add a note",
+		Usage:       "/memory [show|add|reload] [--user | --dir <path>] [note]",
+		Args: []ArgDef{
+			{Name: "action", Required: false, Type: ArgTypeEnum, Values: []string{"show", "add", "reload"}, Description: "Action"},
+			{Name: "note", Required: false, Type: ArgTypeString, Description: "Note to append"},
+		},
+		Category: "Settings",
+		Handler:  handleMemory,
+	})
+
 	r.Register(&Command{
 		Name:        "/theme",
 		Description: "Change color theme",
@@ -335,11 +399,30 @@ func (r *Registry) registerBuiltins() {
 		Handler:     handleTutorial,
 	})
 
+	// Git session commands
+	r.Register(&Command{
+		Name:        "/undo",
+		Description: "Revert the agent's last git commit",
+		Category:    "Tools",
+		Handler:     handleGitUndo,
+	})
+
+	r.Register(&Command{
+		Name:        "/commit",
+		Description: "Squash the agent's git commits into one",
+		Usage:       "/commit [message]",
+		Args: []ArgDef{
+			{Name: "message", Required: false, Type: ArgTypeString, Description: "Commit message (default: generated)"},
+		},
+		Category: "Tools",
+		Handler:  handleGitCommit,
+	})
+
 	// Plan mode
 	r.Register(&Command{
 		Name:        "/plan",
 		Description: "Create and execute a multi-step plan",
-		Usage:       "/plan <task>",
+		Usage:       "/plan <task> | /plan resume [id] | /plan cancel [id]",
 		Args: []ArgDef{
 			{Name: "task", Required: true, Type: ArgTypeString, Description: "Task description to plan"},
 		},
@@ -372,6 +455,18 @@ func (r *Registry) registerBuiltins() {
 		Category: "Settings",
 		Handler:  handleCost,
 	})
+
+	// Custom commands
+	r.Register(&Command{
+		Name:        "/commands",
+		Description: "List custom commands from .rigrun/commands",
+		Usage:       "/commands [list|reload]",
+		Args: []ArgDef{
+			{Name: "action", Required: false, Type: ArgTypeEnum, Values: []string{"list", "reload"}, Description: "Action"},
+		},
+		Category: CustomCategory,
+		Handler:  handleCommands,
+	})
 }
 
 // =============================================================================
@@ -410,10 +505,34 @@ func handleExport(ctx *Context, args []string) tea.Cmd {
 	return HandleExport(ctx, args)
 }
 
+func handleEdit(ctx *Context, args []string) tea.Cmd {
+	return HandleBranchCommand("edit", args)
+}
+
+func handleRegen(ctx *Context, args []string) tea.Cmd {
+	return HandleBranchCommand("regen", args)
+}
+
+func handleBranch(ctx *Context, args []string) tea.Cmd {
+	return HandleBranchCommand("branch", args)
+}
+
+func handleGitUndo(ctx *Context, args []string) tea.Cmd {
+	return HandleGitCommand("undo", args)
+}
+
+func handleGitCommit(ctx *Context, args []string) tea.Cmd {
+	return HandleGitCommand("commit", args)
+}
+
 func handleSessions(ctx *Context, args []string) tea.Cmd {
 	return HandleSessions(ctx, args)
 }
 
+func handleSearchSessions(ctx *Context, args []string) tea.Cmd {
+	return HandleSearchSessions(ctx, args)
+}
+
 func handleModel(ctx *Context, args []string) tea.Cmd {
 	return HandleModel(ctx, args)
 }
@@ -442,6 +561,10 @@ func handleStatus(ctx *Context, args []string) tea.Cmd {
 	return HandleStatus(ctx, args)
 }
 
+func handleMemory(ctx *Context, args []string) tea.Cmd {
+	return HandleMemory(ctx, args)
+}
+
 func handleTheme(ctx *Context, args []string) tea.Cmd {
 	return HandleTheme(ctx, args)
 }
@@ -462,6 +585,10 @@ func handleCost(ctx *Context, args []string) tea.Cmd {
 	return HandleCost(ctx, args)
 }
 
+func handleCommands(ctx *Context, args []string) tea.Cmd {
+	return HandleCommands(ctx, args)
+}
+
 // =============================================================================
 // CONTEXT TYPE
 // =============================================================================
@@ -20,12 +20,14 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/BurntSushi/toml"
+	"github.com/jeranaias/rigrun-tui/internal/cron"
 	"github.com/jeranaias/rigrun-tui/internal/util"
 )
 
@@ -42,6 +44,9 @@ type Config struct {
 	// Routing configuration
 	Routing RoutingConfig `toml:"routing" json:"routing"`
 
+	// Budget caps spending on paid (cloud) tiers
+	Budget BudgetConfig `toml:"budget" json:"budget"`
+
 	// Local (Ollama) configuration
 	Local LocalConfig `toml:"local" json:"local"`
 
@@ -59,6 +64,31 @@ type Config struct {
 
 	// UI configuration
 	UI UIConfig `toml:"ui" json:"ui"`
+
+	// Hooks are user-defined tool lifecycle hooks ([[hooks]] tables)
+	Hooks []HookConfig `toml:"hooks,omitempty" json:"hooks,omitempty"`
+
+	// Permissions is the declarative tool permission policy for headless runs
+	Permissions PermissionsConfig `toml:"permissions" json:"permissions"`
+
+	// Git configures git-aware agent sessions
+	Git GitConfig `toml:"git" json:"git"`
+
+	// Plan configures multi-step plan execution
+	Plan PlanConfig `toml:"plan" json:"plan"`
+
+	// Scheduler configures how "rigrun daemon" runs scheduled jobs
+	Scheduler SchedulerConfig `toml:"scheduler" json:"scheduler"`
+
+	// Schedules are recurring prompts and plans ([[schedules]] tables)
+	Schedules []ScheduleConfig `toml:"schedules,omitempty" json:"schedules,omitempty"`
+
+	// Retention configures how long stored data is kept (SI-12)
+	Retention RetentionConfig `toml:"retention" json:"retention"`
+
+	// policy is the administrator policy applied at load time (CM-5).
+	// Not serialized; see policy.go.
+	policy *policyState
 }
 
 // RoutingConfig contains query routing configuration.
@@ -86,6 +116,25 @@ type RoutingConfig struct {
 	AutoFallback string `toml:"auto_fallback" json:"auto_fallback"`
 }
 
+// BudgetConfig caps spending on paid (cloud) tiers. The router checks the
+// budgets before choosing a paid tier; spend is the recorded cost of cloud
+// queries, priced from the token usage the provider reports.
+type BudgetConfig struct {
+	// SessionUSD caps the spend of one rigrun session in dollars (0 = no limit)
+	SessionUSD float64 `toml:"session_usd" json:"session_usd"`
+	// DailyUSD caps the spend of the current day in dollars (0 = no limit)
+	DailyUSD float64 `toml:"daily_usd" json:"daily_usd"`
+	// WeeklyUSD caps the spend since Monday in dollars (0 = no limit)
+	WeeklyUSD float64 `toml:"weekly_usd" json:"weekly_usd"`
+	// MonthlyUSD caps the spend of the calendar month in dollars (0 = no limit)
+	MonthlyUSD float64 `toml:"monthly_usd" json:"monthly_usd"`
+	// WarnPercent is the share of a budget at which the status bar warns (default: 80)
+	WarnPercent int `toml:"warn_percent" json:"warn_percent"`
+	// OnExceeded is what happens to paid queries once a budget is spent:
+	// "local" routes them to the local model (default), "prompt" asks first
+	OnExceeded string `toml:"on_exceeded" json:"on_exceeded"`
+}
+
 // LocalConfig contains local Ollama configuration.
 type LocalConfig struct {
 	// OllamaURL is the URL of the Ollama server
@@ -193,6 +242,11 @@ type SecurityConfig struct {
 	// EncryptAudit indicates whether audit logs are encrypted (optional).
 	// For highly sensitive deployments, audit logs can also be encrypted.
 	EncryptAudit bool `toml:"encrypt_audit" json:"encrypt_audit"`
+	// EncryptData indicates whether user content is encrypted as it is
+	// written: conversations, cost history, benchmark results, background
+	// tasks, plans and codebase indexes. Takes effect once encryption is
+	// initialized.
+	EncryptData bool `toml:"encrypt_data" json:"encrypt_data"`
 
 	// ==========================================================================
 	// NIST 800-53 SC-13: Cryptographic Protection
@@ -220,6 +274,9 @@ type SecurityConfig struct {
 	// PinnedCertificates maps hostnames to SHA-256 certificate fingerprints (SC-17).
 	// Format: {"openrouter.ai": "abc123..."}
 	PinnedCertificates map[string]string `toml:"pinned_certificates" json:"pinned_certificates"`
+	// TrustStore is the path to a PEM CA bundle used to verify client
+	// certificates for PIV/CAC login (IA-2(12)). Empty uses the system roots.
+	TrustStore string `toml:"trust_store" json:"trust_store"`
 
 	// ==========================================================================
 	// NIST 800-53 IR-6: Incident Reporting
@@ -239,6 +296,31 @@ type SecurityConfig struct {
 	// Default: "warn"
 	SpillageAction string `toml:"spillage_action" json:"spillage_action"`
 
+	// ==========================================================================
+	// NIST 800-53 SC-7(10): Prevent Exfiltration
+	// ==========================================================================
+	// SecretScanning scans prompts and attached file contents for credentials
+	// before they are sent to a cloud tier.
+	SecretScanning bool `toml:"secret_scanning" json:"secret_scanning"`
+	// SecretAction defines the action taken when outbound content contains secrets.
+	// Valid values: "block" (do not send), "redact" (send with placeholders that
+	// are restored in the response), "ask" (prompt the user).
+	// Default: "redact"
+	SecretAction string `toml:"secret_action" json:"secret_action"`
+	// SecretPatterns are additional regular expressions treated as secrets.
+	SecretPatterns []string `toml:"secret_patterns" json:"secret_patterns"`
+
+	// ==========================================================================
+	// NIST 800-53 SC-39: Process Isolation
+	// ==========================================================================
+	// BashSandbox runs agent Bash commands in a Linux namespace/seccomp sandbox.
+	// Valid values: "off", "auto" (sandbox when available, otherwise run as
+	// before), "required" (refuse to run commands without the sandbox).
+	// Default: "off"
+	BashSandbox string `toml:"bash_sandbox" json:"bash_sandbox"`
+	// BashSandboxNetwork keeps network access inside the sandbox.
+	BashSandboxNetwork bool `toml:"bash_sandbox_network" json:"bash_sandbox_network"`
+
 	// ==========================================================================
 	// NIST 800-53 AU-9: Protection of Audit Information
 	// ==========================================================================
@@ -246,6 +328,14 @@ type SecurityConfig struct {
 	// SECURITY: Must be configured via RIGRUN_POLICY_KEY env var or this field.
 	// No silent fallback to hardcoded values - will error if not configured.
 	PolicyKey string `toml:"policy_key" json:"policy_key"`
+
+	// ==========================================================================
+	// NIST 800-53 CM-5: Access Restrictions for Change
+	// ==========================================================================
+	// PolicyPublicKey is the Ed25519 public key (base64 or PEM) that signed
+	// policy bundles are verified against. The system key file, when present,
+	// takes precedence. See policy.go.
+	PolicyPublicKey string `toml:"policy_public_key" json:"policy_public_key"`
 }
 
 // CacheConfig contains cache configuration.
@@ -280,6 +370,271 @@ type UIConfig struct {
 	TutorialStep int `toml:"tutorial_step" json:"tutorial_step"`
 }
 
+// HookConfig is a command run at a point in the tool lifecycle.
+type HookConfig struct {
+	// Event is when the hook runs: "pre_tool", "post_tool", or "stop"
+	Event string `toml:"event" json:"event"`
+	// Matcher is a regex matched against the whole tool name ("" or "*" = all tools)
+	Matcher string `toml:"matcher" json:"matcher,omitempty"`
+	// Command is the shell command to run; the tool call JSON is on stdin
+	Command string `toml:"command" json:"command"`
+	// TimeoutSecs bounds the hook (0 = 60 seconds, capped by the Bash tool limit)
+	TimeoutSecs int `toml:"timeout_secs" json:"timeout_secs,omitempty"`
+}
+
+// GitConfig configures git-aware agent sessions. When enabled, the TUI works
+// on a new branch (or worktree), commits after each agent turn that changed
+// files, and offers /undo and /commit.
+type GitConfig struct {
+	// Mode is "off" (default), "branch" (new branch in this checkout) or
+	// "worktree" (new branch in a worktree beside the repository)
+	Mode string `toml:"mode" json:"mode"`
+	// BranchPrefix prefixes session branch names (default: "rigrun/")
+	BranchPrefix string `toml:"branch_prefix" json:"branch_prefix,omitempty"`
+}
+
+// PlanConfig configures multi-step plan execution.
+type PlanConfig struct {
+	// MaxReplans is how many times a failed plan may be revised by the local
+	// model, each revision shown for approval (0 = never re-plan, default)
+	MaxReplans int `toml:"max_replans" json:"max_replans"`
+}
+
+// MaxPlanReplans bounds plan.max_replans.
+const MaxPlanReplans = 10
+
+// SchedulerConfig configures the scheduler run by "rigrun daemon".
+type SchedulerConfig struct {
+	// CatchUp is what happens to runs missed while no daemon was running:
+	// "skip" them, run "once" for all of them (default), or run "all" of them
+	CatchUp string `toml:"catch_up" json:"catch_up"`
+	// MaxCatchUp caps the missed runs made up by catch_up = "all" (0 = 24)
+	MaxCatchUp int `toml:"max_catch_up" json:"max_catch_up"`
+}
+
+// RetentionConfig configures how long each class of stored data is kept
+// (NIST 800-53 SI-12). Data past its policy is securely deleted at startup
+// and by "rigrun data purge", and each purge is audited.
+type RetentionConfig struct {
+	// PurgeOnStartup applies the policies when rigrun starts (default: true)
+	PurgeOnStartup bool `toml:"purge_on_startup" json:"purge_on_startup"`
+
+	// Conversations are the saved conversations; pinned ones are kept
+	Conversations RetentionPolicyConfig `toml:"conversations" json:"conversations"`
+	// Costs are the per-session cost records
+	Costs RetentionPolicyConfig `toml:"costs" json:"costs"`
+	// Intel is the competitive intelligence report cache
+	Intel RetentionPolicyConfig `toml:"intel" json:"intel"`
+	// Benchmarks are the saved benchmark results and comparisons
+	Benchmarks RetentionPolicyConfig `toml:"benchmarks" json:"benchmarks"`
+	// Checkpoints are the saved plans interrupted plans resume from; plans
+	// that are still running or paused are kept
+	Checkpoints RetentionPolicyConfig `toml:"checkpoints" json:"checkpoints"`
+	// Tasks are the finished background tasks and their output
+	Tasks RetentionPolicyConfig `toml:"tasks" json:"tasks"`
+	// AuditArchives are the archived audit logs, only purged by age and
+	// never before the AU-11 retention period
+	AuditArchives RetentionPolicyConfig `toml:"audit_archives" json:"audit_archives"`
+
+	// Classified applies on top of the above to data marked CONFIDENTIAL or
+	// above, the stricter limit winning
+	Classified RetentionPolicyConfig `toml:"classified" json:"classified"`
+}
+
+// RetentionPolicyConfig limits how much of a class of data is kept. Zero
+// limits are not applied; all zero keeps everything.
+type RetentionPolicyConfig struct {
+	// MaxAgeDays purges data last written longer ago than this
+	MaxAgeDays int `toml:"max_age_days" json:"max_age_days"`
+	// MaxCount keeps at most this many items, the most recent first
+	MaxCount int `toml:"max_count" json:"max_count"`
+	// MaxSizeMB keeps at most this much data, the most recent first
+	MaxSizeMB int `toml:"max_size_mb" json:"max_size_mb"`
+}
+
+// validate checks that no limit is negative.
+func (p RetentionPolicyConfig) validate(prefix string) ValidateErrors {
+	var errs ValidateErrors
+	for _, limit := range []struct {
+		key   string
+		value int
+	}{{"max_age_days", p.MaxAgeDays}, {"max_count", p.MaxCount}, {"max_size_mb", p.MaxSizeMB}} {
+		if limit.value < 0 {
+			errs = append(errs, ValidationError{
+				Field:   prefix + limit.key,
+				Message: "must be non-negative",
+			})
+		}
+	}
+	return errs
+}
+
+// ScheduleConfig is a prompt or plan run on a cron schedule. Schedules are
+// read from the config and from <repo root>/.rigrun/schedules.toml.
+type ScheduleConfig struct {
+	// Name identifies the schedule in the audit log and "rigrun schedule"
+	Name string `toml:"name" json:"name"`
+	// Cron is a five-field cron expression or macro such as "@daily"
+	Cron string `toml:"cron" json:"cron"`
+	// Kind is "ask" (default: answer the prompt) or "plan" (plan and execute it)
+	Kind string `toml:"kind" json:"kind,omitempty"`
+	// Prompt is the question or task
+	Prompt string `toml:"prompt" json:"prompt"`
+	// Model is the local model to use ("" = local.ollama_model)
+	Model string `toml:"model" json:"model,omitempty"`
+	// Agentic lets an "ask" schedule use tools; calls needing approval are denied
+	Agentic bool `toml:"agentic" json:"agentic,omitempty"`
+	// CatchUp overrides scheduler.catch_up for this schedule
+	CatchUp string `toml:"catch_up" json:"catch_up,omitempty"`
+	// Timezone is the IANA zone the expression is read in ("" = local time)
+	Timezone string `toml:"timezone" json:"timezone,omitempty"`
+	// WorkDir is the directory the run works in ("" = where the daemon runs;
+	// the repository root for schedules.toml)
+	WorkDir string `toml:"work_dir" json:"work_dir,omitempty"`
+	// Disabled keeps the schedule without running it
+	Disabled bool `toml:"disabled" json:"disabled,omitempty"`
+}
+
+// ValidCatchUp reports whether policy is a valid catch-up policy ("" means
+// the default).
+func ValidCatchUp(policy string) bool {
+	switch strings.ToLower(policy) {
+	case "", "skip", "once", "all":
+		return true
+	}
+	return false
+}
+
+// validateSchedules checks each schedule's fields and that names are
+// unique. Cron expressions are parsed by the cron package.
+func validateSchedules(schedules []ScheduleConfig, prefix string) ValidateErrors {
+	var errs ValidateErrors
+	names := make(map[string]bool)
+	for i, sched := range schedules {
+		field := fmt.Sprintf("%s[%d]", prefix, i)
+		if strings.TrimSpace(sched.Name) == "" {
+			errs = append(errs, ValidationError{Field: field + ".name", Message: "name is required"})
+		} else if names[sched.Name] {
+			errs = append(errs, ValidationError{Field: field + ".name", Message: fmt.Sprintf("duplicate schedule %q", sched.Name)})
+		}
+		names[sched.Name] = true
+		if _, err := cron.Parse(sched.Cron); err != nil {
+			errs = append(errs, ValidationError{Field: field + ".cron", Message: err.Error()})
+		}
+		switch strings.ToLower(sched.Kind) {
+		case "", "ask", "plan":
+		default:
+			errs = append(errs, ValidationError{
+				Field:   field + ".kind",
+				Message: fmt.Sprintf("must be ask or plan, got %s", sched.Kind),
+			})
+		}
+		if strings.TrimSpace(sched.Prompt) == "" {
+			errs = append(errs, ValidationError{Field: field + ".prompt", Message: "prompt is required"})
+		}
+		if !ValidCatchUp(sched.CatchUp) {
+			errs = append(errs, ValidationError{
+				Field:   field + ".catch_up",
+				Message: fmt.Sprintf("must be skip, once, or all, got %s", sched.CatchUp),
+			})
+		}
+		if sched.Timezone != "" {
+			if _, err := time.LoadLocation(sched.Timezone); err != nil {
+				errs = append(errs, ValidationError{Field: field + ".timezone", Message: err.Error()})
+			}
+		}
+	}
+	return errs
+}
+
+// LoadSchedulesFile loads a project schedules file (.rigrun/schedules.toml),
+// which holds [[schedules]] tables like the config.
+func LoadSchedulesFile(path string) ([]ScheduleConfig, error) {
+	var file struct {
+		Schedules []ScheduleConfig `toml:"schedules"`
+	}
+	md, err := toml.DecodeFile(path, &file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode schedules %s: %w", path, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		// A typo like "promt" would silently leave the field empty
+		return nil, fmt.Errorf("schedules %s: unknown key %s", path, undecoded[0])
+	}
+	if errs := validateSchedules(file.Schedules, "schedules"); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid schedules %s: %w", path, errs)
+	}
+	return file.Schedules, nil
+}
+
+// PermissionsConfig is a declarative tool permission policy for CI jobs and
+// scripted agent runs. Rules are "Tool", "Tool(pattern)", "*" or
+// "risk:level"; deny beats ask beats allow. See tools/permission_policy.go.
+type PermissionsConfig struct {
+	// Default is the decision when no rule matches: "allow", "ask", "deny",
+	// or empty to keep each tool's own permission
+	Default string `toml:"default" json:"default,omitempty"`
+	// Allow lists rules that run without asking
+	Allow []string `toml:"allow" json:"allow,omitempty"`
+	// Ask lists rules that need approval (denied when nobody can answer)
+	Ask []string `toml:"ask" json:"ask,omitempty"`
+	// Deny lists rules that never run
+	Deny []string `toml:"deny" json:"deny,omitempty"`
+}
+
+// IsEmpty returns true if the policy has no rules and no default.
+func (p PermissionsConfig) IsEmpty() bool {
+	return p.Default == "" && len(p.Allow) == 0 && len(p.Ask) == 0 && len(p.Deny) == 0
+}
+
+// validate checks the policy's default and that every rule is non-empty
+// with balanced parentheses. Rule patterns are compiled by the tools package.
+func (p PermissionsConfig) validate(prefix string) ValidateErrors {
+	var errs ValidateErrors
+	switch strings.ToLower(strings.TrimSpace(p.Default)) {
+	case "", "allow", "auto", "ask", "deny", "never":
+	default:
+		errs = append(errs, ValidationError{
+			Field:   prefix + "default",
+			Message: fmt.Sprintf("must be allow, ask, or deny, got %s", p.Default),
+		})
+	}
+	for _, list := range []struct {
+		kind  string
+		rules []string
+	}{{"allow", p.Allow}, {"ask", p.Ask}, {"deny", p.Deny}} {
+		for i, rule := range list.rules {
+			rule = strings.TrimSpace(rule)
+			if rule == "" || strings.Count(rule, "(") != strings.Count(rule, ")") ||
+				(strings.Contains(rule, "(") && !strings.HasSuffix(rule, ")")) {
+				errs = append(errs, ValidationError{
+					Field:   fmt.Sprintf("%s%s[%d]", prefix, list.kind, i),
+					Message: fmt.Sprintf("invalid rule %q (expected Tool, Tool(pattern), * or risk:level)", rule),
+				})
+			}
+		}
+	}
+	return errs
+}
+
+// LoadPermissionsFile loads a permission policy file (--permission-policy).
+// The file is TOML with default, allow, ask and deny at the top level.
+func LoadPermissionsFile(path string) (PermissionsConfig, error) {
+	var p PermissionsConfig
+	md, err := toml.DecodeFile(path, &p)
+	if err != nil {
+		return p, fmt.Errorf("failed to decode permission policy %s: %w", path, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		// A typo like "alow" would silently fall through to the default
+		return p, fmt.Errorf("permission policy %s: unknown key %s", path, undecoded[0])
+	}
+	if errs := p.validate(""); len(errs) > 0 {
+		return p, fmt.Errorf("invalid permission policy %s: %w", path, errs)
+	}
+	return p, nil
+}
+
 // ConsentConfig contains DoD consent/system use notification settings.
 // This supports IL5 compliance with NIST 800-53 AC-8 (System Use Notification).
 type ConsentConfig struct {
@@ -314,6 +669,11 @@ func Default() *Config {
 			AutoFallback:    "local",
 		},
 
+		Budget: BudgetConfig{
+			WarnPercent: 80,
+			OnExceeded:  "local",
+		},
+
 		Local: LocalConfig{
 			OllamaURL:   "http://127.0.0.1:11434",
 			OllamaModel: "qwen2.5-coder:14b",
@@ -336,12 +696,16 @@ func Default() *Config {
 			MFAEnabled:               false,    // IA-2(1): MFA disabled (placeholder)
 			SpillageDetection:        true,     // IR-9: Spillage detection enabled by default
 			SpillageAction:           "warn",    // IR-9: Default to warning only
+			SecretScanning:           true,     // SC-7(10): Scan outbound cloud prompts for credentials
+			SecretAction:             "redact", // SC-7(10): Redact with restorable placeholders
+			BashSandbox:              "off",    // SC-39: Bash sandbox is opt-in
 			TLSMinVersion:            "TLS1.2", // SC-17: Minimum TLS 1.2 for FIPS compliance
 			// SC-28: Protection of Information at Rest - SECURITY: Encryption enabled by default for IL5
 			EncryptionEnabled:        true,  // SC-28: Encryption enabled by default for API keys
 			EncryptConfig:            true,  // SC-28: Encrypt sensitive config fields by default
 			EncryptCache:             true,  // SC-28: Cache encryption ENABLED by default for IL5 compliance
 			EncryptAudit:             false, // SC-28: Audit encryption optional (for highly sensitive deployments)
+			EncryptData:              true,  // SC-28: Encrypt conversations and other user content once a key exists
 		},
 
 		Consent: ConsentConfig{
@@ -368,6 +732,18 @@ func Default() *Config {
 			VimMode:           false, // Vim mode disabled by default
 			TutorialCompleted: false,
 		},
+
+		Scheduler: SchedulerConfig{
+			CatchUp: "once",
+		},
+
+		Retention: RetentionConfig{
+			PurgeOnStartup: true,
+			Costs:          RetentionPolicyConfig{MaxAgeDays: 365},
+			Intel:          RetentionPolicyConfig{MaxAgeDays: 30},
+			Checkpoints:    RetentionPolicyConfig{MaxAgeDays: 90},
+			Tasks:          RetentionPolicyConfig{MaxAgeDays: 90},
+		},
 	}
 }
 
@@ -438,7 +814,9 @@ func ensureSecurePermissions(path string) error {
 
 // Load loads configuration from the config file(s).
 // Tries TOML first, then JSON, and falls back to defaults.
-// Environment overrides are applied last.
+// Environment overrides are applied next, and the administrator policy
+// bundle (if installed) last. If the bundle fails verification, paranoid mode
+// is forced on and the reason is available from PolicyError.
 // CONFIG: Comprehensive validation ensures safe configuration
 func Load() (*Config, error) {
 	cfg := Default()
@@ -460,6 +838,8 @@ func Load() (*Config, error) {
 				if err := cfg.Validate(); err != nil {
 					return nil, fmt.Errorf("invalid config: %w", err)
 				}
+				// CM-5: Administrator policy overrides user config
+				cfg.applySystemPolicy()
 				return cfg, nil
 			}
 		}
@@ -481,6 +861,8 @@ func Load() (*Config, error) {
 				if err := cfg.Validate(); err != nil {
 					return nil, fmt.Errorf("invalid config: %w", err)
 				}
+				// CM-5: Administrator policy overrides user config
+				cfg.applySystemPolicy()
 				return cfg, nil
 			}
 		}
@@ -498,6 +880,9 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("invalid config: %w", err)
 	}
 
+	// CM-5: Administrator policy overrides defaults as well
+	cfg.applySystemPolicy()
+
 	// Return defaults (with any load error for informational purposes)
 	return cfg, loadErr
 }
@@ -566,6 +951,9 @@ func LoadFromPath(path string) (*Config, error) {
 		return nil, fmt.Errorf("invalid config: %w", err)
 	}
 
+	// CM-5: Administrator policy overrides user config
+	cfg.applySystemPolicy()
+
 	return cfg, nil
 }
 
@@ -649,7 +1037,9 @@ func Save(cfg *Config) error {
 
 // SaveTOML saves the configuration to a TOML file.
 // SECURITY: Creates config files with 0600 permissions (owner read/write only).
+// Settings locked by policy are written with the user's own values.
 func SaveTOML(cfg *Config, path string) error {
+	cfg = cfg.userView()
 	if err := EnsureConfigDir(); err != nil {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
@@ -684,7 +1074,9 @@ func SaveTOML(cfg *Config, path string) error {
 // SaveJSON saves the configuration to a JSON file.
 // SECURITY: Creates config files with 0600 permissions (owner read/write only).
 // RELIABILITY: Atomic write with fsync prevents data loss on crash
+// Settings locked by policy are written with the user's own values.
 func SaveJSON(cfg *Config, path string) error {
+	cfg = cfg.userView()
 	if err := EnsureConfigDir(); err != nil {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
@@ -770,6 +1162,39 @@ func (c *Config) Validate() error {
 		})
 	}
 
+	// Validate budgets
+	for _, budget := range []struct {
+		key   string
+		value float64
+	}{
+		{"session_usd", c.Budget.SessionUSD},
+		{"daily_usd", c.Budget.DailyUSD},
+		{"weekly_usd", c.Budget.WeeklyUSD},
+		{"monthly_usd", c.Budget.MonthlyUSD},
+	} {
+		if budget.value < 0 {
+			errs = append(errs, ValidationError{
+				Field:   "budget." + budget.key,
+				Message: "must be non-negative",
+			})
+		}
+	}
+	if c.Budget.WarnPercent < 0 || c.Budget.WarnPercent > 100 {
+		errs = append(errs, ValidationError{
+			Field:   "budget.warn_percent",
+			Message: "must be between 0 and 100",
+		})
+	}
+	if c.Budget.OnExceeded != "" {
+		validActions := map[string]bool{"local": true, "prompt": true}
+		if !validActions[strings.ToLower(c.Budget.OnExceeded)] {
+			errs = append(errs, ValidationError{
+				Field:   "budget.on_exceeded",
+				Message: fmt.Sprintf("invalid action '%s', must be one of: local, prompt", c.Budget.OnExceeded),
+			})
+		}
+	}
+
 	// Validate max tier
 	validTiers := map[string]bool{
 		"cache": true, "local": true, "cloud": true,
@@ -868,6 +1293,35 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	// Validate secret handling (SC-7(10) compliance)
+	if c.Security.SecretAction != "" {
+		validSecretActions := map[string]bool{"block": true, "redact": true, "ask": true}
+		if !validSecretActions[strings.ToLower(c.Security.SecretAction)] {
+			errs = append(errs, ValidationError{
+				Field:   "security.secret_action",
+				Message: fmt.Sprintf("secret_action must be block, redact, or ask, got %s", c.Security.SecretAction),
+			})
+		}
+	}
+	// Validate Bash sandbox mode (SC-39 compliance)
+	if c.Security.BashSandbox != "" {
+		validSandboxModes := map[string]bool{"off": true, "auto": true, "required": true}
+		if !validSandboxModes[strings.ToLower(c.Security.BashSandbox)] {
+			errs = append(errs, ValidationError{
+				Field:   "security.bash_sandbox",
+				Message: fmt.Sprintf("bash_sandbox must be off, auto, or required, got %s", c.Security.BashSandbox),
+			})
+		}
+	}
+	for _, pattern := range c.Security.SecretPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			errs = append(errs, ValidationError{
+				Field:   "security.secret_patterns",
+				Message: fmt.Sprintf("invalid secret pattern %q: %v", pattern, err),
+			})
+		}
+	}
+
 	// Validate classification if provided
 	if c.Security.Classification != "" {
 		validClassifications := map[string]bool{
@@ -923,6 +1377,109 @@ func (c *Config) Validate() error {
 		})
 	}
 
+	// ==========================================================================
+	// Hook Validation
+	// ==========================================================================
+
+	validHookEvents := map[string]bool{"pre_tool": true, "post_tool": true, "stop": true}
+	for i, hook := range c.Hooks {
+		field := fmt.Sprintf("hooks[%d]", i)
+		if !validHookEvents[strings.ToLower(hook.Event)] {
+			errs = append(errs, ValidationError{
+				Field:   field + ".event",
+				Message: fmt.Sprintf("event must be pre_tool, post_tool, or stop, got %s", hook.Event),
+			})
+		}
+		if hook.Matcher != "" && hook.Matcher != "*" {
+			if _, err := regexp.Compile("^(?:" + hook.Matcher + ")$"); err != nil {
+				errs = append(errs, ValidationError{
+					Field:   field + ".matcher",
+					Message: fmt.Sprintf("invalid matcher %q: %v", hook.Matcher, err),
+				})
+			}
+		}
+		if strings.TrimSpace(hook.Command) == "" {
+			errs = append(errs, ValidationError{
+				Field:   field + ".command",
+				Message: "command is required",
+			})
+		}
+		if hook.TimeoutSecs < 0 {
+			errs = append(errs, ValidationError{
+				Field:   field + ".timeout_secs",
+				Message: "must be non-negative",
+			})
+		}
+	}
+
+	// ==========================================================================
+	// Git Validation
+	// ==========================================================================
+
+	switch strings.ToLower(c.Git.Mode) {
+	case "", "off", "branch", "worktree":
+	default:
+		errs = append(errs, ValidationError{
+			Field:   "git.mode",
+			Message: fmt.Sprintf("must be off, branch, or worktree, got %s", c.Git.Mode),
+		})
+	}
+
+	// ==========================================================================
+	// Plan Validation
+	// ==========================================================================
+
+	if c.Plan.MaxReplans < 0 || c.Plan.MaxReplans > MaxPlanReplans {
+		errs = append(errs, ValidationError{
+			Field:   "plan.max_replans",
+			Message: fmt.Sprintf("must be between 0 and %d, got %d", MaxPlanReplans, c.Plan.MaxReplans),
+		})
+	}
+
+	// ==========================================================================
+	// Scheduler Validation
+	// ==========================================================================
+
+	if !ValidCatchUp(c.Scheduler.CatchUp) {
+		errs = append(errs, ValidationError{
+			Field:   "scheduler.catch_up",
+			Message: fmt.Sprintf("must be skip, once, or all, got %s", c.Scheduler.CatchUp),
+		})
+	}
+	if c.Scheduler.MaxCatchUp < 0 {
+		errs = append(errs, ValidationError{
+			Field:   "scheduler.max_catch_up",
+			Message: "must be non-negative",
+		})
+	}
+	errs = append(errs, validateSchedules(c.Schedules, "schedules")...)
+
+	// ==========================================================================
+	// Retention Validation
+	// ==========================================================================
+
+	for _, class := range []struct {
+		key    string
+		policy RetentionPolicyConfig
+	}{
+		{"conversations", c.Retention.Conversations},
+		{"costs", c.Retention.Costs},
+		{"intel", c.Retention.Intel},
+		{"benchmarks", c.Retention.Benchmarks},
+		{"checkpoints", c.Retention.Checkpoints},
+		{"tasks", c.Retention.Tasks},
+		{"audit_archives", c.Retention.AuditArchives},
+		{"classified", c.Retention.Classified},
+	} {
+		errs = append(errs, class.policy.validate("retention."+class.key+".")...)
+	}
+
+	// ==========================================================================
+	// Permission Policy Validation
+	// ==========================================================================
+
+	errs = append(errs, c.Permissions.validate("permissions.")...)
+
 	if len(errs) > 0 {
 		return errs
 	}
@@ -990,6 +1547,9 @@ func (c *Config) SetDefaults() {
 	if c.Security.SpillageAction == "" {
 		c.Security.SpillageAction = defaults.Security.SpillageAction
 	}
+	if c.Security.SecretAction == "" {
+		c.Security.SecretAction = defaults.Security.SecretAction
+	}
 	if c.Security.AllowedCiphers == "" {
 		c.Security.AllowedCiphers = "AES-256-GCM"
 	}
@@ -1057,6 +1617,7 @@ func (c *Config) Migrate() error {
 //   - RIGRUN_MODE: overrides routing.default_mode
 //   - RIGRUN_MAX_TIER: overrides routing.max_tier
 //   - RIGRUN_CLASSIFICATION: overrides security.classification
+//   - RIGRUN_POLICY_PUBLIC_KEY: overrides security.policy_public_key
 func (c *Config) ApplyEnvOverrides() {
 	// RIGRUN_MODEL
 	if model := os.Getenv("RIGRUN_MODEL"); model != "" {
@@ -1106,6 +1667,11 @@ func (c *Config) ApplyEnvOverrides() {
 	if policyKey := os.Getenv("RIGRUN_POLICY_KEY"); policyKey != "" {
 		c.Security.PolicyKey = policyKey
 	}
+
+	// RIGRUN_POLICY_PUBLIC_KEY (CM-5: Access Restrictions for Change)
+	if policyPub := os.Getenv("RIGRUN_POLICY_PUBLIC_KEY"); policyPub != "" {
+		c.Security.PolicyPublicKey = policyPub
+	}
 }
 
 // =============================================================================
@@ -1129,7 +1695,7 @@ func (c *Config) Get(key string) (interface{}, error) {
 			return strings.EqualFold(name, fieldName)
 		})
 
-		if !field.IsValid() {
+		if !field.IsValid() || !field.CanInterface() {
 			return nil, fmt.Errorf("unknown field: %s", strings.Join(parts[:i+1], "."))
 		}
 
@@ -1150,7 +1716,16 @@ func (c *Config) Get(key string) (interface{}, error) {
 }
 
 // Set sets a configuration value using dot notation (e.g., "routing.max_tier").
+// Returns an error wrapping ErrLockedByPolicy if the key is pinned by policy.
 func (c *Config) Set(key string, value interface{}) error {
+	if c.IsLocked(key) {
+		return fmt.Errorf("%s: %w", key, ErrLockedByPolicy)
+	}
+	return c.set(key, value)
+}
+
+// set assigns a configuration value without checking policy locks.
+func (c *Config) set(key string, value interface{}) error {
 	parts := strings.Split(key, ".")
 	if len(parts) == 0 {
 		return errors.New("empty key")
@@ -1268,6 +1843,12 @@ func GetAllKeys() []string {
 		"routing.auto_prefer_local",
 		"routing.auto_max_cost",
 		"routing.auto_fallback",
+		"budget.session_usd",
+		"budget.daily_usd",
+		"budget.weekly_usd",
+		"budget.monthly_usd",
+		"budget.warn_percent",
+		"budget.on_exceeded",
 		"local.ollama_url",
 		"local.ollama_model",
 		"cloud.openrouter_key",
@@ -1296,19 +1877,30 @@ func GetAllKeys() []string {
 		"security.encrypt_config",
 		"security.encrypt_cache",
 		"security.encrypt_audit",
+		"security.encrypt_data",
 		// SC-13: Cryptographic Protection
 		"security.fips_mode",
 		// SC-17: PKI Certificates
 		"security.tls_min_version",
 		"security.certificate_pinning",
 		"security.pinned_certificates",
+		"security.trust_store",
 		// IR-6: Incident Reporting
 		"security.incident_webhook",
 		// IR-9: Information Spillage Response
 		"security.spillage_detection",
 		"security.spillage_action",
+		// SC-7(10): Prevent Exfiltration
+		"security.secret_scanning",
+		"security.secret_action",
+		"security.secret_patterns",
+		// SC-39: Process Isolation
+		"security.bash_sandbox",
+		"security.bash_sandbox_network",
 		// AU-9: Protection of Audit Information
 		"security.policy_key",
+		// CM-5: Access Restrictions for Change
+		"security.policy_public_key",
 		"cache.enabled",
 		"cache.ttl_hours",
 		"cache.max_size",
@@ -1321,6 +1913,37 @@ func GetAllKeys() []string {
 		"ui.vim_mode",
 		"ui.tutorial_completed",
 		"ui.tutorial_step",
+		"git.mode",
+		"git.branch_prefix",
+		"plan.max_replans",
+		"scheduler.catch_up",
+		"scheduler.max_catch_up",
+		// SI-12: Information Management and Retention
+		"retention.purge_on_startup",
+		"retention.conversations.max_age_days",
+		"retention.conversations.max_count",
+		"retention.conversations.max_size_mb",
+		"retention.costs.max_age_days",
+		"retention.costs.max_count",
+		"retention.costs.max_size_mb",
+		"retention.intel.max_age_days",
+		"retention.intel.max_count",
+		"retention.intel.max_size_mb",
+		"retention.benchmarks.max_age_days",
+		"retention.benchmarks.max_count",
+		"retention.benchmarks.max_size_mb",
+		"retention.checkpoints.max_age_days",
+		"retention.checkpoints.max_count",
+		"retention.checkpoints.max_size_mb",
+		"retention.tasks.max_age_days",
+		"retention.tasks.max_count",
+		"retention.tasks.max_size_mb",
+		"retention.audit_archives.max_age_days",
+		"retention.audit_archives.max_count",
+		"retention.audit_archives.max_size_mb",
+		"retention.classified.max_age_days",
+		"retention.classified.max_count",
+		"retention.classified.max_size_mb",
 	}
 }
 
@@ -1439,6 +2062,25 @@ func (c *Config) Clone() *Config {
 		}
 	}
 
+	// Hooks are value structs; copy the slice so appends don't alias
+	if c.Hooks != nil {
+		clone.Hooks = append([]HookConfig(nil), c.Hooks...)
+	}
+	if c.Schedules != nil {
+		clone.Schedules = append([]ScheduleConfig(nil), c.Schedules...)
+	}
+	if c.Permissions.Allow != nil {
+		clone.Permissions.Allow = append([]string(nil), c.Permissions.Allow...)
+	}
+	if c.Permissions.Ask != nil {
+		clone.Permissions.Ask = append([]string(nil), c.Permissions.Ask...)
+	}
+	if c.Permissions.Deny != nil {
+		clone.Permissions.Deny = append([]string(nil), c.Permissions.Deny...)
+	}
+
+	// The applied policy state is immutable and is shared, not copied.
+
 	return &clone
 }
 
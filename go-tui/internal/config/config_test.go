@@ -4,6 +4,9 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 )
@@ -296,6 +299,87 @@ func TestConfig_Validate(t *testing.T) {
 			}(),
 			wantErr: false,
 		},
+		{
+			name: "valid hooks",
+			config: func() *Config {
+				c := Default()
+				c.Hooks = []HookConfig{
+					{Event: "post_tool", Matcher: "Edit|Write", Command: "gofmt -w \"$RIGRUN_TOOL_FILE\""},
+					{Event: "stop", Command: "go test ./...", TimeoutSecs: 300},
+				}
+				return c
+			}(),
+			wantErr: false,
+		},
+		{
+			name: "invalid hook event",
+			config: func() *Config {
+				c := Default()
+				c.Hooks = []HookConfig{{Event: "after_tool", Command: "true"}}
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "invalid hook matcher",
+			config: func() *Config {
+				c := Default()
+				c.Hooks = []HookConfig{{Event: "pre_tool", Matcher: "Edit(", Command: "true"}}
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "hook without command",
+			config: func() *Config {
+				c := Default()
+				c.Hooks = []HookConfig{{Event: "pre_tool"}}
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "valid schedules",
+			config: func() *Config {
+				c := Default()
+				c.Schedules = []ScheduleConfig{
+					{Name: "commits", Cron: "0 8 * * mon-fri", Prompt: "Summarize yesterday's commits", Agentic: true},
+					{Name: "deps", Cron: "@weekly", Kind: "plan", Prompt: "Review dependencies", CatchUp: "skip", Timezone: "UTC"},
+				}
+				return c
+			}(),
+			wantErr: false,
+		},
+		{
+			name: "invalid schedule cron",
+			config: func() *Config {
+				c := Default()
+				c.Schedules = []ScheduleConfig{{Name: "bad", Cron: "0 25 * * *", Prompt: "x"}}
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "duplicate schedule name",
+			config: func() *Config {
+				c := Default()
+				c.Schedules = []ScheduleConfig{
+					{Name: "daily", Cron: "@daily", Prompt: "x"},
+					{Name: "daily", Cron: "@hourly", Prompt: "y"},
+				}
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "invalid catch-up policy",
+			config: func() *Config {
+				c := Default()
+				c.Scheduler.CatchUp = "sometimes"
+				return c
+			}(),
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -380,3 +464,44 @@ func TestConfig_Merge(t *testing.T) {
 		t.Error("Merge should not overwrite unset fields")
 	}
 }
+
+// TestLoadPermissionsFile tests loading a --permission-policy file.
+func TestLoadPermissionsFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	p, err := LoadPermissionsFile(write("ci.toml", `
+default = "deny"
+allow = ["Read", "Bash(go test *)", "Edit(internal/**)"]
+deny = ["Bash(git push *)"]
+`))
+	if err != nil {
+		t.Fatalf("LoadPermissionsFile() error = %v", err)
+	}
+	if p.Default != "deny" || len(p.Allow) != 3 || len(p.Deny) != 1 || p.IsEmpty() {
+		t.Errorf("unexpected policy %+v", p)
+	}
+
+	for name, content := range map[string]string{
+		"typo.toml":    `alow = ["Read"]`,
+		"default.toml": `default = "maybe"`,
+		"rule.toml":    `allow = ["Bash(go test *"]`,
+	} {
+		if _, err := LoadPermissionsFile(write(name, content)); err == nil {
+			t.Errorf("%s should be rejected", name)
+		}
+	}
+
+	// The [permissions] table is validated with the rest of the config
+	cfg := Default()
+	cfg.Permissions.Ask = []string{" "}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "permissions.ask[0]") {
+		t.Errorf("Validate() = %v, want a permissions.ask[0] error", err)
+	}
+}
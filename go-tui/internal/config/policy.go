@@ -0,0 +1,572 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// policy.go - Signed administrator policy bundles.
+//
+// Implements NIST 800-53 CM-5 (Access Restrictions for Change) and CM-6
+// (Configuration Settings): an administrator signs a policy document with an
+// Ed25519 key and installs it at a system path. At startup the bundle is
+// verified against the trusted public key and its settings override user
+// configuration. Overridden keys are "locked by policy" and cannot be changed
+// with /config or rigrun config.
+//
+// SECURITY: A bundle that is present but cannot be verified fails closed -
+// paranoid mode is forced on rather than silently ignoring the policy.
+package config
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// =============================================================================
+// POLICY ERRORS
+// =============================================================================
+
+var (
+	// ErrLockedByPolicy is returned when a setting pinned by the administrator
+	// policy is modified.
+	ErrLockedByPolicy = errors.New("locked by policy")
+
+	// ErrPolicyInvalid is reported by PolicyError when a policy bundle is
+	// installed but fails verification. Paranoid mode is forced on.
+	ErrPolicyInvalid = errors.New("policy bundle invalid")
+)
+
+// =============================================================================
+// POLICY STRUCTURES
+// =============================================================================
+
+// PolicyDocument is the administrator-authored payload of a policy bundle.
+// Unset fields leave the corresponding user setting untouched.
+type PolicyDocument struct {
+	// Version is the policy format version (currently 1)
+	Version int `toml:"version" json:"version"`
+	// Issuer identifies the administrator or organization that issued the policy
+	Issuer string `toml:"issuer" json:"issuer"`
+	// IssuedAt is when the policy was issued
+	IssuedAt time.Time `toml:"issued_at" json:"issued_at"`
+	// ExpiresAt is when the policy stops being valid (zero = never)
+	ExpiresAt time.Time `toml:"expires_at" json:"expires_at,omitempty"`
+
+	// ParanoidMode pins routing.paranoid_mode
+	ParanoidMode *bool `toml:"paranoid_mode" json:"paranoid_mode,omitempty"`
+	// OfflineMode pins routing.offline_mode (IL5 SC-7)
+	OfflineMode *bool `toml:"offline_mode" json:"offline_mode,omitempty"`
+	// Classification pins security.classification
+	Classification string `toml:"classification" json:"classification,omitempty"`
+	// AllowedTiers restricts routing to the listed tiers. routing.max_tier is
+	// pinned to the highest listed tier; if no cloud tier is listed, paranoid
+	// mode is pinned on.
+	AllowedTiers []string `toml:"allowed_tiers" json:"allowed_tiers,omitempty"`
+	// ToolPermissions maps tool names to "auto", "ask" or "never". Policy
+	// permissions can only make a tool stricter than its default.
+	ToolPermissions map[string]string `toml:"tool_permissions" json:"tool_permissions,omitempty"`
+	// NetworkAllowlist replaces the SC-7 boundary allow-list when non-empty.
+	NetworkAllowlist []string `toml:"network_allowlist" json:"network_allowlist,omitempty"`
+	// Settings pins arbitrary config keys in dot notation (e.g. "cache.enabled").
+	Settings map[string]interface{} `toml:"settings" json:"settings,omitempty"`
+}
+
+// PolicyBundle is the signed envelope distributed to managed hosts.
+// The signature covers the raw payload bytes exactly as issued.
+type PolicyBundle struct {
+	// Format is the payload encoding: "toml" or "json"
+	Format string `json:"format"`
+	// Payload is the base64-encoded policy document
+	Payload string `json:"payload"`
+	// Signature is the base64-encoded Ed25519 signature of the payload
+	Signature string `json:"signature"`
+	// KeyID identifies the signing key (see PolicyKeyID)
+	KeyID string `json:"key_id,omitempty"`
+}
+
+// lockedSetting records a config key pinned by policy and the value the user
+// had configured before the policy was applied.
+type lockedSetting struct {
+	Key       string
+	UserValue interface{}
+}
+
+// policyState is the policy applied to a Config. It is immutable once
+// attached, so Clone may share it between copies.
+type policyState struct {
+	doc    *PolicyDocument
+	path   string
+	err    error
+	locked map[string]lockedSetting
+}
+
+// policyTiers lists routing tiers from cheapest to most capable.
+var policyTiers = []string{"cache", "local", "cloud", "haiku", "sonnet", "opus", "gpt-4o"}
+
+// =============================================================================
+// POLICY PATHS
+// =============================================================================
+
+// systemPolicyDir is the directory holding the administrator policy bundle
+// and trusted key. It is a variable so tests can redirect it.
+var systemPolicyDir = defaultSystemPolicyDir()
+
+func defaultSystemPolicyDir() string {
+	if runtime.GOOS == "windows" {
+		if programData := os.Getenv("ProgramData"); programData != "" {
+			return filepath.Join(programData, "rigrun")
+		}
+		return `C:\ProgramData\rigrun`
+	}
+	return "/etc/rigrun"
+}
+
+// PolicyBundlePath returns the system path of the policy bundle.
+func PolicyBundlePath() string {
+	return filepath.Join(systemPolicyDir, "policy.bundle")
+}
+
+// PolicyPublicKeyPath returns the system path of the trusted policy key.
+func PolicyPublicKeyPath() string {
+	return filepath.Join(systemPolicyDir, "policy.pub")
+}
+
+// =============================================================================
+// KEYS
+// =============================================================================
+
+// PolicyKeyID returns a short fingerprint identifying a policy public key.
+func PolicyKeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// ParsePolicyPublicKey parses an Ed25519 public key in PEM (PKIX) or
+// base64 (raw 32-byte) form.
+func ParsePolicyPublicKey(data []byte) (ed25519.PublicKey, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key: %w", err)
+		}
+		pub, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return nil, errors.New("public key is not Ed25519")
+		}
+		return pub, nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode public key: %w", err)
+	}
+	if len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key length %d, expected %d", len(raw), ed25519.PublicKeySize)
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// ParsePolicyPrivateKey parses an Ed25519 private key in PEM (PKCS#8) or
+// base64 (32-byte seed or 64-byte key) form.
+func ParsePolicyPrivateKey(data []byte) (ed25519.PrivateKey, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key: %w", err)
+		}
+		priv, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			return nil, errors.New("private key is not Ed25519")
+		}
+		return priv, nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode private key: %w", err)
+	}
+	switch len(raw) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(raw), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(raw), nil
+	default:
+		return nil, fmt.Errorf("invalid private key length %d", len(raw))
+	}
+}
+
+// TrustedPolicyKey returns the public key policy bundles are verified against.
+// The system key file takes precedence over security.policy_public_key so that
+// a user-writable config cannot substitute its own trust anchor when the
+// administrator has installed one.
+func (c *Config) TrustedPolicyKey() (ed25519.PublicKey, error) {
+	data, err := os.ReadFile(PolicyPublicKeyPath())
+	if err == nil {
+		return ParsePolicyPublicKey(data)
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read policy public key: %w", err)
+	}
+	if c.Security.PolicyPublicKey != "" {
+		return ParsePolicyPublicKey([]byte(c.Security.PolicyPublicKey))
+	}
+	return nil, errors.New("no trusted policy public key configured")
+}
+
+// =============================================================================
+// SIGN / VERIFY
+// =============================================================================
+
+// ParsePolicyDocument decodes a policy document in the given format and
+// checks that it is well formed.
+func ParsePolicyDocument(payload []byte, format string) (*PolicyDocument, error) {
+	doc := &PolicyDocument{}
+	switch strings.ToLower(format) {
+	case "toml":
+		if _, err := toml.NewDecoder(bytes.NewReader(payload)).Decode(doc); err != nil {
+			return nil, fmt.Errorf("failed to decode TOML policy: %w", err)
+		}
+	case "json":
+		if err := json.Unmarshal(payload, doc); err != nil {
+			return nil, fmt.Errorf("failed to decode JSON policy: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported policy format %q, must be toml or json", format)
+	}
+
+	if doc.Version != 1 {
+		return nil, fmt.Errorf("unsupported policy version %d", doc.Version)
+	}
+	for _, tier := range doc.AllowedTiers {
+		if tierRank(tier) < 0 {
+			return nil, fmt.Errorf("invalid tier %q in allowed_tiers", tier)
+		}
+	}
+	for tool, perm := range doc.ToolPermissions {
+		switch strings.ToLower(perm) {
+		case "auto", "allow", "ask", "never", "deny":
+		default:
+			return nil, fmt.Errorf("invalid permission %q for tool %s", perm, tool)
+		}
+	}
+	return doc, nil
+}
+
+// SignPolicy validates a policy payload and signs it into a bundle.
+func SignPolicy(payload []byte, format string, priv ed25519.PrivateKey) (*PolicyBundle, error) {
+	if _, err := ParsePolicyDocument(payload, format); err != nil {
+		return nil, err
+	}
+	pub, ok := priv.Public().(ed25519.PublicKey)
+	if !ok {
+		return nil, errors.New("invalid signing key")
+	}
+	return &PolicyBundle{
+		Format:    strings.ToLower(format),
+		Payload:   base64.StdEncoding.EncodeToString(payload),
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(priv, payload)),
+		KeyID:     PolicyKeyID(pub),
+	}, nil
+}
+
+// ParsePolicyBundle decodes a bundle envelope.
+func ParsePolicyBundle(data []byte) (*PolicyBundle, error) {
+	bundle := &PolicyBundle{}
+	if err := json.Unmarshal(data, bundle); err != nil {
+		return nil, fmt.Errorf("failed to decode policy bundle: %w", err)
+	}
+	if bundle.Payload == "" || bundle.Signature == "" {
+		return nil, errors.New("policy bundle is missing payload or signature")
+	}
+	return bundle, nil
+}
+
+// Verify checks the bundle signature against pub and returns the decoded
+// policy document. Expired policies are rejected.
+func (b *PolicyBundle) Verify(pub ed25519.PublicKey) (*PolicyDocument, error) {
+	if len(pub) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid policy public key")
+	}
+	if b.KeyID != "" && b.KeyID != PolicyKeyID(pub) {
+		return nil, fmt.Errorf("bundle signed by key %s, trusted key is %s", b.KeyID, PolicyKeyID(pub))
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(b.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode payload: %w", err)
+	}
+	sig, err := base64.StdEncoding.DecodeString(b.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode signature: %w", err)
+	}
+	if !ed25519.Verify(pub, payload, sig) {
+		return nil, errors.New("signature verification failed")
+	}
+
+	doc, err := ParsePolicyDocument(payload, b.Format)
+	if err != nil {
+		return nil, err
+	}
+	if !doc.ExpiresAt.IsZero() && time.Now().After(doc.ExpiresAt) {
+		return nil, fmt.Errorf("policy expired at %s", doc.ExpiresAt.Format(time.RFC3339))
+	}
+	return doc, nil
+}
+
+// =============================================================================
+// APPLY
+// =============================================================================
+
+// applySystemPolicy loads, verifies and applies the system policy bundle if
+// one is installed. Failures are recorded on the config (see PolicyError)
+// rather than returned, so callers that fall back to defaults on a load
+// error cannot discard the fail-closed state.
+func (c *Config) applySystemPolicy() {
+	path := PolicyBundlePath()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		c.failClosed(path, fmt.Errorf("failed to read policy bundle: %w", err))
+		return
+	}
+
+	bundle, err := ParsePolicyBundle(data)
+	if err != nil {
+		c.failClosed(path, err)
+		return
+	}
+	pub, err := c.TrustedPolicyKey()
+	if err != nil {
+		c.failClosed(path, err)
+		return
+	}
+	doc, err := bundle.Verify(pub)
+	if err != nil {
+		c.failClosed(path, err)
+		return
+	}
+	if err := c.ApplyPolicy(doc); err != nil {
+		c.failClosed(path, err)
+		return
+	}
+	c.policy.path = path
+}
+
+// failClosed forces paranoid mode after a policy bundle failed verification
+// or could not be applied (ApplyPolicy has already undone any partial writes).
+// SECURITY: An unverifiable policy must not leave the user's (possibly less
+// restrictive) settings in effect.
+func (c *Config) failClosed(path string, cause error) {
+	state := &policyState{path: path, locked: make(map[string]lockedSetting)}
+	state.err = fmt.Errorf("%w (%s): %v", ErrPolicyInvalid, path, cause)
+	state.locked[lockID("routing.paranoid_mode")] = lockedSetting{
+		Key:       "routing.paranoid_mode",
+		UserValue: c.Routing.ParanoidMode,
+	}
+	c.Routing.ParanoidMode = true
+	c.policy = state
+}
+
+// ApplyPolicy overrides configuration with the settings pinned by doc and
+// marks them as locked. If any setting cannot be applied, the settings already
+// pinned are restored to their previous values so the policy applies entirely
+// or not at all; a setting that cannot be restored is named in the error.
+func (c *Config) ApplyPolicy(doc *PolicyDocument) (err error) {
+	state := &policyState{doc: doc, locked: make(map[string]lockedSetting)}
+	prevPolicy := c.policy
+	defer func() {
+		if err == nil {
+			return
+		}
+		c.policy = prevPolicy
+		errs := []error{err}
+		for _, s := range state.locked {
+			if rerr := c.set(s.Key, s.UserValue); rerr != nil {
+				errs = append(errs, fmt.Errorf("failed to restore %s after policy error: %w", s.Key, rerr))
+			}
+		}
+		err = errors.Join(errs...)
+	}()
+
+	pin := func(key string, value interface{}) error {
+		id := lockID(key)
+		if _, ok := state.locked[id]; !ok {
+			prev, err := c.Get(key)
+			if err != nil {
+				return err
+			}
+			state.locked[id] = lockedSetting{Key: key, UserValue: prev}
+		}
+		if err := c.set(key, value); err != nil {
+			return fmt.Errorf("policy setting %s: %w", key, err)
+		}
+		return nil
+	}
+
+	// Generic settings first so the explicit fields below take precedence.
+	keys := make([]string, 0, len(doc.Settings))
+	for key := range doc.Settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := pin(key, doc.Settings[key]); err != nil {
+			return err
+		}
+	}
+
+	if doc.ParanoidMode != nil {
+		if err := pin("routing.paranoid_mode", *doc.ParanoidMode); err != nil {
+			return err
+		}
+	}
+	if doc.OfflineMode != nil {
+		if err := pin("routing.offline_mode", *doc.OfflineMode); err != nil {
+			return err
+		}
+	}
+	if doc.Classification != "" {
+		if err := pin("security.classification", doc.Classification); err != nil {
+			return err
+		}
+	}
+	if len(doc.AllowedTiers) > 0 {
+		highest := -1
+		for _, tier := range doc.AllowedTiers {
+			if rank := tierRank(tier); rank > highest {
+				highest = rank
+			}
+		}
+		if err := pin("routing.max_tier", policyTiers[highest]); err != nil {
+			return err
+		}
+		// No cloud tier allowed: block cloud routing entirely.
+		if highest <= tierRank("local") {
+			if err := pin("routing.paranoid_mode", true); err != nil {
+				return err
+			}
+		}
+	}
+
+	c.policy = state
+	return c.Validate()
+}
+
+// tierRank returns the position of tier in policyTiers, or -1 if unknown.
+func tierRank(tier string) int {
+	tier = strings.ToLower(strings.TrimSpace(tier))
+	for i, t := range policyTiers {
+		if t == tier {
+			return i
+		}
+	}
+	return -1
+}
+
+// lockID returns the canonical identifier for a dot-notation key, matching
+// the case- and separator-insensitive field lookup used by Get and Set.
+func lockID(key string) string {
+	parts := strings.Split(key, ".")
+	for i, part := range parts {
+		parts[i] = strings.ToLower(normalizeFieldName(part))
+	}
+	return strings.Join(parts, ".")
+}
+
+// userView returns the configuration as the user configured it, with
+// policy-pinned settings restored to their pre-policy values. Used when
+// saving so pinned values are never written into the user's config file.
+func (c *Config) userView() *Config {
+	if c.policy == nil || len(c.policy.locked) == 0 {
+		return c
+	}
+	u := c.Clone()
+	u.policy = nil
+	for _, s := range c.policy.locked {
+		if s.UserValue != nil {
+			_ = u.set(s.Key, s.UserValue)
+		}
+	}
+	return u
+}
+
+// =============================================================================
+// POLICY ACCESSORS
+// =============================================================================
+
+// Policy returns the applied policy document, or nil if none is in effect.
+func (c *Config) Policy() *PolicyDocument {
+	if c.policy == nil {
+		return nil
+	}
+	return c.policy.doc
+}
+
+// PolicyError returns why the installed policy bundle was rejected, or nil.
+func (c *Config) PolicyError() error {
+	if c.policy == nil {
+		return nil
+	}
+	return c.policy.err
+}
+
+// PolicyPath returns the path of the applied policy bundle, if any.
+func (c *Config) PolicyPath() string {
+	if c.policy == nil {
+		return ""
+	}
+	return c.policy.path
+}
+
+// IsLocked reports whether key (dot notation) is pinned by policy.
+func (c *Config) IsLocked(key string) bool {
+	if c.policy == nil {
+		return false
+	}
+	_, ok := c.policy.locked[lockID(key)]
+	return ok
+}
+
+// LockedKeys returns the dot-notation keys pinned by policy, sorted.
+func (c *Config) LockedKeys() []string {
+	if c.policy == nil {
+		return nil
+	}
+	keys := make([]string, 0, len(c.policy.locked))
+	for _, s := range c.policy.locked {
+		keys = append(keys, s.Key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// PolicyToolPermissions returns the tool permissions pinned by policy.
+func (c *Config) PolicyToolPermissions() map[string]string {
+	if doc := c.Policy(); doc != nil {
+		return doc.ToolPermissions
+	}
+	return nil
+}
+
+// PolicyNetworkAllowlist returns the network allow-list pinned by policy.
+func (c *Config) PolicyNetworkAllowlist() []string {
+	if doc := c.Policy(); doc != nil {
+		return doc.NetworkAllowlist
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package config
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testPolicyTOML = `
+version = 1
+issuer = "ISSM Test"
+paranoid_mode = true
+classification = "CUI"
+allowed_tiers = ["cache", "local", "haiku", "sonnet"]
+network_allowlist = ["git.example.mil"]
+
+[tool_permissions]
+Bash = "never"
+Write = "ask"
+
+[settings]
+"cache.enabled" = false
+`
+
+// installTestPolicy redirects the system policy directory to a temp dir and
+// writes a bundle signed with a fresh key plus the trusted public key.
+func installTestPolicy(t *testing.T, payload string) {
+	t.Helper()
+	dir := t.TempDir()
+	orig := systemPolicyDir
+	systemPolicyDir = dir
+	t.Cleanup(func() { systemPolicyDir = orig })
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	if err := os.WriteFile(PolicyPublicKeyPath(), []byte(base64.StdEncoding.EncodeToString(pub)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if payload != "" {
+		bundle, err := SignPolicy([]byte(payload), "toml", priv)
+		if err != nil {
+			t.Fatalf("SignPolicy: %v", err)
+		}
+		data, _ := json.Marshal(bundle)
+		if err := os.WriteFile(PolicyBundlePath(), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPolicy_SignVerifyRoundTrip(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+
+	bundle, err := SignPolicy([]byte(testPolicyTOML), "toml", priv)
+	if err != nil {
+		t.Fatalf("SignPolicy: %v", err)
+	}
+	doc, err := bundle.Verify(pub)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if doc.Issuer != "ISSM Test" || doc.ToolPermissions["Bash"] != "never" {
+		t.Errorf("unexpected document: %+v", doc)
+	}
+
+	// Tampered payload must fail
+	tampered := *bundle
+	tampered.Payload = base64.StdEncoding.EncodeToString([]byte(strings.Replace(testPolicyTOML, "true", "false", 1)))
+	if _, err := tampered.Verify(pub); err == nil {
+		t.Error("Verify should reject a tampered payload")
+	}
+
+	// Different key must fail
+	otherPub, _, _ := ed25519.GenerateKey(rand.Reader)
+	if _, err := bundle.Verify(otherPub); err == nil {
+		t.Error("Verify should reject a bundle signed by another key")
+	}
+}
+
+func TestPolicy_RejectsInvalidDocuments(t *testing.T) {
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+
+	tests := []string{
+		`version = 2`,
+		"version = 1\nallowed_tiers = [\"mainframe\"]",
+		"version = 1\n[tool_permissions]\nBash = \"sometimes\"",
+	}
+	for _, payload := range tests {
+		if _, err := SignPolicy([]byte(payload), "toml", priv); err == nil {
+			t.Errorf("SignPolicy(%q) should fail", payload)
+		}
+	}
+
+	expired := "version = 1\nexpires_at = 2020-01-01T00:00:00Z"
+	pub := priv.Public().(ed25519.PublicKey)
+	bundle, err := SignPolicy([]byte(expired), "toml", priv)
+	if err != nil {
+		t.Fatalf("SignPolicy: %v", err)
+	}
+	if _, err := bundle.Verify(pub); err == nil {
+		t.Error("Verify should reject an expired policy")
+	}
+}
+
+func TestPolicy_ApplyLocksSettings(t *testing.T) {
+	cfg := Default()
+	doc, err := ParsePolicyDocument([]byte(testPolicyTOML), "toml")
+	if err != nil {
+		t.Fatalf("ParsePolicyDocument: %v", err)
+	}
+	if err := cfg.ApplyPolicy(doc); err != nil {
+		t.Fatalf("ApplyPolicy: %v", err)
+	}
+
+	if !cfg.Routing.ParanoidMode || cfg.Security.Classification != "CUI" || cfg.Cache.Enabled {
+		t.Errorf("policy settings not applied: %+v", cfg.Routing)
+	}
+	if cfg.Routing.MaxTier != "sonnet" {
+		t.Errorf("MaxTier = %q, want sonnet", cfg.Routing.MaxTier)
+	}
+
+	for _, key := range []string{"routing.paranoid_mode", "routing.paranoidmode", "ROUTING.PARANOID_MODE", "cache.enabled"} {
+		if !cfg.IsLocked(key) {
+			t.Errorf("IsLocked(%q) = false, want true", key)
+		}
+	}
+	if err := cfg.Set("routing.paranoid_mode", "false"); !errors.Is(err, ErrLockedByPolicy) {
+		t.Errorf("Set on locked key: got %v, want ErrLockedByPolicy", err)
+	}
+	if err := cfg.Set("ui.theme", "light"); err != nil {
+		t.Errorf("Set on unlocked key: %v", err)
+	}
+
+	// Saving writes the user's own values, not the pinned ones
+	user := cfg.userView()
+	if user.Routing.ParanoidMode || user.Security.Classification != "UNCLASSIFIED" || !user.Cache.Enabled {
+		t.Errorf("userView should restore user values, got paranoid=%v classification=%s cache=%v",
+			user.Routing.ParanoidMode, user.Security.Classification, user.Cache.Enabled)
+	}
+	if user.UI.Theme != "light" {
+		t.Errorf("userView lost unlocked change, theme = %q", user.UI.Theme)
+	}
+}
+
+func TestPolicy_ApplyFailureRollsBack(t *testing.T) {
+	cfg := Default()
+	doc := &PolicyDocument{
+		Version: 1,
+		// Settings are applied in key order, so cache.enabled is pinned
+		// before the unknown key fails.
+		Settings: map[string]interface{}{"cache.enabled": false, "zzz.unknown": 1},
+	}
+	if err := cfg.ApplyPolicy(doc); err == nil {
+		t.Fatal("ApplyPolicy should fail on an unknown setting")
+	}
+	if !cfg.Cache.Enabled {
+		t.Error("cache.enabled should be restored after the policy failed")
+	}
+	if cfg.Policy() != nil || len(cfg.LockedKeys()) != 0 {
+		t.Error("failed policy must not be recorded")
+	}
+}
+
+func TestPolicy_LocalOnlyTiersForceParanoid(t *testing.T) {
+	cfg := Default()
+	doc := &PolicyDocument{Version: 1, AllowedTiers: []string{"cache", "local"}}
+	if err := cfg.ApplyPolicy(doc); err != nil {
+		t.Fatalf("ApplyPolicy: %v", err)
+	}
+	if cfg.Routing.MaxTier != "local" || !cfg.Routing.ParanoidMode {
+		t.Errorf("local-only tiers: max_tier=%s paranoid=%v", cfg.Routing.MaxTier, cfg.Routing.ParanoidMode)
+	}
+}
+
+func TestPolicy_LoadAppliesSystemBundle(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("USERPROFILE", os.Getenv("HOME"))
+	installTestPolicy(t, testPolicyTOML)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.PolicyError() != nil {
+		t.Fatalf("PolicyError: %v", cfg.PolicyError())
+	}
+	if cfg.Policy() == nil || !cfg.IsLocked("security.classification") {
+		t.Fatal("policy not applied by Load")
+	}
+	if got := cfg.PolicyNetworkAllowlist(); len(got) != 1 || got[0] != "git.example.mil" {
+		t.Errorf("PolicyNetworkAllowlist = %v", got)
+	}
+}
+
+func TestPolicy_InvalidBundleFailsClosed(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("USERPROFILE", os.Getenv("HOME"))
+	installTestPolicy(t, "")
+
+	// Bundle signed by a key other than the trusted one
+	_, rogue, _ := ed25519.GenerateKey(rand.Reader)
+	bundle, err := SignPolicy([]byte("version = 1\nparanoid_mode = false"), "toml", rogue)
+	if err != nil {
+		t.Fatalf("SignPolicy: %v", err)
+	}
+	data, _ := json.Marshal(bundle)
+	if err := os.WriteFile(filepath.Join(systemPolicyDir, "policy.bundle"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, _ := Load()
+	if cfg == nil {
+		t.Fatal("Load returned nil config")
+	}
+	if !errors.Is(cfg.PolicyError(), ErrPolicyInvalid) {
+		t.Errorf("PolicyError = %v, want ErrPolicyInvalid", cfg.PolicyError())
+	}
+	if !cfg.Routing.ParanoidMode || !cfg.IsLocked("routing.paranoid_mode") {
+		t.Error("invalid policy must force and lock paranoid mode")
+	}
+	if cfg.Policy() != nil {
+		t.Error("invalid policy document must not be exposed")
+	}
+}
+
+func TestPolicy_NoBundleNoPolicy(t *testing.T) {
+	orig := systemPolicyDir
+	systemPolicyDir = t.TempDir()
+	defer func() { systemPolicyDir = orig }()
+
+	cfg := Default()
+	cfg.applySystemPolicy()
+	if cfg.Policy() != nil || cfg.PolicyError() != nil || len(cfg.LockedKeys()) != 0 {
+		t.Error("no bundle installed should leave config unlocked")
+	}
+	if _, err := cfg.Get("policy"); err == nil {
+		t.Error("Get should not expose internal policy state")
+	}
+}
@@ -0,0 +1,281 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package cron parses standard five-field cron expressions and computes
+// when they next fire.
+//
+// Fields are minute (0-59), hour (0-23), day of month (1-31), month (1-12
+// or jan-dec) and day of week (0-7 or sun-sat, 0 and 7 both Sunday). Each
+// field is "*", a value, a range "a-b", a list "a,b", or any of these with a
+// step "/n". As in Vixie cron, when both day fields are restricted a day
+// matches if either does. The macros @yearly (@annually), @monthly,
+// @weekly, @daily (@midnight) and @hourly are accepted.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// =============================================================================
+// SCHEDULE
+// =============================================================================
+
+// Schedule is a parsed cron expression. Each field is a bit set of the
+// values it matches.
+type Schedule struct {
+	expr string
+
+	minute, hour, dom, month, dow uint64
+
+	// domAny and dowAny record an unrestricted day field ("*")
+	domAny, dowAny bool
+}
+
+// maxSearch bounds Next and Prev; an expression such as "0 0 30 2 *" never fires.
+const maxSearch = 5 * 366 * 24 * time.Hour
+
+// macros are the supported @ shorthands.
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// field describes one field of an expression.
+type field struct {
+	name     string
+	min, max int
+	names    []string // names[i] is value min+i (nil = numbers only)
+}
+
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12,
+		names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}}
+	dowField = field{name: "day of week", min: 0, max: 7,
+		names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}}
+)
+
+// Parse parses a cron expression.
+func Parse(expr string) (*Schedule, error) {
+	spec := strings.TrimSpace(expr)
+	if macro, ok := macros[strings.ToLower(spec)]; ok {
+		spec = macro
+	} else if strings.HasPrefix(spec, "@") {
+		return nil, fmt.Errorf("unknown cron macro %q", spec)
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields (minute hour day month weekday), got %d", expr, len(fields))
+	}
+
+	s := &Schedule{expr: strings.TrimSpace(expr)}
+	var err error
+	if s.minute, err = parseField(fields[0], minuteField); err != nil {
+		return nil, err
+	}
+	if s.hour, err = parseField(fields[1], hourField); err != nil {
+		return nil, err
+	}
+	if s.dom, err = parseField(fields[2], domField); err != nil {
+		return nil, err
+	}
+	if s.month, err = parseField(fields[3], monthField); err != nil {
+		return nil, err
+	}
+	if s.dow, err = parseField(fields[4], dowField); err != nil {
+		return nil, err
+	}
+
+	// Sunday is both 0 and 7
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domAny = fields[2] == "*"
+	s.dowAny = fields[4] == "*"
+	return s, nil
+}
+
+// String returns the expression as written.
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// Next returns the first time after t, to the minute and in t's location,
+// that the schedule fires. It returns the zero time if the schedule never
+// fires (e.g. February 30th).
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(maxSearch)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			// Around a DST change the next wall-clock hour may not be later
+			next := time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			if !next.After(t) {
+				next = t.Add(time.Hour - time.Duration(t.Minute())*time.Minute)
+			}
+			t = next
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// Prev returns the last time at or before t, to the minute and in t's
+// location, that the schedule fires. It returns the zero time if the
+// schedule never fires.
+func (s *Schedule) Prev(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute)
+	limit := t.Add(-maxSearch)
+
+	for !t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc).Add(-time.Minute)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc).Add(-time.Minute)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			// Around a DST change the wall-clock hour may not be earlier
+			prev := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc).Add(-time.Minute)
+			if !prev.Before(t) {
+				prev = t.Add(-time.Duration(t.Minute()+1) * time.Minute)
+			}
+			t = prev
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(-time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// Latest returns the times the schedule fires in (from, to], at most max
+// of them (the latest), in ascending order.
+func (s *Schedule) Latest(from, to time.Time, max int) []time.Time {
+	var times []time.Time
+	for t := s.Prev(to); !t.IsZero() && t.After(from) && len(times) < max; t = s.Prev(t.Add(-time.Minute)) {
+		times = append(times, t)
+	}
+	for i, j := 0, len(times)-1; i < j; i, j = i+1, j-1 {
+		times[i], times[j] = times[j], times[i]
+	}
+	return times
+}
+
+// Between returns the times the schedule fires in (from, to], at most max
+// of them (the earliest).
+func (s *Schedule) Between(from, to time.Time, max int) []time.Time {
+	var times []time.Time
+	for t := s.Next(from); !t.IsZero() && !t.After(to) && len(times) < max; t = s.Next(t) {
+		times = append(times, t)
+	}
+	return times
+}
+
+// dayMatches reports whether the day fields match t.
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// =============================================================================
+// PARSING
+// =============================================================================
+
+// parseField parses one comma-separated field into a bit set.
+func parseField(spec string, f field) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(spec, ",") {
+		rangeSpec, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			rangeSpec = part[:i]
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step in %s field %q", f.name, part)
+			}
+			step = n
+		}
+
+		lo, hi := f.min, f.max
+		switch {
+		case rangeSpec == "*":
+		case strings.Contains(rangeSpec, "-"):
+			bounds := strings.SplitN(rangeSpec, "-", 2)
+			var err error
+			if lo, err = f.value(bounds[0]); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(bounds[1]); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range in %s field %q", f.name, part)
+			}
+		default:
+			v, err := f.value(rangeSpec)
+			if err != nil {
+				return 0, err
+			}
+			// "a/n" runs from a to the end of the range
+			lo = v
+			if step == 1 {
+				hi = v
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// value parses a number or name in the field's range.
+func (f field) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid %s %q (must be %d-%d)", f.name, s, f.min, f.max)
+	}
+	return v, nil
+}
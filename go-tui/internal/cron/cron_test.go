@@ -0,0 +1,112 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"* * * foo *",
+		"@sometimes",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", expr)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	// Wednesday, 2025-01-15 10:30
+	from := time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2025, 1, 15, 10, 31, 0, 0, time.UTC)},
+		{"30 10 * * *", time.Date(2025, 1, 16, 10, 30, 0, 0, time.UTC)},
+		{"@daily", time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2025, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2025, 1, 15, 10, 45, 0, 0, time.UTC)},
+		{"0 9 * * mon-fri", time.Date(2025, 1, 16, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 7", time.Date(2025, 1, 19, 9, 0, 0, 0, time.UTC)},
+		{"0 0 1 feb *", time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 8 1,20 * 1", time.Date(2025, 1, 20, 8, 0, 0, 0, time.UTC)}, // either day field
+		{"0 12 10-20/5 * *", time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", tt.expr, err)
+		}
+		if got := s.Next(from); !got.Equal(tt.want) {
+			t.Errorf("Next(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestNextKeepsLocation(t *testing.T) {
+	loc := time.FixedZone("IST", 5*3600+1800)
+	s, _ := Parse("0 9 * * *")
+	got := s.Next(time.Date(2025, 1, 15, 10, 0, 0, 0, loc))
+	if want := time.Date(2025, 1, 16, 9, 0, 0, 0, loc); !got.Equal(want) || got.Location() != loc {
+		t.Errorf("Next = %v, want %v", got, want)
+	}
+}
+
+func TestBetween(t *testing.T) {
+	s, _ := Parse("0 * * * *")
+	from := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	times := s.Between(from, from.Add(5*time.Hour), 3)
+	if len(times) != 3 || !times[0].Equal(from.Add(time.Hour)) || !times[2].Equal(from.Add(3*time.Hour)) {
+		t.Errorf("Between = %v", times)
+	}
+}
+
+func TestPrev(t *testing.T) {
+	tests := []struct {
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"0 * * * *", time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC), time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC), time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)},
+		{"30 9 * * 1-5", time.Date(2025, 1, 19, 12, 0, 0, 0, time.UTC), time.Date(2025, 1, 17, 9, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC).Add(-time.Second), time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", tt.expr, err)
+		}
+		if got := s.Prev(tt.from); !got.Equal(tt.want) {
+			t.Errorf("%q Prev(%v) = %v, want %v", tt.expr, tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestLatest(t *testing.T) {
+	s, _ := Parse("0 * * * *")
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(30 * 24 * time.Hour).Add(30 * time.Minute)
+	times := s.Latest(from, to, 3)
+	if len(times) != 3 || !times[0].Equal(to.Truncate(time.Hour).Add(-2*time.Hour)) || !times[2].Equal(to.Truncate(time.Hour)) {
+		t.Errorf("Latest = %v", times)
+	}
+}
@@ -197,17 +197,31 @@ func computeLineDiff(oldLines, newLines []string) []DiffLine {
 		return result
 	}
 
+	// Lines shared at the start and end are context, so only the middle
+	// needs the quadratic LCS table
+	prefix, suffix := commonAffixes(oldLines, newLines)
+	oldEnd := len(oldLines) - suffix
+	newEnd := len(newLines) - suffix
+	for i := 0; i < prefix; i++ {
+		result = append(result, DiffLine{
+			Type:    DiffLineContext,
+			Content: oldLines[i],
+			OldLine: i + 1,
+			NewLine: i + 1,
+		})
+	}
+
 	// Use a simple LCS (Longest Common Subsequence) approach
-	lcs := computeLCS(oldLines, newLines)
+	lcs := computeLCS(oldLines[prefix:oldEnd], newLines[prefix:newEnd])
 
-	oldIdx := 0
-	newIdx := 0
+	oldIdx := prefix
+	newIdx := prefix
 	lcsIdx := 0
 
-	for oldIdx < len(oldLines) || newIdx < len(newLines) {
+	for oldIdx < oldEnd || newIdx < newEnd {
 		// Check if we're at a common line
 		if lcsIdx < len(lcs) &&
-		   oldIdx < len(oldLines) && newIdx < len(newLines) &&
+		   oldIdx < oldEnd && newIdx < newEnd &&
 		   oldLines[oldIdx] == newLines[newIdx] &&
 		   oldLines[oldIdx] == lcs[lcsIdx] {
 			// Context line (unchanged)
@@ -220,7 +234,7 @@ func computeLineDiff(oldLines, newLines []string) []DiffLine {
 			oldIdx++
 			newIdx++
 			lcsIdx++
-		} else if oldIdx < len(oldLines) && (lcsIdx >= len(lcs) || oldLines[oldIdx] != lcs[lcsIdx]) {
+		} else if oldIdx < oldEnd && (lcsIdx >= len(lcs) || oldLines[oldIdx] != lcs[lcsIdx]) {
 			// Line was removed
 			result = append(result, DiffLine{
 				Type:    DiffLineRemoved,
@@ -229,7 +243,7 @@ func computeLineDiff(oldLines, newLines []string) []DiffLine {
 				NewLine: 0,
 			})
 			oldIdx++
-		} else if newIdx < len(newLines) {
+		} else if newIdx < newEnd {
 			// Line was added
 			result = append(result, DiffLine{
 				Type:    DiffLineAdded,
@@ -241,9 +255,41 @@ func computeLineDiff(oldLines, newLines []string) []DiffLine {
 		}
 	}
 
+	for i := 0; i < suffix; i++ {
+		result = append(result, DiffLine{
+			Type:    DiffLineContext,
+			Content: oldLines[oldEnd+i],
+			OldLine: oldEnd + i + 1,
+			NewLine: newEnd + i + 1,
+		})
+	}
+
 	return result
 }
 
+// commonAffixes returns the number of lines old and new share at the start
+// and, after that, at the end.
+func commonAffixes(oldLines, newLines []string) (prefix, suffix int) {
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix &&
+		oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+	return prefix, suffix
+}
+
+// DiffCost estimates the work ComputeDiff does for two contents: the size
+// of the LCS table over the lines between their common prefix and suffix.
+// Callers diffing untrusted or very large files can use it to skip them.
+func DiffCost(oldContent, newContent string) int {
+	oldLines := splitLines(oldContent)
+	newLines := splitLines(newContent)
+	prefix, suffix := commonAffixes(oldLines, newLines)
+	return (len(oldLines) - prefix - suffix) * (len(newLines) - prefix - suffix)
+}
+
 // computeLCS computes the Longest Common Subsequence of two string slices.
 // This is a simplified implementation for line-based diffing.
 func computeLCS(a, b []string) []string {
@@ -292,102 +338,100 @@ func max(a, b int) int {
 	return b
 }
 
-// groupIntoHunks groups diff lines into hunks with context.
-// Each hunk includes a few lines of context before and after changes.
+// groupIntoHunks groups diff lines into hunks with context. Changes separated
+// by no more than twice the context share a hunk, as in unified diff, so each
+// hunk is an independent change that can be reviewed on its own.
 func groupIntoHunks(diffLines []DiffLine, oldLines, newLines []string) []DiffHunk {
-	if len(diffLines) == 0 {
-		return nil
-	}
-
 	const contextLines = 3 // Number of context lines before/after changes
 
 	var hunks []DiffHunk
-	var currentHunk *DiffHunk
-
-	for i, line := range diffLines {
-		isChange := line.Type != DiffLineContext
-
-		if currentHunk == nil && isChange {
-			// Start a new hunk
-			currentHunk = &DiffHunk{}
-
-			// Add context before
-			contextStart := max(0, i-contextLines)
-			for j := contextStart; j < i; j++ {
-				currentHunk.Lines = append(currentHunk.Lines, diffLines[j])
-				// Count context lines
-				if diffLines[j].OldLine > 0 {
-					currentHunk.OldCount++
-				}
-				if diffLines[j].NewLine > 0 {
-					currentHunk.NewCount++
-				}
-			}
+	for i := 0; i < len(diffLines); {
+		if diffLines[i].Type == DiffLineContext {
+			i++
+			continue
+		}
 
-			// Set start positions based on first line in hunk (including context)
-			if len(currentHunk.Lines) > 0 {
-				firstLine := currentHunk.Lines[0]
-				if firstLine.OldLine > 0 {
-					currentHunk.OldStart = firstLine.OldLine
-				} else {
-					currentHunk.OldStart = line.OldLine
-				}
-				if firstLine.NewLine > 0 {
-					currentHunk.NewStart = firstLine.NewLine
-				} else {
-					currentHunk.NewStart = line.NewLine
-				}
-			} else {
-				currentHunk.OldStart = line.OldLine
-				currentHunk.NewStart = line.NewLine
+		// Extend over later changes that are close enough to merge
+		start := max(0, i-contextLines)
+		last := i
+		for j := i + 1; j < len(diffLines) && j <= last+2*contextLines+1; j++ {
+			if diffLines[j].Type != DiffLineContext {
+				last = j
 			}
 		}
+		end := last + contextLines + 1
+		if end > len(diffLines) {
+			end = len(diffLines)
+		}
+
+		hunks = append(hunks, newHunk(diffLines[start:end]))
+		i = end
+	}
 
-		if currentHunk != nil {
-			currentHunk.Lines = append(currentHunk.Lines, line)
+	return hunks
+}
 
-			// Update counts
-			if line.OldLine > 0 {
-				currentHunk.OldCount++
+// newHunk builds a hunk from a run of diff lines. The start positions are
+// those of the first old and new lines in the run; a side with no lines
+// (an empty file) starts at 0.
+func newHunk(lines []DiffLine) DiffHunk {
+	hunk := DiffHunk{Lines: append([]DiffLine(nil), lines...)}
+	for _, line := range lines {
+		if line.OldLine > 0 {
+			if hunk.OldCount == 0 {
+				hunk.OldStart = line.OldLine
 			}
-			if line.NewLine > 0 {
-				currentHunk.NewCount++
+			hunk.OldCount++
+		}
+		if line.NewLine > 0 {
+			if hunk.NewCount == 0 {
+				hunk.NewStart = line.NewLine
 			}
+			hunk.NewCount++
+		}
+	}
+	return hunk
+}
 
-			// Check if we should close this hunk
-			// Close if we've seen enough context lines after the last change
-			contextAfter := 0
-			for j := i + 1; j < len(diffLines) && j < i+1+contextLines; j++ {
-				if diffLines[j].Type != DiffLineContext {
-					contextAfter = -1 // More changes coming
-					break
-				}
-				contextAfter++
-			}
+// SplitHunk splits a hunk whose unified diff text would exceed maxChars into
+// consecutive smaller hunks. Pieces that hold only context lines are dropped.
+// A hunk that fits, or a maxChars of 0, is returned unchanged.
+func SplitHunk(hunk DiffHunk, maxChars int) []DiffHunk {
+	if maxChars <= 0 || hunkSize(hunk.Lines) <= maxChars {
+		return []DiffHunk{hunk}
+	}
 
-			if contextAfter >= 0 && (i == len(diffLines)-1 || contextAfter < contextLines) {
-				// Add remaining context and close hunk
-				for j := i + 1; j <= i+contextAfter && j < len(diffLines); j++ {
-					currentHunk.Lines = append(currentHunk.Lines, diffLines[j])
-					if diffLines[j].OldLine > 0 {
-						currentHunk.OldCount++
-					}
-					if diffLines[j].NewLine > 0 {
-						currentHunk.NewCount++
-					}
-				}
-				hunks = append(hunks, *currentHunk)
-				currentHunk = nil
+	var pieces []DiffHunk
+	flush := func(lines []DiffLine) {
+		for _, line := range lines {
+			if line.Type != DiffLineContext {
+				pieces = append(pieces, newHunk(lines))
+				return
 			}
 		}
 	}
 
-	// Close any remaining hunk
-	if currentHunk != nil {
-		hunks = append(hunks, *currentHunk)
+	start, size := 0, 0
+	for i, line := range hunk.Lines {
+		lineSize := len(line.Content) + 2
+		if i > start && size+lineSize > maxChars {
+			flush(hunk.Lines[start:i])
+			start, size = i, 0
+		}
+		size += lineSize
 	}
+	flush(hunk.Lines[start:])
+	return pieces
+}
 
-	return hunks
+// hunkSize estimates the size of lines in unified diff format: each line
+// carries a prefix character and a newline.
+func hunkSize(lines []DiffLine) int {
+	size := 0
+	for _, line := range lines {
+		size += len(line.Content) + 2
+	}
+	return size
 }
 
 // =============================================================================
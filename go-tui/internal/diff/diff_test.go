@@ -301,3 +301,70 @@ func TestGroupIntoHunks(t *testing.T) {
 		t.Error("Hunks should contain changed lines")
 	}
 }
+
+func TestSplitHunk(t *testing.T) {
+	var oldLines, newLines []string
+	for i := 0; i < 40; i++ {
+		oldLines = append(oldLines, "unchanged line")
+		newLines = append(newLines, "unchanged line")
+	}
+	newLines[5] = "changed near the top"
+	newLines[35] = "changed near the bottom"
+	d := ComputeDiff("big.txt", strings.Join(oldLines, "\n"), strings.Join(newLines, "\n"))
+	if len(d.Hunks) != 2 {
+		t.Fatalf("expected 2 hunks, got %d", len(d.Hunks))
+	}
+
+	// A hunk that fits is returned as is
+	if pieces := SplitHunk(d.Hunks[0], 0); len(pieces) != 1 {
+		t.Errorf("SplitHunk(0) = %d pieces, want 1", len(pieces))
+	}
+
+	// Joining the two changes into one hunk and splitting it again keeps
+	// only the pieces with changes, each with its own line numbers
+	all := newHunk(computeLineDiff(oldLines, newLines))
+	pieces := SplitHunk(all, 200)
+	if len(pieces) < 2 {
+		t.Fatalf("SplitHunk() = %d pieces, want at least 2", len(pieces))
+	}
+	var changed []int
+	for _, p := range pieces {
+		if hunkSize(p.Lines) > 200 {
+			t.Errorf("piece of %d chars exceeds the limit", hunkSize(p.Lines))
+		}
+		for _, line := range p.Lines {
+			if line.Type == DiffLineAdded {
+				changed = append(changed, line.NewLine)
+				if p.NewStart > line.NewLine || line.NewLine >= p.NewStart+p.NewCount {
+					t.Errorf("line %d lies outside piece +%d,%d", line.NewLine, p.NewStart, p.NewCount)
+				}
+			}
+		}
+	}
+	if len(changed) != 2 || changed[0] != 6 || changed[1] != 36 {
+		t.Errorf("added lines across pieces = %v, want [6 36]", changed)
+	}
+}
+
+func TestComputeDiff_CommonAffixes(t *testing.T) {
+	var oldLines []string
+	for i := 0; i < 3000; i++ {
+		oldLines = append(oldLines, "line")
+	}
+	newLines := append([]string(nil), oldLines...)
+	newLines[1500] = "changed"
+	oldContent, newContent := strings.Join(oldLines, "\n"), strings.Join(newLines, "\n")
+
+	// Only the changed middle needs the LCS table
+	if cost := DiffCost(oldContent, newContent); cost != 1 {
+		t.Errorf("DiffCost() = %d, want 1", cost)
+	}
+
+	d := ComputeDiff("big.txt", oldContent, newContent)
+	if d.Stats.Additions != 1 || d.Stats.Deletions != 1 || len(d.Hunks) != 1 {
+		t.Fatalf("unexpected diff: %+v, %d hunks", d.Stats, len(d.Hunks))
+	}
+	if h := d.Hunks[0]; h.OldStart != 1498 || h.NewStart != 1498 || h.OldCount != 7 || h.NewCount != 7 {
+		t.Errorf("hunk = -%d,%d +%d,%d, want -1498,7 +1498,7", h.OldStart, h.OldCount, h.NewStart, h.NewCount)
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package diff provides diff computation and formatting for file changes.
+//
+// This file implements hunk-level review: each hunk of a pending change can
+// be accepted, rejected or replaced with edited text, and the reviewed
+// content is rebuilt from the original with only the chosen hunks applied.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// =============================================================================
+// HUNK DECISIONS
+// =============================================================================
+
+// HunkDecision is the reviewer's decision for one hunk.
+type HunkDecision int
+
+const (
+	// HunkPending means the hunk has not been reviewed; it is not applied
+	HunkPending HunkDecision = iota
+	// HunkAccepted applies the hunk as proposed
+	HunkAccepted
+	// HunkRejected keeps the original lines
+	HunkRejected
+	// HunkEdited applies the reviewer's replacement text
+	HunkEdited
+)
+
+// String returns the string representation of a hunk decision.
+func (d HunkDecision) String() string {
+	switch d {
+	case HunkAccepted:
+		return "accepted"
+	case HunkRejected:
+		return "rejected"
+	case HunkEdited:
+		return "edited"
+	default:
+		return "pending"
+	}
+}
+
+// OldSide returns the lines the hunk replaces (context and removed lines).
+func (h DiffHunk) OldSide() []string {
+	var lines []string
+	for _, line := range h.Lines {
+		if line.Type != DiffLineAdded {
+			lines = append(lines, line.Content)
+		}
+	}
+	return lines
+}
+
+// NewSide returns the lines the hunk proposes (context and added lines).
+func (h DiffHunk) NewSide() []string {
+	var lines []string
+	for _, line := range h.Lines {
+		if line.Type != DiffLineRemoved {
+			lines = append(lines, line.Content)
+		}
+	}
+	return lines
+}
+
+// =============================================================================
+// REVIEW
+// =============================================================================
+
+// Review records the decisions for the hunks of a diff.
+type Review struct {
+	Diff      *Diff
+	Decisions []HunkDecision // One per hunk
+	Edits     [][]string     // Replacement lines for edited hunks
+}
+
+// NewReview creates a review with every hunk pending.
+func NewReview(d *Diff) *Review {
+	return &Review{
+		Diff:      d,
+		Decisions: make([]HunkDecision, len(d.Hunks)),
+		Edits:     make([][]string, len(d.Hunks)),
+	}
+}
+
+// Accept marks a hunk as accepted.
+func (r *Review) Accept(i int) {
+	r.set(i, HunkAccepted, nil)
+}
+
+// Reject marks a hunk as rejected.
+func (r *Review) Reject(i int) {
+	r.set(i, HunkRejected, nil)
+}
+
+// Edit replaces a hunk with the given lines. The lines stand in for the
+// hunk's whole new side, context included.
+func (r *Review) Edit(i int, lines []string) {
+	r.set(i, HunkEdited, lines)
+}
+
+// SetAll gives every hunk the same decision.
+func (r *Review) SetAll(decision HunkDecision) {
+	for i := range r.Decisions {
+		r.set(i, decision, nil)
+	}
+}
+
+func (r *Review) set(i int, decision HunkDecision, lines []string) {
+	if i < 0 || i >= len(r.Decisions) {
+		return
+	}
+	r.Decisions[i] = decision
+	r.Edits[i] = lines
+}
+
+// Pending returns the number of hunks without a decision.
+func (r *Review) Pending() int {
+	return r.count(HunkPending)
+}
+
+// Applied returns the number of hunks that will be applied.
+func (r *Review) Applied() int {
+	return r.count(HunkAccepted) + r.count(HunkEdited)
+}
+
+// AllAccepted reports whether every hunk was accepted unchanged, so the
+// proposed content can be written as is.
+func (r *Review) AllAccepted() bool {
+	return r.count(HunkAccepted) == len(r.Decisions)
+}
+
+func (r *Review) count(decision HunkDecision) int {
+	n := 0
+	for _, d := range r.Decisions {
+		if d == decision {
+			n++
+		}
+	}
+	return n
+}
+
+// Content returns the file content with the accepted and edited hunks
+// applied to the original. Pending hunks are not applied.
+func (r *Review) Content() string {
+	if r.AllAccepted() {
+		return r.Diff.NewContent
+	}
+	if r.Applied() == 0 {
+		return r.Diff.OldContent
+	}
+
+	oldLines := splitLines(r.Diff.OldContent)
+	var out []string
+	pos := 0
+	for i, hunk := range r.Diff.Hunks {
+		start := pos
+		if hunk.OldCount > 0 {
+			start = hunk.OldStart - 1
+		}
+		if start < pos || start+hunk.OldCount > len(oldLines) {
+			// Hunks always come from ComputeDiff; guard against a mismatch
+			// rather than writing a corrupt file
+			return r.Diff.OldContent
+		}
+		out = append(out, oldLines[pos:start]...)
+
+		switch r.Decisions[i] {
+		case HunkAccepted:
+			out = append(out, hunk.NewSide()...)
+		case HunkEdited:
+			out = append(out, r.Edits[i]...)
+		default:
+			out = append(out, hunk.OldSide()...)
+		}
+		pos = start + hunk.OldCount
+	}
+	out = append(out, oldLines[pos:]...)
+
+	if len(out) == 0 {
+		return ""
+	}
+	content := strings.Join(out, "\n")
+	if strings.HasSuffix(r.Diff.NewContent, "\n") ||
+		(r.Diff.NewContent == "" && strings.HasSuffix(r.Diff.OldContent, "\n")) {
+		content += "\n"
+	}
+	return content
+}
+
+// Summary describes which hunks were applied, for the tool result sent back
+// to the model. Edited hunks include the text that was written instead.
+func (r *Review) Summary() string {
+	var sb strings.Builder
+	total := len(r.Decisions)
+	switch applied := r.Applied(); {
+	case total == 0:
+		fmt.Fprintf(&sb, "%s: no changes to review\n", r.Diff.FilePath)
+	case applied == 0:
+		fmt.Fprintf(&sb, "%s: the user rejected all %d hunk(s); the file was not modified\n", r.Diff.FilePath, total)
+	default:
+		fmt.Fprintf(&sb, "%s: applied %d of %d hunk(s) after review\n", r.Diff.FilePath, applied, total)
+	}
+
+	for i, hunk := range r.Diff.Hunks {
+		decision := r.Decisions[i]
+		if decision == HunkPending {
+			decision = HunkRejected
+		}
+		fmt.Fprintf(&sb, "  hunk %d (@@ -%d,%d +%d,%d @@): %s\n",
+			i+1, hunk.OldStart, hunk.OldCount, hunk.NewStart, hunk.NewCount, decision)
+		if decision == HunkEdited {
+			sb.WriteString("    written as:\n")
+			for _, line := range r.Edits[i] {
+				sb.WriteString("    | " + line + "\n")
+			}
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
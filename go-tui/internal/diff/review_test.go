@@ -0,0 +1,125 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package diff provides diff computation and formatting for file changes.
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// twoChangeFile returns a 30-line file and a copy with lines 5 and 20 changed.
+func twoChangeFile() (string, string) {
+	var oldLines, newLines []string
+	for i := 0; i < 30; i++ {
+		oldLines = append(oldLines, fmt.Sprintf("line%d", i))
+		newLines = append(newLines, fmt.Sprintf("line%d", i))
+	}
+	newLines[5] = "changed5"
+	newLines[20] = "changed20"
+	return strings.Join(oldLines, "\n") + "\n", strings.Join(newLines, "\n") + "\n"
+}
+
+func TestComputeDiff_SeparateHunks(t *testing.T) {
+	oldContent, newContent := twoChangeFile()
+	d := ComputeDiff("test.txt", oldContent, newContent)
+
+	if len(d.Hunks) != 2 {
+		t.Fatalf("Expected 2 hunks for distant changes, got %d", len(d.Hunks))
+	}
+	first := d.Hunks[0]
+	if first.OldStart != 3 || first.OldCount != 7 || first.NewStart != 3 || first.NewCount != 7 {
+		t.Errorf("Unexpected first hunk header: -%d,%d +%d,%d",
+			first.OldStart, first.OldCount, first.NewStart, first.NewCount)
+	}
+}
+
+func TestComputeDiff_NearbyChangesShareHunk(t *testing.T) {
+	oldContent := "a\nb\nc\nd\ne\nf\ng\nh\n"
+	newContent := "A\nb\nc\nd\ne\nf\ng\nH\n"
+	d := ComputeDiff("test.txt", oldContent, newContent)
+
+	if len(d.Hunks) != 1 {
+		t.Errorf("Expected changes 6 lines apart to share a hunk, got %d hunks", len(d.Hunks))
+	}
+}
+
+func TestReview_Content(t *testing.T) {
+	oldContent, newContent := twoChangeFile()
+	d := ComputeDiff("test.txt", oldContent, newContent)
+
+	tests := []struct {
+		name   string
+		review func(r *Review)
+		want   string
+	}{
+		{"all accepted", func(r *Review) { r.SetAll(HunkAccepted) }, newContent},
+		{"all rejected", func(r *Review) { r.SetAll(HunkRejected) }, oldContent},
+		{"pending", func(r *Review) {}, oldContent},
+		{
+			"first only",
+			func(r *Review) { r.Accept(0); r.Reject(1) },
+			strings.Replace(oldContent, "line5\n", "changed5\n", 1),
+		},
+		{
+			"second only",
+			func(r *Review) { r.Reject(0); r.Accept(1) },
+			strings.Replace(oldContent, "line20\n", "changed20\n", 1),
+		},
+		{
+			"edited",
+			func(r *Review) {
+				lines := d.Hunks[0].NewSide()
+				lines[3] = "edited5"
+				r.Edit(0, lines)
+			},
+			strings.Replace(oldContent, "line5\n", "edited5\n", 1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewReview(d)
+			tt.review(r)
+			if got := r.Content(); got != tt.want {
+				t.Errorf("Content() mismatch\ngot:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReview_ContentNewFile(t *testing.T) {
+	d := ComputeDiff("new.txt", "", "one\ntwo\n")
+	r := NewReview(d)
+	r.Edit(0, []string{"only"})
+
+	if got := r.Content(); got != "only\n" {
+		t.Errorf("Content() = %q, want %q", got, "only\n")
+	}
+}
+
+func TestReview_Summary(t *testing.T) {
+	oldContent, newContent := twoChangeFile()
+	d := ComputeDiff("test.txt", oldContent, newContent)
+
+	r := NewReview(d)
+	r.Accept(0)
+	summary := r.Summary()
+	for _, want := range []string{"applied 1 of 2", "hunk 1 (@@ -3,7 +3,7 @@): accepted", "hunk 2", "rejected"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("Summary() missing %q:\n%s", want, summary)
+		}
+	}
+
+	r.SetAll(HunkRejected)
+	if !strings.Contains(r.Summary(), "not modified") {
+		t.Errorf("Summary() should say the file was not modified:\n%s", r.Summary())
+	}
+
+	r.Edit(1, []string{"replacement"})
+	if !strings.Contains(r.Summary(), "| replacement") {
+		t.Errorf("Summary() should include edited text:\n%s", r.Summary())
+	}
+}
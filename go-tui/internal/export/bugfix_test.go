@@ -336,3 +336,57 @@ func TestYAMLBackslashEscaping(t *testing.T) {
 		t.Error("Backslashes not properly escaped in YAML (should be quoted)")
 	}
 }
+
+// TestClassificationMarkings tests that marked conversations are exported with
+// banners at the high-water mark and per-message portion markings.
+func TestClassificationMarkings(t *testing.T) {
+	conv := &storage.StoredConversation{
+		ID:        "test-class",
+		Summary:   "Marked",
+		Model:     "test",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Messages: []storage.StoredMessage{
+			{ID: "msg1", Role: "user", Content: "budget", Classification: "CUI", Timestamp: time.Now()},
+			{ID: "msg2", Role: "assistant", Content: "details", Classification: "SECRET", Timestamp: time.Now()},
+		},
+	}
+
+	md, err := NewMarkdownExporter(nil).Export(conv)
+	if err != nil {
+		t.Fatalf("Markdown export failed: %v", err)
+	}
+	mdText := string(md)
+	if strings.Count(mdText, "**SECRET**") != 2 {
+		t.Error("Markdown export should have top and bottom SECRET banners")
+	}
+	if !strings.Contains(mdText, "classification: SECRET") {
+		t.Error("Markdown frontmatter should include classification")
+	}
+	if !strings.Contains(mdText, "(CUI) [User]") || !strings.Contains(mdText, "(S) [Assistant]") {
+		t.Error("Markdown export should include portion markings")
+	}
+
+	htmlOut, err := NewHTMLExporter(nil).Export(conv)
+	if err != nil {
+		t.Fatalf("HTML export failed: %v", err)
+	}
+	htmlText := string(htmlOut)
+	if strings.Count(htmlText, "class=\"classification-banner\"") != 2 {
+		t.Error("HTML export should have top and bottom classification banners")
+	}
+	if !strings.Contains(htmlText, "<span class=\"portion-marking\">(CUI)</span>") {
+		t.Error("HTML export should include portion markings")
+	}
+
+	// Unmarked conversations are exported without banners
+	conv.Messages[0].Classification = ""
+	conv.Messages[1].Classification = ""
+	md, err = NewMarkdownExporter(nil).Export(conv)
+	if err != nil {
+		t.Fatalf("Markdown export failed: %v", err)
+	}
+	if strings.Contains(string(md), "classification:") {
+		t.Error("Unmarked conversation should not carry a classification")
+	}
+}
@@ -21,14 +21,56 @@ func ConvertModelToStored(conv *model.Conversation) *storage.StoredConversation
 		return nil
 	}
 
-	// Convert messages
-	messages := make([]storage.StoredMessage, 0, len(conv.Messages))
+	messages := convertMessages(conv.Messages)
+
+	// Extract context mentions (deduplicated)
+	mentionsMap := make(map[string]bool)
 	for _, msg := range conv.Messages {
+		for _, mention := range msg.ContextMentions {
+			mentionsMap[mention] = true
+		}
+	}
+	mentions := make([]string, 0, len(mentionsMap))
+	for mention := range mentionsMap {
+		mentions = append(mentions, mention)
+	}
+
+	stored := &storage.StoredConversation{
+		ID:             conv.ID,
+		Summary:        conv.GetTitle(),
+		Model:          conv.Model,
+		CreatedAt:      conv.CreatedAt,
+		UpdatedAt:      conv.UpdatedAt,
+		Messages:       messages,
+		Branches:       convertMessages(conv.Branches),
+		TokensUsed:     conv.TokensUsed,
+		Mentions:       mentions,
+		Classification: conv.Classification,
+	}
+
+	// Carry the high-water mark so exports are bannered at the right level
+	if stored.IsMarked() {
+		stored.Classification = conv.HighWaterMark().String()
+	}
+
+	return stored
+}
+
+// convertMessages converts model messages to stored messages.
+func convertMessages(msgs []*model.Message) []storage.StoredMessage {
+	if msgs == nil {
+		return nil
+	}
+	messages := make([]storage.StoredMessage, 0, len(msgs))
+	for _, msg := range msgs {
 		storedMsg := storage.StoredMessage{
 			ID:        msg.ID,
 			Role:      string(msg.Role),
 			Content:   msg.GetDisplayContent(),
 			Timestamp: msg.Timestamp,
+			ParentID:  msg.ParentID,
+
+			Classification: msg.Classification,
 		}
 
 		// Copy statistics for assistant messages
@@ -49,29 +91,7 @@ func ConvertModelToStored(conv *model.Conversation) *storage.StoredConversation
 
 		messages = append(messages, storedMsg)
 	}
-
-	// Extract context mentions (deduplicated)
-	mentionsMap := make(map[string]bool)
-	for _, msg := range conv.Messages {
-		for _, mention := range msg.ContextMentions {
-			mentionsMap[mention] = true
-		}
-	}
-	mentions := make([]string, 0, len(mentionsMap))
-	for mention := range mentionsMap {
-		mentions = append(mentions, mention)
-	}
-
-	return &storage.StoredConversation{
-		ID:         conv.ID,
-		Summary:    conv.GetTitle(),
-		Model:      conv.Model,
-		CreatedAt:  conv.CreatedAt,
-		UpdatedAt:  conv.UpdatedAt,
-		Messages:   messages,
-		TokensUsed: conv.TokensUsed,
-		Mentions:   mentions,
-	}
+	return messages
 }
 
 // ExportModelConversation exports a model.Conversation directly.
@@ -13,6 +13,7 @@ import (
 	"runtime"
 	"time"
 
+	"github.com/jeranaias/rigrun-tui/internal/security"
 	"github.com/jeranaias/rigrun-tui/internal/storage"
 )
 
@@ -54,6 +55,11 @@ type Options struct {
 	// Theme for HTML export ("light" or "dark").
 	// Default: "dark"
 	Theme string
+
+	// IncludeBranches exports the inactive branches of a branched
+	// conversation after the active one.
+	// Default: false (active branch only)
+	IncludeBranches bool
 }
 
 // DefaultOptions returns default export options.
@@ -203,6 +209,24 @@ func openFile(path string) error {
 	return cmd.Start()
 }
 
+// classificationBanner returns the banner marking for an exported conversation,
+// or "" if the conversation carries no classification markings.
+func classificationBanner(conv *storage.StoredConversation) string {
+	if !conv.IsMarked() {
+		return ""
+	}
+	return conv.HighWaterMark().String()
+}
+
+// portionMarking returns the portion marking for a message in a marked
+// conversation, or "" if the conversation is unmarked.
+func portionMarking(conv *storage.StoredConversation, msg *storage.StoredMessage) string {
+	if !conv.IsMarked() {
+		return ""
+	}
+	return security.PortionMarkingFor(msg.Classification)
+}
+
 // formatDuration formats a duration in milliseconds to a human-readable string.
 func formatDuration(ms int64) string {
 	if ms < 1000 {
@@ -0,0 +1,78 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package export
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeranaias/rigrun-tui/internal/storage"
+)
+
+// branchedConversation returns a conversation whose second question was
+// edited, leaving the original exchange on an inactive branch.
+func branchedConversation() *storage.StoredConversation {
+	now := time.Now()
+	return &storage.StoredConversation{
+		ID:        "branched",
+		Summary:   "Branched",
+		CreatedAt: now,
+		UpdatedAt: now,
+		Messages: []storage.StoredMessage{
+			{ID: "q1", Role: "user", Content: "first question", Timestamp: now},
+			{ID: "a1", Role: "assistant", ParentID: "q1", Content: "first answer", Timestamp: now},
+			{ID: "q2b", Role: "user", ParentID: "a1", Content: "edited question", Timestamp: now},
+		},
+		Branches: []storage.StoredMessage{
+			{ID: "q2a", Role: "user", ParentID: "a1", Content: "original question", Timestamp: now},
+			{ID: "a2a", Role: "assistant", ParentID: "q2a", Content: "original answer", Timestamp: now},
+		},
+	}
+}
+
+func TestExport_ActiveBranchByDefault(t *testing.T) {
+	exporters := map[string]Exporter{
+		"markdown": NewMarkdownExporter(nil),
+		"html":     NewHTMLExporter(nil),
+		"json":     NewJSONExporter(nil),
+	}
+	for name, exporter := range exporters {
+		output, err := exporter.Export(branchedConversation())
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !strings.Contains(string(output), "edited question") {
+			t.Errorf("%s export is missing the active branch", name)
+		}
+		if strings.Contains(string(output), "original answer") {
+			t.Errorf("%s export should not include inactive branches by default", name)
+		}
+	}
+}
+
+func TestExport_IncludeBranches(t *testing.T) {
+	opts := DefaultOptions()
+	opts.IncludeBranches = true
+
+	exporters := map[string]Exporter{
+		"markdown": NewMarkdownExporter(opts),
+		"html":     NewHTMLExporter(opts),
+		"json":     NewJSONExporter(opts),
+	}
+	for name, exporter := range exporters {
+		output, err := exporter.Export(branchedConversation())
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !strings.Contains(string(output), "original answer") {
+			t.Errorf("%s export is missing the inactive branch", name)
+		}
+	}
+
+	output, _ := NewMarkdownExporter(opts).Export(branchedConversation())
+	if !strings.Contains(string(output), `## Branch 1`) || !strings.Contains(string(output), `after assistant message "first answer"`) {
+		t.Errorf("markdown should label the branch and where it forks:\n%s", output)
+	}
+}
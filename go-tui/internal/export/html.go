@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/jeranaias/rigrun-tui/internal/security"
 	"github.com/jeranaias/rigrun-tui/internal/storage"
 )
 
@@ -61,6 +62,12 @@ func (e *HTMLExporter) Export(conv *storage.StoredConversation) ([]byte, error)
 	sb.WriteString("</head>\n")
 	sb.WriteString(fmt.Sprintf("<body class=\"%s-theme\">\n", e.options.Theme))
 
+	// Top classification banner (DoDI 5200.48)
+	banner := classificationBanner(conv)
+	if banner != "" {
+		sb.WriteString(e.renderBanner(banner))
+	}
+
 	// Container
 	sb.WriteString("    <div class=\"container\">\n")
 
@@ -72,10 +79,23 @@ func (e *HTMLExporter) Export(conv *storage.StoredConversation) ([]byte, error)
 	// Conversation messages
 	sb.WriteString("        <main class=\"conversation\">\n")
 	for _, msg := range conv.Messages {
-		sb.WriteString(e.renderMessage(&msg))
+		sb.WriteString(e.renderMessage(&msg, portionMarking(conv, &msg)))
 	}
 	sb.WriteString("        </main>\n")
 
+	// Inactive branches
+	if e.options.IncludeBranches {
+		for n, thread := range conv.BranchThreads() {
+			sb.WriteString("        <section class=\"conversation branch\">\n")
+			sb.WriteString(fmt.Sprintf("            <h2 class=\"branch-title\">Branch %d <small>forks %s</small></h2>\n",
+				n+1, html.EscapeString(conv.BranchOrigin(thread))))
+			for _, msg := range thread.Messages {
+				sb.WriteString(e.renderMessage(&msg, portionMarking(conv, &msg)))
+			}
+			sb.WriteString("        </section>\n")
+		}
+	}
+
 	// Footer
 	sb.WriteString("        <footer class=\"footer\">\n")
 	sb.WriteString(fmt.Sprintf("            <p>Exported from <strong>rigrun TUI</strong> on %s</p>\n",
@@ -84,6 +104,11 @@ func (e *HTMLExporter) Export(conv *storage.StoredConversation) ([]byte, error)
 
 	sb.WriteString("    </div>\n")
 
+	// Bottom classification banner
+	if banner != "" {
+		sb.WriteString(e.renderBanner(banner))
+	}
+
 	// Theme toggle script
 	sb.WriteString(e.getScript())
 
@@ -127,8 +152,18 @@ func (e *HTMLExporter) renderHeader(conv *storage.StoredConversation) string {
 	return sb.String()
 }
 
-// renderMessage renders a single message.
-func (e *HTMLExporter) renderMessage(msg *storage.StoredMessage) string {
+// renderBanner renders a classification banner using the standard banner colors.
+func (e *HTMLExporter) renderBanner(marking string) string {
+	c, err := security.ParseClassification(marking)
+	if err != nil {
+		c = security.Classification{Level: security.ClassificationTopSecret}
+	}
+	return fmt.Sprintf("    <div class=\"classification-banner\" style=\"background-color: %s;\">%s</div>\n",
+		string(c.Level.Color()), html.EscapeString(marking))
+}
+
+// renderMessage renders a single message with an optional portion marking.
+func (e *HTMLExporter) renderMessage(msg *storage.StoredMessage, portion string) string {
 	var sb strings.Builder
 
 	roleClass := strings.ToLower(msg.Role)
@@ -136,6 +171,9 @@ func (e *HTMLExporter) renderMessage(msg *storage.StoredMessage) string {
 
 	// Message header
 	sb.WriteString("                <div class=\"message-header\">\n")
+	if portion != "" {
+		sb.WriteString(fmt.Sprintf("                    <span class=\"portion-marking\">%s</span>\n", html.EscapeString(portion)))
+	}
 	sb.WriteString(fmt.Sprintf("                    <span class=\"role-label\">%s</span>\n", e.getRoleLabel(msg.Role)))
 	if e.options.IncludeTimestamps {
 		sb.WriteString(fmt.Sprintf("                    <span class=\"timestamp\">%s</span>\n", formatShortTimestamp(msg.Timestamp)))
@@ -444,6 +482,15 @@ func (e *HTMLExporter) getCSS() string {
             padding: 24px 32px;
         }
 
+        .branch {
+            border-top: 1px dashed var(--border-color);
+        }
+
+        .branch-title {
+            margin-bottom: 16px;
+            font-size: 1.1em;
+        }
+
         .message {
             margin-bottom: 24px;
             padding: 20px;
@@ -606,6 +653,20 @@ func (e *HTMLExporter) getCSS() string {
             }
         }
 
+        /* Classification markings */
+        .classification-banner {
+            color: #ffffff;
+            font-weight: bold;
+            text-align: center;
+            padding: 4px;
+            letter-spacing: 1px;
+        }
+
+        .portion-marking {
+            font-weight: bold;
+            margin-right: 8px;
+        }
+
         /* Responsive */
         @media (max-width: 768px) {
             body {
@@ -16,7 +16,8 @@ import (
 
 // JSONExporter exports conversations to JSON format.
 // NOTE: JSON exports always include the complete conversation data structure
-// and do not respect filtering options. This ensures the exported JSON is a
+// and do not respect filtering options, except that inactive branches are
+// only included with IncludeBranches. This ensures the exported JSON is a
 // faithful representation of the stored conversation that can be re-imported.
 type JSONExporter struct {
 	// Options are accepted but currently not used for filtering.
@@ -35,13 +36,20 @@ func NewJSONExporter(opts *Options) *JSONExporter {
 }
 
 // Export converts a conversation to JSON format.
-// NOTE: This always exports the complete conversation regardless of options.
+// NOTE: This exports the complete conversation regardless of options, apart
+// from IncludeBranches.
 func (e *JSONExporter) Export(conv *storage.StoredConversation) ([]byte, error) {
 	// Validate conversation data
 	if conv == nil {
 		return nil, fmt.Errorf("conversation is nil")
 	}
 
+	if !e.options.IncludeBranches && len(conv.Branches) > 0 {
+		activeOnly := *conv
+		activeOnly.Branches = nil
+		conv = &activeOnly
+	}
+
 	return json.MarshalIndent(conv, "", "  ")
 }
 
@@ -42,11 +42,15 @@ func (e *MarkdownExporter) Export(conv *storage.StoredConversation) ([]byte, err
 	}
 
 	var sb strings.Builder
+	banner := classificationBanner(conv)
 
 	// YAML frontmatter with metadata
 	if e.options.IncludeMetadata {
 		sb.WriteString("---\n")
 		sb.WriteString(fmt.Sprintf("title: %s\n", escapeYAML(conv.Summary)))
+		if banner != "" {
+			sb.WriteString(fmt.Sprintf("classification: %s\n", escapeYAML(banner)))
+		}
 		sb.WriteString(fmt.Sprintf("model: %s\n", conv.Model))
 		sb.WriteString(fmt.Sprintf("date: %s\n", conv.CreatedAt.Format(time.RFC3339)))
 		sb.WriteString(fmt.Sprintf("updated: %s\n", conv.UpdatedAt.Format(time.RFC3339)))
@@ -59,6 +63,11 @@ func (e *MarkdownExporter) Export(conv *storage.StoredConversation) ([]byte, err
 		sb.WriteString("---\n\n")
 	}
 
+	// Top classification banner (DoDI 5200.48)
+	if banner != "" {
+		sb.WriteString(fmt.Sprintf("**%s**\n\n", banner))
+	}
+
 	// Title
 	sb.WriteString(fmt.Sprintf("# %s\n\n", escapeMarkdown(conv.Summary)))
 
@@ -81,35 +90,8 @@ func (e *MarkdownExporter) Export(conv *storage.StoredConversation) ([]byte, err
 	// Conversation messages
 	sb.WriteString("## Conversation\n\n")
 
-	for i, msg := range conv.Messages {
-		// Role label with timestamp
-		roleLabel := e.formatRoleLabel(msg.Role)
-		if e.options.IncludeTimestamps {
-			sb.WriteString(fmt.Sprintf("### %s <sub>%s</sub>\n\n",
-				roleLabel,
-				formatShortTimestamp(msg.Timestamp)))
-		} else {
-			sb.WriteString(fmt.Sprintf("### %s\n\n", roleLabel))
-		}
-
-		// Message content
-		content := msg.Content
-		if content == "" && msg.Role == "tool" {
-			content = e.formatToolMessage(&msg)
-		}
-
-		// Write content with proper code block handling
-		sb.WriteString(e.formatMessageContent(content))
-		sb.WriteString("\n\n")
-
-		// Statistics for assistant messages
-		if msg.Role == "assistant" && e.options.IncludeMetadata {
-			stats := e.formatMessageStats(&msg)
-			if stats != "" {
-				sb.WriteString(stats)
-				sb.WriteString("\n\n")
-			}
-		}
+	for i := range conv.Messages {
+		e.writeMessage(&sb, conv, &conv.Messages[i])
 
 		// Add separator between messages (except last)
 		if i < len(conv.Messages)-1 {
@@ -117,14 +99,64 @@ func (e *MarkdownExporter) Export(conv *storage.StoredConversation) ([]byte, err
 		}
 	}
 
+	// Inactive branches, one section per thread
+	if e.options.IncludeBranches {
+		for n, thread := range conv.BranchThreads() {
+			sb.WriteString(fmt.Sprintf("\n---\n\n## Branch %d\n\n*Forks %s*\n\n", n+1, escapeMarkdown(conv.BranchOrigin(thread))))
+			for i := range thread.Messages {
+				e.writeMessage(&sb, conv, &thread.Messages[i])
+			}
+		}
+	}
+
 	// Footer
 	sb.WriteString("\n---\n\n")
 	sb.WriteString(fmt.Sprintf("*Exported from rigrun TUI on %s*\n",
 		time.Now().Format("January 2, 2006 at 3:04 PM")))
 
+	// Bottom classification banner
+	if banner != "" {
+		sb.WriteString(fmt.Sprintf("\n**%s**\n", banner))
+	}
+
 	return []byte(sb.String()), nil
 }
 
+// writeMessage writes one message under a role heading.
+func (e *MarkdownExporter) writeMessage(sb *strings.Builder, conv *storage.StoredConversation, msg *storage.StoredMessage) {
+	// Role label with timestamp, prefixed by the portion marking
+	roleLabel := e.formatRoleLabel(msg.Role)
+	if mark := portionMarking(conv, msg); mark != "" {
+		roleLabel = mark + " " + roleLabel
+	}
+	if e.options.IncludeTimestamps {
+		sb.WriteString(fmt.Sprintf("### %s <sub>%s</sub>\n\n",
+			roleLabel,
+			formatShortTimestamp(msg.Timestamp)))
+	} else {
+		sb.WriteString(fmt.Sprintf("### %s\n\n", roleLabel))
+	}
+
+	// Message content
+	content := msg.Content
+	if content == "" && msg.Role == "tool" {
+		content = e.formatToolMessage(msg)
+	}
+
+	// Write content with proper code block handling
+	sb.WriteString(e.formatMessageContent(content))
+	sb.WriteString("\n\n")
+
+	// Statistics for assistant messages
+	if msg.Role == "assistant" && e.options.IncludeMetadata {
+		stats := e.formatMessageStats(msg)
+		if stats != "" {
+			sb.WriteString(stats)
+			sb.WriteString("\n\n")
+		}
+	}
+}
+
 // FileExtension returns the file extension for Markdown.
 func (e *MarkdownExporter) FileExtension() string {
 	return ".md"
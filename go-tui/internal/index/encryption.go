@@ -0,0 +1,128 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package index provides codebase indexing for fast symbol search.
+package index
+
+import (
+	"github.com/jeranaias/rigrun-tui/internal/security"
+)
+
+// =============================================================================
+// ENCRYPTION (SC-28)
+// =============================================================================
+
+// Compile-time check that CodebaseIndex is a protected store.
+var _ security.ProtectedStore = (*CodebaseIndex)(nil)
+
+// open decrypts stored values in place
+func (idx *CodebaseIndex) open(values ...*string) error {
+	for _, v := range values {
+		opened, err := security.OpenField(idx.cipher, *v)
+		if err != nil {
+			return err
+		}
+		*v = opened
+	}
+	return nil
+}
+
+// EncryptionStatus counts the symbol signatures and docs, and how many are
+// encrypted
+func (idx *CodebaseIndex) EncryptionStatus() (security.StoreStatus, error) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	status := security.StoreStatus{Name: "Codebase index", Path: idx.config.DatabasePath}
+	for _, column := range []string{"signature", "doc"} {
+		var items, encrypted int
+		err := idx.db.QueryRow(`
+			SELECT COUNT(*), COALESCE(SUM(substr(`+column+`, 1, 4) = 'ENC:'), 0)
+			FROM symbols WHERE COALESCE(`+column+`, '') != ''
+		`).Scan(&items, &encrypted)
+		if err != nil {
+			return status, err
+		}
+		status.Items += items
+		status.Encrypted += encrypted
+	}
+	return status, nil
+}
+
+// Reencrypt replaces each symbol signature and doc with recrypt's result,
+// then rebuilds the full-text index from the replaced values and compacts
+// the database, so that no earlier form of them remains on disk
+func (idx *CodebaseIndex) Reencrypt(recrypt security.Recrypt) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	tx, err := idx.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT id, COALESCE(signature, ''), COALESCE(doc, '') FROM symbols")
+	if err != nil {
+		return err
+	}
+	type symbolText struct {
+		id             int64
+		signature, doc string
+	}
+	var changed []symbolText
+	for rows.Next() {
+		var s symbolText
+		if err := rows.Scan(&s.id, &s.signature, &s.doc); err != nil {
+			rows.Close()
+			return err
+		}
+		signature, err := recrypt(s.signature)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		doc, err := recrypt(s.doc)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		if signature != s.signature || doc != s.doc {
+			changed = append(changed, symbolText{s.id, signature, doc})
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.Prepare("UPDATE symbols SET signature = ?, doc = ? WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, s := range changed {
+		if _, err := stmt.Exec(s.signature, s.doc, s.id); err != nil {
+			return err
+		}
+	}
+
+	// The update trigger cannot remove the old terms of an external content
+	// table, so the full-text index is rebuilt from the new values
+	if _, err := tx.Exec("INSERT INTO symbols_fts(symbols_fts) VALUES('rebuild')"); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	for _, stmt := range []string{"VACUUM", "PRAGMA wal_checkpoint(TRUNCATE)"} {
+		if _, err := idx.db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
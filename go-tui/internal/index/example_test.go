@@ -28,6 +28,7 @@ func Example() {
 	// Create index configuration
 	config := index.DefaultConfig(tmpDir)
 	config.DatabasePath = filepath.Join(tmpDir, "test.db")
+	config.RegistryPath = filepath.Join(tmpDir, "indexes.json")
 
 	// Create codebase index
 	idx, err := index.NewCodebaseIndex(config)
@@ -135,6 +136,7 @@ func Example_search() {
 
 	config := index.DefaultConfig(tmpDir)
 	config.DatabasePath = filepath.Join(tmpDir, "test.db")
+	config.RegistryPath = filepath.Join(tmpDir, "indexes.json")
 
 	idx, _ := index.NewCodebaseIndex(config)
 	defer idx.Close()
@@ -176,6 +178,7 @@ func Example_structure() {
 
 	config := index.DefaultConfig(tmpDir)
 	config.DatabasePath = filepath.Join(tmpDir, "test.db")
+	config.RegistryPath = filepath.Join(tmpDir, "indexes.json")
 
 	idx, _ := index.NewCodebaseIndex(config)
 	defer idx.Close()
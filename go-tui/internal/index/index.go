@@ -16,6 +16,8 @@ import (
 	"time"
 
 	_ "modernc.org/sqlite" // Pure Go SQLite driver
+
+	"github.com/jeranaias/rigrun-tui/internal/security"
 )
 
 // =============================================================================
@@ -52,6 +54,9 @@ type CodebaseIndex struct {
 
 	// Parser registry
 	parsers      map[string]Parser
+
+	// SC-28: Seals symbol signatures and docs; nil stores them in plaintext
+	cipher security.FieldCipher
 }
 
 // Config holds index configuration
@@ -76,6 +81,10 @@ type Config struct {
 
 	// WatchDebounce is the debounce duration for file change events
 	WatchDebounce time.Duration
+
+	// RegistryPath is the file listing the indexes built on this machine,
+	// so that key rotation can reach them all (empty = not listed)
+	RegistryPath string
 }
 
 // DefaultConfig returns default configuration
@@ -96,6 +105,7 @@ func DefaultConfig(root string) *Config {
 		Languages:     []string{}, // All supported
 		EnableWatch:   true,
 		WatchDebounce: 500 * time.Millisecond,
+		RegistryPath:  DefaultRegistryPath(),
 	}
 }
 
@@ -141,6 +151,7 @@ func NewCodebaseIndex(config *Config) (*CodebaseIndex, error) {
 		"PRAGMA mmap_size=268435456",    // 256MB mmap
 		"PRAGMA foreign_keys=ON",        // Enable foreign key constraints
 		"PRAGMA wal_autocheckpoint=1000", // Checkpoint every 1000 pages
+		"PRAGMA secure_delete=ON",        // SC-28: Overwrite deleted content
 	}
 	for _, pragma := range pragmas {
 		if _, err := db.Exec(pragma); err != nil {
@@ -154,6 +165,7 @@ func NewCodebaseIndex(config *Config) (*CodebaseIndex, error) {
 		root:    config.Root,
 		config:  config,
 		parsers: make(map[string]Parser),
+		cipher:  security.DataCipher(),
 	}
 
 	// Initialize schema
@@ -243,6 +255,12 @@ func (idx *CodebaseIndex) Index(ctx context.Context) error {
 		idx.indexingMu.Unlock()
 	}()
 
+	// List the index before it holds any content, so that key rotation
+	// reaches it even if indexing stops part way
+	if err := registerIndex(idx.config); err != nil {
+		return fmt.Errorf("failed to register index: %w", err)
+	}
+
 	startTime := time.Now()
 
 	// Begin transaction
@@ -388,12 +406,21 @@ func (idx *CodebaseIndex) indexFile(tx *sql.Tx, path string, info os.FileInfo, p
 		return 0, err
 	}
 
-	// Insert symbols
+	// Insert symbols; signatures and docs are encrypted when a cipher is
+	// set, leaving names searchable
 	for _, sym := range symbols {
-		_, err := tx.Exec(`
+		signature, err := security.SealField(idx.cipher, sym.Signature)
+		if err != nil {
+			return 0, err
+		}
+		doc, err := security.SealField(idx.cipher, sym.Doc)
+		if err != nil {
+			return 0, err
+		}
+		_, err = tx.Exec(`
 			INSERT INTO symbols (name, type, file_id, line, end_line, signature, doc, parent, visibility)
 			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
-		`, sym.Name, sym.Type, fileID, sym.Line, sym.EndLine, sym.Signature, sym.Doc, sym.Parent, sym.Visibility)
+		`, sym.Name, sym.Type, fileID, sym.Line, sym.EndLine, signature, doc, sym.Parent, sym.Visibility)
 		if err != nil {
 			return 0, err
 		}
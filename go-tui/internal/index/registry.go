@@ -0,0 +1,114 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package index provides codebase indexing for fast symbol search.
+package index
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/jeranaias/rigrun-tui/internal/util"
+)
+
+// =============================================================================
+// INDEX REGISTRY
+// =============================================================================
+
+// RegistryFile is the name of the index registry in ~/.rigrun.
+const RegistryFile = "indexes.json"
+
+// Location identifies a codebase index on disk.
+type Location struct {
+	Root         string `json:"root"`
+	DatabasePath string `json:"database_path"`
+}
+
+// registryMu serializes updates to the registry within the process.
+var registryMu sync.Mutex
+
+// DefaultRegistryPath returns ~/.rigrun/indexes.json, or "" if the home
+// directory is unknown.
+func DefaultRegistryPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(homeDir, ".rigrun", RegistryFile)
+}
+
+// KnownIndexes returns the indexes listed in the registry at path whose
+// database still exists.
+func KnownIndexes(path string) ([]Location, error) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	locations, err := readRegistry(path)
+	if err != nil {
+		return nil, err
+	}
+	var known []Location
+	for _, loc := range locations {
+		if _, err := os.Stat(loc.DatabasePath); err == nil {
+			known = append(known, loc)
+		}
+	}
+	return known, nil
+}
+
+// registerIndex adds the index of config to its registry, if it has one.
+func registerIndex(config *Config) error {
+	if config.RegistryPath == "" {
+		return nil
+	}
+	loc := Location{Root: config.Root, DatabasePath: config.DatabasePath}
+	var err error
+	if loc.Root, err = filepath.Abs(loc.Root); err != nil {
+		return err
+	}
+	if loc.DatabasePath, err = filepath.Abs(loc.DatabasePath); err != nil {
+		return err
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	locations, err := readRegistry(config.RegistryPath)
+	if err != nil {
+		return err
+	}
+	for _, known := range locations {
+		if known == loc {
+			return nil
+		}
+	}
+	locations = append(locations, loc)
+
+	data, err := json.MarshalIndent(locations, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(config.RegistryPath), 0700); err != nil {
+		return err
+	}
+	return util.AtomicWriteFile(config.RegistryPath, data, 0600)
+}
+
+// readRegistry reads the registry at path; a missing registry is empty.
+func readRegistry(path string) ([]Location, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var locations []Location
+	if err := json.Unmarshal(data, &locations); err != nil {
+		return nil, err
+	}
+	return locations, nil
+}
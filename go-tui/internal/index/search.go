@@ -164,6 +164,9 @@ func (idx *CodebaseIndex) Search(query string, options *SearchOptions) ([]Search
 		if err != nil {
 			continue
 		}
+		if err := idx.open(&signature.String, &doc); err != nil {
+			return nil, fmt.Errorf("failed to decrypt symbol %s in %s: %w", result.Name, result.FilePath, err)
+		}
 
 		result.Type = SymbolType(symType)
 		result.Visibility = Visibility(visibility)
@@ -277,6 +280,9 @@ func (idx *CodebaseIndex) SearchByName(name string, options *SearchOptions) ([]S
 		if err != nil {
 			continue
 		}
+		if err := idx.open(&signature.String, &doc); err != nil {
+			return nil, fmt.Errorf("failed to decrypt symbol %s in %s: %w", result.Name, result.FilePath, err)
+		}
 
 		result.Type = SymbolType(symType)
 		result.Visibility = Visibility(visibility)
@@ -338,6 +344,9 @@ func (idx *CodebaseIndex) GetFileSymbols(filePath string) ([]Symbol, error) {
 		if err != nil {
 			continue
 		}
+		if err := idx.open(&signature.String, &doc); err != nil {
+			return nil, fmt.Errorf("failed to decrypt symbol %s in %s: %w", sym.Name, filePath, err)
+		}
 
 		sym.Type = SymbolType(symType)
 		sym.Visibility = Visibility(visibility)
@@ -0,0 +1,411 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package instructions loads project instruction files (RIGRUN.md) and
+// layers them into system prompts.
+//
+// Files are discovered in precedence order, lowest first:
+//
+//  1. ~/.rigrun/RIGRUN.md - personal instructions for every project
+//  2. <repo root>/RIGRUN.md - shared project instructions
+//  3. RIGRUN.md in directories between the repo root and the working
+//     directory, and in directories containing files the agent has touched
+//
+// Later files are more specific and win when instructions conflict. The
+// merged text is appended to the base system prompt.
+package instructions
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jeranaias/rigrun-tui/internal/util"
+)
+
+// FileName is the name of a project instruction file.
+const FileName = "RIGRUN.md"
+
+// MaxFileBytes caps how much of a single instruction file is loaded so a
+// stray large file cannot crowd out the conversation.
+const MaxFileBytes = 32 * 1024
+
+// Scope identifies where an instruction file came from.
+type Scope string
+
+const (
+	// ScopeUser is the user-global file in ~/.rigrun.
+	ScopeUser Scope = "user"
+
+	// ScopeProject is the file at the repository root.
+	ScopeProject Scope = "project"
+
+	// ScopeDirectory is a file in a nested directory.
+	ScopeDirectory Scope = "directory"
+)
+
+// =============================================================================
+// LOADED FILES
+// =============================================================================
+
+// File is one loaded instruction file.
+type File struct {
+	// Path is the absolute path of the file.
+	Path string
+
+	// Scope is where the file sits in the precedence order.
+	Scope Scope
+
+	// Content is the file content (possibly truncated).
+	Content string
+
+	// Tokens is the estimated token cost of the file in the prompt.
+	Tokens int
+
+	// Truncated is true if the file exceeded MaxFileBytes.
+	Truncated bool
+}
+
+// Set is the instruction files that apply to a working directory, in
+// precedence order (lowest first).
+type Set struct {
+	// Root is the repository root (or the working directory outside a repo).
+	Root string
+
+	// Files are the loaded files.
+	Files []File
+}
+
+// Tokens returns the estimated token cost of all files.
+func (s *Set) Tokens() int {
+	if s == nil {
+		return 0
+	}
+	total := 0
+	for _, f := range s.Files {
+		total += f.Tokens
+	}
+	return total
+}
+
+// DisplayPath returns a short path for a file: relative to the repository
+// root for project files and ~-relative for the user file.
+func (s *Set) DisplayPath(f File) string {
+	if f.Scope == ScopeUser {
+		if home, err := os.UserHomeDir(); err == nil {
+			if rel, err := filepath.Rel(home, f.Path); err == nil && !strings.HasPrefix(rel, "..") {
+				return filepath.Join("~", rel)
+			}
+		}
+		return f.Path
+	}
+	if rel, err := filepath.Rel(s.Root, f.Path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return f.Path
+}
+
+// Prompt returns the merged instructions as a system prompt section, or ""
+// when no files were found.
+func (s *Set) Prompt() string {
+	if s == nil || len(s.Files) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("# PROJECT INSTRUCTIONS\n\n")
+	sb.WriteString("Follow these instructions from " + FileName + " files. ")
+	sb.WriteString("They are listed from most general to most specific; when they conflict, the later file wins.\n")
+	for _, f := range s.Files {
+		fmt.Fprintf(&sb, "\n## %s (%s)\n\n", s.DisplayPath(f), f.Scope)
+		sb.WriteString(strings.TrimSpace(f.Content))
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// Apply appends the instructions to a base system prompt.
+func (s *Set) Apply(base string) string {
+	section := s.Prompt()
+	if section == "" {
+		return base
+	}
+	if base == "" {
+		return section
+	}
+	return strings.TrimRight(base, "\n") + "\n\n" + section
+}
+
+// =============================================================================
+// DISCOVERY
+// =============================================================================
+
+// UserPath returns the path of the user-global instruction file.
+func UserPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".rigrun", FileName)
+}
+
+// FindRoot returns the nearest ancestor of dir containing .git, or dir itself
+// when it is not inside a repository.
+func FindRoot(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return dir
+	}
+	for d := dir; ; {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			return d
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return dir
+		}
+		d = parent
+	}
+}
+
+// Load discovers the instruction files for workDir. Touched paths (absolute
+// or relative to workDir) add RIGRUN.md files from their directories.
+func Load(workDir string, touched ...string) *Set {
+	return load(UserPath(), workDir, touched)
+}
+
+// load is Load with an explicit user file path.
+func load(userPath, workDir string, touched []string) *Set {
+	if abs, err := filepath.Abs(workDir); err == nil {
+		workDir = abs
+	}
+	set := &Set{Root: FindRoot(workDir)}
+
+	if userPath != "" {
+		if f, ok := readFile(userPath, ScopeUser); ok {
+			set.Files = append(set.Files, f)
+		}
+	}
+	if projectPath := filepath.Join(set.Root, FileName); projectPath != userPath {
+		if f, ok := readFile(projectPath, ScopeProject); ok {
+			set.Files = append(set.Files, f)
+		}
+	}
+
+	// Nested directories between the root and the working directory or a
+	// touched file, excluding the root itself
+	dirs := make(map[string]bool)
+	addChain := func(dir string) {
+		rel, err := filepath.Rel(set.Root, dir)
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return
+		}
+		for d := dir; d != set.Root && !dirs[d]; d = filepath.Dir(d) {
+			dirs[d] = true
+		}
+	}
+	addChain(workDir)
+	for _, p := range touched {
+		if p == "" {
+			continue
+		}
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(workDir, p)
+		}
+		p = filepath.Clean(p)
+		if info, err := os.Stat(p); err != nil || !info.IsDir() {
+			p = filepath.Dir(p)
+		}
+		addChain(p)
+	}
+
+	nested := make([]string, 0, len(dirs))
+	for d := range dirs {
+		nested = append(nested, d)
+	}
+	sort.Slice(nested, func(i, j int) bool {
+		di, dj := strings.Count(nested[i], string(filepath.Separator)), strings.Count(nested[j], string(filepath.Separator))
+		if di != dj {
+			return di < dj
+		}
+		return nested[i] < nested[j]
+	})
+	for _, d := range nested {
+		if f, ok := readFile(filepath.Join(d, FileName), ScopeDirectory); ok {
+			set.Files = append(set.Files, f)
+		}
+	}
+	return set
+}
+
+// readFile loads one instruction file. Missing, unreadable and empty files
+// are skipped.
+func readFile(path string, scope Scope) (File, bool) {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return File{}, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return File{}, false
+	}
+	f := File{Path: path, Scope: scope}
+	content := string(data)
+	if len(content) > MaxFileBytes {
+		content = util.TruncateBytes(content, MaxFileBytes)
+		f.Truncated = true
+	}
+	f.Content = strings.TrimSpace(content)
+	if f.Content == "" {
+		return File{}, false
+	}
+	if f.Truncated {
+		f.Content += "\n\n[truncated]"
+	}
+	f.Tokens = (len(f.Content) + 3) / 4
+	return f, true
+}
+
+// =============================================================================
+// TRACKER
+// =============================================================================
+
+// Tracker keeps the instruction set for a session up to date as the agent
+// touches files in other directories. It is safe for concurrent use.
+type Tracker struct {
+	mu      sync.Mutex
+	workDir string
+	touched []string
+	seen    map[string]bool
+	set     *Set
+}
+
+// NewTracker loads the instructions for workDir.
+func NewTracker(workDir string) *Tracker {
+	t := &Tracker{workDir: workDir, seen: make(map[string]bool)}
+	t.set = Load(workDir)
+	return t
+}
+
+// Set returns the current instruction set.
+func (t *Tracker) Set() *Set {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.set
+}
+
+// Touch records files the agent read or changed. It returns true if the set
+// of loaded instruction files changed.
+func (t *Tracker) Touch(paths ...string) bool {
+	if t == nil {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	added := false
+	for _, p := range paths {
+		if p != "" && !t.seen[p] {
+			t.seen[p] = true
+			t.touched = append(t.touched, p)
+			added = true
+		}
+	}
+	if !added {
+		return false
+	}
+	return t.reloadLocked()
+}
+
+// Reload re-reads the instruction files. It returns true if the loaded
+// files or their content changed.
+func (t *Tracker) Reload() bool {
+	if t == nil {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.reloadLocked()
+}
+
+func (t *Tracker) reloadLocked() bool {
+	next := Load(t.workDir, t.touched...)
+	changed := next.Prompt() != t.set.Prompt()
+	t.set = next
+	return changed
+}
+
+// PathsFromToolParams returns the file paths referenced by a tool call.
+func PathsFromToolParams(params map[string]interface{}) []string {
+	var paths []string
+	for _, key := range []string{"file_path", "path"} {
+		if p, ok := params[key].(string); ok && p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// =============================================================================
+// NOTES
+// =============================================================================
+
+// NotePath returns the file a note with the given scope is written to. For
+// ScopeDirectory, dir must be inside the repository root.
+func (s *Set) NotePath(scope Scope, dir string) (string, error) {
+	switch scope {
+	case ScopeUser:
+		if p := UserPath(); p != "" {
+			return p, nil
+		}
+		return "", fmt.Errorf("could not determine home directory")
+	case ScopeProject, "":
+		return filepath.Join(s.Root, FileName), nil
+	case ScopeDirectory:
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(s.Root, dir)
+		}
+		dir = filepath.Clean(dir)
+		if rel, err := filepath.Rel(s.Root, dir); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return "", fmt.Errorf("%s is outside the project root %s", dir, s.Root)
+		}
+		return filepath.Join(dir, FileName), nil
+	default:
+		return "", fmt.Errorf("unknown scope %q", scope)
+	}
+}
+
+// AppendNote appends a bullet to an instruction file, creating the file (and
+// its directory) if needed.
+func AppendNote(path, note string) error {
+	note = strings.TrimSpace(strings.ReplaceAll(note, "\n", " "))
+	if note == "" {
+		return fmt.Errorf("note is empty")
+	}
+
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	content := strings.TrimRight(string(existing), "\n")
+	if strings.TrimSpace(content) == "" {
+		content = "# Instructions"
+	}
+	// Notes go in a trailing "## Notes" section
+	lastHeading := ""
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(line, "## ") {
+			lastHeading = strings.TrimSpace(line)
+		}
+	}
+	if lastHeading != "## Notes" {
+		content += "\n\n## Notes\n"
+	}
+	content += "\n- " + note + "\n"
+
+	return util.AtomicWriteFileWithDir(path, []byte(content), 0644, 0755)
+}
@@ -0,0 +1,180 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package instructions
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFile creates a file and its parent directories.
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newRepo creates a repository with instruction files at the root and in
+// nested directories, plus a user file outside it.
+func newRepo(t *testing.T) (root, userPath string) {
+	t.Helper()
+	base := t.TempDir()
+	root = filepath.Join(base, "repo")
+	userPath = filepath.Join(base, "home", ".rigrun", FileName)
+	os.MkdirAll(filepath.Join(root, ".git"), 0755)
+	writeFile(t, userPath, "user rules")
+	writeFile(t, filepath.Join(root, FileName), "project rules")
+	writeFile(t, filepath.Join(root, "svc", FileName), "service rules")
+	writeFile(t, filepath.Join(root, "svc", "api", FileName), "api rules")
+	writeFile(t, filepath.Join(root, "web", FileName), "web rules")
+	writeFile(t, filepath.Join(root, "web", "app.js"), "")
+	return root, userPath
+}
+
+// contents returns the content of each file in the set.
+func contents(s *Set) []string {
+	var out []string
+	for _, f := range s.Files {
+		out = append(out, f.Content)
+	}
+	return out
+}
+
+func TestLoad_PrecedenceOrder(t *testing.T) {
+	root, userPath := newRepo(t)
+
+	set := load(userPath, filepath.Join(root, "svc", "api"), nil)
+	if set.Root != root {
+		t.Errorf("Root = %s, want %s", set.Root, root)
+	}
+	got := strings.Join(contents(set), ",")
+	if got != "user rules,project rules,service rules,api rules" {
+		t.Errorf("files = %s", got)
+	}
+	if set.Files[0].Scope != ScopeUser || set.Files[1].Scope != ScopeProject || set.Files[3].Scope != ScopeDirectory {
+		t.Errorf("unexpected scopes: %+v", set.Files)
+	}
+
+	// The most specific file comes last in the prompt
+	prompt := set.Prompt()
+	if strings.Index(prompt, "api rules") < strings.Index(prompt, "project rules") {
+		t.Error("nested instructions should follow project instructions")
+	}
+	if !strings.Contains(prompt, "## svc/api/"+FileName+" (directory)") {
+		t.Errorf("prompt missing relative path heading:\n%s", prompt)
+	}
+}
+
+func TestLoad_TouchedFilesAddNestedInstructions(t *testing.T) {
+	root, userPath := newRepo(t)
+
+	set := load(userPath, root, nil)
+	if got := strings.Join(contents(set), ","); got != "user rules,project rules" {
+		t.Fatalf("files at root = %s", got)
+	}
+
+	set = load(userPath, root, []string{"web/app.js", filepath.Join(root, "svc", "api", "missing.go"), "/etc/passwd"})
+	if got := strings.Join(contents(set), ","); got != "user rules,project rules,service rules,web rules,api rules" {
+		t.Errorf("files with touched paths = %s", got)
+	}
+}
+
+func TestLoad_TruncatesLargeFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, FileName), strings.Repeat("x", MaxFileBytes+100))
+
+	set := load("", dir, nil)
+	if len(set.Files) != 1 || !set.Files[0].Truncated {
+		t.Fatalf("expected one truncated file, got %+v", set.Files)
+	}
+	if set.Tokens() <= 0 || set.Tokens() > MaxFileBytes/4+10 {
+		t.Errorf("Tokens = %d", set.Tokens())
+	}
+}
+
+func TestSet_ApplyWithoutFiles(t *testing.T) {
+	set := load("", t.TempDir(), nil)
+	if got := set.Apply("base prompt"); got != "base prompt" {
+		t.Errorf("Apply without files changed the prompt: %q", got)
+	}
+	var nilSet *Set
+	if nilSet.Prompt() != "" || nilSet.Tokens() != 0 {
+		t.Error("nil set should be empty")
+	}
+}
+
+func TestTracker_Touch(t *testing.T) {
+	root, _ := newRepo(t)
+	t.Setenv("HOME", t.TempDir())
+
+	tracker := NewTracker(root)
+	if n := len(tracker.Set().Files); n != 1 {
+		t.Fatalf("initial files = %d, want 1", n)
+	}
+	if !tracker.Touch(filepath.Join(root, "web", "app.js")) {
+		t.Error("touching a directory with instructions should change the set")
+	}
+	if tracker.Touch(filepath.Join(root, "web", "app.js")) {
+		t.Error("touching the same file again should not change the set")
+	}
+	if tracker.Touch(filepath.Join(root, "README.md")) {
+		t.Error("touching a file at the root should not change the set")
+	}
+	if n := len(tracker.Set().Files); n != 2 {
+		t.Errorf("files after touch = %d, want 2", n)
+	}
+}
+
+func TestAppendNote(t *testing.T) {
+	root, userPath := newRepo(t)
+	set := load(userPath, root, nil)
+
+	path, err := set.NotePath(ScopeProject, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := AppendNote(path, "run go test before committing"); err != nil {
+		t.Fatalf("AppendNote: %v", err)
+	}
+	if err := AppendNote(path, "use table-driven tests"); err != nil {
+		t.Fatalf("AppendNote: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	want := "project rules\n\n## Notes\n\n- run go test before committing\n- use table-driven tests\n"
+	if string(data) != want {
+		t.Errorf("file = %q, want %q", data, want)
+	}
+
+	// A new nested file is created with a heading
+	path, err = set.NotePath(ScopeDirectory, "docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := AppendNote(path, "keep docs short"); err != nil {
+		t.Fatalf("AppendNote: %v", err)
+	}
+	if data, _ := os.ReadFile(path); !strings.HasPrefix(string(data), "# Instructions\n\n## Notes\n\n- keep docs short") {
+		t.Errorf("new file = %q", data)
+	}
+
+	if _, err := set.NotePath(ScopeDirectory, "../outside"); err == nil {
+		t.Error("directory outside the root should be rejected")
+	}
+	if err := AppendNote(path, "  "); err == nil {
+		t.Error("empty note should be rejected")
+	}
+}
+
+func TestPathsFromToolParams(t *testing.T) {
+	got := PathsFromToolParams(map[string]interface{}{"file_path": "a.go", "path": "dir", "pattern": "*.go"})
+	if strings.Join(got, ",") != "a.go,dir" {
+		t.Errorf("paths = %v", got)
+	}
+}
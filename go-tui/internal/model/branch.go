@@ -0,0 +1,267 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package model contains the data structures for conversations and messages.
+//
+// branch.go implements conversation branching. Messages form a tree through
+// Message.ParentID: editing or regenerating an earlier user message adds a
+// sibling of that message instead of overwriting it. Conversation.Messages
+// is always the active path from the root to the newest leaf, so code that
+// reads the history does not need to know about branches; messages on the
+// other branches are kept in Conversation.Branches.
+package model
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jeranaias/rigrun-tui/internal/security"
+)
+
+// =============================================================================
+// TREE MAINTENANCE
+// =============================================================================
+
+// linkPath fills in missing parent links on the active path. Messages
+// appended directly to Messages (and conversations saved before branching
+// existed) have no ParentID.
+func (c *Conversation) linkPath() {
+	for i := 1; i < len(c.Messages); i++ {
+		if c.Messages[i].ParentID == "" {
+			c.Messages[i].ParentID = c.Messages[i-1].ID
+		}
+	}
+}
+
+// pathIndex returns the index of a message on the active path, or -1.
+func (c *Conversation) pathIndex(id string) int {
+	for i, msg := range c.Messages {
+		if msg.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// findMessage returns a message from the active path or another branch.
+func (c *Conversation) findMessage(id string) *Message {
+	if msg := c.GetMessageByID(id); msg != nil {
+		return msg
+	}
+	for _, msg := range c.Branches {
+		if msg.ID == id {
+			return msg
+		}
+	}
+	return nil
+}
+
+// detach moves Messages[index:] onto the inactive branches.
+func (c *Conversation) detach(index int) {
+	c.Branches = append(c.Branches, c.Messages[index:]...)
+	c.Messages = c.Messages[:index:index]
+}
+
+// =============================================================================
+// FORKING
+// =============================================================================
+
+// Fork starts a new branch at a user message on the active branch: the
+// message and everything after it move to an inactive branch, and a new user
+// message with the given content takes its place as a sibling. Regenerating a
+// response is a fork with the original content.
+func (c *Conversation) Fork(id, content string) (*Message, error) {
+	index := c.pathIndex(id)
+	if index < 0 {
+		return nil, fmt.Errorf("message is not on the active branch")
+	}
+	original := c.Messages[index]
+	if original.Role != RoleUser {
+		return nil, fmt.Errorf("only user messages can be edited")
+	}
+	if c.hasStreaming(index) {
+		return nil, fmt.Errorf("cannot fork while a response is streaming")
+	}
+
+	c.linkPath()
+	c.detach(index)
+
+	msg := NewUserMessage(content)
+	msg.ParentID = original.ParentID
+	// Siblings are ordered by creation time; make sure the fork sorts last
+	// even on clocks with coarse resolution
+	if !msg.Timestamp.After(original.Timestamp) {
+		msg.Timestamp = original.Timestamp.Add(time.Nanosecond)
+	}
+	c.AddMessage(msg)
+	return msg, nil
+}
+
+// hasStreaming reports whether any message from index on is still streaming.
+func (c *Conversation) hasStreaming(index int) bool {
+	for _, msg := range c.Messages[index:] {
+		if msg.IsStreaming {
+			return true
+		}
+	}
+	return false
+}
+
+// =============================================================================
+// NAVIGATION
+// =============================================================================
+
+// Siblings returns the user messages that share a parent with the message
+// with the given ID, including the message itself, oldest first. A message
+// that was never edited or regenerated is its own only sibling.
+func (c *Conversation) Siblings(id string) []*Message {
+	target := c.findMessage(id)
+	if target == nil {
+		return nil
+	}
+	if target.Role != RoleUser {
+		return []*Message{target}
+	}
+	c.linkPath()
+
+	var siblings []*Message
+	for _, group := range [][]*Message{c.Messages, c.Branches} {
+		for _, msg := range group {
+			if msg.Role == RoleUser && msg.ParentID == target.ParentID {
+				siblings = append(siblings, msg)
+			}
+		}
+	}
+	sort.SliceStable(siblings, func(i, j int) bool {
+		return siblings[i].Timestamp.Before(siblings[j].Timestamp)
+	})
+	return siblings
+}
+
+// BranchInfo returns the 1-based position of a message among its siblings
+// and the number of siblings.
+func (c *Conversation) BranchInfo(id string) (pos, count int) {
+	siblings := c.Siblings(id)
+	for i, msg := range siblings {
+		if msg.ID == id {
+			return i + 1, len(siblings)
+		}
+	}
+	return 0, 0
+}
+
+// ForkPoints returns the indexes on the active path of user messages that
+// have siblings, in path order.
+func (c *Conversation) ForkPoints() []int {
+	if len(c.Branches) == 0 {
+		return nil
+	}
+	c.linkPath()
+
+	// Count user messages per parent once instead of per path message
+	counts := make(map[string]int)
+	for _, group := range [][]*Message{c.Messages, c.Branches} {
+		for _, msg := range group {
+			if msg.Role == RoleUser {
+				counts[msg.ParentID]++
+			}
+		}
+	}
+	var points []int
+	for i, msg := range c.Messages {
+		if msg.Role == RoleUser && counts[msg.ParentID] > 1 {
+			points = append(points, i)
+		}
+	}
+	return points
+}
+
+// HasBranches reports whether the conversation has inactive branches.
+func (c *Conversation) HasBranches() bool {
+	return len(c.Branches) > 0
+}
+
+// SwitchBranch makes a sibling of the message with the given ID active. The
+// sibling is delta positions away (wrapping around); the thread below it is
+// restored along the branch that was active most recently. It returns the
+// newly active message.
+func (c *Conversation) SwitchBranch(id string, delta int) (*Message, error) {
+	index := c.pathIndex(id)
+	if index < 0 {
+		return nil, fmt.Errorf("message is not on the active branch")
+	}
+	if c.hasStreaming(index) {
+		return nil, fmt.Errorf("cannot switch branches while a response is streaming")
+	}
+	siblings := c.Siblings(id)
+	if len(siblings) < 2 {
+		return nil, fmt.Errorf("message has no other branches")
+	}
+
+	pos := 0
+	for i, msg := range siblings {
+		if msg.ID == id {
+			pos = i
+		}
+	}
+	n := len(siblings)
+	target := siblings[((pos+delta)%n+n)%n]
+	if target.ID == id {
+		return target, nil
+	}
+
+	c.detach(index)
+	for next := target; next != nil; {
+		c.Messages = append(c.Messages, next)
+		c.removeFromBranches(next.ID)
+		next = c.latestChild(next.ID)
+	}
+
+	c.UpdatedAt = time.Now()
+	c.updateTokenEstimate()
+	return target, nil
+}
+
+// latestChild returns the child of a message that was on the active path
+// most recently. Detached threads are appended to Branches, so that is the
+// last matching entry.
+func (c *Conversation) latestChild(parentID string) *Message {
+	for i := len(c.Branches) - 1; i >= 0; i-- {
+		if c.Branches[i].ParentID == parentID {
+			return c.Branches[i]
+		}
+	}
+	return nil
+}
+
+// removeFromBranches removes a message from Branches, keeping the order of
+// the rest.
+func (c *Conversation) removeFromBranches(id string) {
+	for i, msg := range c.Branches {
+		if msg.ID == id {
+			c.Branches = append(c.Branches[:i], c.Branches[i+1:]...)
+			return
+		}
+	}
+}
+
+// pruneOldBranches drops the branches that were detached first once there
+// are more than MaxMessages inactive messages. Their markings are folded into
+// the conversation marking like pruned history.
+func (c *Conversation) pruneOldBranches() {
+	excess := len(c.Branches) - MaxMessages
+	if excess <= 0 {
+		return
+	}
+	pruned := []security.Classification{security.ParseStoredMarking(c.Classification)}
+	for _, msg := range c.Branches[:excess] {
+		if msg.Classification != "" {
+			pruned = append(pruned, security.ParseStoredMarking(msg.Classification))
+		}
+	}
+	if hwm := security.HighWaterMark(pruned...); hwm.Level > security.ClassificationUnclassified {
+		c.Classification = hwm.String()
+	}
+	c.Branches = append([]*Message(nil), c.Branches[excess:]...)
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+// contents returns the content of the messages on the active branch.
+func contents(c *Conversation) string {
+	var parts []string
+	for _, msg := range c.Messages {
+		parts = append(parts, msg.Content)
+	}
+	return strings.Join(parts, ",")
+}
+
+// reply adds a finished assistant message.
+func reply(c *Conversation, content string) {
+	msg := c.AddAssistantMessage()
+	msg.Content = content
+	msg.IsStreaming = false
+}
+
+// newThread returns a conversation with two exchanges: q1,a1,q2,a2.
+func newThread() *Conversation {
+	c := NewConversation()
+	c.AddUserMessage("q1")
+	reply(c, "a1")
+	c.AddUserMessage("q2")
+	reply(c, "a2")
+	return c
+}
+
+func TestAddMessage_LinksParents(t *testing.T) {
+	c := newThread()
+	if c.Messages[0].ParentID != "" {
+		t.Errorf("first message should be the root, parent = %q", c.Messages[0].ParentID)
+	}
+	for i := 1; i < len(c.Messages); i++ {
+		if c.Messages[i].ParentID != c.Messages[i-1].ID {
+			t.Errorf("message %d parent = %q, want %q", i, c.Messages[i].ParentID, c.Messages[i-1].ID)
+		}
+	}
+}
+
+func TestFork_CreatesSibling(t *testing.T) {
+	c := newThread()
+	q2 := c.Messages[2]
+
+	edited, err := c.Fork(q2.ID, "q2 edited")
+	if err != nil {
+		t.Fatalf("Fork: %v", err)
+	}
+	reply(c, "a2'")
+
+	if got := contents(c); got != "q1,a1,q2 edited,a2'" {
+		t.Errorf("active branch = %s", got)
+	}
+	if len(c.Branches) != 2 {
+		t.Errorf("inactive messages = %d, want 2", len(c.Branches))
+	}
+	if edited.ParentID != q2.ParentID {
+		t.Error("edited message should be a sibling of the original")
+	}
+	if pos, count := c.BranchInfo(edited.ID); pos != 2 || count != 2 {
+		t.Errorf("BranchInfo = %d/%d, want 2/2", pos, count)
+	}
+	if points := c.ForkPoints(); len(points) != 1 || points[0] != 2 {
+		t.Errorf("ForkPoints = %v, want [2]", points)
+	}
+
+	if _, err := c.Fork(c.Messages[1].ID, "x"); err == nil {
+		t.Error("forking at an assistant message should fail")
+	}
+	if _, err := c.Fork(q2.ID, "x"); err == nil {
+		t.Error("forking at a message on an inactive branch should fail")
+	}
+}
+
+func TestFork_AtFirstMessage(t *testing.T) {
+	c := newThread()
+	if _, err := c.Fork(c.Messages[0].ID, "q1 again"); err != nil {
+		t.Fatal(err)
+	}
+	if got := contents(c); got != "q1 again" {
+		t.Errorf("active branch = %s", got)
+	}
+	if _, count := c.BranchInfo(c.Messages[0].ID); count != 2 {
+		t.Errorf("root siblings = %d, want 2", count)
+	}
+}
+
+func TestSwitchBranch_RestoresThreads(t *testing.T) {
+	c := newThread()
+	q2 := c.Messages[2]
+	c.Fork(q2.ID, "q2 v2")
+	reply(c, "a2 v2")
+	c.AddUserMessage("q3")
+	reply(c, "a3")
+	v3, _ := c.Fork(c.Messages[2].ID, "q2 v3")
+	reply(c, "a2 v3")
+
+	// Back to v2, which restores the thread below it
+	if _, err := c.SwitchBranch(v3.ID, -1); err != nil {
+		t.Fatal(err)
+	}
+	if got := contents(c); got != "q1,a1,q2 v2,a2 v2,q3,a3" {
+		t.Errorf("after prev = %s", got)
+	}
+
+	// Wraps around from the first to the last sibling
+	c.SwitchBranch(c.Messages[2].ID, -1)
+	if got := contents(c); got != "q1,a1,q2,a2" {
+		t.Errorf("after second prev = %s", got)
+	}
+	c.SwitchBranch(c.Messages[2].ID, -1)
+	if got := contents(c); got != "q1,a1,q2 v3,a2 v3" {
+		t.Errorf("after wrap = %s", got)
+	}
+
+	// No message is lost or duplicated along the way
+	if total := len(c.Messages) + len(c.Branches); total != 10 {
+		t.Errorf("messages in tree = %d, want 10", total)
+	}
+	if _, err := c.SwitchBranch(c.Messages[0].ID, 1); err == nil {
+		t.Error("switching at a message without siblings should fail")
+	}
+}
+
+func TestSwitchBranch_FollowsLatestNestedBranch(t *testing.T) {
+	c := newThread()
+	c.AddUserMessage("q3")
+	reply(c, "a3")
+	c.Fork(c.Messages[4].ID, "q3 v2") // nested fork below q2
+	reply(c, "a3 v2")
+	c.Fork(c.Messages[2].ID, "q2 v2") // fork above it
+
+	c.SwitchBranch(c.Messages[2].ID, -1)
+	if got := contents(c); got != "q1,a1,q2,a2,q3 v2,a3 v2" {
+		t.Errorf("should return to the most recent nested branch, got %s", got)
+	}
+}
+
+func TestBranches_CountTowardHighWaterMark(t *testing.T) {
+	c := newThread()
+	c.Messages[3].Classification = "SECRET"
+	c.Fork(c.Messages[2].ID, "q2 edited")
+
+	if got := c.HighWaterMark().String(); got != "SECRET" {
+		t.Errorf("HighWaterMark = %s, want SECRET from the inactive branch", got)
+	}
+	if clone := c.Clone(); len(clone.Branches) != len(c.Branches) || clone.Branches[0] == c.Branches[0] {
+		t.Error("Clone should deep copy branches")
+	}
+	c.ClearHistory()
+	if c.HasBranches() {
+		t.Error("ClearHistory should drop branches")
+	}
+}
+
+func TestRemoveMessage_KeepsTreeLinked(t *testing.T) {
+	c := newThread()
+	a1 := c.Messages[1]
+	c.RemoveMessage(a1.ID)
+	if c.Messages[1].ParentID != c.Messages[0].ID {
+		t.Error("the message after a removed one should take its parent")
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/jeranaias/rigrun-tui/internal/ollama"
+	"github.com/jeranaias/rigrun-tui/internal/security"
 )
 
 // MaxMessages is the maximum number of messages to keep in conversation history.
@@ -28,9 +29,12 @@ type Conversation struct {
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 
-	// Messages
+	// Messages is the active branch, from the first message to the newest.
 	Messages []*Message `json:"messages"`
 
+	// Branches holds the messages on inactive branches (see branch.go).
+	Branches []*Message `json:"branches,omitempty"`
+
 	// Model configuration
 	Model string `json:"model"`
 
@@ -41,6 +45,11 @@ type Conversation struct {
 
 	// System prompt (optional)
 	SystemPrompt string `json:"system_prompt,omitempty"`
+
+	// Classification is the high-water mark of messages that are no longer in
+	// Messages (pruned, or carried over from a loaded session). The effective
+	// conversation marking is computed by HighWaterMark.
+	Classification string `json:"classification,omitempty"`
 }
 
 // NewConversation creates a new conversation with a generated ID.
@@ -65,8 +74,12 @@ func NewConversationWithModel(model string) *Conversation {
 // MESSAGE MANAGEMENT
 // =============================================================================
 
-// AddMessage adds a message to the conversation.
+// AddMessage adds a message to the conversation. Unless the message already
+// has a parent, it follows the last message on the active branch.
 func (c *Conversation) AddMessage(msg *Message) {
+	if msg.ParentID == "" && len(c.Messages) > 0 {
+		msg.ParentID = c.Messages[len(c.Messages)-1].ID
+	}
 	c.Messages = append(c.Messages, msg)
 	c.UpdatedAt = time.Now()
 	c.updateTokenEstimate()
@@ -150,15 +163,20 @@ func (c *Conversation) FinalizeLast(stats *Statistics) {
 // ClearHistory removes all messages from the conversation.
 func (c *Conversation) ClearHistory() {
 	c.Messages = make([]*Message, 0)
+	c.Branches = nil
 	c.TokensUsed = 0
 	c.ContextPercent = 0
 	c.UpdatedAt = time.Now()
 }
 
-// RemoveMessage removes a message by ID.
+// RemoveMessage removes a message by ID. The message after it on the active
+// branch takes its place in the tree.
 func (c *Conversation) RemoveMessage(id string) bool {
 	for i, msg := range c.Messages {
 		if msg.ID == id {
+			if i+1 < len(c.Messages) && c.Messages[i+1].ParentID == id {
+				c.Messages[i+1].ParentID = msg.ParentID
+			}
 			c.Messages = append(c.Messages[:i], c.Messages[i+1:]...)
 			c.UpdatedAt = time.Now()
 			c.updateTokenEstimate()
@@ -188,6 +206,25 @@ func (c *Conversation) IsEmpty() bool {
 	return len(c.Messages) == 0
 }
 
+// =============================================================================
+// CLASSIFICATION
+// =============================================================================
+
+// HighWaterMark returns the conversation's overall classification: the highest
+// marking of any message it contains or has contained, on any branch.
+func (c *Conversation) HighWaterMark() security.Classification {
+	marks := make([]security.Classification, 0, len(c.Messages)+len(c.Branches)+1)
+	marks = append(marks, security.ParseStoredMarking(c.Classification))
+	for _, group := range [][]*Message{c.Messages, c.Branches} {
+		for _, msg := range group {
+			if msg.Classification != "" {
+				marks = append(marks, security.ParseStoredMarking(msg.Classification))
+			}
+		}
+	}
+	return security.HighWaterMark(marks...)
+}
+
 // =============================================================================
 // OLLAMA CONVERSION
 // =============================================================================
@@ -438,15 +475,16 @@ func generateConversationID() string {
 // Clone creates a deep copy of the conversation.
 func (c *Conversation) Clone() *Conversation {
 	clone := &Conversation{
-		ID:           c.ID,
-		Title:        c.Title,
-		CreatedAt:    c.CreatedAt,
-		UpdatedAt:    c.UpdatedAt,
-		Model:        c.Model,
-		TokensUsed:   c.TokensUsed,
-		MaxTokens:    c.MaxTokens,
-		SystemPrompt: c.SystemPrompt,
-		Messages:     make([]*Message, len(c.Messages)),
+		ID:             c.ID,
+		Title:          c.Title,
+		CreatedAt:      c.CreatedAt,
+		UpdatedAt:      c.UpdatedAt,
+		Model:          c.Model,
+		TokensUsed:     c.TokensUsed,
+		MaxTokens:      c.MaxTokens,
+		SystemPrompt:   c.SystemPrompt,
+		Classification: c.Classification,
+		Messages:       make([]*Message, len(c.Messages)),
 	}
 
 	for i, msg := range c.Messages {
@@ -454,6 +492,13 @@ func (c *Conversation) Clone() *Conversation {
 		msgCopy := *msg
 		clone.Messages[i] = &msgCopy
 	}
+	if len(c.Branches) > 0 {
+		clone.Branches = make([]*Message, len(c.Branches))
+		for i, msg := range c.Branches {
+			msgCopy := *msg
+			clone.Branches[i] = &msgCopy
+		}
+	}
 
 	return clone
 }
@@ -461,6 +506,7 @@ func (c *Conversation) Clone() *Conversation {
 // pruneOldMessages removes old messages when conversation history exceeds MaxMessages.
 // Keeps the system prompt message (if any) and the most recent MaxMessages messages.
 func (c *Conversation) pruneOldMessages() {
+	c.pruneOldBranches()
 	if len(c.Messages) <= MaxMessages {
 		return
 	}
@@ -481,6 +527,19 @@ func (c *Conversation) pruneOldMessages() {
 		// Keep system messages + last MaxMessages non-system messages
 		keepCount := MaxMessages
 		startIdx := len(otherMessages) - keepCount
+
+		// Fold pruned markings into the conversation marking so the
+		// high-water mark never drops when history is trimmed
+		pruned := []security.Classification{security.ParseStoredMarking(c.Classification)}
+		for _, msg := range otherMessages[:startIdx] {
+			if msg.Classification != "" {
+				pruned = append(pruned, security.ParseStoredMarking(msg.Classification))
+			}
+		}
+		if hwm := security.HighWaterMark(pruned...); hwm.Level > security.ClassificationUnclassified {
+			c.Classification = hwm.String()
+		}
+
 		otherMessages = otherMessages[startIdx:]
 	}
 
@@ -57,6 +57,10 @@ type Message struct {
 	Role      Role      `json:"role"`
 	Timestamp time.Time `json:"timestamp"`
 
+	// ParentID is the message this one follows in the conversation tree.
+	// Empty for the first message.
+	ParentID string `json:"parent_id,omitempty"`
+
 	// Content
 	Content string `json:"content"`
 
@@ -83,6 +87,10 @@ type Message struct {
 	ContextMentions []string `json:"context_mentions,omitempty"` // @file:, @git, etc.
 	ContextInfo     string   `json:"context_info,omitempty"`     // Summary of expanded context (e.g., "2 files, git, codebase")
 
+	// Classification marking for this message (e.g., "CUI", "SECRET//NOFORN").
+	// Empty means no marking was derived; treated as UNCLASSIFIED.
+	Classification string `json:"classification,omitempty"`
+
 	// Routing information (for assistant messages)
 	RoutingTier  string  `json:"routing_tier,omitempty"`  // Tier used: Cache, Local, Cloud, Haiku, Sonnet, Opus
 	RoutingCost  float64 `json:"routing_cost,omitempty"`  // Cost in cents for this message
@@ -29,6 +29,10 @@ var (
 	// ErrNonLocalhost is returned when attempting to connect to non-localhost in offline mode.
 	ErrNonLocalhost = errors.New("IL5 SC-7: only localhost/127.0.0.1 connections allowed in offline mode")
 
+	// ErrNotLocal is returned when a feature that must stay on the machine is
+	// pointed at a non-localhost endpoint, whether or not offline mode is active.
+	ErrNotLocal = errors.New("IL5 SC-7: only localhost/127.0.0.1 endpoints are allowed")
+
 	// ErrCloudBlocked is returned when attempting to use cloud services in offline mode.
 	ErrCloudBlocked = errors.New("IL5 SC-8: cloud services disabled in offline mode")
 
@@ -149,6 +153,20 @@ func ValidateOllamaURL(baseURL string) error {
 	return ValidateURLForOfflineMode(baseURL)
 }
 
+// ValidateLocalURL checks that a URL is an http(s) endpoint on this machine,
+// regardless of offline mode. Features that promise data never leaves the
+// machine use it instead of ValidateOllamaURL.
+func ValidateLocalURL(rawURL string) error {
+	if err := ValidateURLForOfflineMode(rawURL); err != nil {
+		return err
+	}
+	parsed, _ := url.Parse(rawURL)
+	if !IsLocalhost(parsed.Hostname()) {
+		return ErrNotLocal
+	}
+	return nil
+}
+
 // =============================================================================
 // FEATURE GUARDS
 // =============================================================================
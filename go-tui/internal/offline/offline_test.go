@@ -239,6 +239,25 @@ func TestValidateOllamaURL(t *testing.T) {
 	}
 }
 
+func TestValidateLocalURL(t *testing.T) {
+	original := IsOfflineMode()
+	defer SetOfflineMode(original)
+
+	// Enforced even when offline mode is off
+	SetOfflineMode(false)
+
+	for _, url := range []string{"http://localhost:11434", "http://127.0.0.1:11434", "http://[::1]:11434"} {
+		if err := ValidateLocalURL(url); err != nil {
+			t.Errorf("ValidateLocalURL(%q) should be valid, got %v", url, err)
+		}
+	}
+	for _, url := range []string{"http://ollama.example.com:11434", "http://192.168.1.100:11434", "file:///etc/passwd"} {
+		if err := ValidateLocalURL(url); err == nil {
+			t.Errorf("ValidateLocalURL(%q) should be blocked", url)
+		}
+	}
+}
+
 // =============================================================================
 // FEATURE GUARD TESTS
 // =============================================================================
@@ -6,7 +6,9 @@ package plan
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -17,14 +19,27 @@ import (
 // PROGRESS CALLBACK
 // =============================================================================
 
-// ProgressCallback is called when plan execution progress is made.
+// ProgressCallback is called when plan execution progress is made: when a
+// step starts, finishes or is skipped, and when the plan finishes. step is
+// the number of steps that have finished. Calls are never concurrent, even
+// when steps run in parallel.
 type ProgressCallback func(step int, total int, status string)
 
+// DefaultParallelism is the number of independent steps run at once by
+// default.
+const DefaultParallelism = 4
+
+// errStepCancelled marks a step stopped by cancellation rather than failure.
+// Such steps are returned to pending so that a resumed plan runs them again.
+var errStepCancelled = errors.New("step cancelled")
+
 // =============================================================================
 // PLAN EXECUTOR
 // =============================================================================
 
-// PlanExecutor executes a plan step by step.
+// PlanExecutor executes a plan, running each step once the steps it depends
+// on have completed. Independent steps run concurrently, up to the
+// configured parallelism.
 type PlanExecutor struct {
 	// plan is the plan being executed
 	plan *Plan
@@ -35,6 +50,9 @@ type PlanExecutor struct {
 	// mu protects concurrent access to plan state and onProgress
 	mu sync.RWMutex
 
+	// notifyMu serializes progress callbacks from concurrent steps
+	notifyMu sync.Mutex
+
 	// onProgress is called when progress is made
 	onProgress ProgressCallback
 
@@ -44,16 +62,45 @@ type PlanExecutor struct {
 	// cancel can be called to cancel execution
 	cancel context.CancelFunc
 
-	// continueOnError determines whether to continue on step failure
+	// continueOnError determines whether dependents of a failed step still run
 	continueOnError bool
+
+	// parallelism is the maximum number of steps run at once
+	parallelism int
+
+	// store, when set, receives the plan after every step transition
+	store *Store
+
+	// replanner, when set, revises the plan after a step failure, up to
+	// maxReplans revisions, each applied only if approveRevision agrees
+	replanner       Replanner
+	maxReplans      int
+	approveRevision ApproveRevisionFunc
 }
 
-// NewPlanExecutor creates a new plan executor.
+// stepResult reports the outcome of a step run by Execute.
+type stepResult struct {
+	index int
+	err   error
+}
+
+// NewPlanExecutor creates a new plan executor. Plans without a working
+// directory take the tool executor's, or the process's if there is none.
 func NewPlanExecutor(plan *Plan, executor *tools.Executor) *PlanExecutor {
+	if plan.WorkDir == "" {
+		workDir := "."
+		if executor != nil {
+			workDir = executor.GetWorkDir()
+		}
+		if abs, err := filepath.Abs(workDir); err == nil {
+			plan.WorkDir = abs
+		}
+	}
 	return &PlanExecutor{
 		plan:            plan,
 		executor:        executor,
 		continueOnError: false,
+		parallelism:     DefaultParallelism,
 	}
 }
 
@@ -65,107 +112,175 @@ func (e *PlanExecutor) SetProgressCallback(cb ProgressCallback) {
 }
 
 // SetContinueOnError sets whether to continue execution on step failure.
+// When false, the steps that depend on a failed step (directly or not) are
+// skipped and the plan fails once the remaining steps finish. When true,
+// they run as if the failed step had completed.
 func (e *PlanExecutor) SetContinueOnError(continueOnError bool) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 	e.continueOnError = continueOnError
 }
 
-// Execute runs the plan from the current step to completion.
+// SetStore sets the store the plan is saved to after every step transition,
+// so that execution can be resumed after a crash or quit. Save failures do
+// not stop execution.
+func (e *PlanExecutor) SetStore(store *Store) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.store = store
+}
+
+// SetReplanner enables re-planning: when the plan fails, the replanner
+// proposes replacement steps for everything that did not complete, and if
+// approve accepts them (a nil approve accepts every revision) execution
+// continues with the revised plan. A plan is revised at most maxReplans
+// times over its whole history; every attempt is kept in Plan.Revisions.
+func (e *PlanExecutor) SetReplanner(replanner Replanner, maxReplans int, approve ApproveRevisionFunc) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.replanner = replanner
+	e.maxReplans = maxReplans
+	e.approveRevision = approve
+}
+
+// SetParallelism sets the maximum number of independent steps run at once.
+// Values below 1 run one step at a time.
+func (e *PlanExecutor) SetParallelism(n int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if n < 1 {
+		n = 1
+	}
+	e.parallelism = n
+}
+
+// Execute runs the plan to completion. Each step starts once its
+// dependencies have completed, with up to the configured parallelism of
+// steps running at once. It returns an error if the plan's dependencies
+// are invalid, if execution is cancelled (the plan is then paused), or if
+// a step failed and ContinueOnError is false and the plan could not be
+// revised (see SetReplanner).
 func (e *PlanExecutor) Execute(ctx context.Context) error {
+	for {
+		err := e.execute(ctx)
+		if err == nil || !e.revise(ctx) {
+			return err
+		}
+	}
+}
+
+// execute runs the plan until no more steps can start.
+func (e *PlanExecutor) execute(ctx context.Context) error {
 	e.mu.Lock()
 	if !e.plan.CanExecute() {
+		status := e.plan.Status
 		e.mu.Unlock()
-		return fmt.Errorf("plan cannot be executed in status: %s", e.plan.Status)
+		return fmt.Errorf("plan cannot be executed in status: %s", status)
+	}
+	graph, err := BuildGraph(e.plan.Steps)
+	if err != nil {
+		e.mu.Unlock()
+		return fmt.Errorf("invalid plan: %w", err)
 	}
 
 	// Create new context for this execution to avoid reuse issues
 	execCtx, cancel := context.WithCancel(ctx)
 	e.ctx = execCtx
 	e.cancel = cancel
-	continueOnError := e.continueOnError
-	e.mu.Unlock()
+	parallelism := e.parallelism
 
 	// Mark plan as running
-	e.mu.Lock()
 	e.plan.Start()
 	e.mu.Unlock()
 
-	// Execute steps sequentially
+	results := make(chan stepResult)
+	running := 0
 	for {
 		e.mu.Lock()
-		if e.plan.CurrentStep >= len(e.plan.Steps) {
-			e.mu.Unlock()
-			break
+		skipped := e.skipBlockedSteps(graph)
+		var launch []int
+		if execCtx.Err() == nil {
+			ready := e.readySteps(graph)
+			for _, idx := range ready {
+				if running+len(launch) >= parallelism {
+					break
+				}
+				launch = append(launch, idx)
+			}
+		}
+		for _, idx := range launch {
+			e.startStep(&e.plan.Steps[idx], idx)
 		}
-		currentStepIdx := e.plan.CurrentStep
 		e.mu.Unlock()
 
-		// Check for cancellation
-		select {
-		case <-execCtx.Done():
-			e.mu.Lock()
-			e.plan.Pause()
-			e.mu.Unlock()
-			return fmt.Errorf("execution cancelled")
-		default:
+		for _, idx := range skipped {
+			e.notifyStep(idx)
+		}
+		for _, idx := range launch {
+			running++
+			e.notifyStep(idx)
+			go func(idx int) {
+				results <- stepResult{index: idx, err: e.executeStep(execCtx, &e.plan.Steps[idx])}
+			}(idx)
 		}
 
-		// Execute current step
-		e.mu.Lock()
-		step := &e.plan.Steps[currentStepIdx]
-		e.mu.Unlock()
-
-		if err := e.executeStep(execCtx, step); err != nil {
-			if !continueOnError {
-				e.mu.Lock()
-				e.plan.Fail(err)
-				e.mu.Unlock()
-				return fmt.Errorf("step %d failed: %w", currentStepIdx+1, err)
-			}
-			// Mark step as failed but continue
-			e.mu.Lock()
-			step.Status = StepFailed
-			step.Error = err
-			step.EndTime = time.Now()
-			e.mu.Unlock()
+		if running == 0 {
+			break
 		}
 
-		// Notify progress
-		e.notifyProgress()
+		// Wait for a step to finish before scheduling more
+		result := <-results
+		running--
+		e.finishStep(result.index, result.err)
+		e.notifyStep(result.index)
+	}
 
-		// Move to next step
+	if execCtx.Err() != nil {
 		e.mu.Lock()
-		e.plan.CurrentStep++
+		stopped := e.plan.Status == StatusCancelled || e.plan.FinishedSteps() < len(e.plan.Steps)
+		if stopped {
+			e.plan.Pause()
+		}
 		e.mu.Unlock()
+		if stopped {
+			e.notifyProgress()
+			return fmt.Errorf("execution cancelled")
+		}
 	}
 
-	// Mark plan as complete
-	e.mu.Lock()
-	e.plan.Complete()
-	e.mu.Unlock()
-	e.notifyProgress()
-
-	return nil
+	return e.finishPlan()
 }
 
-// ExecuteNext executes the next step in the plan.
-// Returns true if there are more steps to execute.
+// ExecuteNext executes the next step whose dependencies have completed,
+// taking steps in plan order when several are ready, and leaves the plan
+// paused. Returns true if there are more steps to execute.
 func (e *PlanExecutor) ExecuteNext(ctx context.Context) (bool, error) {
 	e.mu.Lock()
 	if !e.plan.CanExecute() {
+		status := e.plan.Status
 		e.mu.Unlock()
-		return false, fmt.Errorf("plan cannot be executed in status: %s", e.plan.Status)
+		return false, fmt.Errorf("plan cannot be executed in status: %s", status)
+	}
+	graph, err := BuildGraph(e.plan.Steps)
+	if err != nil {
+		e.mu.Unlock()
+		return false, fmt.Errorf("invalid plan: %w", err)
+	}
+
+	skipped := e.skipBlockedSteps(graph)
+	ready := e.readySteps(graph)
+	e.mu.Unlock()
+
+	for _, idx := range skipped {
+		e.notifyStep(idx)
 	}
 
 	// Check if there are more steps
-	if e.plan.CurrentStep >= len(e.plan.Steps) {
-		e.plan.Complete()
-		e.mu.Unlock()
-		e.notifyProgress()
-		return false, nil
+	if len(ready) == 0 {
+		return false, e.finishPlan()
 	}
 
+	e.mu.Lock()
 	// Mark plan as running if not already
 	if e.plan.Status != StatusRunning {
 		e.plan.Start()
@@ -178,47 +293,38 @@ func (e *PlanExecutor) ExecuteNext(ctx context.Context) (bool, error) {
 		e.cancel = cancel
 	}
 
-	currentStepIdx := e.plan.CurrentStep
-	step := &e.plan.Steps[currentStepIdx]
+	idx := ready[0]
+	step := &e.plan.Steps[idx]
+	e.startStep(step, idx)
 	continueOnError := e.continueOnError
 	execCtx := e.ctx
 	e.mu.Unlock()
+	e.notifyStep(idx)
 
-	// Execute current step
-	if err := e.executeStep(execCtx, step); err != nil {
-		if !continueOnError {
-			e.mu.Lock()
-			e.plan.Fail(err)
-			e.mu.Unlock()
-			return false, fmt.Errorf("step %d failed: %w", currentStepIdx+1, err)
-		}
-		// Mark step as failed but continue
-		e.mu.Lock()
-		step.Status = StepFailed
-		step.Error = err
-		step.EndTime = time.Now()
-		e.mu.Unlock()
-	}
+	// Execute the step
+	stepErr := e.executeStep(execCtx, step)
+	e.finishStep(idx, stepErr)
+	e.notifyStep(idx)
 
-	// Notify progress
-	e.notifyProgress()
-
-	// Move to next step
+	// Between steps the plan is paused, so that it can be stepped again,
+	// resumed with Execute, or edited
 	e.mu.Lock()
-	e.plan.CurrentStep++
-
-	// Check if there are more steps
-	hasMore := e.plan.CurrentStep < len(e.plan.Steps)
-	if !hasMore {
-		e.plan.Complete()
+	e.skipBlockedSteps(graph)
+	hasMore := len(e.readySteps(graph)) > 0
+	if hasMore {
+		e.plan.Pause()
 	}
 	e.mu.Unlock()
 
+	var finishErr error
 	if !hasMore {
-		e.notifyProgress()
+		finishErr = e.finishPlan()
 	}
 
-	return hasMore, nil
+	if stepErr != nil && !errors.Is(stepErr, errStepCancelled) && !continueOnError {
+		return hasMore, fmt.Errorf("step %d failed: %w", idx+1, stepErr)
+	}
+	return hasMore, finishErr
 }
 
 // Pause pauses plan execution.
@@ -233,8 +339,9 @@ func (e *PlanExecutor) Pause() {
 func (e *PlanExecutor) Resume(ctx context.Context) error {
 	e.mu.Lock()
 	if !e.plan.CanResume() {
+		status := e.plan.Status
 		e.mu.Unlock()
-		return fmt.Errorf("plan cannot be resumed from status: %s", e.plan.Status)
+		return fmt.Errorf("plan cannot be resumed from status: %s", status)
 	}
 	e.mu.Unlock()
 	return e.Execute(ctx)
@@ -251,15 +358,185 @@ func (e *PlanExecutor) Cancel() {
 	e.notifyProgress()
 }
 
-// executeStep executes a single step in the plan.
-func (e *PlanExecutor) executeStep(ctx context.Context, step *PlanStep) error {
-	// Mark step as running
+// =============================================================================
+// RE-PLANNING
+// =============================================================================
+
+// revise asks the replanner to revise a failed plan and applies the
+// revision if it is approved. It returns true if execution should continue
+// with the revised plan. The attempt is recorded in the plan's history
+// whatever its outcome.
+func (e *PlanExecutor) revise(ctx context.Context) bool {
 	e.mu.Lock()
+	if e.replanner == nil || e.plan.Status != StatusFailed || len(e.plan.Revisions) >= e.maxReplans || ctx.Err() != nil {
+		e.mu.Unlock()
+		return false
+	}
+	replanner, approve, maxReplans := e.replanner, e.approveRevision, e.maxReplans
+	rev := e.plan.NewRevision()
+	e.mu.Unlock()
+
+	e.notify(fmt.Sprintf("Re-planning after %s failed (revision %d/%d)", rev.FailedStep, rev.Number, maxReplans))
+
+	// Nothing runs while the plan is failed, so the replanner may read it
+	steps, err := replanner.Replan(ctx, e.plan)
+	if err == nil {
+		rev.NewSteps = steps
+		if approve == nil || approve(ctx, rev) {
+			rev.Status = RevisionApproved
+		} else {
+			rev.Status = RevisionRejected
+		}
+	}
+
+	e.mu.Lock()
+	if err == nil && rev.Status == RevisionApproved {
+		err = e.plan.ApplyRevision(rev)
+	}
+	if err != nil {
+		rev.Status = RevisionFailed
+		rev.Error = err.Error()
+	}
+	e.plan.Revisions = append(e.plan.Revisions, *rev)
+	e.mu.Unlock()
+
+	status := fmt.Sprintf("Revision %d %s", rev.Number, rev.Status)
+	if rev.Error != "" {
+		status += ": " + rev.Error
+	}
+	e.notify(status)
+
+	return rev.Status == RevisionApproved
+}
+
+// =============================================================================
+// SCHEDULING
+// =============================================================================
+
+// readySteps returns the indices of the pending steps whose dependencies
+// are satisfied, in execution order. A dependency is satisfied once it has
+// completed, or, with ContinueOnError, once it has finished in any way.
+// Callers must hold e.mu.
+func (e *PlanExecutor) readySteps(graph *Graph) []int {
+	var ready []int
+	for _, idx := range graph.Order() {
+		if e.plan.Steps[idx].Status != StepPending {
+			continue
+		}
+		satisfied := true
+		for _, dep := range graph.Dependencies(idx) {
+			status := e.plan.Steps[dep].Status
+			if status != StepComplete && !(e.continueOnError && e.plan.Steps[dep].IsComplete()) {
+				satisfied = false
+				break
+			}
+		}
+		if satisfied {
+			ready = append(ready, idx)
+		}
+	}
+	return ready
+}
+
+// skipBlockedSteps marks as skipped every pending step that depends on a
+// failed or skipped step, unless ContinueOnError is set. Walking in
+// execution order carries skips down the graph in one pass. It returns the
+// indices of the newly skipped steps. Callers must hold e.mu.
+func (e *PlanExecutor) skipBlockedSteps(graph *Graph) []int {
+	if e.continueOnError {
+		return nil
+	}
+	var skipped []int
+	for _, idx := range graph.Order() {
+		step := &e.plan.Steps[idx]
+		if step.Status != StepPending {
+			continue
+		}
+		for _, dep := range graph.Dependencies(idx) {
+			status := e.plan.Steps[dep].Status
+			if status == StepFailed || status == StepSkipped {
+				step.Status = StepSkipped
+				step.Error = fmt.Errorf("skipped: depends on %s, which did not complete", e.plan.Steps[dep].ID)
+				skipped = append(skipped, idx)
+				break
+			}
+		}
+	}
+	return skipped
+}
+
+// startStep marks a step as running and makes it the plan's current step.
+// Callers must hold e.mu.
+func (e *PlanExecutor) startStep(step *PlanStep, idx int) {
 	step.Status = StepRunning
 	step.StartTime = time.Now()
+	step.EndTime = time.Time{}
+	step.Error = nil
+	step.Result = ""
+	e.plan.CurrentStep = idx
+}
+
+// finishStep records the outcome of a step. A step stopped by cancellation
+// goes back to pending so that it runs again when the plan is resumed.
+func (e *PlanExecutor) finishStep(idx int, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	step := &e.plan.Steps[idx]
+	if errors.Is(err, errStepCancelled) {
+		step.Status = StepPending
+		step.Error = nil
+		step.Result = ""
+		step.StartTime = time.Time{}
+		step.EndTime = time.Time{}
+		return
+	}
+	if err != nil {
+		step.Status = StepFailed
+		step.Error = err
+		if step.EndTime.IsZero() {
+			step.EndTime = time.Now()
+		}
+	}
+}
+
+// finishPlan marks the plan complete, or failed if a step failed and
+// ContinueOnError is false, once no more steps can run. A plan cancelled
+// while its last steps ran stays cancelled.
+func (e *PlanExecutor) finishPlan() error {
+	e.mu.Lock()
+	if e.plan.Status == StatusCancelled {
+		e.mu.Unlock()
+		e.notifyProgress()
+		return fmt.Errorf("execution cancelled")
+	}
+	var failErr error
+	if !e.continueOnError {
+		for i := range e.plan.Steps {
+			if e.plan.Steps[i].Status == StepFailed {
+				failErr = fmt.Errorf("step %d failed: %w", i+1, e.plan.Steps[i].Error)
+				break
+			}
+		}
+	}
+	if failErr != nil {
+		e.plan.Fail(failErr)
+	} else {
+		e.plan.Complete()
+	}
 	e.mu.Unlock()
 	e.notifyProgress()
 
+	return failErr
+}
+
+// =============================================================================
+// STEP EXECUTION
+// =============================================================================
+
+// executeStep runs the tool calls of a step that startStep has marked as
+// running. It returns errStepCancelled if ctx is done before the step ends.
+func (e *PlanExecutor) executeStep(ctx context.Context, step *PlanStep) error {
 	// Execute each tool call in the step
 	for i := range step.ToolCalls {
 		toolCall := &step.ToolCalls[i]
@@ -267,12 +544,7 @@ func (e *PlanExecutor) executeStep(ctx context.Context, step *PlanStep) error {
 		// Check for cancellation
 		select {
 		case <-ctx.Done():
-			e.mu.Lock()
-			step.Status = StepFailed
-			step.Error = fmt.Errorf("step cancelled")
-			step.EndTime = time.Now()
-			e.mu.Unlock()
-			return step.Error
+			return errStepCancelled
 		default:
 		}
 
@@ -309,12 +581,15 @@ func (e *PlanExecutor) executeStep(ctx context.Context, step *PlanStep) error {
 		e.mu.Unlock()
 	}
 
+	// Record the files the step touched before marking it complete
+	files := step.hashFiles(e.plan.WorkDir)
+
 	// Mark step as complete
 	e.mu.Lock()
+	step.Files = files
 	step.Status = StepComplete
 	step.EndTime = time.Now()
 	e.mu.Unlock()
-	e.notifyProgress()
 
 	return nil
 }
@@ -326,22 +601,60 @@ func (e *PlanExecutor) executeToolCall(toolCall *ToolCall) (string, error) {
 	return "", fmt.Errorf("tool execution not implemented: %s", toolCall.Name)
 }
 
-// notifyProgress calls the progress callback if set.
+// notifyProgress calls the progress callback if set, describing the plan.
 func (e *PlanExecutor) notifyProgress() {
 	e.mu.RLock()
-	cb := e.onProgress
+	status := fmt.Sprintf("Step %s: %s",
+		e.plan.Progress(),
+		e.plan.CurrentStepDescription())
+	if e.plan.IsComplete() {
+		status = fmt.Sprintf("Plan %s (%s steps)", e.plan.Status, e.plan.Progress())
+	}
 	e.mu.RUnlock()
 
-	if cb != nil {
-		e.mu.RLock()
-		status := fmt.Sprintf("Step %s: %s",
-			e.plan.Progress(),
-			e.plan.CurrentStepDescription())
-		currentStep := e.plan.CurrentStep
-		totalSteps := len(e.plan.Steps)
-		e.mu.RUnlock()
+	e.notify(status)
+}
 
-		cb(currentStep, totalSteps, status)
+// notifyStep calls the progress callback if set, describing a change in the
+// status of step idx.
+func (e *PlanExecutor) notifyStep(idx int) {
+	e.mu.RLock()
+	step := &e.plan.Steps[idx]
+	status := fmt.Sprintf("Step %s: %s %s: %s",
+		e.plan.Progress(),
+		step.ID,
+		step.Status,
+		step.Description)
+	e.mu.RUnlock()
+
+	e.notify(status)
+}
+
+// notify saves the plan and delivers a status to the progress callback, one
+// call at a time. Every step transition is followed by a notify, so the
+// stored plan never lags more than one transition behind.
+func (e *PlanExecutor) notify(status string) {
+	e.notifyMu.Lock()
+	defer e.notifyMu.Unlock()
+
+	e.mu.Lock()
+	cb := e.onProgress
+	finished := e.plan.FinishedSteps()
+	totalSteps := len(e.plan.Steps)
+	store := e.store
+	var rec planRecord
+	if store != nil {
+		e.plan.UpdatedAt = time.Now()
+		rec = toRecord(e.plan)
+	}
+	e.mu.Unlock()
+
+	if store != nil {
+		// A failed save only costs resumability; keep executing
+		_ = store.write(rec)
+	}
+	if cb != nil {
+		cb(finished, totalSteps, status)
 	}
 }
 
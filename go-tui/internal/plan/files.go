@@ -0,0 +1,132 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package plan provides plan creation and execution for multi-step tasks.
+package plan
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// =============================================================================
+// TOUCHED FILES
+// =============================================================================
+
+// pathArguments are the tool call arguments that name a file.
+var pathArguments = []string{"path", "file_path", "file", "filename"}
+
+// TouchedPaths returns the files named by the step's tool calls, made
+// absolute against workDir, sorted and without duplicates.
+func (s *PlanStep) TouchedPaths(workDir string) []string {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, tc := range s.ToolCalls {
+		for _, key := range pathArguments {
+			path, ok := tc.Arguments[key].(string)
+			if !ok || path == "" {
+				continue
+			}
+			if !filepath.IsAbs(path) && workDir != "" {
+				path = filepath.Join(workDir, path)
+			}
+			if abs, err := filepath.Abs(path); err == nil {
+				path = abs
+			}
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// hashFiles returns the current content hash of each file the step's tool
+// calls name, so that a resumed plan can tell whether they changed since.
+func (s *PlanStep) hashFiles(workDir string) map[string]string {
+	paths := s.TouchedPaths(workDir)
+	if len(paths) == 0 {
+		return nil
+	}
+	files := make(map[string]string, len(paths))
+	for _, path := range paths {
+		files[path] = fileHash(path)
+	}
+	return files
+}
+
+// fileHash returns the hex SHA-256 of a file's content, MissingFile if it
+// does not exist, or UnreadableFile if it cannot be read.
+func fileHash(path string) string {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return MissingFile
+	}
+	if err != nil {
+		return UnreadableFile
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Values recorded in PlanStep.Files for files without a content hash.
+const (
+	MissingFile    = "missing"
+	UnreadableFile = "unreadable"
+)
+
+// FileChange is a file touched by a completed step that has changed since
+// the step completed.
+type FileChange struct {
+	// StepID is the step that touched the file
+	StepID string `json:"step_id"`
+
+	// Path is the absolute path of the file
+	Path string `json:"path"`
+
+	// Reason describes the change: "modified", "deleted" or "created"
+	Reason string `json:"reason"`
+}
+
+// ChangedFiles returns the files touched by completed steps whose content
+// differs from when the step completed. A plan should not be resumed over
+// such changes without the user's consent, since later steps may depend on
+// the earlier results.
+func (p *Plan) ChangedFiles() []FileChange {
+	var changes []FileChange
+	for i := range p.Steps {
+		step := &p.Steps[i]
+		if step.Status != StepComplete {
+			continue
+		}
+
+		paths := make([]string, 0, len(step.Files))
+		for path := range step.Files {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		for _, path := range paths {
+			recorded, current := step.Files[path], fileHash(path)
+			if recorded == current {
+				continue
+			}
+			reason := "modified"
+			switch {
+			case current == MissingFile:
+				reason = "deleted"
+			case recorded == MissingFile:
+				reason = "created"
+			}
+			changes = append(changes, FileChange{StepID: step.ID, Path: path, Reason: reason})
+		}
+	}
+	return changes
+}
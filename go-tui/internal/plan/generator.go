@@ -82,6 +82,9 @@ Generate a plan with the following structure:
 3. For each step, include:
    - A clear description of what needs to be done
    - Any tool calls needed (e.g., file operations, code execution)
+   - The numbers of the earlier steps it depends on (1-based). Steps with
+     no dependencies on each other may run in parallel, so only list a step
+     when its output or changes are actually needed
 
 Available tools:
 - read_file: Read content from a file
@@ -97,6 +100,7 @@ Format your response as JSON with this structure:
   "steps": [
     {
       "description": "What this step does",
+      "depends_on": [1],
       "tool_calls": [
         {
           "name": "tool_name",
@@ -108,11 +112,32 @@ Format your response as JSON with this structure:
   ]
 }
 
+Use "depends_on": [] for steps that need nothing else to run first.
+
 Respond with ONLY the JSON, no additional text.`, task)
 }
 
-// parsePlanResponse parses the LLM's JSON response into a Plan.
-func (g *Generator) parsePlanResponse(response string) (*Plan, error) {
+// planResponse is the JSON structure the LLM is asked to produce.
+type planResponse struct {
+	Description string             `json:"description"`
+	Steps       []planResponseStep `json:"steps"`
+}
+
+// planResponseStep is a step in a planResponse. DependsOn holds 1-based
+// step numbers; nil means the model gave no dependencies at all.
+type planResponseStep struct {
+	Description string `json:"description"`
+	DependsOn   *[]int `json:"depends_on"`
+	ToolCalls   []struct {
+		Name        string                 `json:"name"`
+		Arguments   map[string]interface{} `json:"arguments"`
+		Description string                 `json:"description"`
+	} `json:"tool_calls"`
+}
+
+// decodePlanResponse cleans up and decodes an LLM response, checking its
+// size and step count.
+func decodePlanResponse(response string) (*planResponse, error) {
 	// Validate response size to prevent memory issues
 	const maxResponseSize = 1024 * 1024 // 1MB limit
 	if len(response) > maxResponseSize {
@@ -127,18 +152,7 @@ func (g *Generator) parsePlanResponse(response string) (*Plan, error) {
 	response = strings.TrimSpace(response)
 
 	// Parse JSON
-	var planData struct {
-		Description string `json:"description"`
-		Steps       []struct {
-			Description string `json:"description"`
-			ToolCalls   []struct {
-				Name        string                 `json:"name"`
-				Arguments   map[string]interface{} `json:"arguments"`
-				Description string                 `json:"description"`
-			} `json:"tool_calls"`
-		} `json:"steps"`
-	}
-
+	var planData planResponse
 	if err := json.Unmarshal([]byte(response), &planData); err != nil {
 		return nil, fmt.Errorf("failed to parse JSON response: %w", err)
 	}
@@ -153,11 +167,55 @@ func (g *Generator) parsePlanResponse(response string) (*Plan, error) {
 		return nil, fmt.Errorf("plan has too many steps: %d (max: %d)", len(planData.Steps), maxSteps)
 	}
 
+	return &planData, nil
+}
+
+// sequential reports whether a response predates dependency edges: none of
+// its steps has a depends_on. Such steps are kept in order by making each
+// one depend on the step before it.
+func (r *planResponse) sequential() bool {
+	for _, stepData := range r.Steps {
+		if stepData.DependsOn != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// newStep converts a response step to a pending PlanStep without
+// dependencies.
+func (d *planResponseStep) newStep(id string) PlanStep {
+	step := PlanStep{
+		ID:          id,
+		Description: d.Description,
+		Status:      StepPending,
+		Editable:    true,
+		ToolCalls:   make([]ToolCall, 0, len(d.ToolCalls)),
+	}
+	for _, tcData := range d.ToolCalls {
+		step.ToolCalls = append(step.ToolCalls, ToolCall{
+			Name:        tcData.Name,
+			Arguments:   tcData.Arguments,
+			Description: tcData.Description,
+		})
+	}
+	return step
+}
+
+// parsePlanResponse parses the LLM's JSON response into a Plan.
+func (g *Generator) parsePlanResponse(response string) (*Plan, error) {
+	planData, err := decodePlanResponse(response)
+	if err != nil {
+		return nil, err
+	}
+
 	// Validate description
 	if strings.TrimSpace(planData.Description) == "" {
 		return nil, fmt.Errorf("plan description cannot be empty")
 	}
 
+	sequential := planData.sequential()
+
 	// Convert to Plan structure
 	plan := &Plan{
 		Description: planData.Description,
@@ -165,26 +223,27 @@ func (g *Generator) parsePlanResponse(response string) (*Plan, error) {
 	}
 
 	for i, stepData := range planData.Steps {
-		step := PlanStep{
-			ID:          fmt.Sprintf("step-%d", i+1),
-			Description: stepData.Description,
-			Status:      StepPending,
-			Editable:    true,
-			ToolCalls:   make([]ToolCall, 0, len(stepData.ToolCalls)),
-		}
+		step := stepData.newStep(fmt.Sprintf("step-%d", i+1))
 
-		for _, tcData := range stepData.ToolCalls {
-			toolCall := ToolCall{
-				Name:        tcData.Name,
-				Arguments:   tcData.Arguments,
-				Description: tcData.Description,
+		if sequential && i > 0 {
+			step.Dependencies = []string{fmt.Sprintf("step-%d", i)}
+		}
+		if stepData.DependsOn != nil {
+			for _, dep := range *stepData.DependsOn {
+				if dep < 1 || dep > len(planData.Steps) {
+					return nil, fmt.Errorf("step %d depends on step %d, which does not exist", i+1, dep)
+				}
+				step.Dependencies = append(step.Dependencies, fmt.Sprintf("step-%d", dep))
 			}
-			step.ToolCalls = append(step.ToolCalls, toolCall)
 		}
 
 		plan.Steps = append(plan.Steps, step)
 	}
 
+	if err := plan.Validate(); err != nil {
+		return nil, err
+	}
+
 	return plan, nil
 }
 
@@ -217,10 +276,11 @@ func GenerateFromExample(task string) *Plan {
 				},
 			},
 			{
-				ID:          "step-2",
-				Description: "Identify refactoring opportunities",
-				Status:      StepPending,
-				Editable:    true,
+				ID:           "step-2",
+				Description:  "Identify refactoring opportunities",
+				Status:       StepPending,
+				Editable:     true,
+				Dependencies: []string{"step-1"},
 				ToolCalls: []ToolCall{
 					{
 						Name:        "read_file",
@@ -230,10 +290,11 @@ func GenerateFromExample(task string) *Plan {
 				},
 			},
 			{
-				ID:          "step-3",
-				Description: "Create new structure",
-				Status:      StepPending,
-				Editable:    true,
+				ID:           "step-3",
+				Description:  "Create new structure",
+				Status:       StepPending,
+				Editable:     true,
+				Dependencies: []string{"step-2"},
 				ToolCalls: []ToolCall{
 					{
 						Name:        "write_file",
@@ -243,10 +304,11 @@ func GenerateFromExample(task string) *Plan {
 				},
 			},
 			{
-				ID:          "step-4",
-				Description: "Update imports and references",
-				Status:      StepPending,
-				Editable:    true,
+				ID:           "step-4",
+				Description:  "Update imports and references",
+				Status:       StepPending,
+				Editable:     true,
+				Dependencies: []string{"step-3"},
 				ToolCalls: []ToolCall{
 					{
 						Name:        "edit_file",
@@ -256,10 +318,11 @@ func GenerateFromExample(task string) *Plan {
 				},
 			},
 			{
-				ID:          "step-5",
-				Description: "Run tests to verify refactoring",
-				Status:      StepPending,
-				Editable:    true,
+				ID:           "step-5",
+				Description:  "Run tests to verify refactoring",
+				Status:       StepPending,
+				Editable:     true,
+				Dependencies: []string{"step-4"},
 				ToolCalls: []ToolCall{
 					{
 						Name:        "execute_command",
@@ -286,10 +349,11 @@ func GenerateFromExample(task string) *Plan {
 				},
 			},
 			{
-				ID:          "step-2",
-				Description: "Implement the core functionality",
-				Status:      StepPending,
-				Editable:    true,
+				ID:           "step-2",
+				Description:  "Implement the core functionality",
+				Status:       StepPending,
+				Editable:     true,
+				Dependencies: []string{"step-1"},
 				ToolCalls: []ToolCall{
 					{
 						Name:        "write_file",
@@ -299,10 +363,11 @@ func GenerateFromExample(task string) *Plan {
 				},
 			},
 			{
-				ID:          "step-3",
-				Description: "Add tests for the new functionality",
-				Status:      StepPending,
-				Editable:    true,
+				ID:           "step-3",
+				Description:  "Add tests for the new functionality",
+				Status:       StepPending,
+				Editable:     true,
+				Dependencies: []string{"step-1"},
 				ToolCalls: []ToolCall{
 					{
 						Name:        "write_file",
@@ -312,10 +377,11 @@ func GenerateFromExample(task string) *Plan {
 				},
 			},
 			{
-				ID:          "step-4",
-				Description: "Run tests and verify functionality",
-				Status:      StepPending,
-				Editable:    true,
+				ID:           "step-4",
+				Description:  "Run tests and verify functionality",
+				Status:       StepPending,
+				Editable:     true,
+				Dependencies: []string{"step-2", "step-3"},
 				ToolCalls: []ToolCall{
 					{
 						Name:        "execute_command",
@@ -0,0 +1,170 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package plan provides plan creation and execution for multi-step tasks.
+package plan
+
+import (
+	"fmt"
+	"strings"
+)
+
+// =============================================================================
+// DEPENDENCY GRAPH
+// =============================================================================
+
+// Graph is the dependency graph of a plan's steps. Steps are identified by
+// their index in Plan.Steps; an edge runs from each step to the steps named
+// in its Dependencies.
+type Graph struct {
+	// deps[i] are the indices of the steps that step i depends on
+	deps [][]int
+
+	// dependents[i] are the indices of the steps that depend on step i
+	dependents [][]int
+
+	// order is a topological order of the steps, ties broken by plan order
+	order []int
+
+	// depth[i] is the length of the longest dependency chain below step i
+	depth []int
+}
+
+// BuildGraph builds and validates the dependency graph of the given steps.
+// It returns an error if a step has no ID, an ID is used twice, a step
+// depends on itself or on an unknown step, or the dependencies form a cycle.
+func BuildGraph(steps []PlanStep) (*Graph, error) {
+	index := make(map[string]int, len(steps))
+	for i, step := range steps {
+		if step.ID == "" {
+			return nil, fmt.Errorf("step %d has no ID", i+1)
+		}
+		if _, exists := index[step.ID]; exists {
+			return nil, fmt.Errorf("duplicate step ID: %s", step.ID)
+		}
+		index[step.ID] = i
+	}
+
+	g := &Graph{
+		deps:       make([][]int, len(steps)),
+		dependents: make([][]int, len(steps)),
+		depth:      make([]int, len(steps)),
+	}
+	for i, step := range steps {
+		seen := make(map[int]bool, len(step.Dependencies))
+		for _, depID := range step.Dependencies {
+			dep, ok := index[depID]
+			if !ok {
+				return nil, fmt.Errorf("step %s depends on unknown step %q", step.ID, depID)
+			}
+			if dep == i {
+				return nil, fmt.Errorf("step %s depends on itself", step.ID)
+			}
+			if seen[dep] {
+				continue
+			}
+			seen[dep] = true
+			g.deps[i] = append(g.deps[i], dep)
+			g.dependents[dep] = append(g.dependents[dep], i)
+		}
+	}
+
+	// Kahn's algorithm, always taking the earliest ready step so that
+	// independent steps keep the order they were written in
+	remaining := make([]int, len(steps))
+	for i := range steps {
+		remaining[i] = len(g.deps[i])
+	}
+	done := make([]bool, len(steps))
+	g.order = make([]int, 0, len(steps))
+	for len(g.order) < len(steps) {
+		next := -1
+		for i := range steps {
+			if !done[i] && remaining[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			return nil, fmt.Errorf("dependency cycle: %s", g.describeCycle(steps, done))
+		}
+		done[next] = true
+		g.order = append(g.order, next)
+		for _, dep := range g.deps[next] {
+			if g.depth[dep]+1 > g.depth[next] {
+				g.depth[next] = g.depth[dep] + 1
+			}
+		}
+		for _, dependent := range g.dependents[next] {
+			remaining[dependent]--
+		}
+	}
+
+	return g, nil
+}
+
+// describeCycle returns a cycle among the steps not yet ordered, e.g.
+// "step-2 -> step-3 -> step-2". Every such step has an unordered
+// dependency, so following those edges must revisit a step.
+func (g *Graph) describeCycle(steps []PlanStep, ordered []bool) string {
+	start := -1
+	for i := range steps {
+		if !ordered[i] {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return ""
+	}
+
+	position := make(map[int]int)
+	var path []int
+	for cur := start; ; {
+		if at, seen := position[cur]; seen {
+			path = append(path[at:], cur)
+			break
+		}
+		position[cur] = len(path)
+		path = append(path, cur)
+		for _, dep := range g.deps[cur] {
+			if !ordered[dep] {
+				cur = dep
+				break
+			}
+		}
+	}
+
+	ids := make([]string, len(path))
+	for i, idx := range path {
+		ids[i] = steps[idx].ID
+	}
+	return strings.Join(ids, " -> ")
+}
+
+// Dependencies returns the indices of the steps that step i depends on.
+func (g *Graph) Dependencies(i int) []int {
+	return g.deps[i]
+}
+
+// Dependents returns the indices of the steps that depend on step i.
+func (g *Graph) Dependents(i int) []int {
+	return g.dependents[i]
+}
+
+// Order returns the step indices in a valid execution order.
+func (g *Graph) Order() []int {
+	return g.order
+}
+
+// Depth returns the length of the longest dependency chain below step i.
+// Steps without dependencies have depth 0.
+func (g *Graph) Depth(i int) int {
+	return g.depth[i]
+}
+
+// Validate checks that the plan's step dependencies form a valid graph.
+func (p *Plan) Validate() error {
+	_, err := BuildGraph(p.Steps)
+	return err
+}
@@ -0,0 +1,322 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package plan provides plan creation and execution for multi-step tasks.
+package plan_test
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jeranaias/rigrun-tui/internal/plan"
+)
+
+// dagPlan returns an approved plan with the given dependencies, keyed by
+// step ID. Steps listed in failing have a tool call, which fails because the
+// executor has no tool executor; the others have none and complete.
+func dagPlan(t *testing.T, ids []string, deps map[string][]string, failing ...string) *plan.Plan {
+	t.Helper()
+	p := &plan.Plan{ID: "dag", Description: "DAG plan", Status: plan.StatusDraft}
+	for _, id := range ids {
+		step := plan.PlanStep{ID: id, Description: "Run " + id, Dependencies: deps[id]}
+		for _, f := range failing {
+			if f == id {
+				step.ToolCalls = []plan.ToolCall{{Name: "execute_command"}}
+			}
+		}
+		p.Steps = append(p.Steps, step)
+	}
+	if err := p.Approve(); err != nil {
+		t.Fatalf("Failed to approve plan: %v", err)
+	}
+	return p
+}
+
+// TestBuildGraphValidation tests that invalid dependencies are rejected.
+func TestBuildGraphValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		steps   []plan.PlanStep
+		wantErr string
+	}{
+		{
+			name:    "missing ID",
+			steps:   []plan.PlanStep{{ID: "a", Dependencies: []string{"b"}}},
+			wantErr: `unknown step "b"`,
+		},
+		{
+			name:    "self dependency",
+			steps:   []plan.PlanStep{{ID: "a", Dependencies: []string{"a"}}},
+			wantErr: "depends on itself",
+		},
+		{
+			name: "cycle",
+			steps: []plan.PlanStep{
+				{ID: "a"},
+				{ID: "b", Dependencies: []string{"c"}},
+				{ID: "c", Dependencies: []string{"b"}},
+			},
+			wantErr: "dependency cycle: b -> c -> b",
+		},
+		{
+			name:    "duplicate ID",
+			steps:   []plan.PlanStep{{ID: "a"}, {ID: "a"}},
+			wantErr: "duplicate step ID",
+		},
+		{
+			name:    "empty ID",
+			steps:   []plan.PlanStep{{ID: ""}},
+			wantErr: "has no ID",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := plan.BuildGraph(tt.steps)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestBuildGraphOrderAndDepth tests topological order and depth.
+func TestBuildGraphOrderAndDepth(t *testing.T) {
+	g, err := plan.BuildGraph([]plan.PlanStep{
+		{ID: "test", Dependencies: []string{"impl", "docs"}},
+		{ID: "impl", Dependencies: []string{"survey"}},
+		{ID: "survey"},
+		{ID: "docs"},
+	})
+	if err != nil {
+		t.Fatalf("BuildGraph failed: %v", err)
+	}
+
+	order := g.Order()
+	want := []int{2, 1, 3, 0}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("Order() = %v, want %v", order, want)
+		}
+	}
+
+	depths := []int{2, 1, 0, 0}
+	for i, d := range depths {
+		if g.Depth(i) != d {
+			t.Errorf("Depth(%d) = %d, want %d", i, g.Depth(i), d)
+		}
+	}
+}
+
+// TestApproveRejectsCycle tests that a plan with a cycle cannot be approved.
+func TestApproveRejectsCycle(t *testing.T) {
+	p := &plan.Plan{
+		Status: plan.StatusDraft,
+		Steps: []plan.PlanStep{
+			{ID: "a", Dependencies: []string{"b"}},
+			{ID: "b", Dependencies: []string{"a"}},
+		},
+	}
+	if err := p.Approve(); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("Expected cycle error, got: %v", err)
+	}
+}
+
+// TestExecuteHonorsDependencies tests that each step starts after its
+// dependencies finish.
+func TestExecuteHonorsDependencies(t *testing.T) {
+	p := dagPlan(t, []string{"a", "b", "c", "d"}, map[string][]string{
+		"b": {"a"},
+		"c": {"a"},
+		"d": {"b", "c"},
+	})
+
+	executor := plan.NewPlanExecutor(p, nil)
+	executor.SetParallelism(2)
+
+	var mu sync.Mutex
+	var started []string
+	executor.SetProgressCallback(func(step, total int, status string) {
+		if strings.Contains(status, " Running: ") {
+			mu.Lock()
+			started = append(started, strings.Fields(status)[2])
+			mu.Unlock()
+		}
+	})
+
+	if err := executor.Execute(context.Background()); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if p.Status != plan.StatusComplete {
+		t.Errorf("Expected plan Complete, got %s", p.Status)
+	}
+	if p.Progress() != "4/4" {
+		t.Errorf("Expected progress 4/4, got %s", p.Progress())
+	}
+
+	if len(started) != 4 || started[0] != "a" || started[3] != "d" {
+		t.Errorf("Unexpected start order: %v", started)
+	}
+	for _, step := range p.Steps {
+		for _, depID := range step.Dependencies {
+			dep := p.GetStep(depID)
+			if step.StartTime.Before(dep.EndTime) {
+				t.Errorf("Step %s started before its dependency %s finished", step.ID, depID)
+			}
+		}
+	}
+}
+
+// TestExecuteSkipsDependentsOfFailedStep tests that dependents of a failed
+// step are skipped while independent steps still run.
+func TestExecuteSkipsDependentsOfFailedStep(t *testing.T) {
+	p := dagPlan(t, []string{"a", "b", "c", "d"}, map[string][]string{
+		"b": {"a"},
+		"c": {"b"},
+	}, "a")
+
+	executor := plan.NewPlanExecutor(p, nil)
+	err := executor.Execute(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "step 1 failed") {
+		t.Fatalf("Expected step 1 failure, got: %v", err)
+	}
+
+	want := map[string]plan.StepStatus{
+		"a": plan.StepFailed,
+		"b": plan.StepSkipped,
+		"c": plan.StepSkipped,
+		"d": plan.StepComplete,
+	}
+	for id, status := range want {
+		if got := p.GetStep(id).Status; got != status {
+			t.Errorf("Step %s: expected %s, got %s", id, status, got)
+		}
+	}
+	if p.Status != plan.StatusFailed {
+		t.Errorf("Expected plan Failed, got %s", p.Status)
+	}
+}
+
+// TestExecuteContinueOnError tests that dependents of a failed step run
+// when ContinueOnError is set.
+func TestExecuteContinueOnError(t *testing.T) {
+	p := dagPlan(t, []string{"a", "b"}, map[string][]string{"b": {"a"}}, "a")
+
+	executor := plan.NewPlanExecutor(p, nil)
+	executor.SetContinueOnError(true)
+	if err := executor.Execute(context.Background()); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if p.GetStep("a").Status != plan.StepFailed || p.GetStep("b").Status != plan.StepComplete {
+		t.Errorf("Unexpected statuses: a=%s b=%s", p.GetStep("a").Status, p.GetStep("b").Status)
+	}
+	if p.Status != plan.StatusComplete {
+		t.Errorf("Expected plan Complete, got %s", p.Status)
+	}
+}
+
+// TestCancelDuringLastStep tests that a plan cancelled while its last step
+// runs stays cancelled instead of being marked complete.
+func TestCancelDuringLastStep(t *testing.T) {
+	runs := map[string]func(*plan.PlanExecutor) error{
+		"Execute": func(e *plan.PlanExecutor) error { return e.Execute(context.Background()) },
+		"ExecuteNext": func(e *plan.PlanExecutor) error {
+			_, err := e.ExecuteNext(context.Background())
+			return err
+		},
+	}
+	for name, run := range runs {
+		t.Run(name, func(t *testing.T) {
+			p := dagPlan(t, []string{"a"}, nil)
+
+			executor := plan.NewPlanExecutor(p, nil)
+			var cancelled atomic.Bool
+			executor.SetProgressCallback(func(step, total int, status string) {
+				// Cancel reports progress, which waits for this callback, so
+				// it runs on its own goroutine; the step starts once it took
+				if strings.Contains(status, "a Running") && cancelled.CompareAndSwap(false, true) {
+					go executor.Cancel()
+					for !executor.IsComplete() {
+						runtime.Gosched()
+					}
+				}
+			})
+
+			if err := run(executor); err == nil {
+				t.Error("Expected an error from the cancelled plan")
+			}
+			if p.Status != plan.StatusCancelled {
+				t.Errorf("Expected plan Cancelled, got %s", p.Status)
+			}
+		})
+	}
+}
+
+// TestExecuteNextFollowsDependencies tests that ExecuteNext runs the next
+// ready step rather than the next step by index.
+func TestExecuteNextFollowsDependencies(t *testing.T) {
+	p := dagPlan(t, []string{"b", "a"}, map[string][]string{"b": {"a"}})
+
+	executor := plan.NewPlanExecutor(p, nil)
+	ctx := context.Background()
+
+	hasMore, err := executor.ExecuteNext(ctx)
+	if err != nil || !hasMore {
+		t.Fatalf("ExecuteNext = %v, %v; want true, nil", hasMore, err)
+	}
+	if p.GetStep("a").Status != plan.StepComplete || p.GetStep("b").Status != plan.StepPending {
+		t.Errorf("Expected a to run first: a=%s b=%s", p.GetStep("a").Status, p.GetStep("b").Status)
+	}
+
+	hasMore, err = executor.ExecuteNext(ctx)
+	if err != nil || hasMore {
+		t.Fatalf("ExecuteNext = %v, %v; want false, nil", hasMore, err)
+	}
+	if p.Status != plan.StatusComplete {
+		t.Errorf("Expected plan Complete, got %s", p.Status)
+	}
+}
+
+// stubLLM returns a fixed completion.
+type stubLLM string
+
+func (s stubLLM) GenerateCompletion(ctx context.Context, prompt string) (string, error) {
+	return string(s), nil
+}
+
+// TestGeneratorDependencies tests that generated plans carry the model's
+// dependency edges, and are sequential when the model gives none.
+func TestGeneratorDependencies(t *testing.T) {
+	withDeps := stubLLM(`{"description": "d", "steps": [
+		{"description": "one", "depends_on": []},
+		{"description": "two", "depends_on": []},
+		{"description": "three", "depends_on": [1, 2]}]}`)
+	p, err := plan.NewGenerator(withDeps).Generate(context.Background(), "task")
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if len(p.Steps[1].Dependencies) != 0 || strings.Join(p.Steps[2].Dependencies, ",") != "step-1,step-2" {
+		t.Errorf("Unexpected dependencies: %v, %v", p.Steps[1].Dependencies, p.Steps[2].Dependencies)
+	}
+
+	withoutDeps := stubLLM(`{"description": "d", "steps": [{"description": "one"}, {"description": "two"}]}`)
+	p, err = plan.NewGenerator(withoutDeps).Generate(context.Background(), "task")
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if strings.Join(p.Steps[1].Dependencies, ",") != "step-1" {
+		t.Errorf("Expected sequential dependencies, got %v", p.Steps[1].Dependencies)
+	}
+
+	cyclic := stubLLM(`{"description": "d", "steps": [
+		{"description": "one", "depends_on": [2]},
+		{"description": "two", "depends_on": [1]}]}`)
+	if _, err := plan.NewGenerator(cyclic).Generate(context.Background(), "task"); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("Expected cycle error, got: %v", err)
+	}
+}
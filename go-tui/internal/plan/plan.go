@@ -6,6 +6,7 @@ package plan
 
 import (
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -154,6 +155,10 @@ type PlanStep struct {
 
 	// Editable indicates if the user can modify this step
 	Editable bool
+
+	// Files maps the files this step's tool calls name to their content
+	// hash when the step completed (see ChangedFiles)
+	Files map[string]string
 }
 
 // Duration returns how long this step took to execute.
@@ -190,7 +195,8 @@ type Plan struct {
 	// Status is the current status of the plan
 	Status PlanStatus
 
-	// CurrentStep is the index of the step currently being executed
+	// CurrentStep is the index of the step most recently started. Steps
+	// run in dependency order, so earlier steps may still be pending.
 	CurrentStep int
 
 	// CreatedAt is when the plan was created
@@ -202,28 +208,41 @@ type Plan struct {
 	// CompletedAt is when the plan finished (successfully or not)
 	CompletedAt time.Time
 
+	// UpdatedAt is when the plan was last saved to a Store
+	UpdatedAt time.Time
+
+	// WorkDir is the directory relative tool call paths resolve against
+	WorkDir string
+
 	// Error contains any error that caused the plan to fail
 	Error error
 
 	// OriginalTask is the user's original task description
 	OriginalTask string
+
+	// Revisions is the history of re-plans after step failures, oldest
+	// first, including those that were rejected or failed
+	Revisions []Revision
 }
 
-// Progress returns the current progress as a string (e.g., "2/5")
+// Progress returns the current progress as a string (e.g., "2/5"), counting
+// the steps that have finished.
 func (p *Plan) Progress() string {
-	total := len(p.Steps)
-	if total == 0 {
-		return "0/0"
-	}
-	current := p.CurrentStep
-	if current >= total {
-		current = total
-	}
-	return fmt.Sprintf("%d/%d", current, total)
+	return fmt.Sprintf("%d/%d", p.FinishedSteps(), len(p.Steps))
 }
 
-// CurrentStepDescription returns the description of the current step.
+// CurrentStepDescription returns the description of the current step. While
+// several steps are running it describes all of them.
 func (p *Plan) CurrentStepDescription() string {
+	var running []string
+	for i := range p.Steps {
+		if p.Steps[i].Status == StepRunning {
+			running = append(running, p.Steps[i].Description)
+		}
+	}
+	if len(running) > 1 {
+		return strings.Join(running, "; ")
+	}
 	if p.CurrentStep >= 0 && p.CurrentStep < len(p.Steps) {
 		return p.Steps[p.CurrentStep].Description
 	}
@@ -257,6 +276,17 @@ func (p *Plan) CompletedSteps() int {
 	return count
 }
 
+// FinishedSteps returns the number of steps in a terminal state.
+func (p *Plan) FinishedSteps() int {
+	count := 0
+	for i := range p.Steps {
+		if p.Steps[i].IsComplete() {
+			count++
+		}
+	}
+	return count
+}
+
 // FailedSteps returns the number of failed steps.
 func (p *Plan) FailedSteps() int {
 	count := 0
@@ -296,6 +326,9 @@ func (p *Plan) Approve() error {
 	if len(p.Steps) == 0 {
 		return fmt.Errorf("cannot approve plan with no steps")
 	}
+	if err := p.Validate(); err != nil {
+		return fmt.Errorf("cannot approve plan: %w", err)
+	}
 	p.Status = StatusApproved
 	return nil
 }
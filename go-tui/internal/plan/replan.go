@@ -0,0 +1,365 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package plan provides plan creation and execution for multi-step tasks.
+package plan
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jeranaias/rigrun-tui/internal/diff"
+	"github.com/jeranaias/rigrun-tui/internal/ollama"
+	"github.com/jeranaias/rigrun-tui/internal/util"
+)
+
+// =============================================================================
+// REVISION STATUS
+// =============================================================================
+
+// RevisionStatus is the outcome of a proposed plan revision.
+type RevisionStatus int
+
+const (
+	// RevisionProposed - Revision generated, awaiting approval
+	RevisionProposed RevisionStatus = iota
+
+	// RevisionApproved - Revision approved and applied to the plan
+	RevisionApproved
+
+	// RevisionRejected - Revision rejected; the plan stayed failed
+	RevisionRejected
+
+	// RevisionFailed - The model could not produce a valid revision
+	RevisionFailed
+)
+
+// String returns the string representation of a revision status.
+func (s RevisionStatus) String() string {
+	switch s {
+	case RevisionProposed:
+		return "Proposed"
+	case RevisionApproved:
+		return "Approved"
+	case RevisionRejected:
+		return "Rejected"
+	case RevisionFailed:
+		return "Failed"
+	default:
+		return "Unknown"
+	}
+}
+
+// =============================================================================
+// REVISION
+// =============================================================================
+
+// Revision records one attempt to re-plan a failed plan. Every attempt is
+// kept in Plan.Revisions, whether or not it was applied, so that the plan's
+// history can be audited.
+type Revision struct {
+	// Number is the 1-based position of this revision in the plan's history
+	Number int
+
+	// FailedStep is the ID of the step whose failure prompted the revision
+	FailedStep string
+
+	// Reason is the failed step's error
+	Reason string
+
+	// OldSteps are the steps the revision replaces: every step that had not
+	// completed when the plan failed
+	OldSteps []PlanStep
+
+	// NewSteps are the replacement steps proposed by the model
+	NewSteps []PlanStep
+
+	// Status is the outcome of the revision
+	Status RevisionStatus
+
+	// Error is why the model's revision was unusable (RevisionFailed)
+	Error string
+
+	// CreatedAt is when the revision was generated
+	CreatedAt time.Time
+}
+
+// Diff renders the change from the old to the new remaining steps, one line
+// per step description and tool call, prefixed "+", "-" or " ".
+func (r *Revision) Diff() string {
+	d := diff.ComputeDiff("steps", renderSteps(r.OldSteps), renderSteps(r.NewSteps))
+	if len(d.Hunks) == 0 {
+		return "  (no changes)\n"
+	}
+
+	var b strings.Builder
+	for _, hunk := range d.Hunks {
+		for _, line := range hunk.Lines {
+			b.WriteString(line.Type.Prefix())
+			b.WriteString(" ")
+			b.WriteString(line.Content)
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// renderSteps renders steps as text for diffing.
+func renderSteps(steps []PlanStep) string {
+	var b strings.Builder
+	for _, step := range steps {
+		b.WriteString(step.Description)
+		b.WriteString("\n")
+		for _, tc := range step.ToolCalls {
+			fmt.Fprintf(&b, "    -> %s: %s\n", tc.Name, tc.Description)
+		}
+	}
+	return b.String()
+}
+
+// Replanner revises a failed plan. Generator implements it with an LLM.
+type Replanner interface {
+	// Replan returns replacement steps for every step of p that has not
+	// completed. New steps may depend on the completed steps.
+	Replan(ctx context.Context, p *Plan) ([]PlanStep, error)
+}
+
+// ApproveRevisionFunc decides whether a proposed revision is applied. It
+// may block, e.g. on the user; it should return false once ctx is done.
+type ApproveRevisionFunc func(ctx context.Context, rev *Revision) bool
+
+// NewRevision prepares the next revision of a failed plan, recording its
+// first failed step and the steps to be replaced.
+func (p *Plan) NewRevision() *Revision {
+	rev := &Revision{
+		Number:    len(p.Revisions) + 1,
+		Status:    RevisionProposed,
+		CreatedAt: time.Now(),
+	}
+	for i := range p.Steps {
+		step := &p.Steps[i]
+		if step.Status == StepComplete {
+			continue
+		}
+		if step.Status == StepFailed && rev.FailedStep == "" {
+			rev.FailedStep = step.ID
+			rev.Reason = errorString(step.Error)
+		}
+		rev.OldSteps = append(rev.OldSteps, *step)
+	}
+	return rev
+}
+
+// ApplyRevision replaces the plan's unfinished steps with the revision's
+// new steps and pauses the plan so that execution can continue. Completed
+// steps are kept. The revision is not added to the history; callers record
+// it whatever the outcome. A cancelled plan can't be revised.
+func (p *Plan) ApplyRevision(rev *Revision) error {
+	if p.Status == StatusCancelled {
+		return fmt.Errorf("plan was cancelled")
+	}
+	steps := make([]PlanStep, 0, len(p.Steps)+len(rev.NewSteps))
+	for i := range p.Steps {
+		if p.Steps[i].Status == StepComplete {
+			steps = append(steps, p.Steps[i])
+		}
+	}
+	steps = append(steps, rev.NewSteps...)
+	if _, err := BuildGraph(steps); err != nil {
+		return fmt.Errorf("invalid revision: %w", err)
+	}
+
+	p.Steps = steps
+	p.Status = StatusPaused
+	p.Error = nil
+	p.CompletedAt = time.Time{}
+	return nil
+}
+
+// =============================================================================
+// RE-PLANNING WITH AN LLM
+// =============================================================================
+
+// maxReplanResultLen bounds how much of each completed step's result is
+// fed back to the model.
+const maxReplanResultLen = 500
+
+// Replan asks the LLM to revise the unfinished part of a failed plan, given
+// the original task, the completed steps' results and the failure.
+func (g *Generator) Replan(ctx context.Context, p *Plan) ([]PlanStep, error) {
+	if g.llmClient == nil {
+		return nil, fmt.Errorf("LLM client not configured")
+	}
+
+	var completed []*PlanStep
+	for i := range p.Steps {
+		if p.Steps[i].Status == StepComplete {
+			completed = append(completed, &p.Steps[i])
+		}
+	}
+
+	response, err := g.llmClient.GenerateCompletion(ctx, g.buildReplanPrompt(p, completed))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate revision: %w", err)
+	}
+
+	steps, err := parseReplanResponse(response, completed, len(p.Revisions)+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse revision: %w", err)
+	}
+	return steps, nil
+}
+
+// buildReplanPrompt constructs the prompt for revising a failed plan. The
+// completed steps are numbered first so that new steps can depend on them.
+func (g *Generator) buildReplanPrompt(p *Plan, completed []*PlanStep) string {
+	var b strings.Builder
+	task := p.OriginalTask
+	if task == "" {
+		task = p.Description
+	}
+	fmt.Fprintf(&b, `You are a task planning assistant. A plan for the following task failed partway through. Revise the rest of the plan so that the task can still be completed.
+
+Task: %s
+`, task)
+
+	b.WriteString("\nCompleted steps:\n")
+	if len(completed) == 0 {
+		b.WriteString("(none)\n")
+	}
+	for i, step := range completed {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, step.Description)
+		if step.Result != "" {
+			fmt.Fprintf(&b, "   Result: %s\n", util.TruncateRunes(step.Result, maxReplanResultLen))
+		}
+	}
+
+	b.WriteString("\nSteps that did not complete:\n")
+	for i := range p.Steps {
+		step := &p.Steps[i]
+		if step.Status == StepComplete {
+			continue
+		}
+		fmt.Fprintf(&b, "- %s [%s]\n", step.Description, step.Status)
+		if step.Status == StepFailed && step.Error != nil {
+			fmt.Fprintf(&b, "  Error: %s\n", step.Error)
+		}
+	}
+
+	fmt.Fprintf(&b, `
+Generate replacement steps for everything that did not complete, avoiding the cause of the failure. Number the new steps from %d, after the completed steps. A step's "depends_on" lists the numbers of completed or new steps it needs first.
+
+Available tools:
+- read_file: Read content from a file
+- write_file: Write content to a file
+- edit_file: Edit specific parts of a file
+- execute_command: Run a shell command
+- search_files: Search for files matching a pattern
+- search_content: Search file contents using regex
+
+Format your response as JSON with this structure:
+{
+  "description": "What the revision changes",
+  "steps": [
+    {
+      "description": "What this step does",
+      "depends_on": [1],
+      "tool_calls": [
+        {
+          "name": "tool_name",
+          "arguments": {"arg1": "value1"},
+          "description": "Why this tool call is needed"
+        }
+      ]
+    }
+  ]
+}
+
+Respond with ONLY the JSON, no additional text.`, len(completed)+1)
+
+	return b.String()
+}
+
+// parseReplanResponse parses revised steps. Step numbers up to
+// len(completed) refer to the completed steps; later numbers to the new
+// steps, whose IDs are prefixed with the revision number to keep them
+// distinct from the steps they replace.
+func parseReplanResponse(response string, completed []*PlanStep, revision int) ([]PlanStep, error) {
+	planData, err := decodePlanResponse(response)
+	if err != nil {
+		return nil, err
+	}
+
+	newID := func(n int) string {
+		return fmt.Sprintf("r%d-step-%d", revision, n)
+	}
+	total := len(completed) + len(planData.Steps)
+	sequential := planData.sequential()
+
+	steps := make([]PlanStep, 0, len(planData.Steps))
+	for i, stepData := range planData.Steps {
+		num := len(completed) + i + 1
+		step := stepData.newStep(newID(i + 1))
+
+		if sequential && i > 0 {
+			step.Dependencies = []string{newID(i)}
+		}
+		if stepData.DependsOn != nil {
+			for _, dep := range *stepData.DependsOn {
+				switch {
+				case dep < 1 || dep > total:
+					return nil, fmt.Errorf("step %d depends on step %d, which does not exist", num, dep)
+				case dep <= len(completed):
+					step.Dependencies = append(step.Dependencies, completed[dep-1].ID)
+				default:
+					step.Dependencies = append(step.Dependencies, newID(dep-len(completed)))
+				}
+			}
+		}
+
+		steps = append(steps, step)
+	}
+
+	all := make([]PlanStep, 0, total)
+	for _, step := range completed {
+		all = append(all, *step)
+	}
+	if _, err := BuildGraph(append(all, steps...)); err != nil {
+		return nil, err
+	}
+
+	return steps, nil
+}
+
+// =============================================================================
+// OLLAMA LLM CLIENT
+// =============================================================================
+
+// OllamaLLM is an LLMClient backed by a local Ollama model.
+type OllamaLLM struct {
+	client *ollama.Client
+	model  string
+}
+
+// NewOllamaLLM creates an LLMClient that uses model on client, or the
+// client's default model if model is empty.
+func NewOllamaLLM(client *ollama.Client, model string) *OllamaLLM {
+	return &OllamaLLM{client: client, model: model}
+}
+
+// GenerateCompletion sends the prompt as a single user message.
+func (o *OllamaLLM) GenerateCompletion(ctx context.Context, prompt string) (string, error) {
+	model := o.model
+	if model == "" {
+		model = o.client.GetDefaultModel()
+	}
+	resp, err := o.client.ChatWithOptions(ctx, model, []ollama.Message{ollama.NewUserMessage(prompt)}, &ollama.Options{
+		Temperature: 0.2,
+	})
+	if err != nil {
+		return "", err
+	}
+	return resp.Message.Content, nil
+}
@@ -0,0 +1,211 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package plan provides plan creation and execution for multi-step tasks.
+package plan_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jeranaias/rigrun-tui/internal/plan"
+)
+
+// replanFunc adapts a function to plan.Replanner.
+type replanFunc func(ctx context.Context, p *plan.Plan) ([]plan.PlanStep, error)
+
+func (f replanFunc) Replan(ctx context.Context, p *plan.Plan) ([]plan.PlanStep, error) {
+	return f(ctx, p)
+}
+
+// replaceWith returns a replanner proposing the given steps.
+func replaceWith(steps ...plan.PlanStep) replanFunc {
+	return func(ctx context.Context, p *plan.Plan) ([]plan.PlanStep, error) {
+		return steps, nil
+	}
+}
+
+// approveAll approves every revision.
+func approveAll(ctx context.Context, rev *plan.Revision) bool { return true }
+
+// TestReplanApproved tests that an approved revision replaces the
+// unfinished steps and execution continues.
+func TestReplanApproved(t *testing.T) {
+	p := dagPlan(t, []string{"a", "b", "c"}, map[string][]string{"c": {"b"}}, "b")
+
+	executor := plan.NewPlanExecutor(p, nil)
+	executor.SetReplanner(replaceWith(
+		plan.PlanStep{ID: "r1-step-1", Description: "Fix b", Dependencies: []string{"a"}},
+	), 2, approveAll)
+
+	if err := executor.Execute(context.Background()); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if p.Status != plan.StatusComplete || p.Error != nil {
+		t.Errorf("Expected plan Complete, got %s (%v)", p.Status, p.Error)
+	}
+
+	ids := make([]string, 0, len(p.Steps))
+	for _, step := range p.Steps {
+		ids = append(ids, step.ID)
+	}
+	if strings.Join(ids, ",") != "a,r1-step-1" {
+		t.Errorf("Unexpected steps after revision: %v", ids)
+	}
+
+	if len(p.Revisions) != 1 {
+		t.Fatalf("Expected 1 revision, got %d", len(p.Revisions))
+	}
+	rev := p.Revisions[0]
+	if rev.Status != plan.RevisionApproved || rev.FailedStep != "b" || len(rev.OldSteps) != 2 {
+		t.Errorf("Unexpected revision: %+v", rev)
+	}
+	diff := rev.Diff()
+	if !strings.Contains(diff, "- Run b") || !strings.Contains(diff, "+ Fix b") {
+		t.Errorf("Unexpected diff:\n%s", diff)
+	}
+}
+
+// TestReplanRejected tests that a rejected revision leaves the plan failed
+// and is still recorded.
+func TestReplanRejected(t *testing.T) {
+	p := dagPlan(t, []string{"a"}, nil, "a")
+
+	executor := plan.NewPlanExecutor(p, nil)
+	executor.SetReplanner(replaceWith(plan.PlanStep{ID: "r1-step-1", Description: "Retry"}), 2,
+		func(ctx context.Context, rev *plan.Revision) bool { return false })
+
+	if err := executor.Execute(context.Background()); err == nil {
+		t.Fatal("Expected failure after rejected revision")
+	}
+	if p.Status != plan.StatusFailed || p.Steps[0].ID != "a" {
+		t.Errorf("Expected unchanged failed plan, got %s with %s", p.Status, p.Steps[0].ID)
+	}
+	if len(p.Revisions) != 1 || p.Revisions[0].Status != plan.RevisionRejected {
+		t.Errorf("Expected a rejected revision, got %+v", p.Revisions)
+	}
+}
+
+// TestApplyRevisionAfterCancel tests that a cancelled plan is not moved back
+// to paused by a revision approved after the cancel.
+func TestApplyRevisionAfterCancel(t *testing.T) {
+	p := dagPlan(t, []string{"a"}, nil, "a")
+	if err := plan.NewPlanExecutor(p, nil).Execute(context.Background()); err == nil {
+		t.Fatal("Expected the plan to fail")
+	}
+
+	rev := p.NewRevision()
+	rev.NewSteps = []plan.PlanStep{{ID: "r1-step-1", Description: "Retry"}}
+	p.Cancel()
+	if err := p.ApplyRevision(rev); err == nil {
+		t.Error("Expected ApplyRevision to refuse a cancelled plan")
+	}
+	if p.Status != plan.StatusCancelled || p.Steps[0].ID != "a" {
+		t.Errorf("Expected unchanged cancelled plan, got %s with %s", p.Status, p.Steps[0].ID)
+	}
+}
+
+// TestReplanCap tests that re-planning stops at the cap, counting earlier
+// revisions, and that replanner errors are recorded.
+func TestReplanCap(t *testing.T) {
+	p := dagPlan(t, []string{"a"}, nil, "a")
+
+	n := 0
+	failing := replanFunc(func(ctx context.Context, p *plan.Plan) ([]plan.PlanStep, error) {
+		n++
+		// Each revision fails again: its step has a tool call
+		return []plan.PlanStep{{
+			ID:        fmt.Sprintf("retry-%d", n),
+			ToolCalls: []plan.ToolCall{{Name: "execute_command"}},
+		}}, nil
+	})
+
+	executor := plan.NewPlanExecutor(p, nil)
+	executor.SetReplanner(failing, 2, nil)
+	if err := executor.Execute(context.Background()); err == nil {
+		t.Fatal("Expected failure once the cap is reached")
+	}
+	if n != 2 || len(p.Revisions) != 2 {
+		t.Errorf("Expected 2 revisions, got %d calls and %d revisions", n, len(p.Revisions))
+	}
+
+	// The cap covers the plan's whole history
+	p.PrepareResume()
+	p.Status = plan.StatusPaused
+	executor.SetReplanner(failing, 2, nil)
+	_ = executor.Execute(context.Background())
+	if n != 2 {
+		t.Errorf("Expected no more revisions past the cap, got %d calls", n)
+	}
+
+	// Replanner errors end re-planning and are kept in the history
+	p2 := dagPlan(t, []string{"a"}, nil, "a")
+	executor = plan.NewPlanExecutor(p2, nil)
+	executor.SetReplanner(replanFunc(func(ctx context.Context, p *plan.Plan) ([]plan.PlanStep, error) {
+		return nil, errors.New("model unavailable")
+	}), 3, nil)
+	_ = executor.Execute(context.Background())
+	if len(p2.Revisions) != 1 || p2.Revisions[0].Status != plan.RevisionFailed || p2.Revisions[0].Error != "model unavailable" {
+		t.Errorf("Expected a failed revision, got %+v", p2.Revisions)
+	}
+}
+
+// TestGeneratorReplan tests that revised steps can depend on completed
+// steps and are validated.
+func TestGeneratorReplan(t *testing.T) {
+	p := &plan.Plan{
+		OriginalTask: "task",
+		Steps: []plan.PlanStep{
+			{ID: "step-1", Description: "survey", Status: plan.StepComplete, Result: "found it"},
+			{ID: "step-2", Description: "build", Status: plan.StepFailed, Error: errors.New("compile error")},
+		},
+	}
+
+	llm := stubLLM(`{"description": "d", "steps": [
+		{"description": "fix build", "depends_on": [1]},
+		{"description": "test", "depends_on": [1, 2]}]}`)
+	steps, err := plan.NewGenerator(llm).Replan(context.Background(), p)
+	if err != nil {
+		t.Fatalf("Replan failed: %v", err)
+	}
+	if len(steps) != 2 || steps[0].ID != "r1-step-1" ||
+		strings.Join(steps[1].Dependencies, ",") != "step-1,r1-step-1" {
+		t.Errorf("Unexpected revised steps: %+v", steps)
+	}
+
+	outOfRange := stubLLM(`{"description": "d", "steps": [{"description": "x", "depends_on": [3]}]}`)
+	if _, err := plan.NewGenerator(outOfRange).Replan(context.Background(), p); err == nil {
+		t.Error("Expected error for dependency on a missing step")
+	}
+}
+
+// TestStoreKeepsRevisions tests that the revision history survives a save.
+func TestStoreKeepsRevisions(t *testing.T) {
+	store := newTestStore(t)
+	p := &plan.Plan{ID: "revised", Status: plan.StatusComplete, Revisions: []plan.Revision{{
+		Number:     1,
+		FailedStep: "b",
+		Reason:     "boom",
+		Status:     plan.RevisionApproved,
+		OldSteps:   []plan.PlanStep{{ID: "b", Status: plan.StepFailed, Error: errors.New("boom")}},
+		NewSteps:   []plan.PlanStep{{ID: "r1-step-1", Description: "Fix b"}},
+	}}}
+	if err := store.Save(p); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := store.Load("revised")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(loaded.Revisions) != 1 {
+		t.Fatalf("Expected 1 revision, got %d", len(loaded.Revisions))
+	}
+	rev := loaded.Revisions[0]
+	if rev.Status != plan.RevisionApproved || rev.OldSteps[0].Error.Error() != "boom" || rev.NewSteps[0].Description != "Fix b" {
+		t.Errorf("Revision not restored: %+v", rev)
+	}
+}
@@ -0,0 +1,597 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package plan provides plan creation and execution for multi-step tasks.
+package plan
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jeranaias/rigrun-tui/internal/security"
+	"github.com/jeranaias/rigrun-tui/internal/util"
+)
+
+// =============================================================================
+// PLAN STORE
+// =============================================================================
+
+// Store persists plans to disk, one JSON file per plan, so that a plan
+// interrupted by a crash or quit can be resumed later. Plans hold the task
+// and the step results, so they are encrypted with the data cipher when one
+// is set (SC-28).
+type Store struct {
+	dir    string
+	cipher security.FieldCipher // Seals plan files; nil writes plaintext
+}
+
+// ErrPlanNotFound is returned when no stored plan matches an ID.
+var ErrPlanNotFound = errors.New("plan not found")
+
+// NewStore creates a plan store in dir, defaulting to ~/.rigrun/plans.
+func NewStore(dir string) (*Store, error) {
+	if dir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = filepath.Join(homeDir, ".rigrun", "plans")
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	return &Store{dir: dir, cipher: security.DataCipher()}, nil
+}
+
+// Dir returns the directory the store writes to.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Save writes the plan to disk atomically and stamps its UpdatedAt.
+func (s *Store) Save(p *Plan) error {
+	if p == nil {
+		return nil
+	}
+	p.UpdatedAt = time.Now()
+	return s.write(toRecord(p))
+}
+
+// write stores a plan record. It does not touch the plan itself, so the
+// executor can snapshot a plan under its lock and write it outside.
+func (s *Store) write(rec planRecord) error {
+	if !validPlanID(rec.ID) {
+		return fmt.Errorf("invalid plan ID: %q", rec.ID)
+	}
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return err
+	}
+	sealed, err := security.SealField(s.cipher, string(data))
+	if err != nil {
+		return err
+	}
+
+	return util.AtomicWriteFile(s.path(rec.ID), []byte(sealed), 0600)
+}
+
+// readFile reads a plan file written by write.
+func (s *Store) readFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	content, err := security.OpenField(s.cipher, string(data))
+	if err != nil {
+		return nil, err
+	}
+	return []byte(content), nil
+}
+
+// Load reads a plan by ID. A unique prefix of the ID is accepted, as the
+// CLI shows plan IDs shortened.
+func (s *Store) Load(id string) (*Plan, error) {
+	if !validPlanID(id) {
+		return nil, fmt.Errorf("invalid plan ID: %q", id)
+	}
+
+	data, err := s.readFile(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		fullID, resolveErr := s.resolvePrefix(id)
+		if resolveErr != nil {
+			return nil, resolveErr
+		}
+		data, err = s.readFile(s.path(fullID))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return decodePlan(data)
+}
+
+// List returns every stored plan, most recently updated first. Files that
+// cannot be read or parsed are skipped.
+func (s *Store) List() ([]*Plan, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var plans []*Plan
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		data, err := s.readFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		p, err := decodePlan(data)
+		if err != nil {
+			continue
+		}
+		plans = append(plans, p)
+	}
+
+	sort.Slice(plans, func(i, j int) bool {
+		return plans[i].UpdatedAt.After(plans[j].UpdatedAt)
+	})
+	return plans, nil
+}
+
+// Interrupted returns the stored plans that were running or paused when
+// last saved, most recently updated first.
+func (s *Store) Interrupted() ([]*Plan, error) {
+	plans, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var interrupted []*Plan
+	for _, p := range plans {
+		if p.Status == StatusRunning || p.Status == StatusPaused {
+			interrupted = append(interrupted, p)
+		}
+	}
+	return interrupted, nil
+}
+
+// Delete removes a stored plan.
+func (s *Store) Delete(id string) error {
+	if !validPlanID(id) {
+		return fmt.Errorf("invalid plan ID: %q", id)
+	}
+	err := os.Remove(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Compile-time check that Store is a protected store.
+var _ security.ProtectedStore = (*Store)(nil)
+
+// planFiles returns the paths of the stored plan files.
+func (s *Store) planFiles() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var paths []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			paths = append(paths, filepath.Join(s.dir, entry.Name()))
+		}
+	}
+	return paths, nil
+}
+
+// EncryptionStatus counts the plan files and how many are encrypted.
+func (s *Store) EncryptionStatus() (security.StoreStatus, error) {
+	status := security.StoreStatus{Name: "Plans", Path: s.dir}
+	files, err := s.planFiles()
+	if err != nil {
+		return status, err
+	}
+	for _, path := range files {
+		sealed, err := security.IsFileSealed(path)
+		if err != nil {
+			return status, err
+		}
+		status.Items++
+		if sealed {
+			status.Encrypted++
+		}
+	}
+	return status, nil
+}
+
+// Reencrypt replaces each plan file with recrypt's result.
+func (s *Store) Reencrypt(recrypt security.Recrypt) error {
+	files, err := s.planFiles()
+	if err != nil {
+		return err
+	}
+	for _, path := range files {
+		if err := security.ReencryptFile(path, recrypt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Compile-time check that Store is a retained store.
+var _ security.RetainedStore = (*Store)(nil)
+
+// RetentionClass returns the data class of stored plans, which are the
+// checkpoints interrupted plans resume from.
+func (s *Store) RetentionClass() string {
+	return security.RetentionCheckpoints
+}
+
+// RetentionItems lists the stored plan files, leaving out plans that have
+// not finished, which may still be resumed.
+func (s *Store) RetentionItems() ([]security.RetentionItem, error) {
+	items, err := security.RetentionFiles(s.dir, func(name string) bool {
+		return strings.HasSuffix(name, ".json")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.finishedItems(items), nil
+}
+
+// Purge securely deletes stored plans. A plan that has not finished is
+// kept, even if listed, as it may have been resumed since.
+func (s *Store) Purge(items []security.RetentionItem) error {
+	return security.PurgeFiles(s.dir, s.finishedItems(items))
+}
+
+// finishedItems returns the items whose plan is complete, failed or
+// cancelled. A file that cannot be read or parsed is never listed, so it
+// cannot be resumed either and counts as finished.
+func (s *Store) finishedItems(items []security.RetentionItem) []security.RetentionItem {
+	var finished []security.RetentionItem
+	for _, item := range items {
+		data, err := s.readFile(filepath.Join(s.dir, filepath.Base(item.ID)))
+		if err == nil {
+			if p, err := decodePlan(data); err == nil &&
+				p.Status != StatusComplete && p.Status != StatusFailed && p.Status != StatusCancelled {
+				continue
+			}
+		}
+		finished = append(finished, item)
+	}
+	return finished
+}
+
+// path returns the file a plan is stored in.
+func (s *Store) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+// resolvePrefix returns the ID of the only stored plan starting with prefix.
+func (s *Store) resolvePrefix(prefix string) (string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return "", err
+	}
+
+	var matches []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasSuffix(name, ".json") && strings.HasPrefix(name, prefix) {
+			matches = append(matches, strings.TrimSuffix(name, ".json"))
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("%w: %s", ErrPlanNotFound, prefix)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("plan ID %q is ambiguous (%d matches)", prefix, len(matches))
+	}
+}
+
+// validPlanID reports whether id is safe to use as a file name.
+func validPlanID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, r := range id {
+		isAlnum := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+		if !isAlnum && r != '-' && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// =============================================================================
+// PREPARE RESUME
+// =============================================================================
+
+// PrepareResume readies a plan loaded after an interruption for execution.
+// Steps that were running when the process stopped never finished, so they
+// go back to pending and run again; a plan left running becomes paused.
+func (p *Plan) PrepareResume() {
+	for i := range p.Steps {
+		step := &p.Steps[i]
+		if step.Status == StepRunning {
+			step.Status = StepPending
+			step.Result = ""
+			step.Error = nil
+			step.StartTime = time.Time{}
+			step.EndTime = time.Time{}
+		}
+	}
+	if p.Status == StatusRunning {
+		p.Status = StatusPaused
+	}
+}
+
+// =============================================================================
+// SERIALIZATION
+// =============================================================================
+
+// planRecord is the on-disk form of a Plan. Errors are stored as their
+// messages and statuses by name, so that files stay readable and survive
+// reordering of the status constants.
+type planRecord struct {
+	ID           string           `json:"id"`
+	Description  string           `json:"description"`
+	OriginalTask string           `json:"original_task,omitempty"`
+	Status       string           `json:"status"`
+	CurrentStep  int              `json:"current_step"`
+	WorkDir      string           `json:"work_dir,omitempty"`
+	Error        string           `json:"error,omitempty"`
+	CreatedAt    time.Time        `json:"created_at"`
+	StartedAt    time.Time        `json:"started_at,omitempty"`
+	CompletedAt  time.Time        `json:"completed_at,omitempty"`
+	UpdatedAt    time.Time        `json:"updated_at"`
+	Steps        []stepRecord     `json:"steps"`
+	Revisions    []revisionRecord `json:"revisions,omitempty"`
+}
+
+// stepRecord is the on-disk form of a PlanStep.
+type stepRecord struct {
+	ID           string            `json:"id"`
+	Description  string            `json:"description"`
+	Status       string            `json:"status"`
+	Dependencies []string          `json:"dependencies,omitempty"`
+	ToolCalls    []toolCallRecord  `json:"tool_calls,omitempty"`
+	Result       string            `json:"result,omitempty"`
+	Error        string            `json:"error,omitempty"`
+	StartTime    time.Time         `json:"start_time,omitempty"`
+	EndTime      time.Time         `json:"end_time,omitempty"`
+	Files        map[string]string `json:"files,omitempty"`
+	Editable     bool              `json:"editable"`
+}
+
+// revisionRecord is the on-disk form of a Revision.
+type revisionRecord struct {
+	Number     int          `json:"number"`
+	FailedStep string       `json:"failed_step"`
+	Reason     string       `json:"reason,omitempty"`
+	Status     string       `json:"status"`
+	Error      string       `json:"error,omitempty"`
+	CreatedAt  time.Time    `json:"created_at"`
+	OldSteps   []stepRecord `json:"old_steps"`
+	NewSteps   []stepRecord `json:"new_steps,omitempty"`
+}
+
+// toolCallRecord is the on-disk form of a ToolCall.
+type toolCallRecord struct {
+	Name        string                 `json:"name"`
+	Arguments   map[string]interface{} `json:"arguments,omitempty"`
+	Description string                 `json:"description,omitempty"`
+}
+
+// toRecord converts a plan to its on-disk form.
+func toRecord(p *Plan) planRecord {
+	rec := planRecord{
+		ID:           p.ID,
+		Description:  p.Description,
+		OriginalTask: p.OriginalTask,
+		Status:       p.Status.String(),
+		CurrentStep:  p.CurrentStep,
+		WorkDir:      p.WorkDir,
+		Error:        errorString(p.Error),
+		CreatedAt:    p.CreatedAt,
+		StartedAt:    p.StartedAt,
+		CompletedAt:  p.CompletedAt,
+		UpdatedAt:    p.UpdatedAt,
+		Steps:        make([]stepRecord, 0, len(p.Steps)),
+	}
+
+	rec.Steps = toStepRecords(p.Steps)
+	for _, rev := range p.Revisions {
+		rec.Revisions = append(rec.Revisions, revisionRecord{
+			Number:     rev.Number,
+			FailedStep: rev.FailedStep,
+			Reason:     rev.Reason,
+			Status:     rev.Status.String(),
+			Error:      rev.Error,
+			CreatedAt:  rev.CreatedAt,
+			OldSteps:   toStepRecords(rev.OldSteps),
+			NewSteps:   toStepRecords(rev.NewSteps),
+		})
+	}
+
+	return rec
+}
+
+// toStepRecords converts steps to their on-disk form.
+func toStepRecords(steps []PlanStep) []stepRecord {
+	records := make([]stepRecord, 0, len(steps))
+	for _, step := range steps {
+		sr := stepRecord{
+			ID:           step.ID,
+			Description:  step.Description,
+			Status:       step.Status.String(),
+			Dependencies: step.Dependencies,
+			Result:       step.Result,
+			Error:        errorString(step.Error),
+			StartTime:    step.StartTime,
+			EndTime:      step.EndTime,
+			Files:        step.Files,
+			Editable:     step.Editable,
+		}
+		for _, tc := range step.ToolCalls {
+			sr.ToolCalls = append(sr.ToolCalls, toolCallRecord{
+				Name:        tc.Name,
+				Arguments:   tc.Arguments,
+				Description: tc.Description,
+			})
+		}
+		records = append(records, sr)
+	}
+	return records
+}
+
+// decodePlan parses a plan from its on-disk form.
+func decodePlan(data []byte) (*Plan, error) {
+	var rec planRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("failed to parse plan: %w", err)
+	}
+
+	status, err := parsePlanStatus(rec.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Plan{
+		ID:           rec.ID,
+		Description:  rec.Description,
+		OriginalTask: rec.OriginalTask,
+		Status:       status,
+		CurrentStep:  rec.CurrentStep,
+		WorkDir:      rec.WorkDir,
+		Error:        stringError(rec.Error),
+		CreatedAt:    rec.CreatedAt,
+		StartedAt:    rec.StartedAt,
+		CompletedAt:  rec.CompletedAt,
+		UpdatedAt:    rec.UpdatedAt,
+	}
+
+	if p.Steps, err = fromStepRecords(rec.Steps); err != nil {
+		return nil, err
+	}
+	for _, rr := range rec.Revisions {
+		rev := Revision{
+			Number:     rr.Number,
+			FailedStep: rr.FailedStep,
+			Reason:     rr.Reason,
+			Error:      rr.Error,
+			CreatedAt:  rr.CreatedAt,
+		}
+		if rev.Status, err = parseRevisionStatus(rr.Status); err != nil {
+			return nil, err
+		}
+		if rev.OldSteps, err = fromStepRecords(rr.OldSteps); err != nil {
+			return nil, fmt.Errorf("revision %d: %w", rr.Number, err)
+		}
+		if rev.NewSteps, err = fromStepRecords(rr.NewSteps); err != nil {
+			return nil, fmt.Errorf("revision %d: %w", rr.Number, err)
+		}
+		p.Revisions = append(p.Revisions, rev)
+	}
+
+	return p, nil
+}
+
+// fromStepRecords parses steps from their on-disk form.
+func fromStepRecords(records []stepRecord) ([]PlanStep, error) {
+	steps := make([]PlanStep, 0, len(records))
+	for _, sr := range records {
+		stepStatus, err := parseStepStatus(sr.Status)
+		if err != nil {
+			return nil, fmt.Errorf("step %s: %w", sr.ID, err)
+		}
+		step := PlanStep{
+			ID:           sr.ID,
+			Description:  sr.Description,
+			Status:       stepStatus,
+			Dependencies: sr.Dependencies,
+			Result:       sr.Result,
+			Error:        stringError(sr.Error),
+			StartTime:    sr.StartTime,
+			EndTime:      sr.EndTime,
+			Files:        sr.Files,
+			Editable:     sr.Editable,
+		}
+		for _, tc := range sr.ToolCalls {
+			step.ToolCalls = append(step.ToolCalls, ToolCall{
+				Name:        tc.Name,
+				Arguments:   tc.Arguments,
+				Description: tc.Description,
+			})
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// parsePlanStatus parses the name of a plan status.
+func parsePlanStatus(s string) (PlanStatus, error) {
+	for status := StatusDraft; status <= StatusCancelled; status++ {
+		if status.String() == s {
+			return status, nil
+		}
+	}
+	return StatusDraft, fmt.Errorf("unknown plan status: %q", s)
+}
+
+// parseStepStatus parses the name of a step status.
+func parseStepStatus(s string) (StepStatus, error) {
+	for status := StepPending; status <= StepSkipped; status++ {
+		if status.String() == s {
+			return status, nil
+		}
+	}
+	return StepPending, fmt.Errorf("unknown step status: %q", s)
+}
+
+// parseRevisionStatus parses the name of a revision status.
+func parseRevisionStatus(s string) (RevisionStatus, error) {
+	for status := RevisionProposed; status <= RevisionFailed; status++ {
+		if status.String() == s {
+			return status, nil
+		}
+	}
+	return RevisionProposed, fmt.Errorf("unknown revision status: %q", s)
+}
+
+// errorString returns an error's message, or "" for nil.
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// stringError returns an error with the given message, or nil for "".
+func stringError(msg string) error {
+	if msg == "" {
+		return nil
+	}
+	return errors.New(msg)
+}
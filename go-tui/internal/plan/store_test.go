@@ -0,0 +1,317 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package plan provides plan creation and execution for multi-step tasks.
+package plan_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeranaias/rigrun-tui/internal/plan"
+	"github.com/jeranaias/rigrun-tui/internal/security"
+	"github.com/jeranaias/rigrun-tui/internal/security/securitytest"
+)
+
+// newTestStore returns a store in a temporary directory.
+func newTestStore(t *testing.T) *plan.Store {
+	t.Helper()
+	store, err := plan.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	return store
+}
+
+// TestStoreRoundTrip tests that a saved plan loads back with its statuses,
+// errors and dependencies, including by ID prefix.
+func TestStoreRoundTrip(t *testing.T) {
+	store := newTestStore(t)
+	p := &plan.Plan{
+		ID:          "3f2a9c10-round-trip",
+		Description: "Round trip",
+		Status:      plan.StatusFailed,
+		Error:       errors.New("step 2 failed"),
+		Steps: []plan.PlanStep{
+			{ID: "step-1", Status: plan.StepComplete, Result: "ok", Files: map[string]string{"/tmp/a": plan.MissingFile}},
+			{ID: "step-2", Status: plan.StepFailed, Error: errors.New("boom"), Dependencies: []string{"step-1"},
+				ToolCalls: []plan.ToolCall{{Name: "write_file", Arguments: map[string]interface{}{"path": "a.go"}}}},
+		},
+	}
+	if err := store.Save(p); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := store.Load("3f2a")
+	if err != nil {
+		t.Fatalf("Load by prefix failed: %v", err)
+	}
+	if loaded.Status != plan.StatusFailed || loaded.Error == nil || loaded.Error.Error() != "step 2 failed" {
+		t.Errorf("Plan status/error not restored: %s, %v", loaded.Status, loaded.Error)
+	}
+	step := loaded.GetStep("step-2")
+	if step.Status != plan.StepFailed || step.Error.Error() != "boom" || step.Dependencies[0] != "step-1" {
+		t.Errorf("Step not restored: %+v", step)
+	}
+	if step.ToolCalls[0].Arguments["path"] != "a.go" {
+		t.Errorf("Tool call arguments not restored: %v", step.ToolCalls[0].Arguments)
+	}
+	if loaded.GetStep("step-1").Files["/tmp/a"] != plan.MissingFile {
+		t.Errorf("Step files not restored: %v", loaded.GetStep("step-1").Files)
+	}
+	if loaded.UpdatedAt.IsZero() {
+		t.Error("Expected UpdatedAt to be set")
+	}
+
+	if _, err := store.Load("ffff"); !errors.Is(err, plan.ErrPlanNotFound) {
+		t.Errorf("Expected ErrPlanNotFound, got: %v", err)
+	}
+	if _, err := store.Load("../escape"); err == nil {
+		t.Error("Expected invalid ID to be rejected")
+	}
+}
+
+// TestStoreInterrupted tests that only running and paused plans are offered
+// for resume, and that PrepareResume reruns steps cut off mid-run.
+func TestStoreInterrupted(t *testing.T) {
+	store := newTestStore(t)
+	for id, status := range map[string]plan.PlanStatus{
+		"running":  plan.StatusRunning,
+		"paused":   plan.StatusPaused,
+		"complete": plan.StatusComplete,
+	} {
+		p := &plan.Plan{ID: id, Status: status, Steps: []plan.PlanStep{
+			{ID: "a", Status: plan.StepComplete},
+			{ID: "b", Status: plan.StepRunning, Dependencies: []string{"a"}},
+		}}
+		if err := store.Save(p); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+
+	plans, err := store.Interrupted()
+	if err != nil {
+		t.Fatalf("Interrupted failed: %v", err)
+	}
+	if len(plans) != 2 {
+		t.Fatalf("Expected 2 interrupted plans, got %d", len(plans))
+	}
+
+	p, err := store.Load("running")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	p.PrepareResume()
+	if p.Status != plan.StatusPaused || p.GetStep("b").Status != plan.StepPending {
+		t.Errorf("Unexpected state after PrepareResume: plan=%s b=%s", p.Status, p.GetStep("b").Status)
+	}
+
+	if err := plan.NewPlanExecutor(p, nil).Execute(context.Background()); err != nil {
+		t.Fatalf("Execute of resumed plan failed: %v", err)
+	}
+	if p.Status != plan.StatusComplete || p.Progress() != "2/2" {
+		t.Errorf("Expected resumed plan to complete, got %s %s", p.Status, p.Progress())
+	}
+}
+
+// TestExecutorSavesPlan tests that the executor persists the plan as it runs.
+func TestExecutorSavesPlan(t *testing.T) {
+	store := newTestStore(t)
+	p := dagPlan(t, []string{"a", "b"}, map[string][]string{"b": {"a"}})
+
+	executor := plan.NewPlanExecutor(p, nil)
+	executor.SetStore(store)
+
+	saves := 0
+	executor.SetProgressCallback(func(step, total int, status string) {
+		if _, err := store.Load(p.ID); err != nil {
+			t.Errorf("Plan not saved before progress callback: %v", err)
+		}
+		saves++
+	})
+	if err := executor.Execute(context.Background()); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	loaded, err := store.Load(p.ID)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded.Status != plan.StatusComplete || loaded.GetStep("b").Status != plan.StepComplete {
+		t.Errorf("Saved plan is stale: %s, b=%s", loaded.Status, loaded.GetStep("b").Status)
+	}
+	if loaded.WorkDir == "" {
+		t.Error("Expected WorkDir to be recorded")
+	}
+	if saves < 5 {
+		t.Errorf("Expected a save per transition, got %d", saves)
+	}
+}
+
+// TestChangedFiles tests that files touched by completed steps are checked
+// against their recorded content.
+func TestChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	kept := filepath.Join(dir, "kept.go")
+	edited := filepath.Join(dir, "edited.go")
+	removed := filepath.Join(dir, "removed.go")
+	created := filepath.Join(dir, "created.go")
+	for _, path := range []string{kept, edited, removed} {
+		if err := os.WriteFile(path, []byte("package a\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sum := sha256.Sum256([]byte("package a\n"))
+	hash := hex.EncodeToString(sum[:])
+
+	p := &plan.Plan{Steps: []plan.PlanStep{
+		{ID: "done", Status: plan.StepComplete, Files: map[string]string{
+			kept: hash, edited: hash, removed: hash, created: plan.MissingFile,
+		}},
+		{ID: "pending", Status: plan.StepPending, Files: map[string]string{kept: "stale"}},
+	}}
+
+	if err := os.WriteFile(edited, []byte("package b\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(removed); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(created, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{edited: "modified", removed: "deleted", created: "created"}
+	changes := p.ChangedFiles()
+	if len(changes) != len(want) {
+		t.Fatalf("Expected %d changes, got %+v", len(want), changes)
+	}
+	for _, change := range changes {
+		if want[change.Path] != change.Reason || change.StepID != "done" {
+			t.Errorf("Unexpected change: %+v", change)
+		}
+	}
+}
+
+// TestTouchedPaths tests that tool call paths resolve against the plan's
+// working directory.
+func TestTouchedPaths(t *testing.T) {
+	step := plan.PlanStep{ToolCalls: []plan.ToolCall{
+		{Name: "write_file", Arguments: map[string]interface{}{"path": "a.go"}},
+		{Name: "edit_file", Arguments: map[string]interface{}{"file_path": "/abs/b.go"}},
+		{Name: "read_file", Arguments: map[string]interface{}{"path": "a.go"}},
+		{Name: "execute_command", Arguments: map[string]interface{}{"command": "go test"}},
+	}}
+
+	paths := step.TouchedPaths("/work")
+	if len(paths) != 2 || paths[0] != filepath.Clean("/abs/b.go") || paths[1] != filepath.Join("/work", "a.go") {
+		t.Errorf("Unexpected paths: %v", paths)
+	}
+}
+
+// TestStoreEncryptsPlans tests that plan files hold no plaintext when a
+// data cipher is set, and that status and re-encryption cover them.
+func TestStoreEncryptsPlans(t *testing.T) {
+	const secret = "Rotate the quartermaster passphrase before Tuesday"
+	c := securitytest.NewEncryptionManager(t)
+	security.SetDataCipher(c)
+	t.Cleanup(func() { security.SetDataCipher(nil) })
+
+	store := newTestStore(t)
+	p := &plan.Plan{
+		ID:           "encrypted-plan",
+		OriginalTask: secret,
+		Status:       plan.StatusPaused,
+		Steps:        []plan.PlanStep{{ID: "step-1", Status: plan.StepComplete, Result: secret}},
+	}
+	if err := store.Save(p); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(store.Dir(), p.ID+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), secret) {
+		t.Error("Plan was written in plaintext")
+	}
+	status, err := store.EncryptionStatus()
+	if err != nil {
+		t.Fatalf("EncryptionStatus failed: %v", err)
+	}
+	if status.Items != 1 || status.Encrypted != 1 {
+		t.Errorf("Expected 1 of 1 plans encrypted, got %d of %d", status.Encrypted, status.Items)
+	}
+
+	// Re-encrypting to plaintext leaves a readable file
+	if err := store.Reencrypt(c.DecryptString); err != nil {
+		t.Fatalf("Reencrypt failed: %v", err)
+	}
+	security.SetDataCipher(nil)
+	plain, err := plan.NewStore(store.Dir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := plain.Load(p.ID)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded.OriginalTask != secret || loaded.Steps[0].Result != secret {
+		t.Errorf("Expected decrypted plan, got %+v", loaded)
+	}
+}
+
+// TestStorePurgeKeepsUnfinishedPlans tests that retention only purges plans
+// that are complete, failed or cancelled.
+func TestStorePurgeKeepsUnfinishedPlans(t *testing.T) {
+	store := newTestStore(t)
+	for id, status := range map[string]plan.PlanStatus{
+		"running-plan":   plan.StatusRunning,
+		"paused-plan":    plan.StatusPaused,
+		"complete-plan":  plan.StatusComplete,
+		"cancelled-plan": plan.StatusCancelled,
+	} {
+		if err := store.Save(&plan.Plan{ID: id, Status: status}); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+
+	engine := &security.RetentionEngine{
+		Policies: map[string]security.RetentionPolicy{security.RetentionCheckpoints: {MaxAge: time.Hour}},
+		Now:      func() time.Time { return time.Now().Add(2 * time.Hour) },
+	}
+	report, err := engine.Run([]security.RetainedStore{store}, false)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if report.Purged() != 2 {
+		t.Errorf("Expected the finished plans purged, got %+v", report.Results)
+	}
+	plans, err := store.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(plans) != 2 {
+		t.Fatalf("Expected the running and paused plans kept, got %d plans", len(plans))
+	}
+	for _, p := range plans {
+		if p.Status != plan.StatusRunning && p.Status != plan.StatusPaused {
+			t.Errorf("Unexpected plan kept: %s (%s)", p.ID, p.Status)
+		}
+	}
+
+	// A plan resumed after it was listed is kept
+	if err := store.Purge([]security.RetentionItem{{ID: "running-plan.json"}}); err != nil {
+		t.Fatalf("Purge failed: %v", err)
+	}
+	if _, err := store.Load("running-plan"); err != nil {
+		t.Errorf("Expected the running plan kept, got %v", err)
+	}
+}
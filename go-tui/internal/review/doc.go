@@ -0,0 +1,33 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package review provides local code review of a git diff with structured
+// findings.
+//
+// A change set is read from git, diffed file by file with diff.ComputeDiff,
+// and its hunks are packed into chunks small enough for a local model.
+// Each chunk is reviewed on its own with related definitions from the
+// codebase index, and the model's findings are parsed, deduplicated and
+// rendered as markdown, JSON or SARIF.
+//
+// # Key Types
+//
+//   - Source: Which changes to review (working tree, staged or a range)
+//   - ChangeSet: The file diffs read from git
+//   - Chunk: A group of hunks reviewed in one model request
+//   - Finding: A single review comment with location, severity and fix
+//   - Report: The deduplicated findings, rendered as markdown or SARIF
+//
+// # Usage
+//
+//	cs, err := review.CollectDiffs(ctx, review.Source{Staged: true})
+//	var findings []review.Finding
+//	for _, chunk := range review.BuildChunks(cs.Diffs, 8000) {
+//		prompt := review.Prompt(chunk, review.RelatedContext(idx, chunk, 2000))
+//		// ... ask the model with review.SystemPrompt ...
+//		found, err := review.ParseFindings(reply, chunk)
+//		findings = append(findings, found...)
+//	}
+//	report := review.Report{Description: cs.Description, Findings: review.Dedupe(findings)}
+//	fmt.Print(report.Markdown())
+package review
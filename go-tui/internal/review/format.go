@@ -0,0 +1,217 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package review provides local code review of a git diff.
+//
+// This file implements the review report and its markdown and SARIF
+// renderings. SARIF 2.1.0 is what code scanning dashboards and most CI
+// systems ingest.
+package review
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// =============================================================================
+// REPORT
+// =============================================================================
+
+// Report is the result of a review.
+type Report struct {
+	Description  string    `json:"description"`
+	Model        string    `json:"model"`
+	Files        int       `json:"files"`
+	Chunks       int       `json:"chunks"`
+	FailedChunks int       `json:"failed_chunks,omitempty"`
+	Skipped      []string  `json:"skipped,omitempty"`
+	Findings     []Finding `json:"findings"`
+}
+
+// Summary describes the findings by severity, e.g. "3 findings (1 high, 2 low)".
+func (r Report) Summary() string {
+	if len(r.Findings) == 0 {
+		return "no findings"
+	}
+	counts := map[Severity]int{}
+	for _, f := range r.Findings {
+		counts[f.Severity]++
+	}
+	var parts []string
+	for _, sev := range []Severity{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow, SeverityInfo} {
+		if counts[sev] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[sev], sev))
+		}
+	}
+	noun := "findings"
+	if len(r.Findings) == 1 {
+		noun = "finding"
+	}
+	return fmt.Sprintf("%d %s (%s)", len(r.Findings), noun, strings.Join(parts, ", "))
+}
+
+// =============================================================================
+// MARKDOWN
+// =============================================================================
+
+// Markdown renders the report as markdown, grouped by file.
+func (r Report) Markdown() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Code review: %s\n\n", r.Description)
+	fmt.Fprintf(&sb, "Reviewed %d file(s) in %d chunk(s) with %s: %s.\n", r.Files, r.Chunks, r.Model, r.Summary())
+	if r.FailedChunks > 0 {
+		fmt.Fprintf(&sb, "\n%d chunk(s) could not be reviewed; findings may be incomplete.\n", r.FailedChunks)
+	}
+	for _, s := range r.Skipped {
+		fmt.Fprintf(&sb, "\nSkipped %s.", s)
+	}
+	if len(r.Skipped) > 0 {
+		sb.WriteString("\n")
+	}
+
+	file := ""
+	for _, f := range r.Findings {
+		if f.File != file {
+			file = f.File
+			fmt.Fprintf(&sb, "\n## %s\n\n", file)
+		}
+		fmt.Fprintf(&sb, "- **%s** `%s`: %s\n", f.Severity, f.Location(), f.Message)
+		if f.SuggestedFix != "" {
+			fmt.Fprintf(&sb, "  - Suggested fix: %s\n", f.SuggestedFix)
+		}
+		if f.Fix != nil {
+			sb.WriteString("\n  ```diff\n")
+			for _, line := range strings.Split(f.Fix.OldString, "\n") {
+				fmt.Fprintf(&sb, "  -%s\n", line)
+			}
+			for _, line := range strings.Split(f.Fix.NewString, "\n") {
+				fmt.Fprintf(&sb, "  +%s\n", line)
+			}
+			sb.WriteString("  ```\n\n")
+		}
+	}
+	return sb.String()
+}
+
+// =============================================================================
+// SARIF
+// =============================================================================
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	sarifRuleID  = "rigrun-review"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID     string                 `json:"ruleId"`
+	Level      string                 `json:"level"`
+	Message    sarifMessage           `json:"message"`
+	Locations  []sarifLocation        `json:"locations"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifact `json:"artifactLocation"`
+	Region           sarifRegion   `json:"region"`
+}
+
+type sarifArtifact struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// sarifLevel maps a severity onto the SARIF levels error, warning and note.
+func sarifLevel(s Severity) string {
+	switch s {
+	case SeverityCritical, SeverityHigh:
+		return "error"
+	case SeverityMedium:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// SARIF renders the report as a SARIF 2.1.0 log. File URIs are relative to
+// the repository root. The rigrun severity, suggested fix and exact edit
+// are kept in each result's properties.
+func (r Report) SARIF(toolVersion string) ([]byte, error) {
+	results := make([]sarifResult, 0, len(r.Findings))
+	for _, f := range r.Findings {
+		props := map[string]interface{}{"severity": string(f.Severity)}
+		if f.SuggestedFix != "" {
+			props["suggestedFix"] = f.SuggestedFix
+		}
+		if f.Fix != nil {
+			props["edit"] = f.Fix
+		}
+		results = append(results, sarifResult{
+			RuleID:  sarifRuleID,
+			Level:   sarifLevel(f.Severity),
+			Message: sarifMessage{Text: f.Message},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifact{URI: f.File},
+				Region:           sarifRegion{StartLine: max(f.Line, 1)},
+			}}},
+			Properties: props,
+		})
+	}
+
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "rigrun review",
+				Version:        toolVersion,
+				InformationURI: "https://github.com/jeranaias/rigrun-tui",
+				Rules: []sarifRule{{
+					ID:               sarifRuleID,
+					ShortDescription: sarifMessage{Text: "Local model code review finding"},
+				}},
+			}},
+			Results: results,
+		}},
+	}
+	return json.MarshalIndent(log, "", "  ")
+}
@@ -0,0 +1,547 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package review provides local code review of a git diff.
+//
+// This file implements chunking, the review prompt, parsing of the model's
+// structured findings and deduplication of findings across chunks.
+package review
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jeranaias/rigrun-tui/internal/diff"
+	"github.com/jeranaias/rigrun-tui/internal/index"
+)
+
+// =============================================================================
+// SEVERITY
+// =============================================================================
+
+// Severity ranks how serious a finding is.
+type Severity string
+
+const (
+	SeverityCritical Severity = "critical"
+	SeverityHigh     Severity = "high"
+	SeverityMedium   Severity = "medium"
+	SeverityLow      Severity = "low"
+	SeverityInfo     Severity = "info"
+)
+
+// severityAliases maps the words models use for severity to a Severity.
+var severityAliases = map[string]Severity{
+	"critical": SeverityCritical, "blocker": SeverityCritical,
+	"high": SeverityHigh, "error": SeverityHigh, "major": SeverityHigh,
+	"medium": SeverityMedium, "warning": SeverityMedium, "moderate": SeverityMedium,
+	"low": SeverityLow, "minor": SeverityLow,
+	"info": SeverityInfo, "note": SeverityInfo, "nit": SeverityInfo, "suggestion": SeverityInfo,
+}
+
+// ParseSeverity parses a severity name such as "high" or "warning".
+func ParseSeverity(s string) (Severity, error) {
+	if sev, ok := severityAliases[strings.ToLower(strings.TrimSpace(s))]; ok {
+		return sev, nil
+	}
+	return "", fmt.Errorf("unknown severity %q (want critical, high, medium, low or info)", s)
+}
+
+// Rank orders severities from info (0) to critical (4).
+func (s Severity) Rank() int {
+	switch s {
+	case SeverityCritical:
+		return 4
+	case SeverityHigh:
+		return 3
+	case SeverityMedium:
+		return 2
+	case SeverityLow:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// =============================================================================
+// FINDINGS
+// =============================================================================
+
+// Finding is a single review comment on the new version of a file.
+type Finding struct {
+	File         string   `json:"file"`
+	Line         int      `json:"line"`
+	Severity     Severity `json:"severity"`
+	Message      string   `json:"message"`
+	SuggestedFix string   `json:"suggested_fix,omitempty"`
+
+	// Fix is an exact replacement that resolves the finding, when the model
+	// gave one that applies cleanly to the file
+	Fix *Fix `json:"fix,omitempty"`
+}
+
+// Fix replaces OldString, which occurs exactly once in the file, with
+// NewString. It maps directly onto an Edit tool call.
+type Fix struct {
+	OldString string `json:"old_string"`
+	NewString string `json:"new_string"`
+}
+
+// EditParams returns the Edit tool parameters that apply the finding's fix,
+// or nil when it has none. root is the repository the file path is relative to.
+func (f Finding) EditParams(root string) map[string]interface{} {
+	if f.Fix == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"file_path":  filepath.Join(root, filepath.FromSlash(f.File)),
+		"old_string": f.Fix.OldString,
+		"new_string": f.Fix.NewString,
+	}
+}
+
+// Location returns "file:line".
+func (f Finding) Location() string {
+	return fmt.Sprintf("%s:%d", f.File, f.Line)
+}
+
+// =============================================================================
+// CHUNKS
+// =============================================================================
+
+// Chunk is a group of hunks reviewed in one model request.
+type Chunk struct {
+	// Diffs hold this chunk's hunks, one diff per file; their content is
+	// that of the whole file
+	Diffs []*diff.Diff
+}
+
+// BuildChunks packs the hunks of diffs into chunks of at most about maxChars
+// characters of prompt text. Hunks stay whole unless a single hunk is too
+// large, in which case it is split with diff.SplitHunk.
+func BuildChunks(diffs []*diff.Diff, maxChars int) []Chunk {
+	var chunks []Chunk
+	var cur Chunk
+	size := 0
+
+	for _, d := range diffs {
+		for _, hunk := range d.Hunks {
+			// Line numbers and markers add about a third to the raw text
+			for _, piece := range diff.SplitHunk(hunk, maxChars*2/3) {
+				pieceSize := len(renderHunk(piece))
+				last := len(cur.Diffs) - 1
+				sameFile := last >= 0 && cur.Diffs[last].FilePath == d.FilePath
+				if len(cur.Diffs) > 0 && size+pieceSize > maxChars {
+					chunks = append(chunks, cur)
+					cur, size, sameFile = Chunk{}, 0, false
+				}
+				if !sameFile {
+					size += len(fileHeader(d))
+					cur.Diffs = append(cur.Diffs, &diff.Diff{
+						FilePath:   d.FilePath,
+						OldContent: d.OldContent,
+						NewContent: d.NewContent,
+						Stats:      d.Stats,
+					})
+				}
+				part := cur.Diffs[len(cur.Diffs)-1]
+				part.Hunks = append(part.Hunks, piece)
+				size += pieceSize
+			}
+		}
+	}
+	if len(cur.Diffs) > 0 {
+		chunks = append(chunks, cur)
+	}
+	return chunks
+}
+
+// Files returns the paths of the files in the chunk.
+func (c Chunk) Files() []string {
+	files := make([]string, len(c.Diffs))
+	for i, d := range c.Diffs {
+		files[i] = d.FilePath
+	}
+	return files
+}
+
+// Text renders the chunk for the model. Each line of the new file carries
+// its line number so findings can point at it; removed lines have none.
+func (c Chunk) Text() string {
+	var sb strings.Builder
+	for _, d := range c.Diffs {
+		sb.WriteString(fileHeader(d))
+		for _, hunk := range d.Hunks {
+			sb.WriteString(renderHunk(hunk))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// fileHeader introduces a file in the chunk text.
+func fileHeader(d *diff.Diff) string {
+	return fmt.Sprintf("=== %s (%s)\n", d.FilePath, d.Stats.FileMode)
+}
+
+// renderHunk renders a hunk with new-file line numbers.
+func renderHunk(hunk diff.DiffHunk) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", hunk.OldStart, hunk.OldCount, hunk.NewStart, hunk.NewCount)
+	for _, line := range hunk.Lines {
+		num := ""
+		if line.Type != diff.DiffLineRemoved {
+			num = strconv.Itoa(line.NewLine)
+		}
+		fmt.Fprintf(&sb, "%5s %s %s\n", num, line.Type.Prefix(), line.Content)
+	}
+	return sb.String()
+}
+
+// diffFor returns the chunk's diff for a path as the model wrote it, which
+// may carry a/ or b/ prefixes or only part of the path.
+func (c Chunk) diffFor(path string) *diff.Diff {
+	path = strings.TrimPrefix(filepath.ToSlash(strings.TrimSpace(path)), "./")
+	for _, prefix := range []string{"a/", "b/"} {
+		if trimmed := strings.TrimPrefix(path, prefix); trimmed != path && c.hasFile(trimmed) {
+			path = trimmed
+		}
+	}
+	if path == "" {
+		return nil
+	}
+	for _, d := range c.Diffs {
+		if d.FilePath == path {
+			return d
+		}
+	}
+	for _, d := range c.Diffs {
+		if strings.HasSuffix(d.FilePath, "/"+path) {
+			return d
+		}
+	}
+	return nil
+}
+
+func (c Chunk) hasFile(path string) bool {
+	for _, d := range c.Diffs {
+		if d.FilePath == path {
+			return true
+		}
+	}
+	return false
+}
+
+// =============================================================================
+// PROMPT
+// =============================================================================
+
+// SystemPrompt instructs the model to answer with findings as JSON.
+const SystemPrompt = `You are a meticulous senior engineer reviewing a code change.
+Report only real problems in the changed lines (marked +): bugs, security issues, missing error handling, races, resource leaks and misleading code. Do not comment on formatting or on code that did not change.
+
+Respond with JSON only, in exactly this form:
+{"findings": [{"file": "path/to/file.go", "line": 42, "severity": "high", "message": "What is wrong and why it matters", "suggested_fix": "How to fix it", "old_string": "exact current code", "new_string": "replacement code"}]}
+
+- file is the path shown after === and line is the number shown at the start of the line in the new file.
+- severity is one of critical, high, medium, low or info.
+- old_string must be copied exactly from the new file and occur in it once; new_string replaces it. Leave both empty when the fix is not a small local edit.
+- If there are no problems, respond with {"findings": []}.`
+
+// Prompt builds the user prompt for one chunk. related is context from the
+// codebase index and may be empty.
+func Prompt(chunk Chunk, related string) string {
+	var sb strings.Builder
+	if related != "" {
+		sb.WriteString("Definitions used by this change, from elsewhere in the codebase:\n")
+		sb.WriteString(related)
+		sb.WriteString("\n")
+	}
+	sb.WriteString("Review this change:\n\n")
+	sb.WriteString(chunk.Text())
+	return sb.String()
+}
+
+// =============================================================================
+// PARSING
+// =============================================================================
+
+// rawFinding is a finding as the model writes it.
+type rawFinding struct {
+	File         string          `json:"file"`
+	Line         json.RawMessage `json:"line"`
+	Severity     string          `json:"severity"`
+	Message      string          `json:"message"`
+	SuggestedFix string          `json:"suggested_fix"`
+	OldString    string          `json:"old_string"`
+	NewString    string          `json:"new_string"`
+}
+
+// ParseFindings parses the model's reply to the prompt for chunk. Findings
+// on files outside the chunk are dropped, lines outside the file are moved
+// to the first change in it, and fixes that don't apply cleanly are removed
+// so that only exact, unique replacements become edits.
+func ParseFindings(reply string, chunk Chunk) ([]Finding, error) {
+	start := strings.IndexAny(reply, "{[")
+	if start < 0 {
+		return nil, errors.New("no JSON in review reply")
+	}
+
+	// A bare array is accepted as well as the requested object
+	var raws []rawFinding
+	dec := json.NewDecoder(strings.NewReader(reply[start:]))
+	if reply[start] == '[' {
+		if err := dec.Decode(&raws); err != nil {
+			return nil, fmt.Errorf("invalid review reply: %w", err)
+		}
+	} else {
+		var obj struct {
+			Findings []rawFinding `json:"findings"`
+		}
+		if err := dec.Decode(&obj); err != nil {
+			return nil, fmt.Errorf("invalid review reply: %w", err)
+		}
+		raws = obj.Findings
+	}
+
+	var findings []Finding
+	for _, raw := range raws {
+		message := strings.TrimSpace(raw.Message)
+		d := chunk.diffFor(raw.File)
+		if message == "" || d == nil {
+			continue
+		}
+
+		severity, err := ParseSeverity(raw.Severity)
+		if err != nil {
+			severity = SeverityMedium
+		}
+
+		f := Finding{
+			File:         d.FilePath,
+			Line:         parseLine(raw.Line),
+			Severity:     severity,
+			Message:      message,
+			SuggestedFix: strings.TrimSpace(raw.SuggestedFix),
+		}
+		if lines := strings.Count(d.NewContent, "\n") + 1; f.Line < 1 || f.Line > lines {
+			f.Line = firstChange(d)
+		}
+		if raw.OldString != "" && raw.OldString != raw.NewString && strings.Count(d.NewContent, raw.OldString) == 1 {
+			f.Fix = &Fix{OldString: raw.OldString, NewString: raw.NewString}
+		}
+		findings = append(findings, f)
+	}
+	return findings, nil
+}
+
+// parseLine reads a line number written as a number or a string such as
+// "42" or "42-45".
+func parseLine(raw json.RawMessage) int {
+	var n float64
+	if json.Unmarshal(raw, &n) == nil {
+		return int(n)
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		digits := strings.TrimLeft(s, "L ")
+		if end := strings.IndexFunc(digits, func(r rune) bool { return r < '0' || r > '9' }); end >= 0 {
+			digits = digits[:end]
+		}
+		n, _ := strconv.Atoi(digits)
+		return n
+	}
+	return 0
+}
+
+// firstChange returns the new-file line of the first change in a diff, or
+// the line the first deletion was at.
+func firstChange(d *diff.Diff) int {
+	for _, hunk := range d.Hunks {
+		for _, line := range hunk.Lines {
+			if line.Type == diff.DiffLineAdded {
+				return line.NewLine
+			}
+		}
+		if hunk.NewStart > 0 {
+			return hunk.NewStart
+		}
+	}
+	return 1
+}
+
+// =============================================================================
+// DEDUPLICATION
+// =============================================================================
+
+// dedupeLineWindow is how far apart two findings with the same message may
+// be and still be duplicates
+const dedupeLineWindow = 2
+
+var nonWord = regexp.MustCompile(`[^a-z0-9]+`)
+
+// normalizeMessage reduces a message to lowercase words for comparison.
+func normalizeMessage(s string) string {
+	return strings.TrimSpace(nonWord.ReplaceAllString(strings.ToLower(s), " "))
+}
+
+// Dedupe merges findings that report the same problem: the same file, lines
+// within a couple of each other, and one message equal to or contained in
+// the other. The merged finding keeps the highest severity and any fix.
+// The result is sorted by file, line and descending severity.
+func Dedupe(findings []Finding) []Finding {
+	sorted := append([]Finding(nil), findings...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].File != sorted[j].File {
+			return sorted[i].File < sorted[j].File
+		}
+		if sorted[i].Line != sorted[j].Line {
+			return sorted[i].Line < sorted[j].Line
+		}
+		return sorted[i].Severity.Rank() > sorted[j].Severity.Rank()
+	})
+
+	var out []Finding
+	for _, f := range sorted {
+		norm := normalizeMessage(f.Message)
+		merged := false
+		for i := len(out) - 1; i >= 0; i-- {
+			prev := &out[i]
+			if prev.File != f.File || f.Line-prev.Line > dedupeLineWindow {
+				break
+			}
+			prevNorm := normalizeMessage(prev.Message)
+			if !strings.Contains(prevNorm, norm) && !strings.Contains(norm, prevNorm) {
+				continue
+			}
+			if f.Severity.Rank() > prev.Severity.Rank() {
+				prev.Severity = f.Severity
+			}
+			if prev.Fix == nil && f.Fix != nil {
+				prev.Fix = f.Fix
+			}
+			if prev.SuggestedFix == "" {
+				prev.SuggestedFix = f.SuggestedFix
+			}
+			merged = true
+			break
+		}
+		if !merged {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// =============================================================================
+// RELATED CONTEXT
+// =============================================================================
+
+const (
+	// maxContextSymbols bounds how many identifiers are looked up per chunk
+	maxContextSymbols = 12
+)
+
+var identifierPattern = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]{2,}`)
+
+// commonWords are keywords and names too common to be worth looking up.
+var commonWords = map[string]bool{
+	"func": true, "return": true, "var": true, "const": true, "type": true, "struct": true,
+	"interface": true, "package": true, "import": true, "for": true, "range": true, "else": true,
+	"switch": true, "case": true, "default": true, "break": true, "continue": true, "defer": true,
+	"nil": true, "true": true, "false": true, "string": true, "int": true, "bool": true, "error": true,
+	"err": true, "byte": true, "len": true, "make": true, "append": true, "new": true, "map": true,
+	"function": true, "class": true, "def": true, "self": true, "this": true, "None": true,
+	"null": true, "undefined": true, "let": true, "async": true, "await": true, "from": true,
+	"ctx": true, "fmt": true, "strings": true, "the": true, "and": true, "not": true,
+}
+
+// Identifiers returns the identifiers used on the chunk's changed lines,
+// most frequent first.
+func (c Chunk) Identifiers(limit int) []string {
+	counts := map[string]int{}
+	var order []string
+	for _, d := range c.Diffs {
+		for _, hunk := range d.Hunks {
+			for _, line := range hunk.Lines {
+				if line.Type == diff.DiffLineContext {
+					continue
+				}
+				for _, id := range identifierPattern.FindAllString(line.Content, -1) {
+					if commonWords[id] {
+						continue
+					}
+					if counts[id] == 0 {
+						order = append(order, id)
+					}
+					counts[id]++
+				}
+			}
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool { return counts[order[i]] > counts[order[j]] })
+	if len(order) > limit {
+		order = order[:limit]
+	}
+	return order
+}
+
+// RelatedContext looks up the definitions of identifiers the chunk uses in
+// the codebase index and lists them, up to maxChars. Definitions inside the
+// chunk's own hunks are left out. It returns "" when idx is nil or has not
+// been built.
+func RelatedContext(idx *index.CodebaseIndex, chunk Chunk, maxChars int) string {
+	if idx == nil || !idx.IsIndexed() {
+		return ""
+	}
+
+	var sb strings.Builder
+	seen := map[string]bool{}
+	opts := &index.SearchOptions{MaxResults: 5}
+	for _, name := range chunk.Identifiers(maxContextSymbols) {
+		results, err := idx.SearchByName(name, opts)
+		if err != nil {
+			continue
+		}
+		for _, r := range results {
+			path := filepath.ToSlash(r.FilePath)
+			loc := fmt.Sprintf("%s:%d", path, r.Line)
+			if r.Name != name || seen[loc] || chunk.covers(path, r.Line) {
+				continue
+			}
+			seen[loc] = true
+
+			desc := r.Signature
+			if desc == "" {
+				desc = fmt.Sprintf("%s %s", r.Type, r.Name)
+			}
+			entry := fmt.Sprintf("- %s: %s\n", loc, desc)
+			if sb.Len()+len(entry) > maxChars {
+				return sb.String()
+			}
+			sb.WriteString(entry)
+		}
+	}
+	return sb.String()
+}
+
+// covers reports whether a new-file line of path is shown in the chunk.
+func (c Chunk) covers(path string, line int) bool {
+	for _, d := range c.Diffs {
+		if d.FilePath != path {
+			continue
+		}
+		for _, hunk := range d.Hunks {
+			if line >= hunk.NewStart && line < hunk.NewStart+hunk.NewCount {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,316 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package review
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jeranaias/rigrun-tui/internal/diff"
+)
+
+// newTestRepo creates a git repository with one commit of main.go.
+func newTestRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "rigrun test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "rigrun test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	dir := t.TempDir()
+	runGit(t, dir, "init", "--quiet", "--initial-branch=main")
+	runGit(t, dir, "config", "commit.gpgsign", "false")
+	writeFile(t, dir, "main.go", "package main\n\nfunc main() {\n}\n")
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "commit", "--quiet", "-m", "Initial commit")
+	return dir
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func diffPaths(cs *ChangeSet) []string {
+	var paths []string
+	for _, d := range cs.Diffs {
+		paths = append(paths, d.FilePath)
+	}
+	return paths
+}
+
+func TestCollectDiffs(t *testing.T) {
+	dir := newTestRepo(t)
+	ctx := context.Background()
+
+	// Staged and unstaged changes, a binary file and a branch commit
+	writeFile(t, dir, "main.go", "package main\n\nfunc main() {\n\tprintln(\"hi\")\n}\n")
+	writeFile(t, dir, "pkg/util.go", "package pkg\n")
+	writeFile(t, dir, "logo.bin", "\x00\x01\x02")
+	runGit(t, dir, "add", "pkg/util.go", "logo.bin")
+
+	cs, err := CollectDiffs(ctx, Source{Dir: dir, Staged: true})
+	if err != nil {
+		t.Fatalf("CollectDiffs(staged) error = %v", err)
+	}
+	if got := strings.Join(diffPaths(cs), ","); got != "pkg/util.go" {
+		t.Errorf("staged diffs = %s, want pkg/util.go", got)
+	}
+	if len(cs.Skipped) != 1 || !strings.Contains(cs.Skipped[0], "logo.bin (binary)") {
+		t.Errorf("Skipped = %v", cs.Skipped)
+	}
+
+	cs, err = CollectDiffs(ctx, Source{Dir: filepath.Join(dir, "pkg")})
+	if err != nil {
+		t.Fatalf("CollectDiffs(worktree) error = %v", err)
+	}
+	if got := strings.Join(diffPaths(cs), ","); got != "main.go,pkg/util.go" {
+		t.Errorf("worktree diffs = %s", got)
+	}
+
+	// Untracked files are reviewed too, unless git ignores them
+	writeFile(t, dir, ".gitignore", "*.log\n")
+	writeFile(t, dir, "debug.log", "noise\n")
+	cs, err = CollectDiffs(ctx, Source{Dir: dir})
+	if err != nil {
+		t.Fatalf("CollectDiffs(worktree) error = %v", err)
+	}
+	if got := strings.Join(diffPaths(cs), ","); got != "main.go,pkg/util.go,.gitignore" {
+		t.Errorf("worktree diffs with untracked files = %s", got)
+	}
+	os.Remove(filepath.Join(dir, "debug.log"))
+	if d := cs.Diffs[0]; d.Stats.Additions != 1 || d.Hunks[0].Lines[3].NewLine != 4 {
+		t.Errorf("main.go diff = %+v", d.Stats)
+	}
+
+	// A branch range compares the branch with where it left main
+	runGit(t, dir, "checkout", "--quiet", "-b", "feature")
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "commit", "--quiet", "-m", "Feature")
+	runGit(t, dir, "checkout", "--quiet", "main")
+	writeFile(t, dir, "other.go", "package main\n")
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "commit", "--quiet", "-m", "Main moves on")
+
+	cs, err = CollectDiffs(ctx, Source{Dir: dir, Range: "main...feature"})
+	if err != nil {
+		t.Fatalf("CollectDiffs(range) error = %v", err)
+	}
+	if got := strings.Join(diffPaths(cs), ","); got != ".gitignore,main.go,pkg/util.go" {
+		t.Errorf("main...feature diffs = %s", got)
+	}
+	cs, err = CollectDiffs(ctx, Source{Dir: dir, Range: "main..feature"})
+	if err != nil {
+		t.Fatalf("CollectDiffs(range) error = %v", err)
+	}
+	if got := strings.Join(diffPaths(cs), ","); got != ".gitignore,main.go,other.go,pkg/util.go" {
+		t.Errorf("main..feature diffs = %s", got)
+	}
+
+	for _, bad := range []Source{{Dir: dir, Range: "--output=/tmp/x"}, {Dir: dir, Range: "main..-x"}, {Dir: dir, Staged: true, Range: "main"}} {
+		if _, err := CollectDiffs(ctx, bad); err == nil {
+			t.Errorf("CollectDiffs(%+v) should fail", bad)
+		}
+	}
+}
+
+func TestBuildChunks(t *testing.T) {
+	var diffs []*diff.Diff
+	for _, name := range []string{"a.go", "b.go", "c.go"} {
+		diffs = append(diffs, diff.ComputeDiff(name, "", strings.Repeat("var x = 1 // "+name+"\n", 30)))
+	}
+
+	// Small files share a chunk
+	if chunks := BuildChunks(diffs, 100000); len(chunks) != 1 || len(chunks[0].Files()) != 3 {
+		t.Fatalf("BuildChunks() = %d chunks", len(chunks))
+	}
+
+	// Large hunks are split, and every added line is reviewed exactly once
+	chunks := BuildChunks(diffs, 1000)
+	if len(chunks) < 3 {
+		t.Fatalf("BuildChunks(1000) = %d chunks, want at least 3", len(chunks))
+	}
+	seen := map[string]int{}
+	for _, c := range chunks {
+		if len(c.Text()) > 1100 {
+			t.Errorf("chunk of %d chars exceeds the budget", len(c.Text()))
+		}
+		for _, d := range c.Diffs {
+			for _, h := range d.Hunks {
+				for _, line := range h.Lines {
+					seen[d.FilePath]++
+					if line.Type != diff.DiffLineAdded {
+						t.Errorf("unexpected %s line", line.Type)
+					}
+				}
+			}
+		}
+	}
+	for _, name := range []string{"a.go", "b.go", "c.go"} {
+		if seen[name] != 30 {
+			t.Errorf("%s: %d lines reviewed, want 30", name, seen[name])
+		}
+	}
+
+	if !strings.Contains(chunks[0].Text(), "    1 + var x = 1 // a.go") {
+		t.Errorf("chunk text lacks numbered lines:\n%s", chunks[0].Text())
+	}
+}
+
+func TestParseFindings(t *testing.T) {
+	d := diff.ComputeDiff("internal/app/server.go", "package app\n", "package app\n\nfunc Serve() {\n\tgo handle()\n}\n")
+	chunk := BuildChunks([]*diff.Diff{d}, 8000)[0]
+
+	reply := "Here is my review:\n```json\n" + `{"findings": [
+		{"file": "b/internal/app/server.go", "line": 4, "severity": "warning", "message": "Goroutine is never waited for", "suggested_fix": "Use a WaitGroup", "old_string": "\tgo handle()", "new_string": "\thandle()"},
+		{"file": "server.go", "line": "3-5", "severity": "extreme", "message": "Serve lacks a doc comment"},
+		{"file": "internal/app/server.go", "line": 99, "severity": "low", "message": "Line out of range", "old_string": "()", "new_string": "(ctx)"},
+		{"file": "other.go", "line": 1, "severity": "high", "message": "Not in this chunk"},
+		{"file": "internal/app/server.go", "line": 3, "severity": "high", "message": "  "}
+	]}` + "\n```"
+
+	findings, err := ParseFindings(reply, chunk)
+	if err != nil {
+		t.Fatalf("ParseFindings() error = %v", err)
+	}
+	if len(findings) != 3 {
+		t.Fatalf("ParseFindings() = %+v, want 3 findings", findings)
+	}
+
+	first := findings[0]
+	if first.File != "internal/app/server.go" || first.Line != 4 || first.Severity != SeverityMedium || first.SuggestedFix != "Use a WaitGroup" {
+		t.Errorf("first finding = %+v", first)
+	}
+	params := first.EditParams("/repo")
+	if params == nil || params["file_path"] != filepath.Join("/repo", "internal", "app", "server.go") || params["old_string"] != "\tgo handle()" {
+		t.Errorf("EditParams() = %v", params)
+	}
+
+	if second := findings[1]; second.Line != 3 || second.Severity != SeverityMedium || second.Fix != nil {
+		t.Errorf("second finding = %+v", second)
+	}
+	// Out-of-range lines move to the first change; ambiguous fixes are dropped
+	if third := findings[2]; third.Line != 2 || third.Fix != nil {
+		t.Errorf("third finding = %+v", third)
+	}
+
+	if found, err := ParseFindings(`[{"file": "internal/app/server.go", "line": 4, "message": "bare array"}]`, chunk); err != nil || len(found) != 1 {
+		t.Errorf("bare array = %+v, %v", found, err)
+	}
+	if _, err := ParseFindings("Looks good to me!", chunk); err == nil {
+		t.Error("a reply without JSON should be an error")
+	}
+}
+
+func TestDedupe(t *testing.T) {
+	fix := &Fix{OldString: "a", NewString: "b"}
+	findings := Dedupe([]Finding{
+		{File: "b.go", Line: 10, Severity: SeverityLow, Message: "Error is ignored."},
+		{File: "a.go", Line: 5, Severity: SeverityInfo, Message: "Unused variable x"},
+		{File: "b.go", Line: 11, Severity: SeverityHigh, Message: "error is ignored", Fix: fix},
+		{File: "b.go", Line: 30, Severity: SeverityLow, Message: "Error is ignored"},
+		{File: "b.go", Line: 10, Severity: SeverityMedium, Message: "Possible nil dereference"},
+	})
+
+	want := []string{"a.go:5 info", "b.go:10 medium", "b.go:10 high", "b.go:30 low"}
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.Location()+" "+string(f.Severity))
+	}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Fatalf("Dedupe() = %v, want %v", got, want)
+	}
+	if findings[2].Fix != fix || findings[2].Message != "Error is ignored." {
+		t.Errorf("merged finding = %+v", findings[2])
+	}
+}
+
+func TestReportFormats(t *testing.T) {
+	report := Report{
+		Description: "staged changes",
+		Model:       "qwen2.5-coder:7b",
+		Files:       1,
+		Chunks:      1,
+		Findings: []Finding{
+			{File: "a.go", Line: 3, Severity: SeverityHigh, Message: "Nil map write", SuggestedFix: "Initialize the map", Fix: &Fix{OldString: "var m map[string]int", NewString: "m := map[string]int{}"}},
+			{File: "a.go", Line: 9, Severity: SeverityInfo, Message: "Consider a constant"},
+		},
+	}
+
+	if got := report.Summary(); got != "2 findings (1 high, 1 info)" {
+		t.Errorf("Summary() = %q", got)
+	}
+	md := report.Markdown()
+	for _, want := range []string{"# Code review: staged changes", "## a.go", "**high** `a.go:3`: Nil map write", "Suggested fix: Initialize the map", "-var m map[string]int", "+m := map[string]int{}"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown lacks %q:\n%s", want, md)
+		}
+	}
+
+	data, err := report.SARIF("1.2.3")
+	if err != nil {
+		t.Fatalf("SARIF() error = %v", err)
+	}
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Version string `json:"version"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				Level     string `json:"level"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+						Region struct {
+							StartLine int `json:"startLine"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatalf("SARIF output is not JSON: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 || log.Runs[0].Tool.Driver.Version != "1.2.3" || len(log.Runs[0].Results) != 2 {
+		t.Fatalf("unexpected SARIF log:\n%s", data)
+	}
+	r := log.Runs[0].Results[0]
+	loc := r.Locations[0].PhysicalLocation
+	if r.Level != "error" || r.RuleID != "rigrun-review" || loc.ArtifactLocation.URI != "a.go" || loc.Region.StartLine != 3 {
+		t.Errorf("first SARIF result = %+v", r)
+	}
+	if log.Runs[0].Results[1].Level != "note" {
+		t.Errorf("info findings should be notes, got %s", log.Runs[0].Results[1].Level)
+	}
+}
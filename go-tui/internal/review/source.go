@@ -0,0 +1,217 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package review provides local code review of a git diff.
+//
+// This file implements the diff source: it asks git which files a change
+// set touches, reads both sides of each file and diffs them with
+// diff.ComputeDiff, so hunks match what the rest of rigrun shows.
+package review
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/jeranaias/rigrun-tui/internal/diff"
+)
+
+// =============================================================================
+// CONSTANTS
+// =============================================================================
+
+const (
+	// MaxFileSize is the largest file, in bytes, that is reviewed
+	MaxFileSize = 1 << 20
+
+	// maxDiffCost bounds the LCS table ComputeDiff builds for one file
+	maxDiffCost = 16_000_000
+
+	// worktreeRev and indexRev name the non-commit sides of a diff
+	worktreeRev = ""
+	indexRev    = ":"
+)
+
+// =============================================================================
+// SOURCE
+// =============================================================================
+
+// Source selects the changes to review.
+type Source struct {
+	// Dir is any directory inside the repository (default: the current one)
+	Dir string
+
+	// Range is "A..B", "A...B" (changes on B since it left A) or a single
+	// revision compared with the working tree, including untracked files.
+	// Empty means HEAD.
+	Range string
+
+	// Staged reviews the index against HEAD instead of the working tree
+	Staged bool
+}
+
+// ChangeSet is the set of file diffs to review.
+type ChangeSet struct {
+	Root        string       // Repository top-level directory
+	Description string       // What was diffed, e.g. "staged changes"
+	Diffs       []*diff.Diff // One diff per changed text file, paths relative to Root
+	Skipped     []string     // Files left out, with the reason
+}
+
+// CollectDiffs reads the change set selected by src from git.
+func CollectDiffs(ctx context.Context, src Source) (*ChangeSet, error) {
+	if src.Staged && src.Range != "" {
+		return nil, errors.New("--staged cannot be combined with a range")
+	}
+	// Revisions are passed to git as arguments, so none may look like an option
+	for _, rev := range strings.Split(src.Range, ".") {
+		if strings.HasPrefix(rev, "-") || strings.ContainsAny(rev, " \t\n") {
+			return nil, fmt.Errorf("invalid revision range %q", src.Range)
+		}
+	}
+
+	root, err := git(ctx, src.Dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	cs := &ChangeSet{Root: strings.TrimSpace(root)}
+
+	// The old and new revisions each side of a file is read from, and the
+	// arguments that make git diff list the same files
+	var oldRev, newRev string
+	var diffArgs []string
+	switch {
+	case src.Staged:
+		oldRev, newRev = "HEAD", indexRev
+		diffArgs = []string{"--cached"}
+		cs.Description = "staged changes"
+	case src.Range == "":
+		oldRev, newRev = "HEAD", worktreeRev
+		diffArgs = []string{"HEAD"}
+		cs.Description = "uncommitted changes"
+	case strings.Contains(src.Range, "..."):
+		from, to := splitRange(src.Range, "...")
+		base, err := git(ctx, cs.Root, "merge-base", from, to)
+		if err != nil {
+			return nil, err
+		}
+		oldRev, newRev = strings.TrimSpace(base), to
+		diffArgs = []string{oldRev, newRev}
+		cs.Description = src.Range
+	case strings.Contains(src.Range, ".."):
+		oldRev, newRev = splitRange(src.Range, "..")
+		diffArgs = []string{oldRev, newRev}
+		cs.Description = src.Range
+	default:
+		oldRev, newRev = src.Range, worktreeRev
+		diffArgs = []string{oldRev}
+		cs.Description = "changes since " + src.Range
+	}
+
+	args := append([]string{"diff", "--name-status", "-z", "--no-renames"}, diffArgs...)
+	out, err := git(ctx, cs.Root, append(args, "--")...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Output is NUL-separated status and path pairs
+	var fields []string
+	if out != "" {
+		fields = strings.Split(strings.TrimSuffix(out, "\x00"), "\x00")
+	}
+
+	// New files the user hasn't added yet are part of the working tree
+	if newRev == worktreeRev {
+		untracked, err := git(ctx, cs.Root, "ls-files", "--others", "--exclude-standard", "-z")
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range strings.Split(strings.TrimSuffix(untracked, "\x00"), "\x00") {
+			if path != "" {
+				fields = append(fields, "A", path)
+			}
+		}
+	}
+	for i := 0; i+1 < len(fields); i += 2 {
+		status, path := fields[i], fields[i+1]
+
+		var oldContent, newContent string
+		if status != "A" {
+			if oldContent, err = cs.read(ctx, oldRev, path); err != nil {
+				return nil, err
+			}
+		}
+		if status != "D" {
+			if newContent, err = cs.read(ctx, newRev, path); err != nil {
+				return nil, err
+			}
+		}
+
+		switch {
+		case len(oldContent) > MaxFileSize || len(newContent) > MaxFileSize:
+			cs.Skipped = append(cs.Skipped, path+" (too large)")
+		case strings.IndexByte(oldContent, 0) >= 0 || strings.IndexByte(newContent, 0) >= 0:
+			cs.Skipped = append(cs.Skipped, path+" (binary)")
+		case diff.DiffCost(oldContent, newContent) > maxDiffCost:
+			cs.Skipped = append(cs.Skipped, path+" (change too large to diff)")
+		default:
+			if d := diff.ComputeDiff(path, oldContent, newContent); len(d.Hunks) > 0 {
+				cs.Diffs = append(cs.Diffs, d)
+			}
+		}
+	}
+
+	return cs, nil
+}
+
+// read returns a file's content at a revision, in the index or in the
+// working tree.
+func (cs *ChangeSet) read(ctx context.Context, rev, path string) (string, error) {
+	switch rev {
+	case worktreeRev:
+		data, err := os.ReadFile(filepath.Join(cs.Root, filepath.FromSlash(path)))
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+		return string(data), err
+	case indexRev:
+		return git(ctx, cs.Root, "show", ":"+path)
+	default:
+		return git(ctx, cs.Root, "show", rev+":"+path)
+	}
+}
+
+// splitRange splits "A..B" on sep; a missing side means HEAD, as in git.
+func splitRange(r, sep string) (string, string) {
+	from, to, _ := strings.Cut(r, sep)
+	if from == "" {
+		from = "HEAD"
+	}
+	if to == "" {
+		to = "HEAD"
+	}
+	return from, to
+}
+
+// git runs git in dir and returns its output. Arguments are passed
+// directly, never through a shell.
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// ROUTER: Spend budget enforcement for paid tiers
+package router
+
+import "fmt"
+
+// ============================================================================
+// SPEND BUDGETS
+// ============================================================================
+
+// Budget actions: what happens to a query routed to a paid tier once a
+// spend budget is exceeded.
+const (
+	// BudgetActionLocal routes the query to the local tier instead.
+	BudgetActionLocal = "local"
+	// BudgetActionPrompt keeps the paid tier and marks the decision
+	// OverBudget, so that the caller asks the user before spending.
+	BudgetActionPrompt = "prompt"
+)
+
+// SpendBudget reports whether spending on paid tiers is within budget.
+type SpendBudget interface {
+	// ExceededBudget describes the first exceeded budget, such as
+	// "daily budget of $5.00 spent ($5.12)", or returns "" when there is
+	// budget left.
+	ExceededBudget() string
+}
+
+// ApplyBudget checks a decision for a paid tier against budget. Once a
+// budget is exceeded the decision falls back to the local tier, or with
+// BudgetActionPrompt keeps its tier and records the exceeded budget in
+// OverBudget. Decisions for free tiers are returned unchanged.
+func ApplyBudget(decision RoutingDecision, budget SpendBudget, action string) RoutingDecision {
+	if budget == nil || decision.Tier.IsLocal() {
+		return decision
+	}
+	exceeded := budget.ExceededBudget()
+	if exceeded == "" {
+		return decision
+	}
+
+	if action == BudgetActionPrompt {
+		decision.OverBudget = exceeded
+		decision.Reason = fmt.Sprintf("%s (over budget: %s)", decision.Reason, exceeded)
+		return decision
+	}
+
+	decision.Reason = fmt.Sprintf("%s -> %s tier (FORCED: %s)", decision.Reason, TierLocal.String(), exceeded)
+	decision.Tier = TierLocal
+	decision.EstimatedCostCents = 0.0 // Local is free
+	decision.IsAutoRouted = false
+	return decision
+}
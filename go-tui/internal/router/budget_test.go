@@ -0,0 +1,67 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package router
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jeranaias/rigrun-tui/internal/security"
+)
+
+// fixedBudget is a SpendBudget with a fixed answer.
+type fixedBudget string
+
+func (b fixedBudget) ExceededBudget() string { return string(b) }
+
+func TestApplyBudget(t *testing.T) {
+	paid := RoutingDecision{Tier: TierSonnet, EstimatedCostCents: 1.5, Reason: "complex"}
+
+	tests := []struct {
+		name       string
+		decision   RoutingDecision
+		budget     SpendBudget
+		action     string
+		wantTier   Tier
+		overBudget string
+	}{
+		{"no budget", paid, nil, BudgetActionLocal, TierSonnet, ""},
+		{"within budget", paid, fixedBudget(""), BudgetActionLocal, TierSonnet, ""},
+		{"exceeded falls back", paid, fixedBudget("daily budget of $5.00 spent"), BudgetActionLocal, TierLocal, ""},
+		{"exceeded prompts", paid, fixedBudget("daily budget of $5.00 spent"), BudgetActionPrompt, TierSonnet,
+			"daily budget of $5.00 spent"},
+		{"free tier unchanged", RoutingDecision{Tier: TierLocal}, fixedBudget("daily budget of $5.00 spent"),
+			BudgetActionLocal, TierLocal, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ApplyBudget(tt.decision, tt.budget, tt.action)
+			if got.Tier != tt.wantTier {
+				t.Errorf("Tier = %s, want %s", got.Tier, tt.wantTier)
+			}
+			if got.OverBudget != tt.overBudget {
+				t.Errorf("OverBudget = %q, want %q", got.OverBudget, tt.overBudget)
+			}
+			if got.Tier == TierLocal && got.EstimatedCostCents != 0 {
+				t.Errorf("EstimatedCostCents = %f, want 0 for local", got.EstimatedCostCents)
+			}
+		})
+	}
+}
+
+func TestRouteQueryDetailedBudget(t *testing.T) {
+	opts := &RouterOptions{
+		Mode:         "auto",
+		HasCloudKey:  true,
+		Budget:       fixedBudget("monthly budget of $20.00 spent"),
+		BudgetAction: BudgetActionLocal,
+	}
+	decision := RouteQueryDetailed("explain how async runtime works in detail", security.ClassificationUnclassified, opts)
+	if decision.Tier != TierLocal {
+		t.Fatalf("Tier = %s, want Local once the budget is spent", decision.Tier)
+	}
+	if !strings.Contains(decision.Reason, "monthly budget") {
+		t.Errorf("Reason %q does not name the exceeded budget", decision.Reason)
+	}
+}
@@ -303,6 +303,11 @@ func RouteQueryDetailed(query string, classification security.ClassificationLeve
 		IsAutoRouted:       isAutoRouted,
 	}
 
+	// Spend budgets are checked before committing to a paid tier
+	if routerOpts != nil {
+		decision = ApplyBudget(decision, routerOpts.Budget, routerOpts.BudgetAction)
+	}
+
 	// Log decision for debugging/audit
 	log.Printf("ROUTING: query=%q class=%s -> tier=%s reason=%q cost=%.4f",
 		truncateForLog(query, 50),
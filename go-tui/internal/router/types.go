@@ -351,6 +351,11 @@ type RouterOptions struct {
 	AutoMaxCost float64
 	// AutoFallback specifies what to do if OpenRouter is unavailable: "local" or "error"
 	AutoFallback string
+
+	// Budget is checked before choosing a paid tier (nil = no budgets)
+	Budget SpendBudget
+	// BudgetAction is what happens once a budget is exceeded: "local" or "prompt"
+	BudgetAction string
 }
 
 // GetMaxTier returns the Tier corresponding to the MaxTier string.
@@ -360,26 +365,40 @@ func (o *RouterOptions) GetMaxTier() *Tier {
 		return nil
 	}
 
-	var tier Tier
-	switch o.MaxTier {
+	// "auto" is not a cap
+	if o.MaxTier == "auto" {
+		return nil
+	}
+	tier, ok := ParseTier(o.MaxTier)
+	if !ok {
+		return nil
+	}
+	return &tier
+}
+
+// ParseTier returns the Tier for a configuration name such as "local",
+// "sonnet" or "gpt-4o".
+func ParseTier(name string) (Tier, bool) {
+	switch name {
 	case "cache":
-		tier = TierCache
+		return TierCache, true
 	case "local":
-		tier = TierLocal
+		return TierLocal, true
+	case "auto":
+		return TierAuto, true
 	case "cloud":
-		tier = TierCloud
+		return TierCloud, true
 	case "haiku":
-		tier = TierHaiku
+		return TierHaiku, true
 	case "sonnet":
-		tier = TierSonnet
+		return TierSonnet, true
 	case "opus":
-		tier = TierOpus
+		return TierOpus, true
 	case "gpt-4o":
-		tier = TierGpt4o
+		return TierGpt4o, true
 	default:
-		return nil
+		return 0, false
 	}
-	return &tier
 }
 
 // ShouldUseLocal returns true if routing should prefer local-only.
@@ -427,6 +446,9 @@ type RoutingDecision struct {
 	SelectedModel string `json:"selected_model,omitempty"`
 	// IsAutoRouted indicates if OpenRouter auto-routing was used.
 	IsAutoRouted bool `json:"is_auto_routed,omitempty"`
+	// OverBudget describes the exceeded spend budget when a paid tier was
+	// kept under the "prompt" budget action; the caller should confirm.
+	OverBudget string `json:"over_budget,omitempty"`
 }
 
 // String returns a human-readable summary of the routing decision.
@@ -0,0 +1,151 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package schedule runs prompts and plans on cron schedules.
+//
+// Schedules come from [[schedules]] tables in the config and in
+// <repo root>/.rigrun/schedules.toml. While "rigrun daemon" runs, each
+// schedule that comes due is queued as a background task (see package
+// tasks); its answer or plan summary is saved as a conversation and the
+// run is recorded in the audit log. Runs missed while no daemon was
+// running are skipped, made up once, or all made up, per the schedule's
+// catch-up policy.
+package schedule
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jeranaias/rigrun-tui/internal/config"
+	"github.com/jeranaias/rigrun-tui/internal/cron"
+	"github.com/jeranaias/rigrun-tui/internal/instructions"
+)
+
+// =============================================================================
+// JOBS
+// =============================================================================
+
+// Kinds of scheduled work.
+const (
+	// KindAsk answers the prompt, with tools if the schedule is agentic
+	KindAsk = "ask"
+
+	// KindPlan generates a plan for the prompt and executes it
+	KindPlan = "plan"
+)
+
+// Catch-up policies for runs missed while no daemon was running.
+const (
+	// CatchUpSkip drops missed runs
+	CatchUpSkip = "skip"
+
+	// CatchUpOnce makes up any number of missed runs with a single run
+	CatchUpOnce = "once"
+
+	// CatchUpAll makes up every missed run, up to the max catch-up
+	CatchUpAll = "all"
+)
+
+// DefaultMaxCatchUp caps the runs made up under CatchUpAll when
+// scheduler.max_catch_up is not set.
+const DefaultMaxCatchUp = 24
+
+// ProjectFile is the project schedules file, relative to the repo root.
+const ProjectFile = ".rigrun/schedules.toml"
+
+// Job is a schedule ready to run.
+type Job struct {
+	config.ScheduleConfig
+
+	// Source is where the schedule was defined: "config" or a file path
+	Source string
+
+	// Schedule is the parsed cron expression
+	Schedule *cron.Schedule
+
+	// Location is the time zone the expression is read in
+	Location *time.Location
+}
+
+// Next returns the first time after t that the job comes due.
+func (j *Job) Next(t time.Time) time.Time {
+	return j.Schedule.Next(t.In(j.Location))
+}
+
+// LoadJobs loads the schedules of the config and of the project containing
+// workDir. Kind, catch-up policy and work directory are resolved, so that
+// every Job has them set. Names must be unique across both sources.
+func LoadJobs(cfg *config.Config, workDir string) ([]*Job, error) {
+	var jobs []*Job
+	names := make(map[string]string)
+	add := func(schedules []config.ScheduleConfig, source, dir string) error {
+		for _, sched := range schedules {
+			if other, ok := names[sched.Name]; ok {
+				return fmt.Errorf("schedule %q is defined in both %s and %s", sched.Name, other, source)
+			}
+			names[sched.Name] = source
+
+			job, err := newJob(sched, source, dir, cfg.Scheduler.CatchUp)
+			if err != nil {
+				return fmt.Errorf("schedule %q (%s): %w", sched.Name, source, err)
+			}
+			jobs = append(jobs, job)
+		}
+		return nil
+	}
+
+	if err := add(cfg.Schedules, "config", workDir); err != nil {
+		return nil, err
+	}
+
+	root := instructions.FindRoot(workDir)
+	path := filepath.Join(root, ProjectFile)
+	if _, err := os.Stat(path); err == nil {
+		schedules, err := config.LoadSchedulesFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := add(schedules, path, root); err != nil {
+			return nil, err
+		}
+	}
+	return jobs, nil
+}
+
+// newJob resolves a schedule's defaults; relative work directories are
+// taken from dir.
+func newJob(sched config.ScheduleConfig, source, dir, defaultCatchUp string) (*Job, error) {
+	schedule, err := cron.Parse(sched.Cron)
+	if err != nil {
+		return nil, err
+	}
+	loc := time.Local
+	if sched.Timezone != "" {
+		if loc, err = time.LoadLocation(sched.Timezone); err != nil {
+			return nil, err
+		}
+	}
+
+	sched.Kind = strings.ToLower(sched.Kind)
+	if sched.Kind == "" {
+		sched.Kind = KindAsk
+	}
+	sched.CatchUp = strings.ToLower(sched.CatchUp)
+	if sched.CatchUp == "" {
+		sched.CatchUp = strings.ToLower(defaultCatchUp)
+	}
+	if sched.CatchUp == "" {
+		sched.CatchUp = CatchUpOnce
+	}
+	switch {
+	case sched.WorkDir == "":
+		sched.WorkDir = dir
+	case !filepath.IsAbs(sched.WorkDir):
+		sched.WorkDir = filepath.Join(dir, sched.WorkDir)
+	}
+
+	return &Job{ScheduleConfig: sched, Source: source, Schedule: schedule, Location: loc}, nil
+}
@@ -0,0 +1,348 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/jeranaias/rigrun-tui/internal/router"
+	"github.com/jeranaias/rigrun-tui/internal/security"
+	"github.com/jeranaias/rigrun-tui/internal/storage"
+	"github.com/jeranaias/rigrun-tui/internal/tasks"
+)
+
+// =============================================================================
+// SCHEDULER
+// =============================================================================
+
+// Task metadata keys of scheduled runs.
+const (
+	// MetaSchedule is the name of the schedule that queued the task
+	MetaSchedule = "schedule"
+
+	// MetaDue is the due time of the run (RFC 3339)
+	MetaDue = "due"
+)
+
+// lateAfter is how late a run may be queued and still count as on time.
+// Due times further back were missed and follow the catch-up policy.
+const lateAfter = 2 * time.Minute
+
+// tickInterval is how often Run checks for due schedules.
+const tickInterval = 15 * time.Second
+
+// auditSession identifies the scheduler in the audit log.
+const auditSession = "SCHEDULER"
+
+// Config configures a Scheduler.
+type Config struct {
+	// Jobs are the schedules to run
+	Jobs []*Job
+
+	// State remembers what ran, so that missed runs can be found
+	State *State
+
+	// Queue runs the work as background tasks
+	Queue *tasks.Queue
+
+	// Work builds the work of a run (see Backend.Work)
+	Work func(job *Job) tasks.AgentFunc
+
+	// Conversations, if set, receives each run's prompt and answer
+	Conversations *storage.ConversationStore
+
+	// MaxCatchUp caps the runs made up under CatchUpAll (0 = DefaultMaxCatchUp)
+	MaxCatchUp int
+}
+
+// Scheduler queues the runs of schedules as they come due.
+type Scheduler struct {
+	cfg  Config
+	jobs map[string]*Job
+
+	mu     sync.Mutex
+	active map[string]*tasks.Task // Last queued run of each job
+}
+
+// New creates a scheduler.
+func New(cfg Config) *Scheduler {
+	if cfg.MaxCatchUp <= 0 {
+		cfg.MaxCatchUp = DefaultMaxCatchUp
+	}
+	s := &Scheduler{
+		cfg:    cfg,
+		jobs:   make(map[string]*Job),
+		active: make(map[string]*tasks.Task),
+	}
+	for _, job := range cfg.Jobs {
+		s.jobs[job.Name] = job
+	}
+	return s
+}
+
+// Run checks for due schedules until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	s.Tick(time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.Tick(now)
+		}
+	}
+}
+
+// Tick queues the runs that came due up to now. Errors are logged; a job
+// whose state cannot be saved is retried on the next tick.
+func (s *Scheduler) Tick(now time.Time) {
+	for _, job := range s.cfg.Jobs {
+		if job.Disabled {
+			continue
+		}
+		if err := s.tickJob(job, now); err != nil {
+			log.Printf("WARNING: Schedule %q: %v", job.Name, err)
+		}
+	}
+}
+
+// tickJob queues the due runs of one job, following its catch-up policy.
+func (s *Scheduler) tickJob(job *Job, now time.Time) error {
+	st, ok := s.cfg.State.Get(job.Name)
+	if !ok || st.LastDue.IsZero() {
+		// A new schedule starts now; nothing before it was missed
+		return s.cfg.State.Update(job.Name, func(st *JobState) { st.LastDue = now })
+	}
+
+	// The most recent due time, and the catch-up window counted back from it
+	from, to := st.LastDue.In(job.Location), now.In(job.Location)
+	latest := job.Schedule.Prev(to)
+	if latest.IsZero() || !latest.After(from) {
+		return nil
+	}
+	recent := job.Schedule.Latest(from, latest, s.cfg.MaxCatchUp)
+
+	var runs []time.Time
+	switch {
+	case now.Sub(latest) < lateAfter:
+		// On time; anything before it was missed
+		runs = []time.Time{latest}
+		if job.CatchUp == CatchUpAll {
+			runs = recent
+		}
+	case job.CatchUp == CatchUpOnce:
+		runs = []time.Time{latest}
+	case job.CatchUp == CatchUpAll:
+		runs = recent
+	}
+
+	// Missed runs are counted up to a bound, so that a per-minute schedule
+	// left for months stays cheap
+	bound := 100 * s.cfg.MaxCatchUp
+	due := job.Schedule.Between(from, latest, bound)
+	if missed := len(due) - len(runs); missed > 0 {
+		count := strconv.Itoa(missed)
+		if len(due) == bound {
+			count += "+"
+		}
+		s.audit(job, "SCHEDULED_RUN_MISSED", map[string]string{
+			"missed":   count,
+			"since":    due[0].Format(time.RFC3339),
+			"catch_up": job.CatchUp,
+		})
+	}
+
+	// Runs never overlap: while the previous run is still going, the due
+	// runs are dropped rather than piled up behind it
+	if prev := s.activeTask(job); prev != nil && len(runs) > 0 {
+		s.audit(job, "SCHEDULED_RUN_SKIPPED", map[string]string{
+			"due":    latest.Format(time.RFC3339),
+			"runs":   strconv.Itoa(len(runs)),
+			"reason": "previous run still active",
+			"task":   prev.ID,
+		})
+		runs = nil
+	}
+
+	for _, dueAt := range runs {
+		if err := s.queue(job, dueAt); err != nil {
+			return err
+		}
+	}
+	return s.cfg.State.Update(job.Name, func(st *JobState) { st.LastDue = latest })
+}
+
+// activeTask returns the previous run of job if it has not finished.
+func (s *Scheduler) activeTask(job *Job) *tasks.Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if prev := s.active[job.Name]; prev != nil && !prev.IsComplete() {
+		return prev
+	}
+	return nil
+}
+
+// queue adds a run of job to the task queue.
+func (s *Scheduler) queue(job *Job, dueAt time.Time) error {
+	task := s.NewTask(job, dueAt)
+	if err := s.cfg.Queue.Add(task); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.active[job.Name] = task
+	s.mu.Unlock()
+
+	return s.cfg.State.Update(job.Name, func(st *JobState) {
+		st.LastDue = dueAt
+		st.LastRun = time.Now()
+		st.TaskID = task.ID
+		st.Status = ""
+		st.Error = ""
+	})
+}
+
+// NewTask creates the background task of a run of job due at dueAt.
+func (s *Scheduler) NewTask(job *Job, dueAt time.Time) *tasks.Task {
+	var task *tasks.Task
+	work := s.cfg.Work(job)
+	task = tasks.NewAgentTask("Scheduled: "+job.Name, func(ctx context.Context, progress tasks.ProgressFunc) (string, error) {
+		return s.recorded(task, job, dueAt, work)(ctx, progress)
+	})
+	task.Args = []string{job.Prompt}
+	task.Metadata[MetaSchedule] = job.Name
+	task.Metadata[MetaDue] = dueAt.Format(time.RFC3339)
+	task.Metadata["kind"] = job.Kind
+	task.Metadata["tier"] = router.TierLocal.String()
+	task.Metadata["model"] = job.Model
+	task.Metadata["work_dir"] = job.WorkDir
+	return task
+}
+
+// Restore rebuilds the work of a scheduled run loaded from the task store.
+// It reports false for tasks not queued by a scheduler.
+func (s *Scheduler) Restore(task *tasks.Task) (tasks.AgentFunc, bool, error) {
+	name, ok := task.Metadata[MetaSchedule].(string)
+	if !ok {
+		return nil, false, nil
+	}
+	job := s.jobs[name]
+	if job == nil {
+		return nil, true, fmt.Errorf("schedule %q no longer exists", name)
+	}
+	due, _ := task.Metadata[MetaDue].(string)
+	dueAt, err := time.Parse(time.RFC3339, due)
+	if err != nil {
+		return nil, true, fmt.Errorf("invalid due time %q", due)
+	}
+
+	s.mu.Lock()
+	s.active[job.Name] = task
+	s.mu.Unlock()
+	return s.recorded(task, job, dueAt, s.cfg.Work(job)), true, nil
+}
+
+// recorded wraps the work of a run to record its outcome.
+func (s *Scheduler) recorded(task *tasks.Task, job *Job, dueAt time.Time, work tasks.AgentFunc) tasks.AgentFunc {
+	return func(ctx context.Context, progress tasks.ProgressFunc) (string, error) {
+		start := time.Now()
+		summary, err := work(ctx, progress)
+		s.record(task, job, dueAt, start, summary, err)
+		return summary, err
+	}
+}
+
+// record saves a finished run to the conversation store, the audit log
+// and the job's state.
+func (s *Scheduler) record(task *tasks.Task, job *Job, dueAt, start time.Time, summary string, runErr error) {
+	status, errText := "success", ""
+	switch {
+	case errors.Is(runErr, context.Canceled):
+		status, errText = "canceled", runErr.Error()
+	case runErr != nil:
+		status, errText = "failed", runErr.Error()
+	}
+
+	convID := ""
+	if s.cfg.Conversations != nil {
+		id, err := s.cfg.Conversations.Save(runConversation(job, dueAt, start, summary, errText))
+		if err != nil {
+			log.Printf("WARNING: Could not save run of schedule %q: %v", job.Name, err)
+		}
+		convID = id
+	}
+
+	// AU-2: Every scheduled run is recorded, successful or not
+	s.audit(job, "SCHEDULED_RUN", map[string]string{
+		"due":          dueAt.Format(time.RFC3339),
+		"task":         task.ID,
+		"status":       status,
+		"error":        errText,
+		"duration_ms":  strconv.FormatInt(time.Since(start).Milliseconds(), 10),
+		"conversation": convID,
+	})
+
+	if err := s.cfg.State.Update(job.Name, func(st *JobState) {
+		if st.TaskID == task.ID || st.TaskID == "" {
+			st.Status = status
+			st.Error = errText
+			st.ConversationID = convID
+		}
+	}); err != nil {
+		log.Printf("WARNING: Schedule %q: %v", job.Name, err)
+	}
+}
+
+// runConversation records a run as a conversation: the prompt, then the
+// answer or the error.
+func runConversation(job *Job, dueAt, start time.Time, summary, errText string) *storage.StoredConversation {
+	conv := &storage.StoredConversation{
+		Summary:   fmt.Sprintf("Scheduled: %s (%s)", job.Name, dueAt.Format("2006-01-02 15:04")),
+		Model:     job.Model,
+		CreatedAt: start,
+		Messages: []storage.StoredMessage{{
+			ID:        uuid.New().String(),
+			Role:      "user",
+			Content:   job.Prompt,
+			Timestamp: start,
+		}},
+	}
+	if summary != "" {
+		conv.Messages = append(conv.Messages, storage.StoredMessage{
+			ID:         uuid.New().String(),
+			Role:       "assistant",
+			Content:    summary,
+			Timestamp:  time.Now(),
+			ParentID:   conv.Messages[0].ID,
+			DurationMs: time.Since(start).Milliseconds(),
+		})
+	}
+	if errText != "" {
+		conv.Messages = append(conv.Messages, storage.StoredMessage{
+			ID:        uuid.New().String(),
+			Role:      "system",
+			Content:   "Scheduled run failed: " + errText,
+			Timestamp: time.Now(),
+			ParentID:  conv.Messages[len(conv.Messages)-1].ID,
+		})
+	}
+	return conv
+}
+
+// audit records a scheduler event for job.
+func (s *Scheduler) audit(job *Job, event string, metadata map[string]string) {
+	metadata["schedule"] = job.Name
+	metadata["kind"] = job.Kind
+	security.AuditLogEvent(auditSession, event, metadata)
+}
@@ -0,0 +1,250 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package schedule
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jeranaias/rigrun-tui/internal/config"
+	"github.com/jeranaias/rigrun-tui/internal/storage"
+	"github.com/jeranaias/rigrun-tui/internal/tasks"
+)
+
+// testJob returns an hourly job with the given catch-up policy.
+func testJob(t *testing.T, catchUp string) *Job {
+	t.Helper()
+	job, err := newJob(config.ScheduleConfig{
+		Name:     "hourly",
+		Cron:     "0 * * * *",
+		Prompt:   "Summarize the logs",
+		Timezone: "UTC",
+		CatchUp:  catchUp,
+	}, "config", t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("newJob failed: %v", err)
+	}
+	return job
+}
+
+// testScheduler returns a scheduler of job whose runs return summary and
+// runErr, with its state in a temp dir.
+func testScheduler(t *testing.T, job *Job, summary string, runErr error) (*Scheduler, *tasks.Queue) {
+	t.Helper()
+	state, err := LoadState(filepath.Join(t.TempDir(), StateFile))
+	if err != nil {
+		t.Fatalf("LoadState failed: %v", err)
+	}
+	queue := tasks.NewQueue(0)
+	s := New(Config{
+		Jobs:  []*Job{job},
+		State: state,
+		Queue: queue,
+		Work: func(job *Job) tasks.AgentFunc {
+			return func(ctx context.Context, progress tasks.ProgressFunc) (string, error) {
+				return summary, runErr
+			}
+		},
+		MaxCatchUp: 3,
+	})
+	return s, queue
+}
+
+// dueTimes returns the due times of the queued runs.
+func dueTimes(queue *tasks.Queue) []string {
+	var due []string
+	for _, task := range queue.Queued() {
+		due = append(due, task.Metadata[MetaDue].(string))
+	}
+	return due
+}
+
+func TestTickCatchUpPolicies(t *testing.T) {
+	lastDue := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	now := time.Date(2025, 3, 1, 15, 30, 0, 0, time.UTC) // 11:00-15:00 missed
+
+	tests := []struct {
+		catchUp string
+		want    []string
+	}{
+		{CatchUpSkip, nil},
+		{CatchUpOnce, []string{"2025-03-01T15:00:00Z"}},
+		{CatchUpAll, []string{"2025-03-01T13:00:00Z", "2025-03-01T14:00:00Z", "2025-03-01T15:00:00Z"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.catchUp, func(t *testing.T) {
+			s, queue := testScheduler(t, testJob(t, tt.catchUp), "ok", nil)
+			s.cfg.State.Update("hourly", func(st *JobState) { st.LastDue = lastDue })
+
+			s.Tick(now)
+
+			got := dueTimes(queue)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected runs %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Expected runs %v, got %v", tt.want, got)
+				}
+			}
+			if st, _ := s.cfg.State.Get("hourly"); !st.LastDue.Equal(now.Truncate(time.Hour)) {
+				t.Errorf("Expected missed runs handled up to 15:00, got %v", st.LastDue)
+			}
+
+			// Nothing more is due until 16:00
+			s.Tick(now.Add(time.Minute))
+			if n := len(queue.Queued()); n != len(tt.want) {
+				t.Errorf("Expected no new runs, got %d queued", n)
+			}
+		})
+	}
+}
+
+func TestTickCatchUpAfterLongGap(t *testing.T) {
+	// Down for 30 days: far more missed hourly runs than 100*MaxCatchUp
+	lastDue := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2025, 1, 31, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		catchUp string
+		want    []string
+	}{
+		{CatchUpOnce, []string{"2025-01-31T12:00:00Z"}},
+		{CatchUpAll, []string{"2025-01-31T10:00:00Z", "2025-01-31T11:00:00Z", "2025-01-31T12:00:00Z"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.catchUp, func(t *testing.T) {
+			s, queue := testScheduler(t, testJob(t, tt.catchUp), "ok", nil)
+			s.cfg.State.Update("hourly", func(st *JobState) { st.LastDue = lastDue })
+
+			s.Tick(now)
+
+			got := dueTimes(queue)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected runs %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Expected runs %v, got %v", tt.want, got)
+				}
+			}
+			if st, _ := s.cfg.State.Get("hourly"); !st.LastDue.Equal(now.Truncate(time.Hour)) {
+				t.Errorf("Expected missed runs handled up to 12:00, got %v", st.LastDue)
+			}
+
+			// The next tick finds nothing new
+			s.Tick(now.Add(time.Minute))
+			if n := len(queue.Queued()); n != len(tt.want) {
+				t.Errorf("Expected no new runs, got %d queued", n)
+			}
+		})
+	}
+}
+
+func TestTickStartsNewScheduleNow(t *testing.T) {
+	s, queue := testScheduler(t, testJob(t, CatchUpAll), "ok", nil)
+	now := time.Date(2025, 3, 1, 15, 30, 0, 0, time.UTC)
+
+	s.Tick(now)
+	if n := len(queue.Queued()); n != 0 {
+		t.Fatalf("Expected a new schedule to have missed nothing, got %d runs", n)
+	}
+
+	s.Tick(now.Add(30*time.Minute + 10*time.Second))
+	if got := dueTimes(queue); len(got) != 1 || got[0] != "2025-03-01T16:00:00Z" {
+		t.Errorf("Expected the 16:00 run, got %v", got)
+	}
+}
+
+func TestTickSkipsOverlappingRun(t *testing.T) {
+	s, queue := testScheduler(t, testJob(t, CatchUpOnce), "ok", nil)
+	start := time.Date(2025, 3, 1, 15, 59, 0, 0, time.UTC)
+	s.Tick(start)
+
+	s.Tick(start.Add(time.Minute))
+	if n := len(queue.Queued()); n != 1 {
+		t.Fatalf("Expected the 16:00 run, got %d runs", n)
+	}
+
+	// The 16:00 run has not started by 17:00
+	s.Tick(start.Add(61 * time.Minute))
+	if n := len(queue.Queued()); n != 1 {
+		t.Errorf("Expected the 17:00 run skipped while 16:00 is queued, got %d runs", n)
+	}
+	if st, _ := s.cfg.State.Get("hourly"); st.LastDue.Hour() != 17 {
+		t.Errorf("Expected the skipped run handled, got last due %v", st.LastDue)
+	}
+}
+
+func TestRunIsRecorded(t *testing.T) {
+	tests := []struct {
+		name    string
+		summary string
+		err     error
+		status  string
+	}{
+		{"success", "Logs are clean", nil, "success"},
+		{"failure", "", errors.New("model unavailable"), "failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := testJob(t, CatchUpOnce)
+			s, _ := testScheduler(t, job, tt.summary, tt.err)
+			conversations, err := storage.NewConversationStoreWithDir(t.TempDir())
+			if err != nil {
+				t.Fatalf("NewConversationStoreWithDir failed: %v", err)
+			}
+			defer conversations.Close()
+			s.cfg.Conversations = conversations
+
+			dueAt := time.Date(2025, 3, 1, 16, 0, 0, 0, time.UTC)
+			if err := s.queue(job, dueAt); err != nil {
+				t.Fatalf("queue failed: %v", err)
+			}
+			task := s.activeTask(job)
+			if task == nil {
+				t.Fatal("Expected the run to be queued")
+			}
+			tasks.Execute(task)
+
+			st, _ := s.cfg.State.Get("hourly")
+			if st.Status != tt.status || st.ConversationID == "" {
+				t.Fatalf("Expected %s run with a conversation, got %+v", tt.status, st)
+			}
+			conv, err := conversations.Load(st.ConversationID)
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			if len(conv.Messages) < 2 || conv.Messages[0].Content != job.Prompt {
+				t.Fatalf("Expected the prompt and its outcome, got %+v", conv.Messages)
+			}
+			if last := conv.Messages[len(conv.Messages)-1]; tt.err == nil && last.Content != tt.summary {
+				t.Errorf("Expected the summary as the answer, got %q", last.Content)
+			}
+		})
+	}
+}
+
+func TestRestoreScheduledTask(t *testing.T) {
+	job := testJob(t, CatchUpOnce)
+	s, _ := testScheduler(t, job, "ok", nil)
+
+	task := s.NewTask(job, time.Date(2025, 3, 1, 16, 0, 0, 0, time.UTC))
+	if _, owned, err := s.Restore(task); !owned || err != nil {
+		t.Errorf("Expected the scheduler to restore its task, got owned=%v err=%v", owned, err)
+	}
+
+	task.Metadata[MetaSchedule] = "removed"
+	if _, owned, err := s.Restore(task); !owned || err == nil {
+		t.Error("Expected an error for a schedule that no longer exists")
+	}
+
+	other := tasks.NewAgentTask("Agent", nil)
+	if _, owned, _ := s.Restore(other); owned {
+		t.Error("Expected tasks not queued by a scheduler to be left to others")
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package schedule
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/jeranaias/rigrun-tui/internal/util"
+)
+
+// =============================================================================
+// STATE
+// =============================================================================
+
+// StateFile is the name of the scheduler state file in ~/.rigrun.
+const StateFile = "schedules.json"
+
+// JobState is what the scheduler remembers about a schedule between runs.
+type JobState struct {
+	// LastDue is the latest due time handled, whether run or skipped.
+	// Missed runs are the due times after it.
+	LastDue time.Time `json:"last_due"`
+
+	// LastRun is when the last run was queued
+	LastRun time.Time `json:"last_run,omitzero"`
+
+	// TaskID is the background task of the last run
+	TaskID string `json:"task_id,omitempty"`
+
+	// Status is the outcome of the last finished run ("" while it runs)
+	Status string `json:"status,omitempty"`
+
+	// Error is the error of the last finished run
+	Error string `json:"error,omitempty"`
+
+	// ConversationID is the conversation the last run was saved to
+	ConversationID string `json:"conversation_id,omitempty"`
+}
+
+// State persists the JobState of every schedule, by name, to a JSON file.
+// It is safe for concurrent use.
+type State struct {
+	mu   sync.Mutex
+	path string
+	jobs map[string]JobState
+}
+
+// LoadState loads the state file at path ("" = ~/.rigrun/schedules.json).
+// A missing file is an empty state.
+func LoadState(path string) (*State, error) {
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get home directory: %w", err)
+		}
+		path = filepath.Join(home, ".rigrun", StateFile)
+	}
+
+	s := &State{path: path, jobs: make(map[string]JobState)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scheduler state: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.jobs); err != nil {
+		return nil, fmt.Errorf("invalid scheduler state %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Get returns the state of a schedule, and whether it has any.
+func (s *State) Get(name string) (JobState, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.jobs[name]
+	return st, ok
+}
+
+// Update changes the state of a schedule and saves the file.
+func (s *State) Update(name string, fn func(st *JobState)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := s.jobs[name]
+	fn(&st)
+	s.jobs[name] = st
+
+	data, err := json.MarshalIndent(s.jobs, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := util.AtomicWriteFileWithDir(s.path, data, 0600, 0700); err != nil {
+		return fmt.Errorf("failed to save scheduler state: %w", err)
+	}
+	return nil
+}
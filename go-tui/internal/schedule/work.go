@@ -0,0 +1,112 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/jeranaias/rigrun-tui/internal/instructions"
+	"github.com/jeranaias/rigrun-tui/internal/ollama"
+	"github.com/jeranaias/rigrun-tui/internal/plan"
+	"github.com/jeranaias/rigrun-tui/internal/tasks"
+	"github.com/jeranaias/rigrun-tui/internal/tools"
+)
+
+// =============================================================================
+// WORK
+// =============================================================================
+
+// Backend builds the work of scheduled runs on the local model. Runs are
+// unattended, so tool calls that need approval are denied (see
+// tasks.NewBackgroundExecutor).
+type Backend struct {
+	// Client is the Ollama client; a job without a model uses its default
+	Client *ollama.Client
+
+	// Registry provides the tools of agentic asks and plans, and their policy
+	Registry *tools.Registry
+
+	// PlanStore, if set, saves plans so that they can be resumed
+	PlanStore *plan.Store
+}
+
+// Work returns the work of a run of job.
+func (b *Backend) Work(job *Job) tasks.AgentFunc {
+	switch {
+	case job.Kind == KindPlan:
+		return b.plan(job.Prompt, job.Model, job.WorkDir)
+	case job.Agentic:
+		return b.agent(job.Prompt, job.Model, job.WorkDir)
+	default:
+		return b.ask(job.Prompt, job.Model, job.WorkDir)
+	}
+}
+
+// Restore rebuilds the work of an agent task queued from the TUI ("agent"
+// or "plan"), so that the daemon can run tasks a TUI session left queued.
+func (b *Backend) Restore(task *tasks.Task) (tasks.AgentFunc, error) {
+	kind, _ := task.Metadata["kind"].(string)
+	model, _ := task.Metadata["model"].(string)
+	prompt := strings.Join(task.Args, " ")
+	workDir, _ := task.Metadata["work_dir"].(string)
+	switch kind {
+	case "plan":
+		return b.plan(prompt, model, workDir), nil
+	case "agent":
+		return b.agent(prompt, model, workDir), nil
+	}
+	return nil, fmt.Errorf("unknown agent task kind %q", kind)
+}
+
+// model returns the model to use, defaulting to the client's.
+func (b *Backend) model(model string) string {
+	if model == "" {
+		return b.Client.GetDefaultModel()
+	}
+	return model
+}
+
+// ask answers the prompt without tools.
+func (b *Backend) ask(prompt, model, workDir string) tasks.AgentFunc {
+	return func(ctx context.Context, progress tasks.ProgressFunc) (string, error) {
+		model := b.model(model)
+		progress("Asking "+model, 0)
+		messages := []ollama.Message{
+			ollama.NewSystemMessage(instructions.Load(workDir).Apply(tools.GenerateSmallModelPrompt())),
+			ollama.NewUserMessage(prompt),
+		}
+		resp, err := b.Client.Chat(ctx, model, messages)
+		if err != nil {
+			return "", err
+		}
+		progress("Answered", 100)
+		return strings.TrimSpace(resp.Message.Content), nil
+	}
+}
+
+// agent answers the prompt with an agentic loop.
+func (b *Backend) agent(prompt, model, workDir string) tasks.AgentFunc {
+	model = b.model(model)
+	return tasks.AgentLoop(tasks.AgentConfig{
+		Prompt:       prompt,
+		SystemPrompt: instructions.Load(workDir).Apply(tools.GenerateAgenticLoopPromptWithContext(runtime.GOOS, workDir)),
+		Chat:         tasks.OllamaChat(b.Client, model, b.Registry),
+		Registry:     b.Registry,
+		WorkDir:      workDir,
+	})
+}
+
+// plan generates a plan for the prompt and executes it.
+func (b *Backend) plan(prompt, model, workDir string) tasks.AgentFunc {
+	return tasks.PlanAgent(tasks.PlanConfig{
+		Task:      prompt,
+		Generator: plan.NewGenerator(plan.NewOllamaLLM(b.Client, b.model(model))),
+		Registry:  b.Registry,
+		WorkDir:   workDir,
+		Store:     b.PlanStore,
+	})
+}
@@ -479,7 +479,7 @@ func (p *AuditProtector) ArchiveLogs(retentionDays int) error {
 	}
 
 	// Create archive directory
-	archiveDir := filepath.Join(filepath.Dir(p.auditLogPath), "archive")
+	archiveDir := p.ArchiveDir()
 	if err := os.MkdirAll(archiveDir, 0700); err != nil {
 		return fmt.Errorf("failed to create archive directory: %w", err)
 	}
@@ -554,6 +554,43 @@ func (p *AuditProtector) ArchiveLogs(retentionDays int) error {
 	return nil
 }
 
+// ArchiveDir returns the directory ArchiveLogs moves old logs to.
+func (p *AuditProtector) ArchiveDir() string {
+	return auditArchiveDir(p.auditLogPath)
+}
+
+// auditArchiveDir returns the archive directory of an audit log.
+func auditArchiveDir(auditLogPath string) string {
+	return filepath.Join(filepath.Dir(auditLogPath), "archive")
+}
+
+// AuditArchives is the directory of archived audit logs, as a store kept
+// under the audit archive retention policy (SI-12). Archived logs are never
+// purged before the AU-11 retention period, whatever the policy.
+type AuditArchives struct {
+	Dir string
+}
+
+// DefaultAuditArchives returns the archives of the default audit log.
+func DefaultAuditArchives() AuditArchives {
+	return AuditArchives{Dir: auditArchiveDir(DefaultAuditPath())}
+}
+
+// RetentionClass returns the data class of archived logs.
+func (a AuditArchives) RetentionClass() string {
+	return RetentionAuditArchives
+}
+
+// RetentionItems lists the archived logs.
+func (a AuditArchives) RetentionItems() ([]RetentionItem, error) {
+	return RetentionFiles(a.Dir, func(string) bool { return true })
+}
+
+// Purge securely deletes archived logs.
+func (a AuditArchives) Purge(items []RetentionItem) error {
+	return PurgeFiles(a.Dir, items)
+}
+
 // verifyArchive verifies that an archived file matches the original.
 func (p *AuditProtector) verifyArchive(srcPath, dstPath string) error {
 	// Compute hash of source file
@@ -11,6 +11,8 @@
 //   - IA-2(1): Multi-factor authentication for network access (placeholder)
 //   - IA-2(8): Network access to privileged accounts requires replay-resistant auth
 //   - IA-5: Authenticator management (API key validation)
+//   - IA-5(1): One-time recovery codes as backup authenticators
+//   - IA-2(12): PIV/CAC certificate login against the PKI trust store
 //   - AU-3: Authentication events must be logged for audit compliance
 package security
 
@@ -71,6 +73,9 @@ const (
 	// AuthMethodCertificate indicates CAC/PKI certificate-based authentication (IA-2(12)).
 	AuthMethodCertificate AuthMethod = "certificate"
 
+	// AuthMethodRecoveryCode indicates a one-time recovery code (IA-5(1)).
+	AuthMethodRecoveryCode AuthMethod = "recovery_code"
+
 	// AuthMethodNone indicates no authentication.
 	AuthMethodNone AuthMethod = "none"
 )
@@ -304,6 +309,13 @@ type AuthManager struct {
 	// pkiConfig holds the PKI/CAC certificate authentication configuration (IA-2(12)).
 	pkiConfig *PKIConfig
 
+	// pkiManager verifies client certificates against its trust store when
+	// pkiConfig is not enabled (IA-2(12)).
+	pkiManager *PKIManager
+
+	// recoveryCodes stores one-time recovery codes (IA-5(1)).
+	recoveryCodes *RecoveryCodeStore
+
 	// mu protects concurrent access.
 	mu sync.RWMutex
 }
@@ -369,6 +381,21 @@ func WithPKIConfig(config *PKIConfig) AuthManagerOption {
 	}
 }
 
+// WithPKIManager enables certificate authentication against the PKI manager's
+// trust store (IA-2(12)).
+func WithPKIManager(pm *PKIManager) AuthManagerOption {
+	return func(a *AuthManager) {
+		a.pkiManager = pm
+	}
+}
+
+// WithRecoveryCodes sets the recovery code store (IA-5(1)).
+func WithRecoveryCodes(store *RecoveryCodeStore) AuthManagerOption {
+	return func(a *AuthManager) {
+		a.recoveryCodes = store
+	}
+}
+
 // NewAuthManager creates a new AuthManager with the given options.
 func NewAuthManager(opts ...AuthManagerOption) *AuthManager {
 	am := &AuthManager{
@@ -527,16 +554,55 @@ func (a *AuthManager) authenticateAPIKey(apiKey string) (*AuthSession, error) {
 // The certificate is validated against the PKI configuration, and if valid,
 // a session is created with the certificate information attached.
 func (a *AuthManager) AuthenticateCertificate(cert *x509.Certificate) (*AuthSession, error) {
+	return a.AuthenticateCertificateChain(cert, nil)
+}
+
+// AuthenticateCertificateChain authenticates with a client certificate and any
+// intermediates presented with it (IA-2(12)).
+//
+// The certificate is validated against the PKI configuration when enabled,
+// otherwise against the PKI manager's trust store. Failed attempts are tracked
+// per certificate fingerprint for AC-7 lockout.
+func (a *AuthManager) AuthenticateCertificateChain(cert *x509.Certificate, intermediates []*x509.Certificate) (*AuthSession, error) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
+	if cert == nil {
+		return nil, ErrNoClientCertificate
+	}
+
 	// Check if PKI authentication is enabled
-	if a.pkiConfig == nil || !a.pkiConfig.Enabled {
+	pkiEnabled := a.pkiConfig != nil && a.pkiConfig.Enabled
+	if !pkiEnabled && a.pkiManager == nil {
 		return nil, errors.New("certificate authentication is not enabled")
 	}
 
+	// Check if locked out (AC-7 integration)
+	identifier := generateAuthIdentifier(AuthMethodCertificate, ComputeCertFingerprint(cert))
+	if a.lockout != nil && a.lockout.IsLocked(identifier) {
+		a.logEvent("AUTH_LOGIN", identifier, false, map[string]string{
+			"method": string(AuthMethodCertificate),
+			"error":  "locked_out",
+		})
+		return nil, ErrLocked
+	}
+
 	// Validate the certificate
-	certInfo, err := ValidateClientCertificate(cert, a.pkiConfig)
+	var certInfo *CertificateInfo
+	var err error
+	if pkiEnabled {
+		certInfo, err = ValidateClientCertificate(cert, a.pkiConfig)
+	} else {
+		certInfo, err = a.pkiManager.VerifyClientCertificate(cert, intermediates)
+	}
+
+	// Record attempt for lockout tracking
+	if a.lockout != nil {
+		if recordErr := a.lockout.RecordAttempt(identifier, err == nil); errors.Is(recordErr, ErrLocked) {
+			return nil, recordErr
+		}
+	}
+
 	if err != nil {
 		a.logEvent("AUTH_LOGIN", "certificate", false, map[string]string{
 			"method": string(AuthMethodCertificate),
@@ -545,34 +611,17 @@ func (a *AuthManager) AuthenticateCertificate(cert *x509.Certificate) (*AuthSess
 		return nil, fmt.Errorf("certificate validation failed: %w", err)
 	}
 
-	// Create session with certificate information
-	now := time.Now()
-
 	// Derive user ID from certificate (use EDIPI if available, otherwise subject)
 	userID := certInfo.EDIPI
 	if userID == "" {
 		userID = "cert_" + certInfo.Fingerprint[:16]
 	}
 
-	sessionID, err := generateSessionID()
+	session, err := a.newSessionLocked(userID, AuthMethodCertificate)
 	if err != nil {
-		a.logEvent("AUTH_LOGIN", "certificate", false, map[string]string{
-			"method": string(AuthMethodCertificate),
-			"error":  "session_id_generation_failed",
-		})
-		return nil, fmt.Errorf("failed to generate session ID: %w", err)
-	}
-
-	session := &AuthSession{
-		UserID:          userID,
-		SessionID:       sessionID,
-		AuthenticatedAt: now,
-		ExpiresAt:       now.Add(a.sessionDuration),
-		AuthMethod:      AuthMethodCertificate,
-		CertificateInfo: certInfo,
-		LastActivity:    now,
-		Metadata:        make(map[string]string),
+		return nil, err
 	}
+	session.CertificateInfo = certInfo
 
 	// Add certificate metadata
 	session.Metadata["cert_subject"] = certInfo.Subject
@@ -585,10 +634,6 @@ func (a *AuthManager) AuthenticateCertificate(cert *x509.Certificate) (*AuthSess
 		session.Metadata["email"] = certInfo.Email
 	}
 
-	// Store session
-	a.sessions[session.SessionID] = session
-	a.userSessions[session.UserID] = session.SessionID
-
 	a.logEvent("AUTH_LOGIN", sanitizeSessionIDForLog(session.SessionID), true, map[string]string{
 		"method":      string(AuthMethodCertificate),
 		"user_id":     userID,
@@ -598,6 +643,34 @@ func (a *AuthManager) AuthenticateCertificate(cert *x509.Certificate) (*AuthSess
 	return session, nil
 }
 
+// newSessionLocked creates and stores a session. Caller must hold a.mu.
+func (a *AuthManager) newSessionLocked(userID string, method AuthMethod) (*AuthSession, error) {
+	sessionID, err := generateSessionID()
+	if err != nil {
+		a.logEvent("AUTH_LOGIN", "unknown", false, map[string]string{
+			"method": string(method),
+			"error":  "session_id_generation_failed",
+		})
+		return nil, fmt.Errorf("failed to generate session ID: %w", err)
+	}
+
+	now := time.Now()
+	session := &AuthSession{
+		UserID:          userID,
+		SessionID:       sessionID,
+		AuthenticatedAt: now,
+		ExpiresAt:       now.Add(a.sessionDuration),
+		AuthMethod:      method,
+		LastActivity:    now,
+		Metadata:        make(map[string]string),
+	}
+
+	a.sessions[session.SessionID] = session
+	a.userSessions[session.UserID] = session.SessionID
+
+	return session, nil
+}
+
 // ValidateAPIKey checks if an API key is valid.
 // This is a public method for use in other packages.
 func (a *AuthManager) ValidateAPIKey(key string) bool {
@@ -1107,6 +1180,156 @@ func (a *AuthManager) SetTOTPSecret(userID string, secret string) error {
 	return nil
 }
 
+// =============================================================================
+// RECOVERY CODES (IA-5(1))
+// =============================================================================
+
+// RecoveryCodes returns the recovery code store, or nil if not configured.
+func (a *AuthManager) RecoveryCodes() *RecoveryCodeStore {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.recoveryCodes
+}
+
+// VerifyRecoveryCode completes MFA for an existing session with a one-time
+// recovery code in place of the user's TOTP code.
+func (a *AuthManager) VerifyRecoveryCode(sessionID string, code string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	session, exists := a.sessions[sessionID]
+	if !exists {
+		return errors.New("session not found")
+	}
+
+	if session.IsExpired() {
+		return errors.New("session expired")
+	}
+
+	session.mu.RLock()
+	userID := session.UserID
+	session.mu.RUnlock()
+
+	if err := a.consumeRecoveryCodeLocked(userID, code, "AUTH_MFA_VERIFY"); err != nil {
+		return err
+	}
+
+	remaining := a.recoveryCodes.Remaining(userID)
+	session.mu.Lock()
+	session.MFAVerified = true
+	session.Metadata["recovery_codes_remaining"] = fmt.Sprintf("%d", remaining)
+	session.mu.Unlock()
+
+	a.logEvent("AUTH_MFA_VERIFY", sanitizeSessionIDForLog(sessionID), true, map[string]string{
+		"user_id":         userID,
+		"method":          string(AuthMethodRecoveryCode),
+		"codes_remaining": fmt.Sprintf("%d", remaining),
+	})
+
+	return nil
+}
+
+// GenerateRecoveryCodes issues a new set of recovery codes for userID on
+// behalf of the holder of sessionID. The session must either belong to userID
+// and have completed MFA, or belong to a user granted PermUserModify in rbac
+// (AC-6). Any previously issued codes for userID are invalidated.
+func (a *AuthManager) GenerateRecoveryCodes(sessionID, userID string, rbac *RBACManager) ([]string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.recoveryCodes == nil {
+		return nil, errors.New("recovery codes are not enabled")
+	}
+
+	session, exists := a.sessions[sessionID]
+	if !exists {
+		return nil, errors.New("session not found")
+	}
+	if session.IsExpired() {
+		return nil, ErrSessionExpired
+	}
+
+	session.mu.RLock()
+	sessionUser := session.UserID
+	mfaVerified := session.MFAVerified
+	session.mu.RUnlock()
+
+	if userID == "" {
+		userID = sessionUser
+	}
+
+	isAdmin := rbac != nil && rbac.CheckPermission(sessionUser, PermUserModify)
+	var denied error
+	switch {
+	case isAdmin:
+	case userID != sessionUser:
+		denied = ErrRecoveryCodesNotPermitted
+	case !mfaVerified:
+		denied = ErrMFARequired
+	}
+	if denied != nil {
+		a.logEvent("RECOVERY_CODES_GENERATED", sanitizeSessionIDForLog(sessionID), false, map[string]string{
+			"user_id":      userID,
+			"requested_by": sessionUser,
+			"error":        denied.Error(),
+		})
+		return nil, denied
+	}
+
+	codes, err := a.recoveryCodes.Generate(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	a.logEvent("RECOVERY_CODES_GENERATED", sanitizeSessionIDForLog(sessionID), true, map[string]string{
+		"user_id":      userID,
+		"requested_by": sessionUser,
+		"count":        fmt.Sprintf("%d", len(codes)),
+	})
+	return codes, nil
+}
+
+// consumeRecoveryCodeLocked verifies and consumes a recovery code with AC-7
+// lockout tracking per user. Caller must hold a.mu.
+func (a *AuthManager) consumeRecoveryCodeLocked(userID, code, eventType string) error {
+	if a.recoveryCodes == nil {
+		return errors.New("recovery codes are not enabled")
+	}
+	if userID == "" {
+		return errors.New("user ID required")
+	}
+	if code == "" {
+		return errors.New("recovery code required")
+	}
+
+	identifier := generateAuthIdentifier(AuthMethodRecoveryCode, userID)
+	if a.lockout != nil && a.lockout.IsLocked(identifier) {
+		a.logEvent(eventType, identifier, false, map[string]string{
+			"method": string(AuthMethodRecoveryCode),
+			"error":  "locked_out",
+		})
+		return ErrLocked
+	}
+
+	err := a.recoveryCodes.Verify(userID, code)
+
+	if a.lockout != nil {
+		if recordErr := a.lockout.RecordAttempt(identifier, err == nil); errors.Is(recordErr, ErrLocked) {
+			return recordErr
+		}
+	}
+
+	if err != nil {
+		a.logEvent(eventType, identifier, false, map[string]string{
+			"method":  string(AuthMethodRecoveryCode),
+			"user_id": userID,
+			"error":   err.Error(),
+		})
+		return err
+	}
+	return nil
+}
+
 // IsMFARequired returns whether MFA is required.
 func (a *AuthManager) IsMFARequired() bool {
 	a.mu.RLock()
@@ -1418,6 +1641,10 @@ var (
 
 	// ErrMFARequired indicates MFA verification is required.
 	ErrMFARequired = errors.New("MFA verification required (IA-2(1))")
+
+	// ErrRecoveryCodesNotPermitted indicates a non-administrator tried to
+	// issue recovery codes for another user.
+	ErrRecoveryCodesNotPermitted = errors.New("only administrators can generate recovery codes for another user (AC-6)")
 )
 
 // =============================================================================
@@ -0,0 +1,323 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package security provides NIST 800-53 SC-28 compliant encryption for data at rest.
+//
+// This file extends SC-28 to the stores of user content: conversations,
+// cost history, benchmark results and the codebase index. Each store seals
+// the values holding user content with the data cipher (see SetDataCipher)
+// and implements ProtectedStore, so that its values can be reported on,
+// encrypted after the fact, and re-encrypted when the master key rotates.
+package security
+
+import (
+	"crypto/cipher"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/jeranaias/rigrun-tui/internal/util"
+)
+
+// =============================================================================
+// DATA CIPHER
+// =============================================================================
+
+// FieldCipher seals and opens individual values as ENC: strings.
+// EncryptionManager implements it.
+type FieldCipher interface {
+	// EncryptString seals plaintext as an ENC: value.
+	EncryptString(plaintext string) (string, error)
+	// DecryptString opens an ENC: value; other values are returned as-is.
+	DecryptString(ciphertext string) (string, error)
+}
+
+var (
+	dataCipherMu sync.RWMutex
+	dataCipher   FieldCipher
+)
+
+// SetDataCipher sets the cipher the stores of user content use for what
+// they write. nil (the default) leaves new data unencrypted.
+func SetDataCipher(c FieldCipher) {
+	dataCipherMu.Lock()
+	defer dataCipherMu.Unlock()
+	dataCipher = c
+}
+
+// DataCipher returns the cipher set by SetDataCipher, or nil. Stores read
+// it when they are opened.
+func DataCipher() FieldCipher {
+	dataCipherMu.RLock()
+	defer dataCipherMu.RUnlock()
+	return dataCipher
+}
+
+// EnableDataEncryption makes the global encryption manager the data cipher
+// when encryption has been initialized, and reports whether it did.
+func EnableDataEncryption() bool {
+	em := GlobalEncryptionManager()
+	if !em.IsInitialized() {
+		return false
+	}
+	SetDataCipher(em)
+	return true
+}
+
+// SealField encrypts a value with c. Empty values, and every value when c
+// is nil, are returned unchanged.
+func SealField(c FieldCipher, value string) (string, error) {
+	if c == nil || value == "" || IsEncrypted(value) {
+		return value, nil
+	}
+	return c.EncryptString(value)
+}
+
+// OpenField decrypts a value sealed by SealField. Plaintext values, written
+// before encryption was enabled, are returned unchanged. With a nil c the
+// global encryption manager is used, so that data sealed earlier stays
+// readable after encryption of new data is turned off.
+func OpenField(c FieldCipher, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	if c == nil {
+		em := GlobalEncryptionManager()
+		if !em.IsInitialized() {
+			return "", ErrNotInitialized
+		}
+		c = em
+	}
+	return c.DecryptString(value)
+}
+
+// =============================================================================
+// PROTECTED STORES
+// =============================================================================
+
+// Recrypt maps a stored value to its replacement during EncryptExisting
+// and RotateKey.
+type Recrypt func(value string) (string, error)
+
+// StoreStatus reports how much of a store's user content is encrypted.
+type StoreStatus struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+
+	// Items counts the stored values holding user content
+	Items int `json:"items"`
+
+	// Encrypted counts those of Items that are encrypted
+	Encrypted int `json:"encrypted"`
+}
+
+// FullyEncrypted reports whether every value of the store is encrypted.
+func (s StoreStatus) FullyEncrypted() bool {
+	return s.Encrypted == s.Items
+}
+
+// ProtectedStore is a store of user content sealed with the data cipher.
+type ProtectedStore interface {
+	// EncryptionStatus counts the store's encrypted and plaintext values.
+	EncryptionStatus() (StoreStatus, error)
+
+	// Reencrypt replaces every value holding user content, encrypted or
+	// not, with recrypt's result. A store kept in one database does so in
+	// one transaction.
+	Reencrypt(recrypt Recrypt) error
+}
+
+// ReencryptFile replaces the content of a file stored as a single value
+// with recrypt's result. A plaintext file that recrypt encrypts is
+// securely deleted, not just replaced, so that its content leaves the disk.
+func ReencryptFile(path string, recrypt Recrypt) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	content := string(data)
+	updated, err := recrypt(content)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if updated == content {
+		return nil
+	}
+	if IsEncrypted(content) {
+		return util.AtomicWriteFile(path, []byte(updated), 0600)
+	}
+
+	tmpPath := path + ".tmp"
+	if err := util.AtomicWriteFile(tmpPath, []byte(updated), 0600); err != nil {
+		return err
+	}
+	if err := GlobalSanitizer().SecureDeleteFile(path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// ReencryptColumns replaces the values of columns in every row of table
+// with recrypt's result, returning how many rows changed. key names the
+// primary key columns. Stores kept in SQLite use it from Reencrypt.
+func ReencryptColumns(tx *sql.Tx, table string, key, columns []string, recrypt Recrypt) (int, error) {
+	rows, err := tx.Query(fmt.Sprintf("SELECT %s, %s FROM %s",
+		strings.Join(key, ", "), strings.Join(columns, ", "), table))
+	if err != nil {
+		return 0, err
+	}
+	type row struct {
+		key    []any
+		values []string
+	}
+	var changed []row
+	for rows.Next() {
+		r := row{key: make([]any, len(key)), values: make([]string, len(columns))}
+		dest := make([]any, 0, len(key)+len(columns))
+		for i := range r.key {
+			dest = append(dest, &r.key[i])
+		}
+		for i := range r.values {
+			dest = append(dest, &r.values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			rows.Close()
+			return 0, err
+		}
+
+		modified := false
+		for i, v := range r.values {
+			nv, err := recrypt(v)
+			if err != nil {
+				rows.Close()
+				return 0, err
+			}
+			if nv != v {
+				r.values[i] = nv
+				modified = true
+			}
+		}
+		if modified {
+			changed = append(changed, r)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(changed) == 0 {
+		return 0, nil
+	}
+
+	set := make([]string, len(columns))
+	for i, c := range columns {
+		set[i] = c + " = ?"
+	}
+	where := make([]string, len(key))
+	for i, k := range key {
+		where[i] = k + " = ?"
+	}
+	stmt, err := tx.Prepare(fmt.Sprintf("UPDATE %s SET %s WHERE %s",
+		table, strings.Join(set, ", "), strings.Join(where, " AND ")))
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+	for _, r := range changed {
+		args := make([]any, 0, len(columns)+len(key))
+		for _, v := range r.values {
+			args = append(args, v)
+		}
+		if _, err := stmt.Exec(append(args, r.key...)...); err != nil {
+			return 0, err
+		}
+	}
+	return len(changed), nil
+}
+
+// IsFileSealed reports whether a file stored as a single value is encrypted.
+func IsFileSealed(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	prefix := make([]byte, len(EncryptedPrefix))
+	n, _ := io.ReadFull(f, prefix)
+	return IsEncrypted(string(prefix[:n])), nil
+}
+
+// EncryptExisting encrypts the values the stores hold in plaintext, written
+// before encryption was enabled.
+func (e *EncryptionManager) EncryptExisting(stores ...ProtectedStore) error {
+	if !e.IsInitialized() {
+		return ErrNotInitialized
+	}
+
+	seal := func(value string) (string, error) {
+		return SealField(e, value)
+	}
+	for _, store := range stores {
+		if err := store.Reencrypt(seal); err != nil {
+			return err
+		}
+	}
+
+	AuditLogEvent("", "DATA_ENCRYPTED", map[string]string{
+		"stores": fmt.Sprintf("%d", len(stores)),
+	})
+	return nil
+}
+
+// recrypter returns the Recrypt that moves values sealed by from to to.
+// Values already sealed by to are kept, so a store interrupted part way can
+// be passed through it again; plaintext values are kept as well. The caller
+// holds e.mu.
+func (e *EncryptionManager) recrypter(from, to cipher.AEAD) Recrypt {
+	return func(value string) (string, error) {
+		if !IsEncrypted(value) {
+			return value, nil
+		}
+		data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, EncryptedPrefix))
+		if err != nil || len(data) < NonceSize {
+			return "", ErrInvalidCiphertext
+		}
+		nonce, sealed := data[:NonceSize], data[NonceSize:]
+
+		if _, err := to.Open(nil, nonce, sealed, nil); err == nil {
+			return value, nil
+		}
+		plaintext, err := from.Open(nil, nonce, sealed, nil)
+		if err != nil {
+			return "", ErrDecryptionFailed
+		}
+		// SECURITY: Zero plaintext to prevent memory disclosure
+		defer ZeroBytes(plaintext)
+
+		newNonce, err := e.generateUniqueNonce()
+		if err != nil {
+			return "", err
+		}
+		return EncryptedPrefix + base64.StdEncoding.EncodeToString(to.Seal(newNonce, newNonce, plaintext, nil)), nil
+	}
+}
+
+// reencryptStores moves the stores' values from one cipher to the other.
+// On failure the stores already moved are moved back. The caller holds e.mu.
+func (e *EncryptionManager) reencryptStores(stores []ProtectedStore, from, to cipher.AEAD) error {
+	for i, store := range stores {
+		if err := store.Reencrypt(e.recrypter(from, to)); err != nil {
+			for j := i; j >= 0; j-- {
+				_ = stores[j].Reencrypt(e.recrypter(to, from))
+			}
+			return err
+		}
+	}
+	return nil
+}
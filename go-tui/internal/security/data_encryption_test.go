@@ -0,0 +1,208 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package security provides IL5 security controls.
+//
+// This file contains tests for SC-28 encryption of the stores of user
+// content: sealing values, encrypting existing data and key rotation.
+package security
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// memKeyStore keeps the master key in memory.
+type memKeyStore struct {
+	key      []byte
+	storeErr error
+}
+
+func (m *memKeyStore) Store(key []byte) error {
+	if m.storeErr != nil {
+		return m.storeErr
+	}
+	m.key = append([]byte(nil), key...)
+	return nil
+}
+func (m *memKeyStore) Retrieve() ([]byte, error) { return m.key, nil }
+func (m *memKeyStore) Delete() error             { m.key = nil; return nil }
+func (m *memKeyStore) Exists() bool              { return m.key != nil }
+
+// memStore is a protected store of values in memory.
+type memStore struct {
+	values []string
+	err    error
+}
+
+func (m *memStore) EncryptionStatus() (StoreStatus, error) {
+	st := StoreStatus{Name: "memory"}
+	for _, v := range m.values {
+		st.Items++
+		if IsEncrypted(v) {
+			st.Encrypted++
+		}
+	}
+	return st, nil
+}
+
+func (m *memStore) Reencrypt(recrypt Recrypt) error {
+	for i, v := range m.values {
+		if m.err != nil && i == len(m.values)-1 {
+			return m.err
+		}
+		nv, err := recrypt(v)
+		if err != nil {
+			return err
+		}
+		m.values[i] = nv
+	}
+	return nil
+}
+
+// newRotationTestManager returns an initialized manager whose key is kept in
+// memory, with the default data paths in a temp dir.
+func newRotationTestManager(t *testing.T) (*EncryptionManager, *memKeyStore) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("USERPROFILE", t.TempDir())
+	em := createTestEncryptionManager(t, filepath.Join(t.TempDir(), "master.key"))
+	ks := &memKeyStore{}
+	em.keyStore = ks
+	return em, ks
+}
+
+func TestSealField(t *testing.T) {
+	em, _ := newRotationTestManager(t)
+
+	sealed, err := SealField(em, "prompt text")
+	require.NoError(t, err)
+	require.True(t, IsEncrypted(sealed))
+
+	opened, err := OpenField(em, sealed)
+	require.NoError(t, err)
+	require.Equal(t, "prompt text", opened)
+
+	// No cipher, empty values and plaintext values pass through
+	for _, v := range []string{"", "plain"} {
+		got, err := SealField(nil, v)
+		require.NoError(t, err)
+		require.Equal(t, v, got)
+		got, err = OpenField(em, v)
+		require.NoError(t, err)
+		require.Equal(t, v, got)
+	}
+}
+
+func TestEncryptExisting(t *testing.T) {
+	em, _ := newRotationTestManager(t)
+	store := &memStore{values: []string{"first prompt", "", "second prompt"}}
+
+	require.NoError(t, em.EncryptExisting(store))
+
+	st, _ := store.EncryptionStatus()
+	require.Equal(t, 2, st.Encrypted, "Expected the non-empty values encrypted")
+	opened, err := em.DecryptString(store.values[2])
+	require.NoError(t, err)
+	require.Equal(t, "second prompt", opened)
+}
+
+func TestRotateKey_ReencryptsStores(t *testing.T) {
+	em, ks := newRotationTestManager(t)
+	sealed, err := em.EncryptString("prompt text")
+	require.NoError(t, err)
+	store := &memStore{values: []string{sealed, "plaintext"}}
+
+	require.NoError(t, em.RotateKey(store))
+
+	require.NotNil(t, ks.key, "Expected the new key stored")
+	require.NotEqual(t, sealed, store.values[0], "Expected the value re-encrypted")
+	require.Equal(t, "plaintext", store.values[1], "Expected plaintext values left alone")
+	opened, err := em.DecryptString(store.values[0])
+	require.NoError(t, err)
+	require.Equal(t, "prompt text", opened)
+}
+
+func TestRotateKey_RollsBackStores(t *testing.T) {
+	tests := []struct {
+		name     string
+		storeErr error
+		keyErr   error
+	}{
+		{"store failure", errors.New("disk full"), nil},
+		{"key store failure", nil, errors.New("keyring locked")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			em, ks := newRotationTestManager(t)
+			ks.storeErr = tt.keyErr
+			var values []string
+			for _, text := range []string{"first", "second", "third"} {
+				v, err := em.EncryptString(text)
+				require.NoError(t, err)
+				values = append(values, v)
+			}
+			moved := &memStore{values: append([]string(nil), values...)}
+			failing := &memStore{values: append([]string(nil), values...), err: tt.storeErr}
+
+			require.Error(t, em.RotateKey(moved, failing))
+
+			// Every value opens with the key still in use
+			for _, store := range []*memStore{moved, failing} {
+				for i, v := range store.values {
+					opened, err := em.DecryptString(v)
+					require.NoError(t, err)
+					require.Equal(t, []string{"first", "second", "third"}[i], opened)
+				}
+			}
+		})
+	}
+}
+
+func TestRotateKey_StoreFailureKeepsFilesReadable(t *testing.T) {
+	em, ks := newRotationTestManager(t)
+	cachePath := DefaultCachePathForEncryption()
+	plainPath := filepath.Join(t.TempDir(), "cache.json")
+	require.NoError(t, os.WriteFile(plainPath, []byte(`{"q":"a"}`), 0600))
+	require.NoError(t, em.EncryptFile(plainPath, cachePath))
+	sealed, err := os.ReadFile(cachePath)
+	require.NoError(t, err)
+
+	sealedValue, err := em.EncryptString("prompt text")
+	require.NoError(t, err)
+	failing := &memStore{values: []string{sealedValue, sealedValue}, err: errors.New("disk full")}
+
+	require.Error(t, em.RotateKey(failing))
+
+	require.Nil(t, ks.key, "Expected no new key stored")
+	after, err := os.ReadFile(cachePath)
+	require.NoError(t, err)
+	require.Equal(t, string(sealed), string(after), "Expected the cache left under the old key")
+	decPath := filepath.Join(t.TempDir(), "cache.dec")
+	require.NoError(t, em.DecryptFile(cachePath, decPath))
+	opened, err := os.ReadFile(decPath)
+	require.NoError(t, err)
+	require.Equal(t, `{"q":"a"}`, string(opened))
+}
+
+func TestRotateKey_FileFailureRollsBackStores(t *testing.T) {
+	em, ks := newRotationTestManager(t)
+	auditPath := DefaultAuditPath() + ".enc"
+	require.NoError(t, os.MkdirAll(filepath.Dir(auditPath), 0700))
+	require.NoError(t, os.WriteFile(auditPath, []byte(EncryptedPrefix+"not base64"), 0600))
+
+	sealed, err := em.EncryptString("prompt text")
+	require.NoError(t, err)
+	store := &memStore{values: []string{sealed}}
+
+	require.Error(t, em.RotateKey(store))
+
+	require.Nil(t, ks.key, "Expected no new key stored")
+	opened, err := em.DecryptString(store.values[0])
+	require.NoError(t, err)
+	require.Equal(t, "prompt text", opened)
+}
@@ -642,8 +642,9 @@ func (e *EncryptionManager) DecryptConfigField(value string) (string, error) {
 
 // RotateKey generates a new master key and re-encrypts all data.
 // This is a critical operation that should be done with care.
-// It re-encrypts all existing encrypted data to prevent data loss.
-func (e *EncryptionManager) RotateKey() error {
+// It re-encrypts all existing encrypted data to prevent data loss, including
+// the values of the given stores of user content.
+func (e *EncryptionManager) RotateKey(stores ...ProtectedStore) error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
@@ -667,9 +668,19 @@ func (e *EncryptionManager) RotateKey() error {
 		return fmt.Errorf("failed to initialize cipher with new key: %w", err)
 	}
 
+	// Re-encrypt the stores first: they roll themselves back on failure,
+	// and the files below are moved back with them if they cannot be moved.
+	newCipher := e.cipher
+	if err := e.reencryptStores(stores, oldCipher, newCipher); err != nil {
+		e.cipher = oldCipher
+		return fmt.Errorf("failed to re-encrypt data stores, rolled back to old key: %w", err)
+	}
+
 	// Re-encrypt all existing data with new key
-	if err := e.reencryptAllData(oldCipher); err != nil {
-		// Rollback on failure - restore old cipher
+	if err := e.reencryptAllData(oldCipher, newCipher); err != nil {
+		// Rollback on failure - move everything back to the old cipher
+		_ = e.reencryptAllData(newCipher, oldCipher)
+		_ = e.reencryptStores(stores, newCipher, oldCipher)
 		e.cipher = oldCipher
 		return fmt.Errorf("failed to re-encrypt data, rolled back to old key: %w", err)
 	}
@@ -677,7 +688,8 @@ func (e *EncryptionManager) RotateKey() error {
 	// Store new key (only after successful re-encryption)
 	if err := e.keyStore.Store(newKey); err != nil {
 		// Attempt to re-encrypt back to old key
-		_ = e.reencryptAllData(e.cipher)
+		_ = e.reencryptAllData(newCipher, oldCipher)
+		_ = e.reencryptStores(stores, newCipher, oldCipher)
 		e.cipher = oldCipher
 		return fmt.Errorf("failed to store new master key, rolled back: %w", err)
 	}
@@ -686,14 +698,17 @@ func (e *EncryptionManager) RotateKey() error {
 	AuditLogEvent("", "ENCRYPTION_ROTATE", map[string]string{
 		"algorithm": "AES-256-GCM",
 		"status":    "success",
+		"stores":    fmt.Sprintf("%d", len(stores)),
 	})
 
 	return nil
 }
 
-// reencryptAllData re-encrypts all encrypted data from old cipher to new cipher.
-// This is called during key rotation to prevent data loss.
-func (e *EncryptionManager) reencryptAllData(oldCipher cipher.AEAD) error {
+// reencryptAllData re-encrypts all encrypted data files from one cipher to
+// the other. This is called during key rotation to prevent data loss. Files
+// already sealed by to are kept, so a rotation that failed part way can be
+// moved back by swapping the ciphers. The caller holds e.mu.
+func (e *EncryptionManager) reencryptAllData(from, to cipher.AEAD) error {
 	// List of files to re-encrypt
 	filesToReencrypt := []string{
 		DefaultCachePathForEncryption(),
@@ -707,52 +722,13 @@ func (e *EncryptionManager) reencryptAllData(oldCipher cipher.AEAD) error {
 	}
 
 	for _, filePath := range filesToReencrypt {
-		// Check if file exists
-		data, err := os.ReadFile(filePath)
-		if err != nil {
+		// RELIABILITY: ReencryptFile writes atomically with fsync, and
+		// leaves unencrypted files alone
+		if err := ReencryptFile(filePath, e.recrypter(from, to)); err != nil {
 			if os.IsNotExist(err) {
 				continue // Skip non-existent files
 			}
-			return fmt.Errorf("failed to read %s: %w", filePath, err)
-		}
-
-		// Check if file is encrypted
-		content := string(data)
-		if !strings.HasPrefix(content, EncryptedPrefix) {
-			continue // Skip unencrypted files
-		}
-
-		// Decode base64
-		encoded := strings.TrimPrefix(content, EncryptedPrefix)
-		ciphertext, err := base64.StdEncoding.DecodeString(encoded)
-		if err != nil {
-			return fmt.Errorf("invalid base64 in %s: %w", filePath, err)
-		}
-
-		// Decrypt with old cipher
-		if len(ciphertext) < NonceSize {
-			return fmt.Errorf("invalid ciphertext in %s", filePath)
-		}
-		nonce := ciphertext[:NonceSize]
-		oldCiphertext := ciphertext[NonceSize:]
-		plaintext, err := oldCipher.Open(nil, nonce, oldCiphertext, nil)
-		if err != nil {
-			return fmt.Errorf("failed to decrypt %s with old key: %w", filePath, err)
-		}
-
-		// Encrypt with new cipher (e.cipher) using unique nonce generation
-		newNonce, err := e.generateUniqueNonce()
-		if err != nil {
-			return fmt.Errorf("failed to generate unique nonce for %s: %w", filePath, err)
-		}
-		newCiphertext := e.cipher.Seal(newNonce, newNonce, plaintext, nil)
-
-		// RELIABILITY: Atomic write with fsync prevents data loss on crash
-		// Write re-encrypted data
-		output := []byte(EncryptedPrefix)
-		output = append(output, []byte(base64.StdEncoding.EncodeToString(newCiphertext))...)
-		if err := util.AtomicWriteFile(filePath, output, 0600); err != nil {
-			return fmt.Errorf("failed to write re-encrypted %s: %w", filePath, err)
+			return fmt.Errorf("failed to re-encrypt %s: %w", filePath, err)
 		}
 	}
 
@@ -0,0 +1,178 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+//
+// Per-message classification derivation and high-water marking.
+// Per DoDI 5200.48 and 32 CFR Part 2002: a document (conversation) is marked
+// at the highest classification of any portion (message) it contains.
+
+package security
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// =============================================================================
+// CONTENT CLASSIFICATION
+// =============================================================================
+
+// ErrClassificationExceedsSession is returned when data marked above the
+// session's classification would be introduced into that session.
+var ErrClassificationExceedsSession = errors.New("classification exceeds session level")
+
+// portionMarkingRegex matches explicit portion markings, e.g. "(U)", "(CUI)",
+// "(S//NF)", "(TS//NOFORN)". A portion marking opens a line or paragraph and
+// is followed by whitespace or the end of the line; the rest of the line is
+// captured so copyright notices can be told apart from "(C)" markings.
+var portionMarkingRegex = regexp.MustCompile(`(?m)^[ \t]*\(((?:U|CUI|C|S|TS)(?://[A-Z][A-Z ]*)*)\)(?:[ \t]+(.*))?$`)
+
+// copyrightNoticeRegex matches the text after "(C)" in a copyright notice,
+// e.g. "(C) 2024 Acme" or "(C) Copyright Acme".
+var copyrightNoticeRegex = regexp.MustCompile(`(?i)^(?:(?:19|20)\d{2}\b|copyright\b|all rights reserved)`)
+
+// bannerMarkingRegex matches banner lines consisting solely of a marking,
+// e.g. "SECRET//NOFORN" or "CUI".
+var bannerMarkingRegex = regexp.MustCompile(`(?m)^\s*((?:UNCLASSIFIED|CUI|CONFIDENTIAL|SECRET|TOP SECRET)(?://[A-Z][A-Z ]*)*)\s*$`)
+
+// spillageLevels maps SpillagePattern classifications to classification levels.
+// Unrecognized classifications (e.g. "POTENTIAL SECRET" from entropy analysis)
+// do not affect the derived marking.
+var spillageLevels = map[string]ClassificationLevel{
+	"CUI":          ClassificationCUI,
+	"CONFIDENTIAL": ClassificationConfidential,
+	"CLASSIFIED":   ClassificationConfidential,
+	"SECRET":       ClassificationSecret,
+	"NATO":         ClassificationSecret,
+	"SCI":          ClassificationTopSecret,
+	"TOP SECRET":   ClassificationTopSecret,
+}
+
+// ClassifyContent derives the classification of a piece of content.
+//
+// The result is the high-water mark of floor (normally the session level),
+// any explicit portion or banner markings found in the content, and any
+// classification markers reported by the spillage detector. The detector may
+// be nil, in which case only explicit markings are considered.
+func ClassifyContent(content string, floor Classification, detector *SpillageManager) Classification {
+	result := floor
+
+	for _, match := range portionMarkingRegex.FindAllStringSubmatch(content, -1) {
+		if match[1] == "C" && copyrightNoticeRegex.MatchString(match[2]) {
+			continue
+		}
+		if c, err := ParseClassification(match[1]); err == nil {
+			result = HighWaterMark(result, c)
+		}
+	}
+	for _, match := range bannerMarkingRegex.FindAllStringSubmatch(content, -1) {
+		if c, err := ParseClassification(match[1]); err == nil {
+			result = HighWaterMark(result, c)
+		}
+	}
+
+	if detector != nil {
+		for _, event := range detector.Detect(content) {
+			if level, ok := spillageLevels[event.Classification]; ok && level > result.Level {
+				result = HighWaterMark(result, Classification{Level: level})
+			}
+		}
+	}
+
+	return result
+}
+
+// HighWaterMark returns the highest level among the given classifications,
+// carrying forward the union of their caveats and CUI designations.
+// With no arguments it returns UNCLASSIFIED.
+func HighWaterMark(cs ...Classification) Classification {
+	result := DefaultClassification()
+	seenCaveat := make(map[string]bool)
+	seenCUI := make(map[CUIDesignation]bool)
+
+	for _, c := range cs {
+		if c.Level > result.Level {
+			result.Level = c.Level
+		}
+		for _, caveat := range c.Caveats {
+			if !seenCaveat[caveat] {
+				seenCaveat[caveat] = true
+				result.Caveats = append(result.Caveats, caveat)
+			}
+		}
+		for _, cui := range c.CUI {
+			if !seenCUI[cui] {
+				seenCUI[cui] = true
+				result.CUI = append(result.CUI, cui)
+			}
+		}
+	}
+
+	// CUI designations only apply to CUI-level markings; above CUI, a NOFORN
+	// designation is carried as a caveat instead.
+	if result.Level != ClassificationCUI && len(result.CUI) > 0 {
+		if seenCUI[CUINOFORN] && !seenCaveat["NOFORN"] {
+			result.Caveats = append(result.Caveats, "NOFORN")
+		}
+		result.CUI = nil
+	}
+	// Dissemination caveats are not valid on UNCLASSIFIED markings.
+	if result.Level == ClassificationUnclassified {
+		result.Caveats = nil
+	}
+
+	return result
+}
+
+// HighWaterMarkOf parses each marking string and returns their high-water mark.
+// Empty strings are treated as UNCLASSIFIED; unparseable markings are an error
+// so that a corrupted marking can never silently lower the result.
+func HighWaterMarkOf(markings ...string) (Classification, error) {
+	cs := make([]Classification, 0, len(markings))
+	for _, marking := range markings {
+		c, err := ParseClassification(marking)
+		if err != nil {
+			return Classification{}, err
+		}
+		cs = append(cs, c)
+	}
+	return HighWaterMark(cs...), nil
+}
+
+// CheckSessionClearance verifies that data marked at the data classification
+// may be loaded into a session operating at the session classification. Returns an error wrapping
+// ErrClassificationExceedsSession when the data is marked higher.
+func CheckSessionClearance(data, session Classification) error {
+	if CompareClassification(data.Level, session.Level) > 0 {
+		return fmt.Errorf("%w: data is %s, session is %s",
+			ErrClassificationExceedsSession, data.String(), session.String())
+	}
+	return nil
+}
+
+// ParseStoredMarking parses a persisted marking string. An empty marking is
+// UNCLASSIFIED; an unparseable one is treated as TOP SECRET so that
+// corruption can never lower a high-water mark.
+func ParseStoredMarking(marking string) Classification {
+	if marking == "" {
+		return DefaultClassification()
+	}
+	c, err := ParseClassification(marking)
+	if err != nil {
+		return Classification{Level: ClassificationTopSecret}
+	}
+	return c
+}
+
+// PortionMarkingFor returns the portion marking, e.g. "(S//NF)", for a
+// marking string. Empty markings render as "(U)"; unparseable markings are
+// rendered verbatim rather than being downgraded.
+func PortionMarkingFor(marking string) string {
+	marking = strings.TrimSpace(marking)
+	c, err := ParseClassification(marking)
+	if err != nil {
+		return "(" + marking + ")"
+	}
+	return RenderPortionMarking(c)
+}
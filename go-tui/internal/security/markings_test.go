@@ -0,0 +1,143 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package security
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestClassifyContent_ExplicitMarkings tests that portion and banner markings
+// in content raise the derived classification above the floor.
+func TestClassifyContent_ExplicitMarkings(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		floor    Classification
+		expected string
+	}{
+		{"plain text keeps floor", "how do I sort a slice?", DefaultClassification(), "UNCLASSIFIED"},
+		{"floor is never lowered", "(U) hello", Classification{Level: ClassificationCUI}, "CUI"},
+		{"portion marking", "(S) the launch window", DefaultClassification(), "SECRET"},
+		{"portion marking with caveat", "intro\n(TS//NF) details", DefaultClassification(), "TOP SECRET//NOFORN"},
+		{"banner line", "CUI\nbudget figures\nCUI", DefaultClassification(), "CUI"},
+		{"marking mid-sentence ignored", "see section (S) below", DefaultClassification(), "UNCLASSIFIED"},
+		{"confidential portion", "(C) the budget figures", DefaultClassification(), "CONFIDENTIAL"},
+		{"copyright year is not a marking", "(C) 2024 Copyright Acme Corp", DefaultClassification(), "UNCLASSIFIED"},
+		{"copyright notice is not a marking", "// (C) Copyright 2024 Acme\n(C) Copyright Acme", DefaultClassification(), "UNCLASSIFIED"},
+		{"marking must be followed by a space", "(S)tatus: ok", DefaultClassification(), "UNCLASSIFIED"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ClassifyContent(tt.content, tt.floor, nil)
+			if got.String() != tt.expected {
+				t.Errorf("ClassifyContent(%q) = %q, want %q", tt.content, got.String(), tt.expected)
+			}
+		})
+	}
+}
+
+// TestClassifyContent_SpillageDetector tests that spillage detection raises the
+// derived classification.
+func TestClassifyContent_SpillageDetector(t *testing.T) {
+	detector := NewSpillageManager()
+
+	got := ClassifyContent("this document is TOP SECRET", DefaultClassification(), detector)
+	if got.Level != ClassificationTopSecret {
+		t.Errorf("expected TOP SECRET from spillage detection, got %s", got.String())
+	}
+
+	got = ClassifyContent("func main() {}", DefaultClassification(), detector)
+	if got.Level != ClassificationUnclassified {
+		t.Errorf("expected UNCLASSIFIED for benign content, got %s", got.String())
+	}
+}
+
+// TestHighWaterMark tests that the highest level wins and caveats are merged.
+func TestHighWaterMark(t *testing.T) {
+	if got := HighWaterMark(); got.Level != ClassificationUnclassified {
+		t.Errorf("empty high-water mark should be UNCLASSIFIED, got %s", got.String())
+	}
+
+	got := HighWaterMark(
+		MustParseClassification("CUI//NOFORN"),
+		MustParseClassification("SECRET"),
+		MustParseClassification("UNCLASSIFIED"),
+	)
+	if got.String() != "SECRET//NOFORN" {
+		t.Errorf("expected SECRET//NOFORN, got %s", got.String())
+	}
+
+	got = HighWaterMark(MustParseClassification("CUI"), MustParseClassification("CUI//NOFORN"))
+	if got.String() != "CUI//NOFORN" {
+		t.Errorf("expected CUI//NOFORN, got %s", got.String())
+	}
+}
+
+// TestHighWaterMarkOf tests parsing of stored marking strings.
+func TestHighWaterMarkOf(t *testing.T) {
+	got, err := HighWaterMarkOf("", "CUI", "CONFIDENTIAL")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Level != ClassificationConfidential {
+		t.Errorf("expected CONFIDENTIAL, got %s", got.String())
+	}
+
+	if _, err := HighWaterMarkOf("CUI", "NOT A MARKING"); err == nil {
+		t.Error("expected error for unparseable marking")
+	}
+}
+
+// TestCheckSessionClearance tests that higher-classified data is refused.
+func TestCheckSessionClearance(t *testing.T) {
+	cui := MustParseClassification("CUI")
+	secret := MustParseClassification("SECRET")
+
+	if err := CheckSessionClearance(cui, secret); err != nil {
+		t.Errorf("CUI data in SECRET session should be allowed: %v", err)
+	}
+	if err := CheckSessionClearance(secret, secret); err != nil {
+		t.Errorf("SECRET data in SECRET session should be allowed: %v", err)
+	}
+
+	err := CheckSessionClearance(secret, cui)
+	if !errors.Is(err, ErrClassificationExceedsSession) {
+		t.Errorf("expected ErrClassificationExceedsSession, got %v", err)
+	}
+}
+
+// TestPortionMarkingFor tests portion rendering of stored markings.
+func TestPortionMarkingFor(t *testing.T) {
+	tests := map[string]string{
+		"":               "(U)",
+		"UNCLASSIFIED":   "(U)",
+		"SECRET//NOFORN": "(S//NF)",
+		"CUI":            "(CUI)",
+	}
+	for marking, expected := range tests {
+		if got := PortionMarkingFor(marking); got != expected {
+			t.Errorf("PortionMarkingFor(%q) = %q, want %q", marking, got, expected)
+		}
+	}
+}
+
+// TestParseStoredMarking tests that stored markings fail closed.
+func TestParseStoredMarking(t *testing.T) {
+	tests := []struct {
+		marking  string
+		expected string
+	}{
+		{"", "UNCLASSIFIED"},
+		{"SECRET//NOFORN", "SECRET//NOFORN"},
+		{"garbled", "TOP SECRET"},
+	}
+
+	for _, tt := range tests {
+		if got := ParseStoredMarking(tt.marking).String(); got != tt.expected {
+			t.Errorf("ParseStoredMarking(%q) = %q, want %q", tt.marking, got, tt.expected)
+		}
+	}
+}
@@ -9,6 +9,7 @@
 //   - Certificate expiration checking
 //   - Optional certificate pinning
 //   - CAC/PKI client certificate authentication (IA-2(12))
+//   - Client certificate verification against the trust store
 
 package security
 
@@ -925,6 +926,126 @@ func (p *PKIManager) GetTLSConfigForClientAuth(config *PKIConfig) (*tls.Config,
 	return tlsConfig, nil
 }
 
+// =============================================================================
+// TRUST STORE CLIENT VERIFICATION (IA-2(12))
+// =============================================================================
+
+// SetTrustStore sets the path to the CA bundle used for client certificate
+// verification. An empty path uses the system roots.
+func (p *PKIManager) SetTrustStore(path string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.trustStore = path
+}
+
+// TrustStore returns the configured CA bundle path (empty = system roots).
+func (p *PKIManager) TrustStore() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.trustStore
+}
+
+// trustPool loads the trust store CA bundle, or the system pool if none is set.
+func (p *PKIManager) trustPool() (*x509.CertPool, error) {
+	path := p.TrustStore()
+	if path == "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load system cert pool: %w", err)
+		}
+		return pool, nil
+	}
+
+	certs, err := LoadCertificatesPEM(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load trust store: %w", err)
+	}
+	pool := x509.NewCertPool()
+	for _, ca := range certs {
+		pool.AddCert(ca)
+	}
+	return pool, nil
+}
+
+// VerifyClientCertificate validates a PIV/CAC-style client certificate against
+// the trust store. Intermediates presented alongside the certificate are used
+// to build the chain but are not trusted on their own.
+func (p *PKIManager) VerifyClientCertificate(cert *x509.Certificate, intermediates []*x509.Certificate) (*CertificateInfo, error) {
+	if cert == nil {
+		return nil, ErrNoClientCertificate
+	}
+	if cert.IsCA {
+		return nil, fmt.Errorf("%w: CA certificates cannot be used for login", ErrCertNotTrusted)
+	}
+
+	now := time.Now()
+	if now.Before(cert.NotBefore) {
+		return nil, fmt.Errorf("%w: certificate not valid until %s", ErrCertNotYetValid, cert.NotBefore)
+	}
+	if now.After(cert.NotAfter) {
+		return nil, fmt.Errorf("%w: certificate expired on %s", ErrCertExpired, cert.NotAfter)
+	}
+
+	roots, err := p.trustPool()
+	if err != nil {
+		return nil, err
+	}
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: x509.NewCertPool(),
+		CurrentTime:   now,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	for _, ic := range intermediates {
+		opts.Intermediates.AddCert(ic)
+	}
+
+	chains, err := cert.Verify(opts)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCertNotTrusted, err)
+	}
+	if len(chains) == 0 {
+		return nil, ErrCertChainInvalid
+	}
+
+	return extractCertificateInfo(cert), nil
+}
+
+// LoadCertificatesPEM reads all certificates from a PEM file, in file order.
+// A single DER-encoded certificate is also accepted.
+func LoadCertificatesPEM(path string) ([]*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var certs []*x509.Certificate
+	for rest := data; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate in %s: %w", path, err)
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		cert, err := x509.ParseCertificate(data)
+		if err != nil {
+			return nil, fmt.Errorf("no certificates found in %s", path)
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
+
 // =============================================================================
 // HELPERS
 // =============================================================================
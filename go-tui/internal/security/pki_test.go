@@ -0,0 +1,174 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package security
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCA is a self-signed CA that issues client certificates.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name, Organization: []string{"Test PKI"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key}
+}
+
+// issue creates a client certificate signed by the CA.
+func (ca *testCA) issue(t *testing.T, cn string, notAfter time.Time, usage x509.ExtKeyUsage) *x509.Certificate {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return cert
+}
+
+// writeTrustStore writes the CA certificates to a PEM bundle.
+func writeTrustStore(t *testing.T, cas ...*testCA) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ca-bundle.pem")
+	var data []byte
+	for _, ca := range cas {
+		data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw})...)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPKIManager_VerifyClientCertificate(t *testing.T) {
+	ca := newTestCA(t, "Test Root CA")
+	rogue := newTestCA(t, "Rogue CA")
+
+	pm := NewPKIManager()
+	pm.SetTrustStore(writeTrustStore(t, ca))
+
+	good := ca.issue(t, "DOE.JOHN.Q.1234567890", time.Now().Add(time.Hour), x509.ExtKeyUsageClientAuth)
+	info, err := pm.VerifyClientCertificate(good, nil)
+	if err != nil {
+		t.Fatalf("VerifyClientCertificate: %v", err)
+	}
+	if info.EDIPI != "1234567890" || info.Fingerprint != ComputeCertFingerprint(good) {
+		t.Errorf("unexpected info: %+v", info)
+	}
+
+	tests := []struct {
+		name string
+		cert *x509.Certificate
+		want error
+	}{
+		{"untrusted issuer", rogue.issue(t, "mallory", time.Now().Add(time.Hour), x509.ExtKeyUsageClientAuth), ErrCertNotTrusted},
+		{"expired", ca.issue(t, "old", time.Now().Add(-time.Minute), x509.ExtKeyUsageClientAuth), ErrCertExpired},
+		{"server-only usage", ca.issue(t, "server", time.Now().Add(time.Hour), x509.ExtKeyUsageServerAuth), ErrCertNotTrusted},
+		{"trust anchor itself", ca.cert, ErrCertNotTrusted},
+		{"nil", nil, ErrNoClientCertificate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := pm.VerifyClientCertificate(tt.cert, nil); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadCertificatesPEM(t *testing.T) {
+	a, b := newTestCA(t, "A"), newTestCA(t, "B")
+	certs, err := LoadCertificatesPEM(writeTrustStore(t, a, b))
+	if err != nil {
+		t.Fatalf("LoadCertificatesPEM: %v", err)
+	}
+	if len(certs) != 2 || certs[0].Subject.CommonName != "A" || certs[1].Subject.CommonName != "B" {
+		t.Errorf("unexpected certificates: %d", len(certs))
+	}
+
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	os.WriteFile(empty, []byte("not a certificate"), 0600)
+	if _, err := LoadCertificatesPEM(empty); err == nil {
+		t.Error("file without certificates should fail")
+	}
+}
+
+func TestAuthManager_CertificateLoginWithTrustStore(t *testing.T) {
+	ca := newTestCA(t, "Test Root CA")
+	rogue := newTestCA(t, "Rogue CA")
+	pm := NewPKIManager()
+	pm.SetTrustStore(writeTrustStore(t, ca))
+
+	// Without PKI config or manager, certificate login is disabled
+	cert := ca.issue(t, "DOE.JANE.1234567890", time.Now().Add(time.Hour), x509.ExtKeyUsageClientAuth)
+	if _, err := NewAuthManager(WithAuthLockout(newTestLockout(t, 3))).AuthenticateCertificate(cert); err == nil {
+		t.Error("certificate login should be disabled without a trust store")
+	}
+
+	mgr := NewAuthManager(WithPKIManager(pm), WithAuthLockout(newTestLockout(t, 3)))
+	session, err := mgr.AuthenticateCertificate(cert)
+	if err != nil {
+		t.Fatalf("AuthenticateCertificate: %v", err)
+	}
+	if session.UserID != "1234567890" || session.AuthMethod != AuthMethodCertificate {
+		t.Errorf("unexpected session: user=%s method=%s", session.UserID, session.AuthMethod)
+	}
+	if session.Metadata["cert_fingerprint"] != ComputeCertFingerprint(cert) {
+		t.Error("session missing certificate fingerprint")
+	}
+
+	// Repeated failures with the same certificate lock it out
+	bad := rogue.issue(t, "mallory", time.Now().Add(time.Hour), x509.ExtKeyUsageClientAuth)
+	for i := 0; i < 3; i++ {
+		mgr.AuthenticateCertificate(bad)
+	}
+	if _, err := mgr.AuthenticateCertificate(bad); !errors.Is(err, ErrLocked) {
+		t.Errorf("got %v, want ErrLocked", err)
+	}
+
+	// Lockout is per certificate
+	if _, err := mgr.AuthenticateCertificate(cert); err != nil {
+		t.Errorf("trusted certificate affected by another's lockout: %v", err)
+	}
+}
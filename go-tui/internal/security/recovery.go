@@ -0,0 +1,325 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// recovery.go - One-time MFA recovery codes.
+//
+// Implements NIST 800-53 IA-5(1) (Authenticator Management) for backup
+// authenticators: recovery codes are generated from crypto/rand, shown to the
+// user exactly once, stored only as salted PBKDF2-SHA256 hashes, and consumed
+// on first use. Generating a new set invalidates all previous codes.
+
+package security
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/pbkdf2"
+
+	"github.com/jeranaias/rigrun-tui/internal/util"
+)
+
+// =============================================================================
+// CONSTANTS
+// =============================================================================
+
+const (
+	// RecoveryCodeCount is the number of codes issued per user.
+	RecoveryCodeCount = 10
+
+	// RecoveryCodeFileName is the name of the recovery code store in ~/.rigrun.
+	RecoveryCodeFileName = "recovery_codes.json"
+
+	// recoveryCodeGroups and recoveryCodeGroupLen give the xxxx-xxxx-xxxx format
+	// (12 symbols from a 30-symbol alphabet, about 58 bits per code).
+	recoveryCodeGroups   = 3
+	recoveryCodeGroupLen = 4
+
+	// recoveryCodeAlphabet omits 0/1/i/l/o/u to avoid transcription errors.
+	recoveryCodeAlphabet = "23456789abcdefghjkmnpqrstvwxyz"
+
+	// recoveryCodeIterations is lower than PBKDF2Iterations because codes are
+	// random rather than user-chosen; each verification hashes up to
+	// RecoveryCodeCount candidates.
+	recoveryCodeIterations = 50000
+
+	recoveryCodeSaltSize = 16
+	recoveryCodeHashSize = 32
+)
+
+// =============================================================================
+// ERRORS
+// =============================================================================
+
+var (
+	// ErrRecoveryCodeInvalid indicates the code does not match any unused code.
+	ErrRecoveryCodeInvalid = errors.New("invalid or already used recovery code")
+
+	// ErrNoRecoveryCodes indicates no recovery codes have been generated for the user.
+	ErrNoRecoveryCodes = errors.New("no recovery codes generated")
+)
+
+// =============================================================================
+// RECOVERY CODE STORE
+// =============================================================================
+
+// recoveryCodeRecord is a single hashed code.
+type recoveryCodeRecord struct {
+	Salt   string     `json:"salt"`
+	Hash   string     `json:"hash"`
+	UsedAt *time.Time `json:"used_at,omitempty"`
+}
+
+// recoveryCodeSet is the set of codes issued to one user.
+type recoveryCodeSet struct {
+	GeneratedAt time.Time            `json:"generated_at"`
+	Codes       []recoveryCodeRecord `json:"codes"`
+}
+
+// RecoveryCodeStatus summarizes a user's recovery codes without revealing them.
+type RecoveryCodeStatus struct {
+	UserID      string     `json:"user_id"`
+	GeneratedAt time.Time  `json:"generated_at"`
+	Total       int        `json:"total"`
+	Remaining   int        `json:"remaining"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+}
+
+// RecoveryCodeStore persists hashed recovery codes per user.
+// The file is re-read on every operation so separate CLI invocations see
+// codes consumed by each other.
+type RecoveryCodeStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewRecoveryCodeStore creates a store backed by the given file.
+// An empty path uses DefaultRecoveryCodePath.
+func NewRecoveryCodeStore(path string) *RecoveryCodeStore {
+	if path == "" {
+		path = DefaultRecoveryCodePath()
+	}
+	return &RecoveryCodeStore{path: path}
+}
+
+// DefaultRecoveryCodePath returns ~/.rigrun/recovery_codes.json.
+func DefaultRecoveryCodePath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return RecoveryCodeFileName
+	}
+	return filepath.Join(home, ".rigrun", RecoveryCodeFileName)
+}
+
+// Path returns the backing file path.
+func (s *RecoveryCodeStore) Path() string {
+	return s.path
+}
+
+// Generate issues a fresh set of recovery codes for the user, replacing any
+// existing set. The plaintext codes are returned once and never stored.
+func (s *RecoveryCodeStore) Generate(userID string) ([]string, error) {
+	if userID == "" {
+		return nil, errors.New("user ID required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sets, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, RecoveryCodeCount)
+	set := &recoveryCodeSet{
+		GeneratedAt: time.Now(),
+		Codes:       make([]recoveryCodeRecord, RecoveryCodeCount),
+	}
+	for i := range codes {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		salt := make([]byte, recoveryCodeSaltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, fmt.Errorf("cryptographic random generation failed: %w", err)
+		}
+		codes[i] = code
+		set.Codes[i] = recoveryCodeRecord{
+			Salt: hex.EncodeToString(salt),
+			Hash: hex.EncodeToString(hashRecoveryCode(code, salt)),
+		}
+	}
+
+	sets[userID] = set
+	if err := s.save(sets); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Verify checks a code for the user and marks it used on success.
+// Codes are compared case-insensitively with separators ignored.
+func (s *RecoveryCodeStore) Verify(userID, code string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sets, err := s.load()
+	if err != nil {
+		return err
+	}
+	set, ok := sets[userID]
+	if !ok || len(set.Codes) == 0 {
+		return ErrNoRecoveryCodes
+	}
+
+	normalized := normalizeRecoveryCode(code)
+	if len(normalized) != recoveryCodeGroups*recoveryCodeGroupLen {
+		return ErrRecoveryCodeInvalid
+	}
+
+	for i := range set.Codes {
+		rec := &set.Codes[i]
+		if rec.UsedAt != nil {
+			continue
+		}
+		salt, err := hex.DecodeString(rec.Salt)
+		if err != nil {
+			continue
+		}
+		want, err := hex.DecodeString(rec.Hash)
+		if err != nil {
+			continue
+		}
+		if subtle.ConstantTimeCompare(hashRecoveryCode(normalized, salt), want) == 1 {
+			now := time.Now()
+			rec.UsedAt = &now
+			return s.save(sets)
+		}
+	}
+	return ErrRecoveryCodeInvalid
+}
+
+// Status returns a summary of the user's codes, or ErrNoRecoveryCodes.
+func (s *RecoveryCodeStore) Status(userID string) (*RecoveryCodeStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sets, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	set, ok := sets[userID]
+	if !ok {
+		return nil, ErrNoRecoveryCodes
+	}
+
+	status := &RecoveryCodeStatus{
+		UserID:      userID,
+		GeneratedAt: set.GeneratedAt,
+		Total:       len(set.Codes),
+	}
+	for _, rec := range set.Codes {
+		if rec.UsedAt == nil {
+			status.Remaining++
+		} else if status.LastUsedAt == nil || rec.UsedAt.After(*status.LastUsedAt) {
+			used := *rec.UsedAt
+			status.LastUsedAt = &used
+		}
+	}
+	return status, nil
+}
+
+// Remaining returns the number of unused codes for the user (0 if none).
+func (s *RecoveryCodeStore) Remaining(userID string) int {
+	status, err := s.Status(userID)
+	if err != nil {
+		return 0
+	}
+	return status.Remaining
+}
+
+// load reads the store file. A missing file is an empty store.
+func (s *RecoveryCodeStore) load() (map[string]*recoveryCodeSet, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return make(map[string]*recoveryCodeSet), nil
+		}
+		return nil, fmt.Errorf("failed to read recovery codes: %w", err)
+	}
+
+	sets := make(map[string]*recoveryCodeSet)
+	if err := json.Unmarshal(data, &sets); err != nil {
+		return nil, fmt.Errorf("failed to parse recovery codes: %w", err)
+	}
+	return sets, nil
+}
+
+// save writes the store file atomically with owner-only permissions.
+func (s *RecoveryCodeStore) save(sets map[string]*recoveryCodeSet) error {
+	data, err := json.MarshalIndent(sets, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode recovery codes: %w", err)
+	}
+	if err := util.AtomicWriteFileWithDir(s.path, data, 0600, 0700); err != nil {
+		return fmt.Errorf("failed to save recovery codes: %w", err)
+	}
+	return nil
+}
+
+// =============================================================================
+// HELPERS
+// =============================================================================
+
+// generateRecoveryCode returns a random code in xxxx-xxxx-xxxx format.
+func generateRecoveryCode() (string, error) {
+	n := recoveryCodeGroups * recoveryCodeGroupLen
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("cryptographic random generation failed: %w", err)
+	}
+
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		if i > 0 && i%recoveryCodeGroupLen == 0 {
+			sb.WriteByte('-')
+		}
+		// 256 is not a multiple of 30, so reject values in the biased tail
+		for int(buf[i]) >= 256-256%len(recoveryCodeAlphabet) {
+			if _, err := rand.Read(buf[i : i+1]); err != nil {
+				return "", fmt.Errorf("cryptographic random generation failed: %w", err)
+			}
+		}
+		sb.WriteByte(recoveryCodeAlphabet[int(buf[i])%len(recoveryCodeAlphabet)])
+	}
+	return sb.String(), nil
+}
+
+// normalizeRecoveryCode lowercases a code and strips separators.
+func normalizeRecoveryCode(code string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(code) {
+		if r == '-' || r == ' ' {
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// hashRecoveryCode derives the stored hash for a code.
+func hashRecoveryCode(code string, salt []byte) []byte {
+	return pbkdf2.Key([]byte(normalizeRecoveryCode(code)), salt, recoveryCodeIterations, recoveryCodeHashSize, sha256.New)
+}
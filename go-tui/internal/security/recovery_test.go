@@ -0,0 +1,210 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package security
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// newTestLockout returns a lockout manager persisted in a temp dir.
+func newTestLockout(t *testing.T, maxAttempts int) *LockoutManager {
+	t.Helper()
+	return NewLockoutManager(
+		WithPersistPath(filepath.Join(t.TempDir(), "lockout.json")),
+		WithMaxAttempts(maxAttempts),
+	)
+}
+
+func TestRecoveryCodeStore_GenerateVerifyConsume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), RecoveryCodeFileName)
+	store := NewRecoveryCodeStore(path)
+
+	codes, err := store.Generate("alice")
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if len(codes) != RecoveryCodeCount {
+		t.Fatalf("Generate returned %d codes, want %d", len(codes), RecoveryCodeCount)
+	}
+	format := regexp.MustCompile(`^[a-z2-9]{4}-[a-z2-9]{4}-[a-z2-9]{4}$`)
+	seen := make(map[string]bool)
+	for _, c := range codes {
+		if !format.MatchString(c) {
+			t.Errorf("code %q has unexpected format", c)
+		}
+		if seen[c] {
+			t.Errorf("duplicate code %q", c)
+		}
+		seen[c] = true
+	}
+
+	// Plaintext codes are never written to disk
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	for _, c := range codes {
+		if strings.Contains(string(data), c) || strings.Contains(string(data), normalizeRecoveryCode(c)) {
+			t.Fatal("recovery code stored in plaintext")
+		}
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("store permissions = %v, want 0600", info.Mode().Perm())
+	}
+
+	// Case and separators are ignored; a code works exactly once
+	if err := store.Verify("alice", strings.ToUpper(strings.ReplaceAll(codes[3], "-", " "))); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if err := store.Verify("alice", codes[3]); !errors.Is(err, ErrRecoveryCodeInvalid) {
+		t.Errorf("reused code: got %v, want ErrRecoveryCodeInvalid", err)
+	}
+
+	// A second store instance sees the consumed code
+	if got := NewRecoveryCodeStore(path).Remaining("alice"); got != RecoveryCodeCount-1 {
+		t.Errorf("Remaining = %d, want %d", got, RecoveryCodeCount-1)
+	}
+
+	// Codes are per user
+	if err := store.Verify("bob", codes[0]); !errors.Is(err, ErrNoRecoveryCodes) {
+		t.Errorf("other user: got %v, want ErrNoRecoveryCodes", err)
+	}
+}
+
+func TestRecoveryCodeStore_RegenerateInvalidatesOld(t *testing.T) {
+	store := NewRecoveryCodeStore(filepath.Join(t.TempDir(), RecoveryCodeFileName))
+
+	old, _ := store.Generate("alice")
+	fresh, err := store.Generate("alice")
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if err := store.Verify("alice", old[0]); !errors.Is(err, ErrRecoveryCodeInvalid) {
+		t.Errorf("old code after regenerate: got %v, want ErrRecoveryCodeInvalid", err)
+	}
+	if err := store.Verify("alice", fresh[0]); err != nil {
+		t.Errorf("new code: %v", err)
+	}
+
+	status, err := store.Status("alice")
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if status.Total != RecoveryCodeCount || status.Remaining != RecoveryCodeCount-1 || status.LastUsedAt == nil {
+		t.Errorf("unexpected status: %+v", status)
+	}
+}
+
+// newTestSession creates a session for userID directly, standing in for a
+// successful primary authentication.
+func newTestSession(t *testing.T, mgr *AuthManager, userID string) *AuthSession {
+	t.Helper()
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	session, err := mgr.newSessionLocked(userID, AuthMethodAPIKey)
+	if err != nil {
+		t.Fatalf("newSessionLocked: %v", err)
+	}
+	return session
+}
+
+func TestAuthManager_RecoveryCodeLockout(t *testing.T) {
+	store := NewRecoveryCodeStore(filepath.Join(t.TempDir(), RecoveryCodeFileName))
+	codes, _ := store.Generate("alice")
+	mgr := NewAuthManager(WithRecoveryCodes(store), WithAuthLockout(newTestLockout(t, 3)))
+	session := newTestSession(t, mgr, "alice")
+
+	for i := 0; i < 3; i++ {
+		mgr.VerifyRecoveryCode(session.SessionID, "aaaa-bbbb-cccc")
+	}
+
+	// A valid code is refused once locked out and is not consumed
+	if err := mgr.VerifyRecoveryCode(session.SessionID, codes[1]); !errors.Is(err, ErrLocked) {
+		t.Errorf("got %v, want ErrLocked", err)
+	}
+	if got := store.Remaining("alice"); got != RecoveryCodeCount {
+		t.Errorf("Remaining = %d, locked attempt must not consume a code", got)
+	}
+}
+
+func TestAuthManager_GenerateRecoveryCodesRequiresMFAOrAdmin(t *testing.T) {
+	dir := t.TempDir()
+	store := NewRecoveryCodeStore(filepath.Join(dir, RecoveryCodeFileName))
+	existing, _ := store.Generate("alice")
+	mgr := NewAuthManager(WithRecoveryCodes(store), WithAuthLockout(newTestLockout(t, 5)), WithMFAEnabled(true))
+	rbac, err := NewRBACManager(WithRBACStoragePath(filepath.Join(dir, "rbac.json")))
+	if err != nil {
+		t.Fatalf("NewRBACManager: %v", err)
+	}
+	defer rbac.Close()
+	if err := rbac.AssignRole("root", RBACRoleAdmin, ""); err != nil {
+		t.Fatalf("AssignRole: %v", err)
+	}
+
+	// Unknown session
+	if _, err := mgr.GenerateRecoveryCodes("auth_missing", "alice", rbac); err == nil {
+		t.Error("generate without a session should fail")
+	}
+
+	// Authenticated but MFA not verified
+	alice := newTestSession(t, mgr, "alice")
+	if _, err := mgr.GenerateRecoveryCodes(alice.SessionID, "alice", rbac); !errors.Is(err, ErrMFARequired) {
+		t.Errorf("unverified session: got %v, want ErrMFARequired", err)
+	}
+
+	// MFA verified, but for another user
+	if err := mgr.VerifyRecoveryCode(alice.SessionID, existing[0]); err != nil {
+		t.Fatalf("VerifyRecoveryCode: %v", err)
+	}
+	if _, err := mgr.GenerateRecoveryCodes(alice.SessionID, "bob", rbac); !errors.Is(err, ErrRecoveryCodesNotPermitted) {
+		t.Errorf("other user: got %v, want ErrRecoveryCodesNotPermitted", err)
+	}
+
+	// MFA verified for the same user
+	codes, err := mgr.GenerateRecoveryCodes(alice.SessionID, "alice", rbac)
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCodes: %v", err)
+	}
+	if len(codes) != RecoveryCodeCount {
+		t.Errorf("got %d codes, want %d", len(codes), RecoveryCodeCount)
+	}
+
+	// Administrators may issue codes for any user without MFA on their session
+	admin := newTestSession(t, mgr, "root")
+	if _, err := mgr.GenerateRecoveryCodes(admin.SessionID, "bob", rbac); err != nil {
+		t.Errorf("admin generate for bob: %v", err)
+	}
+	if got := store.Remaining("bob"); got != RecoveryCodeCount {
+		t.Errorf("bob Remaining = %d, want %d", got, RecoveryCodeCount)
+	}
+}
+
+func TestAuthManager_VerifyRecoveryCodeCompletesMFA(t *testing.T) {
+	store := NewRecoveryCodeStore(filepath.Join(t.TempDir(), RecoveryCodeFileName))
+	codes, _ := store.Generate("alice")
+	mgr := NewAuthManager(WithRecoveryCodes(store), WithAuthLockout(newTestLockout(t, 5)), WithMFAEnabled(true))
+
+	session := newTestSession(t, mgr, "alice")
+
+	if err := mgr.VerifyRecoveryCode(session.SessionID, "wrong"); err == nil {
+		t.Error("invalid code should fail")
+	}
+	if session.MFAVerified {
+		t.Fatal("MFA verified after invalid code")
+	}
+	if err := mgr.VerifyRecoveryCode(session.SessionID, codes[2]); err != nil {
+		t.Fatalf("VerifyRecoveryCode: %v", err)
+	}
+	if !session.MFAVerified {
+		t.Error("MFAVerified should be set after a valid recovery code")
+	}
+	if session.Metadata["recovery_codes_remaining"] != "9" {
+		t.Errorf("remaining = %q, want 9", session.Metadata["recovery_codes_remaining"])
+	}
+}
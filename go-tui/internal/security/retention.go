@@ -0,0 +1,315 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package security provides IL5 security controls.
+//
+// This file implements NIST 800-53 SI-12 (Information Management and
+// Retention): retention policies per class of stored data, applied by a
+// RetentionEngine to the stores that implement RetainedStore. Purged data is
+// securely deleted and the purge is recorded in the audit log.
+package security
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// =============================================================================
+// DATA CLASSES
+// =============================================================================
+
+// Data classes a retention policy is configured for.
+const (
+	RetentionConversations = "conversations"
+	RetentionCosts         = "costs"
+	RetentionIntel         = "intel"
+	RetentionBenchmarks    = "benchmarks"
+	RetentionCheckpoints   = "checkpoints"
+	RetentionTasks         = "tasks"
+	RetentionAuditArchives = "audit_archives"
+)
+
+// retentionFloors are the minimum ages below which a class is never purged,
+// whatever its policy says. Audit archives are kept for the AU-11 period.
+var retentionFloors = map[string]time.Duration{
+	RetentionAuditArchives: DefaultRetentionDays * 24 * time.Hour,
+}
+
+// =============================================================================
+// POLICIES
+// =============================================================================
+
+// RetentionPolicy limits how much of a class of data is kept. Zero limits
+// are not applied.
+type RetentionPolicy struct {
+	// MaxAge purges items last written longer ago than this
+	MaxAge time.Duration `json:"max_age,omitempty"`
+
+	// MaxCount keeps at most this many items, the most recent first
+	MaxCount int `json:"max_count,omitempty"`
+
+	// MaxSize keeps at most this many bytes of items, the most recent first
+	MaxSize int64 `json:"max_size,omitempty"`
+}
+
+// IsZero reports whether the policy keeps everything.
+func (p RetentionPolicy) IsZero() bool {
+	return p.MaxAge <= 0 && p.MaxCount <= 0 && p.MaxSize <= 0
+}
+
+// Stricter returns the policy applying the lower of each limit of p and q.
+func (p RetentionPolicy) Stricter(q RetentionPolicy) RetentionPolicy {
+	return RetentionPolicy{
+		MaxAge:   minLimit(p.MaxAge, q.MaxAge),
+		MaxCount: minLimit(p.MaxCount, q.MaxCount),
+		MaxSize:  minLimit(p.MaxSize, q.MaxSize),
+	}
+}
+
+// minLimit returns the lower of two limits, where zero means no limit.
+func minLimit[T int | int64 | time.Duration](a, b T) T {
+	if a <= 0 || (b > 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+// =============================================================================
+// RETAINED STORES
+// =============================================================================
+
+// RetentionItem is a unit of stored data that is kept or purged as a whole:
+// a conversation, a file.
+type RetentionItem struct {
+	ID   string    `json:"id"`
+	Time time.Time `json:"time"` // Last written
+	Size int64     `json:"size"`
+
+	// Classification is the item's marking, if the store records one
+	Classification string `json:"classification,omitempty"`
+
+	// Reason is the limit an item selected for purging exceeded: "age",
+	// "count" or "size"
+	Reason string `json:"reason,omitempty"`
+}
+
+// RetainedStore is a store of data kept under a retention policy.
+type RetainedStore interface {
+	// RetentionClass names the store's data class.
+	RetentionClass() string
+
+	// RetentionItems lists the items subject to retention.
+	RetentionItems() ([]RetentionItem, error)
+
+	// Purge securely deletes the items.
+	Purge(items []RetentionItem) error
+}
+
+// RetentionFiles lists the files of dir whose names match as retention
+// items, by name. A missing dir holds no items.
+func RetentionFiles(dir string, match func(name string) bool) ([]RetentionItem, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var items []RetentionItem
+	for _, entry := range entries {
+		if entry.IsDir() || !match(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue // Removed since listed
+		}
+		items = append(items, RetentionItem{ID: entry.Name(), Time: info.ModTime(), Size: info.Size()})
+	}
+	return items, nil
+}
+
+// PurgeFiles securely deletes the files of dir listed by RetentionFiles.
+func PurgeFiles(dir string, items []RetentionItem) error {
+	var errs []error
+	for _, item := range items {
+		path := filepath.Join(dir, filepath.Base(item.ID))
+		if err := GlobalSanitizer().SecureDeleteFile(path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// =============================================================================
+// RETENTION ENGINE
+// =============================================================================
+
+// RetentionEngine applies retention policies to stores.
+type RetentionEngine struct {
+	// Policies are the policies by data class
+	Policies map[string]RetentionPolicy
+
+	// Classified applies on top of the class policy to items marked
+	// CONFIDENTIAL or above
+	Classified RetentionPolicy
+
+	// Now returns the current time (default time.Now)
+	Now func() time.Time
+}
+
+// PurgeResult is what a run did to one store.
+type PurgeResult struct {
+	Class  string          `json:"class"`
+	Items  int             `json:"items"`
+	Purged []RetentionItem `json:"purged,omitempty"`
+	Bytes  int64           `json:"bytes"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// RetentionReport is the outcome of a RetentionEngine run.
+type RetentionReport struct {
+	DryRun  bool          `json:"dry_run"`
+	Time    time.Time     `json:"time"`
+	Results []PurgeResult `json:"results"`
+}
+
+// Purged counts the items purged, or to be purged in a dry run.
+func (r *RetentionReport) Purged() int {
+	n := 0
+	for _, res := range r.Results {
+		n += len(res.Purged)
+	}
+	return n
+}
+
+// Run applies the policies to each store, purging what they select unless
+// dryRun is set. A store that fails does not stop the others; the errors
+// are returned together, and also recorded in the report.
+func (e *RetentionEngine) Run(stores []RetainedStore, dryRun bool) (*RetentionReport, error) {
+	report := &RetentionReport{DryRun: dryRun, Time: e.now()}
+
+	var errs []error
+	for _, store := range stores {
+		res, err := e.apply(store, dryRun)
+		if err != nil {
+			res.Error = err.Error()
+			errs = append(errs, fmt.Errorf("%s: %w", res.Class, err))
+		}
+		report.Results = append(report.Results, res)
+	}
+	return report, errors.Join(errs...)
+}
+
+// apply selects and purges the items of one store.
+func (e *RetentionEngine) apply(store RetainedStore, dryRun bool) (PurgeResult, error) {
+	res := PurgeResult{Class: store.RetentionClass()}
+	items, err := store.RetentionItems()
+	if err != nil {
+		return res, err
+	}
+	res.Items = len(items)
+	res.Purged = e.Select(res.Class, items)
+	for _, item := range res.Purged {
+		res.Bytes += item.Size
+	}
+	if dryRun || len(res.Purged) == 0 {
+		return res, nil
+	}
+
+	err = store.Purge(res.Purged)
+
+	// SI-12: Record what was purged
+	ids := make([]string, len(res.Purged))
+	for i, item := range res.Purged {
+		ids[i] = item.ID
+	}
+	AuditLogEvent("", "DATA_PURGED", map[string]string{
+		"class":   res.Class,
+		"count":   fmt.Sprintf("%d", len(res.Purged)),
+		"bytes":   fmt.Sprintf("%d", res.Bytes),
+		"items":   strings.Join(ids, ","),
+		"success": fmt.Sprintf("%t", err == nil),
+	})
+	return res, err
+}
+
+// Select returns the items of a class its policy purges, oldest last. Items
+// are kept most recent first until a limit is reached; items older than
+// MaxAge are purged regardless. Classified items are held to the stricter
+// of the class and classified policies.
+func (e *RetentionEngine) Select(class string, items []RetentionItem) []RetentionItem {
+	policy := e.Policies[class]
+	strict := policy.Stricter(e.Classified)
+	if policy.IsZero() && (strict.IsZero() || !anyClassified(items)) {
+		return nil
+	}
+
+	sorted := append([]RetentionItem(nil), items...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Time.After(sorted[j].Time)
+	})
+
+	now := e.now()
+	floor := retentionFloors[class]
+	var purge []RetentionItem
+	kept, size := 0, int64(0)
+	for _, item := range sorted {
+		p := policy
+		if IsClassifiedMarking(item.Classification) {
+			p = strict
+		}
+		age := now.Sub(item.Time)
+
+		switch {
+		case floor > 0 && age < floor:
+			// Kept for the class's minimum period
+		case p.MaxAge > 0 && age > p.MaxAge:
+			item.Reason = "age"
+		case p.MaxCount > 0 && kept >= p.MaxCount:
+			item.Reason = "count"
+		case p.MaxSize > 0 && size+item.Size > p.MaxSize:
+			item.Reason = "size"
+		}
+		if item.Reason == "" {
+			kept++
+			size += item.Size
+			continue
+		}
+		purge = append(purge, item)
+	}
+	return purge
+}
+
+func (e *RetentionEngine) now() time.Time {
+	if e.Now != nil {
+		return e.Now()
+	}
+	return time.Now()
+}
+
+// anyClassified reports whether any of the items is classified.
+func anyClassified(items []RetentionItem) bool {
+	for _, item := range items {
+		if IsClassifiedMarking(item.Classification) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsClassifiedMarking reports whether a marking is CONFIDENTIAL or above. A
+// marking that cannot be parsed is treated as classified.
+func IsClassifiedMarking(marking string) bool {
+	if marking == "" {
+		return false
+	}
+	c, err := ParseClassification(marking)
+	return err != nil || c.IsClassified()
+}
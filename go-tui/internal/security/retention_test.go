@@ -0,0 +1,126 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package security provides IL5 security controls.
+//
+// This file contains tests for SI-12 retention policies.
+package security
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var retentionNow = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// daysAgo returns an item last written n days before retentionNow.
+func daysAgo(id string, n int, size int64, marking string) RetentionItem {
+	return RetentionItem{ID: id, Time: retentionNow.AddDate(0, 0, -n), Size: size, Classification: marking}
+}
+
+// purgedIDs returns the IDs and reasons of the items an engine selects.
+func purgedIDs(e *RetentionEngine, class string, items []RetentionItem) map[string]string {
+	ids := map[string]string{}
+	for _, item := range e.Select(class, items) {
+		ids[item.ID] = item.Reason
+	}
+	return ids
+}
+
+func TestRetentionEngine_Select(t *testing.T) {
+	items := []RetentionItem{
+		daysAgo("a", 1, 100, ""),
+		daysAgo("b", 10, 100, ""),
+		daysAgo("c", 20, 100, "SECRET"),
+		daysAgo("d", 40, 100, "UNCLASSIFIED"),
+	}
+
+	tests := []struct {
+		name       string
+		policy     RetentionPolicy
+		classified RetentionPolicy
+		want       map[string]string
+	}{
+		{"no policy", RetentionPolicy{}, RetentionPolicy{}, map[string]string{}},
+		{"max age", RetentionPolicy{MaxAge: 30 * 24 * time.Hour}, RetentionPolicy{}, map[string]string{"d": "age"}},
+		{"max count", RetentionPolicy{MaxCount: 2}, RetentionPolicy{}, map[string]string{"c": "count", "d": "count"}},
+		{"max size", RetentionPolicy{MaxSize: 250}, RetentionPolicy{}, map[string]string{"c": "size", "d": "size"}},
+		{"classified only", RetentionPolicy{}, RetentionPolicy{MaxAge: 7 * 24 * time.Hour}, map[string]string{"c": "age"}},
+		{"stricter classified", RetentionPolicy{MaxAge: 30 * 24 * time.Hour}, RetentionPolicy{MaxAge: 15 * 24 * time.Hour},
+			map[string]string{"c": "age", "d": "age"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &RetentionEngine{
+				Policies:   map[string]RetentionPolicy{RetentionCosts: tt.policy},
+				Classified: tt.classified,
+				Now:        func() time.Time { return retentionNow },
+			}
+			require.Equal(t, tt.want, purgedIDs(e, RetentionCosts, items))
+		})
+	}
+}
+
+func TestRetentionEngine_AuditArchiveFloor(t *testing.T) {
+	e := &RetentionEngine{
+		Policies: map[string]RetentionPolicy{RetentionAuditArchives: {MaxAge: 24 * time.Hour}},
+		Now:      func() time.Time { return retentionNow },
+	}
+	items := []RetentionItem{
+		daysAgo("recent.log", 30, 100, ""),
+		daysAgo("expired.log", DefaultRetentionDays+1, 100, ""),
+	}
+	require.Equal(t, map[string]string{"expired.log": "age"}, purgedIDs(e, RetentionAuditArchives, items),
+		"Expected archives kept for the AU-11 period")
+}
+
+// failingStore is a retained store that cannot list its items.
+type failingStore struct{}
+
+func (failingStore) RetentionClass() string                   { return RetentionIntel }
+func (failingStore) RetentionItems() ([]RetentionItem, error) { return nil, errors.New("unreadable") }
+func (failingStore) Purge([]RetentionItem) error              { return nil }
+
+// fileStore keeps the files of a directory.
+type fileStore struct{ dir string }
+
+func (s fileStore) RetentionClass() string { return RetentionBenchmarks }
+func (s fileStore) RetentionItems() ([]RetentionItem, error) {
+	return RetentionFiles(s.dir, func(name string) bool { return filepath.Ext(name) == ".json" })
+}
+func (s fileStore) Purge(items []RetentionItem) error { return PurgeFiles(s.dir, items) }
+
+func TestRetentionEngine_Run(t *testing.T) {
+	dir := t.TempDir()
+	for i, name := range []string{"old.json", "new.json", "notes.txt"} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte("result"), 0600))
+		mtime := retentionNow.AddDate(0, 0, -100*(1-i%2))
+		require.NoError(t, os.Chtimes(path, mtime, mtime))
+	}
+	e := &RetentionEngine{
+		Policies: map[string]RetentionPolicy{RetentionBenchmarks: {MaxAge: 30 * 24 * time.Hour}},
+		Now:      func() time.Time { return retentionNow },
+	}
+	stores := []RetainedStore{failingStore{}, fileStore{dir}}
+
+	// A dry run deletes nothing
+	report, err := e.Run(stores, true)
+	require.Error(t, err, "Expected the failing store reported")
+	require.Equal(t, 1, report.Purged())
+	require.Equal(t, "unreadable", report.Results[0].Error)
+	require.FileExists(t, filepath.Join(dir, "old.json"))
+
+	// A failing store does not stop the others
+	report, err = e.Run(stores, false)
+	require.Error(t, err)
+	require.Equal(t, "old.json", report.Results[1].Purged[0].ID)
+	require.NoFileExists(t, filepath.Join(dir, "old.json"))
+	require.FileExists(t, filepath.Join(dir, "new.json"))
+	require.FileExists(t, filepath.Join(dir, "notes.txt"))
+}
@@ -0,0 +1,683 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// secrets.go - NIST 800-53 SC-7(10): Prevent Exfiltration of credentials.
+//
+// Detects credentials (cloud provider keys, private keys, tokens, .env-style
+// assignments, high-entropy strings and administrator-defined patterns) in
+// content before it leaves the system boundary for a cloud tier.
+//
+// A SecretGuard applies one of three actions to outbound content:
+//
+//   - block:  refuse to send the content
+//   - redact: replace each secret with a stable placeholder such as
+//     [SECRET_AWS_ACCESS_KEY_1]; placeholders in the response are
+//     restored to the original value so tool calls keep working
+//   - ask:    defer the decision to an interactive SecretPrompter
+//
+// Secret values are never written to the audit log; only their type and
+// location are recorded.
+
+package security
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// =============================================================================
+// CONSTANTS
+// =============================================================================
+
+// Secret handling actions
+const (
+	SecretActionBlock  = "block"  // Refuse to send content containing secrets
+	SecretActionRedact = "redact" // Replace secrets with restorable placeholders
+	SecretActionAsk    = "ask"    // Ask the user for each send
+	SecretActionAllow  = "allow"  // Send unmodified (only as a SecretPrompter answer)
+)
+
+// Secret types reported by the built-in rules
+const (
+	SecretTypeAWSAccessKey     = "AWS_ACCESS_KEY"
+	SecretTypeAWSSecretKey     = "AWS_SECRET_KEY"
+	SecretTypeGCPAPIKey        = "GCP_API_KEY"
+	SecretTypeGCPServiceAcct   = "GCP_SERVICE_ACCOUNT"
+	SecretTypePrivateKey       = "PRIVATE_KEY"
+	SecretTypeJWT              = "JWT"
+	SecretTypeGitHubToken      = "GITHUB_TOKEN"
+	SecretTypeSlackToken       = "SLACK_TOKEN"
+	SecretTypeOpenAIKey        = "OPENAI_KEY"
+	SecretTypeAnthropicKey     = "ANTHROPIC_KEY"
+	SecretTypeOpenRouterKey    = "OPENROUTER_KEY"
+	SecretTypeEnvAssignment    = "ENV_SECRET"
+	SecretTypeHighEntropy      = "HIGH_ENTROPY"
+	SecretTypeCustom           = "CUSTOM"
+	secretMinHighEntropyLength = 20
+)
+
+// maxSecretScanFileSize limits the size of files scanned by ScanPath.
+const maxSecretScanFileSize = 5 * 1024 * 1024 // 5MB
+
+// ErrSecretsBlocked is returned when outbound content contains secrets and
+// the configured (or chosen) action is to block it.
+var ErrSecretsBlocked = errors.New("outbound content contains secrets")
+
+// =============================================================================
+// DETECTION RULES
+// =============================================================================
+
+// secretRule is a single detection pattern. When the pattern has a capture
+// group, only the first group is treated as the secret (e.g. the value of an
+// assignment rather than the variable name).
+type secretRule struct {
+	secretType string
+	pattern    *regexp.Regexp
+}
+
+// builtinSecretRules are evaluated in order; earlier rules win when matches
+// overlap, so specific rules precede generic ones.
+var builtinSecretRules = []secretRule{
+	{SecretTypePrivateKey, regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z0-9 ]*PRIVATE KEY-----`)},
+	{SecretTypeAWSAccessKey, regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|AIPA|ANPA|ANVA)[0-9A-Z]{16}\b`)},
+	{SecretTypeAWSSecretKey, regexp.MustCompile(`(?i)aws[_\-.]?(?:secret[_\-.]?)?(?:access[_\-.]?)?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})\b`)},
+	{SecretTypeGCPAPIKey, regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}\b`)},
+	{SecretTypeGCPServiceAcct, regexp.MustCompile(`"private_key_id"\s*:\s*"([a-f0-9]{40})"`)},
+	{SecretTypeJWT, regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+	{SecretTypeGitHubToken, regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{60,})\b`)},
+	{SecretTypeSlackToken, regexp.MustCompile(`\bxox[abprs]-[0-9A-Za-z\-]{10,}`)},
+	{SecretTypeAnthropicKey, regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_\-]{20,}`)},
+	{SecretTypeOpenRouterKey, regexp.MustCompile(`\bsk-or-v1-[A-Za-z0-9]{32,}`)},
+	{SecretTypeOpenAIKey, regexp.MustCompile(`\bsk-(?:proj-)?[A-Za-z0-9_\-]{20,}`)},
+	{SecretTypeEnvAssignment, regexp.MustCompile(`(?m)^[ \t]*(?:export[ \t]+)?[A-Z0-9_]*(?:SECRET|TOKEN|PASSWORD|PASSWD|API_?KEY|PRIVATE_?KEY|ACCESS_?KEY|CREDENTIALS?)[A-Z0-9_]*[ \t]*[=:][ \t]*["']?([^\s"'#]{8,})`)},
+}
+
+// highEntropyRegex finds candidate tokens for entropy analysis.
+var highEntropyRegex = regexp.MustCompile(`[A-Za-z0-9+/=_\-]{20,}`)
+
+// placeholderRegex matches placeholders produced by SecretVault.
+var placeholderRegex = regexp.MustCompile(`\[SECRET_[A-Z0-9_]+_[0-9]+\]`)
+
+// =============================================================================
+// FINDINGS
+// =============================================================================
+
+// SecretFinding describes a single detected secret.
+// The raw value is never serialized.
+type SecretFinding struct {
+	Type    string  `json:"type"`
+	Path    string  `json:"path,omitempty"`
+	Line    int     `json:"line"`
+	Column  int     `json:"column"`
+	Preview string  `json:"preview"`           // Masked value, safe to display
+	Entropy float64 `json:"entropy,omitempty"` // Set for HIGH_ENTROPY findings
+	Start   int     `json:"-"`
+	End     int     `json:"-"`
+	Value   string  `json:"-"`
+}
+
+// String returns a display form of the finding that never includes the secret.
+func (f SecretFinding) String() string {
+	loc := fmt.Sprintf("%d:%d", f.Line, f.Column)
+	if f.Path != "" {
+		loc = f.Path + ":" + loc
+	}
+	return fmt.Sprintf("%s  %s  %s", loc, f.Type, f.Preview)
+}
+
+// SummarizeSecretFindings returns a compact "TYPE xN" summary, sorted by type.
+func SummarizeSecretFindings(findings []SecretFinding) string {
+	counts := make(map[string]int)
+	for _, f := range findings {
+		counts[f.Type]++
+	}
+	types := make([]string, 0, len(counts))
+	for t := range counts {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	parts := make([]string, 0, len(types))
+	for _, t := range types {
+		if counts[t] > 1 {
+			parts = append(parts, fmt.Sprintf("%s x%d", t, counts[t]))
+		} else {
+			parts = append(parts, t)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// maskSecret returns a preview that reveals at most the first four characters.
+func maskSecret(value string) string {
+	if strings.HasPrefix(value, "-----BEGIN") {
+		if idx := strings.Index(value, "\n"); idx > 0 {
+			return strings.TrimSpace(value[:idx]) + " [...]"
+		}
+	}
+	if len(value) <= 8 {
+		return strings.Repeat("*", len(value))
+	}
+	return value[:4] + strings.Repeat("*", 8) + fmt.Sprintf(" (%d chars)", len(value))
+}
+
+// =============================================================================
+// SECRET DETECTOR
+// =============================================================================
+
+// SecretDetector finds credentials in text using built-in rules plus any
+// administrator-defined patterns. It is safe for concurrent use.
+type SecretDetector struct {
+	rules       []secretRule
+	highEntropy bool
+}
+
+// NewSecretDetector creates a detector with the built-in rules and the given
+// custom regular expressions. Custom patterns that fail to compile are
+// returned as an error; the detector is still usable with the remaining rules.
+func NewSecretDetector(customPatterns []string) (*SecretDetector, error) {
+	d := &SecretDetector{
+		rules:       append([]secretRule(nil), builtinSecretRules...),
+		highEntropy: true,
+	}
+
+	var errs []string
+	for _, p := range customPatterns {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		re, err := regexp.Compile(p)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%q: %v", p, err))
+			continue
+		}
+		d.rules = append(d.rules, secretRule{SecretTypeCustom, re})
+	}
+
+	if len(errs) > 0 {
+		return d, fmt.Errorf("invalid secret pattern(s): %s", strings.Join(errs, "; "))
+	}
+	return d, nil
+}
+
+// Scan returns the secrets found in content, ordered by position.
+// Overlapping matches are reported once, by the most specific rule.
+func (d *SecretDetector) Scan(content string) []SecretFinding {
+	if content == "" {
+		return nil
+	}
+
+	var findings []SecretFinding
+	overlaps := func(start, end int) bool {
+		for _, f := range findings {
+			if start < f.End && end > f.Start {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, rule := range d.rules {
+		for _, loc := range rule.pattern.FindAllStringSubmatchIndex(content, -1) {
+			start, end := loc[0], loc[1]
+			if len(loc) >= 4 && loc[2] >= 0 {
+				start, end = loc[2], loc[3]
+			}
+			if start >= end || overlaps(start, end) {
+				continue
+			}
+			if placeholderRegex.MatchString(content[start:end]) {
+				continue
+			}
+			findings = append(findings, SecretFinding{
+				Type:  rule.secretType,
+				Start: start,
+				End:   end,
+				Value: content[start:end],
+			})
+		}
+	}
+
+	if d.highEntropy {
+		for _, loc := range highEntropyRegex.FindAllStringIndex(content, -1) {
+			start, end := loc[0], loc[1]
+			if overlaps(start, end) {
+				continue
+			}
+			value := content[start:end]
+			if !looksRandom(value) {
+				continue
+			}
+			findings = append(findings, SecretFinding{
+				Type:    SecretTypeHighEntropy,
+				Start:   start,
+				End:     end,
+				Value:   value,
+				Entropy: calculateEntropy(value),
+			})
+		}
+	}
+
+	sort.Slice(findings, func(i, j int) bool { return findings[i].Start < findings[j].Start })
+	for i := range findings {
+		findings[i].Line, findings[i].Column = lineColumn(content, findings[i].Start)
+		findings[i].Preview = maskSecret(findings[i].Value)
+	}
+	return findings
+}
+
+// looksRandom reports whether a token is likely a generated credential rather
+// than an identifier or path: long, mixed letters and digits, high entropy.
+func looksRandom(s string) bool {
+	if len(s) < secretMinHighEntropyLength {
+		return false
+	}
+	var hasUpper, hasLower, hasDigit bool
+	for _, r := range s {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			hasUpper = true
+		case r >= 'a' && r <= 'z':
+			hasLower = true
+		case r >= '0' && r <= '9':
+			hasDigit = true
+		}
+	}
+	if !hasDigit || !(hasUpper || hasLower) {
+		return false
+	}
+	return calculateEntropy(s) > EntropyThreshold
+}
+
+// lineColumn converts a byte offset into 1-based line and column numbers.
+func lineColumn(content string, offset int) (int, int) {
+	line := 1 + strings.Count(content[:offset], "\n")
+	col := offset + 1
+	if idx := strings.LastIndex(content[:offset], "\n"); idx >= 0 {
+		col = offset - idx
+	}
+	return line, col
+}
+
+// ScanFile scans a single file. Binary files and files larger than 5MB are
+// skipped and return no findings.
+func (d *SecretDetector) ScanFile(path string) ([]SecretFinding, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > maxSecretScanFileSize {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if isBinaryContent(data) {
+		return nil, nil
+	}
+
+	findings := d.Scan(string(data))
+	for i := range findings {
+		findings[i].Path = path
+	}
+	return findings, nil
+}
+
+// ScanPath scans a file or walks a directory tree, skipping VCS metadata and
+// dependency directories. Unreadable files are skipped.
+func (d *SecretDetector) ScanPath(root string) ([]SecretFinding, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return d.ScanFile(root)
+	}
+
+	var findings []SecretFinding
+	err = filepath.WalkDir(root, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if entry.IsDir() {
+			switch entry.Name() {
+			case ".git", ".hg", ".svn", "node_modules", "vendor", SpillageBackupDir:
+				if path != root {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		fileFindings, err := d.ScanFile(path)
+		if err != nil {
+			return nil
+		}
+		findings = append(findings, fileFindings...)
+		return nil
+	})
+	return findings, err
+}
+
+// isBinaryContent sniffs the first 8KB for NUL bytes.
+func isBinaryContent(data []byte) bool {
+	if len(data) > 8192 {
+		data = data[:8192]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
+
+// =============================================================================
+// SECRET VAULT (STABLE PLACEHOLDERS)
+// =============================================================================
+
+// SecretVault maps secrets to stable placeholders for the lifetime of a
+// session, so the same secret is always replaced by the same placeholder and
+// responses that echo a placeholder can be restored.
+// Values are held in memory only.
+type SecretVault struct {
+	mu            sync.RWMutex
+	bySecret      map[string]string
+	byPlaceholder map[string]string
+	counters      map[string]int
+}
+
+// NewSecretVault creates an empty vault.
+func NewSecretVault() *SecretVault {
+	return &SecretVault{
+		bySecret:      make(map[string]string),
+		byPlaceholder: make(map[string]string),
+		counters:      make(map[string]int),
+	}
+}
+
+// placeholderFor returns the placeholder for a secret, allocating one if needed.
+func (v *SecretVault) placeholderFor(secretType, value string) string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if p, ok := v.bySecret[value]; ok {
+		return p
+	}
+	v.counters[secretType]++
+	p := fmt.Sprintf("[SECRET_%s_%d]", secretType, v.counters[secretType])
+	v.bySecret[value] = p
+	v.byPlaceholder[p] = value
+	return p
+}
+
+// Redact replaces each finding in content with its placeholder.
+// Findings must come from scanning the same content.
+func (v *SecretVault) Redact(content string, findings []SecretFinding) string {
+	if len(findings) == 0 {
+		return content
+	}
+	var b strings.Builder
+	last := 0
+	for _, f := range findings {
+		if f.Start < last || f.End > len(content) {
+			continue
+		}
+		b.WriteString(content[last:f.Start])
+		b.WriteString(v.placeholderFor(f.Type, f.Value))
+		last = f.End
+	}
+	b.WriteString(content[last:])
+	return b.String()
+}
+
+// Restore replaces known placeholders in text with their original values.
+// Unknown placeholders are left untouched.
+func (v *SecretVault) Restore(text string) string {
+	if !strings.Contains(text, "[SECRET_") {
+		return text
+	}
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return placeholderRegex.ReplaceAllStringFunc(text, func(p string) string {
+		if value, ok := v.byPlaceholder[p]; ok {
+			return value
+		}
+		return p
+	})
+}
+
+// Len returns the number of secrets held by the vault.
+func (v *SecretVault) Len() int {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return len(v.bySecret)
+}
+
+// =============================================================================
+// STREAM RESTORER
+// =============================================================================
+
+// SecretStreamRestorer restores placeholders in streamed text where a
+// placeholder may be split across chunks. It holds back any trailing text
+// that could be the start of a placeholder until it is complete.
+type SecretStreamRestorer struct {
+	vault   *SecretVault
+	pending string
+}
+
+// maxPlaceholderLen bounds how much text is held back waiting for a "]".
+const maxPlaceholderLen = 64
+
+// NewStreamRestorer creates a restorer bound to this vault.
+func (v *SecretVault) NewStreamRestorer() *SecretStreamRestorer {
+	return &SecretStreamRestorer{vault: v}
+}
+
+// Write accepts the next chunk and returns the text that is safe to emit.
+func (r *SecretStreamRestorer) Write(chunk string) string {
+	text := r.pending + chunk
+	r.pending = ""
+
+	if idx := strings.LastIndex(text, "["); idx >= 0 {
+		tail := text[idx:]
+		if !strings.Contains(tail, "]") && len(tail) < maxPlaceholderLen &&
+			(strings.HasPrefix("[SECRET_", tail) || strings.HasPrefix(tail, "[SECRET_")) {
+			r.pending = tail
+			text = text[:idx]
+		}
+	}
+	return r.vault.Restore(text)
+}
+
+// Flush returns any held-back text.
+func (r *SecretStreamRestorer) Flush() string {
+	text := r.pending
+	r.pending = ""
+	return r.vault.Restore(text)
+}
+
+// =============================================================================
+// SECRET GUARD
+// =============================================================================
+
+// SecretPrompter asks the user how to handle secrets found in outbound
+// content. It returns SecretActionRedact, SecretActionAllow or
+// SecretActionBlock. Implementations should honor ctx cancellation.
+type SecretPrompter func(ctx context.Context, findings []SecretFinding) string
+
+// SecretScanResult describes one outbound scan for display.
+type SecretScanResult struct {
+	Findings []SecretFinding
+	Action   string // The action actually applied (block, redact or allow)
+}
+
+// Describe returns a one-line description of what was done with the secrets.
+func (r SecretScanResult) Describe() string {
+	summary := SummarizeSecretFindings(r.Findings)
+	count := len(r.Findings)
+	switch r.Action {
+	case SecretActionRedact:
+		return fmt.Sprintf("Redacted %d secret(s) before sending to cloud: %s", count, summary)
+	case SecretActionAllow:
+		return fmt.Sprintf("Sent %d secret(s) to cloud unredacted at user request: %s", count, summary)
+	default:
+		return fmt.Sprintf("Blocked cloud request containing %d secret(s): %s", count, summary)
+	}
+}
+
+// SecretGuard applies the configured action to outbound content.
+type SecretGuard struct {
+	detector *SecretDetector
+	vault    *SecretVault
+	action   string
+
+	mu       sync.RWMutex
+	prompter SecretPrompter
+	notify   func(SecretScanResult)
+}
+
+// NewSecretGuard creates a guard. Unknown actions fall back to block.
+func NewSecretGuard(detector *SecretDetector, action string) *SecretGuard {
+	action = strings.ToLower(strings.TrimSpace(action))
+	switch action {
+	case SecretActionBlock, SecretActionRedact, SecretActionAsk:
+	default:
+		action = SecretActionBlock
+	}
+	return &SecretGuard{
+		detector: detector,
+		vault:    NewSecretVault(),
+		action:   action,
+	}
+}
+
+// SetPrompter sets the function used for the "ask" action. Without a
+// prompter, "ask" fails closed and blocks.
+func (g *SecretGuard) SetPrompter(p SecretPrompter) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.prompter = p
+}
+
+// SetNotifier sets a callback invoked after every scan that found secrets.
+func (g *SecretGuard) SetNotifier(fn func(SecretScanResult)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.notify = fn
+}
+
+// Action returns the configured action.
+func (g *SecretGuard) Action() string {
+	return g.action
+}
+
+// Vault returns the guard's placeholder vault.
+func (g *SecretGuard) Vault() *SecretVault {
+	return g.vault
+}
+
+// Filter scans contents and returns them ready to send. With "redact" the
+// returned contents have secrets replaced by placeholders; with "block" (or
+// when the prompter declines) an error wrapping ErrSecretsBlocked is returned.
+// Every scan with findings is audited as SECRET_DETECTED.
+func (g *SecretGuard) Filter(ctx context.Context, contents []string) ([]string, error) {
+	perContent := make([][]SecretFinding, len(contents))
+	var all []SecretFinding
+	for i, content := range contents {
+		perContent[i] = g.detector.Scan(content)
+		all = append(all, perContent[i]...)
+	}
+	if len(all) == 0 {
+		return contents, nil
+	}
+
+	g.mu.RLock()
+	prompter, notify := g.prompter, g.notify
+	g.mu.RUnlock()
+
+	action := g.action
+	if action == SecretActionAsk {
+		action = SecretActionBlock
+		if prompter != nil {
+			switch answer := prompter(ctx, all); answer {
+			case SecretActionRedact, SecretActionAllow:
+				action = answer
+			}
+		}
+	}
+
+	AuditLogEvent("", "SECRET_DETECTED", map[string]string{
+		"count":  fmt.Sprintf("%d", len(all)),
+		"types":  SummarizeSecretFindings(all),
+		"action": action,
+		"policy": g.action,
+	})
+	if notify != nil {
+		notify(SecretScanResult{Findings: all, Action: action})
+	}
+
+	switch action {
+	case SecretActionAllow:
+		return contents, nil
+	case SecretActionRedact:
+		out := make([]string, len(contents))
+		for i, content := range contents {
+			out[i] = g.vault.Redact(content, perContent[i])
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrSecretsBlocked, SummarizeSecretFindings(all))
+	}
+}
+
+// Restore replaces placeholders in a response with the original secrets.
+func (g *SecretGuard) Restore(text string) string {
+	return g.vault.Restore(text)
+}
+
+// =============================================================================
+// INTERACTIVE PROMPT
+// =============================================================================
+
+// PromptSecretAction is a line-oriented SecretPrompter for terminal use.
+// It writes the findings to out and reads a single answer from in:
+// r = redact, s = send unredacted, anything else blocks.
+func PromptSecretAction(in io.Reader, out io.Writer) SecretPrompter {
+	reader := bufio.NewReader(in)
+	return func(ctx context.Context, findings []SecretFinding) string {
+		fmt.Fprintf(out, "\nSecrets detected in content bound for a cloud model:\n")
+		for _, f := range findings {
+			fmt.Fprintf(out, "  %s\n", f)
+		}
+		fmt.Fprintf(out, "[r]edact and send, [s]end unredacted, or [b]lock? [b]: ")
+
+		answer := make(chan string, 1)
+		go func() {
+			line, _ := reader.ReadString('\n')
+			answer <- strings.ToLower(strings.TrimSpace(line))
+		}()
+
+		select {
+		case <-ctx.Done():
+			return SecretActionBlock
+		case a := <-answer:
+			switch a {
+			case "r", "redact":
+				return SecretActionRedact
+			case "s", "send":
+				return SecretActionAllow
+			}
+			return SecretActionBlock
+		}
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package securitytest provides helpers for testing the stores that encrypt
+// user content with the data cipher.
+package securitytest
+
+import (
+	"testing"
+
+	"github.com/jeranaias/rigrun-tui/internal/security"
+)
+
+// NewEncryptionManager returns an initialized encryption manager with a
+// fresh master key. The key is kept under a temporary home directory, so
+// the test's HOME is changed for its duration and the test must not run in
+// parallel.
+func NewEncryptionManager(t testing.TB) *security.EncryptionManager {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	em, err := security.NewEncryptionManager()
+	if err != nil {
+		t.Fatalf("NewEncryptionManager failed: %v", err)
+	}
+	if err := em.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	return em
+}
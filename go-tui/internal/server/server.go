@@ -32,6 +32,7 @@ import (
 	"github.com/jeranaias/rigrun-tui/internal/ollama"
 	"github.com/jeranaias/rigrun-tui/internal/router"
 	"github.com/jeranaias/rigrun-tui/internal/security"
+	"github.com/jeranaias/rigrun-tui/internal/telemetry"
 )
 
 // ============================================================================
@@ -170,6 +171,10 @@ type Server struct {
 	// paranoidMode blocks all cloud requests when true (NIST SC-7 boundary protection)
 	paranoidMode bool
 
+	// budget records cloud spend and routes requests locally once a spend
+	// budget is exceeded; nil means no budget
+	budget *telemetry.BudgetGuard
+
 	mu sync.RWMutex
 }
 
@@ -225,6 +230,16 @@ func (s *Server) WithAuth(config *AuthConfig) *Server {
 	return s
 }
 
+// WithBudget sets the spend budget cloud requests are checked against and
+// recorded in. Nobody can confirm spending over budget through the API, so
+// an exceeded budget always routes requests locally.
+func (s *Server) WithBudget(guard *telemetry.BudgetGuard) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.budget = guard
+	return s
+}
+
 // Port returns the server port.
 func (s *Server) Port() int {
 	return s.port
@@ -443,7 +458,7 @@ func (s *Server) handleNonStreamingCompletion(w http.ResponseWriter, r *http.Req
 			cloudClient := s.cloud
 			s.mu.RUnlock()
 
-			if cloudClient != nil && cloudClient.IsConfigured() {
+			if cloudClient != nil && cloudClient.IsConfigured() && s.withinBudget() {
 				log.Printf("LOCAL_FALLBACK | error=%v falling_back_to_cloud", err)
 				responseText, promptTokens, completionTokens, err = s.executeCloudRequest(ctx, req)
 				tier = router.TierCloud
@@ -728,6 +743,36 @@ func (s *Server) streamResponse(w http.ResponseWriter, chunks <-chan StreamChunk
 // CRITICAL FIX: Include classification (default Unclassified for API) and paranoid_mode
 // Classification enforcement ensures CUI+ data stays on-premise (NIST AC-4)
 func (s *Server) routeRequest(model, query string) router.Tier {
+	tier := s.requestedTier(model, query)
+	if tier.IsLocal() {
+		return tier
+	}
+
+	// Spend budgets are checked before committing to a paid tier
+	s.mu.RLock()
+	budget := s.budget
+	s.mu.RUnlock()
+	if budget == nil {
+		return tier
+	}
+	decision := router.ApplyBudget(router.RoutingDecision{Tier: tier, Reason: "model " + model},
+		budget, router.BudgetActionLocal)
+	if decision.Tier != tier {
+		log.Printf("BUDGET_EXCEEDED | %s", decision.Reason)
+	}
+	return decision.Tier
+}
+
+// withinBudget reports whether a cloud request is within the spend budget.
+func (s *Server) withinBudget() bool {
+	s.mu.RLock()
+	budget := s.budget
+	s.mu.RUnlock()
+	return budget == nil || budget.ExceededBudget() == ""
+}
+
+// requestedTier returns the tier a request's model asks for, before budgets.
+func (s *Server) requestedTier(model, query string) router.Tier {
 	switch model {
 	case "auto", "":
 		// Default to Unclassified for API requests (TUI has its own classification handling)
@@ -802,14 +847,35 @@ func (s *Server) executeCloudRequest(ctx context.Context, req ChatCompletionRequ
 	}
 
 	// Execute chat request
+	start := time.Now()
 	resp, err := cloudClient.Chat(ctx, cloudMessages)
 	if err != nil {
 		return "", 0, 0, err
 	}
+	s.recordCloudSpend(resp.Usage.PromptTokens, resp.Usage.CompletionTokens, time.Since(start), req)
 
 	return resp.GetContent(), resp.Usage.PromptTokens, resp.Usage.CompletionTokens, nil
 }
 
+// recordCloudSpend records the token usage of a cloud request against the
+// spend budget. The session is saved after each request so that later
+// budget checks count its spend.
+func (s *Server) recordCloudSpend(inputTokens, outputTokens int, duration time.Duration, req ChatCompletionRequest) {
+	s.mu.RLock()
+	budget := s.budget
+	s.mu.RUnlock()
+	if budget == nil || budget.Tracker == nil {
+		return
+	}
+
+	var prompt string
+	if len(req.Messages) > 0 {
+		prompt = req.Messages[len(req.Messages)-1].Content
+	}
+	budget.Tracker.RecordQuery(strings.ToLower(router.TierCloud.String()), inputTokens, outputTokens, duration, prompt)
+	_ = budget.Tracker.SaveCurrentSession()
+}
+
 // ============================================================================
 // MODELS HANDLER
 // ============================================================================
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/jeranaias/rigrun-tui/internal/router"
+	"github.com/jeranaias/rigrun-tui/internal/telemetry"
 )
 
 // =============================================================================
@@ -534,6 +535,29 @@ func TestRouteRequest(t *testing.T) {
 	}
 }
 
+func TestRouteRequest_Budget(t *testing.T) {
+	tracker, err := telemetry.NewCostTracker(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewCostTracker failed: %v", err)
+	}
+	s := NewServer(0).WithBudget(&telemetry.BudgetGuard{Tracker: tracker, Budget: telemetry.Budget{Session: 0.01}})
+
+	if tier := s.routeRequest("cloud", "test"); tier != router.TierCloud {
+		t.Fatalf("routeRequest within budget = %v, want %v", tier, router.TierCloud)
+	}
+
+	// Spend the session budget the way executeCloudRequest records it
+	s.recordCloudSpend(100000, 100000, time.Second, ChatCompletionRequest{
+		Messages: []ChatMessage{{Role: "user", Content: "test"}},
+	})
+	if tier := s.routeRequest("cloud", "test"); tier != router.TierLocal {
+		t.Errorf("routeRequest over budget = %v, want %v", tier, router.TierLocal)
+	}
+	if s.withinBudget() {
+		t.Error("withinBudget() = true after the budget was spent")
+	}
+}
+
 // =============================================================================
 // TYPE TESTS
 // =============================================================================
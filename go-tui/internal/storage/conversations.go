@@ -8,12 +8,11 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
-	"os"
-	"path/filepath"
-	"sort"
+	"fmt"
 	"strings"
 	"time"
 
+	"github.com/jeranaias/rigrun-tui/internal/security"
 	"github.com/jeranaias/rigrun-tui/internal/util"
 )
 
@@ -30,12 +29,20 @@ type StoredConversation struct {
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 
-	// Messages
+	// Messages is the active branch of the conversation
 	Messages []StoredMessage `json:"messages"`
 
+	// Branches holds the messages on inactive branches. Together with
+	// Messages they form a tree linked by StoredMessage.ParentID.
+	Branches []StoredMessage `json:"branches,omitempty"`
+
 	// Context tracking
 	TokensUsed int      `json:"tokens_used,omitempty"`
 	Mentions   []string `json:"mentions,omitempty"`
+
+	// Classification is the conversation's high-water mark: the highest
+	// marking of any message it contains. Recomputed on every Save.
+	Classification string `json:"classification,omitempty"`
 }
 
 // StoredMessage represents a persisted message.
@@ -45,6 +52,9 @@ type StoredMessage struct {
 	Content   string    `json:"content"`
 	Timestamp time.Time `json:"timestamp"`
 
+	// ParentID is the message this one follows (empty for the first message)
+	ParentID string `json:"parent_id,omitempty"`
+
 	// Statistics (for assistant messages)
 	TokenCount   int     `json:"token_count,omitempty"`
 	DurationMs   int64   `json:"duration_ms,omitempty"`
@@ -56,6 +66,9 @@ type StoredMessage struct {
 	ToolInput  string `json:"tool_input,omitempty"`
 	ToolResult string `json:"tool_result,omitempty"`
 	IsSuccess  bool   `json:"is_success,omitempty"`
+
+	// Classification marking for this message (e.g., "CUI", "SECRET//NOFORN")
+	Classification string `json:"classification,omitempty"`
 }
 
 // ConversationMeta contains metadata for listing conversations.
@@ -67,99 +80,51 @@ type ConversationMeta struct {
 	UpdatedAt    time.Time `json:"updated_at"`
 	MessageCount int       `json:"message_count"`
 	Preview      string    `json:"preview"` // First user message truncated
-}
 
-// =============================================================================
-// CONVERSATION STORE
-// =============================================================================
+	// Classification is the conversation's high-water mark
+	Classification string `json:"classification,omitempty"`
 
-// ConversationStore handles conversation persistence.
-type ConversationStore struct {
-	// BaseDir is the directory for storing conversations
-	// Default: ~/.rigrun/conversations/
-	BaseDir string
+	// Pinned conversations are kept when old ones are pruned
+	Pinned bool `json:"pinned,omitempty"`
 
-	// MaxConversations limits stored conversations (0 = unlimited)
-	MaxConversations int
+	// Tags label the conversation (see ConversationStore.AddTag)
+	Tags []string `json:"tags,omitempty"`
 }
 
-// NewConversationStore creates a new conversation store.
-func NewConversationStore() (*ConversationStore, error) {
-	// Get user home directory
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return nil, err
-	}
+// =============================================================================
+// SEARCH HIT TYPE
+// =============================================================================
 
-	baseDir := filepath.Join(homeDir, ".rigrun", "conversations")
+// MessageHit is a single message that matched a session search.
+type MessageHit struct {
+	ConversationID string    `json:"conversation_id"`
+	Summary        string    `json:"summary"`
+	UpdatedAt      time.Time `json:"updated_at"`
 
-	// Ensure directory exists
-	if err := os.MkdirAll(baseDir, 0755); err != nil {
-		return nil, err
-	}
+	// Classification is the conversation's high-water mark
+	Classification string `json:"classification,omitempty"`
 
-	return &ConversationStore{
-		BaseDir:          baseDir,
-		MaxConversations: 100,
-	}, nil
-}
+	MessageID    string `json:"message_id"`
+	MessageIndex int    `json:"message_index"` // Position in the active branch
+	Role         string `json:"role"`
 
-// NewConversationStoreWithDir creates a store with a custom directory.
-func NewConversationStoreWithDir(baseDir string) (*ConversationStore, error) {
-	if err := os.MkdirAll(baseDir, 0755); err != nil {
-		return nil, err
-	}
+	// Snippet is an excerpt of the message with each matched term wrapped
+	// in SnippetMatchStart and SnippetMatchEnd
+	Snippet string `json:"snippet"`
+}
 
-	return &ConversationStore{
-		BaseDir:          baseDir,
-		MaxConversations: 100,
-	}, nil
+// Marking returns the conversation's high-water mark. Callers showing
+// snippets must check it against the session level (AC-4).
+func (h MessageHit) Marking() security.Classification {
+	return security.ParseStoredMarking(h.Classification)
 }
 
 // =============================================================================
-// SAVE OPERATIONS
+// HELPER FUNCTIONS
 // =============================================================================
 
-// Save persists a conversation and returns its ID.
-func (s *ConversationStore) Save(conv *StoredConversation) (string, error) {
-	// Generate ID if not set
-	if conv.ID == "" {
-		conv.ID = generateConversationID()
-	}
-
-	// Auto-generate summary if not set
-	if conv.Summary == "" {
-		conv.Summary = s.generateSummary(conv)
-	}
-
-	// Update timestamp
-	conv.UpdatedAt = time.Now()
-	if conv.CreatedAt.IsZero() {
-		conv.CreatedAt = conv.UpdatedAt
-	}
-
-	// Marshal to JSON
-	data, err := json.MarshalIndent(conv, "", "  ")
-	if err != nil {
-		return "", err
-	}
-
-	// RELIABILITY: Atomic write with fsync prevents data loss on crash
-	filePath := s.filePath(conv.ID)
-	if err := util.AtomicWriteFile(filePath, data, 0644); err != nil {
-		return "", err
-	}
-
-	// Enforce max conversations limit
-	if s.MaxConversations > 0 {
-		s.enforceLimit()
-	}
-
-	return conv.ID, nil
-}
-
 // generateSummary creates a summary from the first user message.
-func (s *ConversationStore) generateSummary(conv *StoredConversation) string {
+func generateSummary(conv *StoredConversation) string {
 	for _, msg := range conv.Messages {
 		if msg.Role == "user" && msg.Content != "" {
 			content := msg.Content
@@ -177,192 +142,21 @@ func (s *ConversationStore) generateSummary(conv *StoredConversation) string {
 	return "New conversation"
 }
 
-// enforceLimit removes oldest conversations if over limit.
-func (s *ConversationStore) enforceLimit() {
-	metas, err := s.List()
-	if err != nil || len(metas) <= s.MaxConversations {
-		return
-	}
-
-	// Sort by updated time (oldest first)
-	sort.Slice(metas, func(i, j int) bool {
-		return metas[i].UpdatedAt.Before(metas[j].UpdatedAt)
-	})
-
-	// Delete excess
-	excess := len(metas) - s.MaxConversations
-	for i := 0; i < excess; i++ {
-		s.Delete(metas[i].ID)
-	}
-}
-
-// =============================================================================
-// LOAD OPERATIONS
-// =============================================================================
-
-// Load retrieves a conversation by ID.
-func (s *ConversationStore) Load(id string) (*StoredConversation, error) {
-	filePath := s.filePath(id)
-
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, ErrConversationNotFound
-		}
-		return nil, err
-	}
-
-	var conv StoredConversation
-	if err := json.Unmarshal(data, &conv); err != nil {
-		return nil, err
-	}
-
-	return &conv, nil
-}
-
-// LoadByIndex loads a conversation by its index in the list (0 = most recent).
-func (s *ConversationStore) LoadByIndex(index int) (*StoredConversation, error) {
-	metas, err := s.List()
-	if err != nil {
-		return nil, err
-	}
-
-	if index < 0 || index >= len(metas) {
-		return nil, ErrConversationNotFound
-	}
-
-	return s.Load(metas[index].ID)
-}
-
-// =============================================================================
-// LIST OPERATIONS
-// =============================================================================
-
-// List returns all saved conversations (most recent first).
-func (s *ConversationStore) List() ([]ConversationMeta, error) {
-	entries, err := os.ReadDir(s.BaseDir)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return []ConversationMeta{}, nil
-		}
-		return nil, err
-	}
-
-	var metas []ConversationMeta
-
-	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
-			continue
-		}
-
-		// Extract ID from filename
-		id := strings.TrimSuffix(entry.Name(), ".json")
-
-		// Load the conversation to get metadata
-		conv, err := s.Load(id)
-		if err != nil {
-			continue // Skip corrupted files
-		}
-
-		// Get first user message as preview
-		preview := ""
-		for _, msg := range conv.Messages {
-			if msg.Role == "user" {
-				preview = msg.Content
-				// Use rune-based truncation for Unicode safety
-				previewRunes := []rune(preview)
-				if len(previewRunes) > 80 {
-					preview = string(previewRunes[:77]) + "..."
-				}
-				break
+// listPreview returns the first user message of a conversation, truncated
+// for listing.
+func listPreview(conv *StoredConversation) string {
+	for _, msg := range conv.Messages {
+		if msg.Role == "user" {
+			// Use rune-based truncation for Unicode safety
+			preview := msg.Content
+			previewRunes := []rune(preview)
+			if len(previewRunes) > 80 {
+				preview = string(previewRunes[:77]) + "..."
 			}
-		}
-
-		metas = append(metas, ConversationMeta{
-			ID:           conv.ID,
-			Summary:      conv.Summary,
-			Model:        conv.Model,
-			CreatedAt:    conv.CreatedAt,
-			UpdatedAt:    conv.UpdatedAt,
-			MessageCount: len(conv.Messages),
-			Preview:      preview,
-		})
-	}
-
-	// Sort by updated time (most recent first)
-	sort.Slice(metas, func(i, j int) bool {
-		return metas[i].UpdatedAt.After(metas[j].UpdatedAt)
-	})
-
-	return metas, nil
-}
-
-// Search finds conversations matching a query string.
-func (s *ConversationStore) Search(query string) ([]ConversationMeta, error) {
-	all, err := s.List()
-	if err != nil {
-		return nil, err
-	}
-
-	query = strings.ToLower(query)
-	var results []ConversationMeta
-
-	for _, meta := range all {
-		// Search in summary and preview
-		if strings.Contains(strings.ToLower(meta.Summary), query) ||
-			strings.Contains(strings.ToLower(meta.Preview), query) {
-			results = append(results, meta)
+			return preview
 		}
 	}
-
-	return results, nil
-}
-
-// =============================================================================
-// DELETE OPERATIONS
-// =============================================================================
-
-// Delete removes a conversation by ID.
-func (s *ConversationStore) Delete(id string) error {
-	filePath := s.filePath(id)
-
-	if err := os.Remove(filePath); err != nil {
-		if os.IsNotExist(err) {
-			return ErrConversationNotFound
-		}
-		return err
-	}
-
-	return nil
-}
-
-// Clear removes all saved conversations.
-func (s *ConversationStore) Clear() error {
-	entries, err := os.ReadDir(s.BaseDir)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil
-		}
-		return err
-	}
-
-	for _, entry := range entries {
-		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
-			filePath := filepath.Join(s.BaseDir, entry.Name())
-			os.Remove(filePath)
-		}
-	}
-
-	return nil
-}
-
-// =============================================================================
-// HELPER FUNCTIONS
-// =============================================================================
-
-// filePath returns the file path for a conversation ID.
-func (s *ConversationStore) filePath(id string) string {
-	return filepath.Join(s.BaseDir, id+".json")
+	return ""
 }
 
 // generateConversationID creates a unique conversation ID.
@@ -464,45 +258,6 @@ func formatPadded(s string, width int) string {
 	return s
 }
 
-
-// =============================================================================
-// SESSION SEARCH (MESSAGE CONTENT)
-// =============================================================================
-
-// SearchMessages searches conversations by message content.
-// Returns conversations where any message contains the query string (case-insensitive).
-func (s *ConversationStore) SearchMessages(query string) ([]ConversationMeta, error) {
-	if query == "" {
-		return s.List()
-	}
-
-	query = strings.ToLower(query)
-	all, err := s.List()
-	if err != nil {
-		return nil, err
-	}
-
-	var results []ConversationMeta
-
-	for _, meta := range all {
-		// Load full conversation to search message content
-		conv, err := s.Load(meta.ID)
-		if err != nil {
-			continue
-		}
-
-		// Search in all messages
-		for _, msg := range conv.Messages {
-			if strings.Contains(strings.ToLower(msg.Content), query) {
-				results = append(results, meta)
-				break // Found a match, move to next conversation
-			}
-		}
-	}
-
-	return results, nil
-}
-
 // =============================================================================
 // SESSION EXPORT
 // =============================================================================
@@ -553,3 +308,114 @@ func (c *StoredConversation) GetPreview() string {
 func (c *StoredConversation) MessageCount() int {
 	return len(c.Messages)
 }
+
+// HighWaterMark returns the highest classification among the stored
+// conversation marking and all message markings. Unparseable markings are
+// treated as TOP SECRET so a corrupted file can never be downgraded.
+func (c *StoredConversation) HighWaterMark() security.Classification {
+	marks := make([]security.Classification, 0, len(c.Messages)+len(c.Branches)+1)
+	marks = append(marks, security.ParseStoredMarking(c.Classification))
+	for _, msg := range c.Messages {
+		marks = append(marks, security.ParseStoredMarking(msg.Classification))
+	}
+	for _, msg := range c.Branches {
+		marks = append(marks, security.ParseStoredMarking(msg.Classification))
+	}
+	return security.HighWaterMark(marks...)
+}
+
+// IsMarked returns true if the conversation or any of its messages carries a
+// classification marking. Unmarked conversations predate per-message marking.
+func (c *StoredConversation) IsMarked() bool {
+	if c.Classification != "" {
+		return true
+	}
+	for _, msg := range c.Messages {
+		if msg.Classification != "" {
+			return true
+		}
+	}
+	for _, msg := range c.Branches {
+		if msg.Classification != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// =============================================================================
+// BRANCHES
+// =============================================================================
+
+// BranchThread is a linear run of messages on an inactive branch.
+type BranchThread struct {
+	// ParentID is the message the thread forks from (empty at the root).
+	ParentID string
+
+	// Messages are the thread's messages in order.
+	Messages []StoredMessage
+}
+
+// BranchThreads splits the inactive branches into linear threads for
+// display. Each thread starts where it forks from the active branch or from
+// another thread, and follows the first child at each step; other children
+// start threads of their own.
+func (c *StoredConversation) BranchThreads() []BranchThread {
+	if len(c.Branches) == 0 {
+		return nil
+	}
+	inBranches := make(map[string]bool, len(c.Branches))
+	children := make(map[string][]int)
+	for i, msg := range c.Branches {
+		inBranches[msg.ID] = true
+		children[msg.ParentID] = append(children[msg.ParentID], i)
+	}
+
+	var threads []BranchThread
+	for i, msg := range c.Branches {
+		// A thread starts at a message whose parent is not in a thread, or
+		// at a later child of a message in a thread
+		if inBranches[msg.ParentID] && children[msg.ParentID][0] == i {
+			continue
+		}
+		thread := BranchThread{ParentID: msg.ParentID}
+		for j := i; ; {
+			thread.Messages = append(thread.Messages, c.Branches[j])
+			next := children[c.Branches[j].ID]
+			if len(next) == 0 {
+				break
+			}
+			j = next[0]
+		}
+		threads = append(threads, thread)
+	}
+	return threads
+}
+
+// BranchOrigin describes where a branch thread forks from.
+func (c *StoredConversation) BranchOrigin(thread BranchThread) string {
+	if thread.ParentID == "" {
+		return "from the start of the conversation"
+	}
+	parent, ok := c.FindMessage(thread.ParentID)
+	if !ok {
+		return "from a pruned message"
+	}
+	preview := truncateString(strings.Join(strings.Fields(parent.Content), " "), 60)
+	return fmt.Sprintf("after %s message %q", parent.Role, preview)
+}
+
+// FindMessage returns a message from the active branch or an inactive one.
+func (c *StoredConversation) FindMessage(id string) (StoredMessage, bool) {
+	for _, msg := range c.Messages {
+		if msg.ID == id {
+			return msg, true
+		}
+	}
+	for _, msg := range c.Branches {
+		if msg.ID == id {
+			return msg, true
+		}
+	}
+	return StoredMessage{}, false
+}
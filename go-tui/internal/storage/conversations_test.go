@@ -26,8 +26,8 @@ func TestNewConversationStoreWithDir(t *testing.T) {
 	if store.BaseDir != tempDir {
 		t.Errorf("BaseDir = %q, want %q", store.BaseDir, tempDir)
 	}
-	if store.MaxConversations != 100 {
-		t.Errorf("MaxConversations = %d, want 100", store.MaxConversations)
+	if store.MaxConversations != 0 {
+		t.Errorf("MaxConversations = %d, want 0", store.MaxConversations)
 	}
 }
 
@@ -75,6 +75,67 @@ func TestConversationStore_SaveAndLoad(t *testing.T) {
 	}
 }
 
+func TestConversationStore_SaveClassificationHighWaterMark(t *testing.T) {
+	store, err := NewConversationStoreWithDir(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	// Unmarked conversations stay unmarked
+	plain := &StoredConversation{
+		Messages: []StoredMessage{{ID: "msg1", Role: "user", Content: "Hello", Timestamp: time.Now()}},
+	}
+	if _, err := store.Save(plain); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if plain.Classification != "" {
+		t.Errorf("Unmarked conversation Classification = %q, want empty", plain.Classification)
+	}
+
+	// Marked conversations are stored at the highest message marking
+	conv := &StoredConversation{
+		Messages: []StoredMessage{
+			{ID: "msg1", Role: "user", Content: "budget", Classification: "CUI", Timestamp: time.Now()},
+			{ID: "msg2", Role: "user", Content: "(S//NF) plan", Classification: "SECRET//NOFORN", Timestamp: time.Now()},
+			{ID: "msg3", Role: "assistant", Content: "ok", Classification: "UNCLASSIFIED", Timestamp: time.Now()},
+		},
+	}
+	id, err := store.Save(conv)
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := store.Load(id)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded.Classification != "SECRET//NOFORN" {
+		t.Errorf("Loaded Classification = %q, want %q", loaded.Classification, "SECRET//NOFORN")
+	}
+	if loaded.Messages[0].Classification != "CUI" {
+		t.Errorf("Message Classification = %q, want %q", loaded.Messages[0].Classification, "CUI")
+	}
+
+	metas, err := store.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	for _, meta := range metas {
+		if meta.ID == id && meta.Classification != "SECRET//NOFORN" {
+			t.Errorf("Meta Classification = %q, want %q", meta.Classification, "SECRET//NOFORN")
+		}
+	}
+}
+
+func TestStoredConversation_HighWaterMarkFailsClosed(t *testing.T) {
+	conv := &StoredConversation{
+		Messages: []StoredMessage{{ID: "msg1", Role: "user", Classification: "GARBLED"}},
+	}
+	if got := conv.HighWaterMark().Level.String(); got != "TOP SECRET" {
+		t.Errorf("HighWaterMark with corrupt marking = %q, want TOP SECRET", got)
+	}
+}
+
 func TestConversationStore_LoadNotFound(t *testing.T) {
 	store, err := NewConversationStoreWithDir(t.TempDir())
 	if err != nil {
@@ -525,3 +586,41 @@ func TestConversationStore_UnicodeContent(t *testing.T) {
 		t.Error("Unicode content should be preserved")
 	}
 }
+
+func TestStoredConversation_BranchThreads(t *testing.T) {
+	conv := &StoredConversation{
+		Messages: []StoredMessage{
+			{ID: "q1", Role: "user", Content: "first question"},
+			{ID: "a1", Role: "assistant", ParentID: "q1"},
+			{ID: "q2c", Role: "user", ParentID: "a1"},
+		},
+		Branches: []StoredMessage{
+			{ID: "q2a", Role: "user", ParentID: "a1"},
+			{ID: "a2a", Role: "assistant", ParentID: "q2a"},
+			{ID: "q3a", Role: "user", ParentID: "a2a"},
+			{ID: "q3b", Role: "user", ParentID: "a2a"},
+			{ID: "q2b", Role: "user", ParentID: "a1", Classification: "CUI"},
+		},
+	}
+
+	threads := conv.BranchThreads()
+	var got []string
+	for _, thread := range threads {
+		var ids []string
+		for _, msg := range thread.Messages {
+			ids = append(ids, msg.ID)
+		}
+		got = append(got, thread.ParentID+":"+strings.Join(ids, ","))
+	}
+	want := "a1:q2a,a2a,q3a|a2a:q3b|a1:q2b"
+	if strings.Join(got, "|") != want {
+		t.Errorf("threads = %s, want %s", strings.Join(got, "|"), want)
+	}
+
+	if origin := conv.BranchOrigin(threads[0]); origin != `after assistant message ""` {
+		t.Errorf("origin = %s", origin)
+	}
+	if !conv.IsMarked() || conv.HighWaterMark().String() != "CUI" {
+		t.Error("markings on inactive branches should count toward the high-water mark")
+	}
+}
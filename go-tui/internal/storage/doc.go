@@ -8,27 +8,37 @@
 //
 // # Key Types
 //
-//   - Store: Main storage interface for conversations
+//   - ConversationStore: The SQLite conversation store
 //   - StoredConversation: Serializable conversation with metadata
 //   - ConversationMeta: Lightweight metadata for listing
 //
 // # Usage
 //
-// Create a store and save a conversation:
+// Open the store and save a conversation:
 //
-//	store := storage.NewStore(dataDir)
-//	err := store.Save(conversation)
+//	store, err := storage.NewConversationStore()
+//	defer store.Close()
+//	id, err := store.Save(conversation)
 //
 // List and load conversations:
 //
 //	metas, err := store.List()
 //	conv, err := store.Load(metas[0].ID)
+//	page, total, err := store.ListPage(storage.ListOptions{Offset: 20, Limit: 20})
 //
 // Search conversations:
 //
 //	results, err := store.Search("query text")
+//	hits, err := store.SearchMessageSnippets("connection pool", 20)
+//
+// Tag and pin conversations:
+//
+//	err = store.AddTag(id, "release")
+//	err = store.SetPinned(id, true)
 //
 // # Storage Location
 //
-// Conversations are stored in ~/.rigrun/sessions/ as JSON files.
+// Conversations are stored in ~/.rigrun/conversations/conversations.db.
+// JSON files left there by earlier versions are imported when the store is
+// opened, then moved to ~/.rigrun/conversations/imported/.
 package storage
@@ -0,0 +1,255 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package storage provides conversation persistence for rigrun TUI.
+//
+// This file implements SC-28 protection of ConversationStore: the
+// security.ProtectedStore methods, and message search over encrypted
+// messages, which the on-disk FTS5 index cannot hold.
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jeranaias/rigrun-tui/internal/security"
+)
+
+// Compile-time check that ConversationStore is a protected store.
+var _ security.ProtectedStore = (*ConversationStore)(nil)
+
+// sealedColumns are the columns holding user content, by table.
+var sealedColumns = []struct {
+	table   string
+	key     []string
+	columns []string
+}{
+	{"conversations", []string{"id"}, []string{"summary", "preview", "mentions"}},
+	{"messages", []string{"conv_id", "seq"}, []string{"content"}},
+	{"tool_calls", []string{"conv_id", "seq"}, []string{"input", "result"}},
+}
+
+// open decrypts stored values in place.
+func (s *ConversationStore) open(values ...*string) error {
+	for _, v := range values {
+		opened, err := security.OpenField(s.cipher, *v)
+		if err != nil {
+			return err
+		}
+		*v = opened
+	}
+	return nil
+}
+
+// EncryptionStatus counts the stored values holding user content. JSON
+// files kept in ImportedDir count as plaintext values.
+func (s *ConversationStore) EncryptionStatus() (security.StoreStatus, error) {
+	status := security.StoreStatus{
+		Name: "Conversations",
+		Path: filepath.Join(s.BaseDir, ConversationDBFile),
+	}
+	for _, t := range sealedColumns {
+		for _, column := range t.columns {
+			var items, encrypted int
+			if err := s.db.QueryRow(fmt.Sprintf(
+				"SELECT COUNT(*), COALESCE(SUM(substr(%[1]s, 1, 4) = 'ENC:'), 0) FROM %[2]s WHERE %[1]s != ''",
+				column, t.table)).Scan(&items, &encrypted); err != nil {
+				return status, err
+			}
+			status.Items += items
+			status.Encrypted += encrypted
+		}
+	}
+	backups, err := s.importedBackups()
+	if err != nil {
+		return status, err
+	}
+	status.Items += len(backups)
+	return status, nil
+}
+
+// Reencrypt passes the stored user content through recrypt in one
+// transaction. Messages left encrypted are removed from the FTS5 index, and
+// the database is compacted, so that no earlier form of a changed value
+// remains on disk; neither do the JSON files in ImportedDir, which are
+// securely deleted once the store holds encrypted messages.
+func (s *ConversationStore) Reencrypt(recrypt security.Recrypt) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	changed := 0
+	for _, t := range sealedColumns {
+		n, err := security.ReencryptColumns(tx, t.table, t.key, t.columns, recrypt)
+		if err != nil {
+			return fmt.Errorf("failed to re-encrypt %s: %w", t.table, err)
+		}
+		changed += n
+	}
+	res, err := tx.Exec(`DELETE FROM messages_fts WHERE conv_id IN
+		(SELECT conv_id FROM messages WHERE substr(content, 1, 4) = 'ENC:')`)
+	if err != nil {
+		return err
+	}
+	unindexed, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if unindexed > 0 {
+		// FTS5 only marks removed terms deleted; merging the index drops them
+		if _, err := tx.Exec("INSERT INTO messages_fts(messages_fts) VALUES('optimize')"); err != nil {
+			return err
+		}
+	}
+	var encrypted int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM messages WHERE substr(content, 1, 4) = 'ENC:'").Scan(&encrypted); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if changed > 0 || unindexed > 0 {
+		// Rewrite the database and empty the WAL, which may still hold
+		// pages of the replaced values
+		for _, stmt := range []string{"VACUUM", "PRAGMA wal_checkpoint(TRUNCATE)"} {
+			if _, err := s.db.Exec(stmt); err != nil {
+				return err
+			}
+		}
+	}
+	if encrypted > 0 {
+		backups, err := s.importedBackups()
+		if err != nil {
+			return err
+		}
+		for _, path := range backups {
+			if err := security.GlobalSanitizer().SecureDeleteFile(path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// importedBackups returns the JSON files ImportJSON moved to ImportedDir.
+func (s *ConversationStore) importedBackups() ([]string, error) {
+	dir := filepath.Join(s.BaseDir, ImportedDir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var paths []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			paths = append(paths, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return paths, nil
+}
+
+// =============================================================================
+// ENCRYPTED SEARCH
+// =============================================================================
+
+// searchSealed is SearchMessageSnippets for a store whose messages are
+// encrypted. The active messages are decrypted into an FTS5 index in
+// memory, which is searched as the on-disk index would be, then discarded.
+func (s *ConversationStore) searchSealed(match string, limit int) ([]MessageHit, error) {
+	rows, err := s.db.Query(`
+		SELECT m.conv_id, c.summary, c.classification, c.updated_at, m.id, m.seq, m.role, m.content,
+		       COALESCE(t.result, '')
+		FROM messages m
+		JOIN conversations c ON c.id = m.conv_id
+		LEFT JOIN tool_calls t ON t.conv_id = m.conv_id AND t.seq = m.seq
+		WHERE m.active = 1`)
+	if err != nil {
+		return nil, err
+	}
+	type message struct {
+		hit  MessageHit
+		text string
+	}
+	var messages []message
+	for rows.Next() {
+		var m message
+		var msg StoredMessage
+		var updated int64
+		if err := rows.Scan(&m.hit.ConversationID, &m.hit.Summary, &m.hit.Classification, &updated,
+			&m.hit.MessageID, &m.hit.MessageIndex, &m.hit.Role, &msg.Content, &msg.ToolResult); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		m.hit.UpdatedAt = fromUnixNanos(updated)
+		if err := s.open(&m.hit.Summary, &msg.Content, &msg.ToolResult); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		msg.Role = m.hit.Role
+		m.text = searchableText(msg)
+		messages = append(messages, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	mem, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		return nil, err
+	}
+	defer mem.Close()
+	mem.SetMaxOpenConns(1) // One connection, one in-memory database
+
+	if _, err := mem.Exec(`PRAGMA temp_store=MEMORY;
+		CREATE VIRTUAL TABLE messages_fts USING fts5(
+		    content, updated_at UNINDEXED, tokenize='unicode61 remove_diacritics 2')`); err != nil {
+		return nil, err
+	}
+	insert, err := mem.Prepare("INSERT INTO messages_fts (rowid, content, updated_at) VALUES (?, ?, ?)")
+	if err != nil {
+		return nil, err
+	}
+	defer insert.Close()
+	for i, m := range messages {
+		if strings.TrimSpace(m.text) == "" {
+			continue
+		}
+		if _, err := insert.Exec(i, m.text, unixNanos(m.hit.UpdatedAt)); err != nil {
+			return nil, err
+		}
+	}
+
+	matches, err := mem.Query(`
+		SELECT rowid, snippet(messages_fts, 0, ?, ?, '...', 16)
+		FROM messages_fts
+		WHERE messages_fts MATCH ?
+		ORDER BY bm25(messages_fts), updated_at DESC
+		LIMIT ?`,
+		SnippetMatchStart, SnippetMatchEnd, match, limit)
+	if err != nil {
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
+	defer matches.Close()
+
+	var hits []MessageHit
+	for matches.Next() {
+		var i int
+		var snippet string
+		if err := matches.Scan(&i, &snippet); err != nil {
+			return nil, err
+		}
+		hit := messages[i].hit
+		hit.Snippet = strings.Join(strings.Fields(snippet), " ")
+		hits = append(hits, hit)
+	}
+	return hits, matches.Err()
+}
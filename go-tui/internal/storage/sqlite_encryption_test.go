@@ -0,0 +1,149 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package storage provides conversation persistence for rigrun TUI.
+package storage
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jeranaias/rigrun-tui/internal/security"
+	"github.com/jeranaias/rigrun-tui/internal/security/securitytest"
+)
+
+// =============================================================================
+// ENCRYPTION TESTS
+// =============================================================================
+
+// useDataCipher sets the data cipher for the rest of the test.
+func useDataCipher(t *testing.T, c security.FieldCipher) {
+	t.Helper()
+	security.SetDataCipher(c)
+	t.Cleanup(func() { security.SetDataCipher(nil) })
+}
+
+// secretPrompt is the text that must not reach the disk in plaintext.
+const secretPrompt = "Rotate the quartermaster passphrase before Tuesday"
+
+func secretConversation() *StoredConversation {
+	return &StoredConversation{
+		Mentions: []string{"@file:quartermaster.txt"},
+		Messages: []StoredMessage{
+			{ID: "m1", Role: "user", Content: secretPrompt},
+			{ID: "m2", Role: "tool", ToolName: "Read", ToolInput: "quartermaster.txt", ToolResult: "quartermaster passphrase file"},
+			{ID: "m3", Role: "assistant", Content: "The quartermaster passphrase is rotated."},
+		},
+	}
+}
+
+// assertNoPlaintext fails if any file under dir contains text.
+func assertNoPlaintext(t *testing.T, dir, text string) {
+	t.Helper()
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if bytes.Contains(data, []byte(text)) {
+			t.Errorf("Found %q in plaintext in %s", text, path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConversationStore_EncryptedAtRest(t *testing.T) {
+	useDataCipher(t, securitytest.NewEncryptionManager(t))
+	dir := t.TempDir()
+
+	store, err := NewConversationStoreWithDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	id, err := store.Save(secretConversation())
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := store.Load(id)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded.Messages[0].Content != secretPrompt || loaded.Messages[1].ToolResult != "quartermaster passphrase file" {
+		t.Errorf("Expected the messages decrypted, got %+v", loaded.Messages)
+	}
+	if len(loaded.Mentions) != 1 || !strings.Contains(loaded.Summary, "quartermaster") {
+		t.Errorf("Expected the summary and mentions decrypted, got %q %v", loaded.Summary, loaded.Mentions)
+	}
+
+	if metas, _ := store.SearchMessages("QUARTERMASTER"); len(metas) != 1 {
+		t.Errorf("Expected a message search match, got %d", len(metas))
+	}
+	hits, err := store.SearchMessageSnippets("quartermaster pass", 10)
+	if err != nil {
+		t.Fatalf("SearchMessageSnippets failed: %v", err)
+	}
+	if len(hits) != 3 || !strings.Contains(hits[0].Snippet, SnippetMatchStart+"quartermaster"+SnippetMatchEnd) {
+		t.Errorf("Expected the 3 messages with marked matches, got %+v", hits)
+	}
+
+	status, err := store.EncryptionStatus()
+	if err != nil {
+		t.Fatalf("EncryptionStatus failed: %v", err)
+	}
+	if status.Items == 0 || !status.FullyEncrypted() {
+		t.Errorf("Expected every value encrypted, got %+v", status)
+	}
+
+	store.Close()
+	assertNoPlaintext(t, dir, "quartermaster")
+}
+
+func TestConversationStore_EncryptExisting(t *testing.T) {
+	dir := t.TempDir()
+
+	// A JSON conversation of an earlier version, and one saved in plaintext
+	data, _ := secretConversation().ExportJSON()
+	if err := os.WriteFile(filepath.Join(dir, "legacy.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	store := newSQLiteTestStore(t, dir)
+	if _, err := store.Save(secretConversation()); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if status, _ := store.EncryptionStatus(); status.Encrypted != 0 {
+		t.Fatalf("Expected no encrypted values yet, got %+v", status)
+	}
+
+	c := securitytest.NewEncryptionManager(t)
+	err := store.Reencrypt(func(value string) (string, error) {
+		return security.SealField(c, value)
+	})
+	if err != nil {
+		t.Fatalf("Reencrypt failed: %v", err)
+	}
+	if status, _ := store.EncryptionStatus(); !status.FullyEncrypted() {
+		t.Errorf("Expected every value encrypted, got %+v", status)
+	}
+	assertNoPlaintext(t, dir, "quartermaster")
+
+	// Opened with the cipher, the store reads and searches the values
+	useDataCipher(t, c)
+	reopened := newSQLiteTestStore(t, dir)
+	conv, err := reopened.Load("legacy")
+	if err != nil || conv.Messages[0].Content != secretPrompt {
+		t.Fatalf("Expected the imported conversation decrypted, got %v %v", conv, err)
+	}
+	if hits, _ := reopened.SearchMessageSnippets("passphrase", 10); len(hits) != 6 {
+		t.Errorf("Expected 6 matching messages, got %d", len(hits))
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package storage provides conversation persistence for rigrun TUI.
+//
+// This file implements security.RetainedStore for ConversationStore, so
+// that saved conversations, and the JSON files they were imported from, are
+// kept under the conversations retention policy (SI-12).
+package storage
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/jeranaias/rigrun-tui/internal/security"
+)
+
+// Compile-time check that ConversationStore is a retained store.
+var _ security.RetainedStore = (*ConversationStore)(nil)
+
+// RetentionClass returns the data class of saved conversations.
+func (s *ConversationStore) RetentionClass() string {
+	return security.RetentionConversations
+}
+
+// RetentionItems lists the saved conversations, sized by their message
+// and tool call content. Pinned conversations are kept, unless classified.
+func (s *ConversationStore) RetentionItems() ([]security.RetentionItem, error) {
+	rows, err := s.db.Query(`
+		SELECT c.id, c.updated_at, c.classification, c.pinned,
+		       COALESCE((SELECT SUM(length(CAST(content AS BLOB))) FROM messages m WHERE m.conv_id = c.id), 0) +
+		       COALESCE((SELECT SUM(length(CAST(input AS BLOB)) + length(CAST(result AS BLOB)))
+		                 FROM tool_calls t WHERE t.conv_id = c.id), 0)
+		FROM conversations c`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []security.RetentionItem
+	for rows.Next() {
+		var item security.RetentionItem
+		var updated int64
+		var pinned bool
+		if err := rows.Scan(&item.ID, &updated, &item.Classification, &pinned, &item.Size); err != nil {
+			return nil, err
+		}
+		if pinned && !security.IsClassifiedMarking(item.Classification) {
+			continue
+		}
+		item.Time = fromUnixNanos(updated)
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// Purge deletes conversations in one transaction. The database deletes
+// securely (see NewConversationStoreWithDir); the FTS5 index is merged so
+// that it drops the purged terms, and the WAL is emptied of their pages.
+func (s *ConversationStore) Purge(items []security.RetentionItem) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, item := range items {
+		if _, err := deleteConversation(tx, item.ID); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec("INSERT INTO messages_fts(messages_fts) VALUES('optimize')"); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec("PRAGMA wal_checkpoint(TRUNCATE)")
+	return err
+}
+
+// ImportedBackups returns the JSON files ImportJSON moved to ImportedDir as
+// a store kept under the conversations retention policy. Only a store
+// without a cipher keeps them; they hold their conversations in plaintext.
+func (s *ConversationStore) ImportedBackups() security.RetainedStore {
+	return importedBackups{dir: filepath.Join(s.BaseDir, ImportedDir)}
+}
+
+// importedBackups is the retained store returned by ImportedBackups.
+type importedBackups struct {
+	dir string
+}
+
+// RetentionClass returns the data class of imported conversation files.
+func (b importedBackups) RetentionClass() string {
+	return security.RetentionConversations
+}
+
+// RetentionItems lists the imported conversation files.
+func (b importedBackups) RetentionItems() ([]security.RetentionItem, error) {
+	return security.RetentionFiles(b.dir, func(name string) bool {
+		return strings.HasSuffix(name, ".json")
+	})
+}
+
+// Purge securely deletes imported conversation files.
+func (b importedBackups) Purge(items []security.RetentionItem) error {
+	return security.PurgeFiles(b.dir, items)
+}
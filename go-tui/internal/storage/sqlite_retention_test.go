@@ -0,0 +1,64 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package storage provides conversation persistence for rigrun TUI.
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeranaias/rigrun-tui/internal/security"
+)
+
+// =============================================================================
+// RETENTION TESTS
+// =============================================================================
+
+func TestConversationStore_Purge(t *testing.T) {
+	dir := t.TempDir()
+	store := newSQLiteTestStore(t, dir)
+
+	expired, err := store.Save(secretConversation())
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	pinned, _ := store.Save(&StoredConversation{Messages: []StoredMessage{{ID: "m1", Role: "user", Content: "Keep this"}}})
+	classified, _ := store.Save(&StoredConversation{Messages: []StoredMessage{
+		{ID: "m1", Role: "user", Content: "Marked message", Classification: "SECRET"},
+	}})
+	for _, id := range []string{pinned, classified} {
+		if err := store.SetPinned(id, true); err != nil {
+			t.Fatalf("SetPinned failed: %v", err)
+		}
+	}
+
+	engine := &security.RetentionEngine{
+		Policies: map[string]security.RetentionPolicy{security.RetentionConversations: {MaxAge: time.Hour}},
+		Now:      func() time.Time { return time.Now().Add(2 * time.Hour) },
+	}
+	report, err := engine.Run([]security.RetainedStore{store}, false)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if report.Purged() != 2 {
+		t.Errorf("Expected the unpinned and the classified conversation purged, got %+v", report.Results)
+	}
+
+	metas, err := store.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(metas) != 1 || metas[0].ID != pinned {
+		t.Errorf("Expected only the pinned conversation kept, got %+v", metas)
+	}
+	if _, err := store.Load(expired); err == nil {
+		t.Error("Expected the purged conversation gone")
+	}
+	if hits, _ := store.SearchMessageSnippets("quartermaster", 10); len(hits) != 0 {
+		t.Errorf("Expected no search hits in purged messages, got %d", len(hits))
+	}
+
+	store.Close()
+	assertNoPlaintext(t, dir, "quartermaster")
+}
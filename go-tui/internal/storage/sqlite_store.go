@@ -0,0 +1,1106 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package storage provides conversation persistence for rigrun TUI.
+//
+// This file implements ConversationStore, which keeps conversations in a
+// SQLite database: conversations, their messages, tool calls and metrics,
+// tags, and an FTS5 index of message content. Every Save is one
+// transaction, so a crash never leaves a conversation half written, and
+// listing and searching no longer read every conversation.
+//
+// When the data cipher is set (see security.SetDataCipher), summaries,
+// previews, mentions, message content and tool input and output are stored
+// encrypted, and messages are left out of the FTS5 index.
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	_ "modernc.org/sqlite" // Pure Go SQLite driver
+
+	"github.com/jeranaias/rigrun-tui/internal/security"
+)
+
+// =============================================================================
+// CONSTANTS
+// =============================================================================
+
+const (
+	// ConversationDBFile is the name of the conversation database inside
+	// BaseDir.
+	ConversationDBFile = "conversations.db"
+
+	// ImportedDir is where ImportJSON moves the files it imported, inside
+	// the directory they were imported from.
+	ImportedDir = "imported"
+
+	// SearchIndexFile is the full-text index earlier versions kept beside
+	// the JSON files. ImportJSON removes it with the files it covered.
+	SearchIndexFile = "search.db"
+
+	// conversationStoreVersion is the schema version in PRAGMA user_version.
+	conversationStoreVersion = 1
+)
+
+// Snippet markers delimit the matched terms in MessageHit.Snippet.
+const (
+	SnippetMatchStart = "\x02"
+	SnippetMatchEnd   = "\x03"
+)
+
+const conversationSchema = `
+CREATE TABLE IF NOT EXISTS conversations (
+    id TEXT PRIMARY KEY,
+    summary TEXT NOT NULL,
+    model TEXT NOT NULL DEFAULT '',
+    classification TEXT NOT NULL DEFAULT '',  -- High-water mark
+    tokens_used INTEGER NOT NULL DEFAULT 0,
+    mentions TEXT NOT NULL DEFAULT '',        -- JSON array
+    preview TEXT NOT NULL DEFAULT '',         -- First user message, truncated
+    message_count INTEGER NOT NULL DEFAULT 0, -- Messages on the active branch
+    pinned INTEGER NOT NULL DEFAULT 0,
+    created_at INTEGER NOT NULL,              -- Unix nanoseconds
+    updated_at INTEGER NOT NULL
+) WITHOUT ROWID;
+
+CREATE INDEX IF NOT EXISTS conversations_updated ON conversations(updated_at);
+
+-- Messages of the active branch come first (seq 0..message_count-1),
+-- followed by those of inactive branches
+CREATE TABLE IF NOT EXISTS messages (
+    conv_id TEXT NOT NULL,
+    seq INTEGER NOT NULL,
+    active INTEGER NOT NULL,
+    id TEXT NOT NULL,
+    parent_id TEXT NOT NULL DEFAULT '',
+    role TEXT NOT NULL,
+    content TEXT NOT NULL,
+    classification TEXT NOT NULL DEFAULT '',
+    timestamp INTEGER NOT NULL,               -- Unix nanoseconds
+    PRIMARY KEY (conv_id, seq)
+) WITHOUT ROWID;
+
+CREATE TABLE IF NOT EXISTS tool_calls (
+    conv_id TEXT NOT NULL,
+    seq INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    input TEXT NOT NULL,
+    result TEXT NOT NULL,
+    success INTEGER NOT NULL,
+    PRIMARY KEY (conv_id, seq)
+) WITHOUT ROWID;
+
+CREATE TABLE IF NOT EXISTS metrics (
+    conv_id TEXT NOT NULL,
+    seq INTEGER NOT NULL,
+    token_count INTEGER NOT NULL,
+    duration_ms INTEGER NOT NULL,
+    tokens_per_sec REAL NOT NULL,
+    ttft_ms INTEGER NOT NULL,
+    PRIMARY KEY (conv_id, seq)
+) WITHOUT ROWID;
+
+CREATE TABLE IF NOT EXISTS tags (
+    conv_id TEXT NOT NULL,
+    tag TEXT NOT NULL,
+    PRIMARY KEY (conv_id, tag)
+) WITHOUT ROWID;
+
+CREATE INDEX IF NOT EXISTS tags_tag ON tags(tag);
+
+CREATE VIRTUAL TABLE IF NOT EXISTS messages_fts USING fts5(
+    content,
+    conv_id UNINDEXED,
+    msg_id UNINDEXED,
+    msg_index UNINDEXED,
+    role UNINDEXED,
+    tokenize='unicode61 remove_diacritics 2'
+);
+`
+
+// conversationColumns are the conversation columns read by scanMetas, in order.
+const conversationColumns = `id, summary, model, classification, preview, message_count, pinned,
+    created_at, updated_at`
+
+// =============================================================================
+// TYPES
+// =============================================================================
+
+// ListOptions selects a page of conversations for ListPage.
+type ListOptions struct {
+	// Offset skips that many of the most recent conversations
+	Offset int
+
+	// Limit caps the conversations returned (0 = no limit)
+	Limit int
+
+	// Tag, if set, selects conversations with this tag
+	Tag string
+
+	// PinnedOnly selects pinned conversations
+	PinnedOnly bool
+}
+
+// TagCount is a tag and the number of conversations that have it.
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// =============================================================================
+// CONVERSATION STORE
+// =============================================================================
+
+// ConversationStore persists conversations in SQLite. It is safe for
+// concurrent use, and several processes (the TUI, the CLI and the daemon)
+// may use the same database.
+type ConversationStore struct {
+	// BaseDir is the directory of the database
+	// Default: ~/.rigrun/conversations/
+	BaseDir string
+
+	// MaxConversations limits stored conversations (0 = unlimited, the
+	// default). The least recently updated are removed first; pinned
+	// conversations are never removed. Left at 0, conversations are only
+	// removed by the retention policy, which audits what it purges.
+	MaxConversations int
+
+	db     *sql.DB
+	cipher security.FieldCipher // Seals user content; nil stores it in plaintext
+}
+
+// NewConversationStore opens the conversation store in
+// ~/.rigrun/conversations, importing any JSON conversation files there.
+func NewConversationStore() (*ConversationStore, error) {
+	// Get user home directory
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewConversationStoreWithDir(filepath.Join(homeDir, ".rigrun", "conversations"))
+}
+
+// NewConversationStoreWithDir opens (or creates) the store in baseDir,
+// importing any JSON conversation files there (see ImportJSON). What it
+// writes is encrypted with the data cipher, if one is set.
+func NewConversationStoreWithDir(baseDir string) (*ConversationStore, error) {
+	if err := os.MkdirAll(baseDir, 0755); err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite", filepath.Join(baseDir, ConversationDBFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open conversation store: %w", err)
+	}
+
+	// SQLite only supports one writer at a time
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+
+	// SC-28: secure_delete overwrites deleted content, so that removed and
+	// re-encrypted conversations leave no plaintext in free pages
+	for _, pragma := range []string{"PRAGMA journal_mode=WAL", "PRAGMA synchronous=NORMAL", "PRAGMA busy_timeout=5000",
+		"PRAGMA secure_delete=ON"} {
+		if _, err := db.Exec(pragma); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to set pragma: %w", err)
+		}
+	}
+
+	if err := initConversationSchema(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize conversation store: %w", err)
+	}
+
+	s := &ConversationStore{
+		BaseDir: baseDir,
+		db:      db,
+		cipher:  security.DataCipher(),
+	}
+
+	// One-time import of the JSON files of earlier versions; a failure leaves
+	// them in place to be imported by the next open
+	if _, err := s.ImportJSON(baseDir); err != nil {
+		log.Printf("WARNING: Could not import saved conversations from %s: %v", baseDir, err)
+	}
+
+	return s, nil
+}
+
+// initConversationSchema creates the tables.
+func initConversationSchema(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	if version > conversationStoreVersion {
+		return fmt.Errorf("database version %d is newer than this rigrun supports (%d)", version, conversationStoreVersion)
+	}
+	if _, err := db.Exec(conversationSchema); err != nil {
+		return err
+	}
+	_, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d", conversationStoreVersion))
+	return err
+}
+
+// Close closes the database.
+func (s *ConversationStore) Close() error {
+	return s.db.Close()
+}
+
+// =============================================================================
+// SAVE OPERATIONS
+// =============================================================================
+
+// Save persists a conversation and returns its ID. The conversation and
+// all its messages are replaced in one transaction; its tags and pin are
+// kept.
+func (s *ConversationStore) Save(conv *StoredConversation) (string, error) {
+	// Generate ID if not set
+	if conv.ID == "" {
+		conv.ID = generateConversationID()
+	}
+
+	// Auto-generate summary if not set
+	if conv.Summary == "" {
+		conv.Summary = generateSummary(conv)
+	}
+
+	// Recompute the high-water mark so the stored marking can never lag
+	// behind the messages it covers
+	if conv.IsMarked() {
+		conv.Classification = conv.HighWaterMark().String()
+	}
+
+	// Update timestamp
+	conv.UpdatedAt = time.Now()
+	if conv.CreatedAt.IsZero() {
+		conv.CreatedAt = conv.UpdatedAt
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	if err := s.writeConversation(tx, conv); err != nil {
+		return "", fmt.Errorf("failed to save conversation: %w", err)
+	}
+
+	// Enforce max conversations limit
+	if s.MaxConversations > 0 {
+		if err := pruneConversations(tx, s.MaxConversations); err != nil {
+			return "", fmt.Errorf("failed to prune conversations: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to save conversation: %w", err)
+	}
+	return conv.ID, nil
+}
+
+// writeConversation replaces a conversation and its messages.
+func (s *ConversationStore) writeConversation(tx *sql.Tx, conv *StoredConversation) error {
+	mentions := ""
+	if len(conv.Mentions) > 0 {
+		data, err := json.Marshal(conv.Mentions)
+		if err != nil {
+			return err
+		}
+		mentions = string(data)
+	}
+
+	var summary, preview string
+	var err error
+	for _, f := range []struct {
+		dst   *string
+		value string
+	}{{&summary, conv.Summary}, {&preview, listPreview(conv)}, {&mentions, mentions}} {
+		if *f.dst, err = security.SealField(s.cipher, f.value); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO conversations (id, summary, model, classification, tokens_used, mentions, preview,
+		                           message_count, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+		    summary = excluded.summary, model = excluded.model, classification = excluded.classification,
+		    tokens_used = excluded.tokens_used, mentions = excluded.mentions, preview = excluded.preview,
+		    message_count = excluded.message_count, created_at = excluded.created_at,
+		    updated_at = excluded.updated_at`,
+		conv.ID, summary, conv.Model, conv.Classification, conv.TokensUsed, mentions, preview,
+		len(conv.Messages), unixNanos(conv.CreatedAt), unixNanos(conv.UpdatedAt),
+	); err != nil {
+		return err
+	}
+
+	if err := deleteMessages(tx, conv.ID); err != nil {
+		return err
+	}
+
+	w, err := newMessageWriter(tx, s.cipher)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	for i, msg := range conv.Messages {
+		if err := w.write(conv.ID, i, true, msg); err != nil {
+			return err
+		}
+	}
+	for i, msg := range conv.Branches {
+		if err := w.write(conv.ID, len(conv.Messages)+i, false, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// messageWriter inserts the rows of messages.
+type messageWriter struct {
+	message, toolCall, metrics, fts *sql.Stmt
+
+	cipher security.FieldCipher
+}
+
+func newMessageWriter(tx *sql.Tx, cipher security.FieldCipher) (*messageWriter, error) {
+	w := &messageWriter{cipher: cipher}
+	for _, p := range []struct {
+		stmt  **sql.Stmt
+		query string
+	}{
+		{&w.message, "INSERT INTO messages (conv_id, seq, active, id, parent_id, role, content, classification, timestamp) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"},
+		{&w.toolCall, "INSERT INTO tool_calls (conv_id, seq, name, input, result, success) VALUES (?, ?, ?, ?, ?, ?)"},
+		{&w.metrics, "INSERT INTO metrics (conv_id, seq, token_count, duration_ms, tokens_per_sec, ttft_ms) VALUES (?, ?, ?, ?, ?, ?)"},
+		{&w.fts, "INSERT INTO messages_fts (content, conv_id, msg_id, msg_index, role) VALUES (?, ?, ?, ?, ?)"},
+	} {
+		stmt, err := tx.Prepare(p.query)
+		if err != nil {
+			w.Close()
+			return nil, err
+		}
+		*p.stmt = stmt
+	}
+	return w, nil
+}
+
+// write inserts a message at position seq. Only the active branch is
+// indexed, so every search hit can be shown once the conversation is loaded;
+// nothing is indexed when messages are encrypted.
+func (w *messageWriter) write(convID string, seq int, active bool, msg StoredMessage) error {
+	content, err := security.SealField(w.cipher, msg.Content)
+	if err != nil {
+		return err
+	}
+	if _, err := w.message.Exec(convID, seq, active, msg.ID, msg.ParentID, msg.Role, content,
+		msg.Classification, unixNanos(msg.Timestamp)); err != nil {
+		return err
+	}
+	if msg.ToolName != "" || msg.ToolInput != "" || msg.ToolResult != "" || msg.IsSuccess {
+		input, err := security.SealField(w.cipher, msg.ToolInput)
+		if err != nil {
+			return err
+		}
+		result, err := security.SealField(w.cipher, msg.ToolResult)
+		if err != nil {
+			return err
+		}
+		if _, err := w.toolCall.Exec(convID, seq, msg.ToolName, input, result, msg.IsSuccess); err != nil {
+			return err
+		}
+	}
+	if msg.TokenCount != 0 || msg.DurationMs != 0 || msg.TokensPerSec != 0 || msg.TTFTMs != 0 {
+		if _, err := w.metrics.Exec(convID, seq, msg.TokenCount, msg.DurationMs, msg.TokensPerSec, msg.TTFTMs); err != nil {
+			return err
+		}
+	}
+	if text := searchableText(msg); active && w.cipher == nil && strings.TrimSpace(text) != "" {
+		if _, err := w.fts.Exec(text, convID, msg.ID, seq, msg.Role); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the prepared statements.
+func (w *messageWriter) Close() {
+	for _, stmt := range []*sql.Stmt{w.message, w.toolCall, w.metrics, w.fts} {
+		if stmt != nil {
+			stmt.Close()
+		}
+	}
+}
+
+// pruneConversations removes the least recently updated unpinned
+// conversations beyond limit.
+func pruneConversations(tx *sql.Tx, limit int) error {
+	rows, err := tx.Query(`
+		SELECT id FROM conversations WHERE pinned = 0
+		ORDER BY updated_at DESC, id
+		LIMIT -1 OFFSET max(0, ? - (SELECT COUNT(*) FROM conversations WHERE pinned = 1))`, limit)
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if _, err := deleteConversation(tx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// =============================================================================
+// LOAD OPERATIONS
+// =============================================================================
+
+// Load retrieves a conversation by ID.
+func (s *ConversationStore) Load(id string) (*StoredConversation, error) {
+	conv := &StoredConversation{Messages: []StoredMessage{}}
+	var mentions string
+	var created, updated int64
+	err := s.db.QueryRow(`
+		SELECT id, summary, model, classification, tokens_used, mentions, created_at, updated_at
+		FROM conversations WHERE id = ?`, id,
+	).Scan(&conv.ID, &conv.Summary, &conv.Model, &conv.Classification, &conv.TokensUsed, &mentions, &created, &updated)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrConversationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	conv.CreatedAt = fromUnixNanos(created)
+	conv.UpdatedAt = fromUnixNanos(updated)
+	if err := s.open(&conv.Summary, &mentions); err != nil {
+		return nil, fmt.Errorf("failed to decrypt conversation %s: %w", id, err)
+	}
+	if mentions != "" {
+		if err := json.Unmarshal([]byte(mentions), &conv.Mentions); err != nil {
+			return nil, fmt.Errorf("invalid mentions of conversation %s: %w", id, err)
+		}
+	}
+
+	rows, err := s.db.Query(`
+		SELECT m.active, m.id, m.parent_id, m.role, m.content, m.classification, m.timestamp,
+		       COALESCE(t.name, ''), COALESCE(t.input, ''), COALESCE(t.result, ''), COALESCE(t.success, 0),
+		       COALESCE(x.token_count, 0), COALESCE(x.duration_ms, 0), COALESCE(x.tokens_per_sec, 0),
+		       COALESCE(x.ttft_ms, 0)
+		FROM messages m
+		LEFT JOIN tool_calls t ON t.conv_id = m.conv_id AND t.seq = m.seq
+		LEFT JOIN metrics x ON x.conv_id = m.conv_id AND x.seq = m.seq
+		WHERE m.conv_id = ?
+		ORDER BY m.seq`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var msg StoredMessage
+		var active bool
+		var timestamp int64
+		if err := rows.Scan(&active, &msg.ID, &msg.ParentID, &msg.Role, &msg.Content, &msg.Classification, &timestamp,
+			&msg.ToolName, &msg.ToolInput, &msg.ToolResult, &msg.IsSuccess,
+			&msg.TokenCount, &msg.DurationMs, &msg.TokensPerSec, &msg.TTFTMs); err != nil {
+			return nil, err
+		}
+		msg.Timestamp = fromUnixNanos(timestamp)
+		if err := s.open(&msg.Content, &msg.ToolInput, &msg.ToolResult); err != nil {
+			return nil, fmt.Errorf("failed to decrypt conversation %s: %w", id, err)
+		}
+		if active {
+			conv.Messages = append(conv.Messages, msg)
+		} else {
+			conv.Branches = append(conv.Branches, msg)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return conv, nil
+}
+
+// LoadByIndex loads a conversation by its index in the list (0 = most recent).
+func (s *ConversationStore) LoadByIndex(index int) (*StoredConversation, error) {
+	if index < 0 {
+		return nil, ErrConversationNotFound
+	}
+	var id string
+	err := s.db.QueryRow("SELECT id FROM conversations ORDER BY updated_at DESC, id LIMIT 1 OFFSET ?", index).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrConversationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.Load(id)
+}
+
+// =============================================================================
+// LIST OPERATIONS
+// =============================================================================
+
+// List returns all saved conversations (most recent first).
+func (s *ConversationStore) List() ([]ConversationMeta, error) {
+	metas, _, err := s.ListPage(ListOptions{})
+	return metas, err
+}
+
+// ListPage returns a page of the conversations selected by opts (most
+// recent first), and how many are selected in all.
+func (s *ConversationStore) ListPage(opts ListOptions) ([]ConversationMeta, int, error) {
+	var where []string
+	var args []any
+	if opts.Tag != "" {
+		where = append(where, "id IN (SELECT conv_id FROM tags WHERE tag = ?)")
+		args = append(args, normalizeTag(opts.Tag))
+	}
+	if opts.PinnedOnly {
+		where = append(where, "pinned = 1")
+	}
+	filter := ""
+	if len(where) > 0 {
+		filter = " WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM conversations"+filter, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = -1 // No limit
+	}
+	rows, err := s.db.Query("SELECT "+conversationColumns+" FROM conversations"+filter+
+		" ORDER BY updated_at DESC, id LIMIT ? OFFSET ?", append(args, limit, max(opts.Offset, 0))...)
+	if err != nil {
+		return nil, 0, err
+	}
+	metas, err := s.scanMetas(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := s.attachTags(metas); err != nil {
+		return nil, 0, err
+	}
+	return metas, total, nil
+}
+
+// scanMetas reads conversation rows selected with conversationColumns.
+func (s *ConversationStore) scanMetas(rows *sql.Rows) ([]ConversationMeta, error) {
+	defer rows.Close()
+	metas := []ConversationMeta{}
+	for rows.Next() {
+		var meta ConversationMeta
+		var created, updated int64
+		if err := rows.Scan(&meta.ID, &meta.Summary, &meta.Model, &meta.Classification, &meta.Preview,
+			&meta.MessageCount, &meta.Pinned, &created, &updated); err != nil {
+			return nil, err
+		}
+		meta.CreatedAt = fromUnixNanos(created)
+		meta.UpdatedAt = fromUnixNanos(updated)
+		if err := s.open(&meta.Summary, &meta.Preview); err != nil {
+			return nil, fmt.Errorf("failed to decrypt conversation %s: %w", meta.ID, err)
+		}
+		metas = append(metas, meta)
+	}
+	return metas, rows.Err()
+}
+
+// attachTags sets the tags of listed conversations.
+func (s *ConversationStore) attachTags(metas []ConversationMeta) error {
+	if len(metas) == 0 {
+		return nil
+	}
+	byID := make(map[string]*ConversationMeta, len(metas))
+	for i := range metas {
+		byID[metas[i].ID] = &metas[i]
+	}
+
+	rows, err := s.db.Query("SELECT conv_id, tag FROM tags ORDER BY tag")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, tag string
+		if err := rows.Scan(&id, &tag); err != nil {
+			return err
+		}
+		if meta, ok := byID[id]; ok {
+			meta.Tags = append(meta.Tags, tag)
+		}
+	}
+	return rows.Err()
+}
+
+// Search finds conversations whose summary or preview contains query
+// (case-insensitive).
+func (s *ConversationStore) Search(query string) ([]ConversationMeta, error) {
+	all, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	query = strings.ToLower(query)
+	var results []ConversationMeta
+
+	for _, meta := range all {
+		// Search in summary and preview
+		if strings.Contains(strings.ToLower(meta.Summary), query) ||
+			strings.Contains(strings.ToLower(meta.Preview), query) {
+			results = append(results, meta)
+		}
+	}
+
+	return results, nil
+}
+
+// SearchMessages searches conversations by message content.
+// Returns conversations where any message on the active branch contains the
+// query string (case-insensitive).
+func (s *ConversationStore) SearchMessages(query string) ([]ConversationMeta, error) {
+	if query == "" {
+		return s.List()
+	}
+	query = strings.ToLower(query)
+
+	// SQLite's lower() only folds ASCII, so the match is done here
+	rows, err := s.db.Query("SELECT conv_id, content FROM messages WHERE active = 1")
+	if err != nil {
+		return nil, err
+	}
+	matched := make(map[string]bool)
+	for rows.Next() {
+		var id, content string
+		if err := rows.Scan(&id, &content); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if err := s.open(&content); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if !matched[id] && strings.Contains(strings.ToLower(content), query) {
+			matched[id] = true
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	all, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	var results []ConversationMeta
+	for _, meta := range all {
+		if matched[meta.ID] {
+			results = append(results, meta)
+		}
+	}
+	return results, nil
+}
+
+// SearchMessageSnippets returns up to limit messages matching query across
+// all saved conversations, best matches first. Every word of the query must
+// appear in the message; the last word also matches as a prefix.
+func (s *ConversationStore) SearchMessageSnippets(query string, limit int) ([]MessageHit, error) {
+	match := ftsQuery(query)
+	if match == "" {
+		return nil, nil
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+	if s.cipher != nil {
+		return s.searchSealed(match, limit)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT m.conv_id, c.summary, c.classification, c.updated_at,
+		       m.msg_id, m.msg_index, m.role,
+		       snippet(messages_fts, 0, ?, ?, '...', 16)
+		FROM messages_fts m
+		JOIN conversations c ON c.id = m.conv_id
+		WHERE messages_fts MATCH ?
+		ORDER BY bm25(messages_fts), c.updated_at DESC
+		LIMIT ?`,
+		SnippetMatchStart, SnippetMatchEnd, match, limit)
+	if err != nil {
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
+	defer rows.Close()
+
+	var hits []MessageHit
+	for rows.Next() {
+		var hit MessageHit
+		var updated int64
+		if err := rows.Scan(&hit.ConversationID, &hit.Summary, &hit.Classification, &updated,
+			&hit.MessageID, &hit.MessageIndex, &hit.Role, &hit.Snippet); err != nil {
+			return nil, err
+		}
+		hit.UpdatedAt = fromUnixNanos(updated)
+		hit.Snippet = strings.Join(strings.Fields(hit.Snippet), " ")
+		if err := s.open(&hit.Summary); err != nil {
+			return nil, err
+		}
+		hits = append(hits, hit)
+	}
+	return hits, rows.Err()
+}
+
+// =============================================================================
+// TAGS AND PINS
+// =============================================================================
+
+// AddTag labels a conversation. Tags are trimmed and lowercased.
+func (s *ConversationStore) AddTag(id, tag string) error {
+	tag = normalizeTag(tag)
+	if tag == "" {
+		return fmt.Errorf("tag cannot be empty")
+	}
+	return s.update(id, "INSERT OR IGNORE INTO tags (conv_id, tag) VALUES (?, ?)", id, tag)
+}
+
+// RemoveTag removes a tag from a conversation.
+func (s *ConversationStore) RemoveTag(id, tag string) error {
+	return s.update(id, "DELETE FROM tags WHERE conv_id = ? AND tag = ?", id, normalizeTag(tag))
+}
+
+// Tags returns every tag in use, with how many conversations have it.
+func (s *ConversationStore) Tags() ([]TagCount, error) {
+	rows, err := s.db.Query("SELECT tag, COUNT(*) FROM tags GROUP BY tag ORDER BY tag")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []TagCount
+	for rows.Next() {
+		var tc TagCount
+		if err := rows.Scan(&tc.Tag, &tc.Count); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tc)
+	}
+	return tags, rows.Err()
+}
+
+// SetPinned pins or unpins a conversation. Pinned conversations are never
+// removed to enforce MaxConversations.
+func (s *ConversationStore) SetPinned(id string, pinned bool) error {
+	return s.update(id, "UPDATE conversations SET pinned = ? WHERE id = ?", pinned, id)
+}
+
+// update runs a statement about an existing conversation in a transaction.
+func (s *ConversationStore) update(id, query string, args ...any) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM conversations WHERE id = ?", id).Scan(&exists); err != nil {
+		return err
+	}
+	if exists == 0 {
+		return ErrConversationNotFound
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// normalizeTag returns the stored form of a tag.
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// =============================================================================
+// DELETE OPERATIONS
+// =============================================================================
+
+// Delete removes a conversation by ID.
+func (s *ConversationStore) Delete(id string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	found, err := deleteConversation(tx, id)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrConversationNotFound
+	}
+	return tx.Commit()
+}
+
+// Clear removes all saved conversations.
+func (s *ConversationStore) Clear() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"messages_fts", "metrics", "tool_calls", "messages", "tags", "conversations"} {
+		if _, err := tx.Exec("DELETE FROM " + table); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// deleteConversation removes a conversation and everything about it,
+// reporting whether it existed.
+func deleteConversation(tx *sql.Tx, id string) (bool, error) {
+	if err := deleteMessages(tx, id); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec("DELETE FROM tags WHERE conv_id = ?", id); err != nil {
+		return false, err
+	}
+	res, err := tx.Exec("DELETE FROM conversations WHERE id = ?", id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// deleteMessages removes the messages of a conversation.
+func deleteMessages(tx *sql.Tx, id string) error {
+	for _, table := range []string{"messages_fts", "metrics", "tool_calls", "messages"} {
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE conv_id = ?", id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// =============================================================================
+// JSON IMPORT
+// =============================================================================
+
+// ImportJSON imports the conversation files (<id>.json) in dir, as written
+// by earlier rigrun versions, in one transaction.
+// Conversations already in the store are kept as they are. Imported files
+// are moved to dir/imported, so that they are imported only once, or
+// securely deleted when the store is encrypted; unreadable files are left
+// in place. Returns how many were imported.
+func (s *ConversationStore) ImportJSON(dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			files = append(files, entry.Name())
+		}
+	}
+	if len(files) == 0 {
+		return 0, nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	imported := 0
+	var done []string
+	for _, name := range files {
+		path := filepath.Join(dir, name)
+		conv, err := readConversationFile(path)
+		if err != nil {
+			log.Printf("WARNING: Skipping unreadable conversation %s: %v", path, err)
+			continue
+		}
+		conv.ID = strings.TrimSuffix(name, ".json")
+		done = append(done, name)
+
+		var exists int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM conversations WHERE id = ?", conv.ID).Scan(&exists); err != nil {
+			return 0, err
+		}
+		if exists > 0 {
+			continue
+		}
+
+		// Files may predate summaries, markings or timestamps
+		if conv.Summary == "" {
+			conv.Summary = generateSummary(conv)
+		}
+		if conv.IsMarked() {
+			conv.Classification = conv.HighWaterMark().String()
+		}
+		if conv.UpdatedAt.IsZero() {
+			if info, err := os.Stat(path); err == nil {
+				conv.UpdatedAt = info.ModTime()
+			}
+		}
+		if conv.CreatedAt.IsZero() {
+			conv.CreatedAt = conv.UpdatedAt
+		}
+
+		if err := s.writeConversation(tx, conv); err != nil {
+			return 0, fmt.Errorf("failed to import %s: %w", path, err)
+		}
+		imported++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	// The files are in the database now; a failed move only means the next
+	// import skips them again
+	if err := s.retireImported(dir, done); err != nil {
+		return imported, err
+	}
+
+	// The old search index only covered the moved files. It holds
+	// their plaintext, which an encrypted store must not leave behind.
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		path := filepath.Join(dir, SearchIndexFile+suffix)
+		if s.cipher == nil {
+			os.Remove(path)
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if err := security.GlobalSanitizer().SecureDeleteFile(path); err != nil {
+			return imported, err
+		}
+	}
+	return imported, nil
+}
+
+// retireImported moves imported files to dir/imported. An encrypted store
+// keeps no plaintext copies, so it securely deletes them instead.
+func (s *ConversationStore) retireImported(dir string, names []string) error {
+	if s.cipher != nil {
+		for _, name := range names {
+			if err := security.GlobalSanitizer().SecureDeleteFile(filepath.Join(dir, name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	importedDir := filepath.Join(dir, ImportedDir)
+	if err := os.MkdirAll(importedDir, 0700); err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := os.Rename(filepath.Join(dir, name), filepath.Join(importedDir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// =============================================================================
+// HELPERS
+// =============================================================================
+
+// unixNanos converts a time to Unix nanoseconds, keeping the zero time 0.
+func unixNanos(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// fromUnixNanos converts Unix nanoseconds from unixNanos to a time.
+func fromUnixNanos(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
+
+// searchableText returns the text of a message as it is shown in the chat.
+// Tool messages display their result rather than their content.
+func searchableText(msg StoredMessage) string {
+	if msg.Role == "tool" && msg.ToolResult != "" {
+		return msg.ToolResult
+	}
+	return msg.Content
+}
+
+// readConversationFile decodes a conversation file.
+func readConversationFile(path string) (*StoredConversation, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var conv StoredConversation
+	if err := json.Unmarshal(data, &conv); err != nil {
+		return nil, err
+	}
+	return &conv, nil
+}
+
+// ftsQuery turns free text into an FTS5 query. Each word is quoted so that
+// FTS5 operators in the input are matched literally, and the last word is a
+// prefix query. Returns "" when the text holds no words.
+func ftsQuery(text string) string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return ""
+	}
+	terms := make([]string, len(words))
+	for i, w := range words {
+		terms[i] = `"` + w + `"`
+	}
+	terms[len(terms)-1] += "*"
+	return strings.Join(terms, " ")
+}
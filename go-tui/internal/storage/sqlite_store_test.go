@@ -0,0 +1,285 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package storage provides conversation persistence for rigrun TUI.
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// =============================================================================
+// SQLITE STORE TESTS
+// =============================================================================
+
+func newSQLiteTestStore(t *testing.T, dir string) *ConversationStore {
+	t.Helper()
+	store, err := NewConversationStoreWithDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestConversationStore_RoundTrip(t *testing.T) {
+	store := newSQLiteTestStore(t, t.TempDir())
+
+	at := time.Date(2025, 3, 1, 12, 0, 0, 123456789, time.UTC)
+	conv := &StoredConversation{
+		Summary:    "Round trip",
+		Model:      "qwen2.5-coder:14b",
+		TokensUsed: 1234,
+		Mentions:   []string{"@file:main.go"},
+		Messages: []StoredMessage{
+			{ID: "m1", Role: "user", Content: "Read main.go", Timestamp: at},
+			{ID: "m2", ParentID: "m1", Role: "tool", Content: "", Timestamp: at,
+				ToolName: "Read", ToolInput: `{"path":"main.go"}`, ToolResult: "package main", IsSuccess: true},
+			{ID: "m3", ParentID: "m2", Role: "assistant", Content: "It is the entry point.", Timestamp: at,
+				TokenCount: 42, DurationMs: 1500, TokensPerSec: 28.5, TTFTMs: 120},
+		},
+		Branches: []StoredMessage{
+			{ID: "b1", ParentID: "m2", Role: "assistant", Content: "An earlier answer", Timestamp: at},
+		},
+	}
+	id, err := store.Save(conv)
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := store.Load(id)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !loaded.UpdatedAt.Equal(conv.UpdatedAt) || !loaded.CreatedAt.Equal(conv.CreatedAt) {
+		t.Errorf("Timestamps = %v/%v, want %v/%v", loaded.CreatedAt, loaded.UpdatedAt, conv.CreatedAt, conv.UpdatedAt)
+	}
+	if loaded.TokensUsed != 1234 || !reflect.DeepEqual(loaded.Mentions, conv.Mentions) {
+		t.Errorf("Context tracking = %d %v", loaded.TokensUsed, loaded.Mentions)
+	}
+	for i, list := range [][2][]StoredMessage{{loaded.Messages, conv.Messages}, {loaded.Branches, conv.Branches}} {
+		if len(list[0]) != len(list[1]) {
+			t.Fatalf("list %d: got %d messages, want %d", i, len(list[0]), len(list[1]))
+		}
+		for j := range list[0] {
+			got, want := list[0][j], list[1][j]
+			if !got.Timestamp.Equal(want.Timestamp) {
+				t.Errorf("message %s: Timestamp = %v, want %v", want.ID, got.Timestamp, want.Timestamp)
+			}
+			got.Timestamp, want.Timestamp = time.Time{}, time.Time{}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("message %s:\n got %+v\nwant %+v", want.ID, got, want)
+			}
+		}
+	}
+
+	// Saving again replaces the messages rather than adding to them
+	conv.Messages = conv.Messages[:1]
+	conv.Branches = nil
+	if _, err := store.Save(conv); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	loaded, _ = store.Load(id)
+	if len(loaded.Messages) != 1 || len(loaded.Branches) != 0 {
+		t.Errorf("After resave: %d messages, %d branch messages", len(loaded.Messages), len(loaded.Branches))
+	}
+	if hits, _ := store.SearchMessageSnippets("entry point", 10); len(hits) != 0 {
+		t.Errorf("Stale search hit after resave: %+v", hits)
+	}
+}
+
+func TestConversationStore_TagsAndPins(t *testing.T) {
+	store := newSQLiteTestStore(t, t.TempDir())
+	store.MaxConversations = 3
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		id, err := store.Save(&StoredConversation{
+			Messages: []StoredMessage{{Role: "user", Content: "Question " + string(rune('A'+i))}},
+		})
+		if err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+		ids = append(ids, id)
+		time.Sleep(5 * time.Millisecond)
+	}
+	oldest := ids[0]
+
+	if err := store.AddTag(oldest, " Release "); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	if err := store.AddTag(ids[1], "release"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	if err := store.SetPinned(oldest, true); err != nil {
+		t.Fatalf("SetPinned failed: %v", err)
+	}
+	if err := store.AddTag("conv_missing", "x"); !errors.Is(err, ErrConversationNotFound) {
+		t.Errorf("AddTag on a missing conversation: %v", err)
+	}
+
+	// Resaving keeps tags and pins
+	conv, _ := store.Load(oldest)
+	if _, err := store.Save(conv); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	// Going over the limit removes the oldest unpinned conversation
+	if _, err := store.Save(&StoredConversation{
+		Messages: []StoredMessage{{Role: "user", Content: "Question D"}},
+	}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if _, err := store.Load(oldest); err != nil {
+		t.Errorf("Pinned conversation was pruned: %v", err)
+	}
+	if _, err := store.Load(ids[1]); !errors.Is(err, ErrConversationNotFound) {
+		t.Errorf("Expected the oldest unpinned conversation pruned, got %v", err)
+	}
+
+	tagged, total, err := store.ListPage(ListOptions{Tag: "RELEASE"})
+	if err != nil {
+		t.Fatalf("ListPage failed: %v", err)
+	}
+	if total != 1 || len(tagged) != 1 || tagged[0].ID != oldest || !tagged[0].Pinned ||
+		!reflect.DeepEqual(tagged[0].Tags, []string{"release"}) {
+		t.Errorf("Tagged = %+v (total %d)", tagged, total)
+	}
+	if tags, _ := store.Tags(); !reflect.DeepEqual(tags, []TagCount{{Tag: "release", Count: 1}}) {
+		t.Errorf("Tags = %+v", tags)
+	}
+
+	if err := store.RemoveTag(oldest, "release"); err != nil {
+		t.Fatalf("RemoveTag failed: %v", err)
+	}
+	if tagged, _, _ := store.ListPage(ListOptions{Tag: "release"}); len(tagged) != 0 {
+		t.Errorf("Expected no tagged conversations, got %+v", tagged)
+	}
+}
+
+func TestConversationStore_NoDefaultLimit(t *testing.T) {
+	store := newSQLiteTestStore(t, t.TempDir())
+
+	// Only the retention policy removes conversations
+	for i := 0; i < 101; i++ {
+		if _, err := store.Save(&StoredConversation{
+			Messages: []StoredMessage{{Role: "user", Content: "Question"}},
+		}); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+	if _, total, err := store.ListPage(ListOptions{Limit: 1}); err != nil || total != 101 {
+		t.Errorf("Stored %d conversations (err %v), want 101", total, err)
+	}
+}
+
+func TestConversationStore_ListPage(t *testing.T) {
+	store := newSQLiteTestStore(t, t.TempDir())
+
+	for i := 0; i < 5; i++ {
+		if _, err := store.Save(&StoredConversation{
+			Messages: []StoredMessage{{Role: "user", Content: "Question " + string(rune('A'+i))}},
+		}); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	page, total, err := store.ListPage(ListOptions{Offset: 1, Limit: 2})
+	if err != nil {
+		t.Fatalf("ListPage failed: %v", err)
+	}
+	if total != 5 || len(page) != 2 || page[0].Preview != "Question D" || page[1].Preview != "Question C" {
+		t.Errorf("Page = %+v (total %d)", page, total)
+	}
+
+	page, _, _ = store.ListPage(ListOptions{Offset: 4, Limit: 2})
+	if len(page) != 1 || page[0].Preview != "Question A" {
+		t.Errorf("Last page = %+v", page)
+	}
+}
+
+func TestConversationStore_ImportJSON(t *testing.T) {
+	dir := t.TempDir()
+	updated := time.Date(2024, 11, 5, 9, 30, 0, 0, time.UTC)
+	write := func(name string, data []byte) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, _ := json.Marshal(StoredConversation{
+		ID:        "conv_legacy",
+		Summary:   "Legacy session",
+		CreatedAt: updated.Add(-time.Hour),
+		UpdatedAt: updated,
+		Messages: []StoredMessage{
+			{ID: "l1", Role: "user", Content: "legacy delta question"},
+			{ID: "l2", Role: "assistant", Content: "answer", TokenCount: 7},
+		},
+	})
+	write("conv_legacy.json", data)
+	write("conv_broken.json", []byte("{"))
+	write(SearchIndexFile, []byte("stale"))
+
+	store := newSQLiteTestStore(t, dir)
+
+	conv, err := store.Load("conv_legacy")
+	if err != nil {
+		t.Fatalf("Imported conversation not found: %v", err)
+	}
+	if !conv.UpdatedAt.Equal(updated) || conv.Summary != "Legacy session" || conv.Messages[1].TokenCount != 7 {
+		t.Errorf("Imported conversation = %+v", conv)
+	}
+	if hits, _ := store.SearchMessageSnippets("delta", 10); len(hits) != 1 {
+		t.Errorf("Imported messages not searchable: %+v", hits)
+	}
+
+	// Imported files are moved aside; unreadable ones stay for inspection
+	if _, err := os.Stat(filepath.Join(dir, ImportedDir, "conv_legacy.json")); err != nil {
+		t.Errorf("Imported file not moved: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "conv_broken.json")); err != nil {
+		t.Errorf("Unreadable file not left in place: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, SearchIndexFile)); !os.IsNotExist(err) {
+		t.Errorf("Stale search index not removed: %v", err)
+	}
+
+	// A file reappearing for a stored conversation does not overwrite it
+	conv.Summary = "Renamed"
+	if _, err := store.Save(conv); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	write("conv_legacy.json", data)
+	if n, err := store.ImportJSON(dir); err != nil || n != 0 {
+		t.Errorf("ImportJSON = %d, %v; want 0, nil", n, err)
+	}
+	if conv, _ := store.Load("conv_legacy"); conv.Summary != "Renamed" {
+		t.Errorf("Import overwrote the stored conversation: %q", conv.Summary)
+	}
+}
+
+func TestFTSQuery(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"-- ", ""},
+		{"pool", `"pool"*`},
+		{"connection pool", `"connection" "pool"*`},
+		{`a"b OR c`, `"a" "b" "OR" "c"*`},
+	}
+	for _, tt := range tests {
+		if got := ftsQuery(tt.in); got != tt.want {
+			t.Errorf("ftsQuery(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
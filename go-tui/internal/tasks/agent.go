@@ -0,0 +1,406 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package tasks provides a background task system for long-running operations.
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jeranaias/rigrun-tui/internal/cloud"
+	"github.com/jeranaias/rigrun-tui/internal/ollama"
+	"github.com/jeranaias/rigrun-tui/internal/plan"
+	"github.com/jeranaias/rigrun-tui/internal/router"
+	"github.com/jeranaias/rigrun-tui/internal/telemetry"
+	"github.com/jeranaias/rigrun-tui/internal/tools"
+	"github.com/jeranaias/rigrun-tui/internal/util"
+)
+
+// =============================================================================
+// AGENT TASKS
+// =============================================================================
+
+// CommandAgent is the command of tasks that run agent work, such as an
+// agentic loop or a plan, instead of a shell command.
+const CommandAgent = "agent"
+
+// ProgressFunc reports what an agent task is doing. A negative percent
+// means the progress is unknown and is left unchanged.
+type ProgressFunc func(step string, percent int)
+
+// AgentFunc is the work of an agent task. It returns a summary of what it
+// did, which is posted to the conversation the task was started from. The
+// summary is used even when an error is returned.
+//
+// Agent tasks run unattended: an AgentFunc must never prompt the user.
+// Tool calls that need approval are denied (see NewBackgroundExecutor).
+type AgentFunc func(ctx context.Context, progress ProgressFunc) (string, error)
+
+// NewAgentTask creates a task that runs fn in the background. It is
+// subject to the runner's concurrency limit and timeout like any other task.
+func NewAgentTask(description string, fn AgentFunc) *Task {
+	task := NewTask(description, CommandAgent, nil)
+	task.agent = fn
+	return task
+}
+
+// =============================================================================
+// NON-INTERACTIVE TOOL PERMISSIONS
+// =============================================================================
+
+// DeniedCalls records tool calls denied because they needed approval.
+type DeniedCalls struct {
+	mu    sync.Mutex
+	tools map[string]int
+}
+
+// add records a denied call to tool.
+func (d *DeniedCalls) add(tool string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.tools == nil {
+		d.tools = make(map[string]int)
+	}
+	d.tools[tool]++
+}
+
+// String lists the denied tools with their counts, e.g. "Bash x2, Write".
+func (d *DeniedCalls) String() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	names := make([]string, 0, len(d.tools))
+	for name := range d.tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		if n := d.tools[name]; n > 1 {
+			parts = append(parts, fmt.Sprintf("%s x%d", name, n))
+		} else {
+			parts = append(parts, name)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// NewBackgroundExecutor creates a tool executor that never asks the user.
+// Tools at or below the auto-approve level run as usual and policy still
+// applies; every call that would need approval is denied, recorded in
+// denied and reported through progress.
+func NewBackgroundExecutor(registry *tools.Registry, denied *DeniedCalls, progress ProgressFunc) *tools.Executor {
+	executor := tools.NewExecutor(registry)
+	executor.SetAutoApproveLevel(tools.PermissionAuto)
+	executor.SetPermissionCallback(func(tool *tools.Tool, params map[string]interface{}) bool {
+		denied.add(tool.Name)
+		progress(fmt.Sprintf("Denied %s: background tasks cannot ask for approval", tool.Name), -1)
+		return false
+	})
+	return executor
+}
+
+// =============================================================================
+// AGENTIC LOOP
+// =============================================================================
+
+// ChatFactory returns the ChatFunc an agent uses to call its model, bound
+// to the task's context. The factory decides the tier and model.
+type ChatFactory func(ctx context.Context) tools.ChatFunc
+
+// AgentConfig configures an agentic loop run as a background task.
+type AgentConfig struct {
+	// Prompt is the task given to the agent
+	Prompt string
+
+	// SystemPrompt starts the agent's own conversation
+	SystemPrompt string
+
+	// Chat calls the model (see OllamaChat)
+	Chat ChatFactory
+
+	// Registry provides the tools and their permission policy
+	Registry *tools.Registry
+
+	// WorkDir is the tools' working directory ("" for the current directory)
+	WorkDir string
+
+	// MaxIterations bounds the loop (0 for tools.DefaultMaxIterations)
+	MaxIterations int
+}
+
+// maxStepLen bounds the tool arguments shown in a progress step.
+const maxStepLen = 80
+
+// AgentLoop returns an AgentFunc that runs an AgenticLoop in a conversation
+// of its own, reporting each tool call as a step. The summary is the
+// agent's final response.
+func AgentLoop(cfg AgentConfig) AgentFunc {
+	return func(ctx context.Context, progress ProgressFunc) (string, error) {
+		if cfg.Chat == nil || cfg.Registry == nil {
+			return "", fmt.Errorf("agent task is not configured")
+		}
+
+		denied := &DeniedCalls{}
+		executor := NewBackgroundExecutor(cfg.Registry, denied, progress)
+		if cfg.WorkDir != "" {
+			executor.SetWorkDir(cfg.WorkDir)
+		}
+
+		loop := tools.NewAgenticLoop(executor, cfg.MaxIterations)
+		calls := 0
+		loop.SetCallbacks(func(call tools.ToolCallMessage) {
+			calls++
+			progress(describeCall(call), -1)
+		}, func(result tools.Result) {
+			if !result.Success {
+				progress("  failed: "+util.TruncateRunes(result.Error, maxStepLen), -1)
+			}
+		})
+		if cfg.SystemPrompt != "" {
+			loop.AddMessage(tools.Message{Role: "system", Content: cfg.SystemPrompt})
+		}
+
+		progress("Started", 0)
+		response, err := loop.RunWithInitialMessage(ctx, cfg.Chat(ctx), cfg.Prompt)
+		return agentSummary(response, calls, denied), err
+	}
+}
+
+// describeCall renders a tool call as a progress step.
+func describeCall(call tools.ToolCallMessage) string {
+	keys := make([]string, 0, len(call.Arguments))
+	for key := range call.Arguments {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	args := make([]string, 0, len(keys))
+	for _, key := range keys {
+		args = append(args, fmt.Sprintf("%s=%v", key, call.Arguments[key]))
+	}
+	return fmt.Sprintf("%s(%s)", call.Name, util.TruncateRunes(strings.Join(args, ", "), maxStepLen))
+}
+
+// agentSummary combines the agent's final response with what it did.
+func agentSummary(response string, calls int, denied *DeniedCalls) string {
+	var b strings.Builder
+	if response = strings.TrimSpace(response); response != "" {
+		b.WriteString(response)
+		b.WriteString("\n\n")
+	}
+	fmt.Fprintf(&b, "(%d tool calls", calls)
+	if list := denied.String(); list != "" {
+		fmt.Fprintf(&b, "; denied without approval: %s", list)
+	}
+	b.WriteString(")")
+	return b.String()
+}
+
+// OllamaChat returns a ChatFactory for a local Ollama model, offering it
+// the registry's tools.
+func OllamaChat(client *ollama.Client, model string, registry *tools.Registry) ChatFactory {
+	ollamaTools := registry.ToOllamaTools()
+	return func(ctx context.Context) tools.ChatFunc {
+		return func(messages []tools.Message) (string, []tools.ToolCallMessage, error) {
+			var content strings.Builder
+			var calls []ollama.ToolCall
+			var chunkErr error
+			err := client.ChatStreamWithTools(ctx, model, tools.MessagesToOllama(messages), ollamaTools, func(chunk ollama.StreamChunk) {
+				if chunk.Error != nil {
+					chunkErr = chunk.Error
+					return
+				}
+				content.WriteString(chunk.Content)
+				calls = append(calls, chunk.ToolCalls...)
+			})
+			if err == nil {
+				err = chunkErr
+			}
+			if err != nil {
+				return "", nil, err
+			}
+
+			// Ollama does not identify tool calls; the conversion assigns IDs
+			msg := tools.OllamaMessageToMessage(ollama.NewAssistantMessageWithTools(content.String(), calls))
+			return msg.Content, msg.ToolCalls, nil
+		}
+	}
+}
+
+// CloudChat returns a ChatFactory for a cloud model on tier. Cloud models
+// are not offered native tools: the system prompt describes them and calls
+// are parsed from the response text. The spend of each response is recorded
+// with budget, and no request is sent once a budget is exceeded.
+func CloudChat(client *cloud.OpenRouterClient, model string, tier router.Tier, budget *telemetry.BudgetGuard) ChatFactory {
+	return func(ctx context.Context) tools.ChatFunc {
+		return func(messages []tools.Message) (string, []tools.ToolCallMessage, error) {
+			if exceeded := budget.ExceededBudget(); exceeded != "" {
+				return "", nil, fmt.Errorf("cost budget exceeded: %s", exceeded)
+			}
+
+			start := time.Now()
+			resp, err := client.ChatWithModel(ctx, model, messagesToCloud(messages))
+			if err != nil {
+				return "", nil, err
+			}
+			if budget != nil && budget.Tracker != nil && !strings.HasSuffix(model, ":free") {
+				budget.Tracker.RecordQuery(strings.ToLower(tier.String()),
+					resp.Usage.PromptTokens, resp.Usage.CompletionTokens, time.Since(start), lastUserMessage(messages))
+				_ = budget.Tracker.SaveCurrentSession()
+			}
+
+			content := resp.GetContent()
+			var calls []tools.ToolCallMessage
+			for _, call := range tools.ParseToolCalls(content) {
+				calls = append(calls, tools.ToolCallMessage{
+					ID:        "call_" + uuid.NewString()[:8],
+					Name:      call.Name,
+					Arguments: call.Params,
+				})
+			}
+			return content, calls, nil
+		}
+	}
+}
+
+// messagesToCloud converts an agent's conversation for a cloud model. Tool
+// results are passed back as user messages, since the model made its calls
+// in text.
+func messagesToCloud(messages []tools.Message) []cloud.ChatMessage {
+	out := make([]cloud.ChatMessage, 0, len(messages))
+	for _, msg := range messages {
+		switch msg.Role {
+		case "system":
+			out = append(out, cloud.NewSystemMessage(msg.Content))
+		case "assistant":
+			out = append(out, cloud.NewAssistantMessage(msg.Content))
+		case "tool":
+			out = append(out, cloud.NewUserMessage("[tool result]\n"+msg.Content))
+		default:
+			out = append(out, cloud.NewUserMessage(msg.Content))
+		}
+	}
+	return out
+}
+
+// lastUserMessage returns the content of the last user message, the prompt
+// a response's spend is recorded against.
+func lastUserMessage(messages []tools.Message) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" {
+			return messages[i].Content
+		}
+	}
+	return ""
+}
+
+// chatLLM generates plans through a ChatFactory.
+type chatLLM struct {
+	chat ChatFactory
+}
+
+// ChatLLM returns a plan.LLMClient that sends each prompt as a single user
+// message through chat, so that plans can be generated on any tier.
+func ChatLLM(chat ChatFactory) plan.LLMClient {
+	return chatLLM{chat: chat}
+}
+
+// GenerateCompletion sends the prompt as a single user message.
+func (c chatLLM) GenerateCompletion(ctx context.Context, prompt string) (string, error) {
+	content, _, err := c.chat(ctx)([]tools.Message{{Role: "user", Content: prompt}})
+	return content, err
+}
+
+// =============================================================================
+// PLANS
+// =============================================================================
+
+// PlanConfig configures a plan generated and executed as a background task.
+type PlanConfig struct {
+	// Task is what the plan should accomplish
+	Task string
+
+	// Generator creates the plan
+	Generator *plan.Generator
+
+	// Registry provides the tools and their permission policy
+	Registry *tools.Registry
+
+	// WorkDir is the tools' working directory ("" for the current directory)
+	WorkDir string
+
+	// Store, if set, persists the plan so that it can be resumed
+	Store *plan.Store
+}
+
+// PlanAgent returns an AgentFunc that generates a plan and executes it
+// without review, reporting each step transition. Failed plans are not
+// re-planned, since revisions need the user's approval. The summary lists
+// the steps and their outcomes.
+func PlanAgent(cfg PlanConfig) AgentFunc {
+	return func(ctx context.Context, progress ProgressFunc) (string, error) {
+		if cfg.Generator == nil || cfg.Registry == nil {
+			return "", fmt.Errorf("plan task is not configured")
+		}
+
+		progress("Planning", 0)
+		p, err := cfg.Generator.Generate(ctx, cfg.Task)
+		if err != nil {
+			return "", err
+		}
+		// Starting the task approves the plan; nobody is there to review it
+		if err := p.Approve(); err != nil {
+			return "", err
+		}
+
+		denied := &DeniedCalls{}
+		toolExecutor := NewBackgroundExecutor(cfg.Registry, denied, progress)
+		if cfg.WorkDir != "" {
+			toolExecutor.SetWorkDir(cfg.WorkDir)
+		}
+		executor := plan.NewPlanExecutor(p, toolExecutor)
+		if cfg.Store != nil {
+			executor.SetStore(cfg.Store)
+		}
+		executor.SetProgressCallback(func(step, total int, status string) {
+			percent := -1
+			if total > 0 {
+				percent = step * 100 / total
+			}
+			progress(status, percent)
+		})
+
+		err = executor.Execute(ctx)
+		summary := planSummary(p)
+		if list := denied.String(); list != "" {
+			summary += "\n(denied without approval: " + list + ")"
+		}
+		return summary, err
+	}
+}
+
+// planSummary lists a plan's steps with their outcomes.
+func planSummary(p *plan.Plan) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Plan %s (%s steps)\n", p.Status, p.Progress())
+	for i := range p.Steps {
+		step := &p.Steps[i]
+		fmt.Fprintf(&b, "- [%s] %s", step.Status, step.Description)
+		switch {
+		case step.Error != nil:
+			fmt.Fprintf(&b, ": %s", util.TruncateRunes(step.Error.Error(), maxStepLen))
+		case step.Result != "":
+			fmt.Fprintf(&b, ": %s", util.TruncateRunes(strings.TrimSpace(step.Result), maxStepLen))
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package tasks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jeranaias/rigrun-tui/internal/cloud"
+	"github.com/jeranaias/rigrun-tui/internal/router"
+	"github.com/jeranaias/rigrun-tui/internal/telemetry"
+	"github.com/jeranaias/rigrun-tui/internal/tools"
+)
+
+// waitForNotification returns the next notification for a task with the
+// given status.
+func waitForNotification(t *testing.T, q *Queue, status TaskStatus) TaskNotification {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case notif := <-q.Notifications():
+			if notif.Status == status {
+				return notif
+			}
+		case <-timeout:
+			t.Fatalf("Timed out waiting for a %s notification", status)
+		}
+	}
+}
+
+func TestAgentTaskStreamsProgress(t *testing.T) {
+	q := NewQueue(0)
+	runner := NewRunnerWithOptions(q, 1, 0)
+	runner.Start()
+	defer runner.Stop()
+
+	release := make(chan struct{})
+	task := NewAgentTask("Agent", func(ctx context.Context, progress ProgressFunc) (string, error) {
+		progress("Reading files", 40)
+		<-release
+		return "All done", nil
+	})
+	task.ConversationID = "conv-1"
+	if err := q.Add(task); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	notif := waitForNotification(t, q, TaskStatusRunning)
+	if notif.Step != "Reading files" || notif.Progress != 40 || notif.ConversationID != "conv-1" {
+		t.Errorf("Unexpected progress notification: %+v", notif)
+	}
+	if running := q.GetRunning(task.ID); running == nil || running.GetStep() != "Reading files" {
+		t.Error("Expected the running task to record its step")
+	}
+	close(release)
+
+	notif = waitForNotification(t, q, TaskStatusComplete)
+	if notif.Result != "All done" || notif.ConversationID != "conv-1" {
+		t.Errorf("Unexpected completion notification: %+v", notif)
+	}
+}
+
+func TestAgentTaskKeepsSummaryOnFailure(t *testing.T) {
+	task := NewAgentTask("Agent", func(ctx context.Context, progress ProgressFunc) (string, error) {
+		return "Got halfway", errors.New("model unavailable")
+	})
+	if err := Execute(task); err == nil {
+		t.Fatal("Expected the agent's error")
+	}
+	if task.GetStatus() != TaskStatusFailed || task.GetResult() != "Got halfway" {
+		t.Errorf("Expected failed task with summary, got %s %q", task.GetStatus(), task.GetResult())
+	}
+}
+
+func TestAgentLoopDeniesApproval(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "out.txt")
+
+	// The model writes a file, then answers
+	turn := 0
+	chat := func(ctx context.Context) tools.ChatFunc {
+		return func(messages []tools.Message) (string, []tools.ToolCallMessage, error) {
+			turn++
+			if turn == 1 {
+				return "", []tools.ToolCallMessage{{ID: "call-1", Name: "Write", Arguments: map[string]interface{}{
+					"file_path": target, "content": "x",
+				}}}, nil
+			}
+			return "Could not write the file.", nil, nil
+		}
+	}
+
+	var steps []string
+	summary, err := AgentLoop(AgentConfig{
+		Prompt:   "write a file",
+		Chat:     chat,
+		Registry: tools.NewRegistry(),
+		WorkDir:  dir,
+	})(context.Background(), func(step string, percent int) {
+		steps = append(steps, step)
+	})
+	if err != nil {
+		t.Fatalf("AgentLoop failed: %v", err)
+	}
+
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Error("Expected the write to be denied")
+	}
+	if !strings.Contains(summary, "Could not write the file.") || !strings.Contains(summary, "denied without approval: Write") {
+		t.Errorf("Unexpected summary: %q", summary)
+	}
+	if !strings.Contains(strings.Join(steps, "\n"), "Denied Write") {
+		t.Errorf("Expected the denial to be reported, got steps: %v", steps)
+	}
+}
+
+func TestCloudChatStopsOverBudget(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"id": "test-id",
+			"model": "test-model",
+			"choices": [{
+				"message": {"role": "assistant", "content": "{\"name\":\"Read\",\"arguments\":{\"file_path\":\"a.txt\"}}"},
+				"finish_reason": "stop"
+			}],
+			"usage": {"prompt_tokens": 200000, "completion_tokens": 100000, "total_tokens": 300000}
+		}`))
+	}))
+	defer server.Close()
+
+	client := cloud.NewOpenRouterClient("sk-or-test-abcdefghijklmnopqrstuvwxyz0123456789")
+	client.WithBaseURL(server.URL)
+	client.WithCertValidation(false)
+	tracker, err := telemetry.NewCostTracker(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewCostTracker failed: %v", err)
+	}
+	budget := &telemetry.BudgetGuard{Tracker: tracker, Budget: telemetry.Budget{Session: 0.01}}
+
+	chat := CloudChat(client, "sonnet", router.TierSonnet, budget)(context.Background())
+	messages := []tools.Message{{Role: "user", Content: "read a.txt"}}
+
+	_, calls, err := chat(messages)
+	if err != nil {
+		t.Fatalf("First call failed: %v", err)
+	}
+	if len(calls) != 1 || calls[0].Name != "Read" || calls[0].Arguments["file_path"] != "a.txt" {
+		t.Errorf("Expected the Read call parsed from the response, got %+v", calls)
+	}
+
+	// The first response spent the budget; no further request is sent
+	if _, _, err := chat(messages); err == nil || !strings.Contains(err.Error(), "budget") {
+		t.Errorf("Expected a budget error, got %v", err)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("Expected 1 request, got %d", got)
+	}
+}
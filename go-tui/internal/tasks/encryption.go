@@ -0,0 +1,110 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package tasks provides a background task system for long-running operations.
+package tasks
+
+import (
+	"path/filepath"
+
+	"github.com/jeranaias/rigrun-tui/internal/security"
+)
+
+// =============================================================================
+// ENCRYPTION (SC-28)
+// =============================================================================
+
+// Compile-time check that Store is a protected store.
+var _ security.ProtectedStore = (*Store)(nil)
+
+// sealedTaskColumns are the columns holding user content, by table.
+var sealedTaskColumns = []struct {
+	table   string
+	key     []string
+	columns []string
+}{
+	{"tasks", []string{"id"}, []string{"description", "args", "error", "result", "metadata"}},
+	{"task_output", []string{"task_id", "seq"}, []string{"data"}},
+}
+
+// seal encrypts values with the store's cipher, if any.
+func (s *Store) seal(values ...string) ([]string, error) {
+	sealed := make([]string, len(values))
+	for i, v := range values {
+		var err error
+		if sealed[i], err = security.SealField(s.cipher, v); err != nil {
+			return nil, err
+		}
+	}
+	return sealed, nil
+}
+
+// open decrypts stored values in place.
+func (s *Store) open(values ...*string) error {
+	for _, v := range values {
+		opened, err := security.OpenField(s.cipher, *v)
+		if err != nil {
+			return err
+		}
+		*v = opened
+	}
+	return nil
+}
+
+// EncryptionStatus counts the stored values holding user content, and how
+// many are encrypted.
+func (s *Store) EncryptionStatus() (security.StoreStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := security.StoreStatus{Name: "Tasks", Path: filepath.Join(s.dir, StoreFile)}
+	for _, t := range sealedTaskColumns {
+		for _, column := range t.columns {
+			var items, encrypted int
+			if err := s.db.QueryRow(
+				"SELECT COUNT(*), COALESCE(SUM(substr("+column+", 1, 4) = 'ENC:'), 0) FROM "+t.table+" WHERE "+column+" != ''",
+			).Scan(&items, &encrypted); err != nil {
+				return status, err
+			}
+			status.Items += items
+			status.Encrypted += encrypted
+		}
+	}
+	return status, nil
+}
+
+// Reencrypt passes the stored user content through recrypt in one
+// transaction, then compacts the database so that no earlier form of a
+// changed value remains on disk.
+func (s *Store) Reencrypt(recrypt security.Recrypt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	changed := 0
+	for _, t := range sealedTaskColumns {
+		n, err := security.ReencryptColumns(tx, t.table, t.key, t.columns, recrypt)
+		if err != nil {
+			return err
+		}
+		changed += n
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if changed == 0 {
+		return nil
+	}
+
+	for _, stmt := range []string{"VACUUM", "PRAGMA wal_checkpoint(TRUNCATE)"} {
+		if _, err := s.db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -34,15 +34,30 @@ type Queue struct {
 
 	// notifyChan sends notifications when tasks complete
 	notifyChan chan TaskNotification
+
+	// store persists tasks and their output (nil = in-memory only)
+	store *Store
+
+	// agentFactory rebuilds the work of agent tasks loaded from the store
+	agentFactory AgentFactory
 }
 
+// AgentFactory rebuilds the work of an agent task loaded from the store
+// (after a restart, or retried from the CLI) from its Args and Metadata.
+type AgentFactory func(task *Task) (AgentFunc, error)
+
 // TaskNotification represents a notification about a task state change.
+// Running notifications report the progress of agent tasks.
 type TaskNotification struct {
-	TaskID      string
-	Description string
-	Status      TaskStatus
-	Error       string
-	Duration    time.Duration
+	TaskID         string
+	Description    string
+	Status         TaskStatus
+	Error          string
+	Duration       time.Duration
+	ConversationID string
+	Step           string // Current step (Running notifications)
+	Progress       int    // Progress percentage (Running notifications)
+	Result         string // Summary of an agent task's work
 }
 
 // =============================================================================
@@ -94,6 +109,10 @@ func (q *Queue) Add(task *Task) error {
 	// Set initial status (ignore error since we're setting to initial state)
 	_ = task.SetStatus(TaskStatusQueued)
 	q.tasks = append(q.tasks, task)
+	if q.store != nil {
+		q.attachOutput(task, 0)
+		q.persist(task)
+	}
 	return nil
 }
 
@@ -140,6 +159,7 @@ func (q *Queue) Cancel(id string) bool {
 		if task.ID == id {
 			if task.GetStatus() == TaskStatusQueued {
 				task.MarkCanceled()
+				q.persist(task)
 				return true
 			}
 		}
@@ -155,6 +175,7 @@ func (q *Queue) MarkRunning(task *Task) {
 
 	task.MarkStarted()
 	q.running[task.ID] = task
+	q.persist(task)
 }
 
 // MarkComplete marks a task as complete and removes it from running.
@@ -164,13 +185,17 @@ func (q *Queue) MarkComplete(task *Task) {
 
 	task.MarkComplete()
 	delete(q.running, task.ID)
+	task.FlushOutput()
+	q.persist(task)
 
 	// Send notification
 	q.notify(TaskNotification{
-		TaskID:      task.ID,
-		Description: task.Description,
-		Status:      TaskStatusComplete,
-		Duration:    task.Duration(),
+		TaskID:         task.ID,
+		Description:    task.Description,
+		Status:         TaskStatusComplete,
+		Duration:       task.Duration(),
+		ConversationID: task.ConversationID,
+		Result:         task.GetResult(),
 	})
 
 	// Cleanup old tasks
@@ -184,14 +209,18 @@ func (q *Queue) MarkFailed(task *Task, err error) {
 
 	task.SetError(err)
 	delete(q.running, task.ID)
+	task.FlushOutput()
+	q.persist(task)
 
 	// Send notification
 	q.notify(TaskNotification{
-		TaskID:      task.ID,
-		Description: task.Description,
-		Status:      TaskStatusFailed,
-		Error:       err.Error(),
-		Duration:    task.Duration(),
+		TaskID:         task.ID,
+		Description:    task.Description,
+		Status:         TaskStatusFailed,
+		Error:          err.Error(),
+		Duration:       task.Duration(),
+		ConversationID: task.ConversationID,
+		Result:         task.GetResult(),
 	})
 
 	// Cleanup old tasks
@@ -205,19 +234,53 @@ func (q *Queue) MarkCanceled(task *Task) {
 
 	task.MarkCanceled()
 	delete(q.running, task.ID)
+	task.FlushOutput()
+	q.persist(task)
 
 	// Send notification
 	q.notify(TaskNotification{
-		TaskID:      task.ID,
-		Description: task.Description,
-		Status:      TaskStatusCanceled,
-		Duration:    task.Duration(),
+		TaskID:         task.ID,
+		Description:    task.Description,
+		Status:         TaskStatusCanceled,
+		Duration:       task.Duration(),
+		ConversationID: task.ConversationID,
+		Result:         task.GetResult(),
 	})
 
 	// Cleanup old tasks
 	q.cleanupLocked()
 }
 
+// UpdateProgress records what a running task is doing, appends the step to
+// its output and notifies listeners. A negative percent leaves the progress
+// unchanged. Progress notifications are dropped once the channel is half
+// full, so that they never crowd out completion notifications.
+func (q *Queue) UpdateProgress(task *Task, step string, percent int) {
+	task.SetStep(step)
+	if percent >= 0 {
+		task.SetProgress(percent)
+	}
+	if step != "" {
+		task.AppendOutput(step + "\n")
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.notifyChan) >= cap(q.notifyChan)/2 {
+		return
+	}
+	q.notify(TaskNotification{
+		TaskID:         task.ID,
+		Description:    task.Description,
+		Status:         TaskStatusRunning,
+		Duration:       task.Duration(),
+		ConversationID: task.ConversationID,
+		Step:           step,
+		Progress:       task.GetProgress(),
+	})
+}
+
 // =============================================================================
 // QUEUE QUERIES
 // =============================================================================
@@ -314,6 +377,171 @@ func (q *Queue) notify(notification TaskNotification) {
 	}
 }
 
+// =============================================================================
+// PERSISTENCE
+// =============================================================================
+
+// SetAgentFactory sets how agent tasks loaded from the store are rebuilt.
+// Set it before AttachStore; agent tasks that cannot be rebuilt fail.
+func (q *Queue) SetAgentFactory(factory AgentFactory) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.agentFactory = factory
+}
+
+// AttachStore makes the queue durable: tasks and their output are saved to
+// store as they change, finished tasks beyond MaxStoredTasks are pruned,
+// and the tasks of sessions that exited are taken over (see Sync).
+func (q *Queue) AttachStore(store *Store) error {
+	if err := store.Heartbeat(); err != nil {
+		return fmt.Errorf("failed to register with task store: %w", err)
+	}
+	if err := store.Prune(MaxStoredTasks); err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	q.store = store
+	for _, task := range q.tasks {
+		q.attachOutput(task, 0)
+		q.persist(task)
+	}
+	q.mu.Unlock()
+
+	return q.Sync()
+}
+
+// Sync brings the queue in line with the store, if any. It renews this
+// session's heartbeat, flushes the output of running tasks, takes over
+// tasks queued from the CLI or left by sessions that exited (re-queueing
+// queued tasks and recording running ones as interrupted), and cancels
+// tasks the CLI asked to cancel. The runner calls it every few seconds.
+func (q *Queue) Sync() error {
+	q.mu.RLock()
+	store := q.store
+	factory := q.agentFactory
+	running := make([]*Task, 0, len(q.running))
+	for _, task := range q.running {
+		running = append(running, task)
+	}
+	q.mu.RUnlock()
+	if store == nil {
+		return nil
+	}
+
+	if err := store.Heartbeat(); err != nil {
+		return err
+	}
+	for _, task := range running {
+		task.FlushOutput()
+	}
+
+	queued, interrupted, err := store.Claim()
+	if err != nil {
+		return fmt.Errorf("failed to claim tasks: %w", err)
+	}
+	for _, task := range interrupted {
+		q.mu.Lock()
+		q.tasks = append(q.tasks, task)
+		q.notify(TaskNotification{
+			TaskID:         task.ID,
+			Description:    task.Description,
+			Status:         TaskStatusInterrupted,
+			Error:          task.Error,
+			Duration:       task.Duration(),
+			ConversationID: task.ConversationID,
+		})
+		q.cleanupLocked()
+		q.mu.Unlock()
+	}
+	for _, task := range queued {
+		q.restore(task, store, factory)
+	}
+
+	ids, err := store.CancelRequests()
+	if err != nil {
+		return fmt.Errorf("failed to read cancel requests: %w", err)
+	}
+	for _, id := range ids {
+		q.Cancel(id)
+	}
+	return nil
+}
+
+// restore adds a queued task loaded from the store, rebuilding the work of
+// agent tasks. Agent tasks that cannot be rebuilt fail.
+func (q *Queue) restore(task *Task, store *Store, factory AgentFactory) {
+	seq, err := store.outputSeq(task.ID)
+	if err == nil && task.Command == CommandAgent {
+		if factory == nil {
+			err = fmt.Errorf("agent tasks cannot run in this session")
+		} else {
+			task.agent, err = factory(task)
+		}
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.tasks = append(q.tasks, task)
+	q.attachOutput(task, seq)
+	if err != nil {
+		task.MarkStarted()
+		task.SetError(fmt.Errorf("cannot restore task: %w", err))
+		q.persist(task)
+		q.notify(TaskNotification{
+			TaskID:         task.ID,
+			Description:    task.Description,
+			Status:         TaskStatusFailed,
+			Error:          task.GetError(),
+			ConversationID: task.ConversationID,
+		})
+	}
+}
+
+// Shutdown stops running tasks and records them as interrupted, keeping
+// their output, then closes the store. Call it when the session exits.
+func (q *Queue) Shutdown() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.store == nil {
+		return nil
+	}
+	for id, task := range q.running {
+		task.MarkInterrupted()
+		task.FlushOutput()
+		q.persist(task)
+		delete(q.running, id)
+	}
+	store := q.store
+	q.store = nil
+	return store.Close()
+}
+
+// attachOutput sends a task's output to the store from chunk seq+1 on.
+// Must be called with lock held and a store attached.
+func (q *Queue) attachOutput(task *Task, seq int) {
+	store := q.store
+	task.setOutputSink(func(seq int, data string) {
+		if err := store.AppendOutput(task.ID, seq, data); err != nil {
+			log.Printf("WARNING: Could not save output of task %s: %v", task.ID, err)
+		}
+	}, seq)
+}
+
+// persist saves a task to the store, if any (must be called with lock
+// held). A failed save is logged: the task still runs, but may not
+// survive a restart.
+func (q *Queue) persist(task *Task) {
+	if q.store == nil {
+		return
+	}
+	if err := q.store.Save(task); err != nil {
+		log.Printf("WARNING: Could not save task %s: %v", task.ID, err)
+	}
+}
+
 // =============================================================================
 // CLEANUP
 // =============================================================================
@@ -0,0 +1,91 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package tasks provides a background task system for long-running operations.
+package tasks
+
+import (
+	"github.com/jeranaias/rigrun-tui/internal/security"
+)
+
+// =============================================================================
+// RETENTION (SI-12)
+// =============================================================================
+
+// Compile-time check that Store is a retained store.
+var _ security.RetainedStore = (*Store)(nil)
+
+// finishedTasks selects the tasks no session will run again.
+const finishedTasks = "status NOT IN ('" + string(TaskStatusQueued) + "', '" + string(TaskStatusRunning) + "')"
+
+// RetentionClass returns the data class of background tasks.
+func (s *Store) RetentionClass() string {
+	return security.RetentionTasks
+}
+
+// RetentionItems lists the finished tasks, sized by their stored content
+// and output. Queued and running tasks are kept.
+func (s *Store) RetentionItems() ([]security.RetentionItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rows, err := s.db.Query(`
+		SELECT t.id, MAX(t.created_at, t.end_time),
+		       length(CAST(t.description AS BLOB)) + length(CAST(t.args AS BLOB)) +
+		       length(CAST(t.error AS BLOB)) + length(CAST(t.result AS BLOB)) +
+		       length(CAST(t.metadata AS BLOB)) +
+		       COALESCE((SELECT SUM(length(CAST(data AS BLOB))) FROM task_output o WHERE o.task_id = t.id), 0)
+		FROM tasks t
+		WHERE ` + finishedTasks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []security.RetentionItem
+	for rows.Next() {
+		var item security.RetentionItem
+		var written int64
+		if err := rows.Scan(&item.ID, &written, &item.Size); err != nil {
+			return nil, err
+		}
+		item.Time = fromUnixNano(written)
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// Purge deletes finished tasks and their output in one transaction. The
+// database deletes securely (see OpenStore), and the WAL is emptied of the
+// deleted pages. A task that was retried since it was listed is kept.
+func (s *Store) Purge(items []security.RetentionItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, item := range items {
+		res, err := tx.Exec("DELETE FROM tasks WHERE id = ? AND "+finishedTasks, item.ID)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			continue
+		}
+		if _, err := tx.Exec("DELETE FROM task_output WHERE task_id = ?", item.ID); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec("PRAGMA wal_checkpoint(TRUNCATE)")
+	return err
+}
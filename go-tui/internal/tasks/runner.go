@@ -9,6 +9,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"log"
 	"os/exec"
 	"strings"
 	"sync"
@@ -75,11 +76,16 @@ func (r *Runner) Stop() {
 // TASK PROCESSING
 // =============================================================================
 
+// syncEvery is how many ticks of the process loop pass between syncs of
+// the queue with its store.
+const syncEvery = 10
+
 // processLoop continuously processes tasks from the queue.
 func (r *Runner) processLoop() {
 	ticker := time.NewTicker(100 * time.Millisecond)
 	defer ticker.Stop()
 
+	ticks := 0
 	for {
 		select {
 		case <-r.stop:
@@ -90,6 +96,13 @@ func (r *Runner) processLoop() {
 				return
 			}
 
+			// Pick up tasks queued and canceled from the CLI
+			if ticks++; ticks%syncEvery == 0 {
+				if err := r.queue.Sync(); err != nil {
+					log.Printf("WARNING: Task store sync failed: %v", err)
+				}
+			}
+
 			// Check for queued tasks
 			queued := r.queue.Queued()
 			for _, task := range queued {
@@ -101,7 +114,14 @@ func (r *Runner) processLoop() {
 				// Acquire semaphore (blocks if at max concurrency)
 				select {
 				case r.semaphore <- struct{}{}:
-					// Successfully acquired semaphore, start task
+					// The task may have been canceled while we waited
+					if task.GetStatus() != TaskStatusQueued {
+						<-r.semaphore
+						continue
+					}
+					// Mark as running before starting so the next tick
+					// cannot pick the same task up again
+					r.queue.MarkRunning(task)
 					r.wg.Add(1)
 					go r.executeTask(task)
 				case <-r.stop:
@@ -118,9 +138,6 @@ func (r *Runner) executeTask(task *Task) {
 	defer r.wg.Done()
 	defer func() { <-r.semaphore }() // Release semaphore when done
 
-	// Mark as running
-	r.queue.MarkRunning(task)
-
 	// Create context with timeout or cancel
 	var ctx context.Context
 	var cancel context.CancelFunc
@@ -132,16 +149,7 @@ func (r *Runner) executeTask(task *Task) {
 	task.SetCancelFunc(cancel)
 	defer cancel()
 
-	// Execute based on command type
-	var err error
-	switch strings.ToLower(task.Command) {
-	case "bash", "sh", "shell":
-		err = r.executeBashTask(ctx, task)
-	case "sleep":
-		err = r.executeSleepTask(ctx, task)
-	default:
-		err = fmt.Errorf("unknown command type: %s", task.Command)
-	}
+	err := r.run(ctx, task)
 
 	// Mark task as complete or failed
 	if err != nil {
@@ -161,6 +169,20 @@ func (r *Runner) executeTask(task *Task) {
 // COMMAND EXECUTORS
 // =============================================================================
 
+// run executes a task based on its command type.
+func (r *Runner) run(ctx context.Context, task *Task) error {
+	switch strings.ToLower(task.Command) {
+	case "bash", "sh", "shell":
+		return r.executeBashTask(ctx, task)
+	case "sleep":
+		return r.executeSleepTask(ctx, task)
+	case CommandAgent:
+		return r.executeAgentTask(ctx, task)
+	default:
+		return fmt.Errorf("unknown command type: %s", task.Command)
+	}
+}
+
 // executeBashTask executes a bash/shell command.
 // Note: Progress tracking is not supported for bash tasks - progress will remain at 0%.
 // Progress is only available for sleep tasks which can estimate completion time.
@@ -244,6 +266,24 @@ func (r *Runner) streamOutput(pipe io.ReadCloser, task *Task, isError bool) {
 	}
 }
 
+// executeAgentTask runs an agent task's work, streaming its progress to the
+// queue's notifications. The summary is kept even if the work fails, so
+// that partial results still reach the conversation.
+func (r *Runner) executeAgentTask(ctx context.Context, task *Task) error {
+	task.mu.RLock()
+	agent := task.agent
+	task.mu.RUnlock()
+	if agent == nil {
+		return fmt.Errorf("agent task has nothing to run")
+	}
+
+	summary, err := agent(ctx, func(step string, percent int) {
+		r.queue.UpdateProgress(task, step, percent)
+	})
+	task.SetResult(summary)
+	return err
+}
+
 // executeSleepTask executes a sleep task (for testing).
 func (r *Runner) executeSleepTask(ctx context.Context, task *Task) error {
 	if len(task.Args) == 0 {
@@ -294,15 +334,7 @@ func Execute(task *Task) error {
 	defer cancel()
 
 	// Execute
-	var err error
-	switch strings.ToLower(task.Command) {
-	case "bash", "sh", "shell":
-		err = runner.executeBashTask(ctx, task)
-	case "sleep":
-		err = runner.executeSleepTask(ctx, task)
-	default:
-		err = fmt.Errorf("unknown command type: %s", task.Command)
-	}
+	err := runner.run(ctx, task)
 
 	// Mark complete or failed
 	if err != nil {
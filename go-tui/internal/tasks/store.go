@@ -0,0 +1,645 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package tasks provides a background task system for long-running operations.
+package tasks
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/jeranaias/rigrun-tui/internal/security"
+
+	_ "modernc.org/sqlite" // Pure Go SQLite driver
+)
+
+// =============================================================================
+// CONSTANTS
+// =============================================================================
+
+const (
+	// StoreFile is the name of the task database inside the store directory.
+	StoreFile = "tasks.db"
+
+	// MaxOutputChunks is how many output chunks are kept per task (10 MB at
+	// OutputChunkSize). Older chunks are dropped as new ones arrive.
+	MaxOutputChunks = 640
+
+	// MaxStoredTasks is how many finished tasks are kept by Prune.
+	MaxStoredTasks = 500
+
+	// ownerStaleAfter is how long a session may miss heartbeats before its
+	// tasks are taken over by another session.
+	ownerStaleAfter = 15 * time.Second
+
+	// taskStoreVersion is the schema version in PRAGMA user_version.
+	taskStoreVersion = 1
+)
+
+const taskStoreSchema = `
+CREATE TABLE IF NOT EXISTS tasks (
+    id TEXT PRIMARY KEY,
+    description TEXT NOT NULL,
+    command TEXT NOT NULL,
+    args TEXT NOT NULL,                       -- JSON array
+    status TEXT NOT NULL,
+    conversation_id TEXT NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    result TEXT NOT NULL DEFAULT '',
+    progress INTEGER NOT NULL DEFAULT 0,
+    metadata TEXT NOT NULL DEFAULT '{}',      -- JSON object
+    owner TEXT NOT NULL DEFAULT '',           -- Session running or queueing the task
+    cancel_requested INTEGER NOT NULL DEFAULT 0,
+    created_at INTEGER NOT NULL,              -- Unix nanoseconds
+    start_time INTEGER NOT NULL DEFAULT 0,
+    end_time INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS tasks_status ON tasks(status);
+
+CREATE TABLE IF NOT EXISTS task_output (
+    task_id TEXT NOT NULL,
+    seq INTEGER NOT NULL,
+    data TEXT NOT NULL,
+    PRIMARY KEY (task_id, seq)
+) WITHOUT ROWID;
+
+CREATE TABLE IF NOT EXISTS owners (
+    id TEXT PRIMARY KEY,
+    heartbeat_at INTEGER NOT NULL             -- Unix nanoseconds
+) WITHOUT ROWID;
+`
+
+// taskColumns are the task columns read by scanTask, in order.
+const taskColumns = `id, description, command, args, status, conversation_id, error, result,
+    progress, metadata, created_at, start_time, end_time`
+
+// ErrTaskNotFound is returned when no stored task matches an ID.
+var ErrTaskNotFound = errors.New("task not found")
+
+// =============================================================================
+// STORE
+// =============================================================================
+
+// Store persists tasks and their output in SQLite so that the queue and
+// its history survive restarts. Each session using the store is an owner
+// identified by a random ID; it keeps a heartbeat so that other sessions
+// (and the CLI) can tell its running tasks from those of a session that
+// exited. It is safe for concurrent use.
+//
+// Descriptions, arguments, results, errors, metadata and output hold user
+// content, so they are encrypted with the data cipher when one is set
+// (SC-28).
+type Store struct {
+	mu     sync.Mutex
+	db     *sql.DB
+	dir    string
+	owner  string
+	cipher security.FieldCipher // Seals user content; nil writes plaintext
+}
+
+// OpenStore opens (or creates) the task database in dir. An empty dir
+// uses ~/.rigrun.
+func OpenStore(dir string) (*Store, error) {
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get home directory: %w", err)
+		}
+		dir = filepath.Join(home, ".rigrun")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create task directory: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", filepath.Join(dir, StoreFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open task store: %w", err)
+	}
+
+	// SQLite only supports one writer at a time
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+
+	for _, pragma := range []string{"PRAGMA journal_mode=WAL", "PRAGMA synchronous=NORMAL", "PRAGMA busy_timeout=5000",
+		"PRAGMA secure_delete=ON"} { // SC-28: Overwrite deleted content
+		if _, err := db.Exec(pragma); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to set pragma: %w", err)
+		}
+	}
+
+	if err := initTaskSchema(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize task store: %w", err)
+	}
+
+	return &Store{db: db, dir: dir, owner: uuid.New().String(), cipher: security.DataCipher()}, nil
+}
+
+// initTaskSchema creates the tables.
+func initTaskSchema(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	if version > taskStoreVersion {
+		return fmt.Errorf("task store version %d is newer than supported version %d", version, taskStoreVersion)
+	}
+	if _, err := db.Exec(taskStoreSchema); err != nil {
+		return err
+	}
+	_, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d", taskStoreVersion))
+	return err
+}
+
+// Close releases this session's tasks to other sessions and closes the
+// database.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, _ = s.db.Exec("DELETE FROM owners WHERE id = ?", s.owner)
+	return s.db.Close()
+}
+
+// =============================================================================
+// WRITING
+// =============================================================================
+
+// Save inserts or updates a task. Unfinished tasks are owned by this
+// session; a pending cancel request is kept until the task finishes. A
+// stored task that has already finished is never moved to another status,
+// so a stale in-memory copy cannot revive a task the CLI canceled.
+func (s *Store) Save(task *Task) error {
+	t := task.Clone()
+	args, err := json.Marshal(t.Args)
+	if err != nil {
+		return fmt.Errorf("failed to encode task arguments: %w", err)
+	}
+	metadata, err := json.Marshal(t.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to encode task metadata: %w", err)
+	}
+	owner := s.owner
+	if t.IsComplete() {
+		owner = ""
+	}
+	sealed, err := s.seal(t.Description, string(args), t.Error, t.Result, string(metadata))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt task: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res, err := s.db.Exec(`
+        INSERT INTO tasks (id, description, command, args, status, conversation_id, error, result,
+            progress, metadata, owner, created_at, start_time, end_time)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+        ON CONFLICT(id) DO UPDATE SET
+            status = excluded.status, error = excluded.error, result = excluded.result,
+            progress = excluded.progress, metadata = excluded.metadata, owner = excluded.owner,
+            start_time = excluded.start_time, end_time = excluded.end_time,
+            cancel_requested = CASE WHEN excluded.owner = '' THEN 0 ELSE cancel_requested END
+        WHERE tasks.status NOT IN (?, ?, ?, ?) OR tasks.status = excluded.status`,
+		t.ID, sealed[0], t.Command, sealed[1], string(t.Status), t.ConversationID,
+		sealed[2], sealed[3], t.Progress, sealed[4], owner,
+		unixNano(t.CreatedAt), unixNano(t.StartTime), unixNano(t.EndTime),
+		string(TaskStatusComplete), string(TaskStatusFailed), string(TaskStatusCanceled), string(TaskStatusInterrupted))
+	if err != nil {
+		return fmt.Errorf("failed to save task: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("task %s already finished; not overwriting it with %s", shortID(t.ID), t.Status)
+	}
+	return nil
+}
+
+// AppendOutput stores chunk seq of a task's output, dropping chunks more
+// than MaxOutputChunks older.
+func (s *Store) AppendOutput(taskID string, seq int, data string) error {
+	sealed, err := s.seal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt task output: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.db.Exec("INSERT OR REPLACE INTO task_output (task_id, seq, data) VALUES (?, ?, ?)",
+		taskID, seq, sealed[0]); err != nil {
+		return fmt.Errorf("failed to save task output: %w", err)
+	}
+	if seq > MaxOutputChunks {
+		if _, err := s.db.Exec("DELETE FROM task_output WHERE task_id = ? AND seq <= ?",
+			taskID, seq-MaxOutputChunks); err != nil {
+			return fmt.Errorf("failed to trim task output: %w", err)
+		}
+	}
+	return nil
+}
+
+// Heartbeat records that this session is alive.
+func (s *Store) Heartbeat() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.db.Exec(`INSERT INTO owners (id, heartbeat_at) VALUES (?, ?)
+        ON CONFLICT(id) DO UPDATE SET heartbeat_at = excluded.heartbeat_at`,
+		s.owner, time.Now().UnixNano())
+	return err
+}
+
+// Claim takes over the unfinished tasks of sessions that are no longer
+// alive, and tasks queued from the CLI. Their queued tasks are re-owned
+// and returned for running; their running tasks are marked interrupted,
+// keeping their output, and returned as well.
+func (s *Store) Claim() (queued, interrupted []*Task, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT `+taskColumns+` FROM tasks
+        WHERE status IN (?, ?) AND owner != ?
+          AND owner NOT IN (SELECT id FROM owners WHERE heartbeat_at >= ?)
+        ORDER BY created_at`,
+		string(TaskStatusQueued), string(TaskStatusRunning), s.owner,
+		now.Add(-ownerStaleAfter).UnixNano())
+	if err != nil {
+		return nil, nil, err
+	}
+	tasks, err := s.scanTasks(rows)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, task := range tasks {
+		if task.Status == TaskStatusRunning {
+			task.Status = TaskStatusInterrupted
+			task.EndTime = now
+			task.Error = "rigrun exited while the task was running"
+			var sealed []string
+			if sealed, err = s.seal(task.Error); err != nil {
+				return nil, nil, err
+			}
+			_, err = tx.Exec("UPDATE tasks SET status = ?, error = ?, end_time = ?, owner = '', cancel_requested = 0 WHERE id = ?",
+				string(task.Status), sealed[0], unixNano(now), task.ID)
+			interrupted = append(interrupted, task)
+		} else {
+			_, err = tx.Exec("UPDATE tasks SET owner = ? WHERE id = ?", s.owner, task.ID)
+			queued = append(queued, task)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM owners WHERE heartbeat_at < ?", now.Add(-ownerStaleAfter).UnixNano()); err != nil {
+		return nil, nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+
+	for _, task := range interrupted {
+		if task.Output, err = s.tail(task.ID); err != nil {
+			return nil, nil, err
+		}
+	}
+	return queued, interrupted, nil
+}
+
+// CancelRequests returns the IDs of this session's tasks that the CLI
+// asked to cancel.
+func (s *Store) CancelRequests() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rows, err := s.db.Query("SELECT id FROM tasks WHERE cancel_requested = 1 AND owner = ?", s.owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// RequestCancel cancels a task. A queued task is canceled at once unless a
+// live session holds it; that task, like a running one, is flagged, and the
+// session cancels it within a few seconds. It returns the task as it now
+// stands.
+func (s *Store) RequestCancel(id string) (*Task, error) {
+	task, err := s.Load(id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch task.Status {
+	case TaskStatusQueued:
+		// A live session would start its in-memory copy anyway, so it has
+		// to cancel the task itself
+		var res sql.Result
+		res, err = s.db.Exec(`UPDATE tasks SET cancel_requested = 1 WHERE id = ? AND status = ?
+            AND owner IN (SELECT id FROM owners WHERE heartbeat_at >= ?)`,
+			task.ID, string(TaskStatusQueued), time.Now().Add(-ownerStaleAfter).UnixNano())
+		if err != nil {
+			break
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			break
+		}
+		task.Status = TaskStatusCanceled
+		task.EndTime = time.Now()
+		_, err = s.db.Exec("UPDATE tasks SET status = ?, end_time = ?, owner = '' WHERE id = ? AND status = ?",
+			string(task.Status), unixNano(task.EndTime), task.ID, string(TaskStatusQueued))
+	case TaskStatusRunning:
+		_, err = s.db.Exec("UPDATE tasks SET cancel_requested = 1 WHERE id = ?", task.ID)
+	default:
+		return nil, fmt.Errorf("task %s already finished: %s", shortID(task.ID), task.Status)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel task: %w", err)
+	}
+	return task, nil
+}
+
+// Retry queues a copy of a finished task. It runs in the next session to
+// claim it (see Claim), which may be one that is already running.
+func (s *Store) Retry(id string) (*Task, error) {
+	old, err := s.Load(id)
+	if err != nil {
+		return nil, err
+	}
+	if !old.IsComplete() {
+		return nil, fmt.Errorf("task %s has not finished: %s", shortID(old.ID), old.Status)
+	}
+
+	task := NewTask(old.Description, old.Command, old.Args)
+	task.ConversationID = old.ConversationID
+	for k, v := range old.Metadata {
+		task.Metadata[k] = v
+	}
+	task.Metadata["retry_of"] = old.ID
+
+	if err := s.Save(task); err != nil {
+		return nil, err
+	}
+
+	// Leave it unowned so that any live session may claim it
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.db.Exec("UPDATE tasks SET owner = '' WHERE id = ?", task.ID); err != nil {
+		return nil, fmt.Errorf("failed to queue task: %w", err)
+	}
+	return task, nil
+}
+
+// Prune deletes the oldest finished tasks, and their output, beyond the
+// most recent keep.
+func (s *Store) Prune(keep int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old := `SELECT id FROM tasks WHERE ` + finishedTasks + ` ORDER BY created_at DESC LIMIT -1 OFFSET ?`
+	if _, err := s.db.Exec("DELETE FROM task_output WHERE task_id IN ("+old+")", keep); err != nil {
+		return fmt.Errorf("failed to prune task output: %w", err)
+	}
+	if _, err := s.db.Exec("DELETE FROM tasks WHERE id IN ("+old+")", keep); err != nil {
+		return fmt.Errorf("failed to prune tasks: %w", err)
+	}
+	return nil
+}
+
+// =============================================================================
+// READING
+// =============================================================================
+
+// Load returns the task with the given ID or unique ID prefix. Its Output
+// holds the last MaxOutputInMemory bytes of its stored output.
+func (s *Store) Load(id string) (*Task, error) {
+	if !validTaskID(id) {
+		return nil, fmt.Errorf("invalid task ID: %q", id)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rows, err := s.db.Query(`SELECT `+taskColumns+` FROM tasks WHERE id = ? OR id LIKE ? LIMIT 2`, id, id+"%")
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := s.scanTasks(rows)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case len(tasks) == 0:
+		return nil, fmt.Errorf("%w: %s", ErrTaskNotFound, id)
+	case len(tasks) > 1 && tasks[0].ID != id && tasks[1].ID != id:
+		return nil, fmt.Errorf("task ID %q is ambiguous", id)
+	}
+
+	task := tasks[0]
+	if len(tasks) > 1 && tasks[1].ID == id {
+		task = tasks[1]
+	}
+	if task.Output, err = s.tail(task.ID); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// List returns the stored tasks, newest first, without their output.
+// limit <= 0 returns all of them.
+func (s *Store) List(limit int) ([]*Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := s.db.Query(`SELECT `+taskColumns+` FROM tasks ORDER BY created_at DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	return s.scanTasks(rows)
+}
+
+// WriteOutput streams a task's stored output to fn one chunk at a time,
+// so that large logs are never held in memory at once. It returns whether
+// earlier chunks were dropped to stay within MaxOutputChunks.
+func (s *Store) WriteOutput(taskID string, fn func(chunk string) error) (truncated bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rows, err := s.db.Query("SELECT seq, data FROM task_output WHERE task_id = ? ORDER BY seq", taskID)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	first := true
+	for rows.Next() {
+		var seq int
+		var data string
+		if err := rows.Scan(&seq, &data); err != nil {
+			return false, err
+		}
+		if err := s.open(&data); err != nil {
+			return false, err
+		}
+		if first {
+			truncated = seq > 1
+			first = false
+		}
+		if err := fn(data); err != nil {
+			return truncated, err
+		}
+	}
+	return truncated, rows.Err()
+}
+
+// outputSeq returns the number of the last stored output chunk of a task.
+func (s *Store) outputSeq(taskID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var seq sql.NullInt64
+	if err := s.db.QueryRow("SELECT MAX(seq) FROM task_output WHERE task_id = ?", taskID).Scan(&seq); err != nil {
+		return 0, err
+	}
+	return int(seq.Int64), nil
+}
+
+// tail returns the last MaxOutputInMemory bytes of a task's stored output.
+// Must be called with the lock held.
+func (s *Store) tail(taskID string) (string, error) {
+	rows, err := s.db.Query("SELECT data FROM task_output WHERE task_id = ? ORDER BY seq DESC", taskID)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var chunks []string
+	size := 0
+	for rows.Next() && size < MaxOutputInMemory {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return "", err
+		}
+		if err := s.open(&data); err != nil {
+			return "", err
+		}
+		chunks = append(chunks, data)
+		size += len(data)
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for i := len(chunks) - 1; i >= 0; i-- {
+		b.WriteString(chunks[i])
+	}
+	return tailBytes(b.String(), MaxOutputInMemory), nil
+}
+
+// scanTasks reads tasks selected with taskColumns, decrypting their user
+// content, and closes rows.
+func (s *Store) scanTasks(rows *sql.Rows) ([]*Task, error) {
+	defer rows.Close()
+
+	var tasks []*Task
+	for rows.Next() {
+		var (
+			t                           Task
+			args, status, metadata      string
+			createdAt, startTime, endAt int64
+		)
+		if err := rows.Scan(&t.ID, &t.Description, &t.Command, &args, &status, &t.ConversationID,
+			&t.Error, &t.Result, &t.Progress, &metadata, &createdAt, &startTime, &endAt); err != nil {
+			return nil, err
+		}
+		if err := s.open(&t.Description, &args, &t.Error, &t.Result, &metadata); err != nil {
+			return nil, fmt.Errorf("task %s: %w", t.ID, err)
+		}
+		if err := json.Unmarshal([]byte(args), &t.Args); err != nil {
+			return nil, fmt.Errorf("task %s has invalid arguments: %w", t.ID, err)
+		}
+		if err := json.Unmarshal([]byte(metadata), &t.Metadata); err != nil || t.Metadata == nil {
+			t.Metadata = make(map[string]interface{})
+		}
+		t.Status = TaskStatus(status)
+		t.CreatedAt = fromUnixNano(createdAt)
+		t.StartTime = fromUnixNano(startTime)
+		t.EndTime = fromUnixNano(endAt)
+		tasks = append(tasks, &t)
+	}
+	return tasks, rows.Err()
+}
+
+// =============================================================================
+// HELPERS
+// =============================================================================
+
+// validTaskID reports whether id can be a task ID or prefix of one. Task
+// IDs are UUIDs, so this also keeps LIKE wildcards out of prefixes.
+func validTaskID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f' || r >= 'A' && r <= 'F' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// shortID returns the first 8 characters of a task ID.
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// unixNano converts a time to Unix nanoseconds, with 0 for the zero time.
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// fromUnixNano converts Unix nanoseconds to a time, with 0 as the zero time.
+func fromUnixNano(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
@@ -0,0 +1,296 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package tasks
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeranaias/rigrun-tui/internal/security"
+	"github.com/jeranaias/rigrun-tui/internal/security/securitytest"
+)
+
+// openTestStore opens a task store in dir, closed when the test ends.
+func openTestStore(t *testing.T, dir string) *Store {
+	t.Helper()
+	store, err := OpenStore(dir)
+	if err != nil {
+		t.Fatalf("OpenStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// crash abandons a session's store without releasing its tasks, as if
+// rigrun had been killed, and lets its heartbeat go stale.
+func crash(t *testing.T, crashed, survivor *Store) {
+	t.Helper()
+	crashed.db.Close()
+	if _, err := survivor.db.Exec("UPDATE owners SET heartbeat_at = 0 WHERE id = ?", crashed.owner); err != nil {
+		t.Fatalf("Failed to age heartbeat: %v", err)
+	}
+}
+
+func TestStoreRestoresTasksAfterCrash(t *testing.T) {
+	dir := t.TempDir()
+
+	// First session: one task waits, one is halfway through
+	first := openTestStore(t, dir)
+	q := NewQueue(0)
+	if err := q.AttachStore(first); err != nil {
+		t.Fatalf("AttachStore failed: %v", err)
+	}
+	waiting := NewTask("Waiting", "sleep", []string{"1"})
+	running := NewTask("Running", "bash", []string{"make"})
+	running.ConversationID = "conv-1"
+	q.Add(waiting)
+	q.Add(running)
+	q.MarkRunning(running)
+	running.AppendOutput("partial output\n")
+	if err := q.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	// Second session, after the first was killed
+	second := openTestStore(t, dir)
+	crash(t, first, second)
+	q2 := NewQueue(0)
+	if err := q2.AttachStore(second); err != nil {
+		t.Fatalf("AttachStore failed: %v", err)
+	}
+
+	if task := q2.Get(waiting.ID); task == nil || task.GetStatus() != TaskStatusQueued {
+		t.Fatalf("Expected the waiting task to be queued again, got %+v", task)
+	}
+	task := q2.Get(running.ID)
+	if task == nil || task.GetStatus() != TaskStatusInterrupted {
+		t.Fatalf("Expected the running task to be interrupted, got %+v", task)
+	}
+	if task.GetOutput() != "partial output\n" {
+		t.Errorf("Expected partial output to be kept, got %q", task.GetOutput())
+	}
+	notif := waitForNotification(t, q2, TaskStatusInterrupted)
+	if notif.TaskID != running.ID || notif.ConversationID != "conv-1" {
+		t.Errorf("Unexpected notification: %+v", notif)
+	}
+}
+
+func TestStoreLeavesLiveSessionsAlone(t *testing.T) {
+	dir := t.TempDir()
+	first := openTestStore(t, dir)
+	q := NewQueue(0)
+	q.AttachStore(first)
+	task := NewTask("Running", "bash", []string{"make"})
+	q.Add(task)
+	q.MarkRunning(task)
+
+	second := openTestStore(t, dir)
+	queued, interrupted, err := second.Claim()
+	if err != nil {
+		t.Fatalf("Claim failed: %v", err)
+	}
+	if len(queued) != 0 || len(interrupted) != 0 {
+		t.Errorf("Expected no tasks taken from a live session, got %d queued, %d interrupted", len(queued), len(interrupted))
+	}
+}
+
+func TestStoreCapsOutputChunks(t *testing.T) {
+	store := openTestStore(t, t.TempDir())
+	q := NewQueue(0)
+	q.AttachStore(store)
+	task := NewTask("Noisy", "bash", []string{"yes"})
+	q.Add(task)
+
+	// Chunks are capped in size, and only the newest are kept
+	line := strings.Repeat("x", 1023) + "\n"
+	for i := 0; i < (MaxOutputChunks+4)*OutputChunkSize/len(line); i++ {
+		task.AppendOutput(line)
+	}
+	task.FlushOutput()
+
+	if len(task.GetOutput()) > MaxOutputInMemory {
+		t.Errorf("Expected at most %d bytes in memory, got %d", MaxOutputInMemory, len(task.GetOutput()))
+	}
+
+	chunks, size := 0, 0
+	truncated, err := store.WriteOutput(task.ID, func(chunk string) error {
+		if len(chunk) > OutputChunkSize {
+			t.Fatalf("Chunk of %d bytes exceeds %d", len(chunk), OutputChunkSize)
+		}
+		chunks++
+		size += len(chunk)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WriteOutput failed: %v", err)
+	}
+	if !truncated || chunks != MaxOutputChunks || size != MaxOutputChunks*OutputChunkSize {
+		t.Errorf("Expected %d full chunks after truncation, got %d chunks, %d bytes, truncated=%v", MaxOutputChunks, chunks, size, truncated)
+	}
+}
+
+func TestStoreCancelAndRetry(t *testing.T) {
+	dir := t.TempDir()
+	session := openTestStore(t, dir)
+	q := NewQueue(0)
+	q.AttachStore(session)
+	waiting := NewTask("Waiting", "sleep", []string{"1"})
+	running := NewTask("Running", "sleep", []string{"60"})
+	q.Add(waiting)
+	q.Add(running)
+	q.MarkRunning(running)
+
+	// The CLI cancels both; the live session holds them, so it cancels
+	// them on Sync
+	cli := openTestStore(t, dir)
+	if task, err := cli.RequestCancel(waiting.ID[:8]); err != nil || task.Status != TaskStatusQueued {
+		t.Fatalf("Expected a cancel request for the queued task, got %v, %v", task, err)
+	}
+	if _, err := cli.RequestCancel(running.ID); err != nil {
+		t.Fatalf("RequestCancel failed: %v", err)
+	}
+	if err := q.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if waiting.GetStatus() != TaskStatusCanceled {
+		t.Errorf("Expected the queued task to be canceled, got %s", waiting.GetStatus())
+	}
+	if running.GetStatus() != TaskStatusCanceled {
+		t.Errorf("Expected the running task to be canceled, got %s", running.GetStatus())
+	}
+	q.MarkCanceled(running)
+	if stored, err := cli.Load(waiting.ID); err != nil || stored.Status != TaskStatusCanceled {
+		t.Errorf("Expected the stored queued task to be canceled, got %v, %v", stored, err)
+	}
+
+	// A finished task is not revived by a stale in-memory copy
+	stale := waiting.Clone()
+	stale.Status = TaskStatusRunning
+	if err := session.Save(stale); err == nil {
+		t.Error("Expected saving over a finished task to fail")
+	}
+	if stored, _ := cli.Load(waiting.ID); stored.Status != TaskStatusCanceled {
+		t.Errorf("Expected the stored task to stay canceled, got %s", stored.Status)
+	}
+
+	// Without a live session holding it, a queued task is canceled at once
+	orphan := NewTask("Orphan", "sleep", []string{"1"})
+	if err := cli.Save(orphan); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if task, err := cli.RequestCancel(orphan.ID); err != nil || task.Status != TaskStatusCanceled {
+		t.Errorf("Expected the orphaned task to be canceled, got %v, %v", task, err)
+	}
+
+	// Retrying queues a copy that the session picks up
+	retry, err := cli.Retry(running.ID)
+	if err != nil {
+		t.Fatalf("Retry failed: %v", err)
+	}
+	if err := q.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	task := q.Get(retry.ID)
+	if task == nil || task.GetStatus() != TaskStatusQueued || task.Metadata["retry_of"] != running.ID {
+		t.Fatalf("Expected the retry to be queued, got %+v", task)
+	}
+	if _, err := cli.Retry(retry.ID); err == nil {
+		t.Error("Expected retrying an unfinished task to fail")
+	}
+}
+
+func TestStoreEncryptsTaskContent(t *testing.T) {
+	const secret = "Rotate the quartermaster passphrase before Tuesday"
+	security.SetDataCipher(securitytest.NewEncryptionManager(t))
+	t.Cleanup(func() { security.SetDataCipher(nil) })
+
+	dir := t.TempDir()
+	store := openTestStore(t, dir)
+	task := NewTask(secret, "ask", []string{secret})
+	task.Result = secret
+	if err := store.Save(task); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := store.AppendOutput(task.ID, 0, secret); err != nil {
+		t.Fatalf("AppendOutput failed: %v", err)
+	}
+
+	status, err := store.EncryptionStatus()
+	if err != nil {
+		t.Fatalf("EncryptionStatus failed: %v", err)
+	}
+	if status.Items == 0 || status.Encrypted != status.Items {
+		t.Errorf("Expected every value encrypted, got %d of %d", status.Encrypted, status.Items)
+	}
+	if _, err := store.db.Exec("PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, StoreFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), secret) {
+		t.Error("Task content was written in plaintext")
+	}
+
+	loaded, err := store.Load(task.ID)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded.Description != secret || loaded.Result != secret || len(loaded.Args) != 1 || loaded.Args[0] != secret {
+		t.Errorf("Expected decrypted content, got %+v", loaded)
+	}
+	var output string
+	if _, err := store.WriteOutput(task.ID, func(chunk string) error {
+		output += chunk
+		return nil
+	}); err != nil {
+		t.Fatalf("WriteOutput failed: %v", err)
+	}
+	if output != secret {
+		t.Errorf("Expected decrypted output, got %q", output)
+	}
+}
+
+func TestStorePurgeKeepsUnfinishedTasks(t *testing.T) {
+	store := openTestStore(t, t.TempDir())
+	queued := NewTask("Waiting", "sleep", []string{"1"})
+	done := NewTask("Done", "bash", []string{"true"})
+	done.Status = TaskStatusComplete
+	done.EndTime = time.Now()
+	for _, task := range []*Task{queued, done} {
+		if err := store.Save(task); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+	if err := store.AppendOutput(done.ID, 0, "output\n"); err != nil {
+		t.Fatalf("AppendOutput failed: %v", err)
+	}
+
+	engine := &security.RetentionEngine{
+		Policies: map[string]security.RetentionPolicy{security.RetentionTasks: {MaxAge: time.Hour}},
+		Now:      func() time.Time { return time.Now().Add(2 * time.Hour) },
+	}
+	report, err := engine.Run([]security.RetainedStore{store}, false)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if report.Purged() != 1 {
+		t.Errorf("Expected the finished task purged, got %+v", report.Results)
+	}
+	if _, err := store.Load(done.ID); err == nil {
+		t.Error("Expected the finished task gone")
+	}
+	var outputRows int
+	store.db.QueryRow("SELECT COUNT(*) FROM task_output").Scan(&outputRows)
+	if outputRows != 0 {
+		t.Errorf("Expected the purged output gone, got %d rows", outputRows)
+	}
+	if _, err := store.Load(queued.ID); err != nil {
+		t.Errorf("Expected the queued task kept, got %v", err)
+	}
+}
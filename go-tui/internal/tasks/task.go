@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 )
@@ -35,6 +36,24 @@ const (
 
 	// TaskStatusCanceled indicates the task was canceled by the user
 	TaskStatusCanceled TaskStatus = "Canceled"
+
+	// TaskStatusInterrupted indicates rigrun exited while the task was
+	// running; its output up to that point is kept
+	TaskStatusInterrupted TaskStatus = "Interrupted"
+)
+
+// =============================================================================
+// TASK OUTPUT LIMITS
+// =============================================================================
+
+const (
+	// OutputChunkSize is the size of the chunks in which output is handed
+	// to the store (see Store.AppendOutput)
+	OutputChunkSize = 16 * 1024
+
+	// MaxOutputInMemory is how much of a task's output is kept in memory.
+	// Earlier output is dropped from Output; with a store it stays on disk.
+	MaxOutputInMemory = 64 * 1024
 )
 
 // String returns the string representation of the task status.
@@ -63,13 +82,17 @@ type Task struct {
 	// Status is the current state of the task
 	Status TaskStatus
 
+	// CreatedAt is when the task was created
+	CreatedAt time.Time
+
 	// StartTime is when the task started running
 	StartTime time.Time
 
 	// EndTime is when the task completed or failed
 	EndTime time.Time
 
-	// Output is the standard output from the task
+	// Output is the standard output from the task, capped to the last
+	// MaxOutputInMemory bytes
 	Output string
 
 	// Error is the error message if the task failed
@@ -84,6 +107,22 @@ type Task struct {
 	// Metadata stores additional task-specific data
 	Metadata map[string]interface{}
 
+	// Step describes what an agent task is currently doing
+	Step string
+
+	// Result is an agent task's summary of its work, posted to the
+	// originating conversation when the task finishes
+	Result string
+
+	// agent is the work of an agent task (Command == CommandAgent)
+	agent AgentFunc
+
+	// outputSink receives output in chunks as it is produced, numbered from
+	// 1 by seq; pending holds output not yet handed over
+	outputSink func(seq int, data string)
+	pending    string
+	seq        int
+
 	// cancel is the context cancel function for this task
 	cancel context.CancelFunc
 
@@ -103,6 +142,7 @@ func NewTask(description, command string, args []string) *Task {
 		Command:     command,
 		Args:        args,
 		Status:      TaskStatusQueued,
+		CreatedAt:   time.Now(),
 		Metadata:    make(map[string]interface{}),
 	}
 }
@@ -140,9 +180,10 @@ func (t *Task) isValidTransition(from, to TaskStatus) bool {
 		// Queued can transition to Running or Canceled
 		return to == TaskStatusRunning || to == TaskStatusCanceled
 	case TaskStatusRunning:
-		// Running can transition to Complete, Failed, or Canceled
-		return to == TaskStatusComplete || to == TaskStatusFailed || to == TaskStatusCanceled
-	case TaskStatusComplete, TaskStatusFailed, TaskStatusCanceled:
+		// Running can transition to Complete, Failed, Canceled, or Interrupted
+		return to == TaskStatusComplete || to == TaskStatusFailed || to == TaskStatusCanceled ||
+			to == TaskStatusInterrupted
+	case TaskStatusComplete, TaskStatusFailed, TaskStatusCanceled, TaskStatusInterrupted:
 		// Terminal states - no transitions allowed
 		return false
 	default:
@@ -177,11 +218,85 @@ func (t *Task) GetProgress() int {
 	return t.Progress
 }
 
-// AppendOutput appends text to the task output (thread-safe).
+// AppendOutput appends text to the task output (thread-safe). Only the
+// last MaxOutputInMemory bytes are kept in Output; with an output sink the
+// text is also handed over in chunks of OutputChunkSize.
 func (t *Task) AppendOutput(output string) {
+	t.mu.Lock()
+	t.Output = tailBytes(t.Output+output, MaxOutputInMemory)
+	sink := t.outputSink
+	var chunks []string
+	var first int
+	if sink != nil {
+		t.pending += output
+		for len(t.pending) >= OutputChunkSize {
+			n := chunkEnd(t.pending, OutputChunkSize)
+			chunks = append(chunks, t.pending[:n])
+			t.pending = t.pending[n:]
+		}
+		// Number the chunks under the lock so they are stored in order
+		first = t.seq + 1
+		t.seq += len(chunks)
+	}
+	t.mu.Unlock()
+
+	for i, chunk := range chunks {
+		sink(first+i, chunk)
+	}
+}
+
+// FlushOutput hands any buffered output to the output sink.
+func (t *Task) FlushOutput() {
+	t.mu.Lock()
+	sink := t.outputSink
+	if sink == nil || t.pending == "" {
+		t.mu.Unlock()
+		return
+	}
+	chunk := t.pending
+	t.pending = ""
+	t.seq++
+	seq := t.seq
+	t.mu.Unlock()
+
+	sink(seq, chunk)
+}
+
+// setOutputSink sets the function receiving the task's output chunks.
+// seq is the number of chunks already stored.
+func (t *Task) setOutputSink(sink func(seq int, data string), seq int) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
-	t.Output += output
+	t.outputSink = sink
+	t.seq = seq
+}
+
+// tailBytes returns the last max bytes of s, starting on a rune boundary.
+func tailBytes(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	start := len(s) - max
+	for start < len(s) && !utf8.RuneStart(s[start]) {
+		start++
+	}
+	return s[start:]
+}
+
+// chunkEnd returns the length of the first chunk of s, at most max bytes
+// and ending on a rune boundary.
+func chunkEnd(s string, max int) int {
+	if len(s) <= max {
+		return len(s)
+	}
+	end := max
+	for end > 0 && !utf8.RuneStart(s[end]) {
+		end--
+	}
+	if end == 0 {
+		return max
+	}
+	return end
 }
 
 // GetOutput returns the current output (thread-safe).
@@ -191,6 +306,34 @@ func (t *Task) GetOutput() string {
 	return t.Output
 }
 
+// SetStep records what the task is currently doing (thread-safe).
+func (t *Task) SetStep(step string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Step = step
+}
+
+// GetStep returns what the task is currently doing (thread-safe).
+func (t *Task) GetStep() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.Step
+}
+
+// SetResult sets the task's summary of its work (thread-safe).
+func (t *Task) SetResult(result string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Result = result
+}
+
+// GetResult returns the task's summary of its work (thread-safe).
+func (t *Task) GetResult() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.Result
+}
+
 // SetError sets the error message and marks the task as failed (thread-safe).
 // This bypasses status transition validation for internal use.
 func (t *Task) SetError(err error) {
@@ -229,6 +372,19 @@ func (t *Task) MarkComplete() {
 	t.Progress = 100
 }
 
+// MarkInterrupted marks the task as interrupted by an exit and stops its
+// work (thread-safe). This bypasses status transition validation for
+// internal use.
+func (t *Task) MarkInterrupted() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Status = TaskStatusInterrupted
+	t.EndTime = time.Now()
+	if t.cancel != nil {
+		t.cancel()
+	}
+}
+
 // MarkCanceled marks the task as canceled (thread-safe).
 // This bypasses status transition validation for internal use.
 func (t *Task) MarkCanceled() {
@@ -293,7 +449,8 @@ func (t *Task) IsRunning() bool {
 // IsComplete returns true if the task has finished (success, failure, or canceled).
 func (t *Task) IsComplete() bool {
 	status := t.GetStatus()
-	return status == TaskStatusComplete || status == TaskStatusFailed || status == TaskStatusCanceled
+	return status == TaskStatusComplete || status == TaskStatusFailed || status == TaskStatusCanceled ||
+		status == TaskStatusInterrupted
 }
 
 // Summary returns a one-line summary of the task.
@@ -335,6 +492,7 @@ func (t *Task) Clone() *Task {
 		Command:        t.Command,
 		Args:           append([]string{}, t.Args...),
 		Status:         t.Status,
+		CreatedAt:      t.CreatedAt,
 		StartTime:      t.StartTime,
 		EndTime:        t.EndTime,
 		Output:         t.Output,
@@ -342,5 +500,7 @@ func (t *Task) Clone() *Task {
 		Progress:       t.Progress,
 		ConversationID: t.ConversationID,
 		Metadata:       metadata,
+		Step:           t.Step,
+		Result:         t.Result,
 	}
 }
@@ -0,0 +1,215 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package telemetry provides cost tracking and analytics for rigrun.
+//
+// This file implements spend budgets: the cloud spend of the current
+// session, day, week and month measured against configured limits.
+package telemetry
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jeranaias/rigrun-tui/internal/router"
+)
+
+// =============================================================================
+// BUDGETS
+// =============================================================================
+
+// Budget periods.
+const (
+	BudgetSession = "session"
+	BudgetDaily   = "daily"
+	BudgetWeekly  = "weekly"
+	BudgetMonthly = "monthly"
+)
+
+// spendDayLayout is the date format of SessionCost.SpendByDay keys.
+const spendDayLayout = "2006-01-02"
+
+// maxSessionSpan bounds how long before a budget period a stored session
+// may have started and still have spend inside the period.
+const maxSessionSpan = 7 * 24 * time.Hour
+
+// Budget caps cloud spend in dollars per period. Zero limits are not applied.
+type Budget struct {
+	Session float64
+	Daily   float64
+	Weekly  float64
+	Monthly float64
+
+	// WarnPercent is the share of a budget at which to warn (0 = once spent)
+	WarnPercent float64
+}
+
+// IsZero reports whether the budget sets no limits.
+func (b Budget) IsZero() bool {
+	return b.Session <= 0 && b.Daily <= 0 && b.Weekly <= 0 && b.Monthly <= 0
+}
+
+// BudgetUsage is the spend against one budget.
+type BudgetUsage struct {
+	Period string    `json:"period"`
+	Since  time.Time `json:"since"`
+	Limit  float64   `json:"limit"` // In dollars
+	Spent  float64   `json:"spent"` // In dollars
+}
+
+// Percent returns the share of the budget spent.
+func (u BudgetUsage) Percent() float64 {
+	if u.Limit <= 0 {
+		return 0
+	}
+	return u.Spent / u.Limit * 100
+}
+
+// Exceeded reports whether the budget is spent.
+func (u BudgetUsage) Exceeded() bool {
+	return u.Limit > 0 && u.Spent >= u.Limit
+}
+
+// String describes the usage, such as "daily budget of $5.00 spent ($5.12)".
+func (u BudgetUsage) String() string {
+	if u.Exceeded() {
+		return fmt.Sprintf("%s budget of $%.2f spent ($%.2f)", u.Period, u.Limit, u.Spent)
+	}
+	return fmt.Sprintf("%s budget %.0f%% spent ($%.2f of $%.2f)", u.Period, u.Percent(), u.Spent, u.Limit)
+}
+
+// BudgetStatus is the spend against each configured budget.
+type BudgetStatus struct {
+	Usage       []BudgetUsage `json:"usage"`
+	WarnPercent float64       `json:"warn_percent"`
+}
+
+// Exceeded returns the first exceeded budget, or nil if there is budget left.
+func (s BudgetStatus) Exceeded() *BudgetUsage {
+	for i := range s.Usage {
+		if s.Usage[i].Exceeded() {
+			return &s.Usage[i]
+		}
+	}
+	return nil
+}
+
+// Warning returns the budget closest to its limit once it is past the
+// warning threshold or spent, or nil.
+func (s BudgetStatus) Warning() *BudgetUsage {
+	var warn *BudgetUsage
+	for i := range s.Usage {
+		u := &s.Usage[i]
+		due := u.Exceeded() || (s.WarnPercent > 0 && u.Percent() >= s.WarnPercent)
+		if due && (warn == nil || u.Percent() > warn.Percent()) {
+			warn = u
+		}
+	}
+	return warn
+}
+
+// BudgetStatus returns the spend against the budgets of b. Spend of the
+// current session is counted with that of the stored sessions, including
+// those of other rigrun processes.
+func (ct *CostTracker) BudgetStatus(b Budget) BudgetStatus {
+	return ct.budgetStatusAt(b, time.Now())
+}
+
+// budgetStatusAt returns the budget status as of now.
+func (ct *CostTracker) budgetStatusAt(b Budget, now time.Time) BudgetStatus {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	week := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7)) // Monday
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	current := ct.GetCurrentSession()
+	periods := []BudgetUsage{
+		{Period: BudgetSession, Since: current.StartTime, Limit: b.Session},
+		{Period: BudgetDaily, Since: day, Limit: b.Daily},
+		{Period: BudgetWeekly, Since: week, Limit: b.Weekly},
+		{Period: BudgetMonthly, Since: month, Limit: b.Monthly},
+	}
+
+	// Stored sessions are only loaded when a calendar budget needs them.
+	// Session IDs carry local time, hence the day of slack past now.
+	var stored []*SessionCost
+	if b.Daily > 0 || b.Weekly > 0 || b.Monthly > 0 {
+		earliest := week
+		if month.Before(earliest) {
+			earliest = month
+		}
+		stored = ct.GetHistory(earliest.Add(-maxSessionSpan), now.Add(24*time.Hour))
+	}
+
+	status := BudgetStatus{WarnPercent: b.WarnPercent}
+	for _, usage := range periods {
+		if usage.Limit <= 0 {
+			continue
+		}
+		if usage.Period == BudgetSession {
+			usage.Spent = current.TotalCost
+		} else {
+			usage.Spent = spendSince(current, usage.Since)
+			for _, session := range stored {
+				if session.ID != current.ID {
+					usage.Spent += spendSince(session, usage.Since)
+				}
+			}
+		}
+		status.Usage = append(status.Usage, usage)
+	}
+	return status
+}
+
+// spendSince returns the spend of a session from the start of a day.
+func spendSince(session *SessionCost, since time.Time) float64 {
+	if session.SpendByDay == nil {
+		// Sessions recorded before spend was split by day count toward
+		// the day they started
+		if session.StartTime.Before(since) {
+			return 0
+		}
+		return session.TotalCost
+	}
+
+	from := since.Format(spendDayLayout)
+	var spent float64
+	for day, spend := range session.SpendByDay {
+		if day >= from {
+			spent += spend
+		}
+	}
+	return spent
+}
+
+// =============================================================================
+// ROUTER INTEGRATION
+// =============================================================================
+
+// Compile-time check that BudgetGuard is a router spend budget.
+var _ router.SpendBudget = (*BudgetGuard)(nil)
+
+// BudgetGuard checks the spend recorded by a cost tracker against a budget
+// before the router chooses a paid tier.
+type BudgetGuard struct {
+	Tracker *CostTracker
+	Budget  Budget
+}
+
+// ExceededBudget describes the first exceeded budget, or returns "".
+func (g *BudgetGuard) ExceededBudget() string {
+	if g == nil || g.Tracker == nil || g.Budget.IsZero() {
+		return ""
+	}
+	if exceeded := g.Tracker.BudgetStatus(g.Budget).Exceeded(); exceeded != nil {
+		return exceeded.String()
+	}
+	return ""
+}
+
+// Status returns the spend against the budget.
+func (g *BudgetGuard) Status() BudgetStatus {
+	if g == nil || g.Tracker == nil {
+		return BudgetStatus{}
+	}
+	return g.Tracker.BudgetStatus(g.Budget)
+}
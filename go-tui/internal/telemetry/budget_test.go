@@ -0,0 +1,90 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package telemetry
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCostTracker_BudgetStatus(t *testing.T) {
+	tracker, err := NewCostTracker(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewCostTracker failed: %v", err)
+	}
+
+	// Wednesday; the week began on Monday June 2
+	now := time.Date(2025, 6, 4, 12, 0, 0, 0, time.Local)
+	current := tracker.sessions[tracker.currentID]
+	current.StartTime = now.Add(-2 * time.Hour)
+	current.TotalCost = 0.3
+	current.SpendByDay = map[string]float64{"2025-06-04": 0.3}
+
+	stored := []*SessionCost{
+		// The current session on disk is not counted twice
+		tracker.copySession(current),
+		// Ran past midnight: only the spend after it is today's
+		{ID: "20250603-230000-1", StartTime: time.Date(2025, 6, 3, 23, 0, 0, 0, time.Local), TotalCost: 1.5,
+			SpendByDay: map[string]float64{"2025-06-03": 1.0, "2025-06-04": 0.5}},
+		// Recorded before spend was split by day
+		{ID: "20250602-100000-1", StartTime: time.Date(2025, 6, 2, 10, 0, 0, 0, time.Local), TotalCost: 2.0},
+		{ID: "20250530-100000-1", StartTime: time.Date(2025, 5, 30, 10, 0, 0, 0, time.Local), TotalCost: 4.0},
+	}
+	for _, session := range stored {
+		if err := tracker.storage.Save(session); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+
+	status := tracker.budgetStatusAt(Budget{Session: 1, Daily: 1, Weekly: 10, Monthly: 4, WarnPercent: 80}, now)
+	want := map[string]float64{
+		BudgetSession: 0.3,
+		BudgetDaily:   0.8,
+		BudgetWeekly:  3.8,
+		BudgetMonthly: 3.8,
+	}
+	if len(status.Usage) != len(want) {
+		t.Fatalf("got %d budgets, want %d", len(status.Usage), len(want))
+	}
+	for _, usage := range status.Usage {
+		if math.Abs(usage.Spent-want[usage.Period]) > 1e-9 {
+			t.Errorf("%s spend = %.2f, want %.2f", usage.Period, usage.Spent, want[usage.Period])
+		}
+	}
+
+	if exceeded := status.Exceeded(); exceeded != nil {
+		t.Errorf("no budget should be exceeded, got %s", exceeded)
+	}
+	if warn := status.Warning(); warn == nil || warn.Period != BudgetMonthly {
+		t.Errorf("expected a warning for the monthly budget (95%%), got %v", warn)
+	}
+
+	status = tracker.budgetStatusAt(Budget{Monthly: 3.5}, now)
+	if exceeded := status.Exceeded(); exceeded == nil || exceeded.Period != BudgetMonthly {
+		t.Errorf("expected the monthly budget exceeded, got %v", exceeded)
+	}
+	if warn := status.Warning(); warn == nil || warn.Period != BudgetMonthly {
+		t.Errorf("expected a warning for the spent budget without a threshold, got %v", warn)
+	}
+}
+
+func TestCostTracker_RecordQueryTierPricing(t *testing.T) {
+	tracker, err := NewCostTracker(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewCostTracker failed: %v", err)
+	}
+
+	tracker.RecordQuery("Opus", 1000, 1000, time.Second, "prompt")
+	tracker.RecordQuery("local", 1000, 1000, time.Second, "prompt")
+
+	session := tracker.GetCurrentSession()
+	// Opus: 1.5 + 7.5 cents per 1K tokens
+	if math.Abs(session.TotalCost-0.09) > 1e-9 {
+		t.Errorf("TotalCost = %f, want 0.09 priced at the Opus tier", session.TotalCost)
+	}
+	if spend := session.SpendByDay[time.Now().Format(spendDayLayout)]; math.Abs(spend-0.09) > 1e-9 {
+		t.Errorf("today's spend = %f, want 0.09", spend)
+	}
+}
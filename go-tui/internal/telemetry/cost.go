@@ -6,6 +6,7 @@ package telemetry
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -43,6 +44,10 @@ type SessionCost struct {
 	TotalCost float64 `json:"total_cost"` // In dollars
 	Savings   float64 `json:"savings"`    // vs all-cloud pricing
 
+	// SpendByDay is TotalCost split by local date (2006-01-02), so that a
+	// session running past midnight counts toward the right day's budget
+	SpendByDay map[string]float64 `json:"spend_by_day,omitempty"`
+
 	// Top queries
 	TopQueries []QueryCost `json:"top_queries"`
 }
@@ -141,14 +146,24 @@ func (ct *CostTracker) RecordQuery(tier string, inputTokens, outputTokens int, d
 		session.LocalTokens.Input += inputTokens
 		session.LocalTokens.Output += outputTokens
 	default:
-		// Assume cloud for anything else
+		// Assume cloud for anything else, priced by its tier when known
 		tierEnum = router.TierCloud
+		if parsed, ok := router.ParseTier(strings.ToLower(tier)); ok && !parsed.IsLocal() {
+			tierEnum = parsed
+		}
 		session.CloudTokens.Input += inputTokens
 		session.CloudTokens.Output += outputTokens
 	}
 
+	now := time.Now()
 	cost = tierEnum.CalculateCostCents(uint32(inputTokens), uint32(outputTokens)) / 100.0 // Convert cents to dollars
 	session.TotalCost += cost
+	if cost > 0 {
+		if session.SpendByDay == nil {
+			session.SpendByDay = make(map[string]float64)
+		}
+		session.SpendByDay[now.Format(spendDayLayout)] += cost
+	}
 
 	// Calculate savings vs all-cloud
 	opusCost := router.TierOpus.CalculateCostCents(uint32(inputTokens), uint32(outputTokens)) / 100.0
@@ -156,7 +171,7 @@ func (ct *CostTracker) RecordQuery(tier string, inputTokens, outputTokens int, d
 
 	// Record query cost
 	queryCost := QueryCost{
-		Timestamp:    time.Now(),
+		Timestamp:    now,
 		Prompt:       prompt,
 		Tier:         tier,
 		InputTokens:  inputTokens,
@@ -361,6 +376,12 @@ func (ct *CostTracker) copySession(src *SessionCost) *SessionCost {
 		TopQueries:  make([]QueryCost, len(src.TopQueries)),
 	}
 	copy(dst.TopQueries, src.TopQueries)
+	if src.SpendByDay != nil {
+		dst.SpendByDay = make(map[string]float64, len(src.SpendByDay))
+		for day, spend := range src.SpendByDay {
+			dst.SpendByDay[day] = spend
+		}
+	}
 	return dst
 }
 
@@ -10,17 +10,24 @@ import (
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/jeranaias/rigrun-tui/internal/security"
 )
 
 // =============================================================================
 // COST STORAGE
 // =============================================================================
 
-// CostStorage persists cost data to disk.
+// CostStorage persists cost data to disk. Session files hold query prompts,
+// so they are encrypted with the data cipher when one is set.
 type CostStorage struct {
-	dir string
+	dir    string
+	cipher security.FieldCipher // Seals session files; nil writes plaintext
 }
 
+// Compile-time check that CostStorage is a protected store.
+var _ security.ProtectedStore = (*CostStorage)(nil)
+
 // NewCostStorage creates a new cost storage manager.
 func NewCostStorage(dir string) (*CostStorage, error) {
 	// Default to ~/.rigrun/costs/
@@ -37,7 +44,7 @@ func NewCostStorage(dir string) (*CostStorage, error) {
 		return nil, err
 	}
 
-	return &CostStorage{dir: dir}, nil
+	return &CostStorage{dir: dir, cipher: security.DataCipher()}, nil
 }
 
 // =============================================================================
@@ -59,8 +66,14 @@ func (cs *CostStorage) Save(session *SessionCost) error {
 		return err
 	}
 
+	// SC-28: Encrypt the session, prompts included
+	sealed, err := security.SealField(cs.cipher, string(data))
+	if err != nil {
+		return err
+	}
+
 	// Write to file
-	return os.WriteFile(filename, data, 0644)
+	return os.WriteFile(filename, []byte(sealed), 0644)
 }
 
 // Load retrieves a session cost from disk.
@@ -72,10 +85,14 @@ func (cs *CostStorage) Load(sessionID string) (*SessionCost, error) {
 	if err != nil {
 		return nil, err
 	}
+	content, err := security.OpenField(cs.cipher, string(data))
+	if err != nil {
+		return nil, err
+	}
 
 	// Unmarshal JSON
 	var session SessionCost
-	if err := json.Unmarshal(data, &session); err != nil {
+	if err := json.Unmarshal([]byte(content), &session); err != nil {
 		return nil, err
 	}
 
@@ -137,7 +154,8 @@ func (cs *CostStorage) Delete(sessionID string) error {
 	return os.Remove(filename)
 }
 
-// DeleteBefore removes all session cost files older than the specified date.
+// DeleteBefore securely deletes all session cost files older than the
+// specified date.
 func (cs *CostStorage) DeleteBefore(before time.Time) error {
 	entries, err := os.ReadDir(cs.dir)
 	if err != nil {
@@ -169,7 +187,7 @@ func (cs *CostStorage) DeleteBefore(before time.Time) error {
 
 		if timestamp.Before(before) {
 			filename := filepath.Join(cs.dir, name)
-			os.Remove(filename) // Ignore errors
+			security.GlobalSanitizer().SecureDeleteFile(filename) // Ignore errors
 		}
 	}
 
@@ -216,3 +234,87 @@ func (cs *CostStorage) Count() (int, error) {
 
 	return count, nil
 }
+
+// =============================================================================
+// RETENTION
+// =============================================================================
+
+// Compile-time check that CostStorage is a retained store.
+var _ security.RetainedStore = (*CostStorage)(nil)
+
+// RetentionClass returns the data class of cost history.
+func (cs *CostStorage) RetentionClass() string {
+	return security.RetentionCosts
+}
+
+// RetentionItems lists the session files.
+func (cs *CostStorage) RetentionItems() ([]security.RetentionItem, error) {
+	return security.RetentionFiles(cs.dir, isSessionFile)
+}
+
+// Purge securely deletes session files.
+func (cs *CostStorage) Purge(items []security.RetentionItem) error {
+	return security.PurgeFiles(cs.dir, items)
+}
+
+// isSessionFile reports whether a file name is that of a session file.
+func isSessionFile(name string) bool {
+	return strings.HasSuffix(name, ".json")
+}
+
+// =============================================================================
+// ENCRYPTION
+// =============================================================================
+
+// EncryptionStatus counts the session files and how many are encrypted.
+func (cs *CostStorage) EncryptionStatus() (security.StoreStatus, error) {
+	status := security.StoreStatus{Name: "Cost history", Path: cs.dir}
+	paths, err := cs.sessionFiles()
+	if err != nil {
+		return status, err
+	}
+	for _, path := range paths {
+		sealed, err := security.IsFileSealed(path)
+		if err != nil {
+			return status, err
+		}
+		status.Items++
+		if sealed {
+			status.Encrypted++
+		}
+	}
+	return status, nil
+}
+
+// Reencrypt replaces each session file with recrypt's result.
+func (cs *CostStorage) Reencrypt(recrypt security.Recrypt) error {
+	paths, err := cs.sessionFiles()
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if err := security.ReencryptFile(path, recrypt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sessionFiles returns the paths of the session files.
+func (cs *CostStorage) sessionFiles() ([]string, error) {
+	entries, err := os.ReadDir(cs.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			paths = append(paths, filepath.Join(cs.dir, entry.Name()))
+		}
+	}
+	return paths, nil
+}
@@ -4,10 +4,14 @@
 package telemetry
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
 	"testing"
 	"time"
+
+	"github.com/jeranaias/rigrun-tui/internal/security"
+	"github.com/jeranaias/rigrun-tui/internal/security/securitytest"
 )
 
 func TestCostTracker_NewCostTracker(t *testing.T) {
@@ -445,3 +449,49 @@ func TestCostStorage_DefaultDirectory(t *testing.T) {
 		t.Errorf("default dir: got %s, want %s", storage.dir, expectedDir)
 	}
 }
+
+func TestCostStorage_Encrypted(t *testing.T) {
+	tmpDir := t.TempDir()
+	storage, err := NewCostStorage(tmpDir)
+	if err != nil {
+		t.Fatalf("NewCostStorage failed: %v", err)
+	}
+	const prompt = "Summarize the quartermaster passphrase memo"
+
+	// A session saved before encryption was enabled
+	plain := &SessionCost{ID: "20250301-120000", TopQueries: []QueryCost{{Prompt: prompt}}}
+	if err := storage.Save(plain); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	c := securitytest.NewEncryptionManager(t)
+	storage.cipher = c
+	sealed := &SessionCost{ID: "20250302-120000", TopQueries: []QueryCost{{Prompt: prompt}}}
+	if err := storage.Save(sealed); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if status, _ := storage.EncryptionStatus(); status.Items != 2 || status.Encrypted != 1 {
+		t.Errorf("Expected 1 of 2 sessions encrypted, got %+v", status)
+	}
+
+	err = storage.Reencrypt(func(value string) (string, error) {
+		return security.SealField(c, value)
+	})
+	if err != nil {
+		t.Fatalf("Reencrypt failed: %v", err)
+	}
+
+	for _, id := range []string{plain.ID, sealed.ID} {
+		data, err := os.ReadFile(filepath.Join(tmpDir, id+".json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(data, []byte("quartermaster")) {
+			t.Errorf("Found the prompt in plaintext in session %s", id)
+		}
+		loaded, err := storage.Load(id)
+		if err != nil || loaded.TopQueries[0].Prompt != prompt {
+			t.Errorf("Expected session %s decrypted, got %v %v", id, loaded, err)
+		}
+	}
+}
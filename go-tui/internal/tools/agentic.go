@@ -569,6 +569,9 @@ func FormatToolResult(call ToolCallMessage, result Result) string {
 	if result.Truncated {
 		sb.WriteString("\n(output was truncated)")
 	}
+	if result.Sandbox != "" {
+		sb.WriteString(fmt.Sprintf("\nSandbox: %s", result.Sandbox))
+	}
 
 	return sb.String()
 }
@@ -590,6 +593,9 @@ func FormatToolResultJSON(call ToolCallMessage, result Result) (string, error) {
 	if result.Truncated {
 		data["truncated"] = true
 	}
+	if result.Sandbox != "" {
+		data["sandbox"] = result.Sandbox
+	}
 
 	bytes, err := json.Marshal(data)
 	if err != nil {
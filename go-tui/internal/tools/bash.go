@@ -8,6 +8,8 @@ package tools
 import (
 	"bytes"
 	"context"
+	"io"
+	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -427,28 +429,15 @@ type BashExecutor struct {
 
 	// BlockedPatterns are patterns to block
 	BlockedPatterns []string
+
+	// Sandbox optionally runs commands in an OS-level sandbox (Linux only)
+	Sandbox SandboxConfig
 }
 
 // Execute runs a shell command with security restrictions.
 func (e *BashExecutor) Execute(ctx context.Context, params map[string]interface{}) (Result, error) {
 	start := time.Now()
-
-	// Set defaults
-	if e.DefaultTimeout == 0 {
-		e.DefaultTimeout = 30 * time.Second
-	}
-	if e.MaxTimeout == 0 {
-		e.MaxTimeout = 10 * time.Minute
-	}
-	if e.MaxOutputSize == 0 {
-		e.MaxOutputSize = 100000 // 100KB
-	}
-	if len(e.BlockedCommands) == 0 {
-		e.BlockedCommands = DefaultBlockedCommands
-	}
-	if len(e.BlockedPatterns) == 0 {
-		e.BlockedPatterns = DefaultBlockedPatterns
-	}
+	e.applyDefaults()
 
 	// Extract parameters
 	command, _ := params["command"].(string)
@@ -472,37 +461,118 @@ func (e *BashExecutor) Execute(ctx context.Context, params map[string]interface{
 		}, nil
 	}
 
-	// Calculate timeout
-	timeout := time.Duration(timeoutSec) * time.Second
+	// Run in the call's working directory, if the executor set one
+	shell := e
+	if dir := workDirFrom(ctx); dir != "" {
+		local := *e
+		local.WorkDir = dir
+		shell = &local
+	}
+
+	result, _ := shell.run(ctx, command, e.clampTimeout(time.Duration(timeoutSec)*time.Second), nil, nil)
+	return result, nil
+}
+
+// applyDefaults fills in unset limits.
+func (e *BashExecutor) applyDefaults() {
+	if e.DefaultTimeout == 0 {
+		e.DefaultTimeout = 30 * time.Second
+	}
+	if e.MaxTimeout == 0 {
+		e.MaxTimeout = 10 * time.Minute
+	}
+	if e.MaxOutputSize == 0 {
+		e.MaxOutputSize = 100000 // 100KB
+	}
+	if len(e.BlockedCommands) == 0 {
+		e.BlockedCommands = DefaultBlockedCommands
+	}
+	if len(e.BlockedPatterns) == 0 {
+		e.BlockedPatterns = DefaultBlockedPatterns
+	}
+}
+
+// clampTimeout limits a requested timeout to [1s, MaxTimeout].
+func (e *BashExecutor) clampTimeout(timeout time.Duration) time.Duration {
 	if timeout > e.MaxTimeout {
 		timeout = e.MaxTimeout
 	}
 	if timeout < time.Second {
 		timeout = time.Second
 	}
+	return timeout
+}
+
+// commandStreams holds the raw output of a finished command.
+type commandStreams struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+// run executes an already-validated command under the executor's sandbox,
+// environment and output rules. stdin and extraEnv are optional.
+func (e *BashExecutor) run(ctx context.Context, command string, timeout time.Duration, stdin io.Reader, extraEnv []string) (Result, commandStreams) {
+	start := time.Now()
+	streams := commandStreams{ExitCode: -1}
 
 	// Create context with timeout
 	cmdCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	// Create command based on platform
+	// SECURITY: Sanitize environment to prevent injection attacks
+	env := append(sanitizeEnvironment(), extraEnv...)
+
+	// Capture output. Sandboxed commands keep only what can be returned.
+	stdout := limitedBuffer{limit: math.MaxInt}
+	stderr := limitedBuffer{limit: math.MaxInt}
+
+	// Create command based on platform and sandbox mode. A required sandbox
+	// fails closed; auto mode falls back with a warning in the output.
 	var cmd *exec.Cmd
-	if runtime.GOOS == "windows" {
-		cmd = exec.CommandContext(cmdCtx, "cmd", "/C", command)
-	} else {
-		cmd = exec.CommandContext(cmdCtx, "bash", "-c", command)
+	profile := ""
+	warning := ""
+	if e.Sandbox.Mode == SandboxAuto || e.Sandbox.Mode == SandboxRequired {
+		profile = SandboxProfileNone
+		ok, reason := SandboxAvailable()
+		if ok {
+			sandboxed, err := sandboxCommand(cmdCtx, e.Sandbox, e.WorkDir, command, timeout, env)
+			if err != nil {
+				reason = err.Error()
+			} else {
+				cmd = sandboxed
+				profile = e.Sandbox.profile()
+				stdout.limit = e.MaxOutputSize + 1
+				stderr.limit = e.MaxOutputSize + 1
+			}
+		}
+		if cmd == nil {
+			if e.Sandbox.Mode == SandboxRequired {
+				return Result{
+					Success:  false,
+					Error:    "sandbox required but unavailable: " + reason,
+					Duration: time.Since(start),
+					Sandbox:  profile,
+				}, streams
+			}
+			warning = "[WARNING: sandbox unavailable (" + reason + "); command ran unsandboxed]\n"
+		}
 	}
+	if cmd == nil {
+		if runtime.GOOS == "windows" {
+			cmd = exec.CommandContext(cmdCtx, "cmd", "/C", command)
+		} else {
+			cmd = exec.CommandContext(cmdCtx, "bash", "-c", command)
+		}
 
-	// Set working directory
-	if e.WorkDir != "" {
-		cmd.Dir = e.WorkDir
+		// Set working directory
+		if e.WorkDir != "" {
+			cmd.Dir = e.WorkDir
+		}
+		cmd.Env = env
 	}
 
-	// SECURITY: Sanitize environment to prevent injection attacks
-	cmd.Env = sanitizeEnvironment()
-
-	// Capture output
-	var stdout, stderr bytes.Buffer
+	cmd.Stdin = stdin
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
@@ -518,25 +588,31 @@ func (e *BashExecutor) Execute(ctx context.Context, params map[string]interface{
 				Success:  false,
 				Error:    "command timed out after " + formatDuration(timeout),
 				Duration: duration,
-			}, nil
+				Sandbox:  profile,
+			}, streams
 		}
 		if cmdCtx.Err() == context.Canceled {
 			return Result{
 				Success:  false,
 				Error:    "command cancelled",
 				Duration: duration,
-			}, nil
+				Sandbox:  profile,
+			}, streams
 		}
 	default:
 	}
 
 	// Build output
-	output, truncated := e.buildOutput(&stdout, &stderr)
+	output, truncated := e.buildOutput(&stdout.Buffer, &stderr.Buffer)
+	output = warning + output
+	streams.Stdout = stdout.String()
+	streams.Stderr = stderr.String()
 
 	// Check for error
 	if err != nil {
 		errorMsg := "command failed"
 		if exitErr, ok := err.(*exec.ExitError); ok {
+			streams.ExitCode = exitErr.ExitCode()
 			errorMsg = "command exited with code " + util.IntToStr(exitErr.ExitCode())
 		}
 
@@ -547,15 +623,18 @@ func (e *BashExecutor) Execute(ctx context.Context, params map[string]interface{
 			Output:    output,
 			Duration:  duration,
 			Truncated: truncated,
-		}, nil
+			Sandbox:   profile,
+		}, streams
 	}
 
+	streams.ExitCode = 0
 	return Result{
 		Success:   true,
 		Output:    output,
 		Duration:  duration,
 		Truncated: truncated,
-	}, nil
+		Sandbox:   profile,
+	}, streams
 }
 
 // =============================================================================
@@ -862,7 +941,7 @@ func (e *BashExecutor) buildOutput(stdout, stderr *bytes.Buffer) (string, bool)
 	if stdout.Len() > 0 {
 		outStr := stdout.String()
 		if len(outStr) > e.MaxOutputSize {
-			outStr = outStr[:e.MaxOutputSize]
+			outStr = util.TruncateBytes(outStr, e.MaxOutputSize)
 			truncated = true
 		}
 		output.WriteString(outStr)
@@ -877,7 +956,7 @@ func (e *BashExecutor) buildOutput(stdout, stderr *bytes.Buffer) (string, bool)
 		remaining := e.MaxOutputSize - output.Len()
 		if remaining > 0 {
 			if len(errStr) > remaining {
-				errStr = errStr[:remaining]
+				errStr = util.TruncateBytes(errStr, remaining)
 				truncated = true
 			}
 			output.WriteString(errStr)
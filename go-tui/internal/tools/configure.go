@@ -0,0 +1,50 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package tools provides the agentic tool system for rigrun TUI.
+// configure.go sets up a registry from the user's configuration.
+package tools
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jeranaias/rigrun-tui/internal/config"
+)
+
+// NewConfiguredRegistry returns a registry set up from cfg: tool permissions
+// pinned by administrator policy, the Bash sandbox, lifecycle hooks and the
+// [permissions] policy. A nil cfg gives the default registry.
+//
+// The registry is returned even when part of the setup fails, so callers
+// that can carry on may do so, but the error lists every failure. Failures
+// fail closed: an invalid sandbox mode requires the sandbox, and a hook that
+// can't be added is not run.
+func NewConfiguredRegistry(cfg *config.Config) (*Registry, error) {
+	r := NewRegistry()
+	if cfg == nil {
+		return r, nil
+	}
+
+	var errs []error
+	// CM-5: Tool permissions pinned by administrator policy
+	if err := r.ApplyPolicyPermissions(cfg.PolicyToolPermissions()); err != nil {
+		errs = append(errs, fmt.Errorf("administrator tool permissions: %w", err))
+	}
+	// SC-39: Optional process isolation for Bash commands
+	if err := r.ApplySandboxSettings(cfg.Security.BashSandbox, cfg.Security.BashSandboxNetwork); err != nil {
+		errs = append(errs, fmt.Errorf("bash sandbox: %w", err))
+	}
+	// User-defined tool lifecycle hooks
+	for i, hook := range cfg.Hooks {
+		if err := r.AddHook(hook.Event, hook.Matcher, hook.Command, hook.TimeoutSecs); err != nil {
+			errs = append(errs, fmt.Errorf("hooks[%d]: %w", i, err))
+		}
+	}
+	// Declarative allow/ask/deny rules
+	perms := cfg.Permissions
+	if err := r.ApplyPermissionPolicy(perms.Allow, perms.Ask, perms.Deny, perms.Default, "config"); err != nil {
+		errs = append(errs, fmt.Errorf("permission policy: %w", err))
+	}
+	return r, errors.Join(errs...)
+}
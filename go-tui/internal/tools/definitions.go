@@ -9,6 +9,7 @@ package tools
 
 import (
 	"context"
+	"fmt"
 	"strings"
 	"time"
 )
@@ -102,6 +103,21 @@ func (p PermissionLevel) String() string {
 	}
 }
 
+// ParsePermissionLevel parses a permission level name. Accepts "auto"/"allow",
+// "ask", and "never"/"deny" (case-insensitive).
+func ParsePermissionLevel(s string) (PermissionLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "auto", "allow":
+		return PermissionAuto, nil
+	case "ask":
+		return PermissionAsk, nil
+	case "never", "deny":
+		return PermissionNever, nil
+	default:
+		return PermissionAsk, fmt.Errorf("unknown permission level %q", s)
+	}
+}
+
 // =============================================================================
 // TOOL DEFINITION
 // =============================================================================
@@ -213,6 +229,10 @@ type Result struct {
 
 	// FilesMatched for glob operations
 	FilesMatched int
+
+	// Sandbox is the sandbox profile a Bash command ran under ("none" when
+	// the sandbox was enabled but unavailable; empty when disabled)
+	Sandbox string
 }
 
 // =============================================================================
@@ -228,6 +248,15 @@ type Registry struct {
 
 	// "Always allow" preferences
 	alwaysAllow map[string]bool
+
+	// Administrator policy minimums (tool name -> permission, CM-5)
+	policy map[string]PermissionLevel
+
+	// User-defined lifecycle hooks, in configuration order
+	hooks []*Hook
+
+	// Declarative allow/ask/deny rules for headless runs (optional)
+	permissionPolicy *PermissionPolicy
 }
 
 // NewRegistry creates a new tool registry with built-in tools.
@@ -236,6 +265,7 @@ func NewRegistry() *Registry {
 		tools:       make(map[string]*Tool),
 		overrides:   make(map[string]PermissionLevel),
 		alwaysAllow: make(map[string]bool),
+		policy:      make(map[string]PermissionLevel),
 	}
 
 	// Register built-in tools
@@ -281,6 +311,11 @@ func (r *Registry) All() []*Tool {
 
 // GetPermission returns the effective permission level for a tool.
 func (r *Registry) GetPermission(toolName string) PermissionLevel {
+	return r.applyPolicy(toolName, r.basePermission(toolName))
+}
+
+// basePermission returns the permission level for a tool before policy.
+func (r *Registry) basePermission(toolName string) PermissionLevel {
 	// Check for "always allow"
 	if r.alwaysAllow[toolName] {
 		return PermissionAuto
@@ -299,6 +334,16 @@ func (r *Registry) GetPermission(toolName string) PermissionLevel {
 	return PermissionAsk
 }
 
+// applyPolicy raises perm to the administrator policy minimum for the tool.
+// SECURITY: Policy can only make a tool stricter; alwaysAllow and overrides
+// cannot relax a policy-pinned permission.
+func (r *Registry) applyPolicy(toolName string, perm PermissionLevel) PermissionLevel {
+	if min, ok := r.policy[toolName]; ok && min > perm {
+		return min
+	}
+	return perm
+}
+
 // GetPermissionWithParams returns the effective permission level for a tool with parameters.
 // This allows context-aware permission decisions based on the operation parameters.
 //
@@ -309,7 +354,14 @@ func (r *Registry) GetPermission(toolName string) PermissionLevel {
 // 2. alwaysAllow (user preference) - only applied if PermissionFunc allows auto
 // 3. overrides (admin config)
 // 4. static Permission (tool default)
+// A declarative permission policy, when set, then decides the call (see
+// PermissionDecision), and the administrator policy minimum is applied last.
 func (r *Registry) GetPermissionWithParams(toolName string, params map[string]interface{}) PermissionLevel {
+	return r.PermissionDecision(toolName, params).Level
+}
+
+// permissionWithParams returns the context-aware permission before policy.
+func (r *Registry) permissionWithParams(toolName string, params map[string]interface{}) PermissionLevel {
 	// Get tool first to access PermissionFunc
 	tool := r.Get(toolName)
 
@@ -352,6 +404,31 @@ func (r *Registry) SetPermissionOverride(toolName string, perm PermissionLevel)
 	r.overrides[toolName] = perm
 }
 
+// SetPolicyPermission pins the minimum permission for a tool per
+// administrator policy.
+func (r *Registry) SetPolicyPermission(toolName string, perm PermissionLevel) {
+	r.policy[toolName] = perm
+}
+
+// ApplyPolicyPermissions pins tool permissions from an administrator policy
+// (tool name -> "auto", "ask" or "never"). Unknown levels are rejected.
+func (r *Registry) ApplyPolicyPermissions(perms map[string]string) error {
+	for toolName, level := range perms {
+		perm, err := ParsePermissionLevel(level)
+		if err != nil {
+			return fmt.Errorf("tool %s: %w", toolName, err)
+		}
+		r.SetPolicyPermission(toolName, perm)
+	}
+	return nil
+}
+
+// IsPolicyLocked returns if a tool's permission is pinned by policy.
+func (r *Registry) IsPolicyLocked(toolName string) bool {
+	_, ok := r.policy[toolName]
+	return ok
+}
+
 // SetAlwaysAllow marks a tool as always allowed.
 func (r *Registry) SetAlwaysAllow(toolName string, always bool) {
 	r.alwaysAllow[toolName] = always
@@ -32,11 +32,17 @@
 //   - WebFetch: Fetch and process web content
 //   - WebSearch: DuckDuckGo search
 //
+// # Hooks
+//
+// User-defined pre_tool, post_tool and stop hooks (see hooks.go) run shell
+// commands around tool calls. They share the Bash tool's sandbox and limits.
+//
 // # Security
 //
 // All tools implement comprehensive security validation:
 //   - Path traversal prevention
 //   - Sensitive file protection
 //   - Shell command restrictions
+//   - Optional Bash sandbox on Linux (namespaces, resource limits, seccomp)
 //   - TLS for web requests
 package tools
@@ -7,12 +7,15 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"sync"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/jeranaias/rigrun-tui/internal/util"
 )
 
 // =============================================================================
@@ -21,7 +24,7 @@ import (
 
 var (
 	// Tool call parsing patterns
-	jsonToolCallRegex  = regexp.MustCompile(`\{[^{}]*"name"\s*:\s*"([^"]+)"[^{}]*(?:"parameters"|"input")\s*:\s*(\{[^{}]*\})[^{}]*\}`)
+	jsonToolCallRegex  = regexp.MustCompile(`\{[^{}]*"name"\s*:\s*"([^"]+)"[^{}]*(?:"parameters"|"input"|"arguments")\s*:\s*(\{[^{}]*\})[^{}]*\}`)
 	functionCallRegex  = regexp.MustCompile(`\b([A-Z][a-zA-Z]*)\s*\(\s*([^)]*)\s*\)`)
 	keyValuePairsRegex = regexp.MustCompile(`(\w+)\s*=\s*(?:"([^"]*)"|\x60([^\x60]*)\x60|'([^']*)'|(\S+))`)
 )
@@ -126,7 +129,9 @@ func (e *Executor) SetAutoApproveLevel(level PermissionLevel) {
 	e.autoApprove = level
 }
 
-// SetWorkDir updates the working directory for tool execution.
+// SetWorkDir updates the working directory for tool execution. Relative
+// file and search paths resolve against it, and Bash commands and hooks run
+// in it; the process's own working directory is left alone.
 func (e *Executor) SetWorkDir(dir string) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
@@ -174,6 +179,12 @@ const DefaultToolTimeout = 30 * time.Second
 // Execute runs a tool call and returns the result.
 // It handles permission checking, execution, timeout handling, and history recording.
 func (e *Executor) Execute(ctx context.Context, call ToolCall) Result {
+	return e.execute(ctx, call, false)
+}
+
+// execute runs a tool call. A reviewed call was already approved by the user
+// in a diff review, so only policy (PermissionNever) can still block it.
+func (e *Executor) execute(ctx context.Context, call ToolCall, reviewed bool) Result {
 	start := time.Now()
 
 	// Look up the tool in the registry
@@ -186,30 +197,68 @@ func (e *Executor) Execute(ctx context.Context, call ToolCall) Result {
 		}
 	}
 
-	// Check permission level
-	approved := e.checkPermission(tool, call.Params)
+	// The call works in the executor's directory, which need not be the
+	// process's (e.g. a git worktree)
+	workDir := e.callWorkDir()
+	if workDir != "" {
+		call.Params = resolvePaths(call.Name, call.Params, workDir)
+		ctx = withWorkDir(ctx, workDir)
+	}
 
 	// Record the execution attempt
 	record := ExecutionRecord{
 		ToolName:  call.Name,
 		Params:    call.Params,
 		Timestamp: start,
-		Approved:  approved,
 	}
-
-	// If not approved, record and return early
-	if !approved {
+	deny := func(reason string) Result {
 		record.Duration = time.Since(start)
 		record.Result = Result{
 			Success:  false,
-			Error:    "permission denied for tool: " + call.Name,
+			Error:    reason,
 			Duration: record.Duration,
 		}
-
 		e.addToHistory(record)
 		return record.Result
 	}
 
+	// CM-5: Calls denied by policy are never executed, and no hook runs
+	if decision := e.registry.decide(tool.Name, call.Params, workDir); decision.Level == PermissionNever {
+		e.registry.AuditPermissionDecision(tool.Name, decision)
+		return deny("permission denied for tool: " + call.Name)
+	}
+
+	// Pre-tool hooks may veto the call or rewrite its parameters. They run
+	// before approval, as in the CLI, so that the approval covers the
+	// parameters the call runs with.
+	params, rewritten, err := e.registry.RunPreToolHooks(ctx, call.Name, call.Params)
+	if err != nil {
+		return deny(err.Error())
+	}
+	if rewritten {
+		if workDir != "" {
+			params = resolvePaths(call.Name, params, workDir)
+		}
+		call.Params = params
+		record.Params = params
+	}
+
+	// Check permission level. A reviewed call keeps its approval unless a
+	// hook changed what was reviewed.
+	if reviewed && !rewritten {
+		decision := e.registry.decide(tool.Name, call.Params, workDir)
+		e.registry.AuditPermissionDecision(tool.Name, decision)
+		record.Approved = decision.Level != PermissionNever
+	} else {
+		record.Approved = e.checkPermission(tool, call.Params, workDir)
+	}
+	if !record.Approved {
+		if rewritten {
+			return deny("permission denied for tool: " + call.Name + " (parameters rewritten by hook)")
+		}
+		return deny("permission denied for tool: " + call.Name)
+	}
+
 	// Validate parameters using comprehensive validation
 	if err := e.validateParams(tool, call.Params); err != nil {
 		result := Result{
@@ -263,10 +312,15 @@ func (e *Executor) Execute(ctx context.Context, call ToolCall) Result {
 
 	// Truncate output if too large
 	if len(result.Output) > e.maxOutputSize {
-		result.Output = result.Output[:e.maxOutputSize]
+		result.Output = util.TruncateBytes(result.Output, e.maxOutputSize)
 		result.Truncated = true
 	}
 
+	// Post-tool hook output is shown to the model with the result
+	if notes := e.registry.RunPostToolHooks(ctx, call.Name, call.Params, result); notes != "" {
+		result.Output = strings.TrimRight(result.Output, "\n") + "\n\n" + notes
+	}
+
 	// Record the execution
 	record.Duration = result.Duration
 	record.Result = result
@@ -285,15 +339,23 @@ func (e *Executor) ExecuteBatch(ctx context.Context, calls []ToolCall) []Result
 }
 
 // checkPermission determines if a tool execution should be allowed.
-// Uses GetPermissionWithParams for context-aware permission checking that considers
-// path-based security rules in addition to tool-level permissions.
-func (e *Executor) checkPermission(tool *Tool, params map[string]interface{}) bool {
+// Uses context-aware permission checking that considers path-based security
+// rules in addition to tool-level permissions. workDir is the call's working
+// directory ("" for the process's).
+func (e *Executor) checkPermission(tool *Tool, params map[string]interface{}, workDir string) bool {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
-	// Use GetPermissionWithParams for context-aware permission checking
-	// This ensures PermissionFunc (path-based security) is evaluated with actual params
-	toolPermission := e.registry.GetPermissionWithParams(tool.Name, params)
+	// Context-aware permission checking: PermissionFunc (path-based
+	// security) is evaluated with actual params
+	decision := e.registry.decide(tool.Name, params, workDir)
+	e.registry.AuditPermissionDecision(tool.Name, decision)
+	toolPermission := decision.Level
+
+	// PermissionNever cannot be overridden by user approval
+	if toolPermission == PermissionNever {
+		return false
+	}
 
 	// If tool permission is <= autoApprove level, auto-approve
 	if toolPermission <= e.autoApprove {
@@ -308,6 +370,70 @@ func (e *Executor) checkPermission(tool *Tool, params map[string]interface{}) bo
 	return false
 }
 
+// ResolveCall returns call with its file or search path resolved against
+// the executor's working directory, as Execute runs it. Previews of a call
+// made before it runs should use the resolved call.
+func (e *Executor) ResolveCall(call ToolCall) ToolCall {
+	if workDir := e.callWorkDir(); workDir != "" {
+		call.Params = resolvePaths(call.Name, call.Params, workDir)
+	}
+	return call
+}
+
+// callWorkDir returns the absolute directory tool calls work in, or "" when
+// it is the process's working directory.
+func (e *Executor) callWorkDir() string {
+	dir := e.GetWorkDir()
+	if dir == "" || dir == "." {
+		return ""
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		return abs
+	}
+	return dir
+}
+
+// workDirKey is the context key of a tool call's working directory.
+type workDirKey struct{}
+
+// withWorkDir returns ctx carrying the working directory of a tool call.
+func withWorkDir(ctx context.Context, dir string) context.Context {
+	return context.WithValue(ctx, workDirKey{}, dir)
+}
+
+// workDirFrom returns the working directory carried by ctx, or "" for the
+// process's.
+func workDirFrom(ctx context.Context) string {
+	dir, _ := ctx.Value(workDirKey{}).(string)
+	return dir
+}
+
+// resolvePaths returns params with the file or search path of a call made
+// absolute against dir; searches without a path run in dir. params itself
+// is not modified.
+func resolvePaths(toolName string, params map[string]interface{}, dir string) map[string]interface{} {
+	var key string
+	switch strings.ToLower(toolName) {
+	case "read", "write", "edit":
+		key = "file_path"
+	case "glob", "grep":
+		key = "path"
+	default:
+		return params
+	}
+	path, _ := params[key].(string)
+	if (path == "" && key == "file_path") || filepath.IsAbs(path) {
+		return params
+	}
+
+	resolved := make(map[string]interface{}, len(params)+1)
+	for k, v := range params {
+		resolved[k] = v
+	}
+	resolved[key] = filepath.Join(dir, path)
+	return resolved
+}
+
 // addToHistory adds an execution record to the history.
 func (e *Executor) addToHistory(record ExecutionRecord) {
 	e.mu.Lock()
@@ -622,7 +748,7 @@ func ParseToolCalls(text string) []*ToolCall {
 func parseJSONToolCalls(text string) []*ToolCall {
 	var calls []*ToolCall
 
-	// Look for JSON objects with "name" and "parameters" or "input"
+	// Look for JSON objects with "name" and "parameters", "input" or "arguments"
 	// Pattern: {"name": "...", "parameters": {...}}
 	matches := jsonToolCallRegex.FindAllStringSubmatch(text, -1)
 	for _, match := range matches {
@@ -0,0 +1,501 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package tools provides the agentic tool system for rigrun TUI.
+// git.go implements git-aware agent sessions.
+//
+// With git mode enabled in config.toml:
+//
+//	[git]
+//	mode          = "branch"   # off, branch or worktree
+//	branch_prefix = "rigrun/"
+//
+// an agent session works on its own branch (or on a new worktree checked out
+// beside the repository), commits after each turn that changed files, can
+// revert its last commit (/undo) and can squash its commits into one
+// (/commit).
+//
+// Every git command runs through the Bash tool's validation, sandbox,
+// environment and timeout rules, and every operation is audit logged.
+package tools
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jeranaias/rigrun-tui/internal/security"
+	"github.com/jeranaias/rigrun-tui/internal/util"
+)
+
+// Git session modes.
+const (
+	// GitModeOff disables git integration.
+	GitModeOff = "off"
+
+	// GitModeBranch works on a new branch in the current checkout.
+	GitModeBranch = "branch"
+
+	// GitModeWorktree works on a new branch in a separate worktree.
+	GitModeWorktree = "worktree"
+)
+
+// DefaultGitBranchPrefix prefixes the branches agent sessions create.
+const DefaultGitBranchPrefix = "rigrun/"
+
+// gitTimeout bounds a single git command.
+const gitTimeout = 60 * time.Second
+
+// maxCommitDiff is the most diff text offered to the commit message model.
+const maxCommitDiff = 12000
+
+// ParseGitMode validates a git mode. Empty means off.
+func ParseGitMode(s string) (string, error) {
+	switch mode := strings.ToLower(strings.TrimSpace(s)); mode {
+	case "", GitModeOff:
+		return GitModeOff, nil
+	case GitModeBranch, GitModeWorktree:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid git mode %q (must be off, branch or worktree)", s)
+	}
+}
+
+// validBranchPrefix matches the branch prefixes a session may use: path
+// components of letters, digits, '.', '_' and '-', each followed by '/' or
+// '-'. The session's timestamp completes the branch name.
+var validBranchPrefix = regexp.MustCompile(`^(?:[A-Za-z0-9_][A-Za-z0-9._-]*[/-])*$`)
+
+// GitSession is an agent session working on a dedicated branch.
+type GitSession struct {
+	// Mode is GitModeBranch or GitModeWorktree
+	Mode string
+
+	// Dir is the checkout the session works in (the worktree in worktree mode)
+	Dir string
+
+	// Branch is the session branch
+	Branch string
+
+	// BaseBranch is the branch the session started from ("" if detached)
+	BaseBranch string
+
+	// BaseCommit is the commit the session branch started at
+	BaseCommit string
+
+	shell *BashExecutor
+
+	mu sync.Mutex
+	// commits are the session's commits that have not been undone, oldest first
+	commits []GitCommit
+}
+
+// GitCommit is a commit made by a session.
+type GitCommit struct {
+	Hash    string
+	Subject string
+}
+
+// Short returns the abbreviated hash.
+func (c GitCommit) Short() string {
+	if len(c.Hash) > 8 {
+		return c.Hash[:8]
+	}
+	return c.Hash
+}
+
+// StartGitSession creates the session branch (and worktree) for an agent
+// session in the repository containing dir. Branch mode requires a clean
+// working tree, so the user's uncommitted changes never end up in the
+// session's commits.
+func (r *Registry) StartGitSession(ctx context.Context, mode, branchPrefix, dir string) (*GitSession, error) {
+	mode, err := ParseGitMode(mode)
+	if err != nil {
+		return nil, err
+	}
+	if mode == GitModeOff {
+		return nil, fmt.Errorf("git mode is off")
+	}
+	if branchPrefix == "" {
+		branchPrefix = DefaultGitBranchPrefix
+	}
+	if !validBranchPrefix.MatchString(branchPrefix) || strings.Contains(branchPrefix, "..") {
+		return nil, fmt.Errorf("invalid git branch prefix %q", branchPrefix)
+	}
+
+	shell := r.hookShell()
+	shell.WorkDir = dir
+	g := &GitSession{Mode: mode, shell: shell}
+
+	root, err := g.git(ctx, nil, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("not a git repository: %w", err)
+	}
+	root = strings.TrimSpace(root)
+	g.Dir = root
+	g.shell.WorkDir = root
+
+	if g.BaseCommit, err = g.git(ctx, nil, "rev-parse", "HEAD"); err != nil {
+		return nil, fmt.Errorf("repository has no commits: %w", err)
+	}
+	g.BaseCommit = strings.TrimSpace(g.BaseCommit)
+	if branch, err := g.git(ctx, nil, "symbolic-ref", "--quiet", "--short", "HEAD"); err == nil {
+		g.BaseBranch = strings.TrimSpace(branch)
+	}
+
+	g.Branch = branchPrefix + time.Now().Format("20060102-150405")
+
+	switch mode {
+	case GitModeBranch:
+		status, err := g.git(ctx, nil, "status", "--porcelain")
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(status) != "" {
+			return nil, fmt.Errorf("working tree has uncommitted changes; commit or stash them first")
+		}
+		if _, err := g.git(ctx, nil, "checkout", "-b", g.Branch); err != nil {
+			return nil, err
+		}
+	case GitModeWorktree:
+		// The worktree goes beside the repository: <repo>-<branch>
+		path := root + "-" + strings.ReplaceAll(strings.TrimPrefix(g.Branch, branchPrefix), "/", "-")
+		if _, err := os.Stat(path); err == nil {
+			return nil, fmt.Errorf("worktree path %s already exists", path)
+		}
+		if _, err := g.git(ctx, nil, "worktree", "add", "-b", g.Branch, path, g.BaseCommit); err != nil {
+			return nil, err
+		}
+		g.Dir = path
+		g.shell.WorkDir = path
+		allowRoot(path)
+	}
+
+	g.audit("GIT_SESSION_START", g.BaseCommit, map[string]string{"mode": mode, "dir": g.Dir})
+	return g, nil
+}
+
+// git runs a git command through the Bash tool's checks. stdin is optional.
+func (g *GitSession) git(ctx context.Context, stdin io.Reader, args ...string) (string, error) {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	command := "git " + strings.Join(quoted, " ")
+
+	// SECURITY: git goes through the same validation as the Bash tool
+	if err := g.shell.validateCommand(command); err != nil {
+		return "", err
+	}
+
+	result, streams := g.shell.run(ctx, command, g.shell.clampTimeout(gitTimeout), stdin, nil)
+	if !result.Success {
+		msg := strings.TrimSpace(streams.Stderr)
+		if msg == "" {
+			msg = result.Error
+		}
+		return "", fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return streams.Stdout, nil
+}
+
+// shellSafeArg matches arguments that need no quoting.
+var shellSafeArg = regexp.MustCompile(`^[A-Za-z0-9._/:=@%+-]+$`)
+
+// shellQuote quotes an argument for the platform shell.
+func shellQuote(s string) string {
+	if shellSafeArg.MatchString(s) {
+		return s
+	}
+	if runtime.GOOS == "windows" {
+		return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// audit logs a git operation (AU-2: the agent changes repository history).
+func (g *GitSession) audit(event, commit string, extra map[string]string) {
+	fields := map[string]string{"branch": g.Branch, "commit": commit}
+	for k, v := range extra {
+		fields[k] = v
+	}
+	security.AuditLogEvent("GIT", event, fields)
+}
+
+// Commits returns the session's commits that have not been undone, oldest
+// first.
+func (g *GitSession) Commits() []GitCommit {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]GitCommit(nil), g.commits...)
+}
+
+// stage stages every change in the checkout and reports whether there is
+// anything to commit.
+func (g *GitSession) stage(ctx context.Context) (bool, error) {
+	if _, err := g.git(ctx, nil, "add", "-A"); err != nil {
+		return false, err
+	}
+	names, err := g.git(ctx, nil, "diff", "--cached", "--name-only")
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(names) != "", nil
+}
+
+// PendingDiff stages the checkout's changes and returns their diff for a
+// commit message, truncated for the model. It returns "" when nothing
+// changed, and stages nothing once the checkout has left the session branch.
+func (g *GitSession) PendingDiff(ctx context.Context) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.checkBranch(ctx); err != nil {
+		return "", err
+	}
+	changed, err := g.stage(ctx)
+	if err != nil || !changed {
+		return "", err
+	}
+	return g.diff(ctx, "--cached")
+}
+
+// SessionDiff returns the diff of the whole session for a squash message.
+func (g *GitSession) SessionDiff(ctx context.Context) (string, error) {
+	return g.diff(ctx, g.BaseCommit, "HEAD")
+}
+
+// diff returns a stat summary followed by the (truncated) patch.
+func (g *GitSession) diff(ctx context.Context, args ...string) (string, error) {
+	// --stat goes last: the Bash tool's blocked patterns include "at "
+	stat, err := g.git(ctx, nil, append(append([]string{"diff"}, args...), "--stat")...)
+	if err != nil {
+		return "", err
+	}
+	patch, err := g.git(ctx, nil, append([]string{"diff"}, args...)...)
+	if err != nil {
+		return "", err
+	}
+	if len(patch) > maxCommitDiff {
+		patch = util.TruncateBytes(patch, maxCommitDiff) + "\n... (diff truncated)"
+	}
+	return strings.TrimSpace(stat) + "\n\n" + patch, nil
+}
+
+// Commit commits every change in the checkout with message. ok is false when
+// there was nothing to commit.
+func (g *GitSession) Commit(ctx context.Context, message string) (commit GitCommit, ok bool, err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.checkBranch(ctx); err != nil {
+		return commit, false, err
+	}
+	changed, err := g.stage(ctx)
+	if err != nil || !changed {
+		return commit, false, err
+	}
+	if commit, err = g.commit(ctx, message); err != nil {
+		return commit, false, err
+	}
+	g.commits = append(g.commits, commit)
+	g.audit("GIT_COMMIT", commit.Hash, map[string]string{"subject": commit.Subject})
+	return commit, true, nil
+}
+
+// commit commits the staged changes and returns the new commit.
+func (g *GitSession) commit(ctx context.Context, message string) (GitCommit, error) {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return GitCommit{}, fmt.Errorf("empty commit message")
+	}
+	// The message goes on stdin so it never passes through the shell
+	if _, err := g.git(ctx, strings.NewReader(message+"\n"), "commit", "--quiet", "-F", "-"); err != nil {
+		return GitCommit{}, err
+	}
+	hash, err := g.git(ctx, nil, "rev-parse", "HEAD")
+	if err != nil {
+		return GitCommit{}, err
+	}
+	subject, _, _ := strings.Cut(message, "\n")
+	return GitCommit{Hash: strings.TrimSpace(hash), Subject: subject}, nil
+}
+
+// checkBranch refuses to touch history when the checkout has moved off the
+// session branch.
+func (g *GitSession) checkBranch(ctx context.Context) error {
+	branch, err := g.git(ctx, nil, "symbolic-ref", "--quiet", "--short", "HEAD")
+	if err != nil || strings.TrimSpace(branch) != g.Branch {
+		return fmt.Errorf("checkout is no longer on the session branch %s", g.Branch)
+	}
+	return nil
+}
+
+// Undo reverts the session's most recent commit with a new revert commit,
+// so nothing is lost from history. Uncommitted changes are left alone;
+// git refuses the revert if they overlap it.
+func (g *GitSession) Undo(ctx context.Context) (GitCommit, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if len(g.commits) == 0 {
+		return GitCommit{}, fmt.Errorf("no session commits to undo")
+	}
+	if err := g.checkBranch(ctx); err != nil {
+		return GitCommit{}, err
+	}
+
+	last := g.commits[len(g.commits)-1]
+	if _, err := g.git(ctx, nil, "revert", "--no-edit", last.Hash); err != nil {
+		// Leave the checkout as it was
+		if _, abortErr := g.git(ctx, nil, "revert", "--abort"); abortErr != nil {
+			return GitCommit{}, fmt.Errorf("%w; the revert could not be aborted, so the checkout may be mid-revert: %v", err, abortErr)
+		}
+		return GitCommit{}, err
+	}
+	g.commits = g.commits[:len(g.commits)-1]
+	g.audit("GIT_REVERT", last.Hash, map[string]string{"subject": last.Subject})
+	return last, nil
+}
+
+// Squash replaces everything on the session branch since it started with a
+// single commit, including any uncommitted changes. It returns the new
+// commit and how many commits it replaced.
+func (g *GitSession) Squash(ctx context.Context, message string) (GitCommit, int, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.checkBranch(ctx); err != nil {
+		return GitCommit{}, 0, err
+	}
+	// SECURITY: Only rewrite history the session created
+	if _, err := g.git(ctx, nil, "merge-base", "--is-ancestor", g.BaseCommit, "HEAD"); err != nil {
+		return GitCommit{}, 0, fmt.Errorf("session base %s is no longer an ancestor of HEAD", g.BaseCommit[:8])
+	}
+	count, err := g.git(ctx, nil, "rev-list", "--count", g.BaseCommit+"..HEAD")
+	if err != nil {
+		return GitCommit{}, 0, err
+	}
+	replaced := 0
+	fmt.Sscanf(strings.TrimSpace(count), "%d", &replaced)
+
+	head, err := g.git(ctx, nil, "rev-parse", "HEAD")
+	if err != nil {
+		return GitCommit{}, 0, err
+	}
+	if _, err := g.git(ctx, nil, "reset", "--soft", g.BaseCommit); err != nil {
+		return GitCommit{}, 0, err
+	}
+	changed, err := g.stage(ctx)
+	if err == nil && !changed {
+		err = fmt.Errorf("the session made no changes")
+	}
+	var commit GitCommit
+	if err == nil {
+		commit, err = g.commit(ctx, message)
+	}
+	if err != nil {
+		// Put the branch back where it was; the working tree is unchanged
+		head = strings.TrimSpace(head)
+		if _, resetErr := g.git(ctx, nil, "reset", "--soft", head); resetErr != nil {
+			return GitCommit{}, 0, fmt.Errorf("%w; the branch could not be restored to %s, run \"git reset --soft %s\": %v",
+				err, head, head, resetErr)
+		}
+		return GitCommit{}, 0, err
+	}
+
+	g.commits = []GitCommit{commit}
+	g.audit("GIT_SQUASH", commit.Hash, map[string]string{
+		"subject":  commit.Subject,
+		"replaced": fmt.Sprintf("%d", replaced),
+	})
+	return commit, replaced, nil
+}
+
+// Describe summarizes the session for the user.
+func (g *GitSession) Describe() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Working on branch %s", g.Branch)
+	if g.BaseBranch != "" {
+		fmt.Fprintf(&sb, " (from %s)", g.BaseBranch)
+	}
+	if g.Mode == GitModeWorktree {
+		fmt.Fprintf(&sb, " in worktree %s", g.Dir)
+	}
+	sb.WriteString(". Changes are committed after each turn; /undo reverts the last commit and /commit squashes the session.")
+	return sb.String()
+}
+
+// =============================================================================
+// COMMIT MESSAGES
+// =============================================================================
+
+// CommitMessagePrompt builds the prompt asking a model for a commit message
+// for diff. request is what the user asked for, if known.
+func CommitMessagePrompt(request, diff string) string {
+	var sb strings.Builder
+	sb.WriteString("Write a git commit message for the change below.\n")
+	sb.WriteString("Use an imperative subject line of at most 72 characters. ")
+	sb.WriteString("Add a short body only if the subject can't explain the change. ")
+	sb.WriteString("Reply with the commit message only, without quotes or code fences.\n\n")
+	if request = strings.TrimSpace(request); request != "" {
+		fmt.Fprintf(&sb, "The change was made for this request:\n%s\n\n", request)
+	}
+	sb.WriteString("Diff:\n")
+	sb.WriteString(diff)
+	return sb.String()
+}
+
+// CleanCommitMessage tidies a model's commit message reply. It falls back to
+// a message naming the changed files when the reply is unusable.
+func CleanCommitMessage(reply, diff string) string {
+	reply = strings.TrimSpace(reply)
+	reply = strings.TrimPrefix(reply, "```text")
+	reply = strings.TrimPrefix(reply, "```")
+	reply = strings.TrimSuffix(reply, "```")
+	reply = strings.Trim(strings.TrimSpace(reply), "\"'`")
+
+	subject, body, _ := strings.Cut(reply, "\n")
+	subject = strings.TrimSpace(strings.TrimPrefix(subject, "Subject:"))
+	subject = strings.Trim(subject, "\"'`")
+	if subject == "" {
+		return fallbackCommitMessage(diff)
+	}
+	if len(subject) > 72 {
+		subject = strings.TrimSpace(subject[:72])
+	}
+	if body = strings.TrimSpace(body); body != "" {
+		return subject + "\n\n" + body
+	}
+	return subject
+}
+
+// fallbackCommitMessage names the changed files from a diff stat.
+func fallbackCommitMessage(diff string) string {
+	var files []string
+	for _, line := range strings.Split(diff, "\n") {
+		name, _, ok := strings.Cut(line, "|")
+		if !ok {
+			if line == "" {
+				break // end of the stat block
+			}
+			continue
+		}
+		files = append(files, filepath.Base(strings.TrimSpace(name)))
+	}
+	switch {
+	case len(files) == 0:
+		return "Update files"
+	case len(files) <= 3:
+		return "Update " + strings.Join(files, ", ")
+	default:
+		return fmt.Sprintf("Update %s and %d more files", strings.Join(files[:2], ", "), len(files)-2)
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package tools
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// newTestRepo creates a git repository with one commit.
+func newTestRepo(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("git session tests use POSIX shell quoting")
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	// Commits need an identity that doesn't depend on the user's config
+	t.Setenv("GIT_AUTHOR_NAME", "rigrun test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "rigrun test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "--quiet", "--initial-branch=main"},
+		{"config", "user.name", "rigrun test"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "commit.gpgsign", "false"},
+	} {
+		runGit(t, dir, args...)
+	}
+	writeRepoFile(t, dir, "main.go", "package main\n")
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "commit", "--quiet", "-m", "Initial commit")
+	return dir
+}
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func writeRepoFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseGitMode(t *testing.T) {
+	for in, want := range map[string]string{"": GitModeOff, "off": GitModeOff, "Branch": GitModeBranch, "worktree": GitModeWorktree} {
+		if got, err := ParseGitMode(in); err != nil || got != want {
+			t.Errorf("ParseGitMode(%q) = %q, %v", in, got, err)
+		}
+	}
+	if _, err := ParseGitMode("fork"); err == nil {
+		t.Error("ParseGitMode should reject unknown modes")
+	}
+}
+
+func TestGitSession_BranchCommitUndoSquash(t *testing.T) {
+	dir := newTestRepo(t)
+	ctx := context.Background()
+	r := NewRegistry()
+
+	// A dirty tree would leak the user's changes into the session
+	writeRepoFile(t, dir, "scratch.txt", "wip")
+	if _, err := r.StartGitSession(ctx, GitModeBranch, "", dir); err == nil {
+		t.Fatal("branch mode should refuse a dirty working tree")
+	}
+	os.Remove(filepath.Join(dir, "scratch.txt"))
+
+	g, err := r.StartGitSession(ctx, GitModeBranch, "agent/", dir)
+	if err != nil {
+		t.Fatalf("StartGitSession() error = %v", err)
+	}
+	if !strings.HasPrefix(g.Branch, "agent/") || g.BaseBranch != "main" {
+		t.Errorf("unexpected session %+v", g)
+	}
+	if got := runGit(t, dir, "branch", "--show-current"); got != g.Branch {
+		t.Errorf("checkout is on %q, want %q", got, g.Branch)
+	}
+
+	// Nothing to commit yet
+	if diff, err := g.PendingDiff(ctx); err != nil || diff != "" {
+		t.Errorf("PendingDiff() = %q, %v", diff, err)
+	}
+	if _, ok, err := g.Commit(ctx, "noop"); ok || err != nil {
+		t.Errorf("Commit() with no changes = %v, %v", ok, err)
+	}
+
+	writeRepoFile(t, dir, "a.go", "package main\n\nfunc a() {}\n")
+	diff, err := g.PendingDiff(ctx)
+	if err != nil || !strings.Contains(diff, "a.go") {
+		t.Fatalf("PendingDiff() = %q, %v", diff, err)
+	}
+	// Messages reach git on stdin, so shell metacharacters are harmless
+	first, ok, err := g.Commit(ctx, "Add a() for the 'x' feature; $(id)\n\nBody line.")
+	if err != nil || !ok {
+		t.Fatalf("Commit() = %v, %v", ok, err)
+	}
+	if got := runGit(t, dir, "log", "-1", "--format=%s"); got != "Add a() for the 'x' feature; $(id)" {
+		t.Errorf("commit subject = %q", got)
+	}
+
+	writeRepoFile(t, dir, "b.go", "package main\n")
+	if _, _, err := g.Commit(ctx, "Add b.go"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Undo reverts the last commit without rewriting history
+	undone, err := g.Undo(ctx)
+	if err != nil || undone.Subject != "Add b.go" {
+		t.Fatalf("Undo() = %+v, %v", undone, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "b.go")); !os.IsNotExist(err) {
+		t.Error("b.go should be gone after undo")
+	}
+	if got := runGit(t, dir, "rev-list", "--count", g.BaseCommit+"..HEAD"); got != "3" {
+		t.Errorf("history should keep the commit and its revert, got %s commits", got)
+	}
+	if len(g.Commits()) != 1 || g.Commits()[0].Hash != first.Hash {
+		t.Errorf("Commits() = %+v", g.Commits())
+	}
+
+	// Squash folds the session into one commit on top of the base
+	squashed, replaced, err := g.Squash(ctx, "Add a()")
+	if err != nil || replaced != 3 {
+		t.Fatalf("Squash() = %+v, %d, %v", squashed, replaced, err)
+	}
+	if got := runGit(t, dir, "rev-list", "--count", g.BaseCommit+"..HEAD"); got != "1" {
+		t.Errorf("squash left %s commits", got)
+	}
+	if got := runGit(t, dir, "show", "--name-only", "--format=", "HEAD"); got != "a.go" {
+		t.Errorf("squashed commit changes %q, want a.go", got)
+	}
+
+	// Undo refuses to touch history once the checkout leaves the branch
+	runGit(t, dir, "checkout", "--quiet", "main")
+	if _, err := g.Undo(ctx); err == nil {
+		t.Error("Undo() should refuse when the checkout is off the session branch")
+	}
+
+	// So does PendingDiff, which would otherwise stage the user's changes
+	writeRepoFile(t, dir, "user.txt", "not the agent's")
+	if _, err := g.PendingDiff(ctx); err == nil {
+		t.Error("PendingDiff() should refuse when the checkout is off the session branch")
+	}
+	if staged := runGit(t, dir, "diff", "--cached", "--name-only"); staged != "" {
+		t.Errorf("PendingDiff() staged %q off the session branch", staged)
+	}
+}
+
+func TestGitSession_Worktree(t *testing.T) {
+	dir := newTestRepo(t)
+	// The worktree is created beside the repository, so keep both in a temp dir
+	repo := filepath.Join(dir, "repo")
+	runGit(t, dir, "clone", "--quiet", dir, repo)
+
+	g, err := NewRegistry().StartGitSession(context.Background(), GitModeWorktree, "", repo)
+	if err != nil {
+		t.Fatalf("StartGitSession() error = %v", err)
+	}
+	if g.Dir == repo || !strings.HasPrefix(g.Dir, repo+"-") {
+		t.Errorf("worktree dir = %q", g.Dir)
+	}
+	if got := runGit(t, g.Dir, "branch", "--show-current"); got != g.Branch {
+		t.Errorf("worktree is on %q, want %q", got, g.Branch)
+	}
+	if got := runGit(t, repo, "branch", "--show-current"); got == g.Branch {
+		t.Error("the main checkout should stay on its branch")
+	}
+	// Tools work in the worktree without moving the process there
+	cwd, _ := os.Getwd()
+	executor := NewExecutor(NewRegistry())
+	executor.SetPermissionCallback(func(*Tool, map[string]interface{}) bool { return true })
+	executor.SetWorkDir(g.Dir)
+	ctx := context.Background()
+	if result := executor.Execute(ctx, ToolCall{Name: "Write", Params: map[string]interface{}{
+		"file_path": "notes.txt", "content": "in the worktree\n",
+	}}); !result.Success {
+		t.Fatalf("Write failed: %s", result.Error)
+	}
+	if _, err := os.Stat(filepath.Join(g.Dir, "notes.txt")); err != nil {
+		t.Errorf("Write should create notes.txt in the worktree: %v", err)
+	}
+	if result := executor.Execute(ctx, ToolCall{Name: "Bash", Params: map[string]interface{}{
+		"command": "ls",
+	}}); !result.Success || !strings.Contains(result.Output, "notes.txt") {
+		t.Errorf("Bash should run in the worktree, got %+v", result)
+	}
+	if now, _ := os.Getwd(); now != cwd {
+		t.Errorf("process moved from %s to %s", cwd, now)
+	}
+}
+
+func TestCleanCommitMessage(t *testing.T) {
+	diff := " a.go | 2 +-\n b.go | 1 +\n 2 files changed\n\ndiff --git a/a.go b/a.go"
+	tests := map[string]string{
+		"```\nFix the parser\n```":      "Fix the parser",
+		"\"Add retries\"\n\nBody here.": "Add retries\n\nBody here.",
+		"Subject: Rename config loader": "Rename config loader",
+		"   ":                           "Update a.go, b.go",
+		strings.Repeat("x", 100):        strings.Repeat("x", 72),
+	}
+	for reply, want := range tests {
+		if got := CleanCommitMessage(reply, diff); got != want {
+			t.Errorf("CleanCommitMessage(%q) = %q, want %q", reply, got, want)
+		}
+	}
+	if prompt := CommitMessagePrompt("add retries", diff); !strings.Contains(prompt, "add retries") || !strings.Contains(prompt, "a.go") {
+		t.Errorf("prompt is missing the request or diff:\n%s", prompt)
+	}
+}
@@ -0,0 +1,331 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package tools provides the agentic tool system for rigrun TUI.
+// hooks.go implements user-defined tool lifecycle hooks.
+//
+// Hooks are shell commands configured in config.toml:
+//
+//	[[hooks]]
+//	event   = "post_tool"      # pre_tool, post_tool or stop
+//	matcher = "Edit|Write"     # tool name regex; empty or "*" matches all
+//	command = "gofmt -w \"$RIGRUN_TOOL_FILE\""
+//
+// Each hook receives the tool call as JSON on stdin. A pre_tool hook blocks
+// the call by exiting non-zero or printing {"decision": "block", "reason":
+// "..."}, and rewrites the parameters by printing {"params": {...}}. Pre_tool
+// hooks see every call policy does not deny, before the user is asked, so
+// that the approval covers the parameters the call runs with. The
+// output of a post_tool hook is appended to the result the model sees. Stop
+// hooks run when the agent finishes a task.
+//
+// Hooks run through the Bash tool's sandbox, environment and timeout rules,
+// and every run is audit logged.
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jeranaias/rigrun-tui/internal/security"
+	"github.com/jeranaias/rigrun-tui/internal/util"
+)
+
+// HookEvent is the point in the tool lifecycle a hook runs at.
+type HookEvent string
+
+const (
+	// HookPreTool runs before a tool executes and may block or rewrite it.
+	HookPreTool HookEvent = "pre_tool"
+
+	// HookPostTool runs after a tool executes; its output reaches the model.
+	HookPostTool HookEvent = "post_tool"
+
+	// HookStop runs when the agent finishes.
+	HookStop HookEvent = "stop"
+)
+
+// DefaultHookTimeout is used when a hook does not set a timeout.
+const DefaultHookTimeout = 60 * time.Second
+
+// ParseHookEvent validates a hook event name.
+func ParseHookEvent(s string) (HookEvent, error) {
+	switch HookEvent(strings.ToLower(strings.TrimSpace(s))) {
+	case HookPreTool:
+		return HookPreTool, nil
+	case HookPostTool:
+		return HookPostTool, nil
+	case HookStop:
+		return HookStop, nil
+	default:
+		return "", fmt.Errorf("invalid hook event %q (must be pre_tool, post_tool or stop)", s)
+	}
+}
+
+// CompileHookMatcher compiles a tool name matcher. The pattern must match the
+// whole tool name; empty and "*" match every tool.
+func CompileHookMatcher(pattern string) (*regexp.Regexp, error) {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" || pattern == "*" {
+		pattern = ".*"
+	}
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid hook matcher %q: %w", pattern, err)
+	}
+	return re, nil
+}
+
+// Hook is a configured lifecycle hook.
+type Hook struct {
+	// Event is when the hook runs.
+	Event HookEvent
+
+	// Matcher selects tools by name (not used for stop hooks).
+	Matcher *regexp.Regexp
+
+	// Command is the shell command to run.
+	Command string
+
+	// Timeout bounds the hook (capped by the Bash tool's maximum).
+	Timeout time.Duration
+}
+
+// matches reports whether the hook applies to a tool.
+func (h *Hook) matches(event HookEvent, toolName string) bool {
+	if h.Event != event {
+		return false
+	}
+	return event == HookStop || h.Matcher == nil || h.Matcher.MatchString(toolName)
+}
+
+// AddHook registers a hook from config values.
+func (r *Registry) AddHook(event, matcher, command string, timeoutSecs int) error {
+	ev, err := ParseHookEvent(event)
+	if err != nil {
+		return err
+	}
+	re, err := CompileHookMatcher(matcher)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(command) == "" {
+		return fmt.Errorf("hook command is required")
+	}
+	timeout := DefaultHookTimeout
+	if timeoutSecs > 0 {
+		timeout = time.Duration(timeoutSecs) * time.Second
+	}
+	r.hooks = append(r.hooks, &Hook{Event: ev, Matcher: re, Command: command, Timeout: timeout})
+	return nil
+}
+
+// Hooks returns the registered hooks.
+func (r *Registry) Hooks() []*Hook {
+	return r.hooks
+}
+
+// HasHooks reports whether any hook is registered for an event.
+func (r *Registry) HasHooks(event HookEvent) bool {
+	for _, h := range r.hooks {
+		if h.Event == event {
+			return true
+		}
+	}
+	return false
+}
+
+// =============================================================================
+// HOOK EXECUTION
+// =============================================================================
+
+// hookInput is the JSON document a hook receives on stdin.
+type hookInput struct {
+	Event   HookEvent              `json:"event"`
+	Tool    string                 `json:"tool_name,omitempty"`
+	Params  map[string]interface{} `json:"params,omitempty"`
+	Result  *hookResult            `json:"result,omitempty"`
+	WorkDir string                 `json:"workdir"`
+}
+
+// hookResult is the tool result passed to post_tool hooks.
+type hookResult struct {
+	Success bool   `json:"success"`
+	Output  string `json:"output"`
+	Error   string `json:"error,omitempty"`
+}
+
+// hookDecision is the optional JSON a pre_tool hook prints on stdout.
+type hookDecision struct {
+	Decision string                 `json:"decision"`
+	Reason   string                 `json:"reason"`
+	Params   map[string]interface{} `json:"params"`
+}
+
+// HookBlockedError is returned when a pre_tool hook vetoes a tool call.
+type HookBlockedError struct {
+	Command string
+	Reason  string
+}
+
+func (e *HookBlockedError) Error() string {
+	if e.Reason == "" {
+		return "blocked by hook: " + e.Command
+	}
+	return "blocked by hook: " + e.Reason
+}
+
+// hookShell returns the executor hooks and git sessions run with: the
+// registry's Bash tool, so they share its sandbox and limits.
+func (r *Registry) hookShell() *BashExecutor {
+	shell := &BashExecutor{}
+	if tool := r.tools["Bash"]; tool != nil {
+		if bash, ok := tool.Executor.(*BashExecutor); ok {
+			copied := *bash
+			shell = &copied
+		}
+	}
+	shell.applyDefaults()
+	return shell
+}
+
+// runHook runs one hook and audit logs the outcome.
+func (r *Registry) runHook(ctx context.Context, h *Hook, input hookInput) (Result, commandStreams) {
+	shell := r.hookShell()
+	if dir := workDirFrom(ctx); dir != "" {
+		shell.WorkDir = dir
+	}
+	if input.WorkDir = shell.WorkDir; input.WorkDir == "" {
+		input.WorkDir, _ = os.Getwd()
+	}
+	stdin, err := json.Marshal(input)
+	if err != nil {
+		return Result{Success: false, Error: "encode hook input: " + err.Error()}, commandStreams{ExitCode: -1}
+	}
+
+	env := []string{
+		"RIGRUN_HOOK_EVENT=" + string(input.Event),
+		"RIGRUN_TOOL_NAME=" + input.Tool,
+	}
+	if file, ok := input.Params["file_path"].(string); ok {
+		env = append(env, "RIGRUN_TOOL_FILE="+file)
+	}
+
+	result, streams := shell.run(ctx, h.Command, shell.clampTimeout(h.Timeout), strings.NewReader(string(stdin)), env)
+
+	// AU-2: Hooks run arbitrary commands on the agent's behalf
+	security.AuditLogEvent("HOOK", "TOOL_HOOK", map[string]string{
+		"event":     string(input.Event),
+		"tool":      input.Tool,
+		"command":   h.Command,
+		"exit_code": util.IntToStr(streams.ExitCode),
+		"success":   fmt.Sprintf("%t", result.Success),
+		"sandbox":   result.Sandbox,
+		"duration":  result.Duration.String(),
+	})
+	return result, streams
+}
+
+// RunPreToolHooks runs the pre_tool hooks matching a tool call. It returns the
+// parameters to execute with and whether a hook rewrote them, or a
+// *HookBlockedError if a hook vetoed the call. Hooks that fail to run block
+// the call.
+func (r *Registry) RunPreToolHooks(ctx context.Context, toolName string, params map[string]interface{}) (map[string]interface{}, bool, error) {
+	rewritten := false
+	for _, h := range r.hooks {
+		if !h.matches(HookPreTool, toolName) {
+			continue
+		}
+		result, streams := r.runHook(ctx, h, hookInput{Event: HookPreTool, Tool: toolName, Params: params})
+		if !result.Success {
+			reason := strings.TrimSpace(streams.Stderr)
+			if reason == "" {
+				reason = strings.TrimSpace(streams.Stdout)
+			}
+			if reason == "" {
+				reason = result.Error
+			}
+			return nil, false, &HookBlockedError{Command: h.Command, Reason: reason}
+		}
+
+		out := strings.TrimSpace(streams.Stdout)
+		if !strings.HasPrefix(out, "{") {
+			continue
+		}
+		var decision hookDecision
+		if err := json.Unmarshal([]byte(out), &decision); err != nil {
+			return nil, false, &HookBlockedError{Command: h.Command, Reason: "invalid hook output: " + err.Error()}
+		}
+		switch strings.ToLower(decision.Decision) {
+		case "block", "deny":
+			return nil, false, &HookBlockedError{Command: h.Command, Reason: decision.Reason}
+		}
+		if decision.Params != nil {
+			params = decision.Params
+			rewritten = true
+		}
+	}
+	return params, rewritten, nil
+}
+
+// RunPostToolHooks runs the post_tool hooks matching a tool call and returns
+// their output for the model, or "" when there is nothing to report.
+func (r *Registry) RunPostToolHooks(ctx context.Context, toolName string, params map[string]interface{}, result Result) string {
+	var notes []string
+	for _, h := range r.hooks {
+		if !h.matches(HookPostTool, toolName) {
+			continue
+		}
+		input := hookInput{
+			Event:  HookPostTool,
+			Tool:   toolName,
+			Params: params,
+			Result: &hookResult{Success: result.Success, Output: result.Output, Error: result.Error},
+		}
+		hookRes, _ := r.runHook(ctx, h, input)
+		if note := formatHookOutput(h, hookRes); note != "" {
+			notes = append(notes, note)
+		}
+	}
+	return strings.Join(notes, "\n\n")
+}
+
+// RunStopHooks runs the stop hooks and returns their combined output, or ""
+// when there is nothing to report.
+func (r *Registry) RunStopHooks(ctx context.Context) string {
+	var notes []string
+	for _, h := range r.hooks {
+		if !h.matches(HookStop, "") {
+			continue
+		}
+		result, _ := r.runHook(ctx, h, hookInput{Event: HookStop})
+		if note := formatHookOutput(h, result); note != "" {
+			notes = append(notes, note)
+		}
+	}
+	return strings.Join(notes, "\n\n")
+}
+
+// formatHookOutput renders a hook's result for the model or the user.
+func formatHookOutput(h *Hook, result Result) string {
+	output := strings.TrimSpace(result.Output)
+	if output == "(no output)" {
+		output = ""
+	}
+	if result.Success {
+		if output == "" {
+			return ""
+		}
+		return fmt.Sprintf("[%s hook: %s]\n%s", h.Event, h.Command, output)
+	}
+	note := fmt.Sprintf("[%s hook failed: %s: %s]", h.Event, h.Command, result.Error)
+	if output != "" {
+		note += "\n" + output
+	}
+	return note
+}
@@ -0,0 +1,273 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/jeranaias/rigrun-tui/internal/config"
+	"github.com/jeranaias/rigrun-tui/internal/security"
+)
+
+// recordingExecutor returns its parameters' "file_path" and records the
+// parameters it was called with.
+type recordingExecutor struct {
+	calls []map[string]interface{}
+}
+
+func (e *recordingExecutor) Execute(ctx context.Context, params map[string]interface{}) (Result, error) {
+	e.calls = append(e.calls, params)
+	path, _ := params["file_path"].(string)
+	return Result{Success: true, Output: "edited " + path}, nil
+}
+
+// newHookExecutor returns an executor whose registry has a fake Edit tool
+// and the given hooks.
+func newHookExecutor(t *testing.T, hooks ...[3]string) (*Executor, *recordingExecutor) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("hook tests use POSIX shell commands")
+	}
+	rec := &recordingExecutor{}
+	r := NewRegistry()
+	r.Register(&Tool{Name: "Edit", Permission: PermissionAuto, Executor: rec})
+	for _, h := range hooks {
+		if err := r.AddHook(h[0], h[1], h[2], 10); err != nil {
+			t.Fatalf("AddHook(%v): %v", h, err)
+		}
+	}
+	exec := NewExecutor(r)
+	exec.SetAutoApproveLevel(PermissionAuto)
+	return exec, rec
+}
+
+func TestAddHook_Validation(t *testing.T) {
+	r := NewRegistry()
+	if err := r.AddHook("before_tool", "", "true", 0); err == nil {
+		t.Error("invalid event should be rejected")
+	}
+	if err := r.AddHook("pre_tool", "Edit(", "true", 0); err == nil {
+		t.Error("invalid matcher should be rejected")
+	}
+	if err := r.AddHook("pre_tool", "", "  ", 0); err == nil {
+		t.Error("empty command should be rejected")
+	}
+	if err := r.AddHook("POST_TOOL", "*", "true", 0); err != nil {
+		t.Errorf("valid hook rejected: %v", err)
+	}
+	if len(r.Hooks()) != 1 || r.Hooks()[0].Timeout != DefaultHookTimeout {
+		t.Errorf("hooks = %+v", r.Hooks())
+	}
+}
+
+func TestCompileHookMatcher_WholeName(t *testing.T) {
+	re, err := CompileHookMatcher("Edit|Write")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]bool{"Edit": true, "Write": true, "MultiEdit": false, "Editor": false} {
+		if got := re.MatchString(name); got != want {
+			t.Errorf("match %s = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestPreToolHook_Blocks(t *testing.T) {
+	exec, rec := newHookExecutor(t,
+		[3]string{"pre_tool", "Edit", `case "$RIGRUN_TOOL_FILE" in *.pb.go) echo "generated file" >&2; exit 1;; esac`},
+	)
+
+	result := exec.Execute(context.Background(), ToolCall{Name: "Edit", Params: map[string]interface{}{"file_path": "api.pb.go"}})
+	if result.Success || !strings.Contains(result.Error, "blocked by hook: generated file") {
+		t.Errorf("edit of generated file should be blocked, got %+v", result)
+	}
+
+	result = exec.Execute(context.Background(), ToolCall{Name: "Edit", Params: map[string]interface{}{"file_path": "api.go"}})
+	if !result.Success {
+		t.Errorf("edit of normal file should run, got %+v", result)
+	}
+	if len(rec.calls) != 1 {
+		t.Errorf("tool ran %d times, want 1", len(rec.calls))
+	}
+}
+
+func TestPreToolHook_DecisionAndRewrite(t *testing.T) {
+	exec, rec := newHookExecutor(t,
+		[3]string{"pre_tool", "", `echo '{"params": {"file_path": "rewritten.go"}}'`},
+	)
+	result := exec.Execute(context.Background(), ToolCall{Name: "Edit", Params: map[string]interface{}{"file_path": "original.go"}})
+	if !result.Success || result.Output != "edited rewritten.go" {
+		t.Errorf("params should be rewritten, got %+v", result)
+	}
+	if got := exec.History()[0].Params["file_path"]; got != "rewritten.go" {
+		t.Errorf("history params = %v", got)
+	}
+
+	exec, rec = newHookExecutor(t,
+		[3]string{"pre_tool", "", `echo '{"decision": "block", "reason": "frozen"}'`},
+	)
+	result = exec.Execute(context.Background(), ToolCall{Name: "Edit", Params: map[string]interface{}{"file_path": "a.go"}})
+	if result.Success || !strings.Contains(result.Error, "frozen") || len(rec.calls) != 0 {
+		t.Errorf("block decision should veto the call, got %+v", result)
+	}
+}
+
+func TestPreToolHook_RunsBeforeApproval(t *testing.T) {
+	exec, rec := newHookExecutor(t,
+		[3]string{"pre_tool", "", `echo '{"params": {"file_path": "rewritten.go"}}'`},
+	)
+	exec.Registry().SetPermissionOverride("Edit", PermissionAsk)
+	var asked []interface{}
+	exec.SetPermissionCallback(func(tool *Tool, params map[string]interface{}) bool {
+		asked = append(asked, params["file_path"])
+		return true
+	})
+
+	result := exec.Execute(context.Background(), ToolCall{Name: "Edit", Params: map[string]interface{}{"file_path": "original.go"}})
+	if !result.Success || len(rec.calls) != 1 {
+		t.Fatalf("approved call should run, got %+v", result)
+	}
+	// The user is asked once, about the parameters the call runs with
+	if len(asked) != 1 || asked[0] != "rewritten.go" {
+		t.Errorf("approval asked for %v, want [rewritten.go]", asked)
+	}
+}
+
+func TestPreToolHook_RewriteAuditedOnce(t *testing.T) {
+	exec, rec := newHookExecutor(t,
+		[3]string{"pre_tool", "", `echo '{"params": {"file_path": "rewritten.go"}}'`},
+	)
+	if err := exec.Registry().ApplyPermissionPolicy([]string{"Edit"}, nil, nil, "", "config"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Capture the audit log for this test only
+	prev := security.GlobalAuditLogger()
+	path := filepath.Join(t.TempDir(), "audit.log")
+	logger, err := security.NewAuditLogger(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	security.SetGlobalAuditLogger(logger)
+	t.Cleanup(func() {
+		security.SetGlobalAuditLogger(prev)
+		logger.Close()
+	})
+
+	result := exec.Execute(context.Background(), ToolCall{Name: "Edit", Params: map[string]interface{}{"file_path": "original.go"}})
+	if !result.Success || len(rec.calls) != 1 {
+		t.Fatalf("allowed call should run, got %+v", result)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// One decision, about the parameters the call runs with
+	if n := strings.Count(string(data), "PERMISSION_POLICY"); n != 1 {
+		t.Errorf("rewritten call audited %d times, want 1:\n%s", n, data)
+	}
+}
+
+func TestPreToolHook_TimeoutBlocks(t *testing.T) {
+	exec, rec := newHookExecutor(t)
+	if err := exec.Registry().AddHook("pre_tool", "", "sleep 5", 1); err != nil {
+		t.Fatal(err)
+	}
+	result := exec.Execute(context.Background(), ToolCall{Name: "Edit", Params: map[string]interface{}{"file_path": "a.go"}})
+	if result.Success || !strings.Contains(result.Error, "timed out") || len(rec.calls) != 0 {
+		t.Errorf("a hook that times out should block the call, got %+v", result)
+	}
+}
+
+func TestPostToolHook_OutputAppendedAndStdin(t *testing.T) {
+	dir := t.TempDir()
+	stdinFile := filepath.Join(dir, "stdin.json")
+	exec, _ := newHookExecutor(t,
+		[3]string{"post_tool", "Edit|Write", "cat > " + stdinFile + "; echo formatted $RIGRUN_TOOL_FILE"},
+		[3]string{"post_tool", "Read", "echo should not run"},
+		[3]string{"post_tool", "", "true"},
+	)
+
+	result := exec.Execute(context.Background(), ToolCall{Name: "Edit", Params: map[string]interface{}{"file_path": "main.go"}})
+	if !result.Success {
+		t.Fatalf("unexpected failure: %+v", result)
+	}
+	want := "edited main.go\n\n[post_tool hook: cat > " + stdinFile + "; echo formatted $RIGRUN_TOOL_FILE]\nformatted main.go"
+	if result.Output != want {
+		t.Errorf("output = %q, want %q", result.Output, want)
+	}
+
+	data, err := os.ReadFile(stdinFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var input hookInput
+	if err := json.Unmarshal(data, &input); err != nil {
+		t.Fatalf("hook stdin is not JSON: %v\n%s", err, data)
+	}
+	if input.Event != HookPostTool || input.Tool != "Edit" || input.Params["file_path"] != "main.go" ||
+		input.Result == nil || input.Result.Output != "edited main.go" || input.WorkDir == "" {
+		t.Errorf("hook input = %+v", input)
+	}
+}
+
+func TestRunStopHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook tests use POSIX shell commands")
+	}
+	r := NewRegistry()
+	if r.RunStopHooks(context.Background()) != "" || r.HasHooks(HookStop) {
+		t.Error("registry without hooks should report nothing")
+	}
+	r.AddHook("stop", "ignored", "echo tests passed", 0)
+	r.AddHook("stop", "", "echo 2 failures; exit 1", 0)
+
+	out := r.RunStopHooks(context.Background())
+	if !strings.Contains(out, "[stop hook: echo tests passed]\ntests passed") {
+		t.Errorf("missing successful hook output:\n%s", out)
+	}
+	if !strings.Contains(out, "[stop hook failed: echo 2 failures; exit 1: command exited with code 1]\n2 failures") {
+		t.Errorf("missing failed hook output:\n%s", out)
+	}
+}
+
+func TestHooks_UseBashSandboxSettings(t *testing.T) {
+	r := NewRegistry()
+	r.ConfigureSandbox(SandboxConfig{Mode: SandboxRequired})
+	if shell := r.hookShell(); shell.Sandbox.Mode != SandboxRequired || shell.MaxTimeout == 0 {
+		t.Errorf("hooks should run with the Bash tool's settings, got %+v", shell.Sandbox)
+	}
+}
+
+func TestNewConfiguredRegistry_ReportsSetupErrors(t *testing.T) {
+	cfg := config.Default()
+	cfg.Hooks = []config.HookConfig{
+		{Event: "post_tool", Matcher: "Edit", Command: "true"},
+		{Event: "pre_tool", Matcher: "(", Command: "true"},
+	}
+	cfg.Permissions.Deny = []string{"Bash(git push *)"}
+
+	r, err := NewConfiguredRegistry(cfg)
+	if err == nil || !strings.Contains(err.Error(), "hooks[1]") {
+		t.Fatalf("NewConfiguredRegistry() error = %v, want the invalid hook reported", err)
+	}
+	if r == nil {
+		t.Fatal("NewConfiguredRegistry() should return the registry with the rest applied")
+	}
+	if !r.HasHooks(HookPostTool) {
+		t.Error("the valid hook should be added")
+	}
+	if r.HasHooks(HookPreTool) {
+		t.Error("the invalid hook should not be added")
+	}
+	if r.PermissionPolicy() == nil {
+		t.Error("the permission policy should be applied")
+	}
+}
@@ -808,6 +808,27 @@ func GenerateToolSystemPrompt(registry *Registry) string {
 	return sb.String()
 }
 
+// GenerateAllowedToolPrompt creates a system prompt offering only the named
+// tools, for a turn a custom command limits. Names the registry doesn't know
+// are left out.
+func GenerateAllowedToolPrompt(registry *Registry, allowed []string) string {
+	if registry == nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	for _, name := range allowed {
+		if tool := registry.Get(name); tool != nil {
+			fmt.Fprintf(&sb, "- %s: %s\n", tool.Name, tool.GetShortDescription())
+		}
+	}
+	if sb.Len() == 0 {
+		return "You are a helpful AI assistant. No tools are available for this request."
+	}
+	return "You are a helpful AI assistant with tool access. Only these tools are available for this request:\n" +
+		sb.String() + "\nUse them when you need information you don't have."
+}
+
 // GenerateMinimalToolPrompt creates a shorter system prompt for models with
 // limited context windows or strong native tool support.
 func GenerateMinimalToolPrompt() string {
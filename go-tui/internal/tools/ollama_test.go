@@ -0,0 +1,29 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateAllowedToolPrompt(t *testing.T) {
+	r := NewRegistry()
+
+	prompt := GenerateAllowedToolPrompt(r, []string{"Read", "Grep", "NoSuchTool"})
+	for _, want := range []string{"- Read:", "- Grep:"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt should offer %q:\n%s", want, prompt)
+		}
+	}
+	for _, other := range []string{"Bash", "WebSearch", "NoSuchTool"} {
+		if strings.Contains(prompt, other) {
+			t.Errorf("prompt should not mention %s:\n%s", other, prompt)
+		}
+	}
+
+	if prompt := GenerateAllowedToolPrompt(r, []string{"NoSuchTool"}); !strings.Contains(prompt, "No tools") {
+		t.Errorf("prompt with no known tools = %q", prompt)
+	}
+}
@@ -0,0 +1,515 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package tools provides the agentic tool system for rigrun TUI.
+// permission_policy.go implements declarative permission policies.
+//
+// A permission policy lets CI jobs and scripted agent runs decide tool calls
+// without anyone at the keyboard. It is a [permissions] table in config.toml
+// or a file passed with --permission-policy:
+//
+//	default = "deny"
+//	allow   = ["Read", "Grep", "Glob", "Bash(go test *)", "Edit(internal/**)"]
+//	ask     = ["risk:critical"]
+//	deny    = ["Bash(git push *)"]
+//
+// A rule is one of:
+//
+//	Tool           every call to the tool
+//	Tool(pattern)  calls whose main argument matches the glob pattern:
+//	               the command for Bash, the path for file tools, the URL
+//	               for WebFetch and the query for WebSearch
+//	risk:level     tools at or above a risk level (low, medium, high, critical)
+//
+// A rule of "*" matches every tool.
+//
+// In path patterns "*" stays within one directory and "**" crosses them.
+// Relative patterns are matched against the path relative to the working
+// directory. In command patterns "*" matches anything, so "go test *" allows
+// any "go test" invocation.
+//
+// Deny rules win over ask rules, which win over allow rules. A call that no
+// rule matches gets the default decision, or the tool's own permission when
+// the policy has no default. The decision on every executed or refused call
+// is audit logged with the rule that matched.
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/jeranaias/rigrun-tui/internal/security"
+)
+
+// PermissionRule is one parsed allow, ask or deny rule.
+type PermissionRule struct {
+	// Spec is the rule as written, e.g. "Bash(go test *)"
+	Spec string
+
+	// Tool is the tool name, or "*" for every tool
+	Tool string
+
+	// Pattern is the argument glob; empty matches every call
+	Pattern string
+
+	// MinRisk applies the rule to tools at or above a risk level
+	MinRisk *RiskLevel
+
+	re *regexp.Regexp
+}
+
+// PermissionDecision is the outcome of evaluating a tool call.
+type PermissionDecision struct {
+	// Level is the effective permission for the call
+	Level PermissionLevel
+
+	// Rule is the policy rule that decided the call: the rule spec, "default",
+	// or empty when no policy applied
+	Rule string
+
+	// Source names where the policy came from (a file path or "config")
+	Source string
+}
+
+// Describe returns a human-readable reason for the decision.
+func (d PermissionDecision) Describe() string {
+	if d.Rule == "" {
+		return "tool permission " + strings.ToLower(d.Level.String())
+	}
+	if d.Source == "" {
+		return "policy rule " + d.Rule
+	}
+	return fmt.Sprintf("policy rule %s (%s)", d.Rule, d.Source)
+}
+
+// PermissionPolicy is a declarative set of allow, ask and deny rules.
+type PermissionPolicy struct {
+	allow []*PermissionRule
+	ask   []*PermissionRule
+	deny  []*PermissionRule
+
+	// def is the decision for calls no rule matches; nil keeps the tool's own
+	def *PermissionLevel
+
+	source string
+}
+
+// NewPermissionPolicy parses a permission policy. def is "allow", "ask",
+// "deny" or empty; source names the policy in decisions and audit logs.
+func NewPermissionPolicy(allow, ask, deny []string, def, source string) (*PermissionPolicy, error) {
+	p := &PermissionPolicy{source: source}
+
+	if strings.TrimSpace(def) != "" {
+		level, err := ParsePermissionLevel(def)
+		if err != nil {
+			return nil, fmt.Errorf("default: %w", err)
+		}
+		p.def = &level
+	}
+
+	var err error
+	if p.allow, err = parsePermissionRules("allow", allow); err != nil {
+		return nil, err
+	}
+	if p.ask, err = parsePermissionRules("ask", ask); err != nil {
+		return nil, err
+	}
+	if p.deny, err = parsePermissionRules("deny", deny); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Source returns where the policy came from.
+func (p *PermissionPolicy) Source() string {
+	return p.source
+}
+
+func parsePermissionRules(kind string, specs []string) ([]*PermissionRule, error) {
+	rules := make([]*PermissionRule, 0, len(specs))
+	for _, spec := range specs {
+		rule, err := ParsePermissionRule(spec)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", kind, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// ParsePermissionRule parses a single rule such as "Read", "Bash(go test *)",
+// "Edit(internal/**)", "*" or "risk:high".
+func ParsePermissionRule(spec string) (*PermissionRule, error) {
+	s := strings.TrimSpace(spec)
+	if s == "" {
+		return nil, fmt.Errorf("empty permission rule")
+	}
+
+	if level, ok := strings.CutPrefix(strings.ToLower(s), "risk:"); ok {
+		risk, err := parseRiskLevel(level)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", spec, err)
+		}
+		return &PermissionRule{Spec: s, Tool: "*", MinRisk: &risk}, nil
+	}
+
+	rule := &PermissionRule{Spec: s, Tool: s}
+	if open := strings.IndexByte(s, '('); open != -1 {
+		if !strings.HasSuffix(s, ")") {
+			return nil, fmt.Errorf("rule %q: missing closing parenthesis", spec)
+		}
+		rule.Tool = strings.TrimSpace(s[:open])
+		rule.Pattern = strings.TrimSpace(s[open+1 : len(s)-1])
+		if rule.Pattern == "" {
+			return nil, fmt.Errorf("rule %q: empty pattern", spec)
+		}
+	}
+
+	if rule.Tool == "" || strings.ContainsAny(rule.Tool, "() \t") {
+		return nil, fmt.Errorf("rule %q: invalid tool name", spec)
+	}
+	if rule.Tool == "*" && rule.Pattern != "" {
+		return nil, fmt.Errorf("rule %q: a pattern needs a tool name", spec)
+	}
+
+	if rule.Pattern != "" {
+		var err error
+		if rule.re, err = compilePermissionGlob(rule.Pattern, permissionArgKind(rule.Tool) == argCommand); err != nil {
+			return nil, fmt.Errorf("rule %q: %w", spec, err)
+		}
+	}
+	return rule, nil
+}
+
+func parseRiskLevel(s string) (RiskLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "low":
+		return RiskLow, nil
+	case "medium":
+		return RiskMedium, nil
+	case "high":
+		return RiskHigh, nil
+	case "critical":
+		return RiskCritical, nil
+	default:
+		return RiskLow, fmt.Errorf("unknown risk level %q (must be low, medium, high or critical)", s)
+	}
+}
+
+// Evaluate returns the policy decision for a tool call. ok is false when no
+// rule matched and the policy has no default.
+func (p *PermissionPolicy) Evaluate(tool *Tool, toolName string, params map[string]interface{}) (decision PermissionDecision, ok bool) {
+	return p.evaluate(tool, toolName, params, "")
+}
+
+// evaluate is Evaluate for a call working in workDir ("" for the process's
+// working directory), which relative path patterns are matched against.
+func (p *PermissionPolicy) evaluate(tool *Tool, toolName string, params map[string]interface{}, workDir string) (decision PermissionDecision, ok bool) {
+	decision.Source = p.source
+
+	// Deny beats ask beats allow, whatever order the rules are written in
+	for _, group := range []struct {
+		rules []*PermissionRule
+		level PermissionLevel
+	}{
+		{p.deny, PermissionNever},
+		{p.ask, PermissionAsk},
+		{p.allow, PermissionAuto},
+	} {
+		for _, rule := range group.rules {
+			if rule.matches(tool, toolName, params, group.level == PermissionAuto, workDir) {
+				decision.Level = group.level
+				decision.Rule = rule.Spec
+				return decision, true
+			}
+		}
+	}
+
+	if p.def != nil {
+		decision.Level = *p.def
+		decision.Rule = "default"
+		return decision, true
+	}
+	return decision, false
+}
+
+// matches reports whether the rule applies to a tool call. Allow rules are
+// matched strictly: a command pattern only allows a simple command, never
+// one chained with other commands or using substitutions or redirections.
+func (rule *PermissionRule) matches(tool *Tool, toolName string, params map[string]interface{}, strict bool, workDir string) bool {
+	if rule.MinRisk != nil {
+		return tool != nil && tool.RiskLevel >= *rule.MinRisk
+	}
+	if rule.Tool != "*" && !strings.EqualFold(rule.Tool, toolName) {
+		return false
+	}
+	if rule.re == nil {
+		return true
+	}
+
+	arg, ok := permissionArg(toolName, params)
+	if !ok {
+		// A call without the argument can't be shown to match the pattern
+		return false
+	}
+
+	switch permissionArgKind(toolName) {
+	case argCommand:
+		return rule.matchCommand(arg, strict)
+	case argPath:
+		return rule.matchPath(arg, workDir)
+	default:
+		return rule.re.MatchString(arg)
+	}
+}
+
+// matchCommand matches a shell command. Strict (allow) matching requires a
+// single simple command; otherwise any command in a chain may match, so a
+// deny rule can't be sidestepped with "true && git push".
+func (rule *PermissionRule) matchCommand(command string, strict bool) bool {
+	parts := splitShellCommands(command)
+	if strict {
+		if len(parts) != 1 || strings.ContainsAny(command, "`<>") || strings.Contains(command, "$(") {
+			return false
+		}
+		return rule.re.MatchString(parts[0])
+	}
+	for _, part := range parts {
+		if rule.re.MatchString(part) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPath matches a file path. Relative patterns match paths inside the
+// working directory (workDir, or the process's if empty); absolute patterns
+// match the absolute path.
+func (rule *PermissionRule) matchPath(path, workDir string) bool {
+	if workDir != "" && !filepath.IsAbs(path) {
+		path = filepath.Join(workDir, path)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	abs = filepath.ToSlash(abs)
+
+	pattern := filepath.ToSlash(rule.Pattern)
+	if strings.HasPrefix(pattern, "/") || filepath.IsAbs(rule.Pattern) {
+		return rule.re.MatchString(abs)
+	}
+
+	cwd := workDir
+	if cwd == "" {
+		if cwd, err = os.Getwd(); err != nil {
+			return false
+		}
+	}
+	rel, err := filepath.Rel(cwd, filepath.FromSlash(abs))
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == ".." || strings.HasPrefix(rel, "../") {
+		return false
+	}
+	return rule.re.MatchString(rel)
+}
+
+// Argument kinds a rule pattern is matched against.
+const (
+	argOther = iota
+	argCommand
+	argPath
+)
+
+// permissionArgKind returns how a tool's pattern argument is matched.
+func permissionArgKind(toolName string) int {
+	switch strings.ToLower(toolName) {
+	case "bash":
+		return argCommand
+	case "read", "write", "edit", "glob", "grep":
+		return argPath
+	default:
+		return argOther
+	}
+}
+
+// permissionArg returns the argument a rule pattern is matched against.
+func permissionArg(toolName string, params map[string]interface{}) (string, bool) {
+	var keys []string
+	switch strings.ToLower(toolName) {
+	case "bash":
+		keys = []string{"command"}
+	case "read", "write", "edit":
+		keys = []string{"file_path"}
+	case "glob", "grep":
+		// Searches without a path run in the working directory
+		if path, ok := params["path"].(string); ok && path != "" {
+			return path, true
+		}
+		return ".", true
+	case "webfetch":
+		keys = []string{"url"}
+	case "websearch":
+		keys = []string{"query"}
+	default:
+		keys = []string{"file_path", "path", "command", "url"}
+	}
+	for _, key := range keys {
+		if value, ok := params[key].(string); ok && value != "" {
+			return strings.TrimSpace(value), true
+		}
+	}
+	return "", false
+}
+
+// shellSeparatorRegex matches the separators between shell commands.
+var shellSeparatorRegex = regexp.MustCompile(`&&|\|\||[;&|\n]`)
+
+// splitShellCommands splits a command line on ;, &, &&, || and | and newlines.
+func splitShellCommands(command string) []string {
+	parts := shellSeparatorRegex.Split(command, -1)
+	commands := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			commands = append(commands, part)
+		}
+	}
+	return commands
+}
+
+// compilePermissionGlob compiles a rule pattern to an anchored regular
+// expression. In path patterns "*" and "?" stay within a directory and "**"
+// crosses directories; in command patterns "*" matches anything.
+func compilePermissionGlob(pattern string, command bool) (*regexp.Regexp, error) {
+	if !command {
+		pattern = filepath.ToSlash(pattern)
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '*' && !command && i+1 < len(pattern) && pattern[i+1] == '*':
+			i++
+			if i+1 < len(pattern) && pattern[i+1] == '/' {
+				// "**/" matches zero or more directories
+				i++
+				b.WriteString("(?:.*/)?")
+			} else {
+				b.WriteString(".*")
+			}
+		case c == '*' && command:
+			b.WriteString(".*")
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?' && command:
+			b.WriteString(".")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+
+	if command {
+		// "go test *" also matches a bare "go test"
+		re := strings.TrimSuffix(b.String(), " .*$")
+		if re != b.String() {
+			return regexp.Compile(re + "(?: .*)?$")
+		}
+	}
+	return regexp.Compile(b.String())
+}
+
+// =============================================================================
+// REGISTRY INTEGRATION
+// =============================================================================
+
+// SetPermissionPolicy sets the declarative permission policy consulted by
+// GetPermissionWithParams. Pass nil to remove it.
+func (r *Registry) SetPermissionPolicy(p *PermissionPolicy) {
+	r.permissionPolicy = p
+}
+
+// ApplyPermissionPolicy parses a permission policy from configuration values
+// and sets it. An empty policy removes any policy that was set.
+func (r *Registry) ApplyPermissionPolicy(allow, ask, deny []string, def, source string) error {
+	if len(allow) == 0 && len(ask) == 0 && len(deny) == 0 && strings.TrimSpace(def) == "" {
+		r.permissionPolicy = nil
+		return nil
+	}
+	p, err := NewPermissionPolicy(allow, ask, deny, def, source)
+	if err != nil {
+		return err
+	}
+	r.permissionPolicy = p
+	return nil
+}
+
+// PermissionPolicy returns the declarative permission policy, or nil.
+func (r *Registry) PermissionPolicy() *PermissionPolicy {
+	return r.permissionPolicy
+}
+
+// PermissionDecision returns the effective permission for a tool call and
+// the policy rule that decided it.
+//
+// SECURITY: A policy rule can't lower a PermissionFunc result of Ask or
+// Never (path-based security checks), and administrator policy minimums are
+// applied last, so they always win.
+func (r *Registry) PermissionDecision(toolName string, params map[string]interface{}) PermissionDecision {
+	return r.decide(toolName, params, "")
+}
+
+// decide is PermissionDecision for a call working in workDir ("" for the
+// process's working directory).
+func (r *Registry) decide(toolName string, params map[string]interface{}, workDir string) PermissionDecision {
+	decision := PermissionDecision{Level: r.permissionWithParams(toolName, params)}
+
+	if r.permissionPolicy == nil {
+		decision.Level = r.applyPolicy(toolName, decision.Level)
+		return decision
+	}
+
+	tool := r.Get(toolName)
+	if policyDecision, ok := r.permissionPolicy.evaluate(tool, toolName, params, workDir); ok {
+		if tool != nil && tool.PermissionFunc != nil {
+			if funcPermission := tool.PermissionFunc(params); funcPermission > policyDecision.Level {
+				policyDecision.Level = funcPermission
+			}
+		}
+		decision = policyDecision
+	}
+	decision.Level = r.applyPolicy(toolName, decision.Level)
+	return decision
+}
+
+// AuditPermissionDecision logs the decision on a tool call that is about to
+// be executed or refused. Looking up a permission (to label a tool in the
+// UI, say) decides nothing and is not logged. Nothing is logged when no
+// permission policy is set.
+func (r *Registry) AuditPermissionDecision(toolName string, decision PermissionDecision) {
+	if r.permissionPolicy == nil {
+		return
+	}
+
+	// AU-2: Log each policy decision with the rule that matched
+	rule := decision.Rule
+	if rule == "" {
+		rule = "none"
+	}
+	security.AuditLogEvent("TOOL", "PERMISSION_POLICY", map[string]string{
+		"tool":     toolName,
+		"decision": decision.Level.String(),
+		"rule":     rule,
+		"source":   r.permissionPolicy.source,
+	})
+}
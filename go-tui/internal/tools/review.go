@@ -0,0 +1,107 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package tools provides the agentic tool system for rigrun TUI.
+//
+// This file implements hunk-level review of Edit and Write calls: the
+// pending changes from one agent turn are shown to the user as diffs, each
+// hunk is accepted, rejected or edited, and only the chosen hunks reach the
+// disk. The tool result tells the model exactly what was applied.
+package tools
+
+import (
+	"context"
+	"time"
+
+	"github.com/jeranaias/rigrun-tui/internal/diff"
+)
+
+// =============================================================================
+// PENDING CHANGES
+// =============================================================================
+
+// PendingChange is an Edit or Write call whose diff is awaiting review.
+type PendingChange struct {
+	Index   int          // Position of the call in the agent turn
+	Preview *DiffPreview // Proposed change
+}
+
+// HunkReviewFunc shows pending changes to the user and returns one review per
+// change, in order. A nil review (or a nil slice) rejects the change
+// entirely. It is typically implemented by the UI layer and blocks until the
+// user has finished.
+type HunkReviewFunc func(ctx context.Context, changes []PendingChange) []*diff.Review
+
+// PendingChanges returns the changes that can be reviewed together, starting
+// with calls[start]. The batch extends over the directly following Edit and
+// Write calls of the turn. It stops at the first call without a diff, since
+// that call (a Bash command, say) may change files the later previews were
+// computed from, and before a second change to a file already in it, since
+// that change can only be previewed once the first has been written. It
+// returns nil when calls[start] has no diff to review.
+func PendingChanges(calls []ToolCall, start int) []PendingChange {
+	var changes []PendingChange
+	files := make(map[string]bool)
+	for i := start; i < len(calls); i++ {
+		call := calls[i]
+		if !ShouldShowDiff(call.Name, call.Params) {
+			break
+		}
+		preview, err := GetToolDiffPreview(call.Name, call.Params)
+		if err != nil || preview == nil {
+			// Executing the call reports the problem
+			break
+		}
+		if files[preview.FilePath] {
+			break
+		}
+		files[preview.FilePath] = true
+		changes = append(changes, PendingChange{Index: i, Preview: preview})
+	}
+	if len(changes) == 0 || changes[0].Index != start {
+		return nil
+	}
+	return changes
+}
+
+// =============================================================================
+// REVIEWED EXECUTION
+// =============================================================================
+
+// ExecuteReviewed applies an Edit or Write call as the user reviewed it. A
+// fully accepted change runs the original call; a partially accepted or
+// edited one writes the reviewed content instead; a rejected one is not
+// executed. The review is the user's approval, so only tools denied by
+// policy are still blocked. The result starts with the review summary.
+func (e *Executor) ExecuteReviewed(ctx context.Context, call ToolCall, review *diff.Review) Result {
+	if review == nil || (review.Applied() == 0 && len(review.Decisions) > 0) {
+		result := Result{Success: false, Error: "the user rejected the change; the file was not modified"}
+		if review != nil {
+			result.Error = review.Summary()
+		}
+		e.addToHistory(ExecutionRecord{
+			ToolName:  call.Name,
+			Params:    call.Params,
+			Timestamp: time.Now(),
+			Approved:  false,
+			Result:    result,
+		})
+		return result
+	}
+
+	if !review.AllAccepted() {
+		call = ToolCall{
+			Name: "Write",
+			Params: map[string]interface{}{
+				"file_path": call.Params["file_path"],
+				"content":   review.Content(),
+			},
+		}
+	}
+
+	result := e.execute(ctx, call, true)
+	if result.Success {
+		result.Output = review.Summary() + "\n\n" + result.Output
+	}
+	return result
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jeranaias/rigrun-tui/internal/diff"
+)
+
+// reviewFixture writes a 30-line file and returns its path and content.
+func reviewFixture(t *testing.T) (string, string) {
+	t.Helper()
+	var lines []string
+	for i := 0; i < 30; i++ {
+		lines = append(lines, fmt.Sprintf("line%d", i))
+	}
+	content := strings.Join(lines, "\n") + "\n"
+	path := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path, content
+}
+
+func TestPendingChanges_Batch(t *testing.T) {
+	path, content := reviewFixture(t)
+	other := filepath.Join(filepath.Dir(path), "other.txt")
+
+	calls := []ToolCall{
+		{Name: "Write", Params: map[string]interface{}{"file_path": path, "content": strings.Replace(content, "line5", "x", 1)}},
+		{Name: "Write", Params: map[string]interface{}{"file_path": other, "content": "new\n"}},
+		{Name: "Edit", Params: map[string]interface{}{"file_path": path, "old_string": "line20", "new_string": "y"}},
+		{Name: "Bash", Params: map[string]interface{}{"command": "echo more >> " + other}},
+		{Name: "Edit", Params: map[string]interface{}{"file_path": other, "old_string": "new", "new_string": "newer"}},
+	}
+
+	changes := PendingChanges(calls, 0)
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes in the batch, got %d", len(changes))
+	}
+	if changes[0].Index != 0 || changes[1].Index != 1 {
+		t.Errorf("unexpected batch indexes %d, %d", changes[0].Index, changes[1].Index)
+	}
+
+	// The second change to the same file starts its own batch, which ends
+	// at the Bash call: the later preview may be stale once it has run
+	if changes := PendingChanges(calls, 2); len(changes) != 1 || changes[0].Index != 2 {
+		t.Errorf("expected a batch with the repeated file only, got %+v", changes)
+	}
+	if changes := PendingChanges(calls, 3); changes != nil {
+		t.Errorf("a Bash call should not start a batch, got %+v", changes)
+	}
+}
+
+func TestExecuteReviewed(t *testing.T) {
+	path, content := reviewFixture(t)
+	proposed := strings.Replace(strings.Replace(content, "line5\n", "x\n", 1), "line20\n", "y\n", 1)
+	call := ToolCall{Name: "Write", Params: map[string]interface{}{"file_path": path, "content": proposed}}
+
+	// Edit and Write are not auto-approved; the review is the approval
+	exec := NewExecutor(NewRegistry())
+	exec.SetAutoApproveLevel(PermissionAuto)
+
+	changes := PendingChanges([]ToolCall{call}, 0)
+	if len(changes) != 1 || len(changes[0].Preview.Diff.Hunks) != 2 {
+		t.Fatalf("expected one change with two hunks, got %+v", changes)
+	}
+	review := diff.NewReview(changes[0].Preview.Diff)
+	review.Reject(0)
+	review.Accept(1)
+
+	result := exec.ExecuteReviewed(context.Background(), call, review)
+	if !result.Success {
+		t.Fatalf("reviewed write failed: %s", result.Error)
+	}
+	if !strings.Contains(result.Output, "applied 1 of 2") {
+		t.Errorf("result should describe the applied hunks:\n%s", result.Output)
+	}
+
+	data, _ := os.ReadFile(path)
+	want := strings.Replace(content, "line20\n", "y\n", 1)
+	if string(data) != want {
+		t.Errorf("file content mismatch\ngot:\n%s\nwant:\n%s", data, want)
+	}
+
+	// Rejecting everything leaves the file alone
+	review.SetAll(diff.HunkRejected)
+	result = exec.ExecuteReviewed(context.Background(), call, review)
+	if result.Success || !strings.Contains(result.Error, "not modified") {
+		t.Errorf("rejected change should not run, got %+v", result)
+	}
+	if data, _ := os.ReadFile(path); string(data) != want {
+		t.Error("rejected change modified the file")
+	}
+}
@@ -0,0 +1,222 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// sandbox.go - Optional OS-level sandbox for the Bash tool.
+//
+// Pattern-based command blocking (validateCommand) is a first line of defense
+// but can be bypassed. When enabled, the sandbox runs each command in a Linux
+// user namespace where the filesystem is read-only except the working
+// directory and a private /tmp, networking is unavailable unless allowed,
+// resource limits apply, and a seccomp filter blocks namespace, mount, module
+// and tracing syscalls. Where the sandbox cannot be created the command runs
+// as before, unless the mode is "required".
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// =============================================================================
+// SANDBOX CONFIGURATION
+// =============================================================================
+
+// SandboxMode controls whether Bash commands run in the sandbox.
+type SandboxMode string
+
+const (
+	// SandboxOff runs commands directly (pattern blocking only).
+	SandboxOff SandboxMode = "off"
+
+	// SandboxAuto uses the sandbox when available and falls back otherwise.
+	SandboxAuto SandboxMode = "auto"
+
+	// SandboxRequired refuses to run commands when the sandbox is unavailable.
+	SandboxRequired SandboxMode = "required"
+)
+
+// Sandbox profiles reported in Result.Sandbox.
+const (
+	// SandboxProfileNone means the command ran without a sandbox.
+	SandboxProfileNone = "none"
+
+	// SandboxProfileIsolated is the default profile: no network.
+	SandboxProfileIsolated = "isolated"
+
+	// SandboxProfileNetwork is the isolated profile with host networking.
+	SandboxProfileNetwork = "isolated+network"
+)
+
+// ParseSandboxMode parses a configured sandbox mode. Empty means off.
+func ParseSandboxMode(s string) (SandboxMode, error) {
+	switch SandboxMode(strings.ToLower(strings.TrimSpace(s))) {
+	case "", SandboxOff:
+		return SandboxOff, nil
+	case SandboxAuto:
+		return SandboxAuto, nil
+	case SandboxRequired:
+		return SandboxRequired, nil
+	default:
+		return SandboxOff, fmt.Errorf("invalid sandbox mode %q (valid: off, auto, required)", s)
+	}
+}
+
+// SandboxLimits are resource limits applied inside the sandbox.
+// Zero values use the defaults.
+type SandboxLimits struct {
+	// CPUSeconds is the CPU time limit (RLIMIT_CPU). Default: the command
+	// timeout times the number of CPUs, so parallel builds are not cut short.
+	CPUSeconds int
+
+	// MemoryBytes is the address space limit (RLIMIT_AS). Default: 4 GiB.
+	MemoryBytes int64
+
+	// MaxProcesses is the process/thread limit (RLIMIT_NPROC). Default: 1024.
+	MaxProcesses int
+
+	// MaxFileSize is the largest file a command may write (RLIMIT_FSIZE). Default: 256 MiB.
+	MaxFileSize int64
+
+	// TmpSize is the size of the private /tmp. Default: 128 MiB.
+	TmpSize int64
+}
+
+// Default sandbox limits.
+const (
+	DefaultSandboxMemory       = 4 << 30
+	DefaultSandboxMaxProcesses = 1024
+	DefaultSandboxMaxFileSize  = 256 << 20
+	DefaultSandboxTmpSize      = 128 << 20
+)
+
+// withDefaults fills unset limits.
+func (l SandboxLimits) withDefaults(timeoutSec int) SandboxLimits {
+	if l.CPUSeconds <= 0 {
+		l.CPUSeconds = timeoutSec * runtime.NumCPU()
+	}
+	if l.MemoryBytes <= 0 {
+		l.MemoryBytes = DefaultSandboxMemory
+	}
+	if l.MaxProcesses <= 0 {
+		l.MaxProcesses = DefaultSandboxMaxProcesses
+	}
+	if l.MaxFileSize <= 0 {
+		l.MaxFileSize = DefaultSandboxMaxFileSize
+	}
+	if l.TmpSize <= 0 {
+		l.TmpSize = DefaultSandboxTmpSize
+	}
+	return l
+}
+
+// SandboxConfig configures the Bash sandbox.
+type SandboxConfig struct {
+	// Mode selects off, auto or required.
+	Mode SandboxMode
+
+	// AllowNetwork keeps host networking inside the sandbox.
+	AllowNetwork bool
+
+	// Limits are the resource limits applied to each command.
+	Limits SandboxLimits
+}
+
+// profile returns the profile name for this configuration.
+func (c SandboxConfig) profile() string {
+	if c.AllowNetwork {
+		return SandboxProfileNetwork
+	}
+	return SandboxProfileIsolated
+}
+
+// ConfigureSandbox sets the sandbox for this registry's Bash tool. The
+// built-in tool definitions are shared, so the Bash tool is replaced with a
+// registry-local copy rather than modified in place.
+func (r *Registry) ConfigureSandbox(cfg SandboxConfig) {
+	tool := r.tools["Bash"]
+	if tool == nil {
+		return
+	}
+	bash, ok := tool.Executor.(*BashExecutor)
+	if !ok {
+		return
+	}
+
+	local := *bash
+	local.Sandbox = cfg
+	copied := *tool
+	copied.Executor = &local
+	r.tools["Bash"] = &copied
+}
+
+// ApplySandboxSettings configures the Bash sandbox from config values. An
+// invalid mode fails closed: the sandbox is required and the error returned.
+func (r *Registry) ApplySandboxSettings(mode string, allowNetwork bool) error {
+	parsed, err := ParseSandboxMode(mode)
+	if err != nil {
+		parsed = SandboxRequired
+	}
+	r.ConfigureSandbox(SandboxConfig{Mode: parsed, AllowNetwork: allowNetwork})
+	return err
+}
+
+// =============================================================================
+// SANDBOX AVAILABILITY
+// =============================================================================
+
+var (
+	sandboxProbeOnce   sync.Once
+	sandboxProbeErr    error
+	sandboxInitEnabled bool
+)
+
+// SandboxAvailable reports whether the sandbox can be created on this host,
+// and if not, why. The probe runs once per process.
+func SandboxAvailable() (bool, string) {
+	sandboxProbeOnce.Do(func() {
+		if !sandboxInitEnabled {
+			sandboxProbeErr = fmt.Errorf("sandbox helper not registered (MaybeRunSandboxInit not called)")
+			return
+		}
+		sandboxProbeErr = probeSandbox()
+	})
+	if sandboxProbeErr != nil {
+		return false, sandboxProbeErr.Error()
+	}
+	return true, ""
+}
+
+// MaybeRunSandboxInit must be called at the start of main (and TestMain in
+// tests that use the sandbox). In a sandbox helper process it sets up the
+// sandbox and execs the command, never returning; otherwise it records that
+// the helper is available and returns immediately.
+func MaybeRunSandboxInit() {
+	sandboxInitEnabled = true
+	runSandboxInitIfRequested()
+}
+
+// =============================================================================
+// OUTPUT LIMITING
+// =============================================================================
+
+// limitedBuffer keeps at most limit bytes and silently discards the rest, so
+// a chatty command cannot exhaust memory and is not killed by a closed pipe.
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+// Write implements io.Writer.
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); room > 0 {
+		if len(p) > room {
+			b.Buffer.Write(p[:room])
+		} else {
+			b.Buffer.Write(p)
+		}
+	}
+	return len(p), nil
+}
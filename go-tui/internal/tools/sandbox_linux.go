@@ -0,0 +1,384 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+//go:build linux
+// +build linux
+
+// sandbox_linux.go - Linux namespace and seccomp sandbox for the Bash tool.
+//
+// The sandbox re-executes the rigrun binary as a helper ("rigrun-sandbox")
+// inside new user, mount, PID and IPC namespaces (and a network namespace
+// unless networking is allowed). The helper configures mounts, resource
+// limits, capabilities and seccomp, then execs bash in place of itself, so
+// the command never runs with more privilege than the helper had.
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// sandboxHelperArg0 identifies a sandbox helper process.
+	sandboxHelperArg0 = "rigrun-sandbox"
+
+	// sandboxInitEnv carries the helper configuration.
+	sandboxInitEnv = "RIGRUN_SANDBOX_INIT"
+
+	// sandboxSetupExitCode is the helper's exit code when setup fails.
+	sandboxSetupExitCode = 125
+)
+
+// sandboxInit is the configuration passed from rigrun to the helper.
+type sandboxInit struct {
+	WorkDir string        `json:"workdir"`
+	Limits  SandboxLimits `json:"limits"`
+}
+
+// =============================================================================
+// PARENT SIDE
+// =============================================================================
+
+// sandboxCommand returns a command that runs the shell command inside the
+// sandbox. The caller sets output streams and runs it.
+func sandboxCommand(ctx context.Context, cfg SandboxConfig, workDir, command string, timeout time.Duration, env []string) (*exec.Cmd, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("locate sandbox helper: %w", err)
+	}
+	if workDir == "" {
+		if workDir, err = os.Getwd(); err != nil {
+			return nil, err
+		}
+	}
+	if workDir, err = filepath.Abs(workDir); err != nil {
+		return nil, err
+	}
+	if resolved, err := filepath.EvalSymlinks(workDir); err == nil {
+		workDir = resolved
+	}
+
+	setup, err := json.Marshal(sandboxInit{
+		WorkDir: workDir,
+		Limits:  cfg.Limits.withDefaults(int(timeout.Seconds())),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cloneflags := uintptr(unix.CLONE_NEWUSER | unix.CLONE_NEWNS | unix.CLONE_NEWPID | unix.CLONE_NEWIPC | unix.CLONE_NEWUTS)
+	if !cfg.AllowNetwork {
+		cloneflags |= unix.CLONE_NEWNET
+	}
+
+	cmd := exec.CommandContext(ctx, exe)
+	cmd.Args = []string{sandboxHelperArg0, command}
+	cmd.Dir = workDir
+	cmd.Env = append(append([]string{}, env...), sandboxInitEnv+"="+string(setup))
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags: cloneflags,
+		UidMappings: []syscall.SysProcIDMap{
+			{ContainerID: 0, HostID: os.Getuid(), Size: 1},
+		},
+		GidMappings: []syscall.SysProcIDMap{
+			{ContainerID: 0, HostID: os.Getgid(), Size: 1},
+		},
+		GidMappingsEnableSetgroups: false,
+	}
+	return cmd, nil
+}
+
+// probeSandbox runs a trivial command in the sandbox to check that the host
+// permits unprivileged user namespaces, mount_setattr and seccomp.
+func probeSandbox() error {
+	if sandboxAuditArch() == 0 {
+		return fmt.Errorf("sandbox not supported on %s", runtime.GOARCH)
+	}
+
+	dir, err := os.MkdirTemp("", "rigrun-sandbox-probe-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cmd, err := sandboxCommand(ctx, SandboxConfig{}, dir, "true", 5*time.Second, sanitizeEnvironment())
+	if err != nil {
+		return err
+	}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%v: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// =============================================================================
+// HELPER SIDE
+// =============================================================================
+
+// runSandboxInitIfRequested turns this process into the sandboxed command
+// when it was started as a sandbox helper. Otherwise it returns.
+func runSandboxInitIfRequested() {
+	raw := os.Getenv(sandboxInitEnv)
+	if raw == "" || len(os.Args) != 2 || os.Args[0] != sandboxHelperArg0 {
+		return
+	}
+
+	// Credentials, no_new_privs and seccomp are per thread: do everything on
+	// the thread that calls execve.
+	runtime.LockOSThread()
+
+	var setup sandboxInit
+	if err := json.Unmarshal([]byte(raw), &setup); err != nil {
+		sandboxFatal(fmt.Errorf("decode config: %w", err))
+	}
+	if err := sandboxExec(setup, os.Args[1]); err != nil {
+		sandboxFatal(err)
+	}
+}
+
+// sandboxFatal reports a setup failure and exits.
+func sandboxFatal(err error) {
+	fmt.Fprintf(os.Stderr, "%s: %v\n", sandboxHelperArg0, err)
+	os.Exit(sandboxSetupExitCode)
+}
+
+// sandboxExec configures the sandbox and execs bash. It only returns on error.
+func sandboxExec(setup sandboxInit, command string) error {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		return err
+	}
+	if err := sandboxMounts(setup); err != nil {
+		return err
+	}
+	if err := sandboxRlimits(setup.Limits); err != nil {
+		return err
+	}
+	if err := sandboxDropCapabilities(); err != nil {
+		return err
+	}
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("no_new_privs: %w", err)
+	}
+	if err := sandboxSeccomp(); err != nil {
+		return err
+	}
+	if err := os.Chdir(setup.WorkDir); err != nil {
+		return err
+	}
+
+	env := make([]string, 0, len(os.Environ()))
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, sandboxInitEnv+"=") {
+			env = append(env, kv)
+		}
+	}
+	return syscall.Exec(bash, []string{"bash", "-c", command}, env)
+}
+
+// sandboxMounts makes the filesystem read-only except the working directory,
+// gives the command a private /tmp and mounts a /proc for the new PID
+// namespace.
+func sandboxMounts(setup sandboxInit) error {
+	// Keep mount changes out of the host namespace
+	if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("make mounts private: %w", err)
+	}
+
+	// Hold the working directory before /tmp is covered
+	wd, err := unix.Open(setup.WorkDir, unix.O_PATH|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return fmt.Errorf("open workdir: %w", err)
+	}
+	defer unix.Close(wd)
+
+	if err := unix.MountSetattr(unix.AT_FDCWD, "/", unix.AT_RECURSIVE, &unix.MountAttr{Attr_set: unix.MOUNT_ATTR_RDONLY}); err != nil {
+		return fmt.Errorf("make filesystem read-only: %w", err)
+	}
+
+	tmpOpts := "mode=1777,size=" + strconv.FormatInt(setup.Limits.TmpSize, 10)
+	if err := unix.Mount("tmpfs", "/tmp", "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, tmpOpts); err != nil {
+		return fmt.Errorf("mount /tmp: %w", err)
+	}
+	if err := os.MkdirAll(setup.WorkDir, 0755); err != nil {
+		return fmt.Errorf("create workdir mountpoint: %w", err)
+	}
+
+	src := "/proc/self/fd/" + strconv.Itoa(wd)
+	if err := unix.Mount(src, setup.WorkDir, "", unix.MS_BIND|unix.MS_REC, ""); err != nil {
+		return fmt.Errorf("bind workdir: %w", err)
+	}
+	clr := &unix.MountAttr{Attr_clr: unix.MOUNT_ATTR_RDONLY}
+	if err := unix.MountSetattr(unix.AT_FDCWD, setup.WorkDir, unix.AT_RECURSIVE, clr); err != nil {
+		// A read-only submount of the host cannot be made writable; keep
+		// the workdir itself writable.
+		if err := unix.MountSetattr(unix.AT_FDCWD, setup.WorkDir, 0, clr); err != nil {
+			return fmt.Errorf("make workdir writable: %w", err)
+		}
+	}
+
+	// A fresh /proc hides host processes. Some container runtimes mask parts
+	// of /proc, which prevents this; the host /proc is then left read-only.
+	_ = unix.Mount("proc", "/proc", "proc", unix.MS_NOSUID|unix.MS_NODEV|unix.MS_NOEXEC, "")
+	return nil
+}
+
+// sandboxRlimits applies the resource limits.
+func sandboxRlimits(l SandboxLimits) error {
+	limits := []struct {
+		resource int
+		value    uint64
+	}{
+		{unix.RLIMIT_CPU, uint64(l.CPUSeconds)},
+		{unix.RLIMIT_AS, uint64(l.MemoryBytes)},
+		{unix.RLIMIT_NPROC, uint64(l.MaxProcesses)},
+		{unix.RLIMIT_FSIZE, uint64(l.MaxFileSize)},
+		{unix.RLIMIT_CORE, 0},
+	}
+	for _, lim := range limits {
+		rl := unix.Rlimit{Cur: lim.value, Max: lim.value}
+		if err := unix.Setrlimit(lim.resource, &rl); err != nil {
+			return fmt.Errorf("setrlimit %d: %w", lim.resource, err)
+		}
+	}
+	return nil
+}
+
+// sandboxDropCapabilities empties the bounding and ambient sets so bash, as
+// root in the user namespace, holds no capabilities after exec.
+func sandboxDropCapabilities() error {
+	for c := 0; c <= unix.CAP_LAST_CAP; c++ {
+		if err := unix.Prctl(unix.PR_CAPBSET_DROP, uintptr(c), 0, 0, 0); err != nil && !errors.Is(err, unix.EINVAL) {
+			return fmt.Errorf("drop capability %d: %w", c, err)
+		}
+	}
+	if err := unix.Prctl(unix.PR_CAP_AMBIENT, unix.PR_CAP_AMBIENT_CLEAR_ALL, 0, 0, 0); err != nil && !errors.Is(err, unix.EINVAL) {
+		return fmt.Errorf("clear ambient capabilities: %w", err)
+	}
+	return nil
+}
+
+// =============================================================================
+// SECCOMP
+// =============================================================================
+
+// sandboxDeniedSyscalls fail with EPERM inside the sandbox.
+var sandboxDeniedSyscalls = []uint32{
+	// Mounts and namespaces
+	unix.SYS_MOUNT, unix.SYS_UMOUNT2, unix.SYS_PIVOT_ROOT, unix.SYS_CHROOT,
+	unix.SYS_MOUNT_SETATTR, unix.SYS_MOVE_MOUNT, unix.SYS_OPEN_TREE,
+	unix.SYS_FSOPEN, unix.SYS_FSCONFIG, unix.SYS_FSMOUNT, unix.SYS_FSPICK,
+	unix.SYS_UNSHARE, unix.SYS_SETNS,
+	// Tracing and other processes' memory
+	unix.SYS_PTRACE, unix.SYS_PROCESS_VM_READV, unix.SYS_PROCESS_VM_WRITEV,
+	// Kernel modules, kexec and BPF
+	unix.SYS_KEXEC_LOAD, unix.SYS_KEXEC_FILE_LOAD, unix.SYS_INIT_MODULE,
+	unix.SYS_FINIT_MODULE, unix.SYS_DELETE_MODULE, unix.SYS_BPF,
+	unix.SYS_PERF_EVENT_OPEN, unix.SYS_USERFAULTFD,
+	// Kernel keyring
+	unix.SYS_KEYCTL, unix.SYS_ADD_KEY, unix.SYS_REQUEST_KEY,
+	// Host administration
+	unix.SYS_REBOOT, unix.SYS_SWAPON, unix.SYS_SWAPOFF, unix.SYS_ACCT,
+	unix.SYS_SETTIMEOFDAY, unix.SYS_CLOCK_SETTIME, unix.SYS_QUOTACTL,
+	unix.SYS_SETHOSTNAME, unix.SYS_SETDOMAINNAME, unix.SYS_SYSLOG,
+	unix.SYS_OPEN_BY_HANDLE_AT, unix.SYS_NAME_TO_HANDLE_AT,
+}
+
+// sandboxCloneNamespaceFlags are the clone flags that create namespaces.
+const sandboxCloneNamespaceFlags = unix.CLONE_NEWNS | unix.CLONE_NEWCGROUP | unix.CLONE_NEWUTS |
+	unix.CLONE_NEWIPC | unix.CLONE_NEWUSER | unix.CLONE_NEWPID | unix.CLONE_NEWNET | unix.CLONE_NEWTIME
+
+// Offsets into struct seccomp_data.
+const (
+	seccompDataNr   = 0
+	seccompDataArch = 4
+	seccompDataArg0 = 16
+)
+
+// sandboxAuditArch returns the seccomp architecture for this build, or 0 if
+// the sandbox does not support it.
+func sandboxAuditArch() uint32 {
+	switch runtime.GOARCH {
+	case "amd64":
+		return unix.AUDIT_ARCH_X86_64
+	case "arm64":
+		return unix.AUDIT_ARCH_AARCH64
+	}
+	return 0
+}
+
+// sandboxSeccompFilter builds the BPF program installed in the sandbox.
+func sandboxSeccompFilter() []unix.SockFilter {
+	stmt := func(code uint16, k uint32) unix.SockFilter {
+		return unix.SockFilter{Code: code, K: k}
+	}
+	jump := func(code uint16, k uint32, jt, jf uint8) unix.SockFilter {
+		return unix.SockFilter{Code: code, Jt: jt, Jf: jf, K: k}
+	}
+	const (
+		ldAbs  = unix.BPF_LD | unix.BPF_W | unix.BPF_ABS
+		jeq    = unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K
+		jge    = unix.BPF_JMP | unix.BPF_JGE | unix.BPF_K
+		jset   = unix.BPF_JMP | unix.BPF_JSET | unix.BPF_K
+		ret    = unix.BPF_RET | unix.BPF_K
+		eperm  = unix.SECCOMP_RET_ERRNO | uint32(unix.EPERM)
+		enosys = unix.SECCOMP_RET_ERRNO | uint32(unix.ENOSYS)
+	)
+
+	// Kill anything using a foreign syscall ABI (e.g. 32-bit on amd64)
+	prog := []unix.SockFilter{
+		stmt(ldAbs, seccompDataArch),
+		jump(jeq, sandboxAuditArch(), 1, 0),
+		stmt(ret, unix.SECCOMP_RET_KILL_PROCESS),
+		stmt(ldAbs, seccompDataNr),
+	}
+	if runtime.GOARCH == "amd64" {
+		// x32 syscalls share the x86-64 arch value
+		prog = append(prog, jump(jge, 0x40000000, 0, 1), stmt(ret, eperm))
+	}
+	for _, nr := range sandboxDeniedSyscalls {
+		prog = append(prog, jump(jeq, nr, 0, 1), stmt(ret, eperm))
+	}
+
+	// clone3 passes flags in memory seccomp cannot inspect; ENOSYS makes
+	// libc fall back to clone, whose flags are checked below.
+	prog = append(prog, jump(jeq, unix.SYS_CLONE3, 0, 1), stmt(ret, enosys))
+	prog = append(prog,
+		jump(jeq, unix.SYS_CLONE, 0, 3),
+		stmt(ldAbs, seccompDataArg0),
+		jump(jset, sandboxCloneNamespaceFlags, 0, 1),
+		stmt(ret, eperm),
+		stmt(ret, unix.SECCOMP_RET_ALLOW),
+	)
+	return prog
+}
+
+// sandboxSeccomp installs the seccomp filter on the calling thread.
+func sandboxSeccomp() error {
+	filter := sandboxSeccompFilter()
+	prog := unix.SockFprog{Len: uint16(len(filter)), Filter: &filter[0]}
+	if err := unix.Prctl(unix.PR_SET_SECCOMP, unix.SECCOMP_MODE_FILTER, uintptr(unsafe.Pointer(&prog)), 0, 0); err != nil {
+		return fmt.Errorf("install seccomp filter: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+//go:build linux
+// +build linux
+
+package tools
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMain lets the test binary act as the sandbox helper.
+func TestMain(m *testing.M) {
+	MaybeRunSandboxInit()
+	os.Exit(m.Run())
+}
+
+// requireSandbox skips the test when the host cannot create the sandbox.
+func requireSandbox(t *testing.T) {
+	t.Helper()
+	if ok, reason := SandboxAvailable(); !ok {
+		t.Skipf("sandbox unavailable: %s", reason)
+	}
+}
+
+// runInSandbox runs a command in the sandbox without the Bash tool's pattern
+// checks, so tests can exercise what the sandbox itself prevents.
+func runInSandbox(t *testing.T, cfg SandboxConfig, workDir, command string) (string, error) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	cmd, err := sandboxCommand(ctx, cfg, workDir, command, 30*time.Second, sanitizeEnvironment())
+	if err != nil {
+		t.Fatalf("sandboxCommand: %v", err)
+	}
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err = cmd.Run()
+	return out.String(), err
+}
+
+func TestBashSandbox_WritesConfinedToWorkDir(t *testing.T) {
+	requireSandbox(t)
+
+	workDir := t.TempDir()
+	outsideTmp := t.TempDir()
+	// The package directory is on the real filesystem, not the private /tmp
+	pkgDir, _ := os.Getwd()
+	outsideFS := filepath.Join(pkgDir, ".sandbox-escape-test")
+	t.Cleanup(func() { os.Remove(outsideFS) })
+
+	exec := &BashExecutor{WorkDir: workDir, Sandbox: SandboxConfig{Mode: SandboxRequired}}
+
+	result, _ := exec.Execute(context.Background(), map[string]interface{}{"command": "echo inside > inside.txt"})
+	if !result.Success {
+		t.Fatalf("write in workdir failed: %s %s", result.Error, result.Output)
+	}
+	if result.Sandbox != SandboxProfileIsolated {
+		t.Errorf("Sandbox = %q, want %q", result.Sandbox, SandboxProfileIsolated)
+	}
+	if data, err := os.ReadFile(filepath.Join(workDir, "inside.txt")); err != nil || string(data) != "inside\n" {
+		t.Errorf("workdir file not written to host: %q, %v", data, err)
+	}
+
+	result, _ = exec.Execute(context.Background(), map[string]interface{}{"command": "echo escaped > " + outsideFS})
+	if result.Success || !strings.Contains(result.Output, "Read-only file system") {
+		t.Errorf("write outside workdir should fail read-only, got success=%v output=%q", result.Success, result.Output)
+	}
+	if _, err := os.Stat(outsideFS); !os.IsNotExist(err) {
+		t.Error("file outside the workdir was created")
+	}
+
+	// /tmp is private to the sandbox: writes there never reach the host
+	escaped := filepath.Join(outsideTmp, "escaped.txt")
+	exec.Execute(context.Background(), map[string]interface{}{"command": "mkdir -p " + outsideTmp + " && echo escaped > " + escaped})
+	if _, err := os.Stat(escaped); !os.IsNotExist(err) {
+		t.Error("write to host /tmp escaped the sandbox")
+	}
+}
+
+func TestBashSandbox_NetworkIsolation(t *testing.T) {
+	requireSandbox(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	port := strconv.Itoa(ln.Addr().(*net.TCPAddr).Port)
+	connect := "exec 3<>/dev/tcp/127.0.0.1/" + port + " && echo connected"
+
+	out, err := runInSandbox(t, SandboxConfig{}, t.TempDir(), connect)
+	if err == nil || strings.Contains(out, "connected") {
+		t.Errorf("network call should fail in the isolated profile: %q", out)
+	}
+
+	out, err = runInSandbox(t, SandboxConfig{AllowNetwork: true}, t.TempDir(), connect)
+	if err != nil || !strings.Contains(out, "connected") {
+		t.Errorf("network call should succeed when allowed: %v %q", err, out)
+	}
+}
+
+func TestBashSandbox_SeccompAndPrivileges(t *testing.T) {
+	requireSandbox(t)
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		command string
+	}{
+		{"mount", "mount -t tmpfs none " + dir},
+		{"new namespace", "unshare -U true"},
+		{"capabilities", "grep -q '^CapEff:[[:space:]]*0*$' /proc/self/status || exit 1; chown 12345 ."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if out, err := runInSandbox(t, SandboxConfig{}, dir, tt.command); err == nil {
+				t.Errorf("%q should fail in the sandbox: %q", tt.command, out)
+			}
+		})
+	}
+}
+
+func TestBashSandbox_OutputLimit(t *testing.T) {
+	requireSandbox(t)
+
+	exec := &BashExecutor{WorkDir: t.TempDir(), MaxOutputSize: 1000, Sandbox: SandboxConfig{Mode: SandboxAuto}}
+	result, _ := exec.Execute(context.Background(), map[string]interface{}{"command": "head -c 1000000 /dev/zero | tr '\\0' x"})
+	if !result.Success || !result.Truncated {
+		t.Fatalf("expected truncated success, got %+v", result)
+	}
+	if len(result.Output) > 1100 {
+		t.Errorf("output not limited: %d bytes", len(result.Output))
+	}
+}
+
+func TestRegistry_ApplySandboxSettings(t *testing.T) {
+	r := NewRegistry()
+	if err := r.ApplySandboxSettings("bogus", false); err == nil {
+		t.Error("invalid mode should be reported")
+	}
+	bash := r.Get("Bash").Executor.(*BashExecutor)
+	if bash.Sandbox.Mode != SandboxRequired {
+		t.Errorf("invalid mode should fail closed, got %q", bash.Sandbox.Mode)
+	}
+
+	// The shared built-in definition is not modified
+	if BashTool.Executor.(*BashExecutor).Sandbox.Mode != "" {
+		t.Error("ApplySandboxSettings modified the shared Bash tool")
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+//go:build !linux
+// +build !linux
+
+// sandbox_other.go - The Bash sandbox is only available on Linux.
+package tools
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"runtime"
+	"time"
+)
+
+// sandboxCommand is unavailable on this platform.
+func sandboxCommand(ctx context.Context, cfg SandboxConfig, workDir, command string, timeout time.Duration, env []string) (*exec.Cmd, error) {
+	return nil, probeSandbox()
+}
+
+// probeSandbox reports that the sandbox is unsupported.
+func probeSandbox() error {
+	return errors.New("sandbox not supported on " + runtime.GOOS)
+}
+
+// runSandboxInitIfRequested is a no-op on this platform.
+func runSandboxInitIfRequested() {}
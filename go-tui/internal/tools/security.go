@@ -11,6 +11,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 )
 
 // =============================================================================
@@ -445,6 +446,20 @@ func ValidatePathSecureWithHandle(path string, flag int, perm os.FileMode) (*os.
 	return f, realPath, nil
 }
 
+// allowedRoots are directories outside the working directory that tools may
+// access: the worktrees of git sessions, which sit beside the repository.
+var (
+	allowedRootsMu sync.RWMutex
+	allowedRoots   []string
+)
+
+// allowRoot lets tools access dir and its subdirectories.
+func allowRoot(dir string) {
+	allowedRootsMu.Lock()
+	defer allowedRootsMu.Unlock()
+	allowedRoots = append(allowedRoots, normalizePath(dir))
+}
+
 // isWithinAllowedPaths checks if a path is within allowed directories.
 // By default, we allow access to the current working directory and subdirectories.
 // This prevents access to system directories outside the workspace.
@@ -474,6 +489,16 @@ func isWithinAllowedPaths(normalizedPath string) bool {
 		return true
 	}
 
+	// Allow access to the worktrees of git sessions
+	allowedRootsMu.RLock()
+	for _, root := range allowedRoots {
+		if isPathWithinDir(normalizedPath, root) {
+			allowedRootsMu.RUnlock()
+			return true
+		}
+	}
+	allowedRootsMu.RUnlock()
+
 	// Allow access to user's home directory
 	homeDir, err := os.UserHomeDir()
 	if err == nil {
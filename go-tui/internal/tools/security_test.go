@@ -603,6 +603,49 @@ func TestPermissionFuncNotBypassedByAlwaysAllow(t *testing.T) {
 	})
 }
 
+// =============================================================================
+// ADMINISTRATOR POLICY TESTS
+// =============================================================================
+
+func TestPolicyPermissionsCannotBeBypassed(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.ApplyPolicyPermissions(map[string]string{
+		"Bash":  "never",
+		"Write": "auto",
+	}); err != nil {
+		t.Fatalf("ApplyPolicyPermissions: %v", err)
+	}
+
+	// User preferences must not loosen a policy denial
+	registry.SetAlwaysAllow("Bash", true)
+	registry.SetPermissionOverride("Bash", PermissionAuto)
+	if perm := registry.GetPermission("Bash"); perm != PermissionNever {
+		t.Errorf("Bash permission = %v, want Never", perm)
+	}
+	if !registry.IsPolicyLocked("Bash") {
+		t.Error("Bash should be reported as policy locked")
+	}
+
+	// Policy can only tighten: "auto" does not lower Write below Ask
+	if perm := registry.GetPermission("Write"); perm != PermissionAsk {
+		t.Errorf("Write permission = %v, want Ask", perm)
+	}
+
+	if _, err := ParsePermissionLevel("sometimes"); err == nil {
+		t.Error("ParsePermissionLevel should reject unknown levels")
+	}
+	if err := registry.ApplyPolicyPermissions(map[string]string{"Read": "sometimes"}); err == nil {
+		t.Error("ApplyPolicyPermissions should reject unknown levels")
+	}
+
+	// The executor must refuse even when the user approves
+	executor := NewExecutor(registry)
+	executor.SetPermissionCallback(AllowAllCallback())
+	if executor.checkPermission(registry.Get("Bash"), map[string]interface{}{"command": "ls"}, "") {
+		t.Error("executor approved a tool denied by policy")
+	}
+}
+
 // =============================================================================
 // TOCTOU AND HASPREFIX BYPASS TESTS
 // =============================================================================
@@ -0,0 +1,206 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package chat provides the chat view component for the TUI.
+//
+// This file implements conversation branching: /edit and /regen fork the
+// thread at an earlier user message, and /branch and the [ and ] keys switch
+// between the branches at a fork point.
+package chat
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/jeranaias/rigrun-tui/internal/model"
+)
+
+const (
+	editUsage   = "Usage: /edit [n] [new text]   (n = user message number, default last)"
+	regenUsage  = "Usage: /regen [n]   (n = user message number, default last)"
+	branchUsage = "Usage: /branch [list]\n       /branch next|prev [n]"
+)
+
+// =============================================================================
+// MESSAGE NUMBERING
+// =============================================================================
+
+// userMessages returns the user messages on the active branch. Commands
+// number them from 1.
+func (m *Model) userMessages() []*model.Message {
+	var msgs []*model.Message
+	for _, msg := range m.conversation.Messages {
+		if msg.Role == model.RoleUser {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs
+}
+
+// userMessageArg resolves an optional user message number from the front of
+// args. It returns the message, its number and the remaining arguments.
+func (m *Model) userMessageArg(args []string) (*model.Message, int, []string, error) {
+	msgs := m.userMessages()
+	if len(msgs) == 0 {
+		return nil, 0, args, fmt.Errorf("there are no messages to branch from")
+	}
+	n := len(msgs)
+	if len(args) > 0 {
+		if parsed, err := strconv.Atoi(args[0]); err == nil {
+			if parsed < 1 || parsed > len(msgs) {
+				return nil, 0, args, fmt.Errorf("message %d does not exist (1-%d)", parsed, len(msgs))
+			}
+			n = parsed
+			args = args[1:]
+		}
+	}
+	return msgs[n-1], n, args, nil
+}
+
+// =============================================================================
+// COMMANDS
+// =============================================================================
+
+// handleEditCommand forks the conversation at a user message. With text the
+// edited message is sent right away; without, the original is loaded into the
+// input and the next submission becomes the new branch.
+func handleEditCommand(m *Model, args []string) (tea.Model, tea.Cmd) {
+	if len(args) > 0 && strings.EqualFold(args[0], "cancel") {
+		m.editMsgID = ""
+		m.input.Reset()
+		m.conversation.AddSystemMessage("Edit cancelled")
+		m.updateViewport()
+		return m, nil
+	}
+
+	target, n, rest, err := m.userMessageArg(args)
+	if err != nil {
+		m.conversation.AddSystemMessage("Error: " + err.Error() + "\n" + editUsage)
+		m.updateViewport()
+		return m, nil
+	}
+
+	if text := strings.TrimSpace(strings.Join(rest, " ")); text != "" {
+		m.input.Reset()
+		m.editMsgID = ""
+		return m.sendMessage(text, target.ID)
+	}
+
+	m.editMsgID = target.ID
+	m.input.SetValue(target.Content)
+	m.input.CursorEnd()
+	m.input.Focus()
+	m.inputMode = true
+	m.conversation.AddSystemMessage(fmt.Sprintf(
+		"Editing message %d. Press Enter to send it as a new branch, or /edit cancel.", n))
+	m.updateViewport()
+	return m, textinput.Blink
+}
+
+// handleRegenCommand sends a user message again on a new branch, keeping the
+// previous response on the old one.
+func handleRegenCommand(m *Model, args []string) (tea.Model, tea.Cmd) {
+	target, _, _, err := m.userMessageArg(args)
+	if err != nil {
+		m.conversation.AddSystemMessage("Error: " + err.Error() + "\n" + regenUsage)
+		m.updateViewport()
+		return m, nil
+	}
+	m.editMsgID = ""
+	return m.sendMessage(target.Content, target.ID)
+}
+
+// handleBranchCommand lists fork points or switches branches.
+func handleBranchCommand(m *Model, args []string) (tea.Model, tea.Cmd) {
+	action := "list"
+	if len(args) > 0 {
+		action = strings.ToLower(args[0])
+		args = args[1:]
+	}
+
+	switch action {
+	case "list", "ls":
+		m.conversation.AddSystemMessage(m.formatBranches())
+
+	case "next", "prev", "previous":
+		delta := 1
+		if action != "next" {
+			delta = -1
+		}
+		var target *model.Message
+		if len(args) > 0 {
+			msg, _, _, err := m.userMessageArg(args)
+			if err != nil {
+				m.conversation.AddSystemMessage("Error: " + err.Error() + "\n" + branchUsage)
+				break
+			}
+			target = msg
+		}
+		if err := m.switchBranch(target, delta); err != nil {
+			m.conversation.AddSystemMessage("Error: " + err.Error())
+		}
+
+	default:
+		m.conversation.AddSystemMessage("Error: Invalid action '" + action + "'\n" + branchUsage)
+	}
+
+	m.updateViewport()
+	return m, nil
+}
+
+// switchBranch moves to the next or previous sibling of a user message, or of
+// the last fork point on the active branch when msg is nil.
+func (m *Model) switchBranch(msg *model.Message, delta int) error {
+	if msg == nil {
+		points := m.conversation.ForkPoints()
+		if len(points) == 0 {
+			return fmt.Errorf("the conversation has no branches; use /edit or /regen to create one")
+		}
+		msg = m.conversation.Messages[points[len(points)-1]]
+	}
+	if _, err := m.conversation.SwitchBranch(msg.ID, delta); err != nil {
+		return err
+	}
+	m.editMsgID = ""
+	return nil
+}
+
+// formatBranches lists the fork points on the active branch.
+func (m *Model) formatBranches() string {
+	points := m.conversation.ForkPoints()
+	if len(points) == 0 {
+		return "No branches. Use /edit [n] or /regen [n] to fork the conversation at a message."
+	}
+
+	number := make(map[string]int)
+	for i, msg := range m.userMessages() {
+		number[msg.ID] = i + 1
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Branches:\n")
+	for _, idx := range points {
+		msg := m.conversation.Messages[idx]
+		pos, count := m.conversation.BranchInfo(msg.ID)
+		fmt.Fprintf(&sb, "  Message %d: branch %d/%d  %s\n", number[msg.ID], pos, count, msg.Preview(50))
+	}
+	sb.WriteString("\nSwitch with /branch next|prev [n], or [ and ] for the last fork point")
+	return sb.String()
+}
+
+// =============================================================================
+// KEYS
+// =============================================================================
+
+// handleBranchKey switches branches at the last fork point from normal mode.
+func (m Model) handleBranchKey(delta int) (tea.Model, tea.Cmd) {
+	if err := m.switchBranch(nil, delta); err != nil {
+		m.conversation.AddSystemMessage("Error: " + err.Error())
+	}
+	m.updateViewport()
+	return m, nil
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package chat provides the chat view component for the TUI.
+//
+// This file implements cloud spend budgets in the chat view: each query's
+// cost is recorded with the cost tracker, routing decisions for paid tiers
+// are checked against the budgets, and the spend is shown in the status bar.
+package chat
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/jeranaias/rigrun-tui/internal/router"
+	"github.com/jeranaias/rigrun-tui/internal/security"
+	"github.com/jeranaias/rigrun-tui/internal/telemetry"
+	"github.com/jeranaias/rigrun-tui/internal/ui/styles"
+)
+
+// pendingSend is a routed message that has not been sent yet.
+type pendingSend struct {
+	displayContent  string
+	expandedContent string
+	contextInfo     string
+	contextWarning  string
+	forkFrom        string
+	msgClass        security.Classification
+	decision        router.RoutingDecision
+}
+
+// =============================================================================
+// SETUP
+// =============================================================================
+
+// SetCostTracker sets the cost tracker that records the spend of each query
+// and the budgets it is checked against. action is what happens to paid
+// queries once a budget is spent: "local" or "prompt".
+func (m *Model) SetCostTracker(tracker *telemetry.CostTracker, budget telemetry.Budget, action string) {
+	m.budget = &telemetry.BudgetGuard{Tracker: tracker, Budget: budget}
+	m.budgetAction = action
+	m.refreshBudget()
+}
+
+// GetCostTracker returns the cost tracker, or nil.
+func (m *Model) GetCostTracker() *telemetry.CostTracker {
+	if m.budget == nil {
+		return nil
+	}
+	return m.budget.Tracker
+}
+
+// =============================================================================
+// SPEND
+// =============================================================================
+
+// recordCost records a completed query with the cost tracker. The session
+// is saved after each query so that other rigrun processes count its spend.
+func (m *Model) recordCost(result router.QueryResult, prompt string) {
+	tracker := m.GetCostTracker()
+	if tracker == nil {
+		return
+	}
+	latency := time.Duration(result.LatencyMs) * time.Millisecond
+	tracker.RecordQuery(strings.ToLower(result.TierUsed.String()),
+		int(result.InputTokens), int(result.OutputTokens), latency, prompt)
+	_ = tracker.SaveCurrentSession()
+	m.refreshBudget()
+}
+
+// refreshBudget updates the spend shown in the status bar.
+func (m *Model) refreshBudget() {
+	if m.budget == nil || m.budget.Budget.IsZero() {
+		m.budgetStatus = telemetry.BudgetStatus{}
+		return
+	}
+	m.budgetStatus = m.budget.Status()
+}
+
+// applyBudget checks a routing decision for a paid tier against the budgets.
+func (m *Model) applyBudget(decision router.RoutingDecision) router.RoutingDecision {
+	if m.budget == nil {
+		return decision
+	}
+	return router.ApplyBudget(decision, m.budget, m.budgetAction)
+}
+
+// =============================================================================
+// OVER-BUDGET PROMPT
+// =============================================================================
+
+// promptBudget holds back a message routed to a paid tier over budget and
+// asks whether to send it anyway.
+func (m Model) promptBudget(send pendingSend) (tea.Model, tea.Cmd) {
+	m.budgetPrompt = &send
+	m.conversation.AddSystemMessage(fmt.Sprintf(
+		"Cost budget exceeded: %s.\nPress [y] to send to %s anyway, [n]/Esc to answer with the local model.",
+		send.decision.OverBudget, send.decision.Tier))
+	m.updateViewport()
+	m.viewport.GotoBottom()
+	return m, nil
+}
+
+// handleBudgetPromptKey sends a held-back message to the paid tier or the
+// local model.
+func (m Model) handleBudgetPromptKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	send := *m.budgetPrompt
+	switch msg.String() {
+	case "y", "Y":
+		send.decision.OverBudget = ""
+	case "n", "N", "esc", "ctrl+c":
+		send.decision = router.ApplyBudget(send.decision, m.budget, router.BudgetActionLocal)
+	default:
+		return m, nil
+	}
+	m.budgetPrompt = nil
+	return m.dispatchMessage(send)
+}
+
+// =============================================================================
+// STATUS BAR
+// =============================================================================
+
+// renderBudgetIndicator renders the budget closest to its limit once it is
+// past the warning threshold, or "".
+func (m Model) renderBudgetIndicator(compact bool) string {
+	warn := m.budgetStatus.Warning()
+	if warn == nil {
+		return ""
+	}
+
+	color := styles.Amber
+	text := fmt.Sprintf("Budget: %s %.0f%%", warn.Period, warn.Percent())
+	if warn.Exceeded() {
+		color = styles.Rose
+		text = fmt.Sprintf("Budget: %s spent", warn.Period)
+	}
+	if compact {
+		text = "$!"
+	}
+	return lipgloss.NewStyle().Foreground(color).Bold(true).Render(text)
+}
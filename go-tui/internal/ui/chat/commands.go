@@ -58,6 +58,11 @@ var commandHandlers = map[string]CommandHandler{
 	"e":        handleExportCommand,
 	"history":  handleHistoryCommand,
 	"hist":     handleHistoryCommand,
+	"edit":     handleEditCommand,
+	"regen":    handleRegenCommand,
+	"retry":    handleRegenCommand,
+	"branch":   handleBranchCommand,
+	"br":       handleBranchCommand,
 
 	// Security & Compliance
 	"audit":    handleAuditCommand,
@@ -93,11 +98,20 @@ var commandHandlers = map[string]CommandHandler{
 	"tok":     handleTokensCommand,
 	"context": handleContextCommand,
 	"ctx":     handleContextCommand,
+	"memory":  handleMemoryCommand,
+	"mem":     handleMemoryCommand,
 
 	// Background Tasks
 	"task":   handleTaskCommand,
 	"tasks":  handleTasksCommand,
 	"cancel": handleCancelTaskCommand,
+
+	// Git Session
+	"undo":   handleUndoCommand,
+	"commit": handleCommitCommand,
+
+	// Custom Commands
+	"commands": handleCommandsCommand,
 }
 
 // handleCommand processes slash commands using the command registry pattern.
@@ -130,6 +144,10 @@ func (m Model) handleCommand(content string) (tea.Model, tea.Cmd) {
 	var resultCmd tea.Cmd
 	if handler, ok := commandHandlers[cmdName]; ok {
 		resultModel, resultCmd = handler(&m, args)
+	} else if custom := m.lookupCustomCommand(cmdName); custom != nil {
+		// Custom command arguments may be quoted
+		rest := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(content), parts[0]))
+		resultModel, resultCmd = m.handleCustomCommand(custom, commands.ParseArgs(rest))
 	} else {
 		// Unknown command
 		m.conversation.AddSystemMessage("Error: Unknown command '" + content + "'\nType /help for available commands")
@@ -159,6 +177,9 @@ func handleHelpCommand(m *Model, args []string) (tea.Model, tea.Cmd) {
 	// Create command registry for help generation
 	registry := commands.NewRegistry()
 	commands.InitializeHandlers(registry)
+	if m.commandRegistry != nil {
+		registry.RegisterCustom(m.commandRegistry.CustomCommands())
+	}
 
 	// Generate help text using the progressive help system
 	helpText := commands.GenerateHelpText(registry, mode)
@@ -204,6 +225,16 @@ func handleSaveCommand(m *Model, args []string) (tea.Model, tea.Cmd) {
 	}
 }
 
+// handleUndoCommand asks the app to revert the agent's last git commit.
+func handleUndoCommand(m *Model, args []string) (tea.Model, tea.Cmd) {
+	return m, commands.HandleGitCommand("undo", args)
+}
+
+// handleCommitCommand asks the app to squash the agent's git commits.
+func handleCommitCommand(m *Model, args []string) (tea.Model, tea.Cmd) {
+	return m, commands.HandleGitCommand("commit", args)
+}
+
 func handleLoadCommand(m *Model, args []string) (tea.Model, tea.Cmd) {
 	if len(args) == 0 {
 		return m, func() tea.Msg {
@@ -223,8 +254,17 @@ func handleListCommand(m *Model, args []string) (tea.Model, tea.Cmd) {
 
 func handleExportCommand(m *Model, args []string) (tea.Model, tea.Cmd) {
 	format := "markdown"
-	if len(args) > 0 {
-		format = strings.ToLower(args[0])
+	tree := false
+	var positional []string
+	for _, arg := range args {
+		if arg == "--tree" {
+			tree = true
+		} else {
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) > 0 {
+		format = strings.ToLower(positional[0])
 		// Support aliases
 		if format == "md" {
 			format = "markdown"
@@ -236,13 +276,13 @@ func handleExportCommand(m *Model, args []string) (tea.Model, tea.Cmd) {
 
 		// Validate format
 		if format != "json" && format != "markdown" && format != "html" {
-			m.conversation.AddSystemMessage("Error: Invalid format '" + format + "'\nUsage: /export [markdown|html|json]")
+			m.conversation.AddSystemMessage("Error: Invalid format '" + format + "'\nUsage: /export [markdown|html|json] [--tree]")
 			m.updateViewport()
 			return m, nil
 		}
 	}
 	return m, func() tea.Msg {
-		return commands.ExportConversationMsg{Format: format}
+		return commands.ExportConversationMsg{Format: format, Tree: tree}
 	}
 }
 
@@ -349,10 +389,13 @@ func handleConfigCommand(m *Model, args []string) (tea.Model, tea.Cmd) {
 		sb.WriteString("Configuration:\n")
 		sb.WriteString("  Model: ")
 		sb.WriteString(cfg.DefaultModel)
+		sb.WriteString(policyLockMark(cfg, "default_model"))
 		sb.WriteString("\n  Mode: ")
 		sb.WriteString(cfg.Routing.DefaultMode)
+		sb.WriteString(policyLockMark(cfg, "routing.default_mode"))
 		sb.WriteString("\n  Ollama URL: ")
 		sb.WriteString(cfg.Local.OllamaURL)
+		sb.WriteString(policyLockMark(cfg, "local.ollama_url"))
 		sb.WriteByte('\n')
 		if cfg.Cloud.OpenRouterKey != "" {
 			sb.WriteString("  OpenRouter: configured\n")
@@ -361,18 +404,44 @@ func handleConfigCommand(m *Model, args []string) (tea.Model, tea.Cmd) {
 		}
 		sb.WriteString("  Max Tier: ")
 		sb.WriteString(cfg.Routing.MaxTier)
+		sb.WriteString(policyLockMark(cfg, "routing.max_tier"))
 		sb.WriteByte('\n')
 		if cfg.Routing.ParanoidMode {
-			sb.WriteString("  Paranoid Mode: enabled\n")
+			sb.WriteString("  Paranoid Mode: enabled")
+			sb.WriteString(policyLockMark(cfg, "routing.paranoid_mode"))
+			sb.WriteByte('\n')
 		}
 		if cfg.Routing.OfflineMode {
-			sb.WriteString("  Offline Mode: enabled (SC-7)\n")
+			sb.WriteString("  Offline Mode: enabled (SC-7)")
+			sb.WriteString(policyLockMark(cfg, "routing.offline_mode"))
+			sb.WriteByte('\n')
 		}
-		sb.WriteString("  Session Timeout: ")
+		sb.WriteString("  Classification: ")
+		sb.WriteString(cfg.Security.Classification)
+		sb.WriteString(policyLockMark(cfg, "security.classification"))
+		sb.WriteString("\n  Session Timeout: ")
 		sb.WriteString(formatInt(cfg.Security.SessionTimeoutSecs))
-		sb.WriteString("s\n  Audit Logging: ")
+		sb.WriteString("s")
+		sb.WriteString(policyLockMark(cfg, "security.session_timeout_secs"))
+		sb.WriteString("\n  Audit Logging: ")
 		sb.WriteString(formatBool(cfg.Security.AuditEnabled))
+		sb.WriteString(policyLockMark(cfg, "security.audit_enabled"))
 		sb.WriteByte('\n')
+		// CM-5: Administrator policy status
+		if err := cfg.PolicyError(); err != nil {
+			sb.WriteString("  Policy: INVALID - paranoid mode enforced (")
+			sb.WriteString(err.Error())
+			sb.WriteString(")\n")
+		} else if doc := cfg.Policy(); doc != nil {
+			sb.WriteString("  Policy: ")
+			sb.WriteString(cfg.PolicyPath())
+			if doc.Issuer != "" {
+				sb.WriteString(" (issued by ")
+				sb.WriteString(doc.Issuer)
+				sb.WriteByte(')')
+			}
+			sb.WriteByte('\n')
+		}
 		m.conversation.AddSystemMessage(sb.String())
 		m.updateViewport()
 		return m, nil
@@ -382,21 +451,23 @@ func handleConfigCommand(m *Model, args []string) (tea.Model, tea.Cmd) {
 	var value string
 	switch key {
 	case "model":
-		value = cfg.DefaultModel
+		value = cfg.DefaultModel + policyLockMark(cfg, "default_model")
 	case "mode":
-		value = cfg.Routing.DefaultMode
+		value = cfg.Routing.DefaultMode + policyLockMark(cfg, "routing.default_mode")
 	case "url", "ollama_url":
-		value = cfg.Local.OllamaURL
+		value = cfg.Local.OllamaURL + policyLockMark(cfg, "local.ollama_url")
 	case "max_tier":
-		value = cfg.Routing.MaxTier
+		value = cfg.Routing.MaxTier + policyLockMark(cfg, "routing.max_tier")
 	case "paranoid":
-		value = formatBool(cfg.Routing.ParanoidMode)
+		value = formatBool(cfg.Routing.ParanoidMode) + policyLockMark(cfg, "routing.paranoid_mode")
 	case "offline":
-		value = formatBool(cfg.Routing.OfflineMode)
+		value = formatBool(cfg.Routing.OfflineMode) + policyLockMark(cfg, "routing.offline_mode")
+	case "classification":
+		value = cfg.Security.Classification + policyLockMark(cfg, "security.classification")
 	case "timeout":
-		value = formatInt(cfg.Security.SessionTimeoutSecs) + "s"
+		value = formatInt(cfg.Security.SessionTimeoutSecs) + "s" + policyLockMark(cfg, "security.session_timeout_secs")
 	case "audit":
-		value = formatBool(cfg.Security.AuditEnabled)
+		value = formatBool(cfg.Security.AuditEnabled) + policyLockMark(cfg, "security.audit_enabled")
 	default:
 		m.conversation.AddSystemMessage("Error: Unknown config key '" + key + "'")
 		m.updateViewport()
@@ -407,6 +478,15 @@ func handleConfigCommand(m *Model, args []string) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// policyLockMark returns a "locked by policy" suffix for keys pinned by the
+// administrator policy (CM-5), or "" otherwise.
+func policyLockMark(cfg *config.Config, key string) string {
+	if cfg != nil && cfg.IsLocked(key) {
+		return " (locked by policy)"
+	}
+	return ""
+}
+
 func handleModelCommand(m *Model, args []string) (tea.Model, tea.Cmd) {
 	if len(args) == 0 {
 		m.conversation.AddSystemMessage("Current model: " + m.modelName + "\nUsage: /model <name>")
@@ -674,6 +754,7 @@ func handleStatusCommand(m *Model, args []string) (tea.Model, tea.Cmd) {
 		sb.WriteString(formatInt(snapshot.CacheHits))
 		sb.WriteByte('\n')
 	}
+	m.writeInstructionStatus(&sb)
 	m.conversation.AddSystemMessage(sb.String())
 	m.updateViewport()
 	return m, nil
@@ -1004,38 +1085,11 @@ func handleResumeCommand(m *Model, args []string) (tea.Model, tea.Cmd) {
 	}
 }
 
-// handleSearchSessionsCommand searches sessions by message content.
+// handleSearchSessionsCommand opens the session search overlay, which
+// searches message content across saved sessions.
+// Usage: /search [query]
 func handleSearchSessionsCommand(m *Model, args []string) (tea.Model, tea.Cmd) {
-	if len(args) == 0 {
-		m.conversation.AddSystemMessage("Error: Search query required\nUsage: /search <query>")
-		m.updateViewport()
-		return m, nil
-	}
-	query := strings.Join(args, " ")
-	return m, func() tea.Msg {
-		return SessionSearchMsg{Query: query}
-	}
-}
-
-// SessionSearchMsg requests searching sessions by message content.
-type SessionSearchMsg struct {
-	Query string
-}
-
-// SessionSearchResultMsg contains search results.
-type SessionSearchResultMsg struct {
-	Query    string
-	Sessions []SessionSearchResult
-	Error    error
-}
-
-// SessionSearchResult contains a single search result.
-type SessionSearchResult struct {
-	ID           string
-	Summary      string
-	MessageCount int
-	Preview      string
-	UpdatedAt    string
+	return m.openSessionSearch(strings.Join(args, " "))
 }
 
 // =============================================================================
@@ -1044,9 +1098,10 @@ type SessionSearchResult struct {
 
 // handleTaskCommand creates a new background task.
 // Usage: /task "Description" command args...
+// The command is bash, sleep, agent (an agentic loop) or plan.
 func handleTaskCommand(m *Model, args []string) (tea.Model, tea.Cmd) {
 	if len(args) < 2 {
-		m.conversation.AddSystemMessage("Error: Task requires description and command\nUsage: /task \"Description\" <command> <args...>\nExample: /task \"Run tests\" bash \"go test ./...\"")
+		m.conversation.AddSystemMessage("Error: Task requires description and command\nUsage: /task \"Description\" <bash|sleep|agent|plan> <args...>\nExample: /task \"Run tests\" bash \"go test ./...\"\nExample: /task \"Fix lint\" agent \"fix the lint errors in internal/foo\"")
 		m.updateViewport()
 		return m, nil
 	}
@@ -1116,9 +1171,12 @@ type TaskCancelMsg struct {
 
 // TaskNotificationMsg notifies about a task state change.
 type TaskNotificationMsg struct {
-	TaskID      string
-	Description string
-	Status      string
-	Duration    time.Duration
-	Error       string
+	TaskID         string
+	Description    string
+	Status         string
+	Duration       time.Duration
+	Error          string
+	ConversationID string // Conversation the task was started from
+	Step           string // Current step of a running agent task
+	Result         string // Summary of an agent task's work
 }
@@ -0,0 +1,118 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package chat provides the chat view component for the TUI.
+//
+// This file runs custom slash commands: prompt templates loaded from
+// ~/.rigrun/commands and <repo>/.rigrun/commands. A custom command expands
+// its template with the given arguments and sends the result as a user
+// message, optionally preferring a routing tier and limiting the tools the
+// model may call for that turn.
+package chat
+
+import (
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/jeranaias/rigrun-tui/internal/commands"
+	"github.com/jeranaias/rigrun-tui/internal/config"
+	"github.com/jeranaias/rigrun-tui/internal/router"
+	"github.com/jeranaias/rigrun-tui/internal/security"
+)
+
+// loadCustomCommands (re)loads the custom commands for the working directory
+// into the registry used for completion and the command palette. It returns
+// the loaded commands and any files that were skipped.
+func (m *Model) loadCustomCommands() ([]*commands.CustomCommand, []error) {
+	cwd, _ := os.Getwd()
+	cmds, errs := commands.LoadCustomCommands(cwd)
+	if m.commandRegistry != nil {
+		errs = append(errs, m.commandRegistry.RegisterCustom(cmds)...)
+	}
+	return cmds, errs
+}
+
+// lookupCustomCommand returns the custom command registered as /name, or nil.
+func (m *Model) lookupCustomCommand(name string) *commands.CustomCommand {
+	if m.commandRegistry == nil {
+		return nil
+	}
+	if cmd := m.commandRegistry.Get("/" + name); cmd != nil {
+		return cmd.Custom
+	}
+	return nil
+}
+
+// handleCommandsCommand lists or reloads the custom commands.
+func handleCommandsCommand(m *Model, args []string) (tea.Model, tea.Cmd) {
+	action := "list"
+	if len(args) > 0 {
+		action = strings.ToLower(args[0])
+	}
+
+	switch action {
+	case "list", "reload":
+		// Listing reloads too, so edits to the templates show up immediately
+		cmds, errs := m.loadCustomCommands()
+		m.conversation.AddSystemMessage(commands.FormatCustomCommands(cmds, errs))
+	default:
+		m.conversation.AddSystemMessage("Usage: /commands [list|reload]")
+	}
+	m.updateViewport()
+	return m, nil
+}
+
+// handleCustomCommand expands a custom command's template and sends it.
+func (m Model) handleCustomCommand(cmd *commands.CustomCommand, args []string) (tea.Model, tea.Cmd) {
+	if m.state != StateReady {
+		m.conversation.AddSystemMessage("Cannot run /" + cmd.Name + " while a response is in progress")
+		m.updateViewport()
+		return m, nil
+	}
+
+	prompt, err := cmd.Expand(args)
+	if err != nil {
+		m.conversation.AddSystemMessage("Error: " + err.Error() + "\nUsage: " + cmd.Usage())
+		m.updateViewport()
+		return m, nil
+	}
+
+	m.pendingCommand = cmd
+	return m.sendMessage(prompt, "")
+}
+
+// applyCommandTier routes a custom command's turn to its preferred tier.
+// Local preferences always apply. Cloud preferences are ignored in local,
+// paranoid or offline mode, capped by routing.max_tier, and still subject to
+// AC-4 classification enforcement.
+func (m *Model) applyCommandTier(decision router.RoutingDecision, cmd *commands.CustomCommand, msgClass security.Classification) router.RoutingDecision {
+	tier, ok := router.ParseTier(cmd.Tier)
+	if !ok || tier == decision.Tier {
+		return decision
+	}
+
+	if tier.IsLocal() {
+		decision.Tier = router.TierLocal
+		decision.EstimatedCostCents = 0
+		decision.Reason = "/" + cmd.Name + " prefers the local tier"
+		return decision
+	}
+
+	cfg := config.Global()
+	if m.offlineMode || m.routingMode == "local" || (cfg != nil && cfg.Routing.ParanoidMode) {
+		return decision
+	}
+	if cfg != nil {
+		if maxTier := (&router.RouterOptions{MaxTier: cfg.Routing.MaxTier}).GetMaxTier(); maxTier != nil && tier.Order() > maxTier.Order() {
+			tier = *maxTier
+		}
+	}
+
+	decision.Tier = tier
+	decision.EstimatedCostCents = tier.CalculateCostCents(500, 1000)
+	decision.Reason = "/" + cmd.Name + " prefers the " + tier.String() + " tier"
+	classification := security.HighWaterMark(m.EffectiveClassification(), msgClass).Level
+	return m.enforceClassificationOnDecision(decision, classification)
+}
@@ -0,0 +1,43 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package chat provides the chat view component for the TUI.
+package chat
+
+import (
+	"testing"
+
+	"github.com/jeranaias/rigrun-tui/internal/config"
+
+	"github.com/jeranaias/rigrun-tui/internal/commands"
+	"github.com/jeranaias/rigrun-tui/internal/router"
+	"github.com/jeranaias/rigrun-tui/internal/security"
+	"github.com/jeranaias/rigrun-tui/internal/ui/styles"
+)
+
+// =============================================================================
+// CUSTOM COMMAND TIER TESTS
+// =============================================================================
+
+func TestApplyCommandTier_LocalModeKeepsLocal(t *testing.T) {
+	m := New(styles.NewTheme())
+	t.Cleanup(m.taskRunner.Stop)
+	m.routingMode = "local"
+
+	// Nothing but the routing mode keeps the command off the cloud
+	prev := config.Global()
+	cfg := config.Default()
+	cfg.Routing.ParanoidMode = false
+	cfg.Routing.MaxTier = ""
+	config.SetGlobal(cfg)
+	t.Cleanup(func() { config.SetGlobal(prev) })
+
+	decision := router.RoutingDecision{Tier: router.TierLocal, Reason: "local mode"}
+	cmd := &commands.CustomCommand{Name: "review", Tier: "opus"}
+
+	// A command's cloud tier does not override /mode local
+	got := m.applyCommandTier(decision, cmd, security.Classification{})
+	if got.Tier != router.TierLocal || got.Reason != "local mode" {
+		t.Errorf("applyCommandTier() = %v (%q), want the local decision", got.Tier, got.Reason)
+	}
+}
@@ -33,6 +33,7 @@ func (m Model) handleExportConversation(msg commands.ExportConversationMsg) (tea
 		opts.IncludeMetadata = true
 		opts.IncludeTimestamps = true
 		opts.Theme = "dark" // Could be made configurable
+		opts.IncludeBranches = msg.Tree
 
 		// Export the conversation
 		path, err := export.ExportModelConversation(m.conversation, format, opts)
@@ -20,7 +20,6 @@ import (
 	"github.com/jeranaias/rigrun-tui/internal/model"
 	"github.com/jeranaias/rigrun-tui/internal/router"
 	"github.com/jeranaias/rigrun-tui/internal/security"
-	"github.com/jeranaias/rigrun-tui/internal/tools"
 )
 
 // =============================================================================
@@ -47,37 +46,102 @@ func (m Model) submitInput() (tea.Model, tea.Cmd) {
 		tutorialCmd = m.tutorial.RecordAction("message")
 	}
 
-	// Clear input
+	// Clear input; a pending /edit turns this message into a new branch
 	m.input.Reset()
+	forkFrom := m.editMsgID
+	m.editMsgID = ""
 
+	updatedModel, sendCmd := m.sendMessage(content, forkFrom)
+
+	// Batch with tutorial command if present
+	if tutorialCmd != nil {
+		return updatedModel, tea.Batch(sendCmd, tutorialCmd)
+	}
+	return updatedModel, sendCmd
+}
+
+// sendMessage sends a user message and starts the response. When forkFrom is
+// the ID of an earlier user message, the message replaces it on a new branch
+// (see /edit and /regen) and the cache is skipped so the branch gets a fresh
+// response.
+func (m Model) sendMessage(content, forkFrom string) (tea.Model, tea.Cmd) {
 	// Process context expansion (@mentions)
-	displayContent, expandedContent, contextInfo := m.expandContextMentions(content)
+	displayContent, expandedContent, contextInfo, contextWarning := m.expandContextMentions(content)
 
 	// Check cache before routing
-	if cachedResponse, hitType := m.checkCache(displayContent); hitType != cache.CacheHitNone {
-		return m.handleCacheHit(displayContent, cachedResponse, hitType)
+	if forkFrom == "" {
+		if cachedResponse, hitType := m.checkCache(displayContent); hitType != cache.CacheHitNone {
+			return m.handleCacheHit(displayContent, cachedResponse, hitType)
+		}
 	}
 
 	// Cache miss - proceed with routing
 	m.lastCacheHit = cache.CacheHitNone
 
-	// Get routing decision
-	decision := m.makeRoutingDecision(expandedContent)
+	// Mark the message from its expanded content so @mentioned files count
+	// toward its classification, then route on the conversation high-water mark
+	msgClass := m.ClassifyContent(expandedContent)
+	decision := m.makeRoutingDecision(expandedContent, msgClass)
+
+	// A custom command may prefer a tier and limit the tools for this turn
+	m.turnTools = nil
+	if cmd := m.pendingCommand; cmd != nil {
+		m.pendingCommand = nil
+		m.turnTools = cmd.AllowedTools
+		decision = m.applyCommandTier(decision, cmd, msgClass)
+	}
+
+	// Spend budgets are checked before committing to a paid tier
+	send := pendingSend{
+		displayContent:  displayContent,
+		expandedContent: expandedContent,
+		contextInfo:     contextInfo,
+		contextWarning:  contextWarning,
+		forkFrom:        forkFrom,
+		msgClass:        msgClass,
+		decision:        m.applyBudget(decision),
+	}
+	if send.decision.OverBudget != "" {
+		return m.promptBudget(send)
+	}
+	return m.dispatchMessage(send)
+}
+
+// dispatchMessage adds a routed message to the conversation and starts the
+// response.
+func (m Model) dispatchMessage(send pendingSend) (tea.Model, tea.Cmd) {
+	decision := send.decision
 	m.lastRouting = &decision
 
 	// Add user message to conversation
-	m.conversation.AddUserMessage(displayContent)
+	var userMsg *model.Message
+	if send.forkFrom != "" {
+		var err error
+		if userMsg, err = m.conversation.Fork(send.forkFrom, send.displayContent); err != nil {
+			m.conversation.AddSystemMessage("Error: " + err.Error())
+			m.updateViewport()
+			return m, nil
+		}
+	} else {
+		userMsg = m.conversation.AddUserMessage(send.displayContent)
+	}
+	userMsg.Classification = send.msgClass.String()
+	if send.contextWarning != "" {
+		m.conversation.AddSystemMessage("Context warning: " + send.contextWarning)
+	}
 
-	// Create assistant message for streaming
+	// Create assistant message for streaming. The response may draw on any
+	// prior turn, so it carries the conversation's high-water mark.
 	assistantMsg := m.conversation.AddAssistantMessage()
+	assistantMsg.Classification = m.EffectiveClassification().String()
 	assistantMsg.RoutingTier = decision.Tier.String()
 	assistantMsg.RoutingCost = decision.EstimatedCostCents
-	if contextInfo != "" {
-		assistantMsg.ContextInfo = contextInfo
+	if send.contextInfo != "" {
+		assistantMsg.ContextInfo = send.contextInfo
 	}
 
 	// Store pending query for caching on completion
-	m.pendingQuery = displayContent
+	m.pendingQuery = send.displayContent
 	m.pendingMsgID = assistantMsg.ID
 
 	// Update viewport
@@ -88,13 +152,7 @@ func (m Model) submitInput() (tea.Model, tea.Cmd) {
 	m.currentQueryStart = time.Now()
 
 	// Route to appropriate backend
-	updatedModel, routeCmd := m.routeQuery(assistantMsg, decision, expandedContent)
-
-	// Batch with tutorial command if present
-	if tutorialCmd != nil {
-		return updatedModel, tea.Batch(routeCmd, tutorialCmd)
-	}
-	return updatedModel, routeCmd
+	return m.routeQuery(assistantMsg, decision, send.expandedContent)
 }
 
 // =============================================================================
@@ -102,17 +160,18 @@ func (m Model) submitInput() (tea.Model, tea.Cmd) {
 // =============================================================================
 
 // expandContextMentions processes @ mentions in the user input.
-// Returns: (displayContent, expandedContent, contextInfo)
+// Returns: (displayContent, expandedContent, contextInfo, warning)
 // - displayContent: what to show in the UI (clean message)
 // - expandedContent: what to send to the LLM (with context)
 // - contextInfo: summary of expanded context for display
-func (m *Model) expandContextMentions(content string) (string, string, string) {
+// - warning: errors fetching context, shown after the user's message
+func (m *Model) expandContextMentions(content string) (string, string, string, string) {
 	displayContent := content
 	expandedContent := content
 	contextInfo := ""
 
 	if !ctxmention.HasMentions(content) || m.contextExpander == nil {
-		return displayContent, expandedContent, contextInfo
+		return displayContent, expandedContent, contextInfo, ""
 	}
 
 	result := m.contextExpander.Expand(content)
@@ -131,13 +190,13 @@ func (m *Model) expandContextMentions(content string) (string, string, string) {
 	// Use expanded message for LLM (with context prepended)
 	expandedContent = result.ExpandedMessage
 
-	// Log any errors fetching context as system message
+	// Errors fetching context are reported once the message is added
+	warning := ""
 	if result.HasErrors() {
-		m.conversation.AddSystemMessage("Context warning: " + result.ErrorSummary())
-		m.updateViewport()
+		warning = result.ErrorSummary()
 	}
 
-	return displayContent, expandedContent, contextInfo
+	return displayContent, expandedContent, contextInfo, warning
 }
 
 // =============================================================================
@@ -150,12 +209,14 @@ func (m Model) handleCacheHit(query, cachedResponse string, hitType cache.CacheH
 	m.lastCacheHit = hitType
 
 	// Add user message (display version for UI)
-	m.conversation.AddUserMessage(query)
+	userMsg := m.conversation.AddUserMessage(query)
+	userMsg.Classification = m.ClassifyContent(query).String()
 
 	// Add assistant message with cached response (not streaming)
 	assistantMsg := m.conversation.AddAssistantMessage()
 	assistantMsg.Content = cachedResponse
 	assistantMsg.IsStreaming = false
+	assistantMsg.Classification = m.EffectiveClassification().String()
 
 	// Set routing info to show cache hit
 	if hitType == cache.CacheHitExact {
@@ -195,17 +256,20 @@ func (m Model) handleCacheHit(query, cachedResponse string, hitType cache.CacheH
 // =============================================================================
 
 // makeRoutingDecision determines which tier to use based on routing mode and content.
+// The classification used is the high-water mark of the conversation so far and
+// the marking of the message being sent (msgClass).
 // Classification enforcement ensures CUI+ data stays on-premise (NIST AC-4).
 //
 // SECURITY CRITICAL (AC-4): This function enforces information flow control.
 // CUI and higher classifications MUST NEVER be routed to cloud services.
 // The ClassificationEnforcer provides the hard security boundary.
-func (m *Model) makeRoutingDecision(content string) router.RoutingDecision {
+func (m *Model) makeRoutingDecision(content string, msgClass security.Classification) router.RoutingDecision {
 	cfg := config.Global()
 
-	// Get classification level from the model (set via SetClassificationLevel)
-	// This integrates with the classification UI when available
-	classification := m.classificationLevel
+	// Use the conversation high-water mark rather than the static session
+	// level: once classified content enters the conversation, every later
+	// turn carries it in context
+	classification := security.HighWaterMark(m.EffectiveClassification(), msgClass).Level
 
 	// ==========================================================================
 	// CRITICAL AC-4 ENFORCEMENT: Check if classification blocks cloud routing
@@ -393,8 +457,6 @@ func (m Model) routeCloudTier(assistantMsg *model.Message, decision router.Routi
 // NewConversation creates a new conversation with proper initialization.
 func NewConversation(m *Model) *model.Conversation {
 	conv := model.NewConversation()
-	if m.toolsEnabled {
-		conv.SystemPrompt = tools.GenerateMinimalToolPrompt()
-	}
+	conv.SystemPrompt = m.systemPrompt()
 	return conv
 }
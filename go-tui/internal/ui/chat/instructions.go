@@ -0,0 +1,196 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package chat provides the chat view component for the TUI.
+//
+// This file layers RIGRUN.md project instructions into the conversation's
+// system prompt and implements the /memory command.
+package chat
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/jeranaias/rigrun-tui/internal/instructions"
+	"github.com/jeranaias/rigrun-tui/internal/ollama"
+	"github.com/jeranaias/rigrun-tui/internal/tools"
+)
+
+// =============================================================================
+// SYSTEM PROMPT
+// =============================================================================
+
+// baseSystemPrompt returns the system prompt before project instructions.
+func (m *Model) baseSystemPrompt() string {
+	if m.toolsEnabled {
+		return tools.GenerateMinimalToolPrompt()
+	}
+	return ""
+}
+
+// systemPrompt returns the base system prompt with project instructions.
+func (m *Model) systemPrompt() string {
+	return m.projectInstructions.Set().Apply(m.baseSystemPrompt())
+}
+
+// refreshSystemPrompt updates the conversation's system prompt after the
+// instruction files changed.
+func (m *Model) refreshSystemPrompt() {
+	if m.conversation != nil {
+		m.conversation.SystemPrompt = m.systemPrompt()
+	}
+}
+
+// Instructions returns the project instruction tracker.
+func (m *Model) Instructions() *instructions.Tracker {
+	return m.projectInstructions
+}
+
+// NoteToolCall records the files a tool call touched. If that brings new
+// RIGRUN.md files into scope, the system prompt is refreshed and true is
+// returned so callers holding a copy of the messages can update it.
+func (m *Model) NoteToolCall(params map[string]interface{}) bool {
+	if !m.projectInstructions.Touch(instructions.PathsFromToolParams(params)...) {
+		return false
+	}
+	m.refreshSystemPrompt()
+	return true
+}
+
+// TurnSystemPrompt returns the system prompt for the current turn: the
+// conversation's, or when a custom command limits the turn's tools, one that
+// offers only those tools.
+func (m *Model) TurnSystemPrompt() string {
+	if !m.toolsEnabled || len(m.turnTools) == 0 {
+		return m.SystemPrompt()
+	}
+	return m.projectInstructions.Set().Apply(tools.GenerateAllowedToolPrompt(m.toolRegistry, m.turnTools))
+}
+
+// withTurnSystemPrompt returns messages with the turn's system prompt, so
+// that a limited turn is not offered other tools in the prompt.
+func (m *Model) withTurnSystemPrompt(messages []ollama.Message) []ollama.Message {
+	if len(m.turnTools) > 0 && len(messages) > 0 && messages[0].Role == "system" {
+		messages[0].Content = m.TurnSystemPrompt()
+	}
+	return messages
+}
+
+// SystemPrompt returns the conversation's current system prompt.
+func (m *Model) SystemPrompt() string {
+	if m.conversation == nil {
+		return ""
+	}
+	return m.conversation.SystemPrompt
+}
+
+// writeInstructionStatus appends the loaded instruction files to a status
+// report.
+func (m *Model) writeInstructionStatus(sb *strings.Builder) {
+	set := m.projectInstructions.Set()
+	if set == nil || len(set.Files) == 0 {
+		sb.WriteString("  Instructions: none (" + instructions.FileName + " not found)\n")
+		return
+	}
+	fmt.Fprintf(sb, "  Instructions: %d file(s), ~%d tokens\n", len(set.Files), set.Tokens())
+	for _, f := range set.Files {
+		note := ""
+		if f.Truncated {
+			note = ", truncated"
+		}
+		fmt.Fprintf(sb, "    %s (%s, ~%d tokens%s)\n", set.DisplayPath(f), f.Scope, f.Tokens, note)
+	}
+}
+
+// =============================================================================
+// /memory COMMAND
+// =============================================================================
+
+const memoryUsage = "Usage: /memory [show]\n" +
+	"       /memory add [--user | --dir <path>] <note>\n" +
+	"       /memory reload"
+
+func handleMemoryCommand(m *Model, args []string) (tea.Model, tea.Cmd) {
+	action := "show"
+	if len(args) > 0 {
+		action = strings.ToLower(args[0])
+		args = args[1:]
+	}
+
+	switch action {
+	case "show", "list":
+		var sb strings.Builder
+		sb.WriteString("Project Instructions:\n")
+		m.writeInstructionStatus(&sb)
+		sb.WriteString("\nAdd a note with /memory add <note>")
+		m.conversation.AddSystemMessage(sb.String())
+
+	case "add":
+		scope := instructions.ScopeProject
+		dir := ""
+		for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+			switch args[0] {
+			case "--user":
+				scope = instructions.ScopeUser
+				args = args[1:]
+			case "--project":
+				scope = instructions.ScopeProject
+				args = args[1:]
+			case "--dir":
+				if len(args) < 2 {
+					m.conversation.AddSystemMessage("Error: --dir requires a path\n" + memoryUsage)
+					m.updateViewport()
+					return m, nil
+				}
+				scope = instructions.ScopeDirectory
+				dir = args[1]
+				args = args[2:]
+			default:
+				m.conversation.AddSystemMessage("Error: unknown option " + args[0] + "\n" + memoryUsage)
+				m.updateViewport()
+				return m, nil
+			}
+		}
+		note := strings.Join(args, " ")
+		if strings.TrimSpace(note) == "" {
+			m.conversation.AddSystemMessage("Error: note is required\n" + memoryUsage)
+			break
+		}
+
+		set := m.projectInstructions.Set()
+		if set == nil {
+			m.conversation.AddSystemMessage("Error: project instructions are not available")
+			break
+		}
+		path, err := set.NotePath(scope, dir)
+		if err == nil {
+			err = instructions.AppendNote(path, note)
+		}
+		if err != nil {
+			m.conversation.AddSystemMessage("Error: " + err.Error())
+			break
+		}
+		// A new file in a nested directory is only loaded once it is in scope
+		if !m.projectInstructions.Touch(path) {
+			m.projectInstructions.Reload()
+		}
+		m.refreshSystemPrompt()
+		m.conversation.AddSystemMessage("Added note to " + path)
+
+	case "reload":
+		m.projectInstructions.Reload()
+		m.refreshSystemPrompt()
+		var sb strings.Builder
+		sb.WriteString("Reloaded project instructions:\n")
+		m.writeInstructionStatus(&sb)
+		m.conversation.AddSystemMessage(sb.String())
+
+	default:
+		m.conversation.AddSystemMessage("Error: Invalid action '" + action + "'\n" + memoryUsage)
+	}
+
+	m.updateViewport()
+	return m, nil
+}
@@ -198,11 +198,13 @@ func GetHelpItems() []HelpItem {
 		{"C-c", "Cancel streaming", streamingOnly, CategoryActions},
 		{"Esc", "Cancel / exit mode", []HelpContext{ContextInput, ContextStreaming, ContextSearch, ContextError}, CategoryActions},
 		{"C-y", "Copy last response", normalAndInput, CategoryActions},
+		{"[ / ]", "Previous / next branch", normalOnly, CategoryActions},
 
 		// Commands - mostly available in normal/input modes
 		{"C-p", "Command palette", normalAndInput, CategoryCommands},
 		{"C-l", "Clear screen", normalAndInput, CategoryCommands},
 		{"C-f or /", "Search", normalOnly, CategoryCommands},
+		{"/search", "Search all sessions", inputOnly, CategoryCommands},
 		{"C-r", "Cycle routing mode", normalAndInput, CategoryCommands},
 		{"/command", "Run slash command", inputOnly, CategoryCommands},
 
@@ -216,8 +218,11 @@ func GetHelpItems() []HelpItem {
 		{"C-q", "Quit (emergency)", all, CategoryActions},
 
 		// Search mode specific
-		{"n/Enter", "Next match", searchOnly, CategorySearch},
-		{"N", "Previous match", searchOnly, CategorySearch},
+		{"Enter", "Finish query", searchOnly, CategorySearch},
+		{"n/Enter", "Next match (after query)", searchOnly, CategorySearch},
+		{"N", "Previous match (after query)", searchOnly, CategorySearch},
+		{"/", "Edit query", searchOnly, CategorySearch},
+		{"Tab", "Search all sessions", searchOnly, CategorySearch},
 		{"Esc", "Exit search", searchOnly, CategorySearch},
 
 		// Error mode specific
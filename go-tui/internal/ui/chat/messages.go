@@ -43,6 +43,8 @@ type StreamRequestMsg struct {
 	UseCloud   bool   // If true, use cloud client instead of Ollama
 	CloudModel string // Cloud model to use (e.g., "haiku", "sonnet", "opus")
 	CloudTier  string // Tier string for display
+	// AllowedTools limits the tools offered to the model (nil = all)
+	AllowedTools []string
 }
 
 // StreamStartMsg signals that streaming has begun.
@@ -154,6 +156,11 @@ type ClearConversationMsg struct{}
 // LoadConversationMsg loads a conversation by ID.
 type LoadConversationMsg struct {
 	ID string
+
+	// MessageID and Query, when set, focus a session search result in the
+	// loaded conversation (see Model.FocusMessage)
+	MessageID string
+	Query     string
 }
 
 // ConversationLoadedMsg delivers a loaded conversation.
@@ -7,6 +7,8 @@ package chat
 import (
 	"context"
 	"fmt"
+	"os"
+	"runtime"
 	"strings"
 	"time"
 
@@ -20,14 +22,19 @@ import (
 	"github.com/jeranaias/rigrun-tui/internal/commands"
 	"github.com/jeranaias/rigrun-tui/internal/config"
 	ctxmention "github.com/jeranaias/rigrun-tui/internal/context"
+	"github.com/jeranaias/rigrun-tui/internal/instructions"
 	"github.com/jeranaias/rigrun-tui/internal/model"
 	"github.com/jeranaias/rigrun-tui/internal/ollama"
+	"github.com/jeranaias/rigrun-tui/internal/plan"
 	"github.com/jeranaias/rigrun-tui/internal/router"
 	"github.com/jeranaias/rigrun-tui/internal/security"
+	"github.com/jeranaias/rigrun-tui/internal/storage"
 	"github.com/jeranaias/rigrun-tui/internal/tasks"
+	"github.com/jeranaias/rigrun-tui/internal/telemetry"
 	"github.com/jeranaias/rigrun-tui/internal/tools"
 	"github.com/jeranaias/rigrun-tui/internal/ui/components"
 	"github.com/jeranaias/rigrun-tui/internal/ui/styles"
+	"github.com/jeranaias/rigrun-tui/internal/util"
 )
 
 // =============================================================================
@@ -61,6 +68,7 @@ type Model struct {
 
 	// Conversation
 	conversation *model.Conversation
+	editMsgID    string // User message being edited; the next submission forks from it
 
 	// Current streaming message
 	streamingMsgID string
@@ -106,6 +114,12 @@ type Model struct {
 	lastRouting  *router.RoutingDecision // Last routing decision for display
 	sessionStats *router.SessionStats    // Cumulative session statistics
 
+	// Spend budgets (see budget.go)
+	budget       *telemetry.BudgetGuard // Cost tracker and the budgets it is checked against
+	budgetAction string                 // "local" or "prompt" once a budget is spent
+	budgetStatus telemetry.BudgetStatus // Spend against the budgets, for the status bar
+	budgetPrompt *pendingSend           // Message awaiting a decision to send it over budget
+
 	// Current query tracking (for session stats on completion)
 	currentQueryTier  router.Tier // Actual tier used for current streaming query
 	currentQueryStart time.Time   // Start time of current query for latency tracking
@@ -121,6 +135,11 @@ type Model struct {
 	toolExecutor *tools.Executor    // Tool execution engine
 	toolsEnabled bool               // Whether tools are enabled for chat
 	agenticLoop  *tools.AgenticLoop // Agentic loop for multi-turn tool use
+	workDir      string             // Directory tools work in ("" = process's)
+	toolSetupErr error              // Tool configuration that failed to apply
+
+	// Project instructions (RIGRUN.md) layered into the system prompt
+	projectInstructions *instructions.Tracker
 
 	// Context mention system (@file, @git, @codebase, @error, @clipboard)
 	contextExpander *ctxmention.Expander // Expands @ mentions into context
@@ -137,10 +156,18 @@ type Model struct {
 
 	// Search functionality (Ctrl+F)
 	searchMode       bool            // True when in search mode
+	searchEditing    bool            // True while the query is being typed
 	searchQuery      string          // Current search query
 	searchInput      textinput.Model // Search input field
 	searchMatches    []SearchMatch   // All matches found
 	searchMatchIndex int             // Current match index (for navigation)
+	searchMatchLines []int           // Viewport line of each match (from the last render)
+	searchAnchor     int             // Viewport offset when search started
+	messageOffsets   []int           // First viewport line of each rendered message
+
+	// Session search overlay (/search)
+	sessionSearch   *components.SessionSearch
+	sessionSearcher func(query string, limit int) ([]storage.MessageHit, error)
 
 	// Help overlay
 	showHelp bool // True when help overlay is visible
@@ -162,6 +189,7 @@ type Model struct {
 
 	// Classification enforcement (NIST 800-53 AC-4)
 	classificationLevel    security.ClassificationLevel     // Current session classification level
+	sessionClassification  security.Classification          // Full session marking (level + caveats)
 	classificationEnforcer *security.ClassificationEnforcer // AC-4 routing enforcer
 
 	// Progress tracking (for agentic loops and multi-step operations)
@@ -171,13 +199,17 @@ type Model struct {
 	// Command palette (Ctrl+P)
 	commandPalette     *components.CommandPalette // Command palette for fuzzy command search
 	commandRegistry    *commands.Registry         // Registry for palette access
+	pendingCommand     *commands.CustomCommand    // Custom command whose prompt is being sent
+	turnTools          []string                   // Tools the current turn is limited to (nil = all)
 
 	// Tutorial overlay
 	tutorial *components.TutorialOverlay // Interactive tutorial overlay
 
 	// Background task system
-	taskQueue  *tasks.Queue  // Background task queue
-	taskRunner *tasks.Runner // Task runner for background execution
+	taskQueue          *tasks.Queue        // Background task queue
+	taskRunner         *tasks.Runner       // Task runner for background execution
+	taskListening      bool                // Whether a notification listener is running
+	pendingTaskResults map[string][]string // Agent task summaries by conversation ID, awaiting delivery
 
 	// Non-blocking error toasts (lazygit-inspired)
 	// Toasts appear in bottom-right corner and auto-dismiss without blocking UI
@@ -212,7 +244,8 @@ func New(theme *styles.Theme) Model {
 	}
 
 	// Initialize tool system
-	toolRegistry := tools.NewRegistry()
+	// Setup errors are shown once the UI is up (see Init)
+	toolRegistry, toolSetupErr := tools.NewConfiguredRegistry(config.Global())
 	toolExecutor := tools.NewExecutor(toolRegistry)
 	// Auto-approve low-risk tools (Read, Glob, Grep)
 	toolExecutor.SetAutoApproveLevel(tools.PermissionAuto)
@@ -226,9 +259,13 @@ func New(theme *styles.Theme) Model {
 	searchInput.Placeholder = "Type to search..."
 	searchInput.CharLimit = 256
 
+	// Load project instructions (RIGRUN.md) for the working directory
+	cwd, _ := os.Getwd()
+	projectInstructions := instructions.NewTracker(cwd)
+
 	// Create conversation with tool-aware system prompt
 	conv := model.NewConversation()
-	conv.SystemPrompt = tools.GenerateMinimalToolPrompt()
+	conv.SystemPrompt = projectInstructions.Set().Apply(tools.GenerateMinimalToolPrompt())
 
 	// Initialize classification enforcer for AC-4 compliance
 	// Uses global audit logger for audit trail of blocked requests
@@ -236,6 +273,9 @@ func New(theme *styles.Theme) Model {
 
 	// Initialize completion system
 	cmdRegistry := commands.NewRegistry()
+	// Custom commands from .rigrun/commands; /commands lists skipped files
+	customCmds, _ := commands.LoadCustomCommands(cwd)
+	cmdRegistry.RegisterCustom(customCmds)
 	completer := commands.NewCompleter(cmdRegistry)
 	completionState := commands.NewCompletionState()
 
@@ -273,7 +313,9 @@ func New(theme *styles.Theme) Model {
 		sessionStats:           router.NewSessionStats(),
 		toolRegistry:           toolRegistry,
 		toolExecutor:           toolExecutor,
+		toolSetupErr:           toolSetupErr,
 		toolsEnabled:           true, // Enable tools by default
+		projectInstructions:    projectInstructions,
 		contextExpander:        contextExpander,
 		activeContext:          components.NewActiveContext(),       // Initialize empty active context
 		showContextBar:         false,                               // Don't show context bar initially
@@ -285,12 +327,15 @@ func New(theme *styles.Theme) Model {
 		showCompletions:        false,                               // Don't show completions initially
 		completionCycleCount:   0,                                   // No cycles yet
 		classificationLevel:    security.ClassificationUnclassified, // Default to UNCLASSIFIED
+		sessionClassification:  security.DefaultClassification(),
 		classificationEnforcer: classEnforcer,
 		commandPalette:         cmdPalette,
+		sessionSearch:          components.NewSessionSearch(),
 		commandRegistry:        cmdRegistry,
 		tutorial:               &tutorial, // Tutorial overlay
 		taskQueue:              taskQueue,
 		taskRunner:             taskRunner,
+		pendingTaskResults:     make(map[string][]string),
 		streamingBuffer:        NewStreamingBuffer(),   // Feature 4.2: Token batching for smooth streaming
 		viewportOptimizer:     NewViewportOptimizer(),                // Feature 4.2: Reduce redundant viewport updates
 		lastStreamTick:        time.Now(),                            // Feature 4.2: Last streaming tick timestamp
@@ -332,6 +377,17 @@ func (m Model) Init() tea.Cmd {
 		})
 	}
 
+	// Tool configuration that failed to apply (it fails closed)
+	if m.toolSetupErr != nil {
+		msg := components.ToastAddMsg{Kind: components.ToastKindWarning, Message: "Tool setup: " + m.toolSetupErr.Error()}
+		cmds = append(cmds, func() tea.Msg { return msg })
+	}
+
+	// Listen for notifications of tasks restored from the task store
+	if m.taskListening {
+		cmds = append(cmds, m.listenForNotifications())
+	}
+
 	return tea.Batch(cmds...)
 }
 
@@ -399,9 +455,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case NewConversationMsg:
 		m.conversation = model.NewConversation()
-		if m.toolsEnabled {
-			m.conversation.SystemPrompt = tools.GenerateMinimalToolPrompt()
-		}
+		m.conversation.SystemPrompt = m.systemPrompt()
 		m.updateViewport()
 		return m, nil
 
@@ -423,11 +477,16 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case SessionResumedMsg:
 		return m.handleSessionResumed(msg)
 
-	case SessionSearchMsg:
-		return m.handleSessionSearch(msg)
+	case components.SessionSearchResultsMsg:
+		var cmd tea.Cmd
+		m.sessionSearch, cmd = m.sessionSearch.Update(msg)
+		return m, cmd
+
+	case components.SessionSearchSelectMsg:
+		return m.handleSessionSearchSelect(msg)
 
-	case SessionSearchResultMsg:
-		return m.handleSessionSearchResult(msg)
+	case commands.SearchSessionsMsg:
+		return m.openSessionSearch(msg.Query)
 
 	case commands.ExportConversationMsg:
 		return m.handleExportConversation(msg)
@@ -435,6 +494,21 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case commands.ExportCompleteMsg:
 		return m.handleExportComplete(msg)
 
+	case commands.CustomCommandMsg:
+		return m.handleCustomCommand(msg.Command, msg.Args)
+
+	case commands.BranchCommandMsg:
+		handler, ok := commandHandlers[msg.Command]
+		if !ok {
+			return m, nil
+		}
+		if m.state != StateReady {
+			m.conversation.AddSystemMessage("Cannot run /" + msg.Command + " while a response is in progress")
+			m.updateViewport()
+			return m, nil
+		}
+		return handler(&m, msg.Args)
+
 	case ShowTutorialMsg:
 		return m.handleShowTutorial(msg)
 
@@ -621,6 +695,11 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, tea.Quit
 	}
 
+	// A message held back over budget captures the keyboard
+	if m.budgetPrompt != nil {
+		return m.handleBudgetPromptKey(msg)
+	}
+
 	// Handle tutorial overlay first - it has priority when visible
 	if m.tutorial != nil && m.tutorial.IsVisible() {
 		var cmd tea.Cmd
@@ -635,6 +714,13 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, cmd
 	}
 
+	// Session search overlay (/search) has priority while open
+	if m.sessionSearch != nil && m.sessionSearch.IsVisible() {
+		var cmd tea.Cmd
+		m.sessionSearch, cmd = m.sessionSearch.Update(msg)
+		return m, cmd
+	}
+
 	// Handle help overlay first - any key dismisses it except navigation
 	if m.showHelp {
 		switch keyStr {
@@ -702,9 +788,9 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case "ctrl+f", "/":
-		// Enter search mode (/ only when not in input mode or input is empty)
-		if keyStr == "/" && m.inputMode && m.input.Value() != "" {
-			// Let / be typed into input
+		// Enter search mode (/ only outside the input, where it starts a command)
+		vimNormal := m.vimHandler != nil && m.vimHandler.Enabled() && m.vimHandler.Mode() == VimModeNormal
+		if keyStr == "/" && m.inputMode && !vimNormal {
 			break
 		}
 		return m.enterSearchMode()
@@ -820,6 +906,13 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				// Quit (only in normal mode)
 				return m, tea.Quit
 
+			case "[", "]":
+				// Cycle branches at the last fork point
+				if keyStr == "[" {
+					return m.handleBranchKey(-1)
+				}
+				return m.handleBranchKey(1)
+
 			default:
 				// Handle navigation in normal mode
 				return m.handleNavigationKeys(msg)
@@ -917,192 +1010,6 @@ func (m Model) handleNavigationKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-// handleSearchKey handles key presses when in search mode.
-func (m Model) handleSearchKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch msg.String() {
-	case "esc", "ctrl+f":
-		// Exit search mode
-		return m.exitSearchMode()
-
-	case "enter", "n":
-		// Navigate to next match (vim-like n)
-		return m.nextSearchMatch()
-
-	case "N":
-		// Navigate to previous match (vim-like N)
-		return m.prevSearchMatch()
-
-	case "ctrl+n", "down":
-		// Next match
-		return m.nextSearchMatch()
-
-	case "ctrl+p", "up":
-		// Previous match
-		return m.prevSearchMatch()
-
-	default:
-		// Forward to search input
-		var cmd tea.Cmd
-		m.searchInput, cmd = m.searchInput.Update(msg)
-
-		// Update search query and find matches
-		newQuery := m.searchInput.Value()
-		if newQuery != m.searchQuery {
-			m.searchQuery = newQuery
-			m.findSearchMatches()
-			m.updateViewport() // Re-render with highlights
-		}
-
-		return m, cmd
-	}
-}
-
-// enterSearchMode activates search mode.
-func (m Model) enterSearchMode() (tea.Model, tea.Cmd) {
-	m.searchMode = true
-	m.searchQuery = ""
-	m.searchInput.Reset()
-	m.searchInput.Focus()
-	m.searchMatches = nil
-	m.searchMatchIndex = 0
-	return m, textinput.Blink
-}
-
-// exitSearchMode deactivates search mode.
-func (m Model) exitSearchMode() (tea.Model, tea.Cmd) {
-	m.searchMode = false
-	m.searchQuery = ""
-	m.searchMatches = nil
-	m.searchMatchIndex = 0
-	m.input.Focus()
-	m.updateViewport() // Re-render without highlights
-	return m, textinput.Blink
-}
-
-// findSearchMatches finds all matches of the search query in the conversation.
-// Stores RUNE positions (not byte positions) for proper Unicode handling.
-func (m *Model) findSearchMatches() {
-	m.searchMatches = nil
-	m.searchMatchIndex = 0
-
-	if m.searchQuery == "" || m.conversation == nil {
-		return
-	}
-
-	queryRunes := []rune(strings.ToLower(m.searchQuery))
-	queryLen := len(queryRunes)
-	if queryLen == 0 {
-		return
-	}
-
-	messages := m.conversation.GetHistory()
-
-	for msgIdx, msg := range messages {
-		content := msg.GetDisplayContent()
-		if content == "" {
-			continue
-		}
-
-		// Convert to lowercase once for efficiency
-		contentLower := strings.ToLower(content)
-		textRunes := []rune(contentLower)
-
-		// Find all case-insensitive matches by rune comparison
-		for i := 0; i <= len(textRunes)-queryLen; i++ {
-			matched := true
-			for j := 0; j < queryLen; j++ {
-				if textRunes[i+j] != queryRunes[j] {
-					matched = false
-					break
-				}
-			}
-			if matched {
-				m.searchMatches = append(m.searchMatches, SearchMatch{
-					MessageIndex: msgIdx,
-					StartPos:     i,            // RUNE position
-					EndPos:       i + queryLen, // RUNE position
-				})
-				i += queryLen - 1 // Skip past this match
-			}
-		}
-	}
-}
-
-// nextSearchMatch navigates to the next search match.
-func (m Model) nextSearchMatch() (tea.Model, tea.Cmd) {
-	if len(m.searchMatches) == 0 {
-		return m, nil
-	}
-
-	m.searchMatchIndex = (m.searchMatchIndex + 1) % len(m.searchMatches)
-	m.scrollToCurrentMatch()
-	m.updateViewport()
-	return m, nil
-}
-
-// prevSearchMatch navigates to the previous search match.
-func (m Model) prevSearchMatch() (tea.Model, tea.Cmd) {
-	if len(m.searchMatches) == 0 {
-		return m, nil
-	}
-
-	m.searchMatchIndex--
-	if m.searchMatchIndex < 0 {
-		m.searchMatchIndex = len(m.searchMatches) - 1
-	}
-	m.scrollToCurrentMatch()
-	m.updateViewport()
-	return m, nil
-}
-
-// scrollToCurrentMatch scrolls the viewport to show the current search match.
-func (m *Model) scrollToCurrentMatch() {
-	if len(m.searchMatches) == 0 {
-		return
-	}
-
-	// Get the current match
-	match := m.searchMatches[m.searchMatchIndex]
-
-	// Calculate approximate line position by estimating lines for each message
-	// up to the matched message
-	targetLine := 0
-	messages := m.conversation.GetHistory()
-	termWidth := m.width
-	if termWidth <= 0 {
-		termWidth = 80 // fallback default
-	}
-	// Account for message padding/margins (roughly 10 chars on each side)
-	contentWidth := termWidth - 20
-	if contentWidth < 40 {
-		contentWidth = 40
-	}
-
-	for i := 0; i < match.MessageIndex && i < len(messages); i++ {
-		msg := messages[i]
-		content := msg.GetDisplayContent()
-		// Estimate lines: header (2 lines) + content lines + spacing (2 lines between messages)
-		contentLines := 1
-		if len(content) > 0 && contentWidth > 0 {
-			// Count actual newlines in content
-			newlineCount := strings.Count(content, "\n")
-			// Estimate wrapped lines based on content length (use rune count for proper Unicode handling)
-			wrappedLines := (len([]rune(content)) + contentWidth - 1) / contentWidth
-			// Use the larger of newline-based or wrap-based estimate
-			if newlineCount+1 > wrappedLines {
-				contentLines = newlineCount + 1
-			} else {
-				contentLines = wrappedLines
-			}
-		}
-		// Header (role label) + content + message separator spacing
-		targetLine += 2 + contentLines + 2
-	}
-
-	// Scroll to that position
-	m.viewport.SetYOffset(targetLine)
-}
-
 // IsSearchMode returns true if search mode is active.
 func (m *Model) IsSearchMode() bool {
 	return m.searchMode
@@ -1226,6 +1133,8 @@ func (m Model) handleStreamComplete(msg StreamCompleteMsg) (tea.Model, tea.Cmd)
 		m.conversation.FinalizeLast(m.streamingStats)
 	}
 
+	query := m.pendingQuery
+
 	// =========================================================================
 	// CACHE STORAGE - Store completed response in cache for future lookups
 	// =========================================================================
@@ -1277,6 +1186,9 @@ func (m Model) handleStreamComplete(msg StreamCompleteMsg) (tea.Model, tea.Cmd)
 			latencyMs,
 		)
 		m.sessionStats.RecordQuery(result)
+
+		// Spend counts toward the budgets
+		m.recordCost(result, query)
 	}
 
 	// Update state
@@ -1526,13 +1438,16 @@ func (m Model) startStreamingCloud(messageID string, cloudModel string, tierName
 // to the LLM while showing the original content in the UI.
 func (m Model) startStreamingLocalWithContent(messageID string, expandedContent string) tea.Cmd {
 	// Get conversation messages, but replace the last user message content with expanded content
-	messages := m.conversation.ToOllamaMessagesWithOverride(expandedContent)
+	messages := m.withTurnSystemPrompt(m.conversation.ToOllamaMessagesWithOverride(expandedContent))
+
+	allowedTools := m.turnTools
 
 	return func() tea.Msg {
 		return StreamRequestMsg{
-			MessageID: messageID,
-			Messages:  messages,
-			UseCloud:  false,
+			MessageID:    messageID,
+			Messages:     messages,
+			UseCloud:     false,
+			AllowedTools: allowedTools,
 		}
 	}
 }
@@ -1542,15 +1457,18 @@ func (m Model) startStreamingLocalWithContent(messageID string, expandedContent
 // to the LLM while showing the original content in the UI.
 func (m Model) startStreamingCloudWithContent(messageID string, cloudModel string, tierName string, expandedContent string) tea.Cmd {
 	// Get conversation messages, but replace the last user message content with expanded content
-	messages := m.conversation.ToOllamaMessagesWithOverride(expandedContent)
+	messages := m.withTurnSystemPrompt(m.conversation.ToOllamaMessagesWithOverride(expandedContent))
+
+	allowedTools := m.turnTools
 
 	return func() tea.Msg {
 		return StreamRequestMsg{
-			MessageID:  messageID,
-			Messages:   messages,
-			UseCloud:   true,
-			CloudModel: cloudModel,
-			CloudTier:  tierName,
+			MessageID:    messageID,
+			Messages:     messages,
+			UseCloud:     true,
+			CloudModel:   cloudModel,
+			CloudTier:    tierName,
+			AllowedTools: allowedTools,
 		}
 	}
 }
@@ -1663,6 +1581,15 @@ func (m *Model) SetCloudClient(client *cloud.OpenRouterClient) {
 	m.cloudClient = client
 }
 
+// SetWorkDir sets the directory tools and agent tasks work in when it is
+// not the process's working directory, as in a git worktree session.
+func (m *Model) SetWorkDir(dir string) {
+	m.workDir = dir
+	if m.toolExecutor != nil {
+		m.toolExecutor.SetWorkDir(dir)
+	}
+}
+
 // GetCloudClient returns the OpenRouter cloud client.
 func (m *Model) GetCloudClient() *cloud.OpenRouterClient {
 	return m.cloudClient
@@ -1684,6 +1611,15 @@ func (m *Model) SetConversation(conv *model.Conversation) {
 		conv = model.NewConversation()
 	}
 	m.conversation = conv
+	m.editMsgID = ""
+
+	// Deliver summaries of background tasks started from this conversation
+	if results := m.pendingTaskResults[conv.ID]; len(results) > 0 {
+		for _, result := range results {
+			conv.AddSystemMessage(result)
+		}
+		delete(m.pendingTaskResults, conv.ID)
+	}
 	m.updateViewport()
 }
 
@@ -1936,6 +1872,9 @@ func (m Model) handleToolCallRequested(msg ToolCallRequestedMsg) (tea.Model, tea
 		return m, nil
 	}
 
+	// Files in other directories can bring nested RIGRUN.md files into scope
+	m.NoteToolCall(msg.Arguments)
+
 	// Add system message showing the tool call
 	m.conversation.AddSystemMessage("Tool call: " + msg.ToolName)
 	m.updateViewport()
@@ -2054,12 +1993,45 @@ func (m *Model) GetClassificationLevel() security.ClassificationLevel {
 // This affects routing decisions - CUI and higher will NEVER route to cloud.
 func (m *Model) SetClassificationLevel(level security.ClassificationLevel) {
 	m.classificationLevel = level
+	m.sessionClassification.Level = level
 	// Update enforcer session ID if needed
 	if m.classificationEnforcer != nil && m.conversation != nil {
 		m.classificationEnforcer.SetSessionID(m.conversation.ID)
 	}
 }
 
+// SetSessionClassification sets the full session marking, including caveats.
+// Every message is marked at least at this level.
+func (m *Model) SetSessionClassification(c security.Classification) {
+	m.sessionClassification = c
+	m.SetClassificationLevel(c.Level)
+}
+
+// GetSessionClassification returns the full session marking.
+func (m *Model) GetSessionClassification() security.Classification {
+	return m.sessionClassification
+}
+
+// EffectiveClassification returns the high-water mark of the session marking
+// and every message in the current conversation. Routing decisions use this
+// rather than the static session level.
+func (m *Model) EffectiveClassification() security.Classification {
+	if m.conversation == nil {
+		return m.sessionClassification
+	}
+	return security.HighWaterMark(m.sessionClassification, m.conversation.HighWaterMark())
+}
+
+// ClassifyContent derives the marking for new content from the session floor,
+// explicit markings and (when enabled) spillage detection.
+func (m *Model) ClassifyContent(content string) security.Classification {
+	var detector *security.SpillageManager
+	if cfg := config.Global(); cfg != nil && cfg.Security.SpillageDetection {
+		detector = security.GlobalSpillageManager()
+	}
+	return security.ClassifyContent(content, m.sessionClassification, detector)
+}
+
 // GetClassificationEnforcer returns the classification enforcer.
 func (m *Model) GetClassificationEnforcer() *security.ClassificationEnforcer {
 	return m.classificationEnforcer
@@ -2068,22 +2040,24 @@ func (m *Model) GetClassificationEnforcer() *security.ClassificationEnforcer {
 // CanRouteToCloud returns true if the current classification allows cloud routing.
 // This is a convenience method for UI display.
 func (m *Model) CanRouteToCloud() bool {
+	level := m.EffectiveClassification().Level
 	if m.classificationEnforcer == nil {
-		return m.classificationLevel == security.ClassificationUnclassified
+		return level == security.ClassificationUnclassified
 	}
-	return m.classificationEnforcer.CanRouteToCloud(m.classificationLevel)
+	return m.classificationEnforcer.CanRouteToCloud(level)
 }
 
 // GetClassificationRestrictions returns a human-readable description of
 // the current classification's routing restrictions.
 func (m *Model) GetClassificationRestrictions() string {
+	level := m.EffectiveClassification().Level
 	if m.classificationEnforcer == nil {
-		if m.classificationLevel == security.ClassificationUnclassified {
+		if level == security.ClassificationUnclassified {
 			return "UNCLASSIFIED: No routing restrictions"
 		}
 		return "Cloud routing BLOCKED - local processing only (AC-4)"
 	}
-	return m.classificationEnforcer.GetClassificationRestrictions(m.classificationLevel)
+	return m.classificationEnforcer.GetClassificationRestrictions(level)
 }
 
 // =============================================================================
@@ -2150,7 +2124,7 @@ func (m *Model) GetContextCostEstimate() float64 {
 }
 
 // =============================================================================
-// SESSION RESUME AND SEARCH HANDLERS
+// SESSION RESUME HANDLERS
 // =============================================================================
 
 // handleSessionResume initiates loading a session for resume with context display.
@@ -2184,57 +2158,6 @@ func (m Model) handleSessionResumed(msg SessionResumedMsg) (tea.Model, tea.Cmd)
 	return m, nil
 }
 
-// handleSessionSearch initiates a search for sessions by message content.
-func (m Model) handleSessionSearch(msg SessionSearchMsg) (tea.Model, tea.Cmd) {
-	// Return a command that will search and return SessionSearchResultMsg
-	query := msg.Query
-	return m, func() tea.Msg {
-		// This will be handled by the main model which has access to storage
-		// For now, add a placeholder message
-		return SessionSearchResultMsg{
-			Query:    query,
-			Sessions: nil,
-			Error:    nil,
-		}
-	}
-}
-
-// handleSessionSearchResult processes search results.
-func (m Model) handleSessionSearchResult(msg SessionSearchResultMsg) (tea.Model, tea.Cmd) {
-	if msg.Error != nil {
-		m.conversation.AddSystemMessage("Search failed: " + msg.Error.Error())
-		m.updateViewport()
-		return m, nil
-	}
-
-	if len(msg.Sessions) == 0 {
-		m.conversation.AddSystemMessage("No sessions found matching: " + msg.Query)
-		m.updateViewport()
-		return m, nil
-	}
-
-	// Format search results
-	var result strings.Builder
-	result.WriteString("Sessions matching \"" + msg.Query + "\":\n")
-	result.WriteString("-----------------------------------------------------\n")
-
-	for _, s := range msg.Sessions {
-		displayID := s.ID
-		if len(displayID) > 12 {
-			displayID = displayID[:12]
-		}
-		result.WriteString(displayID + " | " +
-			s.UpdatedAt + " | " +
-			formatIntSimple(s.MessageCount) + " msgs | " +
-			truncatePreview(s.Preview, 30) + "\n")
-	}
-
-	result.WriteString("\nUse /resume <id> to load a session")
-	m.conversation.AddSystemMessage(result.String())
-	m.updateViewport()
-	return m, nil
-}
-
 // handleCommandExecution executes a command selected from the command palette.
 func (m Model) handleCommandExecution(msg components.ExecuteCommandMsg) (tea.Model, tea.Cmd) {
 	if msg.Command == nil {
@@ -2274,21 +2197,6 @@ func formatIntSimple(n int) string {
 	return string(digits)
 }
 
-// truncatePreview truncates a string to maxLen characters.
-func truncatePreview(s string, maxLen int) string {
-	if maxLen <= 0 {
-		return ""
-	}
-	runes := []rune(s)
-	if len(runes) <= maxLen {
-		return s
-	}
-	if maxLen <= 3 {
-		return string(runes[:maxLen])
-	}
-	return string(runes[:maxLen-3]) + "..."
-}
-
 // =============================================================================
 // ACTIVE CONTEXT MANAGEMENT
 // =============================================================================
@@ -2501,8 +2409,19 @@ func (m Model) handleTaskCreate(msg TaskCreateMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
-	// Create new task
-	task := tasks.NewTask(msg.Description, msg.Command, msg.Args)
+	// Create new task; agent work runs with its own conversation
+	var task *tasks.Task
+	switch strings.ToLower(msg.Command) {
+	case tasks.CommandAgent, "plan":
+		var err error
+		if task, err = m.newAgentTask(msg); err != nil {
+			m.conversation.AddSystemMessage("Error: " + err.Error())
+			m.updateViewport()
+			return m, nil
+		}
+	default:
+		task = tasks.NewTask(msg.Description, msg.Command, msg.Args)
+	}
 	task.ConversationID = m.conversation.ID
 
 	// Add to queue
@@ -2517,9 +2436,109 @@ func (m Model) handleTaskCreate(msg TaskCreateMsg) (tea.Model, tea.Cmd) {
 	m.updateViewport()
 
 	// Start listening for notifications in background
+	if m.taskListening {
+		return m, nil
+	}
+	m.taskListening = true
 	return m, m.listenForNotifications()
 }
 
+// newAgentTask creates a task that runs an agentic loop, or generates and
+// runs a plan, on the tier its prompt routes to. The agent gets a
+// conversation and tool registry of its own, configured like the session's
+// but without the choices made in it, and never prompts: calls that need
+// approval are denied.
+func (m *Model) newAgentTask(msg TaskCreateMsg) (*tasks.Task, error) {
+	kind := strings.ToLower(msg.Command)
+	prompt := strings.Join(msg.Args, " ")
+	tier := m.agentTier(prompt)
+	work, err := m.agentWork(kind, prompt, m.modelName, tier)
+	if err != nil {
+		return nil, err
+	}
+
+	task := tasks.NewAgentTask(msg.Description, work)
+	task.Args = msg.Args
+	task.Metadata["kind"] = kind
+	task.Metadata["tier"] = tier.String()
+	task.Metadata["model"] = m.modelName
+	if cwd := m.agentWorkDir(); cwd != "" {
+		task.Metadata["work_dir"] = cwd
+	}
+	return task, nil
+}
+
+// agentTier routes the prompt of an agent task like a message of the
+// session, so classification enforcement, the routing mode and the cost
+// budgets apply. A task cannot ask to go over budget, so an exceeded budget
+// routes it locally, as does a session without a cloud client.
+func (m *Model) agentTier(prompt string) router.Tier {
+	decision := m.makeRoutingDecision(prompt, m.ClassifyContent(prompt))
+	if m.budget != nil {
+		decision = router.ApplyBudget(decision, m.budget, router.BudgetActionLocal)
+	}
+	if decision.Tier.IsLocal() || !m.HasCloudClient() {
+		return router.TierLocal
+	}
+	return decision.Tier
+}
+
+// agentWorkDir returns the directory agent tasks work in.
+func (m *Model) agentWorkDir() string {
+	if m.workDir != "" {
+		return m.workDir
+	}
+	cwd, _ := os.Getwd()
+	return cwd
+}
+
+// agentWork returns the work of an agent task of the given kind ("plan" or
+// "agent") on tier, using modelName when the tier is local. Each task gets
+// its own tool registry: the session's can change under it through /tools
+// and "always allow", which must not apply to unattended runs.
+func (m *Model) agentWork(kind, prompt, modelName string, tier router.Tier) (tasks.AgentFunc, error) {
+	registry, err := tools.NewConfiguredRegistry(config.Global())
+	if err != nil {
+		return nil, fmt.Errorf("tool setup: %w", err)
+	}
+	cwd := m.agentWorkDir()
+
+	var chat tasks.ChatFactory
+	var llm plan.LLMClient
+	systemPrompt := tools.GenerateMinimalToolPrompt()
+	if tier.IsLocal() {
+		if m.ollama == nil {
+			return nil, fmt.Errorf("agent tasks need a local model (Ollama client not configured)")
+		}
+		chat = tasks.OllamaChat(m.ollama, modelName, registry)
+		llm = plan.NewOllamaLLM(m.ollama, modelName)
+	} else {
+		if !m.HasCloudClient() {
+			return nil, fmt.Errorf("agent tasks on the %s tier need a cloud client (OpenRouter key not configured)", tier)
+		}
+		chat = tasks.CloudChat(m.cloudClient, m.tierToCloudModel(tier), tier, m.budget)
+		llm = tasks.ChatLLM(chat)
+		// Cloud models call tools in text, as this prompt describes
+		systemPrompt = tools.GenerateAgenticLoopPromptWithContext(runtime.GOOS, cwd)
+	}
+
+	if kind == "plan" {
+		return tasks.PlanAgent(tasks.PlanConfig{
+			Task:      prompt,
+			Generator: plan.NewGenerator(llm),
+			Registry:  registry,
+			WorkDir:   cwd,
+		}), nil
+	}
+	return tasks.AgentLoop(tasks.AgentConfig{
+		Prompt:       prompt,
+		SystemPrompt: m.projectInstructions.Set().Apply(systemPrompt),
+		Chat:         chat,
+		Registry:     registry,
+		WorkDir:      cwd,
+	}), nil
+}
+
 // handleTaskList shows the task list.
 func (m Model) handleTaskList(msg TaskListMsg) (tea.Model, tea.Cmd) {
 	if m.taskQueue == nil {
@@ -2575,7 +2594,7 @@ func (m Model) handleTaskCancel(msg TaskCancelMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-// handleTaskNotification handles task completion notifications.
+// handleTaskNotification handles task progress and completion notifications.
 func (m Model) handleTaskNotification(msg TaskNotificationMsg) (tea.Model, tea.Cmd) {
 	// Format notification message
 	var notifMsg string
@@ -2584,6 +2603,12 @@ func (m Model) handleTaskNotification(msg TaskNotificationMsg) (tea.Model, tea.C
 		shortID = shortID[:8]
 	}
 
+	// Progress goes to the status line and the task list, not the chat
+	if msg.Status == string(tasks.TaskStatusRunning) {
+		m.statusMsg = fmt.Sprintf("Task %s: %s", shortID, util.TruncateRunes(msg.Step, 60))
+		return m, m.listenForNotifications()
+	}
+
 	switch msg.Status {
 	case "Complete":
 		notifMsg = fmt.Sprintf("[OK] Task complete: %s [%s] (%.1fs)", msg.Description, shortID, msg.Duration.Seconds())
@@ -2591,10 +2616,24 @@ func (m Model) handleTaskNotification(msg TaskNotificationMsg) (tea.Model, tea.C
 		notifMsg = fmt.Sprintf("[FAIL] Task failed: %s [%s] - %s", msg.Description, shortID, msg.Error)
 	case "Canceled":
 		notifMsg = fmt.Sprintf("[--] Task canceled: %s [%s]", msg.Description, shortID)
+	case "Interrupted":
+		notifMsg = fmt.Sprintf("[!!] Task interrupted: %s [%s] - rigrun exited while it was running (rigrun tasks retry %s)", msg.Description, shortID, shortID)
 	default:
 		notifMsg = fmt.Sprintf("Task %s: %s [%s]", msg.Status, msg.Description, shortID)
 	}
 
+	// Agent summaries belong to the conversation the task came from; keep
+	// them until it is shown again
+	if msg.Result != "" {
+		summary := notifMsg + "\n\n" + msg.Result
+		if msg.ConversationID != "" && msg.ConversationID != m.conversation.ID {
+			m.pendingTaskResults[msg.ConversationID] = append(m.pendingTaskResults[msg.ConversationID], summary)
+			notifMsg += " - summary posted to its conversation"
+		} else {
+			notifMsg = summary
+		}
+	}
+
 	// Add notification to conversation
 	m.conversation.AddSystemMessage(notifMsg)
 	m.updateViewport()
@@ -2609,20 +2648,20 @@ func (m *Model) listenForNotifications() tea.Cmd {
 		return nil
 	}
 
+	// A single listener waits for the next notification; each
+	// TaskNotificationMsg starts the next wait
+	notifications := m.taskQueue.Notifications()
 	return func() tea.Msg {
-		// Non-blocking check for notifications
-		select {
-		case notif := <-m.taskQueue.Notifications():
-			return TaskNotificationMsg{
-				TaskID:      notif.TaskID,
-				Description: notif.Description,
-				Status:      notif.Status.String(),
-				Duration:    notif.Duration,
-				Error:       notif.Error,
-			}
-		case <-time.After(100 * time.Millisecond):
-			// No notification, return nil
-			return nil
+		notif := <-notifications
+		return TaskNotificationMsg{
+			TaskID:         notif.TaskID,
+			Description:    notif.Description,
+			Status:         notif.Status.String(),
+			Duration:       notif.Duration,
+			Error:          notif.Error,
+			ConversationID: notif.ConversationID,
+			Step:           notif.Step,
+			Result:         notif.Result,
 		}
 	}
 }
@@ -2632,6 +2671,39 @@ func (m *Model) GetTaskQueue() *tasks.Queue {
 	return m.taskQueue
 }
 
+// SetTaskStore makes background tasks durable: tasks queued by an earlier
+// session (or the CLI) are run, and tasks it left running are reported as
+// interrupted. Agent tasks are rebuilt on the tier and local model they
+// were started with. Call it after SetOllamaClient.
+func (m *Model) SetTaskStore(store *tasks.Store) error {
+	if m.taskQueue == nil {
+		return fmt.Errorf("task system not initialized")
+	}
+
+	agent := *m
+	m.taskQueue.SetAgentFactory(func(task *tasks.Task) (tasks.AgentFunc, error) {
+		kind, _ := task.Metadata["kind"].(string)
+		modelName, _ := task.Metadata["model"].(string)
+		if modelName == "" {
+			modelName = agent.modelName
+		}
+		tier := router.TierLocal
+		if name, _ := task.Metadata["tier"].(string); name != "" {
+			if parsed, ok := router.ParseTier(strings.ToLower(name)); ok {
+				tier = parsed
+			}
+		}
+		return agent.agentWork(kind, strings.Join(task.Args, " "), modelName, tier)
+	})
+	if err := m.taskQueue.AttachStore(store); err != nil {
+		return err
+	}
+
+	// Restored tasks notify before any task is created here
+	m.taskListening = true
+	return nil
+}
+
 // =============================================================================
 // VIM MODE HANDLERS
 // =============================================================================
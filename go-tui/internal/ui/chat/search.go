@@ -0,0 +1,441 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package chat provides the chat view component for the TUI.
+//
+// This file implements search: incremental find in the current conversation
+// (Ctrl+F, or / outside the input) and the session search overlay that
+// finds messages across all saved conversations (/search, or Tab while
+// finding).
+package chat
+
+import (
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/jeranaias/rigrun-tui/internal/security"
+	"github.com/jeranaias/rigrun-tui/internal/storage"
+	"github.com/jeranaias/rigrun-tui/internal/ui/components"
+)
+
+// sessionSearchLimit caps the results shown by the session search overlay.
+const sessionSearchLimit = 50
+
+// =============================================================================
+// FIND MODE KEYS
+// =============================================================================
+
+// handleSearchKey handles key presses in find mode. While the query is
+// being typed every printable key edits it and the view jumps to the first
+// match as you type. Enter finishes the query; then n and N move between
+// matches (as in vim), / edits the query again and the navigation keys
+// scroll the conversation.
+func (m Model) handleSearchKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	keyStr := msg.String()
+	switch keyStr {
+	case "esc", "ctrl+f":
+		return m.exitSearchMode()
+
+	case "tab":
+		// Widen the search to all saved sessions
+		return m.openSessionSearch(m.searchQuery)
+
+	case "ctrl+n":
+		return m.nextSearchMatch()
+
+	case "ctrl+p":
+		return m.prevSearchMatch()
+	}
+
+	if m.searchEditing {
+		switch keyStr {
+		case "enter":
+			m.searchEditing = false
+			m.searchInput.Blur()
+			return m, nil
+
+		case "down":
+			return m.nextSearchMatch()
+
+		case "up":
+			return m.prevSearchMatch()
+		}
+
+		var cmd tea.Cmd
+		m.searchInput, cmd = m.searchInput.Update(msg)
+
+		// Update search query and find matches
+		if newQuery := m.searchInput.Value(); newQuery != m.searchQuery {
+			m.searchQuery = newQuery
+			m.findSearchMatches()
+			m.selectMatchFromAnchor()
+			m.updateViewport() // Re-render with highlights
+			m.scrollToCurrentMatch()
+		}
+		return m, cmd
+	}
+
+	switch keyStr {
+	case "n", "enter":
+		return m.nextSearchMatch()
+
+	case "N":
+		return m.prevSearchMatch()
+
+	case "/":
+		m.searchEditing = true
+		return m, m.searchInput.Focus()
+	}
+
+	return m.handleNavigationKeys(msg)
+}
+
+// enterSearchMode activates find mode with an empty query.
+func (m Model) enterSearchMode() (tea.Model, tea.Cmd) {
+	m.searchMode = true
+	m.searchEditing = true
+	m.searchQuery = ""
+	m.searchInput.Reset()
+	m.searchInput.Focus()
+	m.searchMatches = nil
+	m.searchMatchIndex = 0
+	m.searchAnchor = m.viewport.YOffset
+	m.input.Blur()
+	m.resizeForSearchBar()
+	return m, textinput.Blink
+}
+
+// exitSearchMode deactivates find mode and removes the highlights.
+func (m Model) exitSearchMode() (tea.Model, tea.Cmd) {
+	m.clearSearch()
+	m.resizeForSearchBar()
+	if !m.inputMode {
+		return m, nil
+	}
+	m.input.Focus()
+	return m, textinput.Blink
+}
+
+// clearSearch resets the find state without touching focus or layout.
+func (m *Model) clearSearch() {
+	m.searchMode = false
+	m.searchEditing = false
+	m.searchQuery = ""
+	m.searchMatches = nil
+	m.searchMatchLines = nil
+	m.searchMatchIndex = 0
+	m.searchInput.Blur()
+	m.updateViewport() // Re-render without highlights
+}
+
+// resizeForSearchBar recomputes the viewport height after the search bar is
+// shown or hidden, keeping the scroll position.
+func (m *Model) resizeForSearchBar() {
+	if m.width == 0 || m.height == 0 {
+		return
+	}
+	offset := m.viewport.YOffset
+	updated, _ := m.handleResize(tea.WindowSizeMsg{Width: m.width, Height: m.height})
+	*m = updated.(Model)
+	m.viewport.SetYOffset(offset)
+}
+
+// =============================================================================
+// MATCHES
+// =============================================================================
+
+// findSearchMatches finds all matches of the search query in the conversation.
+// Stores RUNE positions (not byte positions) for proper Unicode handling.
+func (m *Model) findSearchMatches() {
+	m.searchMatches = nil
+	m.searchMatchIndex = 0
+
+	if m.searchQuery == "" || m.conversation == nil {
+		return
+	}
+
+	queryRunes := []rune(strings.ToLower(m.searchQuery))
+	queryLen := len(queryRunes)
+	if queryLen == 0 {
+		return
+	}
+
+	messages := m.conversation.GetHistory()
+
+	for msgIdx, msg := range messages {
+		content := msg.GetDisplayContent()
+		if content == "" {
+			continue
+		}
+
+		// Convert to lowercase once for efficiency
+		contentLower := strings.ToLower(content)
+		textRunes := []rune(contentLower)
+
+		// Find all case-insensitive matches by rune comparison
+		for i := 0; i <= len(textRunes)-queryLen; i++ {
+			matched := true
+			for j := 0; j < queryLen; j++ {
+				if textRunes[i+j] != queryRunes[j] {
+					matched = false
+					break
+				}
+			}
+			if matched {
+				m.searchMatches = append(m.searchMatches, SearchMatch{
+					MessageIndex: msgIdx,
+					StartPos:     i,            // RUNE position
+					EndPos:       i + queryLen, // RUNE position
+				})
+				i += queryLen - 1 // Skip past this match
+			}
+		}
+	}
+}
+
+// selectMatchFromAnchor makes the first match at or below the line where
+// find mode started the current one, like vim's incremental search. The
+// message offsets from the previous render are still valid because
+// highlighting does not change the layout.
+func (m *Model) selectMatchFromAnchor() {
+	m.searchMatchIndex = 0
+	for i, match := range m.searchMatches {
+		if m.messageEndLine(match.MessageIndex) > m.searchAnchor {
+			m.searchMatchIndex = i
+			return
+		}
+	}
+}
+
+// messageEndLine returns the first viewport line after a rendered message.
+func (m *Model) messageEndLine(msgIndex int) int {
+	if msgIndex+1 < len(m.messageOffsets) {
+		return m.messageOffsets[msgIndex+1]
+	}
+	return math.MaxInt
+}
+
+// nextSearchMatch navigates to the next search match.
+func (m Model) nextSearchMatch() (tea.Model, tea.Cmd) {
+	if len(m.searchMatches) == 0 {
+		return m, nil
+	}
+
+	m.searchMatchIndex = (m.searchMatchIndex + 1) % len(m.searchMatches)
+	m.updateViewport()
+	m.scrollToCurrentMatch()
+	return m, nil
+}
+
+// prevSearchMatch navigates to the previous search match.
+func (m Model) prevSearchMatch() (tea.Model, tea.Cmd) {
+	if len(m.searchMatches) == 0 {
+		return m, nil
+	}
+
+	m.searchMatchIndex--
+	if m.searchMatchIndex < 0 {
+		m.searchMatchIndex = len(m.searchMatches) - 1
+	}
+	m.updateViewport()
+	m.scrollToCurrentMatch()
+	return m, nil
+}
+
+// scrollToCurrentMatch scrolls the viewport so that the current match is
+// visible, a third of the way down the view. It does nothing when the
+// match is already on screen.
+func (m *Model) scrollToCurrentMatch() {
+	if m.searchMatchIndex >= len(m.searchMatchLines) {
+		return
+	}
+	line := m.searchMatchLines[m.searchMatchIndex]
+	if line >= m.viewport.YOffset && line < m.viewport.YOffset+m.viewport.Height {
+		return
+	}
+	m.viewport.SetYOffset(max(line-m.viewport.Height/3, 0))
+}
+
+// locateSearchMatches records the viewport line of every match, given the
+// rendered messages. The k-th match in a message is placed on the line of
+// the k-th occurrence of the query in its rendered text. Rendering can add
+// occurrences (e.g. in the statistics line) or split one across a wrapped
+// line; a match without a line of its own falls back to the last
+// occurrence found, or to the top of its message.
+func (m *Model) locateSearchMatches(parts []string) {
+	m.searchMatchLines = nil
+	if !m.searchMode || len(m.searchMatches) == 0 {
+		return
+	}
+
+	query := strings.ToLower(m.searchQuery)
+	lines := make([]int, len(m.searchMatches))
+	occurrences := make(map[int][]int)
+	seen := make(map[int]int)
+
+	for i, match := range m.searchMatches {
+		idx := match.MessageIndex
+		if idx >= len(parts) || idx >= len(m.messageOffsets) {
+			continue
+		}
+		found, ok := occurrences[idx]
+		if !ok {
+			found = occurrenceLines(parts[idx], query, m.messageOffsets[idx])
+			occurrences[idx] = found
+		}
+
+		k := seen[idx]
+		seen[idx]++
+		switch {
+		case k < len(found):
+			lines[i] = found[k]
+		case len(found) > 0:
+			lines[i] = found[len(found)-1]
+		default:
+			lines[i] = m.messageOffsets[idx]
+		}
+	}
+	m.searchMatchLines = lines
+}
+
+// ansiSequence matches terminal escape sequences (CSI and OSC).
+var ansiSequence = regexp.MustCompile(`\x1b\[[0-9;:?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(\x07|\x1b\\)`)
+
+// occurrenceLines returns, for each occurrence of query in the rendered
+// text, the viewport line it appears on. base is the first line of the text.
+func occurrenceLines(rendered, query string, base int) []int {
+	if query == "" {
+		return nil
+	}
+	var lines []int
+	for i, line := range strings.Split(rendered, "\n") {
+		plain := strings.ToLower(ansiSequence.ReplaceAllString(line, ""))
+		for n := strings.Count(plain, query); n > 0; n-- {
+			lines = append(lines, base+i)
+		}
+	}
+	return lines
+}
+
+// FocusMessage opens find mode for query with the first match in the given
+// message as the current one, and scrolls to it. This is how a session
+// search result is shown after its conversation is loaded. When the query
+// does not occur in the message (session search matches words, not the
+// whole phrase), the view scrolls to the message instead.
+func (m *Model) FocusMessage(messageID, query string) {
+	target := -1
+	for i, msg := range m.conversation.GetHistory() {
+		if msg.ID == messageID {
+			target = i
+			break
+		}
+	}
+	if target < 0 {
+		return
+	}
+
+	m.searchMode = true
+	m.searchEditing = false
+	m.searchQuery = query
+	m.searchInput.SetValue(query)
+	m.searchInput.Blur()
+	m.findSearchMatches()
+
+	current := -1
+	for i, match := range m.searchMatches {
+		if match.MessageIndex == target {
+			current = i
+			break
+		}
+	}
+	if current < 0 {
+		m.clearSearch()
+		if target < len(m.messageOffsets) {
+			m.viewport.SetYOffset(m.messageOffsets[target])
+		}
+		return
+	}
+
+	m.searchMatchIndex = current
+	m.resizeForSearchBar()
+	m.updateViewport()
+	m.scrollToCurrentMatch()
+}
+
+// =============================================================================
+// SESSION SEARCH
+// =============================================================================
+
+// SetSessionSearcher sets the function the session search overlay uses to
+// search saved conversations.
+func (m *Model) SetSessionSearcher(search func(query string, limit int) ([]storage.MessageHit, error)) {
+	m.sessionSearcher = search
+}
+
+// openSessionSearch leaves find mode and opens the session search overlay
+// with an initial query.
+func (m Model) openSessionSearch(query string) (tea.Model, tea.Cmd) {
+	if m.searchMode {
+		m.clearSearch()
+		m.resizeForSearchBar()
+	}
+	if m.sessionSearch == nil {
+		return m, nil
+	}
+
+	// AC-4: Snippets from conversations marked above the session level
+	// must not be displayed. The level is fixed for the life of the overlay.
+	search := m.sessionSearcher
+	clearance := m.sessionClassification
+	if search == nil {
+		m.sessionSearch.SetSearcher(nil)
+	} else {
+		m.sessionSearch.SetSearcher(func(q string) ([]storage.MessageHit, error) {
+			return searchPermittedHits(search, q, clearance, sessionSearchLimit)
+		})
+	}
+
+	m.sessionSearch.SetSize(m.width, m.height)
+	return m, m.sessionSearch.Show(query)
+}
+
+// searchPermittedHits returns up to limit hits the session clearance permits.
+// Hits above the clearance are dropped after the store has applied its limit,
+// so the search is repeated with a doubled limit until enough permitted hits
+// are found or the store has no more matches.
+func searchPermittedHits(search func(query string, limit int) ([]storage.MessageHit, error), query string, clearance security.Classification, limit int) ([]storage.MessageHit, error) {
+	for fetch := limit; ; fetch *= 2 {
+		hits, err := search(query, fetch)
+		if err != nil {
+			return nil, err
+		}
+		visible := make([]storage.MessageHit, 0, limit)
+		for _, hit := range hits {
+			if security.CheckSessionClearance(hit.Marking(), clearance) == nil {
+				visible = append(visible, hit)
+				if len(visible) == limit {
+					return visible, nil
+				}
+			}
+		}
+		if len(hits) < fetch {
+			return visible, nil
+		}
+	}
+}
+
+// handleSessionSearchSelect opens the conversation of the selected result.
+// The main model loads it and calls FocusMessage.
+func (m Model) handleSessionSearchSelect(msg components.SessionSearchSelectMsg) (tea.Model, tea.Cmd) {
+	return m, func() tea.Msg {
+		return LoadConversationMsg{
+			ID:        msg.Hit.ConversationID,
+			MessageID: msg.Hit.MessageID,
+			Query:     msg.Query,
+		}
+	}
+}
@@ -0,0 +1,303 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package chat provides the chat view component for the TUI.
+package chat
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/jeranaias/rigrun-tui/internal/model"
+	"github.com/jeranaias/rigrun-tui/internal/security"
+	"github.com/jeranaias/rigrun-tui/internal/storage"
+	"github.com/jeranaias/rigrun-tui/internal/ui/components"
+	"github.com/jeranaias/rigrun-tui/internal/ui/styles"
+)
+
+// =============================================================================
+// FIND MODE TESTS
+// =============================================================================
+
+// newSearchTestModel returns a sized model whose conversation is long enough
+// to scroll, with "needle" in the first and last messages.
+func newSearchTestModel(t *testing.T) Model {
+	t.Helper()
+	m := New(styles.NewTheme())
+	t.Cleanup(m.taskRunner.Stop)
+
+	conv := model.NewConversation()
+	conv.AddUserMessage("first needle here")
+	for i := 0; i < 30; i++ {
+		conv.AddSystemMessage(fmt.Sprintf("filler message %d", i))
+	}
+	conv.AddSystemMessage("last needle here")
+	m.SetConversation(conv)
+
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+	return updated.(Model)
+}
+
+func sendKeys(m Model, keys ...string) Model {
+	for _, k := range keys {
+		var msg tea.KeyMsg
+		switch k {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		case "ctrl+f":
+			msg = tea.KeyMsg{Type: tea.KeyCtrlF}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		}
+		updated, _ := m.Update(msg)
+		m = updated.(Model)
+	}
+	return m
+}
+
+// currentMatchVisible reports whether the line holding the current match is
+// inside the viewport.
+func currentMatchVisible(m Model) bool {
+	line := m.searchMatchLines[m.searchMatchIndex]
+	return line >= m.viewport.YOffset && line < m.viewport.YOffset+m.viewport.Height
+}
+
+func TestFindMode_TypingAndNavigation(t *testing.T) {
+	m := newSearchTestModel(t)
+	m.viewport.GotoBottom()
+
+	m = sendKeys(m, "ctrl+f")
+	if !m.IsSearchMode() || !m.searchEditing {
+		t.Fatal("ctrl+f should open find mode for typing")
+	}
+
+	// n and N are part of the query while typing
+	m = sendKeys(m, "n", "e", "e", "d", "l", "e")
+	if m.GetSearchQuery() != "needle" {
+		t.Fatalf("query = %q, want needle", m.GetSearchQuery())
+	}
+	if got := len(m.GetSearchMatches()); got != 2 {
+		t.Fatalf("got %d matches, want 2", got)
+	}
+	if len(m.searchMatchLines) != 2 || m.searchMatchLines[0] >= m.searchMatchLines[1] {
+		t.Fatalf("match lines = %v", m.searchMatchLines)
+	}
+
+	// Incremental search starts from where the view was: the last message
+	if m.GetCurrentMatchIndex() != 1 || !currentMatchVisible(m) {
+		t.Errorf("expected the match below the view start to be current and visible, index=%d", m.GetCurrentMatchIndex())
+	}
+
+	// After Enter, n and N navigate and scroll to the match
+	m = sendKeys(m, "enter")
+	if m.searchEditing {
+		t.Fatal("enter should finish the query")
+	}
+	m = sendKeys(m, "n")
+	if m.GetCurrentMatchIndex() != 0 || !currentMatchVisible(m) {
+		t.Errorf("n: index=%d offset=%d lines=%v", m.GetCurrentMatchIndex(), m.viewport.YOffset, m.searchMatchLines)
+	}
+	if m.viewport.YOffset != 0 {
+		t.Errorf("first match is at the top, YOffset = %d", m.viewport.YOffset)
+	}
+	m = sendKeys(m, "N")
+	if m.GetCurrentMatchIndex() != 1 || !currentMatchVisible(m) {
+		t.Errorf("N: index=%d offset=%d", m.GetCurrentMatchIndex(), m.viewport.YOffset)
+	}
+
+	// / edits the query again
+	m = sendKeys(m, "/")
+	if !m.searchEditing {
+		t.Error("/ should resume editing the query")
+	}
+
+	m = sendKeys(m, "esc")
+	if m.IsSearchMode() || m.searchMatches != nil {
+		t.Error("esc should close find mode")
+	}
+}
+
+func TestFindMode_SlashKey(t *testing.T) {
+	m := newSearchTestModel(t)
+
+	// In the input, / starts a command rather than a search
+	m = sendKeys(m, "/")
+	if m.IsSearchMode() || m.input.Value() != "/" {
+		t.Errorf("/ in input: search=%v input=%q", m.IsSearchMode(), m.input.Value())
+	}
+
+	// Outside the input it searches
+	m.input.Reset()
+	m.inputMode = false
+	m = sendKeys(m, "/")
+	if !m.IsSearchMode() {
+		t.Error("/ outside the input should open find mode")
+	}
+}
+
+func TestFindMode_VimSlash(t *testing.T) {
+	m := newSearchTestModel(t)
+	m.vimHandler = NewVimHandler(true)
+
+	m = sendKeys(m, "/")
+	if !m.IsSearchMode() {
+		t.Fatal("/ in vim normal mode should open find mode")
+	}
+	m = sendKeys(m, "n", "e", "e", "d", "l", "e", "enter", "n")
+	if m.GetSearchQuery() != "needle" || m.searchEditing {
+		t.Errorf("query=%q editing=%v", m.GetSearchQuery(), m.searchEditing)
+	}
+}
+
+func TestOccurrenceLines(t *testing.T) {
+	rendered := "\x1b[1mNeedle\x1b[0m one\nnothing\nneedle needle"
+	got := occurrenceLines(rendered, "needle", 10)
+	want := []int{10, 12, 12}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("occurrenceLines = %v, want %v", got, want)
+	}
+}
+
+func TestFocusMessage(t *testing.T) {
+	m := newSearchTestModel(t)
+	history := m.conversation.GetHistory()
+	last := history[len(history)-1]
+
+	m.FocusMessage(last.ID, "needle")
+	if !m.IsSearchMode() || m.searchEditing {
+		t.Fatal("FocusMessage should open find mode with a finished query")
+	}
+	if m.GetCurrentMatchIndex() != 1 || !currentMatchVisible(m) {
+		t.Errorf("current=%d offset=%d lines=%v", m.GetCurrentMatchIndex(), m.viewport.YOffset, m.searchMatchLines)
+	}
+
+	// A phrase that is not in the message scrolls to the message instead
+	m.FocusMessage(history[0].ID, "here first")
+	if m.IsSearchMode() {
+		t.Error("find mode should stay closed when the phrase does not occur")
+	}
+	if m.viewport.YOffset != 0 {
+		t.Errorf("YOffset = %d, want the first message", m.viewport.YOffset)
+	}
+}
+
+// =============================================================================
+// SESSION SEARCH TESTS
+// =============================================================================
+
+func TestSessionSearch_FiltersByClearance(t *testing.T) {
+	m := newSearchTestModel(t)
+	m.SetSessionSearcher(func(query string, limit int) ([]storage.MessageHit, error) {
+		return []storage.MessageHit{
+			{ConversationID: "conv_open", MessageID: "a", Snippet: "open"},
+			{ConversationID: "conv_secret", MessageID: "b", Classification: "SECRET", Snippet: "secret"},
+		}, nil
+	})
+
+	updated, cmd := m.openSessionSearch("needle")
+	m = updated.(Model)
+	if !m.sessionSearch.IsVisible() {
+		t.Fatal("overlay should be visible")
+	}
+
+	// Run the search the overlay started and deliver its result
+	var results components.SessionSearchResultsMsg
+	found := false
+	for _, msg := range runBatch(cmd) {
+		if r, ok := msg.(components.SessionSearchResultsMsg); ok {
+			results, found = r, true
+		}
+	}
+	if !found {
+		t.Fatal("no search results message")
+	}
+	if len(results.Hits) != 1 || results.Hits[0].ConversationID != "conv_open" {
+		t.Fatalf("hits = %+v, want only the unclassified one", results.Hits)
+	}
+
+	updated, _ = m.Update(results)
+	m = updated.(Model)
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if m.sessionSearch.IsVisible() {
+		t.Error("overlay should close on select")
+	}
+
+	// Selecting a result asks the app to load and focus it
+	msg := cmd()
+	sel, ok := msg.(components.SessionSearchSelectMsg)
+	if !ok {
+		t.Fatalf("got %T, want SessionSearchSelectMsg", msg)
+	}
+	_, cmd = m.Update(sel)
+	load, ok := cmd().(LoadConversationMsg)
+	if !ok || load.ID != "conv_open" || load.MessageID != "a" || load.Query != "needle" {
+		t.Errorf("load message = %+v", load)
+	}
+}
+
+func TestSearchPermittedHits_PagesPastHiddenHits(t *testing.T) {
+	// 60 SECRET hits rank ahead of 60 unclassified ones
+	var all []storage.MessageHit
+	for i := 0; i < 120; i++ {
+		hit := storage.MessageHit{ConversationID: fmt.Sprintf("conv_%d", i)}
+		if i < 60 {
+			hit.Classification = "SECRET"
+		}
+		all = append(all, hit)
+	}
+	search := func(query string, limit int) ([]storage.MessageHit, error) {
+		if limit > len(all) {
+			limit = len(all)
+		}
+		return all[:limit], nil
+	}
+
+	hits, err := searchPermittedHits(search, "needle", security.Classification{}, sessionSearchLimit)
+	if err != nil {
+		t.Fatalf("searchPermittedHits: %v", err)
+	}
+	if len(hits) != sessionSearchLimit || hits[0].ConversationID != "conv_60" {
+		t.Fatalf("got %d hits starting at %+v, want %d permitted hits", len(hits), hits[0], sessionSearchLimit)
+	}
+
+	// Fewer permitted matches than the limit stops once the store runs out
+	all = all[:70]
+	if hits, _ = searchPermittedHits(search, "needle", security.Classification{}, sessionSearchLimit); len(hits) != 10 {
+		t.Errorf("got %d hits, want 10", len(hits))
+	}
+}
+
+// runBatch runs a command and the commands of any batch it returns.
+func runBatch(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	msg := cmd()
+	if batch, ok := msg.(tea.BatchMsg); ok {
+		var msgs []tea.Msg
+		for _, c := range batch {
+			msgs = append(msgs, runBatch(c)...)
+		}
+		return msgs
+	}
+	return []tea.Msg{msg}
+}
+
+func TestSearchSessionsCommand(t *testing.T) {
+	m := newSearchTestModel(t)
+	m.input.SetValue("/search pool size")
+	updated, _ := m.submitInput()
+	m = updated.(Model)
+	if !m.sessionSearch.IsVisible() {
+		t.Fatal("/search should open the session search overlay")
+	}
+	if !strings.Contains(m.View(), "Search Sessions") {
+		t.Error("overlay not rendered")
+	}
+}
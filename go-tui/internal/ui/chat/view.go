@@ -156,6 +156,16 @@ func (m Model) renderChat() string {
 		)
 	}
 
+	// Render session search overlay on top if visible
+	if m.sessionSearch != nil && m.sessionSearch.IsVisible() {
+		m.sessionSearch.SetSize(m.width, m.height)
+		return lipgloss.Place(
+			m.width, m.height,
+			lipgloss.Left, lipgloss.Top,
+			baseView+"\n"+m.sessionSearch.View(),
+		)
+	}
+
 	// Render tutorial overlay if visible (highest priority overlay)
 	if m.IsTutorialVisible() && m.tutorial != nil {
 		m.tutorial.SetSize(m.width, m.height)
@@ -287,10 +297,14 @@ func (m Model) renderSearchBar() string {
 		}
 	}
 
-	// Help text
+	// Help text: n/N only navigate once the query is finished with Enter
+	searchHelp := " | Enter=done | Up/Down=prev/next | Tab=all sessions | Esc=close"
+	if !m.searchEditing {
+		searchHelp = " | n/N=next/prev | /=edit | Tab=all sessions | Esc=close"
+	}
 	helpText := lipgloss.NewStyle().
 		Foreground(styles.TextMuted).
-		Render(" | Enter/Down=next | Up=prev | Esc=close")
+		Render(searchHelp)
 
 	// Combine search bar content
 	searchContent := searchInputView + matchInfo + helpText
@@ -386,10 +400,17 @@ func (m *Model) renderMessages() string {
 	var parts []string
 	messages := m.conversation.GetHistory()
 
+	// Record where each message starts so search can scroll to its matches
+	offsets := make([]int, 0, len(messages))
+	line := 0
 	for i, msg := range messages {
 		rendered := m.renderMessage(msg, i == len(messages)-1, i)
 		parts = append(parts, rendered)
+		offsets = append(offsets, line)
+		line += strings.Count(rendered, "\n") + 1
 	}
+	m.messageOffsets = offsets
+	m.locateSearchMatches(parts)
 
 	// Add thinking indicator if streaming
 	if m.state == StateStreaming && m.isThinking {
@@ -547,6 +568,16 @@ func (m *Model) renderUserMessage(msg *model.Message, msgIndex int) string {
 	}
 	rendered := bubble.Render(wrapText(content, wrapWidth))
 
+	// Edited and regenerated messages show their place among the branches
+	if m.conversation.HasBranches() {
+		if pos, count := m.conversation.BranchInfo(msg.ID); count > 1 {
+			indicator := lipgloss.NewStyle().
+				Foreground(styles.TextMuted).
+				Render(fmt.Sprintf("< branch %d/%d >", pos, count))
+			rendered = lipgloss.JoinVertical(lipgloss.Right, rendered, indicator)
+		}
+	}
+
 	// Add margin to push right (user messages align right)
 	marginLeft := m.width - lipgloss.Width(rendered) - 4
 	if marginLeft < 0 {
@@ -1485,6 +1516,11 @@ func (m Model) renderStatusBar() string {
 		}
 		leftParts = append(leftParts, modeStr)
 
+		// Spend budget warning (compact with the icon-only mode)
+		if budgetStr := m.renderBudgetIndicator(!showFullMode); budgetStr != "" {
+			leftParts = append(leftParts, budgetStr)
+		}
+
 		// IL5 SC-7: Show "Cloud: disabled" in offline mode
 		if m.offlineMode {
 			cloudDisabled := lipgloss.NewStyle().
@@ -54,7 +54,6 @@ type VimHandler struct {
 	mode          VimMode
 	enabled       bool
 	commandBuffer string // For : commands
-	searchBuffer  string // For / search
 	visualStart   int    // Start position for visual selection
 	visualEnd     int    // End position for visual selection
 	count         int    // Numeric prefix (e.g., 5j for 5 lines down)
@@ -249,11 +248,9 @@ func (vh *VimHandler) handleNormalMode(keyStr string, vp *viewport.Model, input
 		consumed = true
 		cmd = textinput.Blink
 
-	// Search
+	// Search: / is not consumed so that the chat model opens find mode
 	case "/":
-		vh.enterSearchMode(input)
-		consumed = true
-		cmd = textinput.Blink
+		consumed = false
 
 	default:
 		// Not a vim normal mode key
@@ -374,12 +371,6 @@ func (vh *VimHandler) enterCommandMode(input *textinput.Model) {
 	input.Focus()
 }
 
-func (vh *VimHandler) enterSearchMode(input *textinput.Model) {
-	vh.mode = VimModeCommand
-	vh.commandBuffer = "/"
-	input.Focus()
-}
-
 // =============================================================================
 // COMMAND EXECUTION
 // =============================================================================
@@ -0,0 +1,448 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package components provides UI components for the rigrun TUI.
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/jeranaias/rigrun-tui/internal/diff"
+	"github.com/jeranaias/rigrun-tui/internal/ui/styles"
+)
+
+// =============================================================================
+// HUNK REVIEWER
+// =============================================================================
+
+// HunkReviewer reviews one or more pending file changes hunk by hunk. Each
+// hunk can be accepted, rejected or rewritten in an inline editor; hunks left
+// pending when the review is submitted are not applied.
+type HunkReviewer struct {
+	titles  []string // One per change, e.g. "Edit main.go"
+	reviews []*diff.Review
+	lines   *DiffViewer // Renders diff lines
+
+	file int // Selected change
+	hunk int // Selected hunk within the change
+
+	editor  textarea.Model
+	editing bool
+	done    bool
+
+	width  int
+	height int
+}
+
+// NewHunkReviewer creates a reviewer for the given diffs. titles label the
+// changes and must be the same length as diffs.
+func NewHunkReviewer(titles []string, diffs []*diff.Diff) *HunkReviewer {
+	reviews := make([]*diff.Review, len(diffs))
+	for i, d := range diffs {
+		reviews[i] = diff.NewReview(d)
+	}
+
+	editor := textarea.New()
+	editor.ShowLineNumbers = false
+	editor.Prompt = "  "
+	editor.CharLimit = 0
+
+	return &HunkReviewer{
+		titles:  titles,
+		reviews: reviews,
+		lines:   NewDiffViewer(nil),
+		editor:  editor,
+		width:   80,
+		height:  24,
+	}
+}
+
+// SetSize sets the reviewer dimensions.
+func (r *HunkReviewer) SetSize(width, height int) {
+	r.width = width
+	r.height = height
+	r.editor.SetWidth(minDiffInt(width-8, 96))
+}
+
+// Reviews returns the reviews, one per change, in order.
+func (r *HunkReviewer) Reviews() []*diff.Review {
+	return r.reviews
+}
+
+// Done reports whether the user has submitted or cancelled the review.
+func (r *HunkReviewer) Done() bool {
+	return r.done
+}
+
+// Editing reports whether the inline editor is open.
+func (r *HunkReviewer) Editing() bool {
+	return r.editing
+}
+
+// Selected returns the selected change and hunk.
+func (r *HunkReviewer) Selected() (file, hunk int) {
+	return r.file, r.hunk
+}
+
+// =============================================================================
+// NAVIGATION
+// =============================================================================
+
+// hunkCount returns the number of hunks in the selected change.
+func (r *HunkReviewer) hunkCount() int {
+	if len(r.reviews) == 0 {
+		return 0
+	}
+	return len(r.reviews[r.file].Decisions)
+}
+
+// NextHunk selects the next hunk, moving on to the next change at the end.
+func (r *HunkReviewer) NextHunk() {
+	if r.hunk+1 < r.hunkCount() {
+		r.hunk++
+	} else if r.file+1 < len(r.reviews) {
+		r.file++
+		r.hunk = 0
+	}
+}
+
+// PrevHunk selects the previous hunk, moving back to the previous change at
+// the start.
+func (r *HunkReviewer) PrevHunk() {
+	if r.hunk > 0 {
+		r.hunk--
+	} else if r.file > 0 {
+		r.file--
+		r.hunk = max(r.hunkCount()-1, 0)
+	}
+}
+
+// NextFile selects the first hunk of the next change, wrapping around.
+func (r *HunkReviewer) NextFile() {
+	if len(r.reviews) > 0 {
+		r.file = (r.file + 1) % len(r.reviews)
+		r.hunk = 0
+	}
+}
+
+// PrevFile selects the first hunk of the previous change, wrapping around.
+func (r *HunkReviewer) PrevFile() {
+	if len(r.reviews) > 0 {
+		r.file = (r.file - 1 + len(r.reviews)) % len(r.reviews)
+		r.hunk = 0
+	}
+}
+
+// advance selects the next pending hunk after a decision, if there is one.
+func (r *HunkReviewer) advance() {
+	for f := r.file; f < len(r.reviews); f++ {
+		start := 0
+		if f == r.file {
+			start = r.hunk + 1
+		}
+		for h := start; h < len(r.reviews[f].Decisions); h++ {
+			if r.reviews[f].Decisions[h] == diff.HunkPending {
+				r.file, r.hunk = f, h
+				return
+			}
+		}
+	}
+}
+
+// =============================================================================
+// DECISIONS
+// =============================================================================
+
+// Accept accepts the selected hunk and moves to the next pending one.
+func (r *HunkReviewer) Accept() {
+	if r.hunkCount() > 0 {
+		r.reviews[r.file].Accept(r.hunk)
+		r.advance()
+	}
+}
+
+// Reject rejects the selected hunk and moves to the next pending one.
+func (r *HunkReviewer) Reject() {
+	if r.hunkCount() > 0 {
+		r.reviews[r.file].Reject(r.hunk)
+		r.advance()
+	}
+}
+
+// AcceptFile accepts every hunk of the selected change.
+func (r *HunkReviewer) AcceptFile() {
+	if len(r.reviews) > 0 {
+		r.reviews[r.file].SetAll(diff.HunkAccepted)
+	}
+}
+
+// RejectFile rejects every hunk of the selected change.
+func (r *HunkReviewer) RejectFile() {
+	if len(r.reviews) > 0 {
+		r.reviews[r.file].SetAll(diff.HunkRejected)
+	}
+}
+
+// StartEdit opens the inline editor on the selected hunk's proposed lines,
+// or on the previous edit of the hunk.
+func (r *HunkReviewer) StartEdit() tea.Cmd {
+	if r.hunkCount() == 0 {
+		return nil
+	}
+	review := r.reviews[r.file]
+	lines := review.Edits[r.hunk]
+	if review.Decisions[r.hunk] != diff.HunkEdited {
+		lines = review.Diff.Hunks[r.hunk].NewSide()
+	}
+	r.editor.SetValue(strings.Join(lines, "\n"))
+	r.editor.SetHeight(minDiffInt(max(len(lines)+1, 3), max(r.height-14, 3)))
+	r.editing = true
+	return r.editor.Focus()
+}
+
+// SaveEdit applies the editor's text to the selected hunk.
+func (r *HunkReviewer) SaveEdit() {
+	r.reviews[r.file].Edit(r.hunk, strings.Split(r.editor.Value(), "\n"))
+	r.editing = false
+	r.editor.Blur()
+	r.advance()
+}
+
+// CancelEdit closes the editor without changing the hunk.
+func (r *HunkReviewer) CancelEdit() {
+	r.editing = false
+	r.editor.Blur()
+}
+
+// Submit finishes the review. Pending hunks are not applied.
+func (r *HunkReviewer) Submit() {
+	r.done = true
+}
+
+// Cancel rejects every change and finishes the review.
+func (r *HunkReviewer) Cancel() {
+	for _, review := range r.reviews {
+		review.SetAll(diff.HunkRejected)
+	}
+	r.done = true
+}
+
+// =============================================================================
+// BUBBLE TEA METHODS
+// =============================================================================
+
+// Update handles key events.
+func (r *HunkReviewer) Update(msg tea.Msg) tea.Cmd {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok || r.done {
+		return nil
+	}
+
+	if r.editing {
+		switch keyMsg.String() {
+		case "ctrl+s":
+			r.SaveEdit()
+		case "esc":
+			r.CancelEdit()
+		default:
+			var cmd tea.Cmd
+			r.editor, cmd = r.editor.Update(msg)
+			return cmd
+		}
+		return nil
+	}
+
+	switch keyMsg.String() {
+	case "down", "j":
+		r.NextHunk()
+	case "up", "k":
+		r.PrevHunk()
+	case "tab":
+		r.NextFile()
+	case "shift+tab":
+		r.PrevFile()
+	case "y", "a":
+		r.Accept()
+	case "n", "r":
+		r.Reject()
+	case "Y", "A":
+		r.AcceptFile()
+	case "N", "R":
+		r.RejectFile()
+	case "e":
+		return r.StartEdit()
+	case "enter":
+		r.Submit()
+	case "esc", "ctrl+c":
+		r.Cancel()
+	}
+	return nil
+}
+
+// =============================================================================
+// RENDERING
+// =============================================================================
+
+// View renders the reviewer.
+func (r *HunkReviewer) View() string {
+	if len(r.reviews) == 0 {
+		return "No changes to review"
+	}
+
+	var content strings.Builder
+
+	titleStyle := lipgloss.NewStyle().
+		Foreground(styles.Purple).
+		Bold(true).
+		Underline(true)
+	content.WriteString(titleStyle.Render("Review Changes"))
+	content.WriteString("\n\n")
+
+	content.WriteString(r.renderFiles())
+	content.WriteString("\n\n")
+
+	review := r.reviews[r.file]
+	r.lines.diff = review.Diff
+	content.WriteString(r.lines.renderStats())
+	content.WriteString("\n\n")
+
+	if len(review.Decisions) == 0 {
+		mutedStyle := lipgloss.NewStyle().
+			Foreground(styles.TextMuted).
+			Italic(true)
+		content.WriteString(mutedStyle.Render("No changes"))
+	}
+	for i, hunk := range review.Diff.Hunks {
+		content.WriteString(r.renderHunk(review, i, hunk))
+	}
+
+	content.WriteString("\n")
+	content.WriteString(r.renderHelp())
+
+	containerStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(styles.Purple).
+		Padding(1, 2).
+		Width(minDiffInt(r.width-4, 100))
+
+	return containerStyle.Render(content.String())
+}
+
+// renderFiles renders one line per change with its review progress.
+func (r *HunkReviewer) renderFiles() string {
+	selectedStyle := lipgloss.NewStyle().
+		Foreground(styles.Cyan).
+		Bold(true)
+	mutedStyle := lipgloss.NewStyle().
+		Foreground(styles.TextMuted)
+
+	var lines []string
+	for i, review := range r.reviews {
+		total := len(review.Decisions)
+		status := fmt.Sprintf("%d/%d reviewed", total-review.Pending(), total)
+		if review.Pending() == 0 {
+			status = fmt.Sprintf("%d/%d applied", review.Applied(), total)
+		}
+		if i == r.file {
+			lines = append(lines, selectedStyle.Render("> "+r.titles[i])+"  "+mutedStyle.Render(status))
+		} else {
+			lines = append(lines, mutedStyle.Render("  "+r.titles[i]+"  "+status))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderHunk renders a hunk header with its decision. The selected hunk is
+// expanded (or shown in the editor); the others are collapsed.
+func (r *HunkReviewer) renderHunk(review *diff.Review, i int, hunk diff.DiffHunk) string {
+	selected := i == r.hunk
+
+	headerStyle := lipgloss.NewStyle().
+		Foreground(styles.Cyan).
+		Background(styles.SurfaceDim).
+		Bold(selected).
+		Padding(0, 1)
+
+	marker := "  "
+	if selected {
+		marker = "> "
+	}
+	header := fmt.Sprintf("@@ -%d,%d +%d,%d @@", hunk.OldStart, hunk.OldCount, hunk.NewStart, hunk.NewCount)
+
+	var content strings.Builder
+	content.WriteString(marker + headerStyle.Render(header) + " " + renderDecision(review.Decisions[i]))
+	content.WriteString("\n")
+	if !selected {
+		return content.String()
+	}
+
+	if r.editing {
+		content.WriteString(r.editor.View())
+		content.WriteString("\n")
+		return content.String()
+	}
+
+	if review.Decisions[i] == diff.HunkEdited {
+		editedStyle := lipgloss.NewStyle().
+			Foreground(styles.Amber)
+		for _, line := range review.Edits[i] {
+			content.WriteString(editedStyle.Render("  | " + line))
+			content.WriteString("\n")
+		}
+		return content.String()
+	}
+
+	// Keep the rest of the review on screen for large hunks
+	limit := max(r.height-12-len(r.reviews)-len(review.Decisions), 5)
+	for n, line := range hunk.Lines {
+		if n == limit {
+			mutedStyle := lipgloss.NewStyle().
+				Foreground(styles.TextMuted).
+				Italic(true)
+			content.WriteString(mutedStyle.Render(fmt.Sprintf("  ... %d more lines", len(hunk.Lines)-limit)))
+			content.WriteString("\n")
+			break
+		}
+		content.WriteString(r.lines.renderLine(line))
+		content.WriteString("\n")
+	}
+	return content.String()
+}
+
+// renderDecision renders a hunk's decision as a badge.
+func renderDecision(decision diff.HunkDecision) string {
+	color := styles.TextMuted
+	switch decision {
+	case diff.HunkAccepted:
+		color = styles.Emerald
+	case diff.HunkRejected:
+		color = styles.Rose
+	case diff.HunkEdited:
+		color = styles.Amber
+	}
+	return lipgloss.NewStyle().
+		Foreground(color).
+		Bold(decision != diff.HunkPending).
+		Render("[" + decision.String() + "]")
+}
+
+// renderHelp renders the key help for the current mode.
+func (r *HunkReviewer) renderHelp() string {
+	helpStyle := lipgloss.NewStyle().
+		Foreground(styles.TextMuted).
+		Italic(true)
+
+	if r.editing {
+		return helpStyle.Render("Editing hunk - ctrl+s save  esc cancel")
+	}
+
+	var lines []string
+	lines = append(lines, helpStyle.Render("y accept  n reject  e edit  Y/N whole file  j/k hunk  tab file"))
+	lines = append(lines, helpStyle.Render("Enter apply reviewed hunks (pending ones are skipped)  Esc reject all"))
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package components
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/jeranaias/rigrun-tui/internal/diff"
+)
+
+// twoHunkDiff returns a diff of a 30-line file with lines 5 and 20 changed.
+func twoHunkDiff(path string) *diff.Diff {
+	var oldLines, newLines []string
+	for i := 0; i < 30; i++ {
+		oldLines = append(oldLines, fmt.Sprintf("line%d", i))
+		newLines = append(newLines, fmt.Sprintf("line%d", i))
+	}
+	newLines[5] = "changed5"
+	newLines[20] = "changed20"
+	return diff.ComputeDiff(path, strings.Join(oldLines, "\n")+"\n", strings.Join(newLines, "\n")+"\n")
+}
+
+func keyPress(s string) tea.KeyMsg {
+	switch s {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	case "tab":
+		return tea.KeyMsg{Type: tea.KeyTab}
+	case "ctrl+s":
+		return tea.KeyMsg{Type: tea.KeyCtrlS}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func TestHunkReviewer_Decisions(t *testing.T) {
+	r := NewHunkReviewer([]string{"Edit a.txt", "Write b.txt"}, []*diff.Diff{twoHunkDiff("a.txt"), twoHunkDiff("b.txt")})
+
+	// Accepting moves on to the next pending hunk, across files
+	r.Update(keyPress("y"))
+	r.Update(keyPress("n"))
+	if file, hunk := r.Selected(); file != 1 || hunk != 0 {
+		t.Errorf("expected selection to move to the second file, got %d/%d", file, hunk)
+	}
+	r.Update(keyPress("Y"))
+	r.Update(keyPress("enter"))
+
+	if !r.Done() {
+		t.Fatal("Enter should submit the review")
+	}
+	reviews := r.Reviews()
+	if reviews[0].Decisions[0] != diff.HunkAccepted || reviews[0].Decisions[1] != diff.HunkRejected {
+		t.Errorf("unexpected decisions for the first file: %v", reviews[0].Decisions)
+	}
+	if !reviews[1].AllAccepted() {
+		t.Errorf("Y should accept the whole file: %v", reviews[1].Decisions)
+	}
+}
+
+func TestHunkReviewer_Edit(t *testing.T) {
+	r := NewHunkReviewer([]string{"Edit a.txt"}, []*diff.Diff{twoHunkDiff("a.txt")})
+
+	r.Update(keyPress("e"))
+	if !r.Editing() {
+		t.Fatal("e should open the editor")
+	}
+	r.editor.SetValue("replacement")
+	r.Update(keyPress("ctrl+s"))
+
+	review := r.Reviews()[0]
+	if r.Editing() || review.Decisions[0] != diff.HunkEdited {
+		t.Fatalf("ctrl+s should save the edit, got %v", review.Decisions)
+	}
+	if got := review.Edits[0]; len(got) != 1 || got[0] != "replacement" {
+		t.Errorf("unexpected edit %q", got)
+	}
+
+	// Esc in the editor only closes it
+	r.Update(keyPress("e"))
+	r.Update(keyPress("esc"))
+	if r.Editing() || r.Done() {
+		t.Error("Esc should close the editor without finishing the review")
+	}
+}
+
+func TestHunkReviewer_Cancel(t *testing.T) {
+	r := NewHunkReviewer([]string{"Edit a.txt"}, []*diff.Diff{twoHunkDiff("a.txt")})
+	r.Update(keyPress("y"))
+	r.Update(keyPress("esc"))
+
+	if !r.Done() || r.Reviews()[0].Applied() != 0 {
+		t.Error("Esc should reject every hunk and finish the review")
+	}
+}
+
+func TestHunkReviewer_View(t *testing.T) {
+	r := NewHunkReviewer([]string{"Edit a.txt"}, []*diff.Diff{twoHunkDiff("a.txt")})
+	r.SetSize(100, 40)
+	r.Update(keyPress("y"))
+
+	view := r.View()
+	for _, want := range []string{"Review Changes", "Edit a.txt", "[accepted]", "[pending]", "changed20"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("View() missing %q", want)
+		}
+	}
+}
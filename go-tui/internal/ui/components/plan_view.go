@@ -109,8 +109,14 @@ func (pv *PlanView) renderSteps(p *plan.Plan) string {
 	sb.WriteString(stepsStyle.Render("Steps:"))
 	sb.WriteString("\n")
 
+	// Steps are indented by their depth in the dependency graph, so steps
+	// at the same depth can run in parallel. An invalid graph is shown flat.
+	graph, _ := plan.BuildGraph(p.Steps)
+
 	for i := range p.Steps {
-		sb.WriteString(pv.renderStep(i+1, &p.Steps[i], i == p.CurrentStep))
+		step := &p.Steps[i]
+		isCurrent := step.Status == plan.StepRunning || (i == p.CurrentStep && p.Status != plan.StatusRunning)
+		sb.WriteString(pv.renderStep(i+1, step, isCurrent, graph, i))
 		if i < len(p.Steps)-1 {
 			sb.WriteString("\n")
 		}
@@ -119,8 +125,8 @@ func (pv *PlanView) renderSteps(p *plan.Plan) string {
 	return sb.String()
 }
 
-// renderStep renders a single step.
-func (pv *PlanView) renderStep(num int, step *plan.PlanStep, isCurrent bool) string {
+// renderStep renders a single step. graph may be nil.
+func (pv *PlanView) renderStep(num int, step *plan.PlanStep, isCurrent bool, graph *plan.Graph, index int) string {
 	var sb strings.Builder
 
 	// Step number and status icon
@@ -134,7 +140,13 @@ func (pv *PlanView) renderStep(num int, step *plan.PlanStep, isCurrent bool) str
 		Bold(isCurrent).
 		Foreground(lipgloss.Color("#89B4FA")) // Blue
 
-	sb.WriteString(fmt.Sprintf("  %s %s. ",
+	indent := "  "
+	if graph != nil {
+		indent += strings.Repeat("  ", graph.Depth(index))
+	}
+
+	sb.WriteString(fmt.Sprintf("%s%s %s. ",
+		indent,
 		iconStyle.Render(icon),
 		numStyle.Render(fmt.Sprintf("%d", num))))
 
@@ -149,6 +161,18 @@ func (pv *PlanView) renderStep(num int, step *plan.PlanStep, isCurrent bool) str
 
 	sb.WriteString(descStyle.Render(step.Description))
 
+	// Dependency edges
+	if graph != nil && len(graph.Dependencies(index)) > 0 {
+		deps := make([]string, 0, len(graph.Dependencies(index)))
+		for _, dep := range graph.Dependencies(index) {
+			deps = append(deps, fmt.Sprintf("%d", dep+1))
+		}
+		depStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#6C7086")) // Overlay0
+
+		sb.WriteString(" " + depStyle.Render("<- "+strings.Join(deps, ", ")))
+	}
+
 	// Duration (if completed)
 	if step.Status == plan.StepComplete || step.Status == plan.StepFailed {
 		durationStyle := lipgloss.NewStyle().
@@ -162,19 +186,21 @@ func (pv *PlanView) renderStep(num int, step *plan.PlanStep, isCurrent bool) str
 	if step.Editable && len(step.ToolCalls) > 0 {
 		sb.WriteString("\n")
 		for _, tc := range step.ToolCalls {
-			sb.WriteString(fmt.Sprintf("     -> %s: %s\n",
+			sb.WriteString(fmt.Sprintf("%s   -> %s: %s\n",
+				indent,
 				tc.Name,
 				tc.Description))
 		}
 	}
 
-	// Error (if failed)
-	if step.Status == plan.StepFailed && step.Error != nil {
+	// Error (if failed or skipped)
+	if (step.Status == plan.StepFailed || step.Status == plan.StepSkipped) && step.Error != nil {
 		errorStyle := lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#F38BA8")). // Red
 			Italic(true)
 
-		sb.WriteString(fmt.Sprintf("\n     Error: %s",
+		sb.WriteString(fmt.Sprintf("\n%s   Error: %s",
+			indent,
 			errorStyle.Render(step.Error.Error())))
 	}
 
@@ -189,7 +215,8 @@ func (pv *PlanView) renderStep(num int, step *plan.PlanStep, isCurrent bool) str
 		if len(result) > 100 {
 			result = result[:97] + "..."
 		}
-		sb.WriteString(fmt.Sprintf("\n     %s",
+		sb.WriteString(fmt.Sprintf("\n%s   %s",
+			indent,
 			resultStyle.Render(result)))
 	}
 
@@ -0,0 +1,357 @@
+// Copyright (c) 2024-2025 Jesse Morgan / Morgan Forge
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package components provides UI components for the rigrun TUI.
+//
+// This file implements the session search overlay: an incremental
+// full-text search over the messages of all saved conversations.
+package components
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/jeranaias/rigrun-tui/internal/storage"
+	"github.com/jeranaias/rigrun-tui/internal/ui/styles"
+)
+
+// =============================================================================
+// SESSION SEARCH
+// =============================================================================
+
+// SessionSearcher returns the saved messages matching a query.
+type SessionSearcher func(query string) ([]storage.MessageHit, error)
+
+// SessionSearch is an overlay that searches the messages of all saved
+// conversations as the query is typed and opens the selected one.
+type SessionSearch struct {
+	input    textinput.Model
+	searcher SessionSearcher
+
+	// Results of the latest search. seq numbers each search so that a
+	// slow result for an older query never replaces a newer one.
+	hits     []storage.MessageHit
+	err      error
+	seq      int
+	selected int
+
+	// Dimensions
+	width  int
+	height int
+
+	visible  bool
+	maxItems int
+}
+
+// NewSessionSearch creates a hidden session search overlay.
+func NewSessionSearch() *SessionSearch {
+	ti := textinput.New()
+	ti.Placeholder = "Search all sessions..."
+	ti.Prompt = "> "
+	ti.CharLimit = 256
+	ti.PromptStyle = lipgloss.NewStyle().Foreground(styles.Cyan).Bold(true)
+	ti.TextStyle = lipgloss.NewStyle().Foreground(styles.TextPrimary)
+	ti.PlaceholderStyle = lipgloss.NewStyle().Foreground(styles.TextMuted).Italic(true)
+
+	return &SessionSearch{
+		input:    ti,
+		maxItems: 8,
+	}
+}
+
+// SetSearcher sets the function used to run searches. Without one the
+// overlay reports that session storage is unavailable.
+func (ss *SessionSearch) SetSearcher(searcher SessionSearcher) {
+	ss.searcher = searcher
+}
+
+// =============================================================================
+// BUBBLE TEA INTERFACE
+// =============================================================================
+
+// Update handles key presses and search results for the overlay.
+func (ss *SessionSearch) Update(msg tea.Msg) (*SessionSearch, tea.Cmd) {
+	if !ss.visible {
+		return ss, nil
+	}
+
+	switch msg := msg.(type) {
+	case SessionSearchResultsMsg:
+		if msg.Seq == ss.seq {
+			ss.hits = msg.Hits
+			ss.err = msg.Err
+			ss.selected = 0
+		}
+		return ss, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			ss.Hide()
+			return ss, nil
+
+		case "enter":
+			if ss.selected >= 0 && ss.selected < len(ss.hits) {
+				hit := ss.hits[ss.selected]
+				query := ss.input.Value()
+				ss.Hide()
+				return ss, func() tea.Msg {
+					return SessionSearchSelectMsg{Hit: hit, Query: query}
+				}
+			}
+			return ss, nil
+
+		case "up", "ctrl+p", "shift+tab":
+			if len(ss.hits) > 0 {
+				ss.selected = (ss.selected - 1 + len(ss.hits)) % len(ss.hits)
+			}
+			return ss, nil
+
+		case "down", "ctrl+n", "tab":
+			if len(ss.hits) > 0 {
+				ss.selected = (ss.selected + 1) % len(ss.hits)
+			}
+			return ss, nil
+		}
+	}
+
+	previous := ss.input.Value()
+	var cmd tea.Cmd
+	ss.input, cmd = ss.input.Update(msg)
+	if ss.input.Value() != previous {
+		return ss, tea.Batch(cmd, ss.search())
+	}
+	return ss, cmd
+}
+
+// search starts a search for the current input.
+func (ss *SessionSearch) search() tea.Cmd {
+	ss.seq++
+	seq := ss.seq
+	query := strings.TrimSpace(ss.input.Value())
+	if query == "" {
+		ss.hits = nil
+		ss.err = nil
+		return nil
+	}
+
+	searcher := ss.searcher
+	return func() tea.Msg {
+		if searcher == nil {
+			return SessionSearchResultsMsg{Seq: seq, Query: query, Err: errSessionSearchUnavailable}
+		}
+		hits, err := searcher(query)
+		return SessionSearchResultsMsg{Seq: seq, Query: query, Hits: hits, Err: err}
+	}
+}
+
+// View renders the overlay centered on the screen.
+func (ss *SessionSearch) View() string {
+	if !ss.visible {
+		return ""
+	}
+
+	boxWidth := 80
+	if ss.width > 0 && ss.width < boxWidth+10 {
+		boxWidth = ss.width - 10
+	}
+	if boxWidth < 40 {
+		boxWidth = 40
+	}
+	innerWidth := boxWidth - 6
+
+	header := lipgloss.NewStyle().
+		Foreground(styles.Purple).
+		Bold(true).
+		Padding(0, 1).
+		Render("Search Sessions")
+	separator := lipgloss.NewStyle().
+		Foreground(styles.Overlay).
+		Render(strings.Repeat("-", boxWidth-4))
+
+	ss.input.Width = innerWidth
+	inputView := ss.input.View()
+
+	muted := lipgloss.NewStyle().Foreground(styles.TextMuted).Italic(true)
+	var list string
+	switch {
+	case ss.err != nil:
+		list = lipgloss.NewStyle().Foreground(styles.Rose).Render("Search failed: " + ss.err.Error())
+	case strings.TrimSpace(ss.input.Value()) == "":
+		list = muted.Render("Type to search messages in all saved sessions")
+	case len(ss.hits) == 0:
+		list = muted.Render("No matching messages")
+	default:
+		// Scroll the window of visible items to keep the selection in view
+		start := 0
+		if ss.selected >= ss.maxItems {
+			start = ss.selected - ss.maxItems + 1
+		}
+		end := min(start+ss.maxItems, len(ss.hits))
+
+		var items []string
+		for i := start; i < end; i++ {
+			items = append(items, ss.renderHit(ss.hits[i], i == ss.selected, innerWidth))
+		}
+		if remaining := len(ss.hits) - end; remaining > 0 {
+			items = append(items, muted.Render("  ... "+formatInt(remaining)+" more"))
+		}
+		list = strings.Join(items, "\n")
+	}
+
+	help := lipgloss.NewStyle().
+		Foreground(styles.TextMuted).
+		Padding(1, 0, 0, 0).
+		Render("Up/Down navigate | Enter open | Esc close")
+
+	content := lipgloss.JoinVertical(lipgloss.Left, header, separator, inputView, separator, list, help)
+
+	box := lipgloss.NewStyle().
+		Background(styles.Surface).
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(styles.Purple).
+		Padding(1, 2).
+		Width(boxWidth).
+		Render(content)
+
+	if ss.width > 0 && ss.height > 0 {
+		return lipgloss.Place(
+			ss.width, ss.height,
+			lipgloss.Center, lipgloss.Center,
+			box,
+			lipgloss.WithWhitespaceChars(" "),
+			lipgloss.WithWhitespaceForeground(lipgloss.Color("#000000")),
+		)
+	}
+	return box
+}
+
+// renderHit renders a result as a title line and a snippet line.
+func (ss *SessionSearch) renderHit(hit storage.MessageHit, selected bool, width int) string {
+	indicator := "  "
+	if selected {
+		indicator = "> "
+	}
+
+	meta := hit.Role + "  " + hit.UpdatedAt.Format("2006-01-02 15:04")
+	summary := hit.Summary
+	if summary == "" {
+		summary = hit.ConversationID
+	}
+	summary = truncateString(summary, width-lipgloss.Width(indicator)-len(meta)-2)
+
+	title := indicator +
+		lipgloss.NewStyle().Foreground(styles.Cyan).Bold(true).Render(summary) + "  " +
+		lipgloss.NewStyle().Foreground(styles.TextMuted).Render(meta)
+	if selected {
+		title = lipgloss.NewStyle().
+			Background(styles.Purple).
+			Foreground(styles.TextInverse).
+			Width(width).
+			Render(indicator + summary + "  " + meta)
+	}
+
+	return title + "\n    " + renderSnippet(hit.Snippet, width-4)
+}
+
+// renderSnippet truncates a snippet to width runes and highlights the
+// terms that storage marked as matches.
+func renderSnippet(snippet string, width int) string {
+	plain := lipgloss.NewStyle().Foreground(styles.TextSecondary)
+	match := lipgloss.NewStyle().Foreground(styles.Amber).Bold(true)
+
+	var out strings.Builder
+	var segment []rune
+	inMatch := false
+	flush := func() {
+		if len(segment) == 0 {
+			return
+		}
+		if inMatch {
+			out.WriteString(match.Render(string(segment)))
+		} else {
+			out.WriteString(plain.Render(string(segment)))
+		}
+		segment = segment[:0]
+	}
+
+	used := 0
+	for _, r := range snippet {
+		switch string(r) {
+		case storage.SnippetMatchStart:
+			flush()
+			inMatch = true
+			continue
+		case storage.SnippetMatchEnd:
+			flush()
+			inMatch = false
+			continue
+		}
+		if used >= width-3 && width > 3 {
+			segment = append(segment, '.', '.', '.')
+			break
+		}
+		segment = append(segment, r)
+		used++
+	}
+	flush()
+	return out.String()
+}
+
+// =============================================================================
+// PUBLIC METHODS
+// =============================================================================
+
+// Show opens the overlay with an initial query and starts searching for it.
+func (ss *SessionSearch) Show(query string) tea.Cmd {
+	ss.visible = true
+	ss.hits = nil
+	ss.err = nil
+	ss.selected = 0
+	ss.input.SetValue(query)
+	ss.input.CursorEnd()
+	return tea.Batch(ss.input.Focus(), ss.search())
+}
+
+// Hide closes the overlay.
+func (ss *SessionSearch) Hide() {
+	ss.visible = false
+	ss.input.Blur()
+}
+
+// IsVisible returns true if the overlay is open.
+func (ss *SessionSearch) IsVisible() bool {
+	return ss.visible
+}
+
+// SetSize sets the dimensions for centering the overlay.
+func (ss *SessionSearch) SetSize(width, height int) {
+	ss.width = width
+	ss.height = height
+}
+
+// =============================================================================
+// MESSAGES
+// =============================================================================
+
+// SessionSearchResultsMsg delivers the results of a session search.
+type SessionSearchResultsMsg struct {
+	Seq   int
+	Query string
+	Hits  []storage.MessageHit
+	Err   error
+}
+
+// SessionSearchSelectMsg is sent when a result is opened. Query is the
+// search text, so the conversation can be opened with it highlighted.
+type SessionSearchSelectMsg struct {
+	Hit   storage.MessageHit
+	Query string
+}
+
+// errSessionSearchUnavailable is reported when no searcher is set.
+var errSessionSearchUnavailable = errors.New("session storage not available")
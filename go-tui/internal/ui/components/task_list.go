@@ -12,6 +12,7 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/jeranaias/rigrun-tui/internal/tasks"
 	"github.com/jeranaias/rigrun-tui/internal/ui/styles"
+	"github.com/jeranaias/rigrun-tui/internal/util"
 )
 
 // =============================================================================
@@ -103,7 +104,7 @@ func (tl *TaskList) shouldShow(task *tasks.Task) bool {
 	switch status {
 	case tasks.TaskStatusComplete:
 		return tl.showCompleted
-	case tasks.TaskStatusFailed:
+	case tasks.TaskStatusFailed, tasks.TaskStatusInterrupted:
 		return tl.showFailed
 	case tasks.TaskStatusCanceled:
 		return tl.showCanceled
@@ -189,10 +190,13 @@ func (tl *TaskList) renderTask(task *tasks.Task) string {
 	// Duration
 	duration := formatTaskDuration(task.Duration())
 
-	// Progress (for running tasks)
+	// Progress (for running tasks), with the current step of agent tasks
 	progress := ""
 	if task.IsRunning() {
 		progress = fmt.Sprintf("[%d%%]", task.GetProgress())
+		if step := task.GetStep(); step != "" {
+			progress += " " + lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render(util.TruncateRunes(step, 50))
+		}
 	}
 
 	// Build row
@@ -225,6 +229,8 @@ func (tl *TaskList) statusIcon(status tasks.TaskStatus) (string, lipgloss.Color)
 		return "[X]", lipgloss.Color("9") // Red
 	case tasks.TaskStatusCanceled:
 		return "[--]", lipgloss.Color("240") // Gray
+	case tasks.TaskStatusInterrupted:
+		return "[!!]", lipgloss.Color("208") // Orange
 	default:
 		return "[?]", lipgloss.Color("240")
 	}
@@ -357,6 +363,20 @@ func (tl *TaskList) renderTaskInfo(task *tasks.Task) string {
 		b.WriteString("\n")
 	}
 
+	// Current step (agent tasks)
+	if step := task.GetStep(); step != "" && task.IsRunning() {
+		b.WriteString(labelStyle.Render("Step: "))
+		b.WriteString(valueStyle.Render(step))
+		b.WriteString("\n")
+	}
+
+	// Summary (agent tasks)
+	if result := task.GetResult(); result != "" {
+		b.WriteString(labelStyle.Render("Summary: "))
+		b.WriteString(valueStyle.Render(result))
+		b.WriteString("\n")
+	}
+
 	return b.String()
 }
 
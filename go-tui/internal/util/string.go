@@ -4,6 +4,8 @@
 // Package util provides utility functions for the go-tui application.
 package util
 
+import "unicode/utf8"
+
 // UNICODE: Rune-aware truncation preserves multi-byte characters.
 // These functions handle strings correctly regardless of character encoding,
 // preventing mid-character truncation that would corrupt UTF-8 strings.
@@ -38,6 +40,23 @@ func TruncateRunesNoEllipsis(s string, maxRunes int) string {
 	return string(runes[:maxRunes])
 }
 
+// TruncateBytes truncates a string to at most maxBytes bytes without
+// splitting a multi-byte character: the cut moves back to the previous
+// rune boundary. No ellipsis is appended.
+func TruncateBytes(s string, maxBytes int) string {
+	if maxBytes <= 0 {
+		return ""
+	}
+	if len(s) <= maxBytes {
+		return s
+	}
+	end := maxBytes
+	for end > 0 && !utf8.RuneStart(s[end]) {
+		end--
+	}
+	return s[:end]
+}
+
 // TruncateWidth truncates a string to a maximum display width.
 // This accounts for double-width characters (CJK) that take 2 columns.
 // For now, this provides a basic implementation; for full CJK support,
@@ -209,6 +209,33 @@ func TestTruncateRunesNoEllipsis(t *testing.T) {
 	}
 }
 
+func TestTruncateBytes(t *testing.T) {
+	testCases := []struct {
+		input    string
+		maxBytes int
+		expected string
+	}{
+		{"hello world", 5, "hello"},
+		{"hello", 5, "hello"},
+		{"", 5, ""},
+		{"hello", 0, ""},
+		{"héllo", 2, "h"}, // é is 2 bytes; never split it
+		{"héllo", 3, "hé"},
+		{"日本語", 4, "日"}, // 3-byte runes
+		{"日本語", 6, "日本"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			result := TruncateBytes(tc.input, tc.maxBytes)
+			if result != tc.expected {
+				t.Errorf("TruncateBytes(%q, %d) = %q, want %q",
+					tc.input, tc.maxBytes, result, tc.expected)
+			}
+		})
+	}
+}
+
 func TestSafeSubstring(t *testing.T) {
 	testCases := []struct {
 		input    string
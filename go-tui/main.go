@@ -6,6 +6,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
@@ -21,13 +22,17 @@ import (
 	"github.com/jeranaias/rigrun-tui/internal/commands"
 	"github.com/jeranaias/rigrun-tui/internal/config"
 	"github.com/jeranaias/rigrun-tui/internal/detect"
+	"github.com/jeranaias/rigrun-tui/internal/diff"
 	"github.com/jeranaias/rigrun-tui/internal/model"
 	"github.com/jeranaias/rigrun-tui/internal/offline"
 	"github.com/jeranaias/rigrun-tui/internal/ollama"
+	"github.com/jeranaias/rigrun-tui/internal/plan"
 	"github.com/jeranaias/rigrun-tui/internal/security"
 	"github.com/jeranaias/rigrun-tui/internal/router"
 	"github.com/jeranaias/rigrun-tui/internal/session"
 	"github.com/jeranaias/rigrun-tui/internal/storage"
+	"github.com/jeranaias/rigrun-tui/internal/tasks"
+	"github.com/jeranaias/rigrun-tui/internal/telemetry"
 	"github.com/jeranaias/rigrun-tui/internal/tools"
 	"github.com/jeranaias/rigrun-tui/internal/ui/chat"
 	"github.com/jeranaias/rigrun-tui/internal/ui/components"
@@ -55,9 +60,18 @@ func init() {
 }
 
 func main() {
+	// SC-39: When started as a Bash sandbox helper, set up the sandbox and
+	// exec the command. Must run before anything else.
+	tools.MaybeRunSandboxInit()
+
 	// Parse CLI arguments
 	cmd, args := cli.Parse()
 
+	// SC-28: Encrypt user content as it is written once a master key exists
+	if cfg := config.Global(); cfg.Security.EncryptionEnabled && cfg.Security.EncryptData {
+		security.EnableDataEncryption()
+	}
+
 	// Route to appropriate handler
 	switch cmd {
 	case cli.CmdTUI:
@@ -82,7 +96,10 @@ func main() {
 	case cli.CmdStatus:
 		cli.HandleStatus(args)
 	case cli.CmdConfig:
-		cli.HandleConfig(args)
+		if err := cli.HandleConfig(args); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
 	case cli.CmdSetup:
 		cli.HandleSetup(args)
 	case cli.CmdCache:
@@ -201,6 +218,50 @@ func main() {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
+	case cli.CmdPolicy:
+		if err := cli.HandlePolicy(args); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	case cli.CmdScanSecrets:
+		if err := cli.HandleScanSecrets(args); err != nil {
+			if !errors.Is(err, cli.ErrSecretsFound) {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			}
+			os.Exit(1)
+		}
+	case cli.CmdReview:
+		if err := cli.HandleReview(args); err != nil {
+			if !errors.Is(err, cli.ErrReviewFindings) {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			}
+			os.Exit(1)
+		}
+	case cli.CmdPlan:
+		if err := cli.HandlePlan(args); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	case cli.CmdTasks:
+		if err := cli.HandleTasks(args); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	case cli.CmdSchedule:
+		if err := cli.HandleSchedule(args); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	case cli.CmdDaemon:
+		if err := cli.HandleDaemon(args); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	case cli.CmdCost:
+		if err := cli.HandleCost(args); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
 	case cli.CmdVersion:
 		cli.HandleVersionWithJSON(args)
 	case cli.CmdHelp:
@@ -260,6 +321,40 @@ func runTUI(args cli.Args) {
 		})
 	}
 
+	// ==========================================================================
+	// CM-5: Administrator Policy
+	// Report policy status and enforce the policy network allow-list
+	// ==========================================================================
+	if perr := cfg.PolicyError(); perr != nil {
+		fmt.Fprintf(os.Stderr, "[POLICY] %v\n", perr)
+		fmt.Fprintf(os.Stderr, "[POLICY] Paranoid mode enforced. Run 'rigrun policy verify' for details.\n")
+		security.AuditLogEvent("STARTUP", "POLICY_INVALID", map[string]string{
+			"path":  cfg.PolicyPath(),
+			"error": perr.Error(),
+		})
+	} else if doc := cfg.Policy(); doc != nil {
+		security.AuditLogEvent("STARTUP", "POLICY_APPLIED", map[string]string{
+			"path":        cfg.PolicyPath(),
+			"issuer":      doc.Issuer,
+			"locked_keys": strings.Join(cfg.LockedKeys(), ","),
+		})
+	}
+	cli.EnforcePolicyBoundary(cfg)
+
+	// ==========================================================================
+	// SI-12: Information Retention
+	// Securely delete stored data past its retention policy
+	// ==========================================================================
+	if cfg.Retention.PurgeOnStartup {
+		report, err := cli.ApplyRetention(cfg, false)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[SI-12 RETENTION] %v\n", err)
+		}
+		if n := report.Purged(); n > 0 {
+			fmt.Fprintf(os.Stderr, "[SI-12 RETENTION] Purged %d items past their retention policy\n", n)
+		}
+	}
+
 	// ==========================================================================
 	// IL5 SC-7: Offline Mode Setup
 	// Block ALL network except localhost Ollama when --no-network or config set
@@ -288,11 +383,18 @@ func runTUI(args cli.Args) {
 	// Create the application model with config
 	m := NewModelWithConfig(theme, ollamaClient, cfg)
 
-	// Ensure cleanup of cache goroutine when TUI exits
+	// Ensure cleanup of cache goroutine and search index when TUI exits
 	defer func() {
 		if m.stopCleanup != nil {
 			m.stopCleanup()
 		}
+		if m.convStore != nil {
+			_ = m.convStore.Close()
+		}
+		// Tasks still running are recorded as interrupted
+		if queue := m.chatModel.GetTaskQueue(); queue != nil {
+			_ = queue.Shutdown()
+		}
 	}()
 
 	// Apply CLI args to model (CLI args override config)
@@ -384,6 +486,12 @@ type Model struct {
 	sessionMgr *session.Manager
 	convStore  *storage.ConversationStore
 
+	// Persisted plans: the store, the executor of a resumed plan, and a
+	// revision of it awaiting approval
+	planStore    *plan.Store
+	planExecutor *plan.PlanExecutor
+	planRevision *PlanRevisionMsg
+
 	// Streaming state
 	streamingMsgID string
 	cancelStream   context.CancelFunc
@@ -399,6 +507,7 @@ type Model struct {
 	toolRegistry *tools.Registry
 	toolExecutor *tools.Executor
 	toolsEnabled bool
+	allowedTools []string // Tools a custom command limits the current turn to (nil = all)
 
 	// Agentic loop state - tracks pending tool calls during streaming
 	pendingToolCalls []ollama.ToolCall
@@ -420,6 +529,16 @@ type Model struct {
 	// Consent banner (IL5 AC-8 compliance - System Use Notification)
 	consentBanner   components.ConsentBanner
 	consentRequired bool // Whether consent is required at startup
+
+	// Pending outbound secret decision (SC-7(10), secret_action = "ask")
+	secretPrompt *SecretPromptMsg
+
+	// Pending hunk-level review of Edit/Write changes from the agent
+	diffReview   *DiffReviewMsg
+	diffReviewer *components.HunkReviewer
+
+	// Git-aware agent session ([git] mode); nil when off or not started
+	gitSession *tools.GitSession
 }
 
 // NewModel creates a new application model (uses default config).
@@ -482,15 +601,51 @@ func NewModelWithConfig(theme *styles.Theme, ollamaClient *ollama.Client, cfg *c
 	// Read classification from config; banner is shown on ALL screens when enabled
 	classificationBanner := components.NewClassificationBannerFromString(cfg.Security.Classification)
 
+	// Session classification is the floor for every message's marking and the
+	// ceiling for conversations that may be loaded into this session
+	chatModel.SetSessionClassification(security.ClassificationFromEnv(cfg.Security.Classification))
+
 	// Initialize session manager with default config
 	sessionMgr := session.NewManager(session.DefaultConfig())
 
-	// Initialize conversation store (creates ~/.rigrun/conversations/ directory)
+	// Initialize conversation store (~/.rigrun/conversations/conversations.db)
 	convStore, err := storage.NewConversationStore()
 	if err != nil {
 		// Log error but continue - sessions won't persist but app will work
 		fmt.Fprintf(os.Stderr, "Warning: Could not initialize session storage: %v\n", err)
 	}
+	if convStore != nil {
+		// Session search overlay (/search) uses the store's full-text index
+		chatModel.SetSessionSearcher(convStore.SearchMessageSnippets)
+	}
+
+	// Initialize plan store (creates ~/.rigrun/plans/ directory)
+	planStore, err := plan.NewStore("")
+	if err != nil {
+		// Plans still run, they just can't be resumed after a restart
+		fmt.Fprintf(os.Stderr, "Warning: Could not initialize plan storage: %v\n", err)
+	}
+
+	// Initialize task store (~/.rigrun/tasks.db): background tasks and their
+	// output survive restarts
+	taskStore, err := tasks.OpenStore("")
+	if err != nil {
+		// Tasks still run, they just vanish on exit
+		fmt.Fprintf(os.Stderr, "Warning: Could not initialize task storage: %v\n", err)
+	} else if err := chatModel.SetTaskStore(taskStore); err != nil {
+		_ = taskStore.Close()
+		fmt.Fprintf(os.Stderr, "Warning: Could not restore background tasks: %v\n", err)
+	}
+
+	// Initialize cost tracker (~/.rigrun/costs): cloud spend is checked
+	// against the [budget] limits before a paid tier is chosen
+	costTracker, err := telemetry.NewCostTracker("")
+	if err != nil {
+		// Queries still run, their spend is just not recorded or budgeted
+		fmt.Fprintf(os.Stderr, "Warning: Could not initialize cost tracking: %v\n", err)
+	} else {
+		chatModel.SetCostTracker(costTracker, cli.BudgetFromConfig(cfg), cfg.Budget.OnExceeded)
+	}
 
 	// Initialize cache manager with exact and semantic caching
 	cacheManager := cache.NewCacheManager(nil, nil)
@@ -526,12 +681,25 @@ func NewModelWithConfig(theme *styles.Theme, ollamaClient *ollama.Client, cfg *c
 			// Still set the client - it will fail gracefully and fall back to local
 		}
 
+		// SC-7(10): Scan outbound prompts and @file contents for credentials
+		secretGuard, err := cli.NewSecretGuard(cfg, tuiSecretPrompter)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		if secretGuard != nil {
+			secretGuard.SetNotifier(tuiSecretNotifier)
+			cloudClient.WithSecretGuard(secretGuard)
+		}
+
 		// Pass cloud client to chat model for cloud routing
 		chatModel.SetCloudClient(cloudClient)
 	}
 
 	// Initialize tool system for agentic loop
-	toolRegistry := tools.NewRegistry()
+	toolRegistry, err := tools.NewConfiguredRegistry(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: tool setup: %v\n", err)
+	}
 	toolExecutor := tools.NewExecutor(toolRegistry)
 	// Auto-approve low-risk read-only tools
 	toolExecutor.SetAutoApproveLevel(tools.PermissionAuto)
@@ -579,6 +747,7 @@ func NewModelWithConfig(theme *styles.Theme, ollamaClient *ollama.Client, cfg *c
 		config:               cfg,
 		sessionMgr:           sessionMgr,
 		convStore:            convStore,
+		planStore:            planStore,
 		modelName:            modelName,
 		mode:                 mode,
 		gpu:                  gpuName,
@@ -610,6 +779,8 @@ func (m *Model) Init() tea.Cmd {
 		m.chatModel.Init(),
 		m.checkOllama(),
 		m.startSessionTimeoutTick(), // IL5 AC-12: Start session timeout monitoring
+		m.startGitSession(),
+		m.checkInterruptedPlans(),
 	)
 }
 
@@ -631,6 +802,10 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Update consent banner dimensions (IL5 AC-8)
 		m.consentBanner.SetSize(msg.Width, msg.Height)
 
+		if m.diffReviewer != nil {
+			m.diffReviewer.SetSize(msg.Width, msg.Height)
+		}
+
 		// Update classification banner width (IL5 DoDI 5200.48)
 		if m.classificationBanner != nil {
 			m.classificationBanner.SetWidth(msg.Width)
@@ -668,6 +843,8 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case chat.StreamRequestMsg:
+		// A new user turn; its tool limits last through the agentic loop
+		m.allowedTools = msg.AllowedTools
 		// Convert chat.StreamRequestMsg to local StreamRequestMsg, preserving cloud routing fields
 		return m.startStreaming(StreamRequestMsg{
 			MessageID:  msg.MessageID,
@@ -698,12 +875,51 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case RoutingFallbackMsg:
 		return m.handleRoutingFallback(msg)
 
+	case SecretPromptMsg:
+		return m.handleSecretPrompt(msg)
+
+	case DiffReviewMsg:
+		return m.handleDiffReview(msg)
+
+	case SecretFindingsMsg:
+		return m.handleSecretFindings(msg)
+
 	case ToolCallsDetectedMsg:
 		return m.handleToolCallsDetected(msg)
 
 	case ToolExecutionCompleteMsg:
 		return m.handleToolExecutionComplete(msg)
 
+	case StopHooksCompleteMsg:
+		return m.handleStopHooksComplete(msg)
+
+	// Git-aware agent sessions
+
+	case GitResultMsg:
+		return m.handleGitResult(msg)
+
+	case commands.GitCommandMsg:
+		return m.handleGitCommand(msg)
+
+	// Persisted plans
+	case InterruptedPlansMsg:
+		return m.handleInterruptedPlans(msg)
+
+	case commands.ResumePlanMsg:
+		return m.handleResumePlan(msg)
+
+	case commands.CancelPlanMsg:
+		return m.handleCancelPlan(msg)
+
+	case commands.PlanProgressMsg:
+		return m.handlePlanResult(msg.Status)
+
+	case commands.PlanCompleteMsg:
+		return m.handlePlanComplete(msg)
+
+	case PlanRevisionMsg:
+		return m.handlePlanRevision(msg)
+
 	// Session management messages from /save, /load, /list commands
 	// Handle both commands package and chat package message types
 	case commands.SaveConversationMsg:
@@ -716,7 +932,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.handleLoadConversation(commands.LoadConversationMsg{ID: msg.ID})
 
 	case chat.LoadConversationMsg:
-		return m.handleLoadConversation(commands.LoadConversationMsg{ID: msg.ID})
+		return m.loadConversation(msg.ID, msg.MessageID, msg.Query)
 
 	case commands.ListSessionsMsg:
 		return m.handleListSessions()
@@ -859,6 +1075,19 @@ func (m *Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 	// In chat state
 	if m.state == StateChat {
+		// SC-7(10): A pending secret decision captures the keyboard
+		if m.secretPrompt != nil {
+			return m.handleSecretPromptKey(msg)
+		}
+		// A pending change review captures the keyboard
+		if m.diffReviewer != nil {
+			return m.handleDiffReviewKey(msg)
+		}
+		// A pending plan revision captures the keyboard
+		if m.planRevision != nil {
+			return m.handlePlanRevisionKey(msg)
+		}
+
 		switch msg.String() {
 		case "ctrl+c":
 			// If streaming, cancel it and clean up state
@@ -921,6 +1150,9 @@ func (m *Model) View() string {
 		content = m.welcome.View()
 	case StateChat:
 		content = m.chatModel.View()
+		if m.diffReviewer != nil {
+			content = m.diffReviewer.View()
+		}
 	default:
 		content = m.welcome.View()
 	}
@@ -1016,6 +1248,26 @@ type RoutingFallbackMsg struct {
 	Reason    string // Why the fallback happened
 }
 
+// SecretPromptMsg asks the user how to handle secrets found in an outbound
+// cloud prompt (SC-7(10), secret_action = "ask"). The streaming goroutine
+// waits on Reply.
+type SecretPromptMsg struct {
+	Findings []security.SecretFinding
+	Reply    chan<- string
+}
+
+// DiffReviewMsg asks the user to review the pending Edit and Write changes of
+// an agent turn hunk by hunk. The tool goroutine waits on Reply.
+type DiffReviewMsg struct {
+	Changes []tools.PendingChange
+	Reply   chan<- []*diff.Review
+}
+
+// SecretFindingsMsg reports the action applied to secrets in an outbound prompt.
+type SecretFindingsMsg struct {
+	Result security.SecretScanResult
+}
+
 // ToolCallsDetectedMsg signals that tool calls were detected in the LLM response.
 // This triggers the agentic loop: execute tools and call LLM again.
 type ToolCallsDetectedMsg struct {
@@ -1034,6 +1286,12 @@ type ToolExecutionCompleteMsg struct {
 	Messages     []ollama.Message // Updated messages including tool results
 }
 
+// StopHooksCompleteMsg carries the output of the stop hooks that ran when the
+// agentic loop finished.
+type StopHooksCompleteMsg struct {
+	Output string
+}
+
 // ToolResultEntry holds a single tool execution result.
 type ToolResultEntry struct {
 	ToolName string
@@ -1071,6 +1329,7 @@ func (m *Model) startLocalStreaming(ctx context.Context, msg StreamRequestMsg) t
 	ollamaClient := m.ollamaClient
 	toolsEnabled := m.toolsEnabled
 	toolRegistry := m.toolRegistry
+	allowedTools := m.allowedTools
 	modelName := m.modelName
 	cancelStream := m.cancelStream
 
@@ -1092,7 +1351,11 @@ func (m *Model) startLocalStreaming(ctx context.Context, msg StreamRequestMsg) t
 		// Get tool definitions if tools are enabled
 		var ollamaTools []ollama.Tool
 		if toolsEnabled && toolRegistry != nil {
-			ollamaTools = toolRegistry.ToOllamaTools()
+			for _, tool := range toolRegistry.ToOllamaTools() {
+				if toolAllowed(allowedTools, tool.Function.Name) {
+					ollamaTools = append(ollamaTools, tool)
+				}
+			}
 		}
 
 		// Use ChatStreamWithTools if tools are available, otherwise regular ChatStream
@@ -1255,6 +1518,8 @@ func (m *Model) startCloudStreaming(ctx context.Context, msg StreamRequestMsg) t
 		isFirst := true
 		var accumulatedContent string
 		var tokenCount int
+		var done bool
+		var usage *cloud.Usage
 
 		streamErr := cloudClient.ChatStream(ctx, cloudMessages, func(chunk cloud.StreamChunk) {
 			content := chunk.GetContent()
@@ -1275,27 +1540,49 @@ func (m *Model) startCloudStreaming(ctx context.Context, msg StreamRequestMsg) t
 				isFirst = false
 			}
 
-			// Handle completion (finish_reason is set)
+			// Handle completion (finish_reason is set). The usage the
+			// provider reports arrives after it, on the final chunk.
 			if chunk.IsDone() {
-				// Create stats
-				stats := model.NewStatistics()
-				stats.RecordFirstToken()
+				done = true
+			}
+			if chunk.Usage != nil {
+				usage = chunk.Usage
+			}
+		})
+
+		if streamErr == nil && done {
+			// Create stats, from the provider's token usage when reported
+			stats := model.NewStatistics()
+			stats.RecordFirstToken()
+			if usage != nil {
+				stats.PromptTokens = usage.PromptTokens
+				stats.Finalize(usage.CompletionTokens)
+			} else {
 				stats.Finalize(tokenCount)
+			}
 
-				// Session stats are recorded in handleStreamComplete (chat/model.go)
-				// to avoid double-counting
+			// Session stats are recorded in handleStreamComplete (chat/model.go)
+			// to avoid double-counting
 
-				programMu.Lock()
-				p := programRef
-				programMu.Unlock()
-				if p != nil {
-					p.Send(StreamCompleteMsg{
-						MessageID: msg.MessageID,
-						Stats:     stats,
-					})
-				}
+			programMu.Lock()
+			p := programRef
+			programMu.Unlock()
+			if p != nil {
+				p.Send(StreamCompleteMsg{
+					MessageID: msg.MessageID,
+					Stats:     stats,
+				})
 			}
-		})
+		}
+
+		// SC-7(10): A prompt blocked for containing secrets is not retried
+		// elsewhere; the user must remove the secrets first
+		if errors.Is(streamErr, security.ErrSecretsBlocked) {
+			return StreamErrorMsg{
+				MessageID: msg.MessageID,
+				Error:     streamErr,
+			}
+		}
 
 		if streamErr != nil && streamErr != context.Canceled {
 			// On cloud failure, attempt fallback to local
@@ -1444,6 +1731,8 @@ func (m *Model) handleStreamComplete(msg StreamCompleteMsg) (tea.Model, tea.Cmd)
 
 	// Reset agentic loop state - stream completion means the loop is done
 	// (either naturally finished or was stopped by safety checks)
+	usedTools := m.agenticIteration > 0
+	request := lastUserRequest(m.agenticMessages)
 	m.resetAgenticState()
 
 	// Forward to chat model
@@ -1454,9 +1743,191 @@ func (m *Model) handleStreamComplete(msg StreamCompleteMsg) (tea.Model, tea.Cmd)
 	newChatModel, cmd := m.chatModel.Update(chatMsg)
 	m.chatModel = newChatModel.(chat.Model)
 
+	// Stop hooks run when an agentic task finishes, then the turn's changes
+	// are committed so the commit includes anything the hooks changed
+	var after []tea.Cmd
+	if usedTools && m.toolRegistry != nil && m.toolRegistry.HasHooks(tools.HookStop) {
+		after = append(after, runStopHooks(m.toolRegistry))
+	}
+	if usedTools && m.gitSession != nil {
+		after = append(after, commitGitTurn(m.gitSession, m.ollamaClient, m.modelName, request))
+	}
+	if len(after) > 0 {
+		cmd = tea.Batch(cmd, tea.Sequence(after...))
+	}
+
 	return m, cmd
 }
 
+// runStopHooks runs the configured stop hooks in the background.
+func runStopHooks(registry *tools.Registry) tea.Cmd {
+	return func() tea.Msg {
+		return StopHooksCompleteMsg{Output: registry.RunStopHooks(context.Background())}
+	}
+}
+
+// handleStopHooksComplete shows stop hook output in the conversation.
+func (m *Model) handleStopHooksComplete(msg StopHooksCompleteMsg) (tea.Model, tea.Cmd) {
+	if msg.Output == "" {
+		return m, nil
+	}
+	conv := m.chatModel.GetConversation()
+	conv.AddSystemMessage(msg.Output)
+	m.chatModel.SetConversation(conv)
+	return m, nil
+}
+
+// =============================================================================
+// GIT-AWARE AGENT SESSIONS
+// =============================================================================
+
+// gitCommandTimeout bounds a git operation including its commit message.
+const gitCommandTimeout = 2 * time.Minute
+
+// GitResultMsg carries the outcome of a git operation for the conversation.
+type GitResultMsg struct {
+	Output string
+}
+
+// startGitSession creates the agent's session branch when [git] mode is on.
+// It runs from Init, before any message is handled, so that no tool call
+// can run outside the session; the returned command reports the outcome.
+// In worktree mode the tools work in the worktree, while the process stays
+// where it started.
+func (m *Model) startGitSession() tea.Cmd {
+	if m.config == nil || m.toolRegistry == nil {
+		return nil
+	}
+	mode, err := tools.ParseGitMode(m.config.Git.Mode)
+	if err != nil || mode == tools.GitModeOff {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), gitCommandTimeout)
+	defer cancel()
+	cwd, _ := os.Getwd()
+	session, err := m.toolRegistry.StartGitSession(ctx, mode, m.config.Git.BranchPrefix, cwd)
+	text := ""
+	if err != nil {
+		text = "Git mode is disabled for this session: " + err.Error()
+	} else {
+		m.gitSession = session
+		if session.Mode == tools.GitModeWorktree {
+			if m.toolExecutor != nil {
+				m.toolExecutor.SetWorkDir(session.Dir)
+			}
+			m.chatModel.SetWorkDir(session.Dir)
+		}
+		text = session.Describe()
+	}
+	return func() tea.Msg { return GitResultMsg{Output: text} }
+}
+
+// handleGitResult shows the outcome of a git operation in the conversation.
+func (m *Model) handleGitResult(msg GitResultMsg) (tea.Model, tea.Cmd) {
+	if msg.Output == "" {
+		return m, nil
+	}
+	conv := m.chatModel.GetConversation()
+	conv.AddSystemMessage(msg.Output)
+	m.chatModel.SetConversation(conv)
+	return m, nil
+}
+
+// handleGitCommand runs /undo and /commit against the git session.
+func (m *Model) handleGitCommand(msg commands.GitCommandMsg) (tea.Model, tea.Cmd) {
+	if m.gitSession == nil {
+		return m.handleGitResult(GitResultMsg{Output: "Git mode is off. Set mode = \"branch\" or \"worktree\" in the [git] section of config.toml."})
+	}
+	if m.streamingMsgID != "" {
+		return m.handleGitResult(GitResultMsg{Output: "Wait for the response to finish before /" + msg.Command + "."})
+	}
+
+	session, client, modelName := m.gitSession, m.ollamaClient, m.modelName
+	switch msg.Command {
+	case "undo":
+		return m, func() tea.Msg {
+			ctx, cancel := context.WithTimeout(context.Background(), gitCommandTimeout)
+			defer cancel()
+			commit, err := session.Undo(ctx)
+			if err != nil {
+				return GitResultMsg{Output: "Undo failed: " + err.Error()}
+			}
+			return GitResultMsg{Output: fmt.Sprintf("Reverted %s: %s", commit.Short(), commit.Subject)}
+		}
+	case "commit":
+		message := strings.TrimSpace(strings.Join(msg.Args, " "))
+		return m, func() tea.Msg {
+			ctx, cancel := context.WithTimeout(context.Background(), gitCommandTimeout)
+			defer cancel()
+			if message == "" {
+				diff, err := session.SessionDiff(ctx)
+				if err != nil {
+					return GitResultMsg{Output: "Commit failed: " + err.Error()}
+				}
+				message = generateCommitMessage(ctx, client, modelName, "", diff)
+			}
+			commit, replaced, err := session.Squash(ctx, message)
+			if err != nil {
+				return GitResultMsg{Output: "Commit failed: " + err.Error()}
+			}
+			return GitResultMsg{Output: fmt.Sprintf("Squashed %d commits on %s into %s: %s", replaced, session.Branch, commit.Short(), commit.Subject)}
+		}
+	}
+	return m, nil
+}
+
+// commitGitTurn commits the changes an agent turn made, with a message
+// written by the local model.
+func commitGitTurn(session *tools.GitSession, client *ollama.Client, modelName, request string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), gitCommandTimeout)
+		defer cancel()
+
+		diff, err := session.PendingDiff(ctx)
+		if err != nil {
+			return GitResultMsg{Output: "Git commit failed: " + err.Error()}
+		}
+		if diff == "" {
+			return GitResultMsg{} // the turn changed nothing
+		}
+		commit, ok, err := session.Commit(ctx, generateCommitMessage(ctx, client, modelName, request, diff))
+		if err != nil {
+			return GitResultMsg{Output: "Git commit failed: " + err.Error()}
+		}
+		if !ok {
+			return GitResultMsg{}
+		}
+		return GitResultMsg{Output: fmt.Sprintf("Committed %s: %s (/undo to revert)", commit.Short(), commit.Subject)}
+	}
+}
+
+// generateCommitMessage asks the local model for a commit message. The diff
+// never leaves the machine; without a model a message naming the changed
+// files is used.
+func generateCommitMessage(ctx context.Context, client *ollama.Client, modelName, request, diff string) string {
+	if client == nil || modelName == "" {
+		return tools.CleanCommitMessage("", diff)
+	}
+	resp, err := client.Chat(ctx, modelName, []ollama.Message{
+		{Role: "user", Content: tools.CommitMessagePrompt(request, diff)},
+	})
+	if err != nil || resp == nil {
+		return tools.CleanCommitMessage("", diff)
+	}
+	return tools.CleanCommitMessage(resp.Message.Content, diff)
+}
+
+// lastUserRequest returns the last user message of an agentic conversation.
+func lastUserRequest(messages []ollama.Message) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" {
+			return messages[i].Content
+		}
+	}
+	return ""
+}
+
 // handleStreamError processes a stream error.
 func (m *Model) handleStreamError(msg StreamErrorMsg) (tea.Model, tea.Cmd) {
 	// Clean up streaming state
@@ -1507,6 +1978,391 @@ func (m *Model) handleRoutingFallback(msg RoutingFallbackMsg) (tea.Model, tea.Cm
 	return m, cmd
 }
 
+// =============================================================================
+// OUTBOUND SECRET SCANNING (SC-7(10))
+// =============================================================================
+
+// tuiSecretPrompter is the SecretPrompter used by the TUI. It runs on the
+// streaming goroutine and blocks until the user answers in the chat view.
+func tuiSecretPrompter(ctx context.Context, findings []security.SecretFinding) string {
+	programMu.Lock()
+	p := programRef
+	programMu.Unlock()
+	if p == nil {
+		return security.SecretActionBlock
+	}
+
+	reply := make(chan string, 1)
+	p.Send(SecretPromptMsg{Findings: findings, Reply: reply})
+
+	select {
+	case <-ctx.Done():
+		return security.SecretActionBlock
+	case answer := <-reply:
+		return answer
+	}
+}
+
+// tuiSecretNotifier surfaces the outcome of every outbound scan in the chat view.
+func tuiSecretNotifier(result security.SecretScanResult) {
+	programMu.Lock()
+	p := programRef
+	programMu.Unlock()
+	if p != nil {
+		p.Send(SecretFindingsMsg{Result: result})
+	}
+}
+
+// handleSecretPrompt shows the findings and waits for the user's decision.
+func (m *Model) handleSecretPrompt(msg SecretPromptMsg) (tea.Model, tea.Cmd) {
+	// Only one prompt can be pending; a stale one is answered with block
+	if m.secretPrompt != nil {
+		m.secretPrompt.Reply <- security.SecretActionBlock
+	}
+	m.secretPrompt = &msg
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Secrets detected in prompt bound for a cloud model (SC-7(10)):\n")
+	for _, f := range msg.Findings {
+		fmt.Fprintf(&b, "  %s\n", f)
+	}
+	b.WriteString("Press [r] to redact and send, [s] to send unredacted, [b]/Esc to block.")
+
+	conv := m.chatModel.GetConversation()
+	conv.AddSystemMessage(b.String())
+	m.chatModel.SetConversation(conv)
+	return m, nil
+}
+
+// handleSecretPromptKey answers a pending secret prompt.
+func (m *Model) handleSecretPromptKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var answer string
+	switch msg.String() {
+	case "r", "R":
+		answer = security.SecretActionRedact
+	case "s", "S":
+		answer = security.SecretActionAllow
+	case "b", "B", "n", "N", "esc", "ctrl+c":
+		answer = security.SecretActionBlock
+	default:
+		return m, nil
+	}
+
+	m.secretPrompt.Reply <- answer
+	m.secretPrompt = nil
+	return m, nil
+}
+
+// handleSecretFindings records what was done with secrets in an outbound prompt.
+func (m *Model) handleSecretFindings(msg SecretFindingsMsg) (tea.Model, tea.Cmd) {
+	conv := m.chatModel.GetConversation()
+	conv.AddSystemMessage(msg.Result.Describe())
+	m.chatModel.SetConversation(conv)
+	return m, nil
+}
+
+// =============================================================================
+// PERSISTED PLANS
+// =============================================================================
+
+// InterruptedPlansMsg reports the plans left running or paused by an
+// earlier session, with the files changed since their steps completed.
+type InterruptedPlansMsg struct {
+	Plans   []*plan.Plan
+	Changes map[string][]plan.FileChange
+}
+
+// checkInterruptedPlans looks for plans an earlier session left unfinished.
+func (m *Model) checkInterruptedPlans() tea.Cmd {
+	if m.planStore == nil {
+		return nil
+	}
+	store := m.planStore
+	return func() tea.Msg {
+		plans, err := store.Interrupted()
+		if err != nil || len(plans) == 0 {
+			return nil
+		}
+		changes := make(map[string][]plan.FileChange, len(plans))
+		for _, p := range plans {
+			changes[p.ID] = p.ChangedFiles()
+		}
+		return InterruptedPlansMsg{Plans: plans, Changes: changes}
+	}
+}
+
+// handleInterruptedPlans offers to resume the plans an earlier session left
+// unfinished, warning about files changed since their steps completed.
+func (m *Model) handleInterruptedPlans(msg InterruptedPlansMsg) (tea.Model, tea.Cmd) {
+	var b strings.Builder
+	b.WriteString("Interrupted plans from an earlier session:\n")
+	for _, p := range msg.Plans {
+		fmt.Fprintf(&b, "  %s  %s steps  %s\n", shortPlanID(p.ID), p.Progress(), p.Description)
+		for _, change := range msg.Changes[p.ID] {
+			fmt.Fprintf(&b, "    Warning: %s was %s since %s completed\n", change.Path, change.Reason, change.StepID)
+		}
+	}
+	b.WriteString("Type /plan resume [id] to continue or /plan cancel [id] to discard.")
+	return m.handlePlanResult(b.String())
+}
+
+// loadPlanForCommand loads the plan named by /plan resume|cancel, or the
+// most recently interrupted plan when no ID is given.
+func (m *Model) loadPlanForCommand(id string) (*plan.Plan, error) {
+	if m.planStore == nil {
+		return nil, fmt.Errorf("plan storage is unavailable")
+	}
+	if id != "" {
+		return m.planStore.Load(id)
+	}
+	plans, err := m.planStore.Interrupted()
+	if err != nil {
+		return nil, err
+	}
+	if len(plans) == 0 {
+		return nil, fmt.Errorf("no interrupted plans")
+	}
+	return plans[0], nil
+}
+
+// handleResumePlan resumes a saved plan in the background. Progress arrives
+// as PlanProgressMsg and the outcome as PlanCompleteMsg.
+func (m *Model) handleResumePlan(msg commands.ResumePlanMsg) (tea.Model, tea.Cmd) {
+	if m.planExecutor != nil && !m.planExecutor.IsComplete() {
+		return m.handlePlanResult("A plan is already running. Use /plan cancel to stop it.")
+	}
+
+	p, err := m.loadPlanForCommand(msg.PlanID)
+	if err != nil {
+		return m.handlePlanResult("Cannot resume plan: " + err.Error())
+	}
+	p.PrepareResume()
+	if !p.CanExecute() {
+		return m.handlePlanResult(fmt.Sprintf("Plan %s cannot be resumed from status: %s", shortPlanID(p.ID), p.Status))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Resuming plan %s (%s steps): %s", shortPlanID(p.ID), p.Progress(), p.Description)
+	for _, change := range p.ChangedFiles() {
+		fmt.Fprintf(&b, "\n  Warning: %s was %s since %s completed", change.Path, change.Reason, change.StepID)
+	}
+	m.handlePlanResult(b.String())
+
+	executor := plan.NewPlanExecutor(p, m.toolExecutor)
+	executor.SetStore(m.planStore)
+	if m.config != nil && m.config.Plan.MaxReplans > 0 && m.ollamaClient != nil {
+		generator := plan.NewGenerator(plan.NewOllamaLLM(m.ollamaClient, m.modelName))
+		executor.SetReplanner(generator, m.config.Plan.MaxReplans, tuiRevisionApprover)
+	}
+	planID := p.ID
+	executor.SetProgressCallback(func(step, total int, status string) {
+		programMu.Lock()
+		prog := programRef
+		programMu.Unlock()
+		if prog != nil {
+			prog.Send(commands.PlanProgressMsg{PlanID: planID, CurrentStep: step, TotalSteps: total, Status: status})
+		}
+	})
+	m.planExecutor = executor
+
+	return m, func() tea.Msg {
+		err := executor.Execute(context.Background())
+		return commands.PlanCompleteMsg{PlanID: planID, Success: err == nil, Error: err}
+	}
+}
+
+// handleCancelPlan cancels the running plan, or a saved one so that it is
+// no longer offered for resume.
+func (m *Model) handleCancelPlan(msg commands.CancelPlanMsg) (tea.Model, tea.Cmd) {
+	// A plan awaiting approval of a revision has failed but is still running
+	if m.planExecutor != nil && (!m.planExecutor.IsComplete() || m.planRevision != nil) {
+		running := m.planExecutor.GetPlan().ID
+		if msg.PlanID == "" || strings.HasPrefix(running, msg.PlanID) {
+			// The pending revision is rejected; the executor saves the
+			// cancelled plan
+			if m.planRevision != nil {
+				m.planRevision.Reply <- false
+				m.planRevision = nil
+			}
+			m.planExecutor.Cancel()
+			return m, nil
+		}
+	}
+
+	p, err := m.loadPlanForCommand(msg.PlanID)
+	if err != nil {
+		return m.handlePlanResult("Cannot cancel plan: " + err.Error())
+	}
+	if p.IsComplete() {
+		return m.handlePlanResult(fmt.Sprintf("Plan %s already finished: %s", shortPlanID(p.ID), p.Status))
+	}
+	p.PrepareResume()
+	p.Cancel()
+	if err := m.planStore.Save(p); err != nil {
+		return m.handlePlanResult("Cannot cancel plan: " + err.Error())
+	}
+	return m.handlePlanResult(fmt.Sprintf("Plan %s cancelled.", shortPlanID(p.ID)))
+}
+
+// PlanRevisionMsg asks the user to approve a revision of a failed plan. The
+// plan executor waits on Reply.
+type PlanRevisionMsg struct {
+	Revision *plan.Revision
+	Reply    chan<- bool
+}
+
+// tuiRevisionApprover is the plan.ApproveRevisionFunc used by the TUI. It
+// runs on the plan's goroutine and blocks until the user answers.
+func tuiRevisionApprover(ctx context.Context, rev *plan.Revision) bool {
+	programMu.Lock()
+	p := programRef
+	programMu.Unlock()
+	if p == nil {
+		return false
+	}
+
+	reply := make(chan bool, 1)
+	p.Send(PlanRevisionMsg{Revision: rev, Reply: reply})
+
+	select {
+	case <-ctx.Done():
+		return false
+	case approved := <-reply:
+		return approved
+	}
+}
+
+// handlePlanRevision shows a proposed revision as a diff of the remaining
+// steps and waits for the user's decision.
+func (m *Model) handlePlanRevision(msg PlanRevisionMsg) (tea.Model, tea.Cmd) {
+	// Only one revision can be pending; a stale one is rejected
+	if m.planRevision != nil {
+		m.planRevision.Reply <- false
+	}
+	m.planRevision = &msg
+
+	rev := msg.Revision
+	var b strings.Builder
+	fmt.Fprintf(&b, "Revision %d after %s failed: %s\n", rev.Number, rev.FailedStep, rev.Reason)
+	b.WriteString(rev.Diff())
+	b.WriteString("Press [y] to continue with the revised steps, [n]/Esc to leave the plan failed.")
+	return m.handlePlanResult(b.String())
+}
+
+// handlePlanRevisionKey answers a pending plan revision.
+func (m *Model) handlePlanRevisionKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var approved bool
+	switch msg.String() {
+	case "y", "Y":
+		approved = true
+	case "n", "N", "esc", "ctrl+c":
+		approved = false
+	default:
+		return m, nil
+	}
+
+	m.planRevision.Reply <- approved
+	m.planRevision = nil
+	return m, nil
+}
+
+// handlePlanComplete reports the outcome of a resumed plan.
+func (m *Model) handlePlanComplete(msg commands.PlanCompleteMsg) (tea.Model, tea.Cmd) {
+	if m.planExecutor != nil && m.planExecutor.GetPlan().ID == msg.PlanID {
+		m.planExecutor = nil
+	}
+	if msg.Error != nil {
+		return m.handlePlanResult(fmt.Sprintf("Plan %s stopped: %v", shortPlanID(msg.PlanID), msg.Error))
+	}
+	return m, nil
+}
+
+// handlePlanResult shows plan activity in the conversation.
+func (m *Model) handlePlanResult(text string) (tea.Model, tea.Cmd) {
+	conv := m.chatModel.GetConversation()
+	conv.AddSystemMessage("Plan: " + text)
+	m.chatModel.SetConversation(conv)
+	return m, nil
+}
+
+// shortPlanID shortens a plan ID for display; /plan accepts the prefix.
+func shortPlanID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// =============================================================================
+// HUNK-LEVEL CHANGE REVIEW
+// =============================================================================
+
+// tuiHunkReviewer is the tools.HunkReviewFunc used by the TUI. It runs on the
+// tool goroutine and blocks until the user finishes the review; without a
+// program or on cancellation every change is rejected.
+func tuiHunkReviewer(ctx context.Context, changes []tools.PendingChange) []*diff.Review {
+	programMu.Lock()
+	p := programRef
+	programMu.Unlock()
+	if p == nil {
+		return nil
+	}
+
+	reply := make(chan []*diff.Review, 1)
+	p.Send(DiffReviewMsg{Changes: changes, Reply: reply})
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case reviews := <-reply:
+		return reviews
+	}
+}
+
+// handleDiffReview opens the review of pending changes.
+func (m *Model) handleDiffReview(msg DiffReviewMsg) (tea.Model, tea.Cmd) {
+	// Only one review can be pending; a stale one is rejected
+	if m.diffReview != nil {
+		m.diffReview.Reply <- nil
+	}
+
+	titles := make([]string, len(msg.Changes))
+	diffs := make([]*diff.Diff, len(msg.Changes))
+	for i, change := range msg.Changes {
+		titles[i] = change.Preview.ToolName + " " + change.Preview.FilePath
+		diffs[i] = change.Preview.Diff
+	}
+	m.diffReview = &msg
+	m.diffReviewer = components.NewHunkReviewer(titles, diffs)
+	m.diffReviewer.SetSize(m.width, m.height)
+	return m, nil
+}
+
+// handleDiffReviewKey forwards keys to the reviewer and sends the decisions
+// back to the tool goroutine once the user is done.
+func (m *Model) handleDiffReviewKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	cmd := m.diffReviewer.Update(msg)
+	if !m.diffReviewer.Done() {
+		return m, cmd
+	}
+
+	reviews := m.diffReviewer.Reviews()
+	applied, total := 0, 0
+	for _, review := range reviews {
+		applied += review.Applied()
+		total += len(review.Decisions)
+	}
+	m.diffReview.Reply <- reviews
+	m.diffReview = nil
+	m.diffReviewer = nil
+
+	conv := m.chatModel.GetConversation()
+	conv.AddSystemMessage(fmt.Sprintf("Change review: applying %d of %d hunk(s) across %d file(s)",
+		applied, total, len(reviews)))
+	m.chatModel.SetConversation(conv)
+	return m, cmd
+}
+
 // =============================================================================
 // AGENTIC TOOL LOOP
 // =============================================================================
@@ -1582,6 +2438,7 @@ func (m *Model) resetAgenticState() {
 func (m *Model) executeToolsAsync(parentCtx context.Context, messageID string, toolCalls []ollama.ToolCall, messages []ollama.Message, assistantText string) tea.Cmd {
 	// Capture toolExecutor before closure to avoid race conditions
 	toolExecutor := m.toolExecutor
+	allowedTools := m.allowedTools
 
 	return func() tea.Msg {
 		if toolExecutor == nil {
@@ -1591,9 +2448,21 @@ func (m *Model) executeToolsAsync(parentCtx context.Context, messageID string, t
 			}
 		}
 
+		// Convert to our internal tool call format, with paths resolved
+		// as they will run so that diff previews show the right files
+		calls := make([]tools.ToolCall, len(toolCalls))
+		for i, tc := range toolCalls {
+			calls[i] = toolExecutor.ResolveCall(tools.ToolCall{
+				Name:   tc.Function.Name,
+				Params: tc.Function.Arguments,
+			})
+		}
+
 		// Execute each tool call
 		var results []ToolResultEntry
-		for _, tc := range toolCalls {
+		reviews := make(map[int]*diff.Review)
+		reviewed := make(map[int]bool)
+		for i, tc := range toolCalls {
 			// Check if context was cancelled before starting next tool
 			if parentCtx.Err() != nil {
 				return StreamErrorMsg{
@@ -1601,17 +2470,42 @@ func (m *Model) executeToolsAsync(parentCtx context.Context, messageID string, t
 					Error:     fmt.Errorf("tool execution cancelled"),
 				}
 			}
+			call := calls[i]
+
+			// A custom command's allowed-tools list also binds tool calls
+			// the model makes up
+			if !toolAllowed(allowedTools, call.Name) {
+				results = append(results, ToolResultEntry{
+					ToolName: tc.Function.Name,
+					Result:   fmt.Sprintf("tool %s is not allowed by this command (allowed: %s)", call.Name, strings.Join(allowedTools, ", ")),
+					Success:  false,
+				})
+				continue
+			}
 
-			// Convert to our internal tool call format
-			call := tools.ToolCall{
-				Name:   tc.Function.Name,
-				Params: tc.Function.Arguments,
+			// Edit and Write calls are reviewed hunk by hunk before they
+			// run, together with the other file changes of the turn
+			if !reviewed[i] {
+				if changes := tools.PendingChanges(calls, i); changes != nil {
+					decided := tuiHunkReviewer(parentCtx, changes)
+					for n, change := range changes {
+						reviewed[change.Index] = true
+						if n < len(decided) {
+							reviews[change.Index] = decided[n]
+						}
+					}
+				}
 			}
 
 			// Execute with a timeout context derived from the parent context
 			// This ensures cancellation propagates from user's Ctrl+C
 			ctx, cancel := context.WithTimeout(parentCtx, 2*time.Minute)
-			result := toolExecutor.Execute(ctx, call)
+			var result tools.Result
+			if reviewed[i] {
+				result = toolExecutor.ExecuteReviewed(ctx, call, reviews[i])
+			} else {
+				result = toolExecutor.Execute(ctx, call)
+			}
 			cancel()
 
 			// Collect result
@@ -1656,6 +2550,20 @@ func (m *Model) executeToolsAsync(parentCtx context.Context, messageID string, t
 	}
 }
 
+// toolAllowed reports whether a custom command's allowed-tools list permits
+// the named tool. An empty list allows every tool.
+func toolAllowed(allowed []string, name string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, a := range allowed {
+		if strings.EqualFold(a, name) {
+			return true
+		}
+	}
+	return false
+}
+
 // handleToolExecutionComplete processes completed tool executions and continues the agentic loop.
 func (m *Model) handleToolExecutionComplete(msg ToolExecutionCompleteMsg) (tea.Model, tea.Cmd) {
 	if msg.MessageID != m.streamingMsgID {
@@ -1703,9 +2611,24 @@ func (m *Model) handleToolExecutionComplete(msg ToolExecutionCompleteMsg) (tea.M
 	for _, r := range msg.ToolResults {
 		// Use the model package's NewToolMessage for proper display
 		toolMsg := model.NewToolMessage(r.ToolName, r.Result, r.Success)
+		// Tool output (file reads, command output) can introduce classified
+		// content, so it is marked like any other message
+		toolMsg.Classification = m.chatModel.ClassifyContent(r.Result).String()
 		m.chatModel.GetConversation().Messages = append(m.chatModel.GetConversation().Messages, toolMsg)
 	}
 
+	// Files the tools touched can bring nested RIGRUN.md files into scope;
+	// refresh the system prompt carried in the continuation messages
+	promptChanged := false
+	for _, tc := range m.pendingToolCalls {
+		if m.chatModel.NoteToolCall(tc.Function.Arguments) {
+			promptChanged = true
+		}
+	}
+	if promptChanged && len(msg.Messages) > 0 && msg.Messages[0].Role == "system" {
+		msg.Messages[0].Content = m.chatModel.TurnSystemPrompt()
+	}
+
 	// Clear pending state
 	m.pendingToolCalls = nil
 
@@ -1714,6 +2637,7 @@ func (m *Model) handleToolExecutionComplete(msg ToolExecutionCompleteMsg) (tea.M
 
 	// Add a new assistant message for the continuation response
 	assistantMsg := m.chatModel.GetConversation().AddAssistantMessage()
+	assistantMsg.Classification = m.chatModel.EffectiveClassification().String()
 
 	// Continue the conversation - call LLM again with updated messages including tool results
 	// This creates a new StreamRequestMsg which will flow through startStreaming again
@@ -1805,6 +2729,12 @@ func (m *Model) handleSaveComplete(msg commands.SaveCompleteMsg) (tea.Model, tea
 
 // handleLoadConversation loads a conversation from storage.
 func (m *Model) handleLoadConversation(msg commands.LoadConversationMsg) (tea.Model, tea.Cmd) {
+	return m.loadConversation(msg.ID, "", "")
+}
+
+// loadConversation loads a conversation by ID or list number. A session
+// search result also passes the message to focus and the search query.
+func (m *Model) loadConversation(id, focusMessageID, query string) (tea.Model, tea.Cmd) {
 	if m.convStore == nil {
 		m.chatModel.GetConversation().AddSystemMessage("Error: Session storage not available")
 		m.chatModel.SetConversation(m.chatModel.GetConversation())
@@ -1819,12 +2749,12 @@ func (m *Model) handleLoadConversation(msg commands.LoadConversationMsg) (tea.Mo
 		var err error
 
 		// Check if ID is a number (index) or an actual ID
-		if idx, parseErr := strconv.Atoi(msg.ID); parseErr == nil {
+		if idx, parseErr := strconv.Atoi(id); parseErr == nil {
 			// Load by index (1-based for user friendliness)
 			storedConv, err = convStore.LoadByIndex(idx - 1)
 		} else {
 			// Load by ID
-			storedConv, err = convStore.Load(msg.ID)
+			storedConv, err = convStore.Load(id)
 		}
 
 		if err != nil {
@@ -1832,7 +2762,9 @@ func (m *Model) handleLoadConversation(msg commands.LoadConversationMsg) (tea.Mo
 		}
 
 		return SessionLoadedMsg{
-			Conversation: storedConv,
+			Conversation:   storedConv,
+			FocusMessageID: focusMessageID,
+			Query:          query,
 		}
 	}
 }
@@ -1840,6 +2772,10 @@ func (m *Model) handleLoadConversation(msg commands.LoadConversationMsg) (tea.Mo
 // SessionLoadedMsg contains the loaded conversation data.
 type SessionLoadedMsg struct {
 	Conversation *storage.StoredConversation
+
+	// FocusMessageID and Query come from a session search result
+	FocusMessageID string
+	Query          string
 }
 
 // handleLoadComplete processes load completion.
@@ -1882,14 +2818,16 @@ type SessionListMsg struct {
 
 // convertToStoredConversation converts a model.Conversation to storage.StoredConversation.
 func convertToStoredConversation(conv *model.Conversation, modelName string, stats *router.SessionStats) *storage.StoredConversation {
-	messages := conv.GetHistory()
 	stored := &storage.StoredConversation{
 		ID:        conv.ID,
 		Summary:   conv.GetTitle(),
 		Model:     modelName,
 		CreatedAt: conv.CreatedAt,
 		UpdatedAt: conv.UpdatedAt,
-		Messages:  make([]storage.StoredMessage, 0, len(messages)),
+		Messages:  convertToStoredMessages(conv.GetHistory()),
+		Branches:  convertToStoredMessages(conv.Branches),
+
+		Classification: conv.Classification,
 	}
 
 	// Track routing cost from session stats
@@ -1898,17 +2836,28 @@ func convertToStoredConversation(conv *model.Conversation, modelName string, sta
 		stored.TokensUsed = snapshot.TotalQueries * 500 // Rough estimate
 	}
 
-	// Convert messages
+	return stored
+}
+
+// convertToStoredMessages converts model messages to stored messages.
+func convertToStoredMessages(messages []*model.Message) []storage.StoredMessage {
+	if messages == nil {
+		return nil
+	}
+	stored := make([]storage.StoredMessage, 0, len(messages))
 	for _, msg := range messages {
 		storedMsg := storage.StoredMessage{
 			ID:           msg.ID,
 			Role:         string(msg.Role),
 			Content:      msg.GetDisplayContent(),
 			Timestamp:    msg.Timestamp,
+			ParentID:     msg.ParentID,
 			TokenCount:   msg.TokenCount,
 			DurationMs:   msg.TotalDuration.Milliseconds(),
 			TokensPerSec: msg.TokensPerSec,
 			TTFTMs:       msg.TTFT.Milliseconds(),
+
+			Classification: msg.Classification,
 		}
 
 		// Include tool info if present
@@ -1919,9 +2868,8 @@ func convertToStoredConversation(conv *model.Conversation, modelName string, sta
 			storedMsg.IsSuccess = msg.IsSuccess
 		}
 
-		stored.Messages = append(stored.Messages, storedMsg)
+		stored = append(stored, storedMsg)
 	}
-
 	return stored
 }
 
@@ -1933,9 +2881,18 @@ func convertFromStoredConversation(stored *storage.StoredConversation) *model.Co
 	conv.Model = stored.Model
 	conv.CreatedAt = stored.CreatedAt
 	conv.UpdatedAt = stored.UpdatedAt
+	conv.Classification = stored.Classification
+	conv.Messages = append(conv.Messages, convertFromStoredMessages(stored.Messages)...)
+	conv.Branches = convertFromStoredMessages(stored.Branches)
 
-	// Convert messages
-	for _, storedMsg := range stored.Messages {
+	return conv
+}
+
+// convertFromStoredMessages converts stored messages to model messages,
+// skipping unknown roles.
+func convertFromStoredMessages(stored []storage.StoredMessage) []*model.Message {
+	var messages []*model.Message
+	for _, storedMsg := range stored {
 		var msg *model.Message
 
 		switch storedMsg.Role {
@@ -1957,10 +2914,11 @@ func convertFromStoredConversation(stored *storage.StoredConversation) *model.Co
 
 		msg.ID = storedMsg.ID
 		msg.Timestamp = storedMsg.Timestamp
-		conv.Messages = append(conv.Messages, msg)
+		msg.ParentID = storedMsg.ParentID
+		msg.Classification = storedMsg.Classification
+		messages = append(messages, msg)
 	}
-
-	return conv
+	return messages
 }
 
 // handleSessionLoaded processes a successfully loaded session.
@@ -1971,6 +2929,20 @@ func (m *Model) handleSessionLoaded(msg SessionLoadedMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	// AC-4: Refuse to load a conversation marked above the session level.
+	// Loading it would spill higher-classified content into this session.
+	sessionClass := m.chatModel.GetSessionClassification()
+	if err := security.CheckSessionClearance(msg.Conversation.HighWaterMark(), sessionClass); err != nil {
+		security.AuditLogEvent(m.sessionMgr.SessionID(), "CLASSIFIED_LOAD_REFUSED", map[string]string{
+			"conversation_id": msg.Conversation.ID,
+			"conversation":    msg.Conversation.HighWaterMark().String(),
+			"session":         sessionClass.String(),
+		})
+		m.chatModel.GetConversation().AddSystemMessage("Refused to load session: " + err.Error())
+		m.chatModel.SetConversation(m.chatModel.GetConversation())
+		return m, nil
+	}
+
 	// Convert stored conversation to model conversation
 	conv := convertFromStoredConversation(msg.Conversation)
 
@@ -1989,6 +2961,11 @@ func (m *Model) handleSessionLoaded(msg SessionLoadedMsg) (tea.Model, tea.Cmd) {
 	m.chatModel.GetConversation().AddSystemMessage("Loaded session: " + msg.Conversation.Summary)
 	m.chatModel.SetConversation(m.chatModel.GetConversation())
 
+	// Jump to the session search result that was opened
+	if msg.FocusMessageID != "" {
+		m.chatModel.FocusMessage(msg.FocusMessageID, msg.Query)
+	}
+
 	return m, nil
 }
 